@@ -0,0 +1,280 @@
+// bench/bench.go
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+	"time"
+
+	"tig/internal/diff"
+	"tig/internal/safe"
+	"tig/internal/workspace"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Case is a single benchmark run against a generated tree. Run stops the
+// benchmark and returns an error on failure.
+type Case struct {
+	Name string
+	Run  func(b *testing.B, root string) error
+}
+
+// Cases are the standard benchmarks
+var Cases = []Case{
+	{Name: "status", Run: benchStatus},
+	{Name: "gate-all", Run: benchGateAll},
+	{Name: "diff", Run: benchDiff},
+	{Name: "safe-store", Run: benchSafeStore},
+	{Name: "safe-get", Run: benchSafeGet},
+}
+
+// Result is the outcome of one benchmark
+type Result struct {
+	Name        string  `json:"name"`
+	Iterations  int     `json:"iterations"`
+	NsPerOp     int64   `json:"ns_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	MBPerSec    float64 `json:"mb_per_sec,omitempty"`
+}
+
+// Report is a full benchmark run, comparable against a baseline
+type Report struct {
+	Fixture   Fixture   `json:"fixture"`
+	GoVersion string    `json:"go_version"`
+	Platform  string    `json:"platform"`
+	CPUs      int       `json:"cpus"`
+	CreatedAt time.Time `json:"created_at"`
+	Results   []Result  `json:"results"`
+}
+
+// Options configures Run
+type Options struct {
+	Dir    string         // where the fixture is generated; a temp dir if empty
+	Filter *regexp.Regexp // benchmarks to run; all if nil
+	// Progress is called before each benchmark starts
+	Progress func(name string)
+}
+
+// Run generates the fixture and runs the matching benchmarks against it
+func Run(f Fixture, opts Options) (*Report, error) {
+	root := opts.Dir
+	if root == "" {
+		dir, err := os.MkdirTemp("", "tig-bench-")
+		if err != nil {
+			return nil, fmt.Errorf("creating fixture directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		root = dir
+	}
+	if err := f.Generate(root); err != nil {
+		return nil, fmt.Errorf("generating fixture: %w", err)
+	}
+
+	report := &Report{
+		Fixture:   f,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	for _, c := range Cases {
+		if opts.Filter != nil && !opts.Filter.MatchString(c.Name) {
+			continue
+		}
+		if opts.Progress != nil {
+			opts.Progress(c.Name)
+		}
+
+		var failure error
+		r := testing.Benchmark(func(b *testing.B) {
+			if err := c.Run(b, root); err != nil {
+				failure = err
+				b.FailNow()
+			}
+		})
+		if failure != nil {
+			return nil, fmt.Errorf("benchmark %s: %w", c.Name, failure)
+		}
+
+		result := Result{
+			Name:        c.Name,
+			Iterations:  r.N,
+			NsPerOp:     r.NsPerOp(),
+			BytesPerOp:  r.AllocedBytesPerOp(),
+			AllocsPerOp: r.AllocsPerOp(),
+		}
+		if r.Bytes > 0 && r.T > 0 {
+			result.MBPerSec = float64(r.Bytes) * float64(r.N) / 1e6 / r.T.Seconds()
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// openStore creates a throwaway in-memory database and a safe under dir.
+// Callers close the safe before the database, so its last access-time
+// flush lands in an open database.
+func openStore(dir string, cacheSize int) (*badger.DB, *safe.Safe, error) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening database: %w", err)
+	}
+	s, err := safe.New(db, safe.Options{Root: dir, CacheSize: cacheSize})
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("creating safe: %w", err)
+	}
+	return db, s, nil
+}
+
+// benchStatus scans the working tree of a fresh repository
+func benchStatus(b *testing.B, root string) error {
+	db, s, err := openStore(b.TempDir(), 1000)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer s.Close()
+	ws, err := workspace.NewLocalWorkspace(root, db, s)
+	if err != nil {
+		return err
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ws.Status(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// benchGateAll gates the whole tree into an empty safe
+func benchGateAll(b *testing.B, root string) error {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		dir := filepath.Join(b.TempDir(), "content")
+		db, s, err := openStore(dir, 1000)
+		if err != nil {
+			return err
+		}
+		ws, err := workspace.NewLocalWorkspace(root, db, s)
+		if err != nil {
+			s.Close()
+			db.Close()
+			return err
+		}
+		b.StartTimer()
+
+		err = ws.Gate([]string{"."})
+
+		b.StopTimer()
+		s.Close()
+		db.Close()
+		os.RemoveAll(dir)
+		if err != nil {
+			return err
+		}
+		b.StartTimer()
+	}
+	return nil
+}
+
+// benchDiff diffs a large source file against an edited copy
+func benchDiff(b *testing.B, root string) error {
+	rng := rand.New(rand.NewSource(2))
+	old := SourceFile(rng, 0, 64*1024)
+
+	// Edit roughly one line in ten
+	lines := bytes.Split(old, []byte("\n"))
+	for i := range lines {
+		if rng.Intn(10) == 0 {
+			lines[i] = append([]byte("\t// edited\n"), lines[i]...)
+		}
+	}
+	edited := bytes.Join(lines, []byte("\n"))
+
+	engine := diff.NewEngine(3)
+	b.SetBytes(int64(len(old) + len(edited)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.Diff(old, edited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blobSize is the size of content used by the safe benchmarks
+const blobSize = 64 * 1024
+
+// benchSafeStore stores distinct blobs
+func benchSafeStore(b *testing.B, root string) error {
+	db, s, err := openStore(b.TempDir(), 1000)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer s.Close()
+
+	blob := make([]byte, blobSize)
+	rand.New(rand.NewSource(3)).Read(blob)
+
+	b.SetBytes(blobSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Vary the content so every store writes a new object
+		blob[0], blob[1], blob[2], blob[3] = byte(i), byte(i>>8), byte(i>>16), byte(i>>24)
+		if _, err := s.Store(blob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// benchSafeGet reads blobs from disk, missing the cache
+func benchSafeGet(b *testing.B, root string) error {
+	db, s, err := openStore(b.TempDir(), 1)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer s.Close()
+
+	rng := rand.New(rand.NewSource(4))
+	hashes := make([]string, 256)
+	for i := range hashes {
+		blob := make([]byte, blobSize)
+		rng.Read(blob)
+		hash, err := s.Store(blob)
+		if err != nil {
+			return err
+		}
+		hashes[i] = hash
+	}
+
+	b.SetBytes(blobSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Get(hashes[i%len(hashes)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
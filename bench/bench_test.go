@@ -0,0 +1,74 @@
+// bench/bench_test.go
+package bench
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tiny keeps go test runs fast; tig bench uses the larger fixtures
+var tiny = Fixture{Name: "tiny", Files: 200, FileSize: 1024, Binaries: 1, BinarySize: 256 << 10}
+
+func benchmark(b *testing.B, name string) {
+	root := b.TempDir()
+	if err := tiny.Generate(root); err != nil {
+		b.Fatal(err)
+	}
+	for _, c := range Cases {
+		if c.Name == name {
+			if err := c.Run(b, root); err != nil {
+				b.Fatal(err)
+			}
+			return
+		}
+	}
+	b.Fatalf("no benchmark %s", name)
+}
+
+func BenchmarkStatus(b *testing.B)    { benchmark(b, "status") }
+func BenchmarkGateAll(b *testing.B)   { benchmark(b, "gate-all") }
+func BenchmarkDiff(b *testing.B)      { benchmark(b, "diff") }
+func BenchmarkSafeStore(b *testing.B) { benchmark(b, "safe-store") }
+func BenchmarkSafeGet(b *testing.B)   { benchmark(b, "safe-get") }
+
+func TestRunAndCompare(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs benchmarks")
+	}
+
+	report, err := Run(tiny, Options{Filter: regexp.MustCompile("^diff$")})
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, "diff", report.Results[0].Name)
+	assert.Positive(t, report.Results[0].NsPerOp)
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, report.Save(path))
+	baseline, err := LoadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, tiny, baseline.Fixture)
+
+	// Twice as slow as the baseline is a regression
+	slower := *report
+	slower.Results = []Result{report.Results[0]}
+	slower.Results[0].NsPerOp *= 2
+	deltas := Compare(baseline, &slower, DefaultThreshold)
+	require.Len(t, deltas, 1)
+	assert.InDelta(t, 1.0, deltas[0].Change, 0.01)
+	assert.Len(t, Regressions(deltas), 1)
+
+	assert.Empty(t, Regressions(Compare(baseline, report, DefaultThreshold)))
+}
+
+func TestLookup(t *testing.T) {
+	f, err := Lookup("10k")
+	require.NoError(t, err)
+	assert.Equal(t, 10000, f.Files)
+
+	_, err = Lookup("1m")
+	assert.ErrorContains(t, err, "small, 10k, 100k")
+}
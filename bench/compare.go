@@ -0,0 +1,84 @@
+// bench/compare.go
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultThreshold is the slowdown treated as a regression
+const DefaultThreshold = 0.10
+
+// Delta compares one benchmark against its baseline
+type Delta struct {
+	Name      string  `json:"name"`
+	Baseline  int64   `json:"baseline_ns_per_op"`
+	Current   int64   `json:"current_ns_per_op"`
+	Change    float64 `json:"change"` // relative change in time per op; positive is slower
+	Regressed bool    `json:"regressed"`
+}
+
+// Compare matches results by name against a baseline. A benchmark
+// regressed when it is slower by more than threshold, e.g. 0.1 for 10%.
+// Benchmarks missing from either report are skipped.
+func Compare(baseline, current *Report, threshold float64) []Delta {
+	base := make(map[string]Result, len(baseline.Results))
+	for _, r := range baseline.Results {
+		base[r.Name] = r
+	}
+
+	var deltas []Delta
+	for _, r := range current.Results {
+		b, ok := base[r.Name]
+		if !ok || b.NsPerOp == 0 {
+			continue
+		}
+		change := float64(r.NsPerOp-b.NsPerOp) / float64(b.NsPerOp)
+		deltas = append(deltas, Delta{
+			Name:      r.Name,
+			Baseline:  b.NsPerOp,
+			Current:   r.NsPerOp,
+			Change:    change,
+			Regressed: change > threshold,
+		})
+	}
+	return deltas
+}
+
+// Regressions returns the deltas that regressed
+func Regressions(deltas []Delta) []Delta {
+	var regressed []Delta
+	for _, d := range deltas {
+		if d.Regressed {
+			regressed = append(regressed, d)
+		}
+	}
+	return regressed
+}
+
+// LoadReport reads a report saved with Save
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline: %w", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// Save writes the report as JSON
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling report: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating report directory: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
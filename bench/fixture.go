@@ -0,0 +1,102 @@
+// bench/fixture.go
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Fixture describes a generated repository tree
+type Fixture struct {
+	Name       string `json:"name"`
+	Files      int    `json:"files"`       // source files
+	FileSize   int    `json:"file_size"`   // approximate bytes per source file
+	Binaries   int    `json:"binaries"`    // incompressible binary files
+	BinarySize int    `json:"binary_size"` // bytes per binary file
+}
+
+// Fixtures are the standard trees, from quick smoke runs to large repos
+var Fixtures = []Fixture{
+	{Name: "small", Files: 1000, FileSize: 2048, Binaries: 2, BinarySize: 1 << 20},
+	{Name: "10k", Files: 10000, FileSize: 4096, Binaries: 4, BinarySize: 16 << 20},
+	{Name: "100k", Files: 100000, FileSize: 4096, Binaries: 8, BinarySize: 64 << 20},
+}
+
+// filesPerDir keeps directories at a realistic size
+const filesPerDir = 100
+
+// Lookup returns the standard fixture with the given name
+func Lookup(name string) (Fixture, error) {
+	var names []string
+	for _, f := range Fixtures {
+		if f.Name == name {
+			return f, nil
+		}
+		names = append(names, f.Name)
+	}
+	return Fixture{}, fmt.Errorf("unknown fixture %q (want one of %s)", name, strings.Join(names, ", "))
+}
+
+// Size returns the total bytes the fixture writes
+func (f Fixture) Size() int64 {
+	return int64(f.Files)*int64(f.FileSize) + int64(f.Binaries)*int64(f.BinarySize)
+}
+
+// Generate writes the fixture into dir. Output is deterministic, so runs
+// on different machines benchmark the same tree.
+func (f Fixture) Generate(dir string) error {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < f.Files; i++ {
+		path := filepath.Join(dir, "src", fmt.Sprintf("pkg%04d", i/filesPerDir), fmt.Sprintf("file%06d.go", i))
+		if err := writeFile(path, SourceFile(rng, i, f.FileSize)); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < f.Binaries; i++ {
+		data := make([]byte, f.BinarySize)
+		rng.Read(data)
+		path := filepath.Join(dir, "assets", fmt.Sprintf("blob%02d.bin", i))
+		if err := writeFile(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+var identifiers = []string{
+	"ctx", "err", "req", "resp", "cfg", "data", "path", "name", "count",
+	"result", "items", "store", "cache", "logger", "index", "buf", "key",
+}
+
+// SourceFile generates about size bytes of Go-like source. Files share
+// most of their vocabulary, like the files of a real project.
+func SourceFile(rng *rand.Rand, n, size int) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package pkg%04d\n\nimport (\n\t\"fmt\"\n\t\"strings\"\n)\n\n", n/filesPerDir)
+	for fn := 0; b.Len() < size; fn++ {
+		fmt.Fprintf(&b, "// Handle%d processes %s for file %d\n", fn, identifiers[rng.Intn(len(identifiers))], n)
+		fmt.Fprintf(&b, "func Handle%d(%s string) (string, error) {\n", fn, identifiers[rng.Intn(len(identifiers))])
+		for line := 0; line < 3+rng.Intn(6); line++ {
+			a := identifiers[rng.Intn(len(identifiers))]
+			c := identifiers[rng.Intn(len(identifiers))]
+			fmt.Fprintf(&b, "\tif %s := strings.TrimSpace(%s); %s == \"\" {\n\t\treturn \"\", fmt.Errorf(\"missing %s: %%d\", %d)\n\t}\n", a, c, a, c, rng.Intn(1000))
+		}
+		b.WriteString("\treturn \"ok\", nil\n}\n\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,118 @@
+// client/cache.go
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// DefaultCacheSize is how many GET responses a client keeps for
+// revalidation
+const DefaultCacheSize = 256
+
+// cachedResponse is a response kept with the ETag it was served with
+type cachedResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// cachingTransport revalidates GET requests with If-None-Match against
+// the responses it kept, answering from them when the server replies 304
+// Not Modified
+type cachingTransport struct {
+	next    http.RoundTripper
+	entries *lru.Cache[string, cachedResponse]
+
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+func newCachingTransport(next http.RoundTripper, size int) *cachingTransport {
+	entries, _ := lru.New[string, cachedResponse](size)
+	return &cachingTransport{next: next, entries: entries}
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+		return t.next.RoundTrip(req)
+	}
+	key := req.URL.String()
+	cached, ok := t.entries.Get(key)
+	if ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case ok && resp.StatusCode == http.StatusNotModified:
+		resp.Body.Close()
+		t.count(true)
+		header := cached.header.Clone()
+		for k, v := range resp.Header {
+			header[k] = v // The server's current headers win
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(cached.body)),
+			ContentLength: int64(len(cached.body)),
+			Request:       req,
+		}, nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		t.count(false)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		t.entries.Add(key, cachedResponse{etag: resp.Header.Get("ETag"), header: resp.Header.Clone(), body: body})
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+	t.count(false)
+	if ok {
+		t.entries.Remove(key)
+	}
+	return resp, nil
+}
+
+func (t *cachingTransport) count(hit bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if hit {
+		t.hits++
+	} else {
+		t.misses++
+	}
+}
+
+// CacheStats counts GET responses answered from the cache after the
+// server confirmed them unchanged (hits) and ones fetched in full
+type CacheStats struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// CacheStats returns the client's response cache counts
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	return CacheStats{Hits: c.cache.hits, Misses: c.cache.misses}
+}
@@ -5,7 +5,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"tig/internal/intent"
@@ -15,15 +17,31 @@ import (
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	cache      *cachingTransport // Revalidates GETs by ETag
 }
 
+// New creates a client of the server at baseURL. GET responses are
+// cached and revalidated with their ETags, so polling an unchanged
+// resource costs the server a 304 rather than the whole body.
 func New(baseURL string) *Client {
-	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: time.Second * 10,
-		},
+	c := &Client{baseURL: baseURL}
+	return c.WithCacheSize(DefaultCacheSize)
+}
+
+// WithCacheSize sets how many GET responses the client keeps for
+// revalidation; 0 turns the cache off
+func (c *Client) WithCacheSize(n int) *Client {
+	var transport http.RoundTripper = http.DefaultTransport
+	c.cache = nil
+	if n > 0 {
+		c.cache = newCachingTransport(transport, n)
+		transport = c.cache
 	}
+	c.httpClient = &http.Client{
+		Timeout:   time.Second * 10,
+		Transport: transport,
+	}
+	return c
 }
 
 // Intent operations
@@ -52,6 +70,11 @@ func (c *Client) CreateIntent(description, intentType string) (*intent.Intent, e
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusForbidden {
+		// Rejected by a server-side hook, whose message is the body
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("intent rejected: %s", strings.TrimSpace(string(msg)))
+	}
 	if resp.StatusCode != http.StatusCreated {
 		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
 	}
@@ -0,0 +1,145 @@
+// client/push.go
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"tig/internal/change"
+	"tig/internal/remote"
+)
+
+// PushProgress reports how far a push has got. Byte counts are of
+// uncompressed content; TotalBytes comes from the changeset's recorded
+// file sizes.
+type PushProgress struct {
+	Blobs      int   `json:"blobs"`
+	TotalBlobs int   `json:"total_blobs"`
+	Bytes      int64 `json:"bytes"`
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+// Push sends a changeset and whichever of its content the server lacks
+// in one gzip-compressed, chunked request, so large changesets stream
+// without being held in memory. src supplies content by hash; progress,
+// if not nil, is called as each blob is sent. Pushing a changeset the
+// server already has is harmless.
+func (c *Client) Push(ctx context.Context, cs *change.ChangeSet, src remote.BlobSource, progress func(PushProgress)) (*remote.PushResult, error) {
+	meta, err := json.Marshal(cs)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]int64)
+	var hashes []string
+	for _, ch := range cs.Changes {
+		if ch.NewHash == "" || ch.Type == "delete" {
+			continue
+		}
+		if _, seen := sizes[ch.NewHash]; !seen {
+			hashes = append(hashes, ch.NewHash)
+		}
+		sizes[ch.NewHash] = ch.Size
+	}
+	missing, err := c.missing(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	p := PushProgress{TotalBlobs: len(missing)}
+	for _, hash := range missing {
+		p.TotalBytes += sizes[hash]
+	}
+	if progress != nil {
+		progress(p)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		err := func() error {
+			if err := remote.WritePushHeader(zw, meta); err != nil {
+				return err
+			}
+			bw := remote.NewBlobWriter(zw)
+			for _, hash := range missing {
+				data, err := src(hash)
+				if err != nil {
+					return fmt.Errorf("reading %s: %w", hash, err)
+				}
+				if err := bw.WriteBlob(hash, data); err != nil {
+					return err
+				}
+				p.Blobs++
+				p.Bytes += int64(len(data))
+				if progress != nil {
+					progress(p)
+				}
+			}
+			return zw.Close()
+		}()
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/transfer/push", pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	// Large pushes outlast the client's request timeout; ctx bounds them
+	resp, err := (&http.Client{Transport: c.httpClient.Transport}).Do(req)
+	pr.Close()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("pushing changeset %s: %s: %s", cs.ID, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var res remote.PushResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("decoding push result: %w", err)
+	}
+	return &res, nil
+}
+
+// missing returns which of hashes the server lacks
+func (c *Client) missing(ctx context.Context, hashes []string) ([]string, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	body, err := json.Marshal(remote.Manifest{Hashes: hashes})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/transfer/manifest", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var set remote.MissingSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decoding missing set: %w", err)
+	}
+	return set.Missing, nil
+}
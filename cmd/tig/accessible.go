@@ -0,0 +1,49 @@
+// cmd/tig/accessible.go
+package main
+
+import (
+	"tig/internal/config"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// accessible is set when output must not rely on color: markers are
+// spelled out and color and syntax highlighting are turned off
+var accessible bool
+
+func init() {
+	rootCmd.PersistentFlags().Bool("accessible", false,
+		`Spell out markers instead of relying on color (default from `+config.AccessibleEnv+` or "accessible" in the user config)`)
+
+	cobra.OnInitialize(func() {
+		if f := rootCmd.PersistentFlags().Lookup("accessible"); f != nil && f.Changed {
+			accessible = f.Value.String() == "true"
+		} else {
+			accessible = config.Accessible()
+		}
+		if accessible {
+			color.NoColor = true
+		}
+	})
+}
+
+// Labels for status entries and diff lines in accessible mode, padded to
+// a common width so paths and code stay aligned
+const (
+	labelGated     = "GATED:     "
+	labelModified  = "MODIFIED:  "
+	labelUntracked = "UNTRACKED: "
+	labelDeleted   = "DELETED:   "
+	labelAdded     = "ADDED:     "
+	labelRemoved   = "REMOVED:   "
+	labelContext   = "           "
+)
+
+// statusMarker returns the marker printed before a path in tig status
+func statusMarker(symbol, label string, paint func(a ...interface{}) string) string {
+	if accessible {
+		return label
+	}
+	return paint(symbol)
+}
@@ -0,0 +1,257 @@
+// cmd/tig/admin.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"tig/internal/config"
+	"tig/internal/health"
+	"tig/internal/parcel"
+	"tig/internal/remote"
+	"tig/internal/safe"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var adminCmd = &cobra.Command{
+		Use:   "admin",
+		Short: "Repository maintenance commands",
+	}
+
+	var retrainCmd = &cobra.Command{
+		Use:   "retrain-dictionary",
+		Short: "Train a compression dictionary from repository content",
+		Long: `Sample stored content, train a zstd dictionary from it and use the
+dictionary to compress new content.
+
+Dictionaries help most in repositories with many small, similar files.
+Previous dictionaries are kept so existing content stays readable. Only
+content stored after retraining is compressed with the new dictionary,
+and only when compression.level is set in .tig/config.json.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			size, _ := cmd.Flags().GetInt("size")
+			limit, _ := cmd.Flags().GetInt("samples")
+			maxSample, _ := cmd.Flags().GetInt("max-sample-size")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			cfg, err := config.LoadRepo(p.Root)
+			if err != nil {
+				return err
+			}
+
+			samples, err := p.Safe.Sample(limit, maxSample)
+			if err != nil {
+				return err
+			}
+
+			dir := parcel.DictionaryDir(p.Root)
+			dicts, err := safe.LoadDictionaries(dir)
+			if err != nil {
+				return err
+			}
+
+			d, err := safe.TrainDictionary(samples, size, safe.NextDictionaryID(dicts))
+			if err != nil {
+				return err
+			}
+			id, err := safe.SaveDictionary(dir, d)
+			if err != nil {
+				return err
+			}
+
+			cfg.Compression.Dictionary = id
+			if err := config.SaveRepo(p.Root, cfg); err != nil {
+				return err
+			}
+
+			// Report the gain on the samples at the configured level
+			opts, err := parcel.CompressionOptions(p.Root, cfg.Compression)
+			if err != nil {
+				return err
+			}
+			if opts.Level == 0 {
+				opts.Level = 3
+			}
+			opts.MinSize = 0
+			before, withDict, err := safe.MeasureCompression(samples, *opts)
+			if err != nil {
+				return err
+			}
+			opts.Dictionary = nil
+			_, withoutDict, err := safe.MeasureCompression(samples, *opts)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Trained dictionary %d (%d bytes) from %d samples\n", id, len(d), len(samples))
+			fmt.Printf("Sample compression at level %d: %.2fx without dictionary, %.2fx with\n",
+				opts.Level, ratio(before, withoutDict), ratio(before, withDict))
+			if cfg.Compression.Level == 0 {
+				fmt.Println("Compression is disabled; set compression.level in .tig/config.json to use it")
+			}
+			return nil
+		},
+	}
+	retrainCmd.Flags().Int("size", safe.DefaultDictionarySize, "Target dictionary size in bytes")
+	retrainCmd.Flags().Int("samples", 2000, "Maximum number of content items to sample")
+	retrainCmd.Flags().Int("max-sample-size", 128*1024, "Skip content larger than this many bytes")
+
+	var statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Check the health of the database, content safe and disk",
+		Long: `Probe each subsystem the repository depends on: a Badger write and read,
+free disk space where the safe stores content, and a content write and
+read through the safe. Replication is reported once replicas exist.
+
+Without --server the checks run against the local repository. With
+--server they run inside a running tig serve, as its /healthz/deep
+endpoint does. The command fails if any subsystem is failing.`,
+		Example: `  tig admin status
+  tig admin status --server http://localhost:8080 --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverURL, _ := cmd.Flags().GetString("server")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			var report *health.Report
+			if serverURL != "" {
+				var err error
+				report, err = remote.NewClient(serverURL).Status(cmd.Context())
+				if err != nil {
+					return err
+				}
+			} else {
+				p, err := initParcel()
+				if err != nil {
+					return err
+				}
+				defer p.Close()
+
+				report = health.New(p.DB, p.Safe, config.Health{}.MinFree()).Run(cmd.Context())
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				fmt.Printf("%-12s %-9s %9s  %s\n", "SUBSYSTEM", "STATUS", "TIME", "DETAILS")
+				for _, c := range report.Checks {
+					fmt.Printf("%-12s %-9s %7.1fms  %s\n", c.Name, c.Status, c.DurationMS, c.Message)
+				}
+				fmt.Printf("\nOverall: %s\n", report.Status)
+			}
+
+			if !report.Healthy() {
+				return fmt.Errorf("repository is unhealthy")
+			}
+			return nil
+		},
+	}
+	statusCmd.Flags().String("server", "", "URL of a running tig serve to check")
+	statusCmd.Flags().Bool("json", false, "Output the report as JSON")
+
+	var tiersCmd = &cobra.Command{
+		Use:   "tiers",
+		Short: "Move stored content between hot, warm and cold storage",
+		Long: `Apply the storage tier policy set under tiers in .tig/config.json.
+
+Content read recently stays hot: uncompressed in the safe. Content unread
+for tiers.warm_after_days is compressed (warm), and content unread for
+tiers.cold_after_days is moved to tiers.cold_dir (cold). Reading cold
+content does not move it back; run this command again to re-tier content
+that has become busy.
+
+With --dry-run the moves are listed without changing anything.`,
+		Example: `  tig admin tiers --dry-run
+  tig admin tiers --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			if !p.Tiers.Enabled() {
+				return fmt.Errorf("no storage tiers configured: set tiers.warm_after_days or tiers.cold_after_days in .tig/config.json")
+			}
+			policy := parcel.TierPolicy(p.Tiers)
+			moves, err := p.Safe.PlanTiers(policy, time.Now())
+			if err != nil {
+				return err
+			}
+			verb := "Would move"
+			if !isDryRun(cmd) {
+				verb = "Moved"
+				if moves, err = p.Safe.ApplyTiers(moves, policy); err != nil {
+					return err
+				}
+			}
+
+			if asJSON {
+				if moves == nil {
+					moves = []safe.TierMove{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(moves)
+			}
+			if len(moves) == 0 {
+				fmt.Println("All content is in its tier")
+				return nil
+			}
+			type total struct {
+				count int
+				bytes int64
+			}
+			totals := map[string]*total{}
+			var order []string
+			for _, m := range moves {
+				key := m.From + " -> " + m.To
+				if totals[key] == nil {
+					totals[key] = &total{}
+					order = append(order, key)
+				}
+				totals[key].count++
+				totals[key].bytes += m.Size
+			}
+			sort.Strings(order)
+			for _, key := range order {
+				fmt.Printf("%s %d items (%s) %s\n", verb, totals[key].count, formatBytes(totals[key].bytes), key)
+			}
+			return nil
+		},
+	}
+	tiersCmd.Flags().Bool("json", false, "Output the moves as JSON")
+	supportDryRun(tiersCmd)
+
+	adminCmd.AddCommand(retrainCmd)
+	adminCmd.AddCommand(tiersCmd)
+	adminCmd.AddCommand(statusCmd)
+	adminCmd.AddCommand(adminLicenseCommand())
+	rootCmd.AddCommand(adminCmd)
+}
+
+// ratio returns before/after, guarding against empty input
+func ratio(before, after int) float64 {
+	if after == 0 {
+		return 1
+	}
+	return float64(before) / float64(after)
+}
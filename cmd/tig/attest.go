@@ -0,0 +1,108 @@
+// cmd/tig/attest.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"tig/internal/attest"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var attestCmd = &cobra.Command{
+		Use:   "attest <changeset|intent> --artifact <file>...",
+		Short: "Record which changeset a build artifact was produced from",
+		Long: `Record the SHA-256 of build artifacts together with the changeset they were
+built from, so a deployment can later prove which source state produced a
+binary with tig attest verify. The changeset may be given by ID, unique ID
+prefix, or as an intent whose changeset was built.
+
+Attestations travel with clones of the repository.`,
+		Example: `  tig attest 3f2a --artifact dist/tig-linux-amd64 --artifact dist/tig-darwin-arm64
+  tig attest verify dist/tig-linux-amd64`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			artifacts, _ := cmd.Flags().GetStringArray("artifact")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			if len(artifacts) == 0 {
+				return &usageError{fmt.Errorf("at least one --artifact is required")}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			attestations, err := p.Attest(args[0], artifacts)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				return printAttestations(attestations)
+			}
+			for _, a := range attestations {
+				fmt.Printf("Attested %s (sha256 %s) as built from changeset %s\n", a.Artifact, a.Hash, a.ChangeSetID)
+			}
+			return nil
+		},
+	}
+	attestCmd.Flags().StringArray("artifact", nil, "Artifact file to attest (repeatable)")
+	attestCmd.Flags().Bool("json", false, "Output the attestations as JSON")
+
+	var verifyCmd = &cobra.Command{
+		Use:   "verify <file>",
+		Short: "Show which changeset produced an artifact",
+		Long: `Hash an artifact and look up the changesets it was attested for, checking
+that each changeset still holds the changes it was attested with. The
+command fails if the artifact was never attested, or not for the changeset
+given with --changeset.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, _ := cmd.Flags().GetString("changeset")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			attestations, err := p.VerifyArtifact(args[0], ref)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				return printAttestations(attestations)
+			}
+			fmt.Printf("%s (sha256 %s) was built from:\n", args[0], attestations[0].Hash)
+			for _, a := range attestations {
+				fmt.Printf("  changeset %s", a.ChangeSetID)
+				if a.IntentID != "" {
+					fmt.Printf("  intent %s", a.IntentID)
+				}
+				fmt.Printf("  attested %s", a.CreatedAt.Format(time.RFC3339))
+				if a.Builder != "" {
+					fmt.Printf(" by %s", a.Builder)
+				}
+				fmt.Println()
+			}
+			return nil
+		},
+	}
+	verifyCmd.Flags().String("changeset", "", "Require the artifact to be attested for this changeset or intent")
+	verifyCmd.Flags().Bool("json", false, "Output the matching attestations as JSON")
+
+	attestCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(attestCmd)
+}
+
+func printAttestations(attestations []attest.Attestation) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(attestations)
+}
@@ -0,0 +1,147 @@
+// cmd/tig/bench.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"tig/bench"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var benchCmd = &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark tig on a generated repository",
+		Long: `Generate a repository tree and benchmark status, gating the whole
+tree, diffing and content storage against it.
+
+Fixtures: small (1k files), 10k (10k files, 64MB of binaries) and 100k
+(100k files, 512MB of binaries). Large fixtures need matching free disk
+space.
+
+With --baseline, results are compared to a report saved earlier with
+--save, and the command fails if any benchmark is slower by more than
+--threshold. Use this as a performance regression gate in CI.`,
+		Example: `  tig bench --fixture 10k --save baseline.json
+  tig bench --fixture 10k --baseline baseline.json --threshold 0.15
+  tig bench --run 'safe-.*'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fixtureName, _ := cmd.Flags().GetString("fixture")
+			dir, _ := cmd.Flags().GetString("dir")
+			run, _ := cmd.Flags().GetString("run")
+			baselinePath, _ := cmd.Flags().GetString("baseline")
+			savePath, _ := cmd.Flags().GetString("save")
+			threshold, _ := cmd.Flags().GetFloat64("threshold")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			fixture, err := bench.Lookup(fixtureName)
+			if err != nil {
+				return err
+			}
+			opts := bench.Options{Dir: dir}
+			if run != "" {
+				if opts.Filter, err = regexp.Compile(run); err != nil {
+					return fmt.Errorf("invalid --run pattern: %w", err)
+				}
+			}
+
+			var baseline *bench.Report
+			if baselinePath != "" {
+				if baseline, err = bench.LoadReport(baselinePath); err != nil {
+					return err
+				}
+				if baseline.Fixture != fixture {
+					fmt.Fprintf(os.Stderr, "warning: baseline was recorded with fixture %s\n", baseline.Fixture.Name)
+				}
+			}
+
+			if !asJSON {
+				fmt.Fprintf(os.Stderr, "Generating %s fixture (%d files, %s)...\n",
+					fixture.Name, fixture.Files+fixture.Binaries, formatBytes(fixture.Size()))
+				opts.Progress = func(name string) {
+					fmt.Fprintf(os.Stderr, "Running %s...\n", name)
+				}
+			}
+
+			report, err := bench.Run(fixture, opts)
+			if err != nil {
+				return err
+			}
+
+			if savePath != "" {
+				if err := report.Save(savePath); err != nil {
+					return err
+				}
+			}
+
+			var deltas []bench.Delta
+			if baseline != nil {
+				deltas = bench.Compare(baseline, report, threshold)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(struct {
+					*bench.Report
+					Deltas []bench.Delta `json:"deltas,omitempty"`
+				}{report, deltas}); err != nil {
+					return err
+				}
+			} else {
+				printBenchReport(report, deltas)
+			}
+
+			if regressed := bench.Regressions(deltas); len(regressed) > 0 {
+				return fmt.Errorf("%d benchmark(s) regressed by more than %.0f%%", len(regressed), threshold*100)
+			}
+			return nil
+		},
+	}
+
+	benchCmd.Flags().String("fixture", "small", "Fixture to generate: small, 10k or 100k")
+	benchCmd.Flags().String("dir", "", "Generate the fixture here instead of a temporary directory")
+	benchCmd.Flags().String("run", "", "Only run benchmarks matching this regular expression")
+	benchCmd.Flags().String("baseline", "", "Compare against a saved report")
+	benchCmd.Flags().String("save", "", "Save the report to this file")
+	benchCmd.Flags().Float64("threshold", bench.DefaultThreshold, "Slowdown that counts as a regression, e.g. 0.1 for 10%")
+	benchCmd.Flags().Bool("json", false, "Output as JSON")
+	rootCmd.AddCommand(benchCmd)
+}
+
+// printBenchReport prints results as a table, with changes against the
+// baseline when there is one
+func printBenchReport(report *bench.Report, deltas []bench.Delta) {
+	byName := make(map[string]bench.Delta, len(deltas))
+	for _, d := range deltas {
+		byName[d.Name] = d
+	}
+
+	fmt.Printf("%s, %s, %d CPUs\n\n", report.GoVersion, report.Platform, report.CPUs)
+	fmt.Printf("%-12s %8s %14s %10s %12s %10s\n", "BENCHMARK", "RUNS", "TIME/OP", "MB/S", "ALLOCS/OP", "CHANGE")
+	for _, r := range report.Results {
+		throughput := "-"
+		if r.MBPerSec > 0 {
+			throughput = fmt.Sprintf("%.1f", r.MBPerSec)
+		}
+		change := ""
+		if d, ok := byName[r.Name]; ok {
+			change = fmt.Sprintf("%+.1f%%", d.Change*100)
+			if d.Regressed {
+				change += " !"
+			}
+		}
+		perOp := time.Duration(r.NsPerOp)
+		if perOp > time.Millisecond {
+			perOp = perOp.Round(time.Microsecond)
+		}
+		fmt.Printf("%-12s %8d %14s %10s %12d %10s\n",
+			r.Name, r.Iterations, perOp, throughput, r.AllocsPerOp, change)
+	}
+}
@@ -0,0 +1,290 @@
+// cmd/tig/bisect.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"os/exec"
+	"strings"
+
+	"tig/internal/intent"
+	"tig/internal/parcel"
+
+	"github.com/spf13/cobra"
+)
+
+// bisectSkipCode is the exit status with which a bisect run command says
+// the checked out intent cannot be tested, as with git bisect run
+const bisectSkipCode = 125
+
+func init() {
+	var bisectCmd = &cobra.Command{
+		Use:   "bisect",
+		Short: "Find the intent that introduced a regression by binary search",
+		Long: `Search the committed intents, in the order they were committed, for the
+first one with a regression. Start with an intent that has it and one
+that does not; tig checks out the tree as of the intent halfway between,
+and you mark it good or bad until a single intent is left.
+
+tig bisect run marks the intents itself from a command's exit status,
+and tig bisect auto from the test results recorded with tig test record.
+tig bisect reset ends the search and restores the working tree.`,
+		Example: `  tig bisect start 9c1d 3f2a
+  tig bisect bad
+  tig bisect good
+  tig bisect run go test ./internal/diff
+  tig bisect auto
+  tig bisect reset`,
+	}
+
+	var startCmd = &cobra.Command{
+		Use:   "start [<bad> [<good>]]",
+		Short: "Start a bisect between a bad and a good intent",
+		Long: `Start a bisect. <bad> is an intent with the regression and defaults to
+the most recently committed intent; <good> is an earlier intent without
+it and can be marked later with tig bisect good. --stream limits the
+search to a stream's intents.
+
+The working tree must be clean. Each step rewrites the tracked files to
+the intent being tested, so leave edits out of the working tree until
+tig bisect reset.`,
+		Args:              cobra.MaximumNArgs(2),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			streamRef, _ := cmd.Flags().GetString("stream")
+			var bad, good string
+			if len(args) > 0 {
+				bad = args[0]
+			}
+			if len(args) > 1 {
+				good = args[1]
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			status, err := p.BisectStart(bad, good, streamRef)
+			if err != nil {
+				return err
+			}
+			return printBisect(p, status)
+		},
+	}
+	startCmd.Flags().String("stream", "", "Only bisect this stream's intents (ID, prefix, or name)")
+	startCmd.RegisterFlagCompletionFunc("stream", completeStreams)
+
+	markCommand := func(verdict, short string) *cobra.Command {
+		return &cobra.Command{
+			Use:               verdict + " [<intent>]",
+			Short:             short,
+			Args:              cobra.MaximumNArgs(1),
+			ValidArgsFunction: completeIntents,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var ref string
+				if len(args) > 0 {
+					ref = args[0]
+				}
+
+				p, err := initParcel()
+				if err != nil {
+					return err
+				}
+				defer p.Close()
+
+				status, err := p.BisectMark(verdict, ref)
+				if err != nil {
+					return err
+				}
+				return printBisect(p, status)
+			},
+		}
+	}
+
+	var runCmd = &cobra.Command{
+		Use:   "run <command> [args...]",
+		Short: "Mark intents automatically from a command's exit status",
+		Long: `Run a command on each intent checked out and mark the intent from its
+exit status: 0 is good, 125 skips an intent that cannot be tested, and
+any other status up to 127 is bad. A status above 127, or a command that
+cannot be started, stops the run. The repository is closed while the
+command runs, so it may run tig itself.
+
+With --check, intents with a recorded result of that check, such as
+test results recorded with tig test record, are marked from it and the
+command only runs on the others.`,
+		Example: `  tig bisect run go test ./...
+  tig bisect run sh -c 'make && ./scripts/smoke-test'
+  tig bisect run --check tests go test ./...`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			check, _ := cmd.Flags().GetString("check")
+			for {
+				p, err := initParcel()
+				if err != nil {
+					return err
+				}
+				var status *parcel.BisectStatus
+				if check != "" {
+					var steps []parcel.BisectStep
+					steps, status, err = p.BisectRecorded(check)
+					printRecordedSteps(steps, check)
+				} else {
+					status, err = p.BisectState()
+				}
+				p.Close()
+				if err != nil {
+					return err
+				}
+				if status.Waiting {
+					return &usageError{fmt.Errorf("mark a good intent with tig bisect good <intent> before running")}
+				}
+				if status.Done() {
+					p, err := initParcel()
+					if err != nil {
+						return err
+					}
+					defer p.Close()
+					return printBisect(p, status)
+				}
+
+				fmt.Printf("Running %s on intent %s\n", strings.Join(args, " "), status.Next.ID)
+				verdict, err := runBisectCommand(args)
+				if err != nil {
+					return err
+				}
+
+				if p, err = initParcel(); err != nil {
+					return err
+				}
+				_, err = p.BisectMark(verdict, "")
+				p.Close()
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Intent %s is %s\n", status.Next.ID, verdict)
+			}
+		},
+	}
+	runCmd.Flags().String("check", "", "Mark intents with a recorded result of this check from it instead of running the command")
+	runCmd.Flags().SetInterspersed(false)
+
+	var autoCmd = &cobra.Command{
+		Use:   "auto",
+		Short: "Mark intents from their recorded test results",
+		Long: `Mark intents from the results recorded with tig test record, or of
+another check with --check: an intent whose check passed is good and one
+whose check failed is bad. Without a good intent yet, the latest intent
+before the bad one that passed is marked good. Marking stops at the
+first intent the search needs that has no result; it is checked out to
+test by hand or with tig bisect run.`,
+		Example: `  tig bisect start
+  tig bisect auto
+  tig bisect auto --check e2e`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			check, _ := cmd.Flags().GetString("check")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			steps, status, err := p.BisectRecorded(check)
+			if err != nil {
+				return err
+			}
+			if len(steps) == 0 && !status.Done() {
+				fmt.Printf("No recorded %s result decides the next step\n", check)
+			}
+			printRecordedSteps(steps, check)
+			return printBisect(p, status)
+		},
+	}
+	autoCmd.Flags().String("check", intent.TestsCheck, "Check whose recorded results decide")
+
+	var resetCmd = &cobra.Command{
+		Use:   "reset",
+		Short: "End the bisect and restore the working tree",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			if err := p.BisectReset(); err != nil {
+				return err
+			}
+			fmt.Println("Bisect ended; working tree restored")
+			return nil
+		},
+	}
+
+	bisectCmd.AddCommand(startCmd)
+	bisectCmd.AddCommand(markCommand(parcel.BisectGood, "Mark an intent, by default the one checked out, as without the regression"))
+	bisectCmd.AddCommand(markCommand(parcel.BisectBad, "Mark an intent, by default the one checked out, as having the regression"))
+	bisectCmd.AddCommand(markCommand(parcel.BisectSkip, "Skip an intent, by default the one checked out, that cannot be tested"))
+	bisectCmd.AddCommand(runCmd)
+	bisectCmd.AddCommand(autoCmd)
+	bisectCmd.AddCommand(resetCmd)
+	rootCmd.AddCommand(bisectCmd)
+}
+
+// runBisectCommand runs a bisect run command and turns its exit status
+// into a verdict
+func runBisectCommand(args []string) (string, error) {
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := c.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return parcel.BisectGood, nil
+	case !errors.As(err, &exitErr):
+		return "", fmt.Errorf("running %s: %w", args[0], err)
+	case exitErr.ExitCode() == bisectSkipCode:
+		return parcel.BisectSkip, nil
+	case exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128:
+		return parcel.BisectBad, nil
+	default:
+		return "", fmt.Errorf("%s exited with status %d; bisect run stopped", args[0], exitErr.ExitCode())
+	}
+}
+
+// printRecordedSteps reports the intents marked from recorded results
+func printRecordedSteps(steps []parcel.BisectStep, check string) {
+	for _, step := range steps {
+		fmt.Printf("Intent %s is %s (recorded %s result)\n", step.IntentID, step.Verdict, check)
+	}
+}
+
+// printBisect reports where a bisect stands
+func printBisect(p *parcel.Parcel, status *parcel.BisectStatus) error {
+	switch {
+	case status.Culprit != nil:
+		fmt.Printf("%s is the first bad intent\n", status.Culprit.ID)
+		fmt.Printf("  %s: %s\n", status.Culprit.Type, status.Culprit.Description)
+	case len(status.Suspects) > 0:
+		fmt.Println("Skipped intents leave the first bad intent among:")
+		for _, id := range status.Suspects {
+			i, err := p.ResolveIntent(id)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("  %s %s\n", i.ID, i.Description)
+		}
+	case status.Waiting:
+		fmt.Printf("Mark a good intent with tig bisect good <intent>; %d intent(s) come before the bad one\n", status.Remaining)
+	default:
+		fmt.Printf("Bisecting: %d intent(s) left to test (about %d step(s))\n", status.Remaining, bits.Len(uint(status.Remaining)))
+		fmt.Printf("Checked out intent %s: %s\n", status.Next.ID, status.Next.Description)
+	}
+	return nil
+}
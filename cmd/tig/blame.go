@@ -0,0 +1,91 @@
+// cmd/tig/blame.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tig/internal/blame"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var blameCmd = &cobra.Command{
+		Use:   "blame <path>",
+		Short: "Show which intent last changed each line of a file",
+		Long: `Show the committed content of a file with the intent, author and date
+that introduced each line. History follows moves and copies to the file
+they came from.
+
+Lines that arrived through a cherry-pick or merge are attributed to the
+intent they were originally written in. Use --first-parent to attribute
+them to the changeset that brought them in instead.
+
+With --reverse <intent>, the file is shown as of that intent and each
+line is attributed to the last intent that still contained it, which
+shows when lines were removed.`,
+		Example: `  tig blame main.go
+  tig blame --first-parent internal/util.go
+  tig blame --reverse 3f2a main.go`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			firstParent, _ := cmd.Flags().GetBool("first-parent")
+			reverseFrom, _ := cmd.Flags().GetString("reverse")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			opts := blame.Options{FirstParent: firstParent}
+			if reverseFrom != "" {
+				i, err := p.ResolveIntent(reverseFrom)
+				if err != nil {
+					return err
+				}
+				if i.ChangeSetID == "" {
+					return fmt.Errorf("intent %s has no changeset", i.ID)
+				}
+				opts.Reverse = i.ChangeSetID
+			}
+
+			path := filepath.Clean(args[0])
+			lines, err := blame.File(p.DB, p.Safe, path, opts)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(lines)
+			}
+
+			width := len(fmt.Sprint(len(lines)))
+			for _, l := range lines {
+				id := l.IntentID
+				if len(id) > 8 {
+					id = id[:8]
+				}
+				origin := ""
+				if l.Path != path {
+					origin = " " + l.Path
+				}
+				fmt.Printf("%-8s%s (%-12s %s %*d) %s\n",
+					id, origin, l.Author, l.Time.Format("2006-01-02"), width, l.Num, l.Content)
+			}
+			return nil
+		},
+	}
+
+	blameCmd.Flags().Bool("first-parent", false, "Attribute lines to the changeset that brought them in, not where they were cherry-picked or merged from")
+	blameCmd.Flags().String("reverse", "", "Show the file as of this intent and when each line was last present")
+	blameCmd.Flags().Bool("json", false, "Output the lines as JSON")
+
+	rootCmd.AddCommand(blameCmd)
+}
@@ -0,0 +1,126 @@
+// cmd/tig/clone.go
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"tig/internal/parcel"
+	"tig/internal/safe"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func init() {
+	var cloneCmd = &cobra.Command{
+		Use:   "clone <url> [directory]",
+		Short: "Copy a repository from a tig server",
+		Long: `Clone a repository served with 'tig serve' into a new directory.
+
+With --partial only metadata (intents, streams, changesets and the tracked
+tree) is copied. File content is fetched from the server the first time
+a checkout or diff needs it; use 'tig fetch --blobs' to download it ahead
+of time.
+
+Files are checked out as copy-on-write clones of the stored content on
+filesystems that support it (Btrfs, XFS, APFS). With --read-only they
+are hardlinked to it elsewhere, which suits build and CI mirrors.`,
+		Example: `  tig clone http://tig.example.com:8080
+  tig clone --partial http://tig.example.com:8080 myrepo`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			partial, _ := cmd.Flags().GetBool("partial")
+			noCheckout, _ := cmd.Flags().GetBool("no-checkout")
+			workers, _ := cmd.Flags().GetInt("jobs")
+			readOnly, _ := cmd.Flags().GetBool("read-only")
+
+			dir := ""
+			if len(args) == 2 {
+				dir = args[1]
+			} else {
+				dir = defaultCloneDir(args[0])
+			}
+
+			logger, err := zap.NewDevelopment()
+			if err != nil {
+				return fmt.Errorf("initializing logger: %w", err)
+			}
+
+			p, err := parcel.Clone(cmd.Context(), args[0], dir, parcel.CloneOptions{
+				Partial:    partial,
+				NoCheckout: noCheckout,
+				Workers:    workers,
+				ReadOnly:   readOnly,
+			}, logger)
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+			registerRepo(dir)
+
+			kind := "repository"
+			if partial {
+				kind = "partial clone"
+			}
+			fmt.Printf("Cloned %s into %s\n", kind, dir)
+			return nil
+		},
+	}
+
+	cloneCmd.Flags().Bool("partial", false, "Copy metadata only and fetch file content on demand")
+	cloneCmd.Flags().Bool("no-checkout", false, "Don't write files into the working tree")
+	cloneCmd.Flags().Bool("read-only", false, "Check out read-only files, hardlinked to stored content when cloning isn't supported")
+	cloneCmd.Flags().IntP("jobs", "j", 0, "Number of parallel downloads (default: number of CPUs)")
+	rootCmd.AddCommand(cloneCmd)
+
+	var fetchCmd = &cobra.Command{
+		Use:   "fetch --blobs <path-glob>",
+		Short: "Download content ahead of time in a partial clone",
+		Long: `Fetch every recorded version of the files matching a glob from the
+remote, so later checkouts and diffs don't need the network.`,
+		Example: `  tig fetch --blobs 'src/**'
+  tig fetch --blobs '*.go'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pattern, _ := cmd.Flags().GetString("blobs")
+			workers, _ := cmd.Flags().GetInt("jobs")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			n, err := p.FetchBlobs(pattern, safe.BatchOptions{Workers: workers})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Fetched %d objects\n", n)
+			return nil
+		},
+	}
+
+	fetchCmd.Flags().String("blobs", "", "Glob of paths whose content to download, e.g. 'src/**'")
+	fetchCmd.Flags().IntP("jobs", "j", 0, "Number of parallel downloads (default: number of CPUs)")
+	fetchCmd.MarkFlagRequired("blobs")
+	rootCmd.AddCommand(fetchCmd)
+}
+
+// defaultCloneDir derives a directory name from the last element of the
+// remote URL, falling back to the host name
+func defaultCloneDir(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "repo"
+	}
+	if base := path.Base(strings.TrimRight(u.Path, "/")); base != "." && base != "/" && base != "" {
+		return base
+	}
+	if host := u.Hostname(); host != "" {
+		return host
+	}
+	return "repo"
+}
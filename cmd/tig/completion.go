@@ -0,0 +1,61 @@
+// cmd/tig/completion.go
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+func init() {
+	rootCmd.Version = version
+}
+
+// completeIntents completes intent IDs for commands taking one intent
+func completeIntents(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	p, err := initParcel()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer p.Close()
+
+	intents, err := p.ListIntents()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var out []string
+	for _, i := range intents {
+		out = append(out, i.ID+"\t"+i.Description)
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStreams completes stream names for commands taking one stream
+func completeStreams(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	p, err := initParcel()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer p.Close()
+
+	streams, err := p.ListStreams()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var out []string
+	for _, s := range streams {
+		out = append(out, s.Name+"\t"+s.Type)
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
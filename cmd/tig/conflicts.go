@@ -0,0 +1,33 @@
+// cmd/tig/conflicts.go
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"tig/internal/conflict"
+
+	"github.com/fatih/color"
+)
+
+// printConflicts warns about paths that open intents on different streams
+// both modify
+func printConflicts(conflicts []conflict.Conflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+
+	yellow := color.New(color.FgYellow).SprintFunc()
+	fmt.Println(yellow("Warning: potential conflicts between open intents:"))
+	for _, c := range conflicts {
+		var refs []string
+		if c.Gated {
+			refs = append(refs, "gated changes")
+		}
+		for _, r := range c.Intents {
+			refs = append(refs, fmt.Sprintf("%s (%s)", r.IntentID[:min(8, len(r.IntentID))], r.StreamName))
+		}
+		fmt.Printf("\t%s %s: %s\n", yellow("!"), c.Path, strings.Join(refs, ", "))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,151 @@
+// cmd/tig/deployments.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/deploy"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var deploymentsCmd = &cobra.Command{
+		Use:     "deployments",
+		Aliases: []string{"deploy"},
+		Short:   "Track which release or changeset runs in each environment",
+		Long: `A deployment records that a release, or the head of a stream, is running in
+an environment such as staging or prod. Each intent landed in what was
+deployed is marked with when it first reached the environment, which tig
+intent show prints as "deployed to prod on <date>".
+
+Deploy pipelines record deployments with tig deployments record or by
+POSTing to /api/deployments on tig serve.`,
+	}
+
+	var recordCmd = &cobra.Command{
+		Use:   "record <environment>",
+		Short: "Record a release or stream as deployed to an environment",
+		Example: `  tig deployments record prod --release v1.4.0
+  tig deployments record staging --stream main --url https://ci.example.com/deploys/97`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tag, _ := cmd.Flags().GetString("release")
+			streamRef, _ := cmd.Flags().GetString("stream")
+			url, _ := cmd.Flags().GetString("url")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			if (tag == "") == (streamRef == "") {
+				return &usageError{fmt.Errorf("give exactly one of --release and --stream")}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			d, first, err := p.Deploy(args[0], tag, streamRef, url)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(d)
+			}
+			fmt.Printf("Deployed %s to %s: %d intent(s), %d deployed there for the first time\n",
+				d.Source(), d.Environment, len(d.Intents), len(first))
+			return nil
+		},
+	}
+	recordCmd.Flags().String("release", "", "Release tag that was deployed")
+	recordCmd.Flags().StringP("stream", "s", "", "Stream whose head was deployed (ID, prefix, or name)")
+	recordCmd.Flags().String("url", "", "Link to the deploy job")
+	recordCmd.Flags().Bool("json", false, "Output the deployment as JSON")
+	recordCmd.RegisterFlagCompletionFunc("stream", completeStreams)
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List deployments, newest first",
+		Example: `  tig deployments list
+  tig deployments list --env prod
+  tig deployments list --current`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			env, _ := cmd.Flags().GetString("env")
+			current, _ := cmd.Flags().GetBool("current")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			if current && env != "" {
+				return &usageError{fmt.Errorf("--current and --env cannot be combined")}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			var list []deploy.Deployment
+			if current {
+				list, err = p.CurrentDeployments()
+			} else {
+				list, err = p.Deployments(env)
+			}
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				if list == nil {
+					list = []deploy.Deployment{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(list)
+			}
+			if len(list) == 0 {
+				fmt.Println("No deployments found")
+				return nil
+			}
+			for _, d := range list {
+				line := fmt.Sprintf("%s  %-12s %s  (%d intents)", d.DeployedAt.Format(time.RFC3339), d.Environment, d.Source(), len(d.Intents))
+				switch {
+				case d.RolledBack:
+					line += "  rolled back"
+				case d.RollbackOf != "":
+					line += "  rollback of " + d.RollbackOf
+				}
+				if d.URL != "" {
+					line += "  " + d.URL
+				}
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().String("env", "", "Only list deployments to this environment")
+	listCmd.Flags().Bool("current", false, "Only list the latest deployment to each environment")
+	listCmd.Flags().Bool("json", false, "Output deployments as JSON")
+
+	deploymentsCmd.AddCommand(recordCmd, listCmd)
+	rootCmd.AddCommand(deploymentsCmd)
+}
+
+// formatDeployed describes where an intent has been deployed, e.g.
+// "prod on 2026-03-02, staging on 2026-02-27"
+func formatDeployed(deployed map[string]time.Time) string {
+	envs := make([]string, 0, len(deployed))
+	for env := range deployed {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	parts := make([]string, len(envs))
+	for n, env := range envs {
+		parts[n] = fmt.Sprintf("%s on %s", env, deployed[env].Local().Format("2006-01-02"))
+	}
+	return strings.Join(parts, ", ")
+}
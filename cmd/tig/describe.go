@@ -0,0 +1,66 @@
+// cmd/tig/describe.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"tig/internal/parcel"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// reviewDescription runs the repository's description processors before
+// tig intent create. With --suggest the configured assistant proposes a
+// description from the gated diff, which replaces the given one if the
+// user accepts it; the final description is then linted unless --no-lint
+// is set.
+func reviewDescription(cmd *cobra.Command, p *parcel.Parcel, opts *parcel.CommitOptions) error {
+	suggest, _ := cmd.Flags().GetBool("suggest")
+	noLint, _ := cmd.Flags().GetBool("no-lint")
+	if len(p.DescriptionProcessors) == 0 {
+		if suggest {
+			return fmt.Errorf("no description assistant configured: set describe.suggest_command or describe.suggest_url in .tig/config.json")
+		}
+		return nil
+	}
+
+	if suggest {
+		draft, err := p.DescriptionDraft(opts.Description, opts.Type, true)
+		if err != nil {
+			return err
+		}
+		review, err := p.ReviewDescription(cmd.Context(), draft)
+		if err != nil {
+			return fmt.Errorf("suggesting a description: %w", err)
+		}
+		if len(review.Suggestions) == 0 {
+			fmt.Println("No description suggested")
+		}
+		q := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+		for _, s := range review.Suggestions {
+			fmt.Printf("Suggested description:\n\n%s\n\n", s.Description)
+			ok, err := q.confirm("Use it?", false)
+			if err != nil {
+				return err
+			}
+			if ok {
+				opts.Description = s.Description
+				break
+			}
+		}
+	}
+
+	if noLint {
+		return nil
+	}
+	draft, err := p.DescriptionDraft(opts.Description, opts.Type, false)
+	if err != nil {
+		return err
+	}
+	return p.CheckDescription(cmd.Context(), draft, func(problem parcel.DescriptionProblem) {
+		color.Yellow("warning: %s", problem)
+	})
+}
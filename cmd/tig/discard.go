@@ -0,0 +1,133 @@
+// cmd/tig/discard.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"tig/internal/diff"
+	"tig/internal/highlight"
+	"tig/internal/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var discardCmd = &cobra.Command{
+		Use:   "discard <paths...>",
+		Short: "Throw away working-tree edits",
+		Long: `Restore files to their gated content, or to their last recorded content
+when they are not gated, from the Safe. Files whose deletion is gated are
+removed. The files that would change are listed and confirmed first;
+untracked files are refused, since there is nothing to restore them to.
+
+With -p, the hunks of one file are shown in turn and only the ones you
+accept are reverted.`,
+		Example: `  tig discard main.go
+  tig discard --all --yes
+  tig discard -p main.go`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			yes, _ := cmd.Flags().GetBool("yes")
+			patch, _ := cmd.Flags().GetBool("patch")
+			switch {
+			case patch && (all || len(args) != 1):
+				return &usageError{fmt.Errorf("-p takes exactly one file")}
+			case all && len(args) > 0:
+				return &usageError{fmt.Errorf("--all takes no paths")}
+			case !all && len(args) == 0:
+				return &usageError{fmt.Errorf("specify paths to discard, or --all")}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			if patch {
+				n, err := p.DiscardHunks(args[0], chooseHunks(os.Stdin, os.Stdout, p.Highlighter, args[0], "Discard"))
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Discarded %d hunk(s) of %s\n", n, args[0])
+				return nil
+			}
+
+			plan, err := p.PlanDiscard(args, all)
+			if err != nil {
+				return err
+			}
+			if len(plan) == 0 {
+				fmt.Println("Nothing to discard")
+				return nil
+			}
+			fmt.Println("Edits to these files will be lost:")
+			for _, d := range plan {
+				if d.Delete {
+					fmt.Printf("\t%s (deletion is gated)\n", d.Path)
+				} else {
+					fmt.Printf("\t%s\n", d.Path)
+				}
+			}
+			if !yes {
+				q := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+				ok, err := q.confirm(fmt.Sprintf("Discard changes to %d file(s)?", len(plan)), false)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Println("Nothing discarded")
+					return nil
+				}
+			}
+
+			if err := p.Discard(plan); err != nil {
+				return err
+			}
+			fmt.Printf("Discarded changes to %d file(s)\n", len(plan))
+			return nil
+		},
+	}
+	discardCmd.Flags().Bool("all", false, "Discard edits to every tracked and gated file")
+	discardCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+	discardCmd.Flags().BoolP("patch", "p", false, "Choose hunks of one file to discard interactively")
+	rootCmd.AddCommand(discardCmd)
+}
+
+// chooseHunks shows each hunk of path and asks whether to act on it: y
+// for this hunk, n to skip it, a for this and all later hunks, q to skip
+// the rest
+func chooseHunks(in io.Reader, out io.Writer, h *highlight.Highlighter, path, verb string) workspace.ChooseHunk {
+	q := &prompter{in: bufio.NewReader(in), out: out}
+	var all, quit bool
+	return func(n, total int, hunk diff.Hunk) (bool, error) {
+		if all || quit {
+			return all, nil
+		}
+		fmt.Fprintln(out)
+		printColoredDiff(h, path, (&diff.DiffResult{Hunks: []diff.Hunk{hunk}}).Format())
+		for {
+			answer, err := q.ask(fmt.Sprintf("%s this hunk (%d/%d) [y,n,a,q]", verb, n+1, total), "")
+			if err != nil {
+				return false, err
+			}
+			switch strings.ToLower(answer) {
+			case "y", "yes":
+				return true, nil
+			case "", "n", "no":
+				return false, nil
+			case "a":
+				all = true
+				return true, nil
+			case "q":
+				quit = true
+				return false, nil
+			}
+			fmt.Fprintln(out, "y - yes, n - no, a - this and all later hunks, q - none of the rest")
+		}
+	}
+}
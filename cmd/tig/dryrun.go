@@ -0,0 +1,120 @@
+// cmd/tig/dryrun.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// dryRunAnnotation marks commands that honor --dry-run
+const dryRunAnnotation = "tig.dry-run"
+
+func init() {
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Show what the command would change without changing anything")
+}
+
+// supportDryRun marks cmds as honoring --dry-run. Other commands refuse
+// the flag rather than ignore it.
+func supportDryRun(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		if cmd.Annotations == nil {
+			cmd.Annotations = map[string]string{}
+		}
+		cmd.Annotations[dryRunAnnotation] = "true"
+	}
+}
+
+// isDryRun reports whether --dry-run was given
+func isDryRun(cmd *cobra.Command) bool {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	return dryRun
+}
+
+// refuseDryRun fails commands given --dry-run that cannot honor it, so
+// that nothing is changed by mistake
+func refuseDryRun(next runE) runE {
+	return func(cmd *cobra.Command, args []string) error {
+		if isDryRun(cmd) && cmd.Annotations[dryRunAnnotation] != "true" {
+			return &usageError{fmt.Errorf("%s does not support --dry-run", cmd.CommandPath())}
+		}
+		return next(cmd, args)
+	}
+}
+
+// dryRunReport lists what a command run with --dry-run would change, each
+// list sorted so the same state always gives the same report
+type dryRunReport struct {
+	Command      string   `json:"command"`
+	FilesRead    []string `json:"files_read,omitempty"`
+	FilesWritten []string `json:"files_written,omitempty"`
+	FilesRemoved []string `json:"files_removed,omitempty"`
+	KeysWritten  []string `json:"keys_written,omitempty"`
+	KeysDeleted  []string `json:"keys_deleted,omitempty"`
+	BlobsStored  []string `json:"blobs_stored,omitempty"`
+	BlobsRemoved []string `json:"blobs_removed,omitempty"`
+	Ungated      []string `json:"ungated,omitempty"` // gated changes dropped
+}
+
+func (r *dryRunReport) print(asJSON bool) error {
+	for _, list := range []*[]string{&r.FilesRead, &r.FilesWritten, &r.FilesRemoved, &r.KeysWritten, &r.KeysDeleted, &r.BlobsStored, &r.BlobsRemoved, &r.Ungated} {
+		slices.Sort(*list)
+		*list = slices.Compact(*list)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+
+	lines := []struct {
+		label string
+		items []string
+	}{
+		{"read file", r.FilesRead},
+		{"write file", r.FilesWritten},
+		{"remove file", r.FilesRemoved},
+		{"store blob", r.BlobsStored},
+		{"remove blob", r.BlobsRemoved},
+		{"ungate", r.Ungated},
+		{"write key", r.KeysWritten},
+		{"delete key", r.KeysDeleted},
+	}
+	empty := true
+	for _, l := range lines {
+		empty = empty && len(l.items) == 0
+	}
+	if empty {
+		fmt.Printf("Dry run: %s would change nothing\n", r.Command)
+		return nil
+	}
+	fmt.Printf("Dry run: %s would\n", r.Command)
+	for _, l := range lines {
+		for _, item := range l.items {
+			fmt.Printf("  %-12s %s\n", l.label, item)
+		}
+	}
+	fmt.Println("Nothing was changed")
+	return nil
+}
+
+// stableKey replaces the parts of a key a dry run cannot predict, new IDs
+// and timestamps, with placeholders so that reports are repeatable. ids
+// is nil when the dry run creates no new IDs.
+func stableKey(key string, ids *strings.Replacer) string {
+	if ids != nil {
+		key = ids.Replace(key)
+	}
+	parts := strings.Split(key, ":")
+	for n, part := range parts {
+		if len(part) >= 10 && strings.Trim(part, "0123456789") == "" {
+			parts[n] = "<time>"
+		}
+	}
+	return strings.Join(parts, ":")
+}
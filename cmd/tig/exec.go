@@ -0,0 +1,134 @@
+// cmd/tig/exec.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tig/internal/parcel"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var execCmd = &cobra.Command{
+		Use:   "exec [--stream <stream>] -- <command> [args...]",
+		Short: "Run a command with the repository state in its environment",
+		Long: `Run a command with environment variables describing the repository, so
+scripts can act on it without parsing tig's output:
+
+  TIG_ROOT          the repository root
+  TIG_STREAM        the current stream's name, empty without one
+  TIG_STREAM_ID     the current stream's ID
+  TIG_HEAD          the changeset the current stream's tree ends at
+  TIG_INTENT        the most recently created intent
+  TIG_GATED_FILES   a file listing the gated paths, one per line
+  TIG_GATED_COUNT   how many paths are gated
+
+The current stream is the one last switched to with tig stream switch,
+or else the one the latest intent was added to, unless --stream names
+another. The repository is
+closed while the command runs, so it may run tig itself. tig exits with
+the command's exit status.`,
+		Example: `  tig exec -- sh -c 'echo "$TIG_STREAM at $TIG_HEAD"'
+  tig exec -- sh -c 'xargs gofmt -l < "$TIG_GATED_FILES"'
+  tig exec --stream release -- ./scripts/check-release.sh`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			streamRef, _ := cmd.Flags().GetString("stream")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			env, gatedFile, err := scriptEnv(p, streamRef)
+			p.Close()
+			if gatedFile != "" {
+				defer os.Remove(gatedFile)
+			}
+			if err != nil {
+				return err
+			}
+
+			c := exec.Command(args[0], args[1:]...)
+			c.Env = append(os.Environ(), env...)
+			c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+			err = c.Run()
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return &exitStatus{code: exitErr.ExitCode()}
+			}
+			return err
+		},
+	}
+	execCmd.Flags().String("stream", "", "Stream to describe instead of the current one (ID, prefix, or name)")
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.RegisterFlagCompletionFunc("stream", completeStreams)
+	rootCmd.AddCommand(execCmd)
+}
+
+// scriptEnv describes the repository as TIG_* environment variables. The
+// gated paths are written to a temporary file, returned for removal.
+func scriptEnv(p *parcel.Parcel, streamRef string) ([]string, string, error) {
+	latest, _, err := p.LatestIntent()
+	if err != nil {
+		return nil, "", err
+	}
+	st, err := p.CurrentStream()
+	if err != nil {
+		return nil, "", err
+	}
+	if streamRef != "" {
+		if st, err = p.ResolveStream(streamRef); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var streamName, streamID, head, intentID string
+	if st != nil {
+		streamName, streamID = st.Name, st.ID
+		if head, err = p.Head(st); err != nil {
+			return nil, "", fmt.Errorf("finding head of stream %s: %w", st.Name, err)
+		}
+	}
+	if latest != nil {
+		intentID = latest.ID
+	}
+
+	status, err := p.Status()
+	if err != nil {
+		return nil, "", fmt.Errorf("reading status: %w", err)
+	}
+	var gated []string
+	for _, c := range status {
+		if c.Gated {
+			gated = append(gated, c.Path)
+		}
+	}
+	sort.Strings(gated)
+	f, err := os.CreateTemp("", "tig-gated-*")
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	if len(gated) > 0 {
+		if _, err := f.WriteString(strings.Join(gated, "\n") + "\n"); err != nil {
+			return nil, f.Name(), err
+		}
+	}
+
+	return []string{
+		"TIG_ROOT=" + p.Root,
+		"TIG_STREAM=" + streamName,
+		"TIG_STREAM_ID=" + streamID,
+		"TIG_HEAD=" + head,
+		"TIG_INTENT=" + intentID,
+		"TIG_GATED_FILES=" + f.Name(),
+		"TIG_GATED_COUNT=" + strconv.Itoa(len(gated)),
+	}, f.Name(), nil
+}
@@ -0,0 +1,159 @@
+// cmd/tig/exit.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"tig/internal/content"
+	tigerrors "tig/internal/errors"
+	"tig/internal/safe"
+	"tig/internal/storage"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes are stable so scripts can branch on the kind of failure
+const (
+	exitOK        = 0
+	exitError     = 1 // any failure without a more specific code
+	exitUsage     = 2 // invalid command, arguments or flags, or no repository
+	exitConflict  = 3 // conflicting changes or concurrent updates
+	exitNotFound  = 4 // a referenced intent, stream, file or content is missing
+	exitDirtyTree = 5 // the working tree has changes the command would lose
+)
+
+// errorKinds names each exit code in JSON error envelopes
+var errorKinds = map[int]string{
+	exitError:     "error",
+	exitUsage:     "usage",
+	exitConflict:  "conflict",
+	exitNotFound:  "not_found",
+	exitDirtyTree: "dirty_tree",
+}
+
+// usageError marks errors caused by how a command was invoked
+type usageError struct{ err error }
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// exitStatus carries the exit code of a command tig ran on the user's
+// behalf, which tig exits with in turn without reporting anything
+type exitStatus struct{ code int }
+
+func (e *exitStatus) Error() string { return fmt.Sprintf("exit status %d", e.code) }
+
+// exitCode classifies err
+func exitCode(err error) int {
+	var status *exitStatus
+	if errors.As(err, &status) {
+		return status.code
+	}
+	var usage *usageError
+	msg := err.Error()
+	switch {
+	case errors.As(err, &usage),
+		strings.HasPrefix(msg, "unknown command"),
+		strings.HasPrefix(msg, "required flag"):
+		return exitUsage
+	case errors.Is(err, tigerrors.ErrNotRepository):
+		// Running outside a repository is a mistake in how tig was
+		// invoked, not a missing intent or stream
+		return exitUsage
+	case errors.Is(err, tigerrors.ErrDirtyTree):
+		return exitDirtyTree
+	case errors.Is(err, tigerrors.ErrConflict),
+		errors.Is(err, storage.ErrExists),
+		errors.Is(err, badger.ErrConflict):
+		return exitConflict
+	case errors.Is(err, storage.ErrNotFound),
+		errors.Is(err, badger.ErrKeyNotFound),
+		errors.Is(err, safe.ErrContentNotFound),
+		errors.Is(err, content.ErrContentNotFound),
+		errors.Is(err, os.ErrNotExist),
+		isNotFound(err):
+		return exitNotFound
+	}
+	return exitError
+}
+
+// isNotFound reports whether err is or wraps a not found error from the
+// errors package
+func isNotFound(err error) bool {
+	var tigErr *tigerrors.Error
+	return errors.As(err, &tigErr) && tigErr.Type == tigerrors.ErrorTypeNotFound
+}
+
+// errorEnvelope is the JSON written for failures with --json
+type errorEnvelope struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Kind    string `json:"kind"`
+		Message string `json:"message"`
+		Command string `json:"command,omitempty"`
+	} `json:"error"`
+}
+
+// reportError writes err to w, as a JSON envelope if asJSON, and returns
+// the exit code
+func reportError(w io.Writer, cmd *cobra.Command, err error, asJSON bool) int {
+	code := exitCode(err)
+	var status *exitStatus
+	if errors.As(err, &status) {
+		return code // The command has reported its own failure
+	}
+	if asJSON {
+		var env errorEnvelope
+		env.Error.Code = code
+		env.Error.Kind = errorKinds[code]
+		env.Error.Message = err.Error()
+		if cmd != nil {
+			env.Error.Command = cmd.CommandPath()
+		}
+		json.NewEncoder(w).Encode(env)
+		return code
+	}
+
+	fmt.Fprintln(w, "Error:", err)
+	if code == exitUsage && cmd != nil && !errors.Is(err, tigerrors.ErrNotRepository) {
+		fmt.Fprintf(w, "Run '%s --help' for usage.\n", cmd.CommandPath())
+	}
+	return code
+}
+
+// wantsJSON reports whether errors should be written as JSON. Flags are
+// not parsed when parsing them failed, so the arguments are checked too.
+func wantsJSON(cmd *cobra.Command) bool {
+	if cmd != nil {
+		if asJSON, err := cmd.Flags().GetBool("json"); err == nil && asJSON {
+			return true
+		}
+	}
+	return slices.Contains(os.Args[1:], "--json")
+}
+
+// markUsageErrors makes argument and flag validation failures of cmd and
+// its subcommands usage errors
+func markUsageErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &usageError{err}
+	})
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(cmd *cobra.Command, a []string) error {
+			if err := args(cmd, a); err != nil {
+				return &usageError{err}
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
@@ -0,0 +1,66 @@
+// cmd/tig/exit_test.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	tigerrors "tig/internal/errors"
+	"tig/internal/storage"
+	"tig/internal/workspace"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	_, notRepo := workspace.FindRoot(t.TempDir())
+	require.Error(t, notRepo)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"generic", errors.New("boom"), exitError},
+		{"usage", &usageError{errors.New("bad flag")}, exitUsage},
+		{"unknown command", errors.New(`unknown command "lgo" for "tig"`), exitUsage},
+		{"not a repository", fmt.Errorf("opening: %w", notRepo), exitUsage},
+		{"conflict", fmt.Errorf("merging: %w", tigerrors.ErrConflict), exitConflict},
+		{"exists", storage.ErrExists, exitConflict},
+		{"not found", tigerrors.NotFound("intent i1 not found"), exitNotFound},
+		{"store not found", fmt.Errorf("getting intent: %w", storage.ErrNotFound), exitNotFound},
+		{"dirty tree", fmt.Errorf("switching: %w", tigerrors.ErrDirtyTree), exitDirtyTree},
+		{"exit status", &exitStatus{code: exitConflict}, exitConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exitCode(tt.err))
+		})
+	}
+}
+
+func TestReportError(t *testing.T) {
+	cmd := &cobra.Command{Use: "status"}
+	notRepo := fmt.Errorf("/tmp/x is %w", tigerrors.ErrNotRepository)
+
+	var out bytes.Buffer
+	assert.Equal(t, exitUsage, reportError(&out, cmd, notRepo, false))
+	assert.Equal(t, "Error: /tmp/x is not a tig repository\n", out.String())
+
+	out.Reset()
+	assert.Equal(t, exitUsage, reportError(&out, cmd, &usageError{errors.New("bad flag")}, false))
+	assert.Contains(t, out.String(), "Run 'status --help' for usage.")
+
+	out.Reset()
+	assert.Equal(t, exitNotFound, reportError(&out, cmd, tigerrors.NotFound("intent i1 not found"), true))
+	var env errorEnvelope
+	require.NoError(t, json.Unmarshal(out.Bytes(), &env))
+	assert.Equal(t, exitNotFound, env.Error.Code)
+	assert.Equal(t, "not_found", env.Error.Kind)
+	assert.Equal(t, "status", env.Error.Command)
+}
@@ -0,0 +1,96 @@
+// cmd/tig/format.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"tig/internal/intent"
+
+	"github.com/spf13/cobra"
+)
+
+// templateFuncs are available to --format templates in addition to the
+// text/template builtins
+var templateFuncs = template.FuncMap{
+	"short": func(id string) string {
+		if len(id) > 8 {
+			return id[:8]
+		}
+		return id
+	},
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+}
+
+// addFormatFlag registers the --format flag on a list or show command
+func addFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().String("format", "", "Format output using a Go template, e.g. '{{.ID}} {{.Description}}'")
+}
+
+// formatTemplate parses the --format flag, returning nil when it is unset
+func formatTemplate(cmd *cobra.Command) (*template.Template, error) {
+	text, _ := cmd.Flags().GetString("format")
+	if text == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// printTemplate renders one item per line with the given template
+func printTemplate(tmpl *template.Template, items ...any) error {
+	return writeTemplate(os.Stdout, tmpl, items...)
+}
+
+// writeTemplate renders one item per line with the given template to w
+func writeTemplate(w io.Writer, tmpl *template.Template, items ...any) error {
+	for _, item := range items {
+		if err := tmpl.Execute(w, item); err != nil {
+			return fmt.Errorf("executing --format template: %w", err)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// formatBytes renders a size with a binary unit, e.g. 1.5MB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatExtensions renders an intent's repository-defined fields for list
+// output, e.g. " risk=high rollout=canary"
+func formatExtensions(i *intent.Intent) string {
+	var b strings.Builder
+	for _, name := range i.ExtensionNames() {
+		fmt.Fprintf(&b, " %s=%v", name, i.Extensions[name])
+	}
+	return b.String()
+}
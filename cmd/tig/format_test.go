@@ -0,0 +1,63 @@
+// cmd/tig/format_test.go
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"tig/internal/intent"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatTemplate(t *testing.T) {
+	i := &intent.Intent{
+		ID:          "3f2a9c41d7e8",
+		Type:        "feature",
+		Description: "Bill in cents",
+		CreatedAt:   time.Date(2024, 1, 31, 15, 4, 5, 0, time.UTC),
+		Projects:    []string{"billing", "web"},
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr string
+	}{
+		{name: "field", format: "{{.ID}} {{.Description}}", want: "3f2a9c41d7e8 Bill in cents\n"},
+		{name: "short", format: "{{short .ID}}", want: "3f2a9c41\n"},
+		{name: "json", format: "{{json .Projects}}", want: `["billing","web"]` + "\n"},
+		{name: "join", format: `{{join .Projects ","}}`, want: "billing,web\n"},
+		{name: "upper", format: "{{upper .Type}}", want: "FEATURE\n"},
+		{name: "lower", format: "{{lower .Description}}", want: "bill in cents\n"},
+		{name: "date", format: `{{date "2006-01-02" .CreatedAt}}`, want: "2024-01-31\n"},
+		{name: "unset", format: "", want: ""},
+		{name: "invalid", format: "{{.ID", wantErr: "parsing --format template"},
+		{name: "unknown func", format: "{{title .Type}}", wantErr: "parsing --format template"},
+		{name: "missing field", format: "{{.Owner}}", wantErr: "executing --format template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addFormatFlag(cmd)
+			require.NoError(t, cmd.Flags().Set("format", tt.format))
+
+			var out bytes.Buffer
+			tmpl, err := formatTemplate(cmd)
+			if err == nil && tmpl != nil {
+				err = writeTemplate(&out, tmpl, i)
+			}
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// cmd/tig/fsck.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"tig/internal/fsck"
+	"tig/internal/parcel"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var fsckCmd = &cobra.Command{
+		Use:   "fsck",
+		Short: "Verify content storage integrity and reference counts",
+		Long: `Check that every stored object is intact and that its reference count
+matches the changesets, gated changes and file states that use it. The
+.tig sentinel is checked for signs that the directory was edited by hand
+or restored in part; other commands refuse to run until this is fixed.
+
+With --repair, reference counts are rebuilt from live references,
+unreferenced content is removed and the repository's current state is
+sealed as intact.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repair, _ := cmd.Flags().GetBool("repair")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := openParcel(parcel.NewForRepair)
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			problems, err := p.Integrity()
+			if err != nil {
+				return err
+			}
+			report, err := fsck.New(p.DB, p.Safe).Run(repair)
+			if err != nil {
+				return err
+			}
+			if repair {
+				if err := p.Reseal(); err != nil {
+					return err
+				}
+			}
+			for _, problem := range problems {
+				report.Issues = append(report.Issues, fsck.Issue{Kind: fsck.KindTampered, Detail: problem, Repaired: repair})
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				fmt.Printf("Checked %d objects, %d references\n", report.Objects, report.References)
+				for _, issue := range report.Issues {
+					status := ""
+					if issue.Repaired {
+						status = " (repaired)"
+					}
+					fmt.Printf("%-8s %-12s  %s%s\n", issue.Kind, shortHash(issue.Hash), issue.Detail, status)
+				}
+			}
+
+			unrepaired := 0
+			for _, issue := range report.Issues {
+				if !issue.Repaired {
+					unrepaired++
+				}
+			}
+			if unrepaired > 0 {
+				if !repair {
+					return fmt.Errorf("found %d problems (run 'tig fsck --repair' to fix them)", unrepaired)
+				}
+				return fmt.Errorf("%d problems could not be repaired", unrepaired)
+			}
+			if !asJSON {
+				if len(report.Issues) > 0 {
+					fmt.Printf("Repaired %d problems\n", len(report.Issues))
+				} else {
+					fmt.Println("No problems found")
+				}
+			}
+			return nil
+		},
+	}
+
+	fsckCmd.Flags().Bool("repair", false, "Rebuild reference counts and remove unreferenced content")
+	fsckCmd.Flags().Bool("json", false, "Output the report as JSON")
+	rootCmd.AddCommand(fsckCmd)
+}
+
+// shortHash abbreviates a content hash for display
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,223 @@
+// cmd/tig/history.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"tig/internal/parcel"
+	"tig/internal/query"
+	"tig/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var historyCmd = &cobra.Command{
+		Use:   "history [<intent|stream> <id>]",
+		Short: "Show every recorded change to an intent or stream",
+		Long: `Show the mutation log of an intent or stream: when it was created,
+updated or deleted and which fields each change touched.
+
+Use --since to limit the log to recent changes, e.g. "what changed in this
+stream's configuration last week", and --at to print the entity as it was
+at a point in time. --porcelain writes the log as stable tab-separated
+records for scripts. --project shows the log of a project defined in the
+repo config instead: the changes to every intent tagged with it.
+
+Times are durations before now, such as 7d or 12h, or dates such as
+2024-01-31 or 2024-01-31T15:04:05Z.`,
+		Example: `  tig history stream main --since 7d
+  tig history intent 3f2a
+  tig history stream release --at 2024-01-31 --json
+  tig history --project billing --since 30d`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if project, _ := cmd.Flags().GetString("project"); project != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sinceRef, _ := cmd.Flags().GetString("since")
+			atRef, _ := cmd.Flags().GetString("at")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			var since, at time.Time
+			var err error
+			if sinceRef != "" {
+				if since, err = parseTimeRef(sinceRef); err != nil {
+					return &usageError{err}
+				}
+			}
+			if atRef != "" {
+				if at, err = parseTimeRef(atRef); err != nil {
+					return &usageError{err}
+				}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			project, _ := cmd.Flags().GetString("project")
+			var entries []storage.AuditEntry
+			var subject string
+			if project != "" {
+				if entries, err = p.ProjectHistory(project); err != nil {
+					return err
+				}
+				subject = "project " + project
+			} else {
+				entity, id, err := resolveEntity(p, args[0], args[1])
+				if err != nil {
+					return err
+				}
+				mutations, err := storage.History(p.DB, entity, id)
+				if err != nil {
+					return err
+				}
+				if len(mutations) == 0 {
+					return fmt.Errorf("%w: no history for %s %s", storage.ErrNotFound, entity, id)
+				}
+				if atRef != "" {
+					return printHistoryAt(mutations, entity, id, at)
+				}
+				if entries, err = storage.Audit(mutations); err != nil {
+					return err
+				}
+				subject = entity + " " + id
+			}
+			if !since.IsZero() {
+				n := 0
+				for n < len(entries) && !entries[n].Time.After(since) {
+					n++
+				}
+				entries = entries[n:]
+			}
+
+			if porcelain, err := porcelainVersion(cmd); err != nil {
+				return err
+			} else if porcelain != "" {
+				printHistoryPorcelain(entries)
+				return nil
+			}
+
+			if asJSON {
+				if entries == nil {
+					entries = []storage.AuditEntry{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(entries)
+			}
+
+			if len(entries) == 0 && since.IsZero() {
+				fmt.Printf("No changes to %s\n", subject)
+				return nil
+			}
+			if len(entries) == 0 {
+				fmt.Printf("No changes to %s since %s\n", subject, since.Format(time.RFC3339))
+				return nil
+			}
+			for _, e := range entries {
+				when := e.Time.Local().Format("2006-01-02 15:04:05")
+				if project != "" {
+					fmt.Printf("%s  %s %s  %s\n", when, e.Entity, shortIntentID(e.ID), e.Kind)
+				} else {
+					fmt.Printf("%s  %s\n", when, e.Kind)
+				}
+				if e.Kind == storage.MutationUpdate {
+					for _, c := range e.Changes {
+						fmt.Printf("    %s: %s → %s\n", c.Field, historyValue(c.Before), historyValue(c.After))
+					}
+				}
+			}
+			return nil
+		},
+	}
+
+	historyCmd.Flags().String("since", "", "Only show changes after this time")
+	historyCmd.Flags().String("at", "", "Print the entity as it was at this time")
+	historyCmd.Flags().Bool("json", false, "Output as JSON")
+	historyCmd.Flags().String("project", "", "Show the log of a project defined in the repo config")
+	addPorcelainFlag(historyCmd)
+	historyCmd.MarkFlagsMutuallyExclusive("since", "at")
+	historyCmd.MarkFlagsMutuallyExclusive("porcelain", "at")
+	// Porcelain v1 history records carry no intent ID
+	historyCmd.MarkFlagsMutuallyExclusive("porcelain", "project")
+	historyCmd.MarkFlagsMutuallyExclusive("project", "at")
+	rootCmd.AddCommand(historyCmd)
+}
+
+// printHistoryAt prints the state an entity was left in by the last of
+// its mutations at or before at
+func printHistoryAt(mutations []storage.Mutation, entity, id string, at time.Time) error {
+	var state json.RawMessage
+	for _, m := range mutations {
+		if m.Time.After(at) {
+			break
+		}
+		state = m.State
+	}
+	if len(state) == 0 {
+		return fmt.Errorf("%w: %s %s did not exist at %s", storage.ErrNotFound, entity, id, at.Format(time.RFC3339))
+	}
+	var v any
+	if err := json.Unmarshal(state, &v); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// resolveEntity maps a kind and ID or prefix to the entity's store prefix
+// and full ID. Deleted entities cannot be resolved, so an unresolved ID is
+// looked up in the log as given.
+func resolveEntity(p *parcel.Parcel, kind, ref string) (string, string, error) {
+	switch kind {
+	case "intent":
+		if i, err := p.ResolveIntent(ref); err == nil {
+			return kind, i.ID, nil
+		}
+	case "stream":
+		if s, err := p.ResolveStream(ref); err == nil {
+			return kind, s.ID, nil
+		}
+	default:
+		return "", "", &usageError{fmt.Errorf("unknown entity %q: expected intent or stream", kind)}
+	}
+	return kind, ref, nil
+}
+
+// parseTimeRef parses a duration before now, e.g. 7d, or a date
+func parseTimeRef(ref string) (time.Time, error) {
+	if d, err := query.ParseDuration(ref); err == nil {
+		if d > 0 {
+			d = -d
+		}
+		return time.Now().Add(d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, ref, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use a duration like 7d or a date like 2024-01-31)", ref)
+}
+
+func historyValue(v any) string {
+	if v == nil {
+		return "-"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(string(data))
+}
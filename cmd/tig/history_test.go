@@ -0,0 +1,24 @@
+// cmd/tig/history_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryArgs(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"history"})
+	require.NoError(t, err)
+	require.Equal(t, "history", cmd.Name())
+
+	// An entity and ID, or a project and nothing else
+	assert.NoError(t, cmd.Args(cmd, []string{"intent", "3f2a"}))
+	assert.Error(t, cmd.Args(cmd, nil))
+
+	require.NoError(t, cmd.Flags().Set("project", "billing"))
+	t.Cleanup(func() { cmd.Flags().Set("project", "") })
+	assert.NoError(t, cmd.Args(cmd, nil))
+	assert.Error(t, cmd.Args(cmd, []string{"intent", "3f2a"}))
+}
@@ -0,0 +1,223 @@
+// cmd/tig/import.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"tig/internal/importer"
+	"tig/internal/parcel"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var importSnapshotsCmd = &cobra.Command{
+		Use:   "import-snapshots <dir>",
+		Short: "Import a series of directory snapshots as history",
+		Long: `Import a project that was never under version control from copies of it
+taken over time, such as dated backup folders. Each subdirectory of <dir>
+is one snapshot; they are imported in name order, or by modification time
+with --order mtime.
+
+Each snapshot becomes a changeset against the previous one, with an
+intent named after the snapshot, dated by the newest file it contains.
+Snapshots identical to their predecessor are skipped. Afterwards the last
+snapshot is written into the working tree, which must be clean; use
+--no-checkout to only record the history.`,
+		Example: `  tig import-snapshots ../backups
+  tig import-snapshots ../backups --order mtime --stream main
+  tig import-snapshots ../backups --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			order, _ := cmd.Flags().GetString("order")
+			intentType, _ := cmd.Flags().GetString("type")
+			streamRef, _ := cmd.Flags().GetString("stream")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			noCheckout, _ := cmd.Flags().GetBool("no-checkout")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			if order != parcel.SnapshotsByName && order != parcel.SnapshotsByMTime {
+				return &usageError{fmt.Errorf("invalid --order %q: use %s or %s", order, parcel.SnapshotsByName, parcel.SnapshotsByMTime)}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			opts := parcel.SnapshotOptions{
+				Order:    order,
+				Type:     intentType,
+				DryRun:   dryRun,
+				Checkout: !noCheckout,
+			}
+			if streamRef != "" {
+				s, err := p.ResolveStream(streamRef)
+				if err != nil {
+					return err
+				}
+				opts.StreamID = s.ID
+			}
+
+			progress := func(s parcel.ImportedSnapshot) {
+				if asJSON {
+					return
+				}
+				summary := fmt.Sprintf("+%d ~%d -%d", s.Added, s.Modified, s.Deleted)
+				switch {
+				case s.Added+s.Modified+s.Deleted == 0:
+					summary = "unchanged, skipped"
+				case s.IntentID != "":
+					summary += "  intent " + s.IntentID[:8]
+				}
+				fmt.Printf("%-24s %s  %s\n", s.Name, s.Time.Format("2006-01-02 15:04"), summary)
+			}
+			imported, err := p.ImportSnapshots(args[0], opts, progress)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(imported)
+			}
+			if dryRun {
+				fmt.Printf("Dry run: %d snapshot(s) compared, nothing recorded\n", len(imported))
+			} else {
+				fmt.Printf("Imported %d snapshot(s)\n", len(imported))
+			}
+			return nil
+		},
+	}
+
+	importSnapshotsCmd.Flags().String("order", parcel.SnapshotsByName, "Order snapshots by name or mtime")
+	importSnapshotsCmd.Flags().StringP("type", "t", "import", "Type of the generated intents")
+	importSnapshotsCmd.Flags().StringP("stream", "s", "", "Add the generated intents to this stream")
+	importSnapshotsCmd.Flags().Bool("no-checkout", false, "Do not write the last snapshot into the working tree")
+	importSnapshotsCmd.Flags().Bool("json", false, "Output the imported snapshots as JSON")
+	supportDryRun(importSnapshotsCmd)
+	rootCmd.AddCommand(importSnapshotsCmd)
+
+	var importCmd = &cobra.Command{
+		Use:   "import",
+		Short: "Import history from another version control system",
+		Long: `Import the history of a Mercurial repository or a Subversion dump. Each
+revision becomes a changeset with an intent carrying its message, author
+and date, and each branch becomes a stream of the same name, created if
+needed. Only the default branch (hg default, svn trunk) updates the
+tracked files; other branches are recorded as history against their own
+files.
+
+Imports are resumable: progress is checkpointed with every revision, so
+running the same import again after an interruption, or after the source
+gained revisions, continues where the last run stopped.`,
+	}
+
+	var hgCmd = &cobra.Command{
+		Use:   "hg <repository>",
+		Short: "Import a Mercurial repository",
+		Long: `Import a local Mercurial repository, reading its changesets with hg log
+and file contents with hg cat. The hg command must be installed.`,
+		Example: `  tig import hg ../legacy-hg
+  tig import hg ../legacy-hg --stream main`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd, importer.NewHgRepo(args[0]))
+		},
+	}
+
+	var svnCmd = &cobra.Command{
+		Use:   "svn <dumpfile>",
+		Short: "Import a Subversion dump",
+		Long: `Import a dump written by svnadmin dump or svnrdump dump, without --deltas.
+Files under trunk/ and branches/<name>/ are imported onto trunk and the
+named branches; tags/ is skipped. A repository without a trunk directory
+is imported onto trunk as a whole.`,
+		Example: `  svnadmin dump /srv/svn/project > project.dump
+  tig import svn project.dump`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd, &importer.SVNDump{Path: args[0]})
+		},
+	}
+
+	for _, c := range []*cobra.Command{hgCmd, svnCmd} {
+		c.Flags().StringP("type", "t", "import", "Type of the generated intents")
+		c.Flags().StringP("stream", "s", "", "Stream for the default branch instead of one named after it")
+		c.Flags().Bool("no-checkout", false, "Do not write the default branch into the working tree")
+		c.Flags().Bool("json", false, "Output the import summary as JSON")
+		importCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(importCmd)
+}
+
+// runImport imports src into the repository, printing each revision
+func runImport(cmd *cobra.Command, src importer.Source) error {
+	intentType, _ := cmd.Flags().GetString("type")
+	streamRef, _ := cmd.Flags().GetString("stream")
+	noCheckout, _ := cmd.Flags().GetBool("no-checkout")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	p, err := initParcel()
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	opts := importer.Options{
+		Type:     intentType,
+		Checkout: !noCheckout,
+		Progress: func(pr importer.Progress) {
+			if asJSON {
+				return
+			}
+			rev := pr.Revision
+			summary := fmt.Sprintf("%d file(s)", len(rev.Changes))
+			if pr.Skipped {
+				summary = "no file changes, skipped"
+			} else if pr.IntentID != "" {
+				summary += "  intent " + pr.IntentID[:8]
+			}
+			id := rev.ID
+			if len(id) > 12 {
+				id = id[:12]
+			}
+			fmt.Printf("%5d  %-12s %-16s %s  %s\n", pr.Imported, id, rev.Branch, rev.Time.Format("2006-01-02 15:04"), summary)
+		},
+	}
+	if streamRef != "" {
+		s, err := p.ResolveStream(streamRef)
+		if err != nil {
+			return err
+		}
+		opts.StreamID = s.ID
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	result, err := importer.Run(ctx, p, src, opts)
+	if err != nil {
+		if result != nil && result.Imported > 0 {
+			fmt.Fprintf(os.Stderr, "Imported %d revision(s) before stopping; run the import again to resume\n", result.Imported)
+		}
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	if result.Resumed != "" {
+		fmt.Printf("Resumed after revision %s\n", result.Resumed)
+	}
+	fmt.Printf("Imported %d revision(s) into %d stream(s)\n", result.Imported, len(result.Streams))
+	return nil
+}
@@ -0,0 +1,133 @@
+// cmd/tig/incident.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"tig/internal/incident"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var incidentCmd = &cobra.Command{
+		Use:   "incident",
+		Short: "Link incidents and defects to the intents that caused them",
+		Long: `Record which intents caused an incident or defect, by the incident's ID in
+your incident tracker. Links feed the defect density signal of risk
+scoring, so later changes to the same files score higher, and tig report
+hotspots ranks the paths whose changes caused the most incidents.
+
+Incident trackers can link causes by POSTing to
+/api/incidents/<incident-id>/causes on tig serve.`,
+	}
+
+	var linkCmd = &cobra.Command{
+		Use:   "link <incident-id> --caused-by <intent>",
+		Short: "Record the intent that caused an incident",
+		Example: `  tig incident link INC-1042 --caused-by 3f2a
+  tig incident link INC-1042 --caused-by 3f2a --note "nil map in retry path"
+  tig incident link INC-1042 --caused-by 3f2a --remove`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			intentRef, _ := cmd.Flags().GetString("caused-by")
+			note, _ := cmd.Flags().GetString("note")
+			by, _ := cmd.Flags().GetString("by")
+			remove, _ := cmd.Flags().GetBool("remove")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			if intentRef == "" {
+				return &usageError{fmt.Errorf("--caused-by is required")}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			if remove {
+				if err := p.UnlinkIncident(args[0], intentRef); err != nil {
+					return err
+				}
+				fmt.Printf("Unlinked %s from intent %s\n", args[0], intentRef)
+				return nil
+			}
+			l, err := p.LinkIncident(args[0], intentRef, note, by)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(l)
+			}
+			fmt.Printf("Linked %s to intent %s as its cause\n", l.IncidentID, shortIntentID(l.IntentID))
+			return nil
+		},
+	}
+	linkCmd.Flags().String("caused-by", "", "Intent that caused the incident (ID or prefix)")
+	linkCmd.Flags().String("note", "", "How the intent caused the incident")
+	linkCmd.Flags().String("by", "", "Who made the link (default: the configured author)")
+	linkCmd.Flags().Bool("remove", false, "Remove the link instead")
+	linkCmd.Flags().Bool("json", false, "Output the link as JSON")
+	linkCmd.RegisterFlagCompletionFunc("caused-by", completeIntents)
+
+	var listCmd = &cobra.Command{
+		Use:   "list [incident-id]",
+		Short: "List incidents and their causal intents, newest link first",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+			var incidentID string
+			if len(args) == 1 {
+				incidentID = args[0]
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			links, err := p.Incidents(incidentID)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				if links == nil {
+					links = []incident.Link{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(links)
+			}
+			if len(links) == 0 {
+				fmt.Println("No incidents linked")
+				return nil
+			}
+			for _, l := range links {
+				line := fmt.Sprintf("%s  %-12s caused by %s", l.LinkedAt.Format(time.RFC3339), l.IncidentID, shortIntentID(l.IntentID))
+				if l.Note != "" {
+					line += "  " + l.Note
+				}
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().Bool("json", false, "Output links as JSON")
+
+	incidentCmd.AddCommand(linkCmd, listCmd)
+	rootCmd.AddCommand(incidentCmd)
+}
+
+// shortIntentID abbreviates an intent ID for display
+func shortIntentID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
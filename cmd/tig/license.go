@@ -0,0 +1,166 @@
+// cmd/tig/license.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tig/internal/config"
+	"tig/internal/license"
+	"tig/internal/remote"
+
+	"github.com/spf13/cobra"
+)
+
+// adminLicenseCommand returns the admin command group for the license
+// enabling enterprise server features
+func adminLicenseCommand() *cobra.Command {
+	var licenseCmd = &cobra.Command{
+		Use:   "license",
+		Short: "Inspect and install the server license",
+		Long: `Enterprise builds of tig serve enable feature groups (` + strings.Join(license.AllFeatures, ", ") + `)
+according to a signed license file, verified offline against keys compiled
+into the binary. The license is read from .tig/license.json unless the
+server config sets license.path. Other builds report the license but
+enable every feature group.`,
+	}
+
+	var statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Show the license and the feature groups it enables",
+		Example: `  tig admin license status
+  tig admin license status --server http://localhost:8080 --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverURL, _ := cmd.Flags().GetString("server")
+			configPath, _ := cmd.Flags().GetString("config")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			var status *license.Status
+			if serverURL != "" {
+				var err error
+				if status, err = remote.NewClient(serverURL).License(cmd.Context()); err != nil {
+					return err
+				}
+			} else {
+				path, err := licensePath(configPath)
+				if err != nil {
+					return err
+				}
+				keys, err := license.EmbeddedKeys()
+				if err != nil {
+					return err
+				}
+				s := license.Open(path, keys).Status()
+				status = &s
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(status)
+			}
+
+			if status.Path != "" {
+				fmt.Printf("License file: %s\n", status.Path)
+			}
+			switch {
+			case status.Error != "":
+				fmt.Printf("License:      invalid (%s)\n", status.Error)
+			case !status.Licensed:
+				fmt.Println("License:      none installed")
+			default:
+				fmt.Printf("Licensee:     %s\n", status.Licensee)
+				if status.ID != "" {
+					fmt.Printf("License ID:   %s\n", status.ID)
+				}
+				switch {
+				case status.ExpiresAt.IsZero():
+					fmt.Println("Expires:      never")
+				case status.Expired:
+					fmt.Printf("Expires:      %s (expired)\n", status.ExpiresAt.Format("2006-01-02"))
+				default:
+					fmt.Printf("Expires:      %s\n", status.ExpiresAt.Format("2006-01-02"))
+				}
+			}
+			if !status.Enforced {
+				fmt.Println("Enforced:     no, this build enables every feature group")
+			}
+			fmt.Println("\nFeatures:")
+			for _, f := range license.AllFeatures {
+				state := "disabled"
+				if status.Features[f] {
+					state = "enabled"
+				}
+				fmt.Printf("  %-12s %s\n", f, state)
+			}
+			return nil
+		},
+	}
+	statusCmd.Flags().String("server", "", "URL of a running tig serve to check")
+	statusCmd.Flags().String("config", "", "Path to the server config file naming the license")
+	statusCmd.Flags().Bool("json", false, "Output the status as JSON")
+
+	var installCmd = &cobra.Command{
+		Use:   "install <file>",
+		Short: "Verify a license file and install it for tig serve",
+		Long: `Verify a license file against the trusted keys and copy it to
+.tig/license.json, or to license.path of the server config given with
+--config. Restart tig serve to apply it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+
+			keys, err := license.EmbeddedKeys()
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			l, err := license.Parse(data, keys)
+			if err != nil {
+				return err
+			}
+
+			path, err := licensePath(configPath)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				return fmt.Errorf("installing license: %w", err)
+			}
+			fmt.Printf("Installed license for %s (%s) at %s\n", l.Licensee, strings.Join(l.Features, ", "), path)
+			return nil
+		},
+	}
+	installCmd.Flags().String("config", "", "Path to the server config file naming the license")
+
+	licenseCmd.AddCommand(statusCmd, installCmd)
+	return licenseCmd
+}
+
+// licensePath returns where tig serve reads the license from, given the
+// optional server config it runs with
+func licensePath(configPath string) (string, error) {
+	cfg := config.Default()
+	if configPath != "" {
+		var err error
+		if cfg, err = config.Load(configPath); err != nil {
+			return "", fmt.Errorf("loading config: %w", err)
+		}
+	}
+	p, err := initParcel()
+	if err != nil {
+		return "", err
+	}
+	defer p.Close()
+	return cfg.License.File(p.Root), nil
+}
@@ -0,0 +1,121 @@
+// cmd/tig/links.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"tig/internal/intent"
+	"tig/internal/remote"
+	"tig/internal/report"
+
+	"github.com/spf13/cobra"
+)
+
+// linkTimeout bounds fetching linked intents from other repositories
+const linkTimeout = 30 * time.Second
+
+// intentLinkCommands returns the intent subcommands for linking intents
+// across repositories
+func intentLinkCommands() []*cobra.Command {
+	var linkCmd = &cobra.Command{
+		Use:   "link <id> <repository URL>#<intent ID>...",
+		Short: "Link an intent to intents in other repositories",
+		Long: `Record that an intent belongs with intents in other repositories, such as
+the matching change to a service it calls. Each link names the linked
+repository by the URL of its tig serve API. Links are checked by
+fetching the linked intent unless --no-verify is given.`,
+		Example: `  tig intent link 3f2a https://tig.example.com/billing#9c1e
+  tig intent link 3f2a https://tig.example.com/billing#9c1e --remove`,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			remove, _ := cmd.Flags().GetBool("remove")
+			noVerify, _ := cmd.Flags().GetBool("no-verify")
+
+			links := make([]intent.Link, len(args)-1)
+			for n, arg := range args[1:] {
+				l, err := intent.ParseLink(arg)
+				if err != nil {
+					return &usageError{err}
+				}
+				links[n] = l
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			if remove {
+				i, err := p.LinkIntent(args[0], nil, links)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Removed %d link(s) from intent %s\n", len(links), i.ID)
+				return nil
+			}
+
+			if !noVerify {
+				ctx, cancel := context.WithTimeout(cmd.Context(), linkTimeout)
+				defer cancel()
+				for _, r := range remote.ResolveLinks(ctx, links) {
+					if r.Error != "" {
+						return fmt.Errorf("verifying link %s: %s", r.Link, r.Error)
+					}
+				}
+			}
+			i, err := p.LinkIntent(args[0], links, nil)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Intent %s has %d link(s)\n", i.ID, len(i.Links))
+			return nil
+		},
+	}
+	linkCmd.Flags().Bool("remove", false, "Remove the links instead of adding them")
+	linkCmd.Flags().Bool("no-verify", false, "Don't check that the linked intents exist")
+
+	var linksCmd = &cobra.Command{
+		Use:   "links <id>",
+		Short: "Show an intent's linked intents and their combined impact",
+		Long: `Fetch the intents an intent links to from their repositories and combine
+their declared impact: whether any is breaking, the components in their
+scope and the dependencies they affect. The report is Markdown, or JSON
+with --json. Links that cannot be fetched are listed as unresolved.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			i, err := p.ResolveIntent(args[0])
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), linkTimeout)
+			defer cancel()
+			r := report.BuildLinkedImpact(i, remote.ResolveLinks(ctx, i.Links))
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(r)
+			}
+			fmt.Print(r.Markdown())
+			return nil
+		},
+	}
+	linksCmd.Flags().Bool("json", false, "Output the combined impact as JSON")
+
+	return []*cobra.Command{linkCmd, linksCmd}
+}
@@ -2,13 +2,22 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"tig/internal/config"
+	"tig/internal/diff"
+	"tig/internal/highlight"
+	"tig/internal/intent"
 	"tig/internal/parcel"
+	"tig/internal/risk"
+	"tig/internal/safe"
+	"tig/internal/workspace"
 	"tig/shared/types"
 
 	"github.com/fatih/color"
@@ -23,7 +32,18 @@ var rootCmd = &cobra.Command{
 	Short: "Tig is a semantic version control system",
 	Long: `Tig is a next-generation version control system that tracks why code changes, 
 not just what changed. It provides semantic grouping of changes and intelligent 
-dependency tracking.`,
+dependency tracking.
+
+Exit codes:
+  0  success
+  1  error
+  2  invalid command, arguments or flags, or not a tig repository
+  3  conflict
+  4  not found
+  5  the working tree has uncommitted changes
+
+With --json, errors are written to stderr as
+{"error": {"code": 4, "kind": "not_found", "message": "..."}}.`,
 }
 
 var PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
@@ -40,21 +60,65 @@ func init() {
 	var initCmd = &cobra.Command{
 		Use:   "init",
 		Short: "Initialize a new Tig repository",
-		Long:  `Tig is a next-generation version control system that tracks what changed and why.`,
+		Long: `Tig is a next-generation version control system that tracks what changed and why.
+
+With --template, the new repository is seeded from a template so that
+new repositories start with the same setup. A template is a directory
+laid out like a repository: a path, the name of a directory in the
+templates folder of the user config directory, or the URL of a .tar.gz
+or .zip archive of one.
+
+Template files are copied into the working tree, never overwriting
+existing files. The template's .tig/config.json becomes the repository
+config, with the patterns in its .tigignore added to watch.exclude.
+Streams described in .tig/streams/*.json are created, and other files
+under .tig, such as hooks and policies, are copied as is.
+
+With --interactive, a wizard sets up the repository step by step: your
+author name, ignore patterns for dependency and build directories and
+large binaries found in the tree, a default stream, and gating of the
+existing files.`,
+		Example: `  tig init
+  tig init --interactive
+  tig init --template service
+  tig init --template https://example.com/templates/service.tar.gz`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			template, _ := cmd.Flags().GetString("template")
+			interactive, _ := cmd.Flags().GetBool("interactive")
+
 			dir, err := os.Getwd()
 			if err != nil {
 				return fmt.Errorf("getting current directory: %w", err)
 			}
 
+			if interactive {
+				return runInitWizard(cmd.InOrStdin(), cmd.OutOrStdout(), dir)
+			}
+			if template != "" {
+				logger, err := zap.NewDevelopment()
+				if err != nil {
+					return fmt.Errorf("initializing logger: %w", err)
+				}
+				if err := parcel.InitializeFromTemplate(cmd.Context(), dir, template, logger); err != nil {
+					return fmt.Errorf("initializing repository from template: %w", err)
+				}
+				registerRepo(dir)
+				fmt.Printf("Initialized Tig repository in %s from template %s\n", dir, template)
+				return nil
+			}
+
 			if err := parcel.Initialize(dir); err != nil {
 				return fmt.Errorf("initializing repository: %w", err)
 			}
+			registerRepo(dir)
 
 			fmt.Println("Initialized empty Tig repository in", dir)
 			return nil
 		},
 	}
+	initCmd.Flags().String("template", "", "Seed the repository from a template directory, name or archive URL")
+	initCmd.Flags().BoolP("interactive", "i", false, "Set up the repository step by step")
+	initCmd.MarkFlagsMutuallyExclusive("template", "interactive")
 
 	var gateCmd = &cobra.Command{
 		Use:   "gate [paths...]",
@@ -68,8 +132,37 @@ func init() {
 				return fmt.Errorf("initializing parcel: %w", err)
 			}
 
+			force, _ := cmd.Flags().GetBool("force")
+			if isDryRun(cmd) {
+				defer parcelInstance.Close()
+				plan, err := parcelInstance.PlanGate(args, parcel.GateOptions{
+					Force: force,
+					Warn: func(v parcel.GateViolation) {
+						color.Yellow("warning: %s", v)
+					},
+				})
+				if err != nil {
+					return fmt.Errorf("gating changes: %w", err)
+				}
+				report := &dryRunReport{Command: cmd.CommandPath(), BlobsStored: plan.Store}
+				for _, c := range plan.Changes {
+					report.FilesRead = append(report.FilesRead, c.Path)
+					report.KeysWritten = append(report.KeysWritten, workspace.GatedKey(c.Path), safe.MetaKey(c.NewHash))
+				}
+				return report.print(wantsJSON(cmd))
+			}
+
 			// Gate the specified paths
-			if err := parcelInstance.Gate(args); err != nil {
+			var stats safe.StoreStats
+			start := time.Now()
+			err = parcelInstance.GateWith(args, parcel.GateOptions{
+				Force: force,
+				Warn: func(v parcel.GateViolation) {
+					color.Yellow("warning: %s", v)
+				},
+				Stats: func(s safe.StoreStats) { stats = s },
+			})
+			if err != nil {
 				if parcelInstance.DB != nil {
 					parcelInstance.DB.Close()
 				}
@@ -84,6 +177,11 @@ func init() {
 			}
 
 			fmt.Println("Changes gated successfully")
+			if stats.Items > 0 {
+				fmt.Printf("%d file(s), %s read, %s stored (%d new, %d compressed, %.0f%% saved) in %s\n",
+					stats.Items, formatBytes(stats.LogicalBytes), formatBytes(stats.StoredBytes),
+					stats.New, stats.Compressed, 100*stats.Saved(), time.Since(start).Round(time.Millisecond))
+			}
 			return nil
 		},
 	}
@@ -105,6 +203,33 @@ func init() {
 			}
 			defer parcelInstance.DB.Close()
 
+			if isDryRun(cmd) {
+				if patch, _ := cmd.Flags().GetBool("patch"); patch {
+					return &usageError{fmt.Errorf("-p cannot be combined with --dry-run")}
+				}
+				gated, err := parcelInstance.PlanUngate(args)
+				if err != nil {
+					return err
+				}
+				report := &dryRunReport{Command: cmd.CommandPath(), Ungated: gated}
+				for _, path := range gated {
+					report.KeysDeleted = append(report.KeysDeleted, workspace.GatedKey(path))
+				}
+				return report.print(wantsJSON(cmd))
+			}
+
+			if patch, _ := cmd.Flags().GetBool("patch"); patch {
+				if len(args) != 1 {
+					return &usageError{fmt.Errorf("-p takes exactly one file")}
+				}
+				n, err := parcelInstance.UngateHunks(args[0], chooseHunks(os.Stdin, os.Stdout, parcelInstance.Highlighter, args[0], "Ungate"))
+				if err != nil {
+					return fmt.Errorf("ungating hunks: %w", err)
+				}
+				fmt.Printf("Ungated %d hunk(s) of %s\n", n, args[0])
+				return nil
+			}
+
 			// Ungate the specified paths
 			if err := parcelInstance.Workspace.Ungate(args); err != nil {
 				return fmt.Errorf("ungating files: %w", err)
@@ -126,7 +251,15 @@ func init() {
 			if err != nil {
 				return err
 			}
-			defer p.DB.Close()
+			defer p.Close()
+
+			if isDryRun(cmd) {
+				orphaned, err := p.PlanCleanup()
+				if err != nil {
+					return err
+				}
+				return (&dryRunReport{Command: cmd.CommandPath(), Ungated: orphaned}).print(wantsJSON(cmd))
+			}
 
 			// Perform cleanup
 			if err := p.Workspace.CleanupGatedChanges(); err != nil {
@@ -149,10 +282,54 @@ func init() {
 			}
 			defer ws.DB.Close()
 
-			intents, err := ws.ListIntents()
+			var intents []*intent.Intent
+			if project, _ := cmd.Flags().GetString("project"); project != "" {
+				if _, err := ws.Project(project); err != nil {
+					return err
+				}
+				intents, err = ws.ProjectIntents(project)
+			} else {
+				intents, err = ws.ListIntents()
+			}
 			if err != nil {
 				return fmt.Errorf("listing intents: %w", err)
 			}
+			if state, _ := cmd.Flags().GetString("state"); state != "" {
+				if !intent.ValidState(state) {
+					return &usageError{fmt.Errorf("unknown state %q; states are %s", state, strings.Join(intent.States, ", "))}
+				}
+				intents = intent.FilterState(intents, state)
+			}
+			// Riskiest first, for reviewers deciding where to look
+			var scores map[string]*risk.Score
+			if withRisk, _ := cmd.Flags().GetBool("risk"); withRisk {
+				if scores, err = riskScores(ws, intents); err != nil {
+					return err
+				}
+				sort.SliceStable(intents, func(x, y int) bool {
+					return scores[intents[x].ID].Score > scores[intents[y].ID].Score
+				})
+			}
+
+			if porcelain, err := porcelainVersion(cmd); err != nil {
+				return err
+			} else if porcelain != "" {
+				printIntentsPorcelain(intents)
+				return nil
+			}
+
+			tmpl, err := formatTemplate(cmd)
+			if err != nil {
+				return err
+			}
+			if tmpl != nil {
+				for _, i := range intents {
+					if err := printTemplate(tmpl, i); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
 
 			if len(intents) == 0 {
 				fmt.Println("No intents found")
@@ -161,11 +338,19 @@ func init() {
 
 			fmt.Println("\nIntents:")
 			for _, i := range intents {
-				fmt.Printf("%s  %s  %s  [%s]\n",
+				// The risk score follows the usual columns, so the layout
+				// stays the same with or without --risk
+				var riskColumn string
+				if s := scores[i.ID]; s != nil {
+					riskColumn = fmt.Sprintf("  risk %d (%s)", s.Score, s.Level)
+				}
+				fmt.Printf("%s  %s  %s  [%s]%s%s\n",
 					i.ID[:8],
 					i.CreatedAt.Format(time.RFC3339),
 					i.Type,
 					i.Description,
+					formatExtensions(i),
+					riskColumn,
 				)
 			}
 
@@ -220,6 +405,26 @@ func init() {
 				return fmt.Errorf("listing streams: %w", err)
 			}
 
+			if porcelain, err := porcelainVersion(cmd); err != nil {
+				return err
+			} else if porcelain != "" {
+				printStreamsPorcelain(streams)
+				return nil
+			}
+
+			tmpl, err := formatTemplate(cmd)
+			if err != nil {
+				return err
+			}
+			if tmpl != nil {
+				for _, s := range streams {
+					if err := printTemplate(tmpl, s); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
 			if len(streams) == 0 {
 				fmt.Println("No streams found")
 				return nil
@@ -244,6 +449,70 @@ func init() {
 		},
 	}
 
+	var showStreamCmd = &cobra.Command{
+		Use:               "show <id>",
+		Short:             "Show details of a stream",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeStreams,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tmpl, err := formatTemplate(cmd)
+			if err != nil {
+				return err
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			s, err := p.ResolveStream(args[0])
+			if err != nil {
+				return err
+			}
+
+			if tmpl != nil {
+				return printTemplate(tmpl, s)
+			}
+
+			status := "inactive"
+			if s.State.Active {
+				status = "active"
+			}
+			fmt.Printf("Stream %s\n", s.ID)
+			fmt.Printf("Name:     %s\n", s.Name)
+			fmt.Printf("Type:     %s\n", s.Type)
+			fmt.Printf("Status:   %s (%s)\n", s.State.Status, status)
+			fmt.Printf("Created:  %s\n", s.CreatedAt.Format(time.RFC3339))
+			fmt.Printf("Updated:  %s\n", s.UpdatedAt.Format(time.RFC3339))
+			fmt.Printf("Intents:  %d\n", len(s.State.Intents))
+			for _, id := range s.State.Intents {
+				fmt.Printf("  %s\n", id)
+			}
+			if group := s.Config.Protection.ReviewerGroup; group != "" {
+				fmt.Printf("Reviewers: %d from group %s\n", s.Config.Protection.RequiredReviewers, group)
+			}
+			if schedules := s.Config.Protection.Schedules; len(schedules) > 0 {
+				now := time.Now()
+				fmt.Printf("Schedules:\n")
+				for _, sc := range schedules {
+					state := ""
+					if until := sc.Until(now); !until.IsZero() {
+						state = fmt.Sprintf("  (in force until %s)", until.Format("Mon Jan 2 15:04 MST"))
+					}
+					fmt.Printf("  %s: %s - %s%s\n", sc.Name, sc.Start, sc.End, state)
+				}
+				if len(s.Config.Protection.OverrideBy) > 0 {
+					fmt.Printf("Override: %s\n", strings.Join(s.Config.Protection.OverrideBy, ", "))
+				}
+			}
+			if drop := s.Config.Protection.MaxCoverageDrop; drop != nil {
+				fmt.Printf("Coverage: may drop at most %.1f points\n", *drop)
+			}
+			return nil
+		},
+	}
+
 	var addIntentCmd = &cobra.Command{
 		Use:   "add-intent",
 		Short: "Add an intent to a stream",
@@ -325,23 +594,59 @@ func init() {
 			if err != nil {
 				return err
 			}
-			// Ensure we close the DB properly
-			defer func() {
-				if p != nil && p.DB != nil {
-					p.DB.Close()
-				}
-			}()
+			defer p.Close()
 
 			// Load gated changes
 			if err := p.Workspace.LoadGatedChanges(); err != nil {
 				return fmt.Errorf("loading gated changes: %w", err)
 			}
 
+			untrackedMode, _ := cmd.Flags().GetString("untracked")
+			switch untrackedMode {
+			case "all", "dirs", "no":
+			default:
+				return &usageError{fmt.Errorf("invalid --untracked %q: expected all, dirs or no", untrackedMode)}
+			}
+
+			var project *config.Project
+			if name, _ := cmd.Flags().GetString("project"); name != "" {
+				pr, err := p.Project(name)
+				if err != nil {
+					return err
+				}
+				project = &pr
+			}
+
+			porcelain, err := porcelainVersion(cmd)
+			if err != nil {
+				return err
+			}
+
+			if against, _ := cmd.Flags().GetString("against"); against != "" {
+				if porcelain != "" {
+					return &usageError{fmt.Errorf("--porcelain cannot be combined with --against")}
+				}
+				jsonOut, _ := cmd.Flags().GetBool("json")
+				return printStatusAgainst(p, against, project, jsonOut)
+			}
+
 			// Get status
 			changes, err := p.Workspace.Status()
 			if err != nil {
 				return fmt.Errorf("getting status: %w", err)
 			}
+			if project != nil {
+				changes = parcel.InProject(*project, changes)
+			}
+
+			if porcelain != "" {
+				conflicts, err := p.Conflicts()
+				if err != nil {
+					return fmt.Errorf("detecting conflicts: %w", err)
+				}
+				printStatusPorcelain(changes, conflicts, untrackedMode != "no")
+				return nil
+			}
 
 			// Group changes by type
 			var (
@@ -364,6 +669,10 @@ func init() {
 				}
 			}
 
+			if untrackedMode == "no" {
+				untracked = nil
+			}
+
 			// Use colors
 			green := color.New(color.FgGreen).SprintFunc()
 			red := color.New(color.FgRed).SprintFunc()
@@ -371,9 +680,15 @@ func init() {
 			blue := color.New(color.FgBlue).SprintFunc()
 
 			// Print summary header if there are changes
+			conflicts, err := p.Conflicts()
+			if err != nil {
+				return fmt.Errorf("detecting conflicts: %w", err)
+			}
+
 			totalChanges := len(gated) + len(modified) + len(untracked) + len(deleted)
 			if totalChanges == 0 {
 				fmt.Println("No changes detected (working tree clean)")
+				printConflicts(conflicts)
 				return nil
 			}
 
@@ -384,7 +699,7 @@ func init() {
 				fmt.Println("Changes ready for intent (gated):")
 				fmt.Println("  (use \"tig intent create <description>\" to create a new intent)")
 				for _, c := range gated {
-					fmt.Printf("\t%s %s\n", green("✓"), c.Path)
+					fmt.Printf("\t%s %s\n", statusMarker("✓", labelGated, green), c.Path)
 				}
 				fmt.Println()
 			}
@@ -394,7 +709,7 @@ func init() {
 				fmt.Println("Modified files:")
 				fmt.Println("  (use \"tig gate <file>...\" to include in next intent)")
 				for _, c := range modified {
-					fmt.Printf("\t%s %s\n", yellow("M"), c.Path)
+					fmt.Printf("\t%s %s\n", statusMarker("M", labelModified, yellow), c.Path)
 				}
 				fmt.Println()
 			}
@@ -403,8 +718,16 @@ func init() {
 			if len(untracked) > 0 {
 				fmt.Println("Untracked files:")
 				fmt.Println("  (use \"tig gate <file>...\" to include in next intent)")
-				for _, c := range untracked {
-					fmt.Printf("\t%s %s\n", blue("?"), c.Path)
+				entries, err := untrackedEntries(p, untracked, changes, untrackedMode == "dirs")
+				if err != nil {
+					return err
+				}
+				for _, e := range entries {
+					if e.Dir {
+						fmt.Printf("\t%s %s%c (%d files)\n", statusMarker("?", labelUntracked, blue), e.Path, filepath.Separator, e.Files)
+					} else {
+						fmt.Printf("\t%s %s\n", statusMarker("?", labelUntracked, blue), e.Path)
+					}
 				}
 				fmt.Println()
 			}
@@ -414,11 +737,12 @@ func init() {
 				fmt.Println("Deleted files:")
 				fmt.Println("  (use \"tig gate <file>...\" to include deletion in next intent)")
 				for _, c := range deleted {
-					fmt.Printf("\t%s %s\n", red("D"), c.Path)
+					fmt.Printf("\t%s %s\n", statusMarker("D", labelDeleted, red), c.Path)
 				}
 				fmt.Println()
 			}
 
+			printConflicts(conflicts)
 			return nil
 		},
 	}
@@ -438,6 +762,12 @@ func init() {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			description := args[0]
 			intentType, _ := cmd.Flags().GetString("type")
+			noAutoMerge, _ := cmd.Flags().GetBool("no-auto-merge")
+			scope, _ := cmd.Flags().GetStringSlice("scope")
+			breaking, _ := cmd.Flags().GetBool("breaking")
+			dependencies, _ := cmd.Flags().GetStringSlice("dependency")
+			fieldArgs, _ := cmd.Flags().GetStringArray("field")
+			dependsOn, _ := cmd.Flags().GetStringSlice("depends-on")
 
 			p, err := initParcel()
 			if err != nil {
@@ -445,25 +775,284 @@ func init() {
 			}
 			defer p.Close()
 
-			// Create changeset first
-			cs, err := p.Tracker.CreateChangeSet(description)
+			extensions, err := p.ParseIntentFields(fieldArgs)
 			if err != nil {
-				return fmt.Errorf("creating changeset: %w", err)
+				return err
 			}
 
-			// Create intent
-			intent, err := p.CreateIntent(description, intentType)
+			for n, ref := range dependsOn {
+				dep, err := p.ResolveIntent(ref)
+				if err != nil {
+					return err
+				}
+				dependsOn[n] = dep.ID
+			}
+
+			var streamID string
+			if ref, _ := cmd.Flags().GetString("stream"); ref != "" {
+				st, err := p.ResolveStream(ref)
+				if err != nil {
+					return err
+				}
+				streamID = st.ID
+			} else if noRoute, _ := cmd.Flags().GetBool("no-route"); !noRoute {
+				st, err := p.RouteGated()
+				if err != nil {
+					return err
+				}
+				if st != nil {
+					streamID = st.ID
+					fmt.Printf("Routing to stream %s\n", st.Name)
+				}
+			}
+
+			opts := parcel.CommitOptions{
+				Description: description,
+				Type:        intentType,
+				NoAutoMerge: noAutoMerge,
+				StreamID:    streamID,
+				Impact: intent.Impact{
+					Scope:        scope,
+					Breaking:     breaking,
+					Dependencies: dependencies,
+				},
+				Extensions: extensions,
+				DependsOn:  dependsOn,
+			}
+			if err := reviewDescription(cmd, p, &opts); err != nil {
+				return fmt.Errorf("creating intent: %w", err)
+			}
+
+			if isDryRun(cmd) {
+				plan, err := p.PlanCommitIntent(opts)
+				if err != nil {
+					return fmt.Errorf("creating intent: %w", err)
+				}
+				ids := strings.NewReplacer(plan.Intent.ID, "<new intent>", plan.ChangeSet.ID, "<new changeset>")
+				report := &dryRunReport{Command: cmd.CommandPath()}
+				for _, w := range plan.Writes {
+					if w.Delete {
+						report.KeysDeleted = append(report.KeysDeleted, stableKey(w.Key, ids))
+					} else {
+						report.KeysWritten = append(report.KeysWritten, stableKey(w.Key, ids))
+					}
+				}
+				return report.print(wantsJSON(cmd))
+			}
+
+			// The changeset, intent and stream membership commit together
+			created, cs, err := p.CommitIntent(opts)
 			if err != nil {
 				return fmt.Errorf("creating intent: %w", err)
 			}
 
-			// Update intent with changeset ID
-			intent.ChangeSetID = cs.ID
-			if err := p.UpdateIntent(intent); err != nil {
-				return fmt.Errorf("updating intent: %w", err)
+			fmt.Printf("Created intent %s with %d changes\n", created.ID, len(cs.Changes))
+			return nil
+		},
+	}
+
+	var cherryPickCmd = &cobra.Command{
+		Use:               "cherry-pick <id>",
+		Short:             "Copy an intent and its changeset onto another stream",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			streamRef, _ := cmd.Flags().GetString("stream")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			src, err := p.ResolveIntent(args[0])
+			if err != nil {
+				return err
+			}
+			st, err := p.ResolveStream(streamRef)
+			if err != nil {
+				return err
+			}
+
+			picked, err := p.CherryPick(src.ID, st.ID)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Cherry-picked intent %s onto stream %s as %s\n", src.ID, st.Name, picked.ID)
+			return nil
+		},
+	}
+
+	var setIntentCmd = &cobra.Command{
+		Use:   "set <id> [name=value...]",
+		Short: "Set repository-defined metadata fields of an intent",
+		Long: `Set or remove the extra metadata fields defined under "intent_fields" in
+.tig/config.json, such as a risk level or rollout plan. Values are
+checked against the field's type before the intent is saved.`,
+		Example: `  tig intent set 3f2a risk=high rollout="canary then 100%"
+  tig intent set 3f2a --unset rollout`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			unset, _ := cmd.Flags().GetStringSlice("unset")
+			if len(args) == 1 && len(unset) == 0 {
+				return &usageError{fmt.Errorf("nothing to set: give name=value pairs or --unset")}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
 			}
+			defer p.Close()
 
-			fmt.Printf("Created intent %s with %d changes\n", intent.ID, len(cs.Changes))
+			values, err := p.ParseIntentFields(args[1:])
+			if err != nil {
+				return err
+			}
+			i, err := p.SetIntentFields(args[0], values, unset)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Updated intent %s\n", i.ID)
+			return nil
+		},
+	}
+
+	var stateIntentCmd = &cobra.Command{
+		Use:   "state <id> <state>",
+		Short: "Move an intent to another lifecycle state",
+		Long: `Move an intent between the lifecycle states draft, in-review, approved,
+landed and reverted. Only the transitions allowed by "workflow" in
+.tig/config.json are accepted; without one, intents go from draft to
+in-review to approved to landed, may step back one state before
+landing, and may be reverted once landed.
+
+Reviews move intents to in-review and approvals on to approved, and the
+merge queue marks intents landed, as far as the workflow allows.`,
+		Example: `  tig intent state 3f2a in-review
+  tig intent state 3f2a reverted --by release-bot`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			by, _ := cmd.Flags().GetString("by")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			i, err := p.SetIntentState(args[0], args[1], by)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Intent %s is %s\n", i.ID, i.CurrentState())
+			return nil
+		},
+	}
+
+	var showIntentCmd = &cobra.Command{
+		Use:               "show <id>",
+		Short:             "Show details of an intent",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tmpl, err := formatTemplate(cmd)
+			if err != nil {
+				return err
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			i, err := p.ResolveIntent(args[0])
+			if err != nil {
+				return err
+			}
+
+			if tmpl != nil {
+				return printTemplate(tmpl, i)
+			}
+
+			fmt.Printf("Intent %s\n", i.ID)
+			fmt.Printf("Type:        %s\n", i.Type)
+			fmt.Printf("State:       %s\n", i.CurrentState())
+			fmt.Printf("Description: %s\n", i.Description)
+			if i.Metadata.Author != "" {
+				fmt.Printf("Author:      %s\n", i.Metadata.Author)
+			}
+			fmt.Printf("Created:     %s\n", i.CreatedAt.Format(time.RFC3339))
+			if i.ChangeSetID != "" {
+				fmt.Printf("Changeset:   %s\n", i.ChangeSetID)
+			}
+			if i.Impact.Breaking {
+				fmt.Println("Breaking:    yes")
+			}
+			if len(i.Impact.Scope) > 0 {
+				fmt.Printf("Scope:       %s\n", strings.Join(i.Impact.Scope, ", "))
+			}
+			if len(i.Projects) > 0 {
+				fmt.Printf("Projects:    %s\n", strings.Join(i.Projects, ", "))
+			}
+			if len(i.Impact.Dependencies) > 0 {
+				fmt.Printf("Impacts:     %s\n", strings.Join(i.Impact.Dependencies, ", "))
+			}
+			if len(i.DependsOn) > 0 {
+				fmt.Printf("Depends on:  %s\n", strings.Join(i.DependsOn, ", "))
+			}
+			for _, l := range i.Links {
+				fmt.Printf("Linked:      %s\n", l)
+			}
+			if len(i.Metadata.Refs) > 0 {
+				fmt.Printf("Refs:        %s\n", strings.Join(i.Metadata.Refs, ", "))
+			}
+			if i.Coverage != nil {
+				fmt.Printf("Coverage:    %s\n", formatCoverage(i.Coverage))
+			}
+			// Scoring reads the repository's history; a failure there
+			// should not hide the rest of the intent
+			if score, err := p.IntentRisk(i); err != nil {
+				fmt.Println("Risk:        unavailable")
+			} else {
+				fmt.Printf("Risk:        %s\n", formatRisk(score))
+			}
+			if len(i.Deployed) > 0 {
+				fmt.Printf("Deployed to: %s\n", formatDeployed(i.Deployed))
+			}
+			incidents, err := p.CausedIncidents(i.ID)
+			if err != nil {
+				return err
+			}
+			if len(incidents) > 0 {
+				fmt.Printf("Incidents:   %s\n", strings.Join(incidents, ", "))
+			}
+			if len(i.Checks) > 0 {
+				fmt.Println("Checks:")
+				for _, c := range i.Checks {
+					line := fmt.Sprintf("  %s: %s", c.Name, c.Status)
+					if c.Tests != nil {
+						line += fmt.Sprintf(" (%s)", c.Tests)
+					}
+					if c.URL != "" {
+						line += "  " + c.URL
+					}
+					fmt.Println(line)
+					if c.Tests != nil {
+						for _, failure := range c.Tests.Failures {
+							fmt.Printf("    failed: %s\n", failure)
+						}
+					}
+				}
+			}
+			for _, name := range i.ExtensionNames() {
+				fmt.Printf("%-12s %v\n", name+":", i.Extensions[name])
+			}
 			return nil
 		},
 	}
@@ -481,6 +1070,9 @@ func init() {
 			if p.Tracker == nil {
 				return fmt.Errorf("tracker not initialized")
 			}
+			structural, _ := cmd.Flags().GetBool("structural")
+			outputs, _ := cmd.Flags().GetBool("notebook-outputs")
+			opts := diffOptions{structural: structural, notebookOutputs: outputs || p.Diff.NotebookOutputs}
 
 			// If no paths specified, get all changed files from status
 			if len(args) == 0 {
@@ -493,12 +1085,9 @@ func init() {
 					if change.Type == "delete" {
 						continue // Skip deleted files
 					}
-					result, err := p.Tracker.ShowFileDiff(change.Path)
-					if err != nil {
+					if err := showFileDiff(p, change.Path, opts); err != nil {
 						return fmt.Errorf("showing diff for %s: %w", change.Path, err)
 					}
-					fmt.Printf("\ndiff --tig a/%s b/%s\n", change.Path, change.Path)
-					printColoredDiff(result.Format())
 				}
 				return nil
 			}
@@ -522,13 +1111,9 @@ func init() {
 				}
 
 				// Get and show diff
-				result, err := p.Tracker.ShowFileDiff(relPath)
-				if err != nil {
+				if err := showFileDiff(p, relPath, opts); err != nil {
 					return fmt.Errorf("showing diff for %s: %w", path, err)
 				}
-
-				fmt.Printf("\ndiff --tig a/%s b/%s\n", relPath, relPath)
-				printColoredDiff(result.Format())
 			}
 
 			return nil
@@ -538,12 +1123,39 @@ func init() {
 	// Add flags
 	createIntentCmd.Flags().StringP("description", "d", "", "Intent description")
 	createIntentCmd.Flags().StringP("type", "t", "feature", "Intent type (feature, fix, refactor, security, performance)")
+	createIntentCmd.Flags().Bool("no-auto-merge", false, "Never merge this intent automatically, even on AutoMerge streams")
+	createIntentCmd.Flags().StringP("stream", "s", "", "Stream to add the intent to (ID, prefix, or name); overrides the repo's routes")
+	createIntentCmd.Flags().Bool("no-route", false, "Do not add the intent to the stream the repo's routes pick")
+	createIntentCmd.Flags().StringSlice("scope", nil, "Components the intent affects (repeatable or comma-separated)")
+	createIntentCmd.Flags().Bool("breaking", false, "Mark the intent as a breaking change")
+	createIntentCmd.Flags().StringSlice("dependency", nil, "Dependencies the intent impacts (repeatable or comma-separated)")
+	createIntentCmd.Flags().StringSlice("depends-on", nil, "Intents that must land before this one (repeatable or comma-separated)")
+	setIntentCmd.Flags().StringSlice("unset", nil, "Remove a field from the intent (repeatable)")
+	stateIntentCmd.Flags().String("by", "", "Who made the change (default: the configured author)")
+	createIntentCmd.Flags().StringArray("field", nil, "Set a repository-defined intent field, as name=value (repeatable)")
+	createIntentCmd.Flags().Bool("suggest", false, "Ask the repository's description assistant to suggest a description from the gated diff")
+	createIntentCmd.Flags().Bool("no-lint", false, "Create the intent even if its description breaks the repository's rules")
+	createIntentCmd.RegisterFlagCompletionFunc("stream", completeStreams)
+
+	cherryPickCmd.Flags().StringP("stream", "s", "", "Target stream (ID, prefix, or name)")
+	cherryPickCmd.MarkFlagRequired("stream")
+	cherryPickCmd.RegisterFlagCompletionFunc("stream", completeStreams)
 	createIntentCmd.MarkFlagRequired("description")
 
 	createStreamCmd.Flags().StringP("name", "n", "", "Stream name")
 	createStreamCmd.Flags().StringP("type", "t", "feature", "Stream type (feature, release, hotfix)")
 	createStreamCmd.MarkFlagRequired("name")
 
+	addFormatFlag(listIntentsCmd)
+	addFormatFlag(showIntentCmd)
+	addFormatFlag(listStreamsCmd)
+	addFormatFlag(showStreamCmd)
+	for _, cmd := range []*cobra.Command{statusCmd, listIntentsCmd, listStreamsCmd} {
+		addPorcelainFlag(cmd)
+	}
+	listIntentsCmd.MarkFlagsMutuallyExclusive("porcelain", "format")
+	listStreamsCmd.MarkFlagsMutuallyExclusive("porcelain", "format")
+
 	addIntentCmd.Flags().StringP("stream", "s", "", "Stream ID")
 	addIntentCmd.Flags().StringP("intent", "i", "", "Intent ID")
 	addIntentCmd.MarkFlagRequired("stream")
@@ -552,32 +1164,58 @@ func init() {
 	// Add commands to root
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(intentCmd)
+	statusCmd.Flags().String("untracked", "dirs", "Show untracked files individually (all), collapsed into new directories (dirs) or not at all (no)")
+	statusCmd.Flags().String("against", "", "Compare the working tree with the tree of an intent or stream")
+	statusCmd.Flags().Bool("json", false, "Output changes against the baseline as JSON")
+	statusCmd.Flags().String("project", "", "Only show changes within a project defined in the repo config")
+	listIntentsCmd.Flags().String("project", "", "Only list intents tagged with a project defined in the repo config")
+	listIntentsCmd.Flags().Bool("risk", false, "Show each intent's risk score, riskiest first")
+	listIntentsCmd.Flags().String("state", "", "Only list intents in a lifecycle state (draft, in-review, approved, landed, reverted)")
 	rootCmd.AddCommand(statusCmd)
+	gateCmd.Flags().Bool("force", false, "Gate files the repository's gate rules refuse")
 	rootCmd.AddCommand(gateCmd)
+	ungateCmd.Flags().BoolP("patch", "p", false, "Choose hunks of one file to ungate interactively")
 	rootCmd.AddCommand(ungateCmd)
 	rootCmd.AddCommand(cleanupCmd)
 	rootCmd.AddCommand(streamCmd)
 	rootCmd.AddCommand(changeCmd)
+	diffCmd.Flags().Bool("structural", false, "Diff every JSON and YAML file by key instead of by line, not only those the repo config selects")
+	diffCmd.Flags().Bool("notebook-outputs", false, "Show output changes of Jupyter notebooks, not only their cell sources")
 	rootCmd.AddCommand(diffCmd)
 	rootCmd.AddCommand(untrackCmd)
 
 	// Add intent subcommands
 	intentCmd.AddCommand(createIntentCmd)
 	intentCmd.AddCommand(listIntentsCmd)
+	intentCmd.AddCommand(showIntentCmd)
+	intentCmd.AddCommand(cherryPickCmd)
+	intentCmd.AddCommand(setIntentCmd)
+	intentCmd.AddCommand(stateIntentCmd)
+	intentCmd.AddCommand(intentLinkCommands()...)
 	intentCmd.AddCommand(createIntentCmd)
 
 	// Add stream subcommands
 	streamCmd.AddCommand(createStreamCmd)
 	streamCmd.AddCommand(listStreamsCmd)
+	streamCmd.AddCommand(showStreamCmd)
 	streamCmd.AddCommand(addIntentCmd)
+	streamCmd.AddCommand(streamSwitchCommand())
+	streamCmd.AddCommand(streamMergeCommand())
 
 	// Add change tracking commands
 
 	changeCmd.AddCommand(untrackCmd)
 
+	supportDryRun(gateCmd, ungateCmd, cleanupCmd, createIntentCmd)
+
 }
 
 func initParcel() (*parcel.Parcel, error) {
+	return openParcel(parcel.New)
+}
+
+// openParcel opens the repository in the working directory with open
+func openParcel(open func(string, *zap.Logger) (*parcel.Parcel, error)) (*parcel.Parcel, error) {
 	// Get current working directory
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -591,19 +1229,156 @@ func initParcel() (*parcel.Parcel, error) {
 	}
 
 	// Initialize Parcel with logger
-	p, err := parcel.New(cwd, logger)
+	p, err := open(cwd, logger)
 	if err != nil {
 		return nil, fmt.Errorf("initializing parcel: %w", err)
 	}
+	noteRepoSize(p)
 
 	return p, nil
 }
 
-func printColoredDiff(diff string) {
+// printColoredDiff prints a formatted diff of path, coloring markers and,
+// unless highlighting is off, the code in each line by its language. In
+// accessible mode markers are spelled out instead.
+// untrackedEntries lists untracked files for tig status, collapsing
+// directories that hold nothing else when collapse is set
+func untrackedEntries(p *parcel.Parcel, untracked, changes []shared.Change, collapse bool) ([]workspace.UntrackedEntry, error) {
+	if !collapse {
+		entries := make([]workspace.UntrackedEntry, len(untracked))
+		for n, c := range untracked {
+			entries[n] = workspace.UntrackedEntry{Path: c.Path, Files: 1}
+		}
+		return entries, nil
+	}
+
+	states, err := p.FileStates()
+	if err != nil {
+		return nil, fmt.Errorf("reading tracked files: %w", err)
+	}
+	var paths, other []string
+	for path := range states {
+		other = append(other, filepath.FromSlash(path))
+	}
+	for _, c := range changes {
+		if c.Type == "untracked" && !c.Gated {
+			paths = append(paths, c.Path)
+		} else {
+			other = append(other, c.Path)
+		}
+	}
+	return workspace.CollapseUntracked(paths, other), nil
+}
+
+// printStatusAgainst lists how the working tree differs from the tree of
+// an intent or stream
+func printStatusAgainst(p *parcel.Parcel, ref string, project *config.Project, jsonOut bool) error {
+	b, err := p.ResolveBaseline(ref)
+	if err != nil {
+		return err
+	}
+	changes, err := p.StatusAgainst(b)
+	if err != nil {
+		return fmt.Errorf("getting status: %w", err)
+	}
+	if project != nil {
+		changes = parcel.InProject(*project, changes)
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Baseline *parcel.Baseline `json:"baseline"`
+			Changes  []shared.Change  `json:"changes"`
+		}{b, changes})
+	}
+
+	fmt.Printf("Changes against %s %s (changeset %s):\n\n", b.Kind, b.Name, shortHash(b.ChangeSetID))
+	if len(changes) == 0 {
+		fmt.Println("No changes (working tree matches)")
+		return nil
+	}
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	blue := color.New(color.FgBlue).SprintFunc()
+	for _, c := range changes {
+		var marker string
+		switch c.Type {
+		case "add":
+			marker = statusMarker("A", labelAdded, blue)
+		case "delete":
+			marker = statusMarker("D", labelDeleted, red)
+		default:
+			marker = statusMarker("M", labelModified, yellow)
+		}
+		if c.Gated {
+			marker += " " + statusMarker("✓", labelGated, green)
+		}
+		fmt.Printf("\t%s %s\n", marker, c.Path)
+	}
+	return nil
+}
+
+// diffOptions are the tig diff flags choosing how files are diffed
+type diffOptions struct {
+	structural      bool // diff every JSON and YAML file by key
+	notebookOutputs bool // include notebook output changes
+}
+
+// showFileDiff prints the diff of one working tree file. Images are
+// summarized, notebooks are diffed by cell, and JSON and YAML files are
+// diffed by key when asked to or the repo config selects them, falling
+// back to lines if they do not parse.
+func showFileDiff(p *parcel.Parcel, path string, opts diffOptions) error {
+	summary, err := p.ImageDiff(path)
+	if err != nil {
+		return err
+	}
+	if summary != nil {
+		fmt.Printf("\ndiff --tig a/%s b/%s\n", path, path)
+		printColoredDiff(p.Highlighter, "", summary.Format())
+		return nil
+	}
+
+	result, err := p.NotebookDiff(path, opts.notebookOutputs)
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		fmt.Printf("\ndiff --tig a/%s b/%s\n", path, path)
+		printColoredDiff(p.Highlighter, "", result.Format())
+		return nil
+	}
+
+	if opts.structural || p.Diff.IsStructural(path) {
+		changes, ok, err := p.KeyChanges(path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			fmt.Printf("\ndiff --tig --structural a/%s b/%s\n", path, path)
+			printColoredDiff(p.Highlighter, "", diff.FormatKeyChanges(changes))
+			return nil
+		}
+	}
+
+	result, err = p.Tracker.ShowFileDiff(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\ndiff --tig a/%s b/%s\n", path, path)
+	printColoredDiff(p.Highlighter, path, result.Format())
+	return nil
+}
+
+func printColoredDiff(h *highlight.Highlighter, path, diff string) {
 	// Create color objects
 	added := color.New(color.FgGreen)
 	removed := color.New(color.FgRed)
 	header := color.New(color.FgCyan)
+	highlighted := h.Enabled() && !color.NoColor && highlight.Language(path) != ""
 
 	// Process diff line by line
 	lines := strings.Split(diff, "\n")
@@ -613,9 +1388,21 @@ func printColoredDiff(diff string) {
 			continue
 		}
 
+		marker, code := line[:min(2, len(line))], line[min(2, len(line)):]
 		switch {
+		case accessible && (marker == "+ " || marker == "- " || marker == "  "):
+			label := map[string]string{"+ ": labelAdded, "- ": labelRemoved, "  ": labelContext}[marker]
+			fmt.Println(label + code)
 		case strings.HasPrefix(line, "@@"):
 			header.Println(line)
+		case highlighted && (marker == "+ " || marker == "- " || marker == "  "):
+			switch marker {
+			case "+ ":
+				marker = added.Sprint(marker)
+			case "- ":
+				marker = removed.Sprint(marker)
+			}
+			fmt.Println(marker + h.Terminal(path, code))
 		case strings.HasPrefix(line, "+"):
 			added.Println(line)
 		case strings.HasPrefix(line, "-"):
@@ -627,8 +1414,17 @@ func printColoredDiff(diff string) {
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	rootCmd.PersistentFlags().Bool("json", false, "Write errors as JSON objects, for scripts")
+	markUsageErrors(rootCmd)
+	applyMiddleware(rootCmd, commandMiddleware...)
+
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	recordUsage(cmd, time.Since(start), err)
+	if err != nil {
+		os.Exit(reportError(os.Stderr, cmd, err, wantsJSON(cmd)))
 	}
+	os.Exit(exitOK)
 }
@@ -0,0 +1,142 @@
+// cmd/tig/merge.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"tig/internal/merge"
+	"tig/internal/parcel"
+
+	"github.com/spf13/cobra"
+)
+
+// streamMergeCommand returns the stream subcommand that merges one stream
+// into another
+func streamMergeCommand() *cobra.Command {
+	var mergeCmd = &cobra.Command{
+		Use:   "merge <source> <target>",
+		Short: "Merge the changes of one stream into another",
+		Long: `Merge the changesets of the source stream's intents that the target
+lacks into the target. The working tree is switched to the target, so it
+must hold no uncommitted changes, and every file those changesets touch
+is merged three ways against the content they changed.
+
+Files that merge cleanly are written and gated. Files changed on both
+sides in ways that do not combine are written with conflict markers,
+left ungated, and set the target's status to "conflict"; resolve them,
+gate them and create an intent on the target to record the merge, which
+returns the target to "stable". tig exits with status 3 when conflicts
+remain.
+
+With --dry-run, the files are merged against the target's head without
+switching the working tree, and the files and keys the merge would
+write are listed; tig still exits with status 3 when the merge would
+conflict.`,
+		Example: `  tig stream merge feature-login main --dry-run
+  tig stream merge feature-login main
+  tig intent create "Merge feature-login" -d "Merge feature-login" --stream main`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeStreams,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			if isDryRun(cmd) {
+				return planStreamMerge(cmd, p, args[0], args[1])
+			}
+
+			m, err := p.MergeStream(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			conflicts := m.Conflicts()
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(m); err != nil {
+					return err
+				}
+			} else {
+				printMergedFiles(m.Files)
+				switch {
+				case len(conflicts) > 0:
+					fmt.Printf("Merged %s into %s with %d conflict(s); resolve and gate them, then create an intent on %s\n",
+						m.Source.Name, m.Target.Name, len(conflicts), m.Target.Name)
+				case len(m.Files) == 0:
+					fmt.Printf("%s already has the changes of %s\n", m.Target.Name, m.Source.Name)
+				default:
+					fmt.Printf("Merged %s into %s; %d file(s) gated, create an intent on %s to record the merge\n",
+						m.Source.Name, m.Target.Name, len(m.Files), m.Target.Name)
+				}
+			}
+
+			if len(conflicts) > 0 {
+				return &exitStatus{code: exitConflict}
+			}
+			return nil
+		},
+	}
+	mergeCmd.Flags().Bool("json", false, "Output the merge result as JSON")
+	supportDryRun(mergeCmd)
+	return mergeCmd
+}
+
+// planStreamMerge reports what merging source into target would change
+func planStreamMerge(cmd *cobra.Command, p *parcel.Parcel, source, target string) error {
+	plan, err := p.PlanMergeStream(source, target)
+	if err != nil {
+		return err
+	}
+	conflicts := plan.Conflicts()
+
+	report := &dryRunReport{Command: cmd.CommandPath()}
+	for _, f := range plan.Files {
+		switch {
+		case f.Outcome == merge.FileDeleted:
+			report.FilesRemoved = append(report.FilesRemoved, f.Path)
+		case f.Content != nil:
+			report.FilesWritten = append(report.FilesWritten, f.Path)
+		}
+	}
+	for _, w := range plan.Writes {
+		if w.Delete {
+			report.KeysDeleted = append(report.KeysDeleted, stableKey(w.Key, nil))
+		} else {
+			report.KeysWritten = append(report.KeysWritten, stableKey(w.Key, nil))
+		}
+	}
+
+	asJSON := wantsJSON(cmd)
+	if !asJSON {
+		printMergedFiles(plan.Files)
+		if len(conflicts) > 0 {
+			fmt.Printf("Merging %s into %s would leave %d conflict(s)\n", plan.Source.Name, plan.Target.Name, len(conflicts))
+		}
+	}
+	if err := report.print(asJSON); err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &exitStatus{code: exitConflict}
+	}
+	return nil
+}
+
+// printMergedFiles lists the outcome of each file of a stream merge
+func printMergedFiles(files []merge.FileResult) {
+	for _, f := range files {
+		line := fmt.Sprintf("\t%-9s %s", f.Outcome, f.Path)
+		if f.Reason != "" {
+			line += " (" + f.Reason + ")"
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,174 @@
+// cmd/tig/middleware.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"tig/internal/config"
+	"tig/internal/selfupdate"
+
+	"github.com/spf13/cobra"
+)
+
+// runE is the signature of a command's RunE
+type runE func(cmd *cobra.Command, args []string) error
+
+// middleware wraps the RunE of every command, e.g. to time it
+type middleware func(next runE) runE
+
+// commandMiddleware is applied to every command, outermost first
+var commandMiddleware = []middleware{recoverPanics, refuseDryRun, timeCommand, checkForUpdates}
+
+// updateCheckTimeout bounds the background release check
+const updateCheckTimeout = 3 * time.Second
+
+// updateCheckGrace is how long a finished command waits for a background
+// release check still in flight, so its result is remembered
+const updateCheckGrace = 250 * time.Millisecond
+
+func init() {
+	rootCmd.PersistentFlags().Bool("verbose", false, "Report how long the command took on stderr")
+}
+
+// applyMiddleware wraps the RunE of cmd and its subcommands
+func applyMiddleware(cmd *cobra.Command, mws ...middleware) {
+	if cmd.RunE != nil {
+		run := runE(cmd.RunE)
+		for n := len(mws) - 1; n >= 0; n-- {
+			run = mws[n](run)
+		}
+		cmd.RunE = run
+	}
+	for _, sub := range cmd.Commands() {
+		applyMiddleware(sub, mws...)
+	}
+}
+
+// recoverPanics turns a panic into an error and writes a crash report to
+// .tig/crash in the repository, or to the user cache directory outside
+// one
+func recoverPanics(next runE) runE {
+	return func(cmd *cobra.Command, args []string) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			path, writeErr := writeCrashReport(cmd, args, r, debug.Stack())
+			if writeErr != nil {
+				err = fmt.Errorf("tig crashed: %v (writing crash report: %v)", r, writeErr)
+				return
+			}
+			err = fmt.Errorf("tig crashed: %v\nA crash report was written to %s; please attach it when reporting the problem", r, path)
+		}()
+		return next(cmd, args)
+	}
+}
+
+// crashDir returns where crash reports are written
+func crashDir() (string, error) {
+	if cwd, err := os.Getwd(); err == nil {
+		if info, err := os.Stat(filepath.Join(cwd, ".tig")); err == nil && info.IsDir() {
+			return filepath.Join(cwd, ".tig", "crash"), nil
+		}
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tig", "crash"), nil
+}
+
+func writeCrashReport(cmd *cobra.Command, args []string, r any, stack []byte) (string, error) {
+	dir, err := crashDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	var b strings.Builder
+	fmt.Fprintf(&b, "tig %s crashed at %s\n\n", version, now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Command:  %s %s\n", cmd.CommandPath(), strings.Join(args, " "))
+	fmt.Fprintf(&b, "Platform: %s/%s, %s\n", runtime.GOOS, runtime.GOARCH, runtime.Version())
+	fmt.Fprintf(&b, "Panic:    %v\n\n%s", r, stack)
+
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s-%d.txt", now.Format("20060102-150405"), os.Getpid()))
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// timeCommand reports how long the command took with --verbose
+func timeCommand(next runE) runE {
+	return func(cmd *cobra.Command, args []string) error {
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		if !verbose {
+			return next(cmd, args)
+		}
+		start := time.Now()
+		err := next(cmd, args)
+		fmt.Fprintf(os.Stderr, "%s took %s\n", cmd.CommandPath(), time.Since(start).Round(time.Microsecond))
+		return err
+	}
+}
+
+// checkForUpdates looks for a newer release in the background at most
+// once a day and mentions it on stderr after the command. Development
+// builds and machines that opted out with TIG_UPDATE_CHECK=0 or
+// disable_update_check in the user config never check.
+func checkForUpdates(next runE) runE {
+	return func(cmd *cobra.Command, args []string) error {
+		if version == "dev" || cmd.Name() == "selfupdate" || cmd.Name() == "serve" || !config.UpdateCheckEnabled() {
+			return next(cmd, args)
+		}
+		path, err := selfupdate.StatePath()
+		if err != nil {
+			return next(cmd, args)
+		}
+		state, _ := selfupdate.LoadState(path)
+
+		var checked chan selfupdate.CheckState
+		if state.Due(time.Now()) {
+			checked = make(chan selfupdate.CheckState, 1)
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+				defer cancel()
+				u, err := selfupdate.New(os.Getenv("TIG_UPDATE_URL"), os.Getenv("TIG_UPDATE_CHANNEL"), version)
+				if err != nil {
+					return
+				}
+				s := selfupdate.CheckState{CheckedAt: time.Now()}
+				if rel, _, err := u.Check(ctx); err == nil {
+					s.Latest = rel.Version
+				}
+				if selfupdate.SaveState(path, s) == nil {
+					checked <- s
+				}
+			}()
+		}
+
+		err = next(cmd, args)
+
+		if checked != nil {
+			select {
+			case state = <-checked:
+			case <-time.After(updateCheckGrace):
+			}
+		}
+		if state.Latest != "" && selfupdate.Newer(state.Latest, version) {
+			fmt.Fprintf(os.Stderr, "\ntig %s is available (current %s); run tig selfupdate to install it\n", state.Latest, version)
+		}
+		return err
+	}
+}
@@ -0,0 +1,68 @@
+// cmd/tig/mv.go
+package main
+
+import (
+	"fmt"
+
+	"tig/internal/parcel"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var mvCmd = &cobra.Command{
+		Use:   "mv <source> <destination>",
+		Short: "Move or rename a file or directory and gate the move",
+		Long: `Move a file or directory and immediately gate the move. Each moved file
+keeps its old path and content hash, so its history follows it without
+relying on rename detection. If the destination is an existing
+directory, the source is moved into it.`,
+		Example: `  tig mv util.go internal/util.go
+  tig mv docs manual`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			if err := p.Move(args[0], args[1]); err != nil {
+				return err
+			}
+			fmt.Printf("Moved %s to %s\n", args[0], args[1])
+			return nil
+		},
+	}
+
+	var rmCmd = &cobra.Command{
+		Use:   "rm <paths...>",
+		Short: "Remove files and gate their deletion",
+		Long: `Remove files from the working tree and immediately gate their deletion,
+recording the content hash each file had. With --cached the files stay
+on disk.`,
+		Example: `  tig rm old.go
+  tig rm -r legacy/`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recursive, _ := cmd.Flags().GetBool("recursive")
+			cached, _ := cmd.Flags().GetBool("cached")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			if err := p.Remove(args, parcel.RemoveOptions{Recursive: recursive, Cached: cached}); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %d path(s)\n", len(args))
+			return nil
+		},
+	}
+	rmCmd.Flags().BoolP("recursive", "r", false, "Remove directories and their contents")
+	rmCmd.Flags().Bool("cached", false, "Gate the deletion but keep the files on disk")
+
+	rootCmd.AddCommand(mvCmd, rmCmd)
+}
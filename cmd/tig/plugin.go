@@ -0,0 +1,134 @@
+// cmd/tig/plugin.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tig/internal/plugin"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var pluginCmd = &cobra.Command{
+		Use:   "plugin",
+		Short: "Run the repository's WebAssembly plugins",
+		Long: `Plugins are WebAssembly modules in .tig/plugins that validate, format or
+analyze intents. They run sandboxed, without access to files, network or
+the environment, and read the intent, its changeset and its diff through
+host functions, so a plugin gives the same result here as on the server.`,
+	}
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the repository's plugins",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			names, err := plugin.List(root)
+			if err != nil {
+				return err
+			}
+			if len(names) == 0 {
+				fmt.Printf("No plugins in %s\n", filepath.Join(".tig", plugin.Dir))
+				return nil
+			}
+			for _, name := range names {
+				fmt.Println(name)
+			}
+			return nil
+		},
+	}
+
+	var runCmd = &cobra.Command{
+		Use:   "run <plugin> <intent>",
+		Short: "Run a plugin on an intent",
+		Long: `Run a plugin on an intent, printing what it wrote and any failures it
+reported. The command fails if the plugin does.`,
+		Example: `  tig plugin run lint 3f2a
+  tig plugin run changelog 3f2a --json`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return completeIntents(cmd, nil, toComplete)
+			}
+			if len(args) > 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			root, err := os.Getwd()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			names, _ := plugin.List(root)
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			wasm, err := plugin.Load(p.Root, args[0])
+			if err != nil {
+				return err
+			}
+			i, err := p.ResolveIntent(args[1])
+			if err != nil {
+				return err
+			}
+			in, err := plugin.Gather(p.DB, p.Safe, i)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			runtime, err := plugin.New(ctx, plugin.Options{Timeout: timeout})
+			if err != nil {
+				return err
+			}
+			defer runtime.Close(ctx)
+			res, err := runtime.Run(ctx, args[0], wasm, in)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(res); err != nil {
+					return err
+				}
+			} else {
+				if res.Output != "" {
+					fmt.Println(res.Output)
+				}
+				for _, f := range res.Failures {
+					fmt.Fprintf(os.Stderr, "%s: %s\n", res.Plugin, f)
+				}
+			}
+			if !res.Passed {
+				return fmt.Errorf("plugin %s failed on intent %s", res.Plugin, i.ID)
+			}
+			return nil
+		},
+	}
+	runCmd.Flags().Duration("timeout", plugin.DefaultTimeout, "Stop the plugin after this long")
+	runCmd.Flags().Bool("json", false, "Print the result as JSON")
+
+	pluginCmd.AddCommand(listCmd, runCmd)
+	rootCmd.AddCommand(pluginCmd)
+}
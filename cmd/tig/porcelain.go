@@ -0,0 +1,144 @@
+// cmd/tig/porcelain.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/conflict"
+	"tig/internal/intent"
+	"tig/internal/storage"
+	"tig/internal/stream"
+	"tig/shared/types"
+
+	"github.com/spf13/cobra"
+)
+
+// Porcelain formats are for scripts: one tab-separated record per line,
+// with no colour, headers or hints. A published version never changes;
+// new fields or records mean a new version, and the old ones stay
+// available.
+//
+// v1 records, by command:
+//
+//	tig status             <state> TAB <path>
+//	                       state is gated, modified, untracked, deleted or
+//	                       conflict
+//	tig intent list        <id> TAB <created> TAB <type> TAB <changeset> TAB <description>
+//	tig stream list        <id> TAB <name> TAB <type> TAB <active|inactive> TAB <created> TAB <intents>
+//	tig history            <time> TAB <kind> TAB <field> TAB <before> TAB <after>
+//	                       kind is create, update or delete; an update has
+//	                       one record per changed field, the others one
+//	                       record with empty field, before and after
+//
+// tig history is the log: every recorded change to an intent or stream.
+// tig intent list, oldest first, is the log of what was committed.
+//
+// IDs are full, times are RFC 3339 in UTC, and records are sorted by
+// path, by creation time or, for history, by time of the change.
+// Backslashes, tabs and newlines within a field are written as \\, \t
+// and \n.
+const porcelainV1 = "v1"
+
+// porcelainVersions lists the supported porcelain formats
+var porcelainVersions = []string{porcelainV1}
+
+// addPorcelainFlag registers --porcelain on a status or list command
+func addPorcelainFlag(cmd *cobra.Command) {
+	cmd.Flags().String("porcelain", "", "Stable tab-separated output for scripts; the format version defaults to "+porcelainV1)
+	cmd.Flags().Lookup("porcelain").NoOptDefVal = porcelainV1
+}
+
+// porcelainVersion returns the requested porcelain format, or "" for
+// human output
+func porcelainVersion(cmd *cobra.Command) (string, error) {
+	version, _ := cmd.Flags().GetString("porcelain")
+	if version == "" {
+		return "", nil
+	}
+	for _, v := range porcelainVersions {
+		if version == v {
+			return version, nil
+		}
+	}
+	return "", &usageError{fmt.Errorf("unknown porcelain version %q (supported: %s)", version, strings.Join(porcelainVersions, ", "))}
+}
+
+var porcelainEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// writeRecord writes one porcelain record
+func writeRecord(w io.Writer, fields ...string) {
+	for n, f := range fields {
+		fields[n] = porcelainEscaper.Replace(f)
+	}
+	fmt.Fprintln(w, strings.Join(fields, "\t"))
+}
+
+func porcelainTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// printStatusPorcelain writes the status records for changes and conflicts
+func printStatusPorcelain(changes []shared.Change, conflicts []conflict.Conflict, untracked bool) {
+	type record struct{ state, path string }
+	var records []record
+	for _, c := range changes {
+		switch {
+		case c.Gated:
+			records = append(records, record{"gated", c.Path})
+		case c.Type == "modify":
+			records = append(records, record{"modified", c.Path})
+		case c.Type == "untracked" && untracked:
+			records = append(records, record{"untracked", c.Path})
+		case c.Type == "delete":
+			records = append(records, record{"deleted", c.Path})
+		}
+	}
+	for _, c := range conflicts {
+		records = append(records, record{"conflict", c.Path})
+	}
+	sort.SliceStable(records, func(a, b int) bool { return records[a].path < records[b].path })
+	for _, r := range records {
+		writeRecord(os.Stdout, r.state, r.path)
+	}
+}
+
+// printIntentsPorcelain writes one record per intent, oldest first
+func printIntentsPorcelain(intents []*intent.Intent) {
+	sorted := append([]*intent.Intent(nil), intents...)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].CreatedAt.Before(sorted[b].CreatedAt) })
+	for _, i := range sorted {
+		writeRecord(os.Stdout, i.ID, porcelainTime(i.CreatedAt), i.Type, i.ChangeSetID, i.Description)
+	}
+}
+
+// printStreamsPorcelain writes one record per stream, oldest first
+func printStreamsPorcelain(streams []*stream.Stream) {
+	sorted := append([]*stream.Stream(nil), streams...)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].CreatedAt.Before(sorted[b].CreatedAt) })
+	for _, s := range sorted {
+		state := "inactive"
+		if s.State.Active {
+			state = "active"
+		}
+		writeRecord(os.Stdout, s.ID, s.Name, s.Type, state, porcelainTime(s.CreatedAt), fmt.Sprint(len(s.State.Intents)))
+	}
+}
+
+// printHistoryPorcelain writes the records of a mutation log, oldest first.
+// Field values are JSON, as in the human output.
+func printHistoryPorcelain(entries []storage.AuditEntry) {
+	for _, e := range entries {
+		if e.Kind != storage.MutationUpdate || len(e.Changes) == 0 {
+			writeRecord(os.Stdout, porcelainTime(e.Time), e.Kind, "", "", "")
+			continue
+		}
+		for _, c := range e.Changes {
+			writeRecord(os.Stdout, porcelainTime(e.Time), e.Kind, c.Field, historyValue(c.Before), historyValue(c.After))
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// cmd/tig/query.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"tig/internal/query"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var queryCmd = &cobra.Command{
+		Use:   "query <expr>",
+		Short: "Query intents, streams, and changesets",
+		Long: `Query repository data with a small filter language.
+
+Fields can be compared with =, !=, <, <=, >, >= and ~ (glob match).
+Times accept dates (2024-01-31) or relative durations (-7d, -12h).
+Intent fields defined under "intent_fields" in .tig/config.json are
+available as meta.<name>.`,
+		Example: `  tig query 'intents where type="fix" and created > -7d and path ~ "internal/safe/**"'
+  tig query 'streams where active = true order by name'
+  tig query 'intents where meta.risk = "high"'
+  tig query 'changesets where author = "alice" limit 10' --output json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+			explain, _ := cmd.Flags().GetBool("explain")
+
+			tmpl, err := formatTemplate(cmd)
+			if err != nil {
+				return err
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			res, err := query.NewExecutor(p.DB).Run(args[0])
+			if err != nil {
+				return err
+			}
+
+			if explain {
+				fmt.Fprintf(os.Stderr, "plan: %s\n", res.Plan)
+			}
+
+			if tmpl != nil {
+				return printTemplate(tmpl, res.Items...)
+			}
+
+			switch output {
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(res.Items)
+			case "table":
+				if len(res.Rows) == 0 {
+					fmt.Printf("No %s found\n", res.Entity)
+					return nil
+				}
+				tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, strings.Join(res.Columns, "\t"))
+				for _, row := range res.Rows {
+					fmt.Fprintln(tw, strings.Join(row, "\t"))
+				}
+				return tw.Flush()
+			default:
+				return fmt.Errorf("unknown output format %q (use table or json)", output)
+			}
+		},
+	}
+
+	queryCmd.Flags().StringP("output", "o", "table", "Output format (table, json)")
+	queryCmd.Flags().Bool("explain", false, "Print the index scan plan to stderr")
+	addFormatFlag(queryCmd)
+
+	rootCmd.AddCommand(queryCmd)
+}
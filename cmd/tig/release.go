@@ -0,0 +1,149 @@
+// cmd/tig/release.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var releaseCmd = &cobra.Command{
+		Use:   "release",
+		Short: "Tag stream trees with immutable release manifests",
+		Long: `A release records the full tree of a stream, every path with its content
+hash, together with the intents landed on it. The manifest is stored in the
+content safe, so a tag always names exactly the same files, and clones
+receive releases along with the rest of the repository.
+
+tig serve also records a release, tagged <stream>/<n>, each time an intent
+lands on a release stream.`,
+	}
+
+	var createCmd = &cobra.Command{
+		Use:   "create <tag>",
+		Short: "Record the tree of a stream under a new release tag",
+		Example: `  tig release create v1.4.0 --stream release-1.4
+  tig release export v1.4.0 ./dist/v1.4.0`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			streamRef, _ := cmd.Flags().GetString("stream")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			m, err := p.CreateRelease(args[0], streamRef)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Released %s from stream %s: %d files, %d intents\n", m.Tag, m.Stream, len(m.Files), len(m.Intents))
+			return nil
+		},
+	}
+	createCmd.Flags().StringP("stream", "s", "", "Stream to release (ID, prefix, or name)")
+	createCmd.MarkFlagRequired("stream")
+	createCmd.RegisterFlagCompletionFunc("stream", completeStreams)
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List release tags",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			tags, err := p.Releases()
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(tags)
+			}
+			if len(tags) == 0 {
+				fmt.Println("No releases found")
+				return nil
+			}
+			for _, t := range tags {
+				fmt.Printf("%s  %s  %s\n", t.CreatedAt.Format(time.RFC3339), shortHash(t.Manifest), t.Name)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().Bool("json", false, "Output tags as JSON")
+
+	var showCmd = &cobra.Command{
+		Use:   "show <tag>",
+		Short: "Show the manifest of a release",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			m, err := p.Release(args[0])
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(m)
+			}
+
+			fmt.Printf("Release %s\n", m.Tag)
+			fmt.Printf("Stream:    %s\n", m.Stream)
+			fmt.Printf("Changeset: %s\n", m.ChangeSetID)
+			fmt.Printf("Created:   %s\n", m.CreatedAt.Format(time.RFC3339))
+			fmt.Printf("\nIntents (%d):\n", len(m.Intents))
+			for _, id := range m.Intents {
+				fmt.Printf("  %s\n", id)
+			}
+			fmt.Printf("\nFiles (%d):\n", len(m.Files))
+			for _, f := range m.Files {
+				fmt.Printf("  %s  %s\n", shortHash(f.Hash), f.Path)
+			}
+			return nil
+		},
+	}
+	showCmd.Flags().Bool("json", false, "Output the manifest as JSON")
+
+	var exportCmd = &cobra.Command{
+		Use:   "export <tag> <dir>",
+		Short: "Write the files of a release into an empty directory",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			m, err := p.ExportRelease(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Exported %d files of %s to %s\n", len(m.Files), m.Tag, args[1])
+			return nil
+		},
+	}
+
+	releaseCmd.AddCommand(createCmd, listCmd, showCmd, exportCmd)
+	rootCmd.AddCommand(releaseCmd)
+}
@@ -0,0 +1,423 @@
+// cmd/tig/report.go
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tig/internal/merge"
+	"tig/internal/parcel"
+	"tig/internal/query"
+	"tig/internal/report"
+	"tig/internal/stale"
+	"tig/internal/storage"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var reportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Generate reports about intents and streams",
+	}
+
+	var impactCmd = &cobra.Command{
+		Use:   "impact",
+		Short: "Summarize the declared impact of a stream's intents",
+		Long: `Aggregate the impact declared by a stream's intents: the components in
+their scope, breaking changes with the dependencies they affect, and the
+dependencies impacted overall. The report is Markdown, ready for a
+release readiness review, or JSON with --json.
+
+--since limits the report to intents created after a point: a changeset
+tag, an intent ID or prefix, or a date (2006-01-02 or RFC 3339).
+
+Intents declare their impact with the --scope, --breaking and
+--dependency flags of tig intent create.`,
+		Example: `  tig report impact --stream release
+  tig report impact --stream release --since v1.4.0 > impact.md
+  tig report impact --stream release --since 2026-09-01 --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			streamRef, _ := cmd.Flags().GetString("stream")
+			since, _ := cmd.Flags().GetString("since")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			st, err := p.ResolveStream(streamRef)
+			if err != nil {
+				return err
+			}
+			var sinceTime time.Time
+			if since != "" {
+				if sinceTime, err = resolveSince(p, since); err != nil {
+					return err
+				}
+			}
+			intents, err := p.GetStreamIntents(st.ID)
+			if err != nil {
+				return fmt.Errorf("loading intents of stream %s: %w", st.Name, err)
+			}
+
+			r := report.BuildImpact(st.Name, intents, sinceTime)
+			r.Since = since
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(r)
+			}
+			fmt.Print(r.Markdown())
+			return nil
+		},
+	}
+
+	impactCmd.Flags().StringP("stream", "s", "", "Stream to report on (ID, prefix, or name)")
+	impactCmd.Flags().String("since", "", "Only include intents created after this tag, intent or date")
+	impactCmd.Flags().Bool("json", false, "Output the report as JSON")
+	impactCmd.MarkFlagRequired("stream")
+
+	var contentsCmd = &cobra.Command{
+		Use:   "contents <from> <to>",
+		Short: "List the intents, files and authors between two changesets",
+		Long: `List everything that landed after <from> up to and including <to>: the
+changesets, the intents they committed, the files they changed, their
+authors and any breaking changes. Deploy pipelines can use it to annotate
+a build with what it contains.
+
+<from> and <to> are changeset IDs, or intent IDs or prefixes standing for
+the changeset that committed the intent. The output is Markdown, or JSON
+with --json.`,
+		Example: `  tig report contents 3f2a 9c1d
+  tig report contents $PREVIOUS_DEPLOY $CURRENT_BUILD --json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			var c *report.Contents
+			err = p.DB.View(func(txn *badger.Txn) error {
+				from, err := report.ChangeSetOf(txn, args[0], p.ResolveIntent)
+				if err != nil {
+					return err
+				}
+				to, err := report.ChangeSetOf(txn, args[1], p.ResolveIntent)
+				if err != nil {
+					return err
+				}
+				c, err = report.BuildContents(txn, from, to, p.ResolveIntent)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(c)
+			}
+			fmt.Print(c.Markdown())
+			return nil
+		},
+	}
+
+	contentsCmd.Flags().Bool("json", false, "Output the contents as JSON")
+
+	var complianceCmd = &cobra.Command{
+		Use:   "compliance",
+		Short: "Export a signed audit report of intents, reviews and merges",
+		Long: `Export an audit record of a period for SOC 2 style reviews: every intent
+created in it with its reviews and check results, every merge into a
+protected stream with who asked for it and the approvals and checks it
+was merged on, and the merges that did not meet the stream's rules.
+
+--since and --until take a changeset tag, an intent ID or prefix, or a
+date (2006-01-02 or RFC 3339); --until defaults to now.
+
+The report is signed with an Ed25519 key, by default one kept in the
+user config directory and created on first use, so an auditor can check
+with tig report verify that the JSON report was not altered. Markdown
+and PDF reports carry the signature of their JSON equivalent.
+
+The format follows the --output file's extension (.md, .json or .pdf);
+without --output the report is printed as Markdown, or JSON with --json.`,
+		Example: `  tig report compliance --since 2026-07-01 --until 2026-10-01 -o q3.pdf
+  tig report compliance --since v2.3.0 --json > audit.json
+  tig report verify audit.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sinceRef, _ := cmd.Flags().GetString("since")
+			untilRef, _ := cmd.Flags().GetString("until")
+			output, _ := cmd.Flags().GetString("output")
+			keyPath, _ := cmd.Flags().GetString("key")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			format := "markdown"
+			switch ext := strings.ToLower(filepath.Ext(output)); {
+			case output == "" && asJSON, ext == ".json":
+				format = "json"
+			case ext == ".pdf":
+				format = "pdf"
+			case output == "", ext == ".md", ext == ".markdown":
+			default:
+				return &usageError{fmt.Errorf("unsupported report format %q: use .md, .json or .pdf", ext)}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			since, err := resolveSince(p, sinceRef)
+			if err != nil {
+				return err
+			}
+			until := time.Now()
+			if untilRef != "" {
+				if until, err = resolveSince(p, untilRef); err != nil {
+					return err
+				}
+			}
+			if !until.After(since) {
+				return &usageError{fmt.Errorf("--until must be after --since")}
+			}
+
+			if keyPath == "" {
+				if keyPath, err = report.SigningKeyPath(); err != nil {
+					return err
+				}
+			}
+			key, created, err := report.LoadSigningKey(keyPath)
+			if err != nil {
+				return err
+			}
+			if created {
+				fmt.Fprintf(os.Stderr, "Created signing key %s\n", keyPath)
+			}
+
+			intents, err := p.ListIntents()
+			if err != nil {
+				return fmt.Errorf("loading intents: %w", err)
+			}
+			streams, err := p.ListStreams()
+			if err != nil {
+				return fmt.Errorf("loading streams: %w", err)
+			}
+			landings, err := merge.Landings(p.DB, since, until)
+			if err != nil {
+				return err
+			}
+
+			c := report.BuildCompliance(intents, streams, landings, since, until)
+			if err := c.Sign(key); err != nil {
+				return err
+			}
+
+			var data []byte
+			switch format {
+			case "json":
+				if data, err = json.MarshalIndent(c, "", "  "); err != nil {
+					return err
+				}
+				data = append(data, '\n')
+			case "pdf":
+				data = report.PDF("Compliance report", c.Markdown())
+			default:
+				data = []byte(c.Markdown())
+			}
+			if output == "" {
+				_, err = os.Stdout.Write(data)
+				return err
+			}
+			if err := os.WriteFile(output, data, 0644); err != nil {
+				return fmt.Errorf("writing report: %w", err)
+			}
+			fmt.Printf("Wrote %s: %d intent(s), %d protected merge(s), %d exception(s)\n",
+				output, c.Summary.Intents, c.Summary.Merges, c.Summary.Exceptions)
+			return nil
+		},
+	}
+
+	complianceCmd.Flags().String("since", "", "Start of the period: a tag, intent or date")
+	complianceCmd.Flags().String("until", "", "End of the period: a tag, intent or date (default now)")
+	complianceCmd.Flags().StringP("output", "o", "", "Write the report to this .md, .json or .pdf file")
+	complianceCmd.Flags().String("key", "", "Base64 Ed25519 seed file to sign with (default in the user config directory)")
+	complianceCmd.Flags().Bool("json", false, "Print the report as JSON")
+	complianceCmd.MarkFlagRequired("since")
+
+	var verifyCmd = &cobra.Command{
+		Use:   "verify <report.json>",
+		Short: "Check a compliance report against its signature",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			var c report.Compliance
+			if err := json.Unmarshal(data, &c); err != nil {
+				return fmt.Errorf("decoding report: %w", err)
+			}
+			key, err := c.Verify()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Signature valid, signed with key %s\n", base64.StdEncoding.EncodeToString(key))
+			fmt.Printf("Period %s to %s, generated %s\n", c.Since.Format(time.RFC3339),
+				c.Until.Format(time.RFC3339), c.Generated.Format(time.RFC3339))
+			return nil
+		},
+	}
+
+	var staleCmd = &cobra.Command{
+		Use:   "stale",
+		Short: "List intents idle in review and streams left unsynced",
+		Long: `List the intents in review with no activity, such as a review, check
+result or state change, for --intent-age, and the active streams that
+have not synced for --stream-age. Ages take a unit of h, d or w.
+
+tig serve reminds about the same items through notifications when the
+stale section of its config is enabled. The report is Markdown, or JSON
+with --json.`,
+		Example: `  tig report stale
+  tig report stale --intent-age 3d --stream-age 2w
+  tig report stale --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			intentAge, _ := cmd.Flags().GetString("intent-age")
+			streamAge, _ := cmd.Flags().GetString("stream-age")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			var opts stale.Options
+			var err error
+			if opts.IntentAge, err = query.ParseDuration(intentAge); err != nil {
+				return &usageError{fmt.Errorf("--intent-age: %w", err)}
+			}
+			if opts.StreamAge, err = query.ParseDuration(streamAge); err != nil {
+				return &usageError{fmt.Errorf("--stream-age: %w", err)}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			intents, err := p.ListIntents()
+			if err != nil {
+				return fmt.Errorf("loading intents: %w", err)
+			}
+			streams, err := p.ListStreams()
+			if err != nil {
+				return fmt.Errorf("loading streams: %w", err)
+			}
+
+			r := stale.Detect(intents, streams, opts, time.Now())
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(r)
+			}
+			fmt.Print(r.Markdown())
+			return nil
+		},
+	}
+
+	staleCmd.Flags().String("intent-age", "7d", "Idle time after which an intent in review is stale")
+	staleCmd.Flags().String("stream-age", "30d", "Time without a sync after which a stream is stale")
+	staleCmd.Flags().Bool("json", false, "Output the report as JSON")
+
+	var hotspotsCmd = &cobra.Command{
+		Use:   "hotspots",
+		Short: "Rank paths by the incidents their changes caused",
+		Long: `Rank the paths changed by intents linked to incidents with tig incident
+link, by the number of incidents caused by changes to them and then by
+the number of causal intents. Hotspots are where extra review and tests
+pay off most. The report is Markdown, or JSON with --json.`,
+		Example: `  tig report hotspots
+  tig report hotspots --limit 10 --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, _ := cmd.Flags().GetInt("limit")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			if limit < 0 {
+				return &usageError{fmt.Errorf("--limit cannot be negative")}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			r, err := p.Hotspots(limit)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(r)
+			}
+			fmt.Print(r.Markdown())
+			return nil
+		},
+	}
+
+	hotspotsCmd.Flags().Int("limit", 20, "Number of paths to list, 0 for all")
+	hotspotsCmd.Flags().Bool("json", false, "Output the report as JSON")
+
+	reportCmd.AddCommand(impactCmd)
+	reportCmd.AddCommand(contentsCmd)
+	reportCmd.AddCommand(complianceCmd)
+	reportCmd.AddCommand(verifyCmd)
+	reportCmd.AddCommand(staleCmd)
+	reportCmd.AddCommand(hotspotsCmd)
+	rootCmd.AddCommand(reportCmd)
+}
+
+// resolveSince turns a changeset tag, intent reference or date into the
+// time a report starts after
+func resolveSince(p *parcel.Parcel, ref string) (time.Time, error) {
+	var tagged time.Time
+	var found bool
+	err := p.DB.View(func(txn *badger.Txn) error {
+		var err error
+		tagged, found, err = report.TagTime(txn, ref)
+		return err
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if found {
+		return tagged, nil
+	}
+
+	if i, err := p.ResolveIntent(ref); err == nil {
+		return i.CreatedAt, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, ref, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: no tag, intent or date matches %s", storage.ErrNotFound, ref)
+}
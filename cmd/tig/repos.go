@@ -0,0 +1,193 @@
+// cmd/tig/repos.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"tig/internal/config"
+	tigerrors "tig/internal/errors"
+	"tig/internal/parcel"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// repoInfo is a registered repository as shown by tig repos list
+type repoInfo struct {
+	Path         string    `json:"path"`
+	State        string    `json:"state"` // clean, dirty, missing or unavailable
+	Stream       string    `json:"stream,omitempty"`
+	LastActivity time.Time `json:"last_activity"`
+	Error        string    `json:"error,omitempty"`
+}
+
+func init() {
+	var reposCmd = &cobra.Command{
+		Use:   "repos",
+		Short: "List the repositories on this machine",
+		Long: `tig init and tig clone record each repository in a per-user registry, so
+that tig repos list can show every checkout at a glance. Repositories
+created before the registry existed can be added with tig repos add.`,
+	}
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "Show registered repositories with their stream, state and last activity",
+		Long: `List registered repositories. The stream is the one last switched to,
+or else the one the most recent intent was added to. A repository is dirty when its working tree differs from
+the tracked files or has gated changes, and missing when it no longer
+exists; remove those with tig repos remove.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			registry, err := config.LoadRegistry()
+			if err != nil {
+				return err
+			}
+			infos := make([]repoInfo, len(registry.Repos))
+			for n, r := range registry.Repos {
+				infos[n] = inspectRepo(r)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(infos)
+			}
+			if len(infos) == 0 {
+				fmt.Println("No repositories registered")
+				return nil
+			}
+
+			green := color.New(color.FgGreen).SprintFunc()
+			yellow := color.New(color.FgYellow).SprintFunc()
+			red := color.New(color.FgRed).SprintFunc()
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "PATH\tSTREAM\tSTATE\tLAST ACTIVITY")
+			for _, info := range infos {
+				stream := info.Stream
+				if stream == "" {
+					stream = "-"
+				}
+				state := info.State
+				switch state {
+				case "clean":
+					state = green(state)
+				case "dirty":
+					state = yellow(state)
+				default:
+					state = red(state)
+				}
+				activity := "-"
+				if !info.LastActivity.IsZero() {
+					activity = info.LastActivity.Local().Format("2006-01-02 15:04")
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", info.Path, stream, state, activity)
+			}
+			return tw.Flush()
+		},
+	}
+	listCmd.Flags().Bool("json", false, "Output the repositories as JSON")
+
+	var addCmd = &cobra.Command{
+		Use:   "add [directory]",
+		Short: "Register an existing repository",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) == 1 {
+				dir = args[0]
+			}
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(filepath.Join(abs, ".tig")); err != nil {
+				return fmt.Errorf("%s is %w", abs, tigerrors.ErrNotRepository)
+			}
+			if err := config.RegisterRepo(abs); err != nil {
+				return err
+			}
+			fmt.Printf("Registered %s\n", abs)
+			return nil
+		},
+	}
+
+	var removeCmd = &cobra.Command{
+		Use:   "remove <directory>",
+		Short: "Forget a repository; its files are left alone",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			abs, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			registry, err := config.LoadRegistry()
+			if err != nil {
+				return err
+			}
+			if !registry.Remove(abs) {
+				return tigerrors.NotFound(fmt.Sprintf("repository not found in the registry: %s", abs))
+			}
+			if err := config.SaveRegistry(registry); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %s from the registry\n", abs)
+			return nil
+		},
+	}
+
+	reposCmd.AddCommand(listCmd, addCmd, removeCmd)
+	rootCmd.AddCommand(reposCmd)
+}
+
+// registerRepo records a new repository in the user's registry. A
+// registry that cannot be written only warns; the repository is fine.
+func registerRepo(dir string) {
+	if err := config.RegisterRepo(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not add %s to the repository registry: %v\n", dir, err)
+	}
+}
+
+// inspectRepo opens a registered repository to report its state. Its
+// last activity is its newest intent, or when it was last registered.
+func inspectRepo(r config.KnownRepo) repoInfo {
+	info := repoInfo{Path: r.Path, LastActivity: r.LastUsed}
+	if _, err := os.Stat(filepath.Join(r.Path, ".tig")); err != nil {
+		info.State = "missing"
+		return info
+	}
+
+	p, err := parcel.New(r.Path, zap.NewNop())
+	if err != nil {
+		info.State = "unavailable"
+		info.Error = err.Error()
+		return info
+	}
+	defer p.Close()
+
+	latest, _, err := p.LatestIntent()
+	if err == nil && latest != nil && latest.CreatedAt.After(info.LastActivity) {
+		info.LastActivity = latest.CreatedAt
+	}
+	if st, err := p.CurrentStream(); err == nil && st != nil {
+		info.Stream = st.Name
+	}
+
+	info.State = "clean"
+	if err := p.RequireCleanTree(); errors.Is(err, tigerrors.ErrDirtyTree) {
+		info.State = "dirty"
+	} else if err != nil {
+		info.State = "unavailable"
+		info.Error = err.Error()
+	}
+	return info
+}
@@ -0,0 +1,79 @@
+// cmd/tig/restore.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var restoreCmd = &cobra.Command{
+		Use:   "restore <paths...> [--changeset <id> | --from <stream>]",
+		Short: "Bring files back to an earlier version",
+		Long: `Write files or directories back into the working tree from the Safe.
+
+With --changeset, files are restored as they were in that changeset,
+given by ID, unique prefix or the intent that committed it, and gated as
+modifications. With --from, they are copied from the tree of another
+stream as of its last landed intent and gated, so a single fix can be
+ported without switching. With neither, files return to their last
+recorded content and are ungated.
+
+Files with uncommitted edits are refused unless --force is given, since
+they would be overwritten.`,
+		Example: `  tig restore main.go --changeset 3f2a
+  tig restore docs/ --changeset 3f2a
+  tig restore --from hotfix-1.2 internal/auth/token.go
+  tig restore config.yaml`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, _ := cmd.Flags().GetString("from")
+			changeSet, _ := cmd.Flags().GetString("changeset")
+			force, _ := cmd.Flags().GetBool("force")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			var restored []string
+			var source string
+			switch {
+			case from != "":
+				restored, err = p.RestoreFrom(from, args, force)
+				source = "stream " + from
+			case changeSet != "":
+				restored, err = p.Restore(changeSet, args, force)
+				source = "changeset " + changeSet
+			default:
+				restored, err = p.Restore("", args, force)
+				source = "recorded content"
+			}
+			if err != nil {
+				return err
+			}
+			if len(restored) == 0 {
+				fmt.Printf("Files already match %s\n", source)
+				return nil
+			}
+			for _, path := range restored {
+				fmt.Printf("\t%s\n", path)
+			}
+			if from == "" && changeSet == "" {
+				fmt.Printf("Restored %d file(s) to their recorded content\n", len(restored))
+				return nil
+			}
+			fmt.Printf("Restored and gated %d file(s) from %s\n", len(restored), source)
+			return nil
+		},
+	}
+	restoreCmd.Flags().String("from", "", "Stream to copy the files from (ID, prefix, or name)")
+	restoreCmd.Flags().String("changeset", "", "Changeset to restore the files from (ID, prefix, or intent)")
+	restoreCmd.Flags().Bool("force", false, "Overwrite files with uncommitted edits")
+	restoreCmd.MarkFlagsMutuallyExclusive("from", "changeset")
+	restoreCmd.RegisterFlagCompletionFunc("from", completeStreams)
+	restoreCmd.RegisterFlagCompletionFunc("changeset", completeIntents)
+	rootCmd.AddCommand(restoreCmd)
+}
@@ -0,0 +1,263 @@
+// cmd/tig/review.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"tig/internal/assign"
+	"tig/internal/review"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var reviewCmd = &cobra.Command{
+		Use:   "review",
+		Short: "Share intents for review",
+	}
+
+	var exportCmd = &cobra.Command{
+		Use:   "export <intent>",
+		Short: "Export an intent as a standalone HTML review page",
+		Long: `Write a single HTML file with the intent's metadata, impact and a
+side-by-side, syntax-highlighted diff of every changed file. The page has
+no external dependencies, so it can be shared with people who don't run
+Tig.`,
+		Example:           `  tig review export 3f2a -o review.html`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			i, err := p.ResolveIntent(args[0])
+			if err != nil {
+				return err
+			}
+
+			bundle, err := review.Build(p.DB, p.Safe, p.Highlighter, i, review.Options{
+				Structural:      p.Diff.IsStructural,
+				NotebookOutputs: p.Diff.NotebookOutputs,
+			})
+			if err != nil {
+				return fmt.Errorf("building review: %w", err)
+			}
+
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("creating %s: %w", output, err)
+			}
+			if err := review.Render(f, bundle); err != nil {
+				f.Close()
+				return fmt.Errorf("writing %s: %w", output, err)
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("writing %s: %w", output, err)
+			}
+
+			fmt.Printf("Wrote review of %d files to %s\n", len(bundle.Files), output)
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringP("output", "o", "review.html", "File to write the review page to")
+
+	var groupCmd = &cobra.Command{
+		Use:   "group",
+		Short: "Manage the reviewer groups reviews are assigned from",
+	}
+
+	var groupSetCmd = &cobra.Command{
+		Use:   "set <name> <members...>",
+		Short: "Create or replace a reviewer group",
+		Long: `Create or replace a reviewer group. Reviews are assigned from it in
+turn with --strategy round-robin, or to the members with the fewest open
+reviews with --strategy load. Set a stream's protection.reviewer_group
+to assign reviewers whenever an intent joins it.`,
+		Example: `  tig review group set backend alice bob carol --strategy load`,
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			strategy, _ := cmd.Flags().GetString("strategy")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			g := &assign.Group{Name: args[0], Members: args[1:], Strategy: strategy}
+			if err := assign.SetGroup(p.DB, g); err != nil {
+				return err
+			}
+			fmt.Printf("Reviewer group %s: %s (%s)\n", g.Name, strings.Join(g.Members, ", "), g.Strategy)
+			return nil
+		},
+	}
+	groupSetCmd.Flags().String("strategy", assign.RoundRobin, "How reviewers are chosen: round-robin or load")
+
+	var groupListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List reviewer groups",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			groups, err := assign.Groups(p.DB)
+			if err != nil {
+				return err
+			}
+			if len(groups) == 0 {
+				fmt.Println("No reviewer groups")
+				return nil
+			}
+			for _, g := range groups {
+				fmt.Printf("%s  %s  %s\n", g.Name, g.Strategy, strings.Join(g.Members, ", "))
+			}
+			return nil
+		},
+	}
+
+	var assignCmd = &cobra.Command{
+		Use:   "assign <intent> [reviewer]",
+		Short: "Assign reviewers to an intent",
+		Long: `Assign a named reviewer to an intent, or with --group, members of a
+reviewer group chosen by the group's strategy. The intent's author and
+anyone already assigned or reviewing are passed over.`,
+		Example: `  tig review assign 3f2a carol
+  tig review assign 3f2a --group backend -n 2`,
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			group, _ := cmd.Flags().GetString("group")
+			count, _ := cmd.Flags().GetInt("count")
+			if (len(args) == 2) == (group != "") {
+				return &usageError{fmt.Errorf("name a reviewer or a --group")}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			i, err := p.ResolveIntent(args[0])
+			if err != nil {
+				return err
+			}
+			var assigned []assign.Assignment
+			if group != "" {
+				if assigned, err = assign.Assign(p.DB, group, i, count); err != nil {
+					return err
+				}
+			} else {
+				a, err := assign.Add(p.DB, i.ID, args[1])
+				if err != nil {
+					return err
+				}
+				assigned = append(assigned, *a)
+			}
+			for _, a := range assigned {
+				fmt.Printf("Assigned %s to review %s\n", a.Reviewer, i.ID)
+			}
+			return nil
+		},
+	}
+	assignCmd.Flags().String("group", "", "Reviewer group to assign from")
+	assignCmd.Flags().IntP("count", "n", 1, "Number of reviewers to assign from the group")
+
+	var reassignCmd = &cobra.Command{
+		Use:   "reassign <intent> <from> [to]",
+		Short: "Hand a review assignment to someone else",
+		Long: `Hand a reviewer's open assignment on an intent to another reviewer,
+or without one named, to the next member of the group it was assigned
+from.`,
+		Args:              cobra.RangeArgs(2, 3),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			i, err := p.ResolveIntent(args[0])
+			if err != nil {
+				return err
+			}
+			var to string
+			if len(args) == 3 {
+				to = args[2]
+			}
+			a, err := assign.Reassign(p.DB, i, args[1], to)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Reassigned review of %s from %s to %s\n", i.ID, args[1], a.Reviewer)
+			return nil
+		},
+	}
+
+	var assignmentsCmd = &cobra.Command{
+		Use:               "assignments [intent]",
+		Short:             "List review assignments",
+		Long:              `List an intent's review assignments, or the open assignments of everyone or of --reviewer.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeIntents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reviewer, _ := cmd.Flags().GetString("reviewer")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			var assignments []assign.Assignment
+			if len(args) == 1 {
+				i, err := p.ResolveIntent(args[0])
+				if err != nil {
+					return err
+				}
+				assignments, err = assign.ForIntent(p.DB, i.ID)
+				if err != nil {
+					return err
+				}
+			} else if assignments, err = assign.Open(p.DB, reviewer); err != nil {
+				return err
+			}
+
+			if len(assignments) == 0 {
+				fmt.Println("No review assignments")
+				return nil
+			}
+			for _, a := range assignments {
+				state := "open"
+				if a.Done {
+					state = "done"
+				}
+				note := ""
+				if a.DelegatedFrom != "" {
+					note = "  (from " + a.DelegatedFrom + ")"
+				}
+				fmt.Printf("%s  %-12s  %-4s  %s%s\n", a.IntentID[:min(8, len(a.IntentID))], a.Reviewer, state,
+					a.AssignedAt.Local().Format("2006-01-02 15:04"), note)
+			}
+			return nil
+		},
+	}
+	assignmentsCmd.Flags().String("reviewer", "", "Only list this reviewer's open assignments")
+
+	groupCmd.AddCommand(groupSetCmd, groupListCmd)
+	reviewCmd.AddCommand(exportCmd, groupCmd, assignCmd, reassignCmd, assignmentsCmd)
+	rootCmd.AddCommand(reviewCmd)
+}
@@ -0,0 +1,38 @@
+// cmd/tig/risk.go
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"tig/internal/intent"
+	"tig/internal/parcel"
+	"tig/internal/risk"
+)
+
+// riskScores scores intents against the repository's history, by ID
+func riskScores(p *parcel.Parcel, intents []*intent.Intent) (map[string]*risk.Score, error) {
+	sc, err := p.RiskScorer()
+	if err != nil {
+		return nil, err
+	}
+	scores, err := sc.ScoreAll(intents)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*risk.Score, len(scores))
+	for _, s := range scores {
+		byID[s.IntentID] = s
+	}
+	return byID, nil
+}
+
+// formatRisk describes a risk score with its reasons, e.g.
+// "72 (high): 1240 lines changed, breaking change"
+func formatRisk(s *risk.Score) string {
+	out := fmt.Sprintf("%d (%s)", s.Score, s.Level)
+	if len(s.Reasons) > 0 {
+		out += ": " + strings.Join(s.Reasons, ", ")
+	}
+	return out
+}
@@ -0,0 +1,138 @@
+// cmd/tig/rollback.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"tig/internal/deploy"
+	"tig/internal/release"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var rollbackCmd = &cobra.Command{
+		Use:   "rollback --env <environment>",
+		Short: "Roll an environment back to its last good deployment",
+		Long: `Find the deployment running in an environment and the last good one before
+it: the latest earlier deployment that was not itself rolled back and ran
+another changeset, or the release given with --to. The intents deployed
+since then are listed, the good deployment is recorded as deployed again,
+the replaced one is marked rolled back, and the intents leaving the
+environment lose their "deployed to" date for it.
+
+With --revert, those intents also move to the reverted state. With
+--output, the plan is written as JSON for the deploy pipeline to act on;
+with --export, the files of the release rolled back to are written into
+an empty directory. --dry-run shows the plan without changing anything.
+
+tig serve takes the same plan at GET /api/deployments/rollback and carries
+it out at POST /api/deployments/rollback, notifying webhooks with a
+deployment.rolled_back event.`,
+		Example: `  tig rollback --env prod --dry-run
+  tig rollback --env prod --output rollback.json --export ./dist/prod
+  tig rollback --env staging --to v1.3.2 --revert`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			env, _ := cmd.Flags().GetString("env")
+			tag, _ := cmd.Flags().GetString("to")
+			revert, _ := cmd.Flags().GetBool("revert")
+			url, _ := cmd.Flags().GetString("url")
+			output, _ := cmd.Flags().GetString("output")
+			exportDir, _ := cmd.Flags().GetString("export")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			if env == "" {
+				return &usageError{fmt.Errorf("--env is required")}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			plan, err := p.PlanRollback(env, tag)
+			if err != nil {
+				return err
+			}
+			var m *release.Manifest
+			if exportDir != "" {
+				if plan.To.Release == "" {
+					return fmt.Errorf("cannot export %s: it was not a release", plan.To.Source())
+				}
+				if m, err = p.Release(plan.To.Release); err != nil {
+					return err
+				}
+			}
+
+			dryRun := isDryRun(cmd)
+			if !dryRun {
+				// Export first: a destination that is not empty should
+				// stop the rollback, not follow it
+				if m != nil {
+					if err := release.Export(p.Safe, m, exportDir); err != nil {
+						return err
+					}
+				}
+				if _, err := p.Rollback(plan, revert, url, ""); err != nil {
+					return err
+				}
+			}
+			if output != "" {
+				data, err := json.MarshalIndent(plan, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(output, append(data, '\n'), 0644); err != nil {
+					return fmt.Errorf("writing rollback plan: %w", err)
+				}
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(plan)
+			}
+			printRollback(plan, dryRun)
+			if !dryRun && m != nil {
+				fmt.Printf("Exported %d files of %s to %s\n", len(m.Files), m.Tag, exportDir)
+			}
+			return nil
+		},
+	}
+	rollbackCmd.Flags().String("env", "", "Environment to roll back")
+	rollbackCmd.Flags().String("to", "", "Release to roll back to instead of the last good deployment")
+	rollbackCmd.Flags().Bool("revert", false, "Move the intents leaving the environment to the reverted state")
+	rollbackCmd.Flags().String("url", "", "Link to the rollback job")
+	rollbackCmd.Flags().String("output", "", "Write the rollback plan as JSON to this file")
+	rollbackCmd.Flags().String("export", "", "Write the files of the release rolled back to into this empty directory")
+	rollbackCmd.Flags().Bool("json", false, "Output the rollback plan as JSON")
+	supportDryRun(rollbackCmd)
+
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+// printRollback describes a rollback plan, carried out or not
+func printRollback(plan *deploy.Rollback, dryRun bool) {
+	verb := "Rolled"
+	if dryRun {
+		verb = "Would roll"
+	}
+	fmt.Printf("%s %s back from %s to %s\n", verb, plan.Environment, plan.From.Source(), plan.To.Source())
+	if len(plan.Intents) == 0 {
+		fmt.Println("No intents leave the environment")
+	} else {
+		fmt.Printf("Intents leaving %s (%d):\n", plan.Environment, len(plan.Intents))
+		for _, id := range plan.Intents {
+			fmt.Printf("  %s\n", id)
+		}
+	}
+	if len(plan.Reverted) > 0 {
+		fmt.Printf("Reverted %d intent(s)\n", len(plan.Reverted))
+	}
+	if dryRun {
+		fmt.Println("Nothing was changed")
+	}
+}
@@ -0,0 +1,80 @@
+// cmd/tig/selfupdate.go
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"tig/internal/selfupdate"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var selfupdateCmd = &cobra.Command{
+		Use:   "selfupdate",
+		Short: "Update tig to the latest release",
+		Long: `Check the release endpoint for a newer version of tig and, if one exists,
+download it over HTTPS, verify its Ed25519 signature against the release
+keys built into tig, and replace the running binary.
+
+The endpoint and channel can also be set with TIG_UPDATE_URL and
+TIG_UPDATE_CHANNEL.`,
+		Example: `  tig selfupdate
+  tig selfupdate --check
+  tig selfupdate --channel beta`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checkOnly, _ := cmd.Flags().GetBool("check")
+			force, _ := cmd.Flags().GetBool("force")
+			endpoint, _ := cmd.Flags().GetString("endpoint")
+			if endpoint == "" {
+				endpoint = os.Getenv("TIG_UPDATE_URL")
+			}
+			channel, _ := cmd.Flags().GetString("channel")
+			if !cmd.Flags().Changed("channel") && os.Getenv("TIG_UPDATE_CHANNEL") != "" {
+				channel = os.Getenv("TIG_UPDATE_CHANNEL")
+			}
+
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("locating executable: %w", err)
+			}
+			selfupdate.CleanupOld(exe)
+
+			u, err := selfupdate.New(endpoint, channel, version)
+			if err != nil {
+				return err
+			}
+			rel, newer, err := u.Check(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if !newer && !force {
+				fmt.Printf("tig %s is up to date\n", version)
+				return nil
+			}
+			fmt.Printf("tig %s (%s) is available (current %s)\n", rel.Version, channel, version)
+			if rel.Notes != "" {
+				fmt.Println(rel.Notes)
+			}
+			if checkOnly {
+				return nil
+			}
+
+			if err := u.Apply(cmd.Context(), rel, exe); err != nil {
+				return err
+			}
+
+			fmt.Printf("Updated to tig %s\n", rel.Version)
+			return nil
+		},
+	}
+
+	selfupdateCmd.Flags().Bool("check", false, "Only report whether an update is available")
+	selfupdateCmd.Flags().Bool("force", false, "Reinstall even if already up to date")
+	selfupdateCmd.Flags().String("endpoint", "", "Release manifest URL")
+	selfupdateCmd.Flags().String("channel", selfupdate.Stable, "Release channel (stable, beta)")
+	rootCmd.AddCommand(selfupdateCmd)
+}
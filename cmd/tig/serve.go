@@ -0,0 +1,93 @@
+// cmd/tig/serve.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"tig/internal/config"
+	"tig/internal/logging"
+	"tig/internal/server"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var serveCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the HTTP API and web UI for this repository",
+		Long: `Start the tig server for the current repository. It serves the REST API
+under /api, Prometheus metrics at /metrics and a web dashboard at /.
+
+Settings such as notifications, scrubbing and stale reminders are read
+from --config; the host and port flags override it.`,
+		Example: `  tig serve
+  tig serve --port 9000
+  tig serve --config server.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+
+			cfg := config.Default()
+			if configPath != "" {
+				var err error
+				if cfg, err = config.Load(configPath); err != nil {
+					return fmt.Errorf("loading config: %w", err)
+				}
+			}
+			if cmd.Flags().Changed("host") {
+				cfg.Server.Host, _ = cmd.Flags().GetString("host")
+			}
+			if cmd.Flags().Changed("port") {
+				cfg.Server.Port, _ = cmd.Flags().GetInt("port")
+			}
+
+			lg, err := logging.NewLogger(cfg.LogLevel)
+			if err != nil {
+				return fmt.Errorf("initializing logger: %w", err)
+			}
+			defer lg.Sync()
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			// Highlighting can be turned off per repository
+			if !p.Highlighter.Enabled() {
+				cfg.Diff.DisableHighlight = true
+			}
+			// So can the files diffed key by key, unless the server config picks them
+			if len(cfg.Diff.Structural) == 0 {
+				cfg.Diff.Structural = p.Diff.Structural
+			}
+			if p.Diff.NotebookOutputs {
+				cfg.Diff.NotebookOutputs = true
+			}
+			// Intents created through the API follow the repository's schema
+			cfg.IntentFields = p.IntentFields
+			cfg.Workflow = p.Workflow
+
+			srv, err := server.New(cfg, p.DB, p.Safe, p.Root, lg)
+			if err != nil {
+				return err
+			}
+			defer srv.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			fmt.Fprintf(os.Stderr, "Serving %s on http://%s\n", p.Root, srv.Addr())
+			return srv.ListenAndServe(ctx)
+		},
+	}
+
+	serveCmd.Flags().String("config", "", "Path to a server config file")
+	serveCmd.Flags().String("host", "localhost", "Host to listen on")
+	serveCmd.Flags().Int("port", 8080, "Port to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,345 @@
+// cmd/tig/stats.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"tig/internal/config"
+	"tig/internal/remote"
+	"tig/internal/stats"
+	"tig/internal/storage"
+	"tig/internal/telemetry"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var statsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Show repository statistics",
+	}
+
+	var churnCmd = &cobra.Command{
+		Use:   "churn",
+		Short: "Show the most frequently changed files",
+		Long: `List the files that change most often, who changes them and the
+average number of files per intent.
+
+Statistics are updated as changesets are created. Use --rebuild to
+recompute them from the full history.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, _ := cmd.Flags().GetInt("limit")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			rebuild, _ := cmd.Flags().GetBool("rebuild")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			if rebuild {
+				if err := stats.Rebuild(p.DB); err != nil {
+					return err
+				}
+			}
+
+			report, err := stats.Churn(p.DB, limit)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+
+			fmt.Printf("Changesets:          %d\n", report.ChangeSets)
+			fmt.Printf("Changes:             %d\n", report.Changes)
+			fmt.Printf("Average intent size: %.1f files\n", report.AverageIntentSize)
+			if len(report.Files) == 0 {
+				return nil
+			}
+
+			fmt.Println()
+			fmt.Printf("%7s  %-40s %s\n", "CHANGES", "PATH", "AUTHORS")
+			for _, f := range report.Files {
+				fmt.Printf("%7d  %-40s %s\n", f.Changes, f.Path, strings.Join(f.TopAuthors(), ", "))
+			}
+			return nil
+		},
+	}
+
+	churnCmd.Flags().IntP("limit", "n", 20, "Number of files to show (0 for all)")
+	churnCmd.Flags().Bool("json", false, "Output the report as JSON")
+	churnCmd.Flags().Bool("rebuild", false, "Recompute statistics from all changesets")
+
+	var authorsCmd = &cobra.Command{
+		Use:   "authors",
+		Short: "Show contributions per author over time",
+		Long: `Count, per author, the intents created, the lines their changesets added
+and deleted, the breaking changes and the active streams holding their
+intents. An intent's author is the author of its changeset.
+
+Counts are also split into day, week or month buckets for team
+dashboards; the same report is served at /api/stats/authors by tig
+serve. Line counts are computed once per changeset and cached.`,
+		Example: `  tig stats authors
+  tig stats authors --since 30d --bucket day
+  tig stats authors --since 2026-01-01 --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sinceRef, _ := cmd.Flags().GetString("since")
+			bucket, _ := cmd.Flags().GetString("bucket")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			since, err := parseTimeRef(sinceRef)
+			if err != nil {
+				return &usageError{err}
+			}
+			if _, err := stats.BucketStart(since, bucket); err != nil {
+				return &usageError{err}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			intents, err := p.ListIntents()
+			if err != nil {
+				return err
+			}
+			streams, err := p.StreamStore.List()
+			if err != nil {
+				return err
+			}
+			report, err := stats.Authors(p.DB, p.Safe, intents, streams, stats.AuthorOptions{Since: since, Bucket: bucket})
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+
+			if len(report.Authors) == 0 {
+				fmt.Printf("No intents since %s\n", since.Local().Format("2006-01-02"))
+				return nil
+			}
+			fmt.Printf("Since %s\n\n", since.Local().Format("2006-01-02"))
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "AUTHOR\tINTENTS\tADDED\tDELETED\tBREAKING\tACTIVE STREAMS")
+			for _, a := range report.Authors {
+				streams := strings.Join(a.ActiveStreams, ", ")
+				if streams == "" {
+					streams = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%d\t+%d\t-%d\t%d\t%s\n", a.Author, a.Intents, a.LinesAdded, a.LinesDeleted, a.Breaking, streams)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+
+			fmt.Printf("\nIntents per %s:\n", bucket)
+			for _, a := range report.Authors {
+				counts := make([]string, len(a.Buckets))
+				for n, b := range a.Buckets {
+					counts[n] = fmt.Sprintf("%s:%d", b.Start.Format("2006-01-02"), b.Intents)
+				}
+				fmt.Fprintf(tw, "  %s\t%s\n", a.Author, strings.Join(counts, "  "))
+			}
+			return tw.Flush()
+		},
+	}
+
+	authorsCmd.Flags().String("since", "90d", "Only count intents created after this time: a duration like 90d or a date")
+	authorsCmd.Flags().String("bucket", stats.BucketWeek, "Period of the time buckets: day, week or month")
+	authorsCmd.Flags().Bool("json", false, "Output the report as JSON")
+
+	var cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Show cache sizes and hit rates",
+		Long: `Show the size and hit rate of the content cache and the Badger block and
+index caches. Sizes are set in the "cache" section of .tig/config.json.
+
+Counters cover a single process, so without --server they only reflect
+this command's own reads. Use --server to ask a running tig serve for
+its counters; they are also exported to Prometheus at /metrics.`,
+		Example: `  tig stats cache
+  tig stats cache --server http://localhost:8080`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverURL, _ := cmd.Flags().GetString("server")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			var caches []storage.CacheStats
+			if serverURL != "" {
+				var err error
+				caches, err = remote.NewClient(serverURL).CacheStats(cmd.Context())
+				if err != nil {
+					return err
+				}
+			} else {
+				p, err := initParcel()
+				if err != nil {
+					return err
+				}
+				defer p.Close()
+
+				caches = append([]storage.CacheStats{p.Safe.CacheStats()}, storage.BadgerCacheStats(p.DB)...)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(caches)
+			}
+
+			fmt.Printf("%-8s %-9s %12s %10s %10s %6s\n", "CACHE", "STATUS", "CAPACITY", "HITS", "MISSES", "RATIO")
+			for _, c := range caches {
+				status, capacity := "disabled", "-"
+				if c.Enabled {
+					status = "enabled"
+					capacity = fmt.Sprintf("%d MB", c.Capacity>>20)
+					if c.Name == "content" {
+						capacity = fmt.Sprintf("%d items", c.Capacity)
+					}
+				}
+				fmt.Printf("%-8s %-9s %12s %10d %10d %5.1f%%\n", c.Name, status, capacity, c.Hits, c.Misses, c.HitRatio*100)
+			}
+			return nil
+		},
+	}
+
+	cacheCmd.Flags().String("server", "", "URL of a running tig serve to report on")
+	cacheCmd.Flags().Bool("json", false, "Output the statistics as JSON")
+
+	var usageCmd = &cobra.Command{
+		Use:   "usage",
+		Short: "Show or configure opt-in usage statistics",
+		Long: `Show how often each command ran on this machine, how long it took and
+how large the repositories were.
+
+Statistics are off until you opt in with --enable. Only command names,
+durations, failures and a coarse repository size bucket are recorded;
+arguments, paths, names and repository contents never are. The data stays
+in your user config directory unless you upload it.
+
+Teams that collect statistics centrally can set "upload_url" in the
+"telemetry" section of the user config; --upload posts the aggregate
+there. The ` + config.TelemetryEnv + ` environment variable overrides the user
+config, e.g. ` + config.TelemetryEnv + `=0 on shared machines.`,
+		Example: `  tig stats usage --enable
+  tig stats usage
+  tig stats usage --upload --reset`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			enable, _ := cmd.Flags().GetBool("enable")
+			disable, _ := cmd.Flags().GetBool("disable")
+			upload, _ := cmd.Flags().GetBool("upload")
+			reset, _ := cmd.Flags().GetBool("reset")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			cfg, err := config.LoadUser()
+			if err != nil {
+				return err
+			}
+			if enable || disable {
+				cfg.Telemetry.Enabled = enable
+				if err := config.SaveUser(cfg); err != nil {
+					return err
+				}
+				if enable {
+					fmt.Println("Usage statistics enabled")
+				} else {
+					fmt.Println("Usage statistics disabled")
+				}
+				return nil
+			}
+
+			path, err := telemetry.Path()
+			if err != nil {
+				return err
+			}
+			u, err := telemetry.Load(path)
+			if err != nil {
+				return err
+			}
+
+			if upload {
+				if cfg.Telemetry.UploadURL == "" {
+					return &usageError{fmt.Errorf("no upload_url in the telemetry section of the user config")}
+				}
+				if err := telemetry.Upload(cmd.Context(), cfg.Telemetry.UploadURL, version, u); err != nil {
+					return err
+				}
+				fmt.Printf("Uploaded usage of %d command(s) to %s\n", len(u.Commands), cfg.Telemetry.UploadURL)
+			}
+			if reset {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("resetting usage: %w", err)
+				}
+				fmt.Println("Usage statistics reset")
+			}
+			if upload || reset {
+				return nil
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(u)
+			}
+
+			if !config.TelemetryEnabled() {
+				fmt.Println("Usage statistics are disabled; enable them with tig stats usage --enable")
+			}
+			if len(u.Commands) == 0 {
+				fmt.Println("No usage recorded")
+				return nil
+			}
+			fmt.Printf("Since %s\n\n", u.Since.Local().Format("2006-01-02"))
+
+			names := make([]string, 0, len(u.Commands))
+			for name := range u.Commands {
+				names = append(names, name)
+			}
+			sort.Slice(names, func(x, y int) bool {
+				if u.Commands[names[x]].Runs != u.Commands[names[y]].Runs {
+					return u.Commands[names[x]].Runs > u.Commands[names[y]].Runs
+				}
+				return names[x] < names[y]
+			})
+			fmt.Printf("%-24s %6s %8s %10s %10s  %s\n", "COMMAND", "RUNS", "FAILED", "AVG", "MAX", "REPO SIZES")
+			for _, name := range names {
+				c := u.Commands[name]
+				fmt.Printf("%-24s %6d %8d %8.0fms %8dms  %s\n", name, c.Runs, c.Failures, c.AverageMs(), c.MaxMs, formatSizes(c.RepoSizes))
+			}
+			return nil
+		},
+	}
+
+	usageCmd.Flags().Bool("enable", false, "Opt in to recording usage statistics")
+	usageCmd.Flags().Bool("disable", false, "Stop recording usage statistics")
+	usageCmd.Flags().Bool("upload", false, "Post the statistics to the configured upload_url")
+	usageCmd.Flags().Bool("reset", false, "Delete the recorded statistics")
+	usageCmd.Flags().Bool("json", false, "Output the statistics as JSON")
+	usageCmd.MarkFlagsMutuallyExclusive("enable", "disable")
+
+	statsCmd.AddCommand(churnCmd)
+	statsCmd.AddCommand(authorsCmd)
+	statsCmd.AddCommand(cacheCmd)
+	statsCmd.AddCommand(usageCmd)
+	rootCmd.AddCommand(statsCmd)
+}
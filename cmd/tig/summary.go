@@ -0,0 +1,119 @@
+// cmd/tig/summary.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"tig/internal/parcel"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var summaryCmd = &cobra.Command{
+		Use:   "summary [directory]",
+		Short: "Summarize the recent history of a directory",
+		Long: `Give a quick orientation in a directory: the last intent that touched
+it, how many file changes it has seen recently and where, who has
+changed it most, and which changes under it are gated.
+
+The directory is relative to the repository root and defaults to the
+whole repository. Churn is counted from --since, 30 days ago by default;
+contributors are counted over all history.`,
+		Example: `  tig summary internal/parcel
+  tig summary cmd --since 7d
+  tig summary --json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sinceRef, _ := cmd.Flags().GetString("since")
+			limit, _ := cmd.Flags().GetInt("limit")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			since, err := parseTimeRef(sinceRef)
+			if err != nil {
+				return &usageError{err}
+			}
+			if limit < 0 {
+				return &usageError{fmt.Errorf("--limit must not be negative")}
+			}
+			dir := "."
+			if len(args) == 1 {
+				dir = filepath.Clean(args[0])
+			}
+			if filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, ".."+string(filepath.Separator)) {
+				return &usageError{fmt.Errorf("%s is outside the repository; give a path relative to its root", args[0])}
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			s, err := p.Summarize(dir, parcel.SummaryOptions{Since: since, Limit: limit})
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(s)
+			}
+			printSummary(s)
+			return nil
+		},
+	}
+
+	summaryCmd.Flags().String("since", "30d", "Start of the churn window: a duration like 7d or a date")
+	summaryCmd.Flags().IntP("limit", "n", 5, "Files and contributors to list; 0 lists all")
+	summaryCmd.Flags().Bool("json", false, "Output the summary as JSON")
+	rootCmd.AddCommand(summaryCmd)
+}
+
+func printSummary(s *parcel.DirSummary) {
+	bold := color.New(color.Bold).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	fmt.Printf("%s %s\n", bold("Directory:"), s.Dir)
+	if s.ChangeSets == 0 {
+		fmt.Println("No recorded changes")
+	} else {
+		fmt.Printf("Changesets: %d\n", s.ChangeSets)
+	}
+	if i := s.LastIntent; i != nil {
+		id := i.ID
+		if len(id) > 8 {
+			id = id[:8]
+		}
+		fmt.Printf("Last intent: %s %s (%s, %s)\n", id, i.Description, i.Type, i.CreatedAt.Local().Format("2006-01-02"))
+	}
+
+	fmt.Printf("\n%s %d file changes since %s\n", bold("Churn:"), s.Churn, s.Since.Local().Format("2006-01-02"))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, f := range s.ChurnFiles {
+		fmt.Fprintf(tw, "  %s\t%d\n", f.Path, f.Changes)
+	}
+	tw.Flush()
+
+	if len(s.Contributors) > 0 {
+		fmt.Printf("\n%s\n", bold("Top contributors:"))
+		for _, c := range s.Contributors {
+			fmt.Fprintf(tw, "  %s\t%d\n", c.Author, c.Changes)
+		}
+		tw.Flush()
+	}
+
+	if len(s.Gated) > 0 {
+		fmt.Printf("\n%s %d\n", bold("Gated changes:"), len(s.Gated))
+		for _, path := range s.Gated {
+			fmt.Printf("  %s\n", yellow(path))
+		}
+	}
+}
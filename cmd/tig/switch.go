@@ -0,0 +1,63 @@
+// cmd/tig/switch.go
+package main
+
+import (
+	"fmt"
+
+	"tig/internal/parcel"
+
+	"github.com/spf13/cobra"
+)
+
+// streamSwitchCommand returns the stream subcommand that moves the
+// working tree to another stream
+func streamSwitchCommand() *cobra.Command {
+	var switchCmd = &cobra.Command{
+		Use:   "switch <stream>",
+		Short: "Make a stream current and write its files into the working tree",
+		Long: `Make a stream current and materialize its tree, as of its last landed
+intent, into the working tree. Tracked files are rewritten to match the
+stream and those it does not have are removed. A stream without
+changesets takes over the working tree as it is.
+
+Uncommitted changes, gated or not, stop the switch unless --stash is
+given. Stashed changes are set aside for the stream they were made on
+and brought back, gated as before, when you next switch to it.`,
+		Example: `  tig stream switch hotfix-1.2
+  tig stream switch main --stash`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeStreams,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stash, _ := cmd.Flags().GetBool("stash")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			res, err := p.SwitchStream(args[0], parcel.SwitchOptions{Stash: stash})
+			if err != nil {
+				return err
+			}
+
+			if len(res.Stashed) > 0 {
+				fmt.Printf("Stashed %d change(s)\n", len(res.Stashed))
+			}
+			if res.Head == "" {
+				fmt.Printf("Switched to stream %s; it has no changesets, so the working tree was kept\n", res.Stream.Name)
+			} else {
+				fmt.Printf("Switched to stream %s at %s (%d files, %d removed)\n", res.Stream.Name, res.Head, res.Written, res.Removed)
+			}
+			if len(res.Unstashed) > 0 {
+				fmt.Printf("Restored %d stashed change(s):\n", len(res.Unstashed))
+				for _, path := range res.Unstashed {
+					fmt.Printf("\t%s\n", path)
+				}
+			}
+			return nil
+		},
+	}
+	switchCmd.Flags().Bool("stash", false, "Set uncommitted changes aside instead of refusing to switch")
+	return switchCmd
+}
@@ -0,0 +1,104 @@
+// cmd/tig/sync.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"tig/internal/parcel"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var pushCmd = &cobra.Command{
+		Use:   "push [url]",
+		Short: "Send changesets, intents and streams to a tig server",
+		Long: `Send a tig server the history it lacks: changesets with the content they
+reference, intents, streams, release tags and attestations. The two
+sides first exchange inventories, so only what the server is missing is
+sent. Of an intent or stream changed on both sides, the newer version
+wins.
+
+The URL defaults to the repository's remote, set when it was cloned. A
+repository without a remote takes the first URL it pushes to or pulls
+from as its remote.`,
+		Example: `  tig push
+  tig push http://tig.example.com:8080`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSync(cmd, args, (*parcel.Parcel).Push)
+		},
+	}
+	pushCmd.Flags().Bool("json", false, "Output the result as JSON")
+	rootCmd.AddCommand(pushCmd)
+
+	var pullCmd = &cobra.Command{
+		Use:   "pull [url]",
+		Short: "Fetch changesets, intents and streams from a tig server",
+		Long: `Fetch the history this repository lacks from a tig server: changesets
+with their content, intents, streams, release tags and attestations.
+Partial clones record the content as remote and fetch it when it is
+first needed. Of an intent or stream changed on both sides, the newer
+version wins.
+
+When the current stream gains new changesets and the working tree is
+clean, the tree is updated to the stream's new head. With uncommitted
+changes it is left alone; commit them and run tig stream switch.
+
+The URL defaults to the repository's remote.`,
+		Example: `  tig pull
+  tig pull http://tig.example.com:8080`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSync(cmd, args, (*parcel.Parcel).Pull)
+		},
+	}
+	pullCmd.Flags().Bool("json", false, "Output the result as JSON")
+	rootCmd.AddCommand(pullCmd)
+}
+
+// runSync runs a push or pull and reports its result
+func runSync(cmd *cobra.Command, args []string, sync func(*parcel.Parcel, context.Context, string) (*parcel.SyncResult, error)) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	var url string
+	if len(args) > 0 {
+		url = args[0]
+	}
+
+	p, err := initParcel()
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	res, err := sync(p, cmd.Context(), url)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	if res.ChangeSets == 0 && res.Records == 0 {
+		fmt.Printf("Up to date with %s\n", res.Remote)
+		return nil
+	}
+	verb := "Pushed to"
+	if cmd.Name() == "pull" {
+		verb = "Pulled from"
+	}
+	fmt.Printf("%s %s: %d changeset(s), %d record(s), %d object(s) (%s)\n",
+		verb, res.Remote, res.ChangeSets, res.Records, res.Blobs, formatBytes(res.Bytes))
+	switch {
+	case res.Updated:
+		fmt.Printf("Working tree updated to %s\n", res.Head)
+	case res.Dirty:
+		fmt.Println("The current stream moved on, but the working tree has uncommitted changes; commit them and run tig stream switch")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// cmd/tig/telemetry.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"tig/internal/config"
+	"tig/internal/parcel"
+	"tig/internal/telemetry"
+
+	"github.com/spf13/cobra"
+)
+
+// repoFiles is the number of files tracked in the repository the command
+// opened, or -1 outside a repository. It is only counted when usage
+// statistics are enabled.
+var repoFiles = -1
+
+// noteRepoSize counts the files of the opened repository for usage
+// statistics, if they are enabled
+func noteRepoSize(p *parcel.Parcel) {
+	if !config.TelemetryEnabled() {
+		return
+	}
+	if n, err := telemetry.TrackedFiles(p.DB); err == nil {
+		repoFiles = n
+	}
+}
+
+// recordUsage adds the finished command to the usage statistics, if they
+// are enabled. Failing to record never fails the command.
+func recordUsage(cmd *cobra.Command, d time.Duration, err error) {
+	if cmd == nil || cmd == rootCmd || strings.HasPrefix(cmd.Name(), "__") || !config.TelemetryEnabled() {
+		return
+	}
+	name := strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" ")
+	telemetry.Record(name, d, telemetry.SizeBucket(repoFiles), err != nil)
+}
+
+// formatSizes lists the repository size buckets of a command's runs
+func formatSizes(sizes map[string]int) string {
+	var buckets []string
+	for _, b := range telemetry.SizeBuckets {
+		if n := sizes[b]; n > 0 {
+			buckets = append(buckets, fmt.Sprintf("%s:%d", b, n))
+		}
+	}
+	return strings.Join(buckets, " ")
+}
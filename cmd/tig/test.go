@@ -0,0 +1,176 @@
+// cmd/tig/test.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"tig/internal/intent"
+	"tig/internal/junit"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var testCmd = &cobra.Command{
+		Use:   "test",
+		Short: "Record test results and coverage against intents",
+	}
+
+	var recordCmd = &cobra.Command{
+		Use:   "record --intent <id> --junit <file>",
+		Short: "Record a JUnit report's results against an intent",
+		Long: `Read a JUnit XML report and record how many tests passed, failed and were
+skipped as a check on the intent, named "tests" unless --name says
+otherwise. The check passes when no test failed or errored, and replaces
+the intent's earlier result for the same check.
+
+Recorded results show in tig intent show and the intent's checks over
+the API, and tig bisect auto marks intents good or bad from them.`,
+		Example: `  tig test record --intent 3f2a --junit build/test-results.xml
+  go test -json ./... | go-junit-report | tig test record --intent 3f2a --junit -
+  tig test record --intent 3f2a --junit e2e.xml --name e2e --url https://ci.example.com/runs/812`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, _ := cmd.Flags().GetString("intent")
+			file, _ := cmd.Flags().GetString("junit")
+			name, _ := cmd.Flags().GetString("name")
+			url, _ := cmd.Flags().GetString("url")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			if id == "" || file == "" {
+				return &usageError{fmt.Errorf("--intent and --junit are required")}
+			}
+
+			var r io.Reader = os.Stdin
+			if file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+			summary, err := junit.Parse(r)
+			if err != nil {
+				return err
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			i, err := p.RecordTests(id, name, summary, url)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(summary)
+			}
+			fmt.Printf("Recorded %s on intent %s: %s (%s)\n", name, i.ID, summary.Status(), summary)
+			for _, failure := range summary.Failures {
+				fmt.Printf("  failed: %s\n", failure)
+			}
+			return nil
+		},
+	}
+	recordCmd.Flags().String("intent", "", "Intent the tests ran against (ID or prefix)")
+	recordCmd.Flags().String("junit", "", "JUnit XML report to read, or - for standard input")
+	recordCmd.Flags().String("name", intent.TestsCheck, "Name of the check to record the results as")
+	recordCmd.Flags().String("url", "", "Link to the test run, such as a CI job")
+	recordCmd.Flags().Bool("json", false, "Output the recorded summary as JSON")
+	recordCmd.RegisterFlagCompletionFunc("intent", completeIntents)
+
+	var coverageCmd = &cobra.Command{
+		Use:   "coverage --intent <id> --report <file>",
+		Short: "Record a coverage report's results against an intent",
+		Long: `Read a coverage report, a Go coverprofile or an lcov tracefile, and record
+on the intent how much of each file its changeset changed is covered.
+Each file is compared with the coverage last recorded for it, by any
+intent, and the change in percentage points is kept with the intent.
+
+Streams whose protection sets max_coverage_drop refuse to merge an
+intent without recorded coverage, or whose coverage of the changed files
+fell further than that.`,
+		Example: `  go test -coverprofile=cover.out ./... && tig test coverage --intent 3f2a --report cover.out
+  tig test coverage --intent 3f2a --report coverage/lcov.info`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, _ := cmd.Flags().GetString("intent")
+			file, _ := cmd.Flags().GetString("report")
+			format, _ := cmd.Flags().GetString("format")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			if id == "" || file == "" {
+				return &usageError{fmt.Errorf("--intent and --report are required")}
+			}
+
+			var r io.Reader = os.Stdin
+			if file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			i, err := p.RecordCoverage(id, r, format)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(i.Coverage)
+			}
+			fmt.Printf("Recorded coverage on intent %s: %s\n", i.ID, formatCoverage(i.Coverage))
+			for _, f := range i.Coverage.Files {
+				line := fmt.Sprintf("  %-40s %5.1f%% (%d/%d)", f.Path, f.Percent(), f.Covered, f.Total)
+				if f.Previous != nil {
+					line += fmt.Sprintf("  was %.1f%%", f.Previous.Percent())
+				}
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+	coverageCmd.Flags().String("intent", "", "Intent the tests ran against (ID or prefix)")
+	coverageCmd.Flags().String("report", "", "Coverage report to read, or - for standard input")
+	coverageCmd.Flags().String("format", "", "Report format, go or lcov; detected when not given")
+	coverageCmd.Flags().Bool("json", false, "Output the recorded coverage as JSON")
+	coverageCmd.RegisterFlagCompletionFunc("intent", completeIntents)
+
+	testCmd.AddCommand(recordCmd)
+	testCmd.AddCommand(coverageCmd)
+	rootCmd.AddCommand(testCmd)
+}
+
+// formatCoverage describes the coverage of an intent's changed files,
+// e.g. "82.5% of 3 changed file(s), down 1.2 points"
+func formatCoverage(c *intent.Coverage) string {
+	out := fmt.Sprintf("%.1f%% of %d changed file(s)", c.Percent(), len(c.Files))
+	switch {
+	case c.Previous == nil:
+		out += ", no earlier coverage"
+	case c.Delta > 0:
+		out += fmt.Sprintf(", up %.1f points", c.Delta)
+	case c.Delta < 0:
+		out += fmt.Sprintf(", down %.1f points", -c.Delta)
+	default:
+		out += ", unchanged"
+	}
+	return out
+}
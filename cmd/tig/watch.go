@@ -0,0 +1,109 @@
+// cmd/tig/watch.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"tig/internal/change"
+	"tig/internal/glob"
+
+	"github.com/spf13/cobra"
+)
+
+// watchOutput is a change event as printed by tig watch
+type watchOutput struct {
+	change.WatchEvent
+	Gated bool   `json:"gated,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func init() {
+	var watchCmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Print file changes as they happen",
+		Long: `Watch the working tree and print a line for every file that is created,
+modified or deleted. Events are batched using the repository's watch
+flush_interval.
+
+Paths matching a --gate pattern are gated automatically as they change.`,
+		Example: `  tig watch
+  tig watch --json
+  tig watch --gate 'docs/**' --gate '*.md'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+			patterns, _ := cmd.Flags().GetStringSlice("gate")
+
+			p, err := initParcel()
+			if err != nil {
+				return err
+			}
+			defer p.Close()
+
+			events, cancel, err := p.Watch()
+			if err != nil {
+				return err
+			}
+			defer cancel()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if !asJSON {
+				fmt.Fprintf(os.Stderr, "Watching %s (Ctrl-C to stop)\n", p.Root)
+			}
+
+			enc := json.NewEncoder(os.Stdout)
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case e := <-events:
+					out := watchOutput{WatchEvent: e}
+					if e.Op != change.WatchDelete && matchesAny(patterns, e.Path) {
+						if err := p.Gate([]string{e.Path}); err != nil {
+							out.Error = err.Error()
+						} else {
+							out.Gated = true
+						}
+					}
+
+					if asJSON {
+						if err := enc.Encode(out); err != nil {
+							return err
+						}
+						continue
+					}
+
+					line := fmt.Sprintf("%s  %-6s  %s", e.Time.Format("15:04:05"), e.Op, e.Path)
+					switch {
+					case out.Gated:
+						line += "  (gated)"
+					case out.Error != "":
+						line += "  (gate failed: " + out.Error + ")"
+					}
+					fmt.Println(line)
+				}
+			}
+		},
+	}
+
+	watchCmd.Flags().Bool("json", false, "Print events as JSON lines")
+	watchCmd.Flags().StringSlice("gate", nil, "Automatically gate changed paths matching this glob (repeatable)")
+	rootCmd.AddCommand(watchCmd)
+}
+
+// matchesAny reports whether path matches one of the glob patterns
+func matchesAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if glob.MatchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
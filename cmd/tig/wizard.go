@@ -0,0 +1,177 @@
+// cmd/tig/wizard.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"tig/internal/config"
+	"tig/internal/parcel"
+)
+
+// prompter asks questions on the terminal. At the end of input every
+// question takes its default, so the wizard can also run unattended.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prints question with its default and returns the answer
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	line, err := p.in.ReadString('\n')
+	if err == io.EOF {
+		fmt.Fprintln(p.out)
+	} else if err != nil {
+		return "", err
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// confirm asks a yes/no question
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := p.ask(question, hint)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return def, nil
+}
+
+// runInitWizard initializes a repository in dir step by step: author
+// identity, ignore patterns, a default stream and initial gating
+func runInitWizard(in io.Reader, out io.Writer, dir string) error {
+	q := &prompter{in: bufio.NewReader(in), out: out}
+	fmt.Fprintf(out, "Initializing a Tig repository in %s\n", dir)
+
+	// Author identity
+	fmt.Fprintf(out, "\nStep 1 of 4: author\n")
+	current := config.Author()
+	author, err := q.ask("Name recorded on your intents", current)
+	if err != nil {
+		return err
+	}
+	if author != current {
+		cfg, err := config.LoadUser()
+		if err != nil {
+			return err
+		}
+		cfg.Author = author
+		if err := config.SaveUser(cfg); err != nil {
+			return err
+		}
+		if os.Getenv(config.AuthorEnv) != "" {
+			fmt.Fprintf(out, "Saved, but %s is set and takes precedence\n", config.AuthorEnv)
+		}
+	}
+
+	if err := parcel.Initialize(dir); err != nil {
+		return fmt.Errorf("initializing repository: %w", err)
+	}
+	registerRepo(dir)
+
+	// Ignore patterns
+	fmt.Fprintf(out, "\nStep 2 of 4: ignore patterns\n")
+	suggestions, err := parcel.DetectIgnores(dir)
+	if err != nil {
+		return err
+	}
+	if len(suggestions) == 0 {
+		fmt.Fprintln(out, "No dependency or build directories or large binaries found")
+	} else {
+		fmt.Fprintln(out, "These look like files that should not be versioned:")
+		for _, s := range suggestions {
+			fmt.Fprintf(out, "  %-32s %s\n", s.Pattern, s.Reason)
+		}
+		exclude, err := q.confirm("Exclude them from tracking?", true)
+		if err != nil {
+			return err
+		}
+		if exclude {
+			cfg, err := config.LoadRepo(dir)
+			if err != nil {
+				return err
+			}
+			for _, s := range suggestions {
+				if !slices.Contains(cfg.Watch.Exclude, s.Pattern) {
+					cfg.Watch.Exclude = append(cfg.Watch.Exclude, s.Pattern)
+				}
+			}
+			if err := config.SaveRepo(dir, cfg); err != nil {
+				return fmt.Errorf("writing repo config: %w", err)
+			}
+			fmt.Fprintf(out, "Added %d pattern(s) to watch.exclude in %s\n", len(suggestions), config.RepoConfigPath(dir))
+		}
+	}
+
+	p, err := initParcel()
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	// Default stream
+	fmt.Fprintf(out, "\nStep 3 of 4: default stream\n")
+	name, err := q.ask("Default stream name (- to skip)", "main")
+	if err != nil {
+		return err
+	}
+	if name != "-" {
+		streamType, err := q.ask("Stream type (feature, release, hotfix)", "feature")
+		if err != nil {
+			return err
+		}
+		s, err := p.CreateStream(name, streamType)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Created stream %s (%s)\n", s.Name, s.ID[:8])
+	}
+
+	// Initial gating
+	fmt.Fprintf(out, "\nStep 4 of 4: initial gating\n")
+	gate, err := q.confirm("Gate the existing files for the first intent?", true)
+	if err != nil {
+		return err
+	}
+	if gate {
+		if err := p.Gate([]string{"."}); err != nil {
+			return fmt.Errorf("gating files: %w", err)
+		}
+		changes, err := p.Status()
+		if err != nil {
+			return err
+		}
+		gated := 0
+		for _, c := range changes {
+			if c.Gated {
+				gated++
+			}
+		}
+		fmt.Fprintf(out, "Gated %d file(s)\n", gated)
+	}
+
+	fmt.Fprintln(out, "\nRepository ready. Record the first intent with:")
+	fmt.Fprintln(out, `  tig intent create "Initial import" -d "Initial import"`)
+	return nil
+}
@@ -0,0 +1,175 @@
+// internal/api/assign_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tig/internal/assign"
+	tigerrors "tig/internal/errors"
+	"tig/internal/events"
+	"tig/internal/intent"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// AssignHandler manages reviewer groups and the review assignments made
+// from them
+type AssignHandler struct {
+	db      *badger.DB
+	intents intent.Box
+	events  events.Publisher
+}
+
+func NewAssignHandler(db *badger.DB, intents intent.Box) *AssignHandler {
+	return &AssignHandler{db: db, intents: intents}
+}
+
+// WithEvents sets the publisher notified of new assignments
+func (h *AssignHandler) WithEvents(p events.Publisher) *AssignHandler {
+	h.events = p
+	return h
+}
+
+// Groups lists the reviewer groups
+func (h *AssignHandler) Groups(w http.ResponseWriter, r *http.Request) {
+	groups, err := assign.Groups(h.db)
+	if err != nil {
+		writeAssignError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// SetGroup creates or replaces the reviewer group {name}
+func (h *AssignHandler) SetGroup(w http.ResponseWriter, r *http.Request) {
+	var g assign.Group
+	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	g.Name = r.PathValue("name")
+	if err := assign.SetGroup(h.db, &g); err != nil {
+		writeAssignError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(g)
+}
+
+// Open lists open assignments, oldest first, of ?reviewer= or everyone
+func (h *AssignHandler) Open(w http.ResponseWriter, r *http.Request) {
+	open, err := assign.Open(h.db, r.URL.Query().Get("reviewer"))
+	if err != nil {
+		writeAssignError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(open)
+}
+
+// List returns the review assignments of intent {id}
+func (h *AssignHandler) List(w http.ResponseWriter, r *http.Request) {
+	i, err := h.intents.Get(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	assignments, err := assign.ForIntent(h.db, i.ID)
+	if err != nil {
+		writeAssignError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assignments)
+}
+
+// Assign assigns reviewers to intent {id}: the named reviewer, or count
+// members (default 1) of group chosen by its strategy
+func (h *AssignHandler) Assign(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reviewer string `json:"reviewer"`
+		Group    string `json:"group"`
+		Count    int    `json:"count"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Reviewer == "") == (req.Group == "") {
+		http.Error(w, "one of reviewer or group is required", http.StatusBadRequest)
+		return
+	}
+	i, err := h.intents.Get(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var assigned []assign.Assignment
+	if req.Reviewer != "" {
+		a, err := assign.Add(h.db, i.ID, req.Reviewer)
+		if err != nil {
+			writeAssignError(w, err)
+			return
+		}
+		assigned = append(assigned, *a)
+	} else {
+		if assigned, err = assign.Assign(h.db, req.Group, i, max(1, req.Count)); err != nil {
+			writeAssignError(w, err)
+			return
+		}
+	}
+	for _, a := range assigned {
+		h.publish(i, a)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assigned)
+}
+
+// Reassign hands {reviewer}'s open assignment to intent {id} to the
+// reviewer named in the body, or to the next member of its group
+func (h *AssignHandler) Reassign(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		To string `json:"to"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	i, err := h.intents.Get(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	a, err := assign.Reassign(h.db, i, r.PathValue("reviewer"), req.To)
+	if err != nil {
+		writeAssignError(w, err)
+		return
+	}
+	h.publish(i, *a)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+// publish announces an assignment
+func (h *AssignHandler) publish(i *intent.Intent, a assign.Assignment) {
+	if h.events == nil {
+		return
+	}
+	h.events.Publish(events.Event{
+		Type:     events.ReviewAssigned,
+		IntentID: i.ID,
+		Summary:  i.Description,
+		Data:     map[string]string{"reviewer": a.Reviewer, "group": a.Group, "delegated_from": a.DelegatedFrom},
+	})
+}
+
+func writeAssignError(w http.ResponseWriter, err error) {
+	var apiErr *tigerrors.Error
+	if errors.As(err, &apiErr) {
+		http.Error(w, err.Error(), apiErr.Code)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
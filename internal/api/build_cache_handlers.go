@@ -0,0 +1,73 @@
+// internal/api/build_cache_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tig/internal/buildcache"
+	tigerrors "tig/internal/errors"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// BuildCacheHandler maps source states to cached build artifacts so CI
+// can skip rebuilding a state that was already built
+type BuildCacheHandler struct {
+	db *badger.DB
+}
+
+func NewBuildCacheHandler(db *badger.DB) *BuildCacheHandler {
+	return &BuildCacheHandler{db: db}
+}
+
+// Get returns the cached build of {key}, a changeset ID or tree hash
+func (h *BuildCacheHandler) Get(w http.ResponseWriter, r *http.Request) {
+	key, _, err := buildcache.Resolve(h.db, r.PathValue("key"))
+	if err != nil {
+		writeBuildCacheError(w, err)
+		return
+	}
+	e, err := buildcache.Get(h.db, key)
+	if err != nil {
+		writeBuildCacheError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+// Set records the artifacts built from {key}, replacing any earlier
+// entry. The body is a buildcache.Entry; its key is taken from the path.
+func (h *BuildCacheHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var e buildcache.Entry
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	key, changeSetID, err := buildcache.Resolve(h.db, r.PathValue("key"))
+	if err != nil {
+		writeBuildCacheError(w, err)
+		return
+	}
+	e.Key = key
+	if changeSetID != "" {
+		e.ChangeSetID = changeSetID
+	}
+	if err := buildcache.Set(h.db, &e); err != nil {
+		writeBuildCacheError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+func writeBuildCacheError(w http.ResponseWriter, err error) {
+	var apiErr *tigerrors.Error
+	if errors.As(err, &apiErr) {
+		http.Error(w, err.Error(), apiErr.Code)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,32 @@
+// internal/api/conflict_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"tig/internal/conflict"
+)
+
+// ConflictHandler reports potential conflicts between open intents
+type ConflictHandler struct {
+	detector *conflict.Detector
+}
+
+func NewConflictHandler(detector *conflict.Detector) *ConflictHandler {
+	return &ConflictHandler{detector: detector}
+}
+
+func (h *ConflictHandler) List(w http.ResponseWriter, r *http.Request) {
+	conflicts, err := h.detector.Detect()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if conflicts == nil {
+		conflicts = []conflict.Conflict{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conflicts)
+}
@@ -0,0 +1,75 @@
+// internal/api/coverage_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"tig/internal/coverage"
+	"tig/internal/events"
+	"tig/internal/intent"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// CoverageHandler records coverage reports against intents
+type CoverageHandler struct {
+	db      *badger.DB
+	intents intent.Box
+	events  events.Publisher
+}
+
+func NewCoverageHandler(db *badger.DB, intents intent.Box) *CoverageHandler {
+	return &CoverageHandler{db: db, intents: intents}
+}
+
+// WithEvents sets the publisher notified when coverage is recorded
+func (h *CoverageHandler) WithEvents(p events.Publisher) *CoverageHandler {
+	h.events = p
+	return h
+}
+
+// Record reads a coverage report, a Go coverprofile or an lcov tracefile
+// sent as the request body, and records the coverage of the files intent
+// {id} changed. ?format= names the format when it can't be detected.
+func (h *CoverageHandler) Record(w http.ResponseWriter, r *http.Request) {
+	i, err := h.intents.Get(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	profile, format, err := coverage.Parse(r.Body, r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if i.ChangeSetID == "" {
+		http.Error(w, fmt.Sprintf("intent %s has no changeset to measure coverage of", i.ID), http.StatusConflict)
+		return
+	}
+	if i.Coverage, err = coverage.Record(h.db, i, profile, format, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := h.intents.Update(i); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if h.events != nil {
+		h.events.Publish(events.Event{
+			Type:     events.CoverageRecorded,
+			IntentID: i.ID,
+			Summary:  i.Description,
+			Data: map[string]string{
+				"percent": fmt.Sprintf("%.1f", i.Coverage.Percent()),
+				"delta":   fmt.Sprintf("%+.1f", i.Coverage.Delta),
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(i)
+}
@@ -0,0 +1,214 @@
+// internal/api/deploy_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"tig/internal/deploy"
+	tigerrors "tig/internal/errors"
+	"tig/internal/events"
+	"tig/internal/intent"
+	"tig/internal/safe"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DeployHandler records which release or changeset runs in each
+// environment
+type DeployHandler struct {
+	db      *badger.DB
+	safe    *safe.Safe
+	intents intent.Box
+	streams stream.Box
+	events  events.Publisher
+}
+
+func NewDeployHandler(db *badger.DB, s *safe.Safe, intents intent.Box, streams stream.Box) *DeployHandler {
+	return &DeployHandler{db: db, safe: s, intents: intents, streams: streams}
+}
+
+// WithEvents sets the publisher notified of each deployment
+func (h *DeployHandler) WithEvents(p events.Publisher) *DeployHandler {
+	h.events = p
+	return h
+}
+
+// deployRequest names what was deployed: a release tag or a stream ID,
+// whose head changeset is taken
+type deployRequest struct {
+	Environment string `json:"environment"`
+	Release     string `json:"release,omitempty"`
+	Stream      string `json:"stream,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// Record stores a deployment and marks its intents as deployed to the
+// environment
+func (h *DeployHandler) Record(w http.ResponseWriter, r *http.Request) {
+	var req deployRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var d *deploy.Deployment
+	var err error
+	switch {
+	case (req.Release == "") == (req.Stream == ""):
+		http.Error(w, "give either a release or a stream", http.StatusBadRequest)
+		return
+	case req.Release != "":
+		d, err = deploy.FromRelease(h.db, h.safe, req.Environment, req.Release)
+	default:
+		st, serr := h.streams.Get(req.Stream)
+		if serr != nil {
+			http.Error(w, serr.Error(), http.StatusNotFound)
+			return
+		}
+		d, err = deploy.FromStream(req.Environment, st, st.State.Head)
+	}
+	if err != nil {
+		writeDeployError(w, err)
+		return
+	}
+	d.URL = req.URL
+
+	first, err := deploy.Record(h.db, h.intents, d)
+	if err != nil {
+		writeDeployError(w, err)
+		return
+	}
+
+	if h.events != nil {
+		h.events.Publish(events.Event{
+			Type:     events.Deployed,
+			StreamID: d.StreamID,
+			Summary:  fmt.Sprintf("%d intent(s) newly deployed", len(first)),
+			Data: map[string]string{
+				"environment": d.Environment,
+				"source":      d.Source(),
+				"changeset":   d.ChangeSetID,
+				"intents":     strings.Join(first, ","),
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(d)
+}
+
+// List returns deployments newest first, to one environment when
+// ?environment= is given
+func (h *DeployHandler) List(w http.ResponseWriter, r *http.Request) {
+	list, err := deploy.List(h.db, r.URL.Query().Get("environment"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []deploy.Deployment{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// Current returns the latest deployment to each environment
+func (h *DeployHandler) Current(w http.ResponseWriter, r *http.Request) {
+	list, err := deploy.Current(h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []deploy.Deployment{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// rollbackRequest names the environment to roll back and, optionally, the
+// release to roll it back to
+type rollbackRequest struct {
+	Environment string `json:"environment"`
+	Release     string `json:"release,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// PlanRollback returns how ?environment= would be rolled back, to
+// ?release= or its last good deployment, without changing anything
+func (h *DeployHandler) PlanRollback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	plan, err := h.planRollback(q.Get("environment"), q.Get("release"))
+	if err != nil {
+		writeDeployError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// Rollback redeploys the last good deployment of an environment, or a
+// given release, and returns the plan it carried out
+func (h *DeployHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	var req rollbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	plan, err := h.planRollback(req.Environment, req.Release)
+	if err != nil {
+		writeDeployError(w, err)
+		return
+	}
+	d, err := deploy.ApplyRollback(h.db, h.intents, plan, req.URL, time.Now().UTC())
+	if err != nil {
+		writeDeployError(w, err)
+		return
+	}
+
+	if h.events != nil {
+		h.events.Publish(events.Event{
+			Type:     events.RolledBack,
+			StreamID: d.StreamID,
+			Summary:  fmt.Sprintf("%d intent(s) rolled back", len(plan.Intents)),
+			Data: map[string]string{
+				"environment": plan.Environment,
+				"from":        plan.From.Source(),
+				"to":          d.Source(),
+				"changeset":   d.ChangeSetID,
+				"manifest":    d.Manifest,
+				"intents":     strings.Join(plan.Intents, ","),
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+func (h *DeployHandler) planRollback(env, tag string) (*deploy.Rollback, error) {
+	var target *deploy.Deployment
+	if tag != "" {
+		var err error
+		if target, err = deploy.FromRelease(h.db, h.safe, env, tag); err != nil {
+			return nil, err
+		}
+	}
+	return deploy.PlanRollback(h.db, env, target)
+}
+
+func writeDeployError(w http.ResponseWriter, err error) {
+	var apiErr *tigerrors.Error
+	if errors.As(err, &apiErr) {
+		http.Error(w, err.Error(), apiErr.Code)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
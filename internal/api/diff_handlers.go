@@ -0,0 +1,157 @@
+// internal/api/diff_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"tig/internal/diff"
+	"tig/internal/highlight"
+	"tig/internal/intent"
+	"tig/internal/review"
+	"tig/internal/safe"
+	"tig/internal/storage"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DiffHandler serves the changes of intents as highlighted side-by-side
+// diffs
+type DiffHandler struct {
+	db          *badger.DB
+	safe        *safe.Safe
+	intents     intent.Box
+	highlighter *highlight.Highlighter
+	opts        review.Options
+}
+
+func NewDiffHandler(db *badger.DB, s *safe.Safe, intents intent.Box, h *highlight.Highlighter) *DiffHandler {
+	return &DiffHandler{db: db, safe: s, intents: intents, highlighter: h}
+}
+
+// WithOptions chooses how JSON, YAML and notebook files are diffed
+func (h *DiffHandler) WithOptions(opts review.Options) *DiffHandler {
+	h.opts = opts
+	return h
+}
+
+// Intent returns the files changed by an intent. Code in each row is
+// escaped HTML, highlighted with the classes served at /highlight.css.
+// With ?view=hunks each file is returned as plain-text hunks instead, and
+// with unified=true also as unified diff text. ?structural=true diffs
+// every JSON and YAML file key by key and ?structural=false none; by
+// default the configured patterns pick them. Notebook outputs are shown
+// as configured unless ?notebook_outputs= says otherwise.
+func (h *DiffHandler) Intent(w http.ResponseWriter, r *http.Request) {
+	i, err := h.intents.Get(pathID(r))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	opts := h.opts
+	if v := r.URL.Query().Get("structural"); v != "" {
+		all, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "structural must be true or false", http.StatusBadRequest)
+			return
+		}
+		opts.Structural = nil
+		if all {
+			opts.Structural = func(string) bool { return true }
+		}
+	}
+	if v := r.URL.Query().Get("notebook_outputs"); v != "" {
+		outputs, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "notebook_outputs must be true or false", http.StatusBadRequest)
+			return
+		}
+		opts.NotebookOutputs = outputs
+	}
+
+	switch r.URL.Query().Get("view") {
+	case "", "rows":
+	case "hunks":
+		unified, _ := strconv.ParseBool(r.URL.Query().Get("unified"))
+		patches, err := review.Patches(h.db, h.safe, i, unified, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(patches)
+		return
+	default:
+		http.Error(w, "view must be rows or hunks", http.StatusBadRequest)
+		return
+	}
+
+	bundle, err := review.Build(h.db, h.safe, h.highlighter, i, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	files := bundle.Files
+	if files == nil {
+		files = []review.File{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(files)
+}
+
+// Thumbnail sizes in pixels
+const (
+	defaultThumbnailSize = 128
+	maxThumbnailSize     = 1024
+)
+
+// Thumbnail returns a PNG or JPEG image stored under {hash} scaled down
+// to fit within ?size= pixels square, for image diffs in the web UI
+func (h *DiffHandler) Thumbnail(w http.ResponseWriter, r *http.Request) {
+	size := defaultThumbnailSize
+	if v := r.URL.Query().Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxThumbnailSize {
+			http.Error(w, fmt.Sprintf("size must be between 1 and %d", maxThumbnailSize), http.StatusBadRequest)
+			return
+		}
+		size = n
+	}
+
+	data, err := h.safe.Get(r.PathValue("hash"))
+	switch {
+	case errors.Is(err, safe.ErrInvalidHash):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, safe.ErrContentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	thumb, err := diff.Thumbnail(data, size)
+	if err != nil {
+		http.Error(w, "content is not a PNG or JPEG image", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Write(thumb)
+}
+
+// CSS serves the stylesheet for highlighted code
+func (h *DiffHandler) CSS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/css")
+	w.Write([]byte(highlight.CSS()))
+}
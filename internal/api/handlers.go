@@ -8,7 +8,10 @@ import (
 	"net/http"
 	"time"
 
+	"tig/internal/config"
 	"tig/internal/errors"
+	"tig/internal/events"
+	"tig/internal/hooks"
 	"tig/internal/intent"
 	"tig/internal/stream"
 
@@ -103,13 +106,59 @@ func (m *MockIntentBox) FindWithBreakingChanges() ([]*intent.Intent, error) {
 }
 
 type IntentHandler struct {
-    box intent.Box
+    box    intent.Box
+    events events.Publisher
+    fields config.IntentFields
+    hooks  *hooks.Runner
+    workflow config.Workflow
 }
 
 func NewIntentHandler(box intent.Box) *IntentHandler {
     return &IntentHandler{box: box}
 }
 
+// WithEvents sets the publisher notified about intent lifecycle events
+func (h *IntentHandler) WithEvents(p events.Publisher) *IntentHandler {
+    h.events = p
+    return h
+}
+
+// WithFields sets the repository's intent metadata schema that created
+// and updated intents must follow
+func (h *IntentHandler) WithFields(fields config.IntentFields) *IntentHandler {
+    h.fields = fields
+    return h
+}
+
+// WithHooks sets the server-side hooks run as intents are received
+func (h *IntentHandler) WithHooks(r *hooks.Runner) *IntentHandler {
+    h.hooks = r
+    return h
+}
+
+// publishCreated emits creation events for a newly stored intent
+func (h *IntentHandler) publishCreated(i *intent.Intent) {
+    if h.events == nil {
+        return
+    }
+
+    e := events.Event{
+        Type:     events.IntentCreated,
+        IntentID: i.ID,
+        Summary:  i.Description,
+        Data: map[string]string{
+            "type":   i.Type,
+            "author": i.Metadata.Author,
+        },
+    }
+    h.events.Publish(e)
+
+    if i.Impact.Breaking {
+        e.Type = events.BreakingChange
+        h.events.Publish(e)
+    }
+}
+
 func (h *IntentHandler) Create(w http.ResponseWriter, r *http.Request) {
     var i intent.Intent
     if err := json.NewDecoder(r.Body).Decode(&i); err != nil {
@@ -122,26 +171,46 @@ func (h *IntentHandler) Create(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "description is required", http.StatusBadRequest)
         return
     }
+    if err := intent.CheckExtensions(i.Extensions, h.fields); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    if i.State != "" && !intent.ValidState(i.State) {
+        http.Error(w, "unknown state "+i.State, http.StatusBadRequest)
+        return
+    }
 
     // Set system fields
     i.ID = uuid.New().String()
+    i.StateHistory = nil
     i.CreatedAt = time.Now()
     i.UpdatedAt = i.CreatedAt
 
+    // Pre-receive hooks may reject the intent; their message is relayed
+    if err := h.hooks.Run(r.Context(), hooks.Payload{Event: hooks.PreReceiveIntent, Intent: &i}); err != nil {
+        if _, ok := err.(*hooks.Rejection); ok {
+            http.Error(w, err.Error(), http.StatusForbidden)
+            return
+        }
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
     if err := h.box.Create(&i); err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
 
+    h.hooks.Run(r.Context(), hooks.Payload{Event: hooks.PostReceiveIntent, Intent: &i})
+    h.publishCreated(&i)
+
     w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(http.StatusCreated)
     json.NewEncoder(w).Encode(i)
 }
 
 func (h *IntentHandler) Get(w http.ResponseWriter, r *http.Request) {
-    // Get ID from URL params stored in context
-    params := r.Context().Value("url_params").(map[string]string)
-    id := params["id"]
+    id := pathID(r)
     if id == "" {
         http.Error(w, "missing id", http.StatusBadRequest)
         return
@@ -163,9 +232,7 @@ func (h *IntentHandler) Get(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *IntentHandler) Update(w http.ResponseWriter, r *http.Request) {
-    // Get ID from URL params stored in context
-    params := r.Context().Value("url_params").(map[string]string)
-    id := params["id"]
+    id := pathID(r)
     if id == "" {
         http.Error(w, "missing id", http.StatusBadRequest)
         return
@@ -189,10 +256,18 @@ func (h *IntentHandler) Update(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    if err := intent.CheckExtensions(updates.Extensions, h.fields); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
     // Apply updates while preserving system fields
     updates.ID = existing.ID
     updates.CreatedAt = existing.CreatedAt
     updates.UpdatedAt = time.Now()
+    // States only change through the workflow
+    updates.State = existing.State
+    updates.StateHistory = existing.StateHistory
 
     if err := h.box.Update(&updates); err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -218,26 +293,47 @@ func (h *IntentHandler) Delete(w http.ResponseWriter, r *http.Request) {
     w.WriteHeader(http.StatusNoContent)
 }
 
+// List returns all intents, or one page with ?limit=N&after=ID. With
+// ?state= only intents in that lifecycle state are listed, unpaged.
 func (h *IntentHandler) List(w http.ResponseWriter, r *http.Request) {
+    state := r.URL.Query().Get("state")
+    if state == "" {
+        writeList(w, r, h.box, h.box.List)
+        return
+    }
+    if !intent.ValidState(state) {
+        http.Error(w, "unknown state "+state, http.StatusBadRequest)
+        return
+    }
     intents, err := h.box.List()
     if err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
-
+    matched := intent.FilterState(intents, state)
+    if matched == nil {
+        matched = []*intent.Intent{}
+    }
     w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(intents)
+    json.NewEncoder(w).Encode(matched)
 }
 
 // StreamHandler handles HTTP requests for Stream operations
 type StreamHandler struct {
-    box stream.Box
+    box    stream.Box
+    events events.Publisher
 }
 
 func NewStreamHandler(box stream.Box) *StreamHandler {
     return &StreamHandler{box: box}
 }
 
+// WithEvents sets the publisher notified when intents join a stream
+func (h *StreamHandler) WithEvents(p events.Publisher) *StreamHandler {
+    h.events = p
+    return h
+}
+
 func (h *StreamHandler) Create(w http.ResponseWriter, r *http.Request) {
     var st stream.Stream
     if err := json.NewDecoder(r.Body).Decode(&st); err != nil {
@@ -250,6 +346,10 @@ func (h *StreamHandler) Create(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "name is required", http.StatusBadRequest)
         return
     }
+    if err := st.Config.Protection.Validate(); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
 
     // Set system fields
     st.ID = uuid.New().String()
@@ -269,6 +369,11 @@ func (h *StreamHandler) Create(w http.ResponseWriter, r *http.Request) {
     json.NewEncoder(w).Encode(st)
 }
 
+// List returns all streams, or one page with ?limit=N&after=ID
+func (h *StreamHandler) List(w http.ResponseWriter, r *http.Request) {
+    writeList(w, r, h.box, h.box.List)
+}
+
 func (h *StreamHandler) Get(w http.ResponseWriter, r *http.Request) {
     id := r.PathValue("id")
     if id == "" {
@@ -325,6 +430,14 @@ func (h *StreamHandler) AddIntent(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    if h.events != nil {
+        h.events.Publish(events.Event{
+            Type:     events.IntentAdded,
+            StreamID: streamID,
+            IntentID: req.IntentID,
+        })
+    }
+
     w.WriteHeader(http.StatusOK)
 }
 
@@ -389,6 +502,42 @@ func (h *StreamHandler) SetFeatureFlag(w http.ResponseWriter, r *http.Request) {
     w.WriteHeader(http.StatusOK)
 }
 
+// SetProtection replaces a stream's protection rules, including its
+// freeze windows and scheduled changes
+func (h *StreamHandler) SetProtection(w http.ResponseWriter, r *http.Request) {
+    var protection stream.Protection
+    if err := json.NewDecoder(r.Body).Decode(&protection); err != nil {
+        http.Error(w, "invalid request body", http.StatusBadRequest)
+        return
+    }
+    if err := protection.Validate(); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    if protection.RequiredChecks == nil {
+        protection.RequiredChecks = []string{}
+    }
+
+    st, err := h.box.Get(r.PathValue("id"))
+    if err != nil {
+        if _, ok := err.(*errors.Error); ok {
+            http.Error(w, err.Error(), http.StatusNotFound)
+            return
+        }
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    st.Config.Protection = protection
+    st.UpdatedAt = time.Now()
+    if err := h.box.Update(st); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(st)
+}
+
 func (h *StreamHandler) GetFeatureFlags(w http.ResponseWriter, r *http.Request) {
     streamID := r.PathValue("id")
     if streamID == "" {
@@ -410,6 +559,16 @@ func (h *StreamHandler) GetFeatureFlags(w http.ResponseWriter, r *http.Request)
     json.NewEncoder(w).Encode(st.Config.FeatureFlags)
 }
 
+// pathID returns the {id} path value, falling back to parameters set
+// with WithURLParams
+func pathID(r *http.Request) string {
+    if id := r.PathValue("id"); id != "" {
+        return id
+    }
+    params, _ := r.Context().Value("url_params").(map[string]string)
+    return params["id"]
+}
+
 // WithURLParams adds URL parameters to request context for testing
 func WithURLParams(ctx context.Context, params map[string]string) context.Context {
     return context.WithValue(ctx, "url_params", params)
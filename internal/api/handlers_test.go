@@ -50,6 +50,16 @@ func TestIntentHandler_Create(t *testing.T) {
             wantStatus: http.StatusBadRequest,
             wantErr:    true,
         },
+        {
+            name: "undefined extension field",
+            input: map[string]interface{}{
+                "type":        "feature",
+                "description": "Test feature intent",
+                "extensions":  map[string]interface{}{"risk": "high"},
+            },
+            wantStatus: http.StatusBadRequest,
+            wantErr:    true,
+        },
     }
 
     for _, tt := range tests {
@@ -0,0 +1,39 @@
+// internal/api/health_handlers.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"tig/internal/health"
+)
+
+// deepCheckTimeout bounds how long a deep health check may take
+const deepCheckTimeout = 5 * time.Second
+
+// HealthHandler serves the deep health check
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Deep probes every subsystem and reports each one's status. The response
+// is 503 when any subsystem is failing, so orchestrators can act on the
+// status code alone.
+func (h *HealthHandler) Deep(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), deepCheckTimeout)
+	defer cancel()
+
+	report := h.checker.Run(ctx)
+
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Healthy() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,132 @@
+// internal/api/history_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tig/internal/storage"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// maxEventsPage caps how many mutations one replay request returns
+const maxEventsPage = 1000
+
+// HistoryHandler serves the mutation log of intents and streams
+type HistoryHandler struct {
+	db *badger.DB
+}
+
+func NewHistoryHandler(db *badger.DB) *HistoryHandler {
+	return &HistoryHandler{db: db}
+}
+
+// EventsPage is one page of replayed mutations. Pass Next as ?after= to
+// fetch the following page.
+type EventsPage struct {
+	Mutations []storage.Mutation `json:"mutations"`
+	Next      string             `json:"next,omitempty"`
+}
+
+// Events replays the mutation log, oldest first, so webhook consumers can
+// catch up on deliveries they missed. ?after=<cursor> resumes after a
+// previous page, ?since=<RFC3339> starts at a point in time, ?entity=
+// restricts the log to "intent" or "stream" and ?limit=N caps the page
+// (default 100).
+func (h *HistoryHandler) Events(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxEventsPage {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	after := q.Get("after")
+	if v := q.Get("since"); v != "" {
+		if after != "" {
+			http.Error(w, "after and since are mutually exclusive", http.StatusBadRequest)
+			return
+		}
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "invalid since: expected an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		after = storage.SinceCursor(since)
+	}
+
+	page := EventsPage{Mutations: []storage.Mutation{}}
+	err := storage.Mutations(h.db, q.Get("entity"), after, func(m storage.Mutation) error {
+		page.Mutations = append(page.Mutations, m)
+		if len(page.Mutations) == limit {
+			return storage.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n := len(page.Mutations); n > 0 {
+		page.Next = page.Mutations[n-1].Cursor
+	} else {
+		page.Next = after
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// Entity returns a handler listing the recorded mutations of one entity
+// and the fields each changed. ?at=<RFC3339> instead returns the entity's
+// state at that time.
+func (h *HistoryHandler) Entity(entity string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		mutations, err := storage.History(h.db, entity, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(mutations) == 0 {
+			http.Error(w, "no history for "+entity+" "+id, http.StatusNotFound)
+			return
+		}
+
+		if v := r.URL.Query().Get("at"); v != "" {
+			at, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "invalid at: expected an RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+			var state json.RawMessage
+			for _, m := range mutations {
+				if m.Time.After(at) {
+					break
+				}
+				state = m.State
+			}
+			if len(state) == 0 {
+				http.Error(w, entity+" "+id+" did not exist at "+v, http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(state)
+			return
+		}
+
+		entries, err := storage.Audit(mutations)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	}
+}
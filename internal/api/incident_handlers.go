@@ -0,0 +1,102 @@
+// internal/api/incident_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	tigerrors "tig/internal/errors"
+	"tig/internal/events"
+	"tig/internal/incident"
+	"tig/internal/intent"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// IncidentHandler links incidents to the intents that caused them, for
+// incident trackers to call when a postmortem names the cause
+type IncidentHandler struct {
+	db      *badger.DB
+	intents intent.Box
+	events  events.Publisher
+}
+
+func NewIncidentHandler(db *badger.DB, intents intent.Box) *IncidentHandler {
+	return &IncidentHandler{db: db, intents: intents}
+}
+
+// WithEvents sets the publisher notified of each link
+func (h *IncidentHandler) WithEvents(p events.Publisher) *IncidentHandler {
+	h.events = p
+	return h
+}
+
+// Link records the intent in the body as the cause of incident {id}
+func (h *IncidentHandler) Link(w http.ResponseWriter, r *http.Request) {
+	var l incident.Link
+	if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	l.IncidentID = r.PathValue("id")
+	l.LinkedAt = l.LinkedAt.UTC()
+	if err := incident.Add(h.db, h.intents, &l); err != nil {
+		writeIncidentError(w, err)
+		return
+	}
+
+	if h.events != nil {
+		summary := l.Note
+		if summary == "" {
+			summary = "linked as the cause"
+		}
+		h.events.Publish(events.Event{
+			Type:     events.IncidentLinked,
+			IntentID: l.IntentID,
+			Summary:  summary,
+			Data: map[string]string{
+				"incident":  l.IncidentID,
+				"linked_by": l.LinkedBy,
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(l)
+}
+
+// Unlink removes the link between incident {id} and intent {intent}
+func (h *IncidentHandler) Unlink(w http.ResponseWriter, r *http.Request) {
+	if err := incident.Remove(h.db, r.PathValue("id"), r.PathValue("intent")); err != nil {
+		writeIncidentError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// List returns causal links newest first, of one incident when
+// ?incident= is given
+func (h *IncidentHandler) List(w http.ResponseWriter, r *http.Request) {
+	links, err := incident.List(h.db, r.URL.Query().Get("incident"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if links == nil {
+		links = []incident.Link{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+func writeIncidentError(w http.ResponseWriter, err error) {
+	var apiErr *tigerrors.Error
+	if errors.As(err, &apiErr) {
+		http.Error(w, err.Error(), apiErr.Code)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
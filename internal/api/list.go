@@ -0,0 +1,97 @@
+// internal/api/list.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// MaxPageSize caps ?limit on list endpoints
+const MaxPageSize = 1000
+
+// NextCursorHeader carries the ?after value for the next page. It is
+// absent on the last page.
+const NextCursorHeader = "X-Next-Cursor"
+
+// pager streams entities in ID order or returns them a page at a time
+type pager[T any] interface {
+	ListFunc(fn func(T) error) error
+	ListPage(after string, limit int) ([]T, string, error)
+}
+
+// writeList responds with the entities in box as a JSON array. With
+// ?limit=N (and ?after=ID to continue) one page is returned; otherwise the
+// array is streamed without loading every entity. Boxes that can't page
+// fall back to list.
+func writeList[T any](w http.ResponseWriter, r *http.Request, box any, list func() ([]T, error)) {
+	after := r.URL.Query().Get("after")
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > MaxPageSize {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", MaxPageSize), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	p, ok := box.(pager[T])
+	if !ok {
+		items, err := list()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(items)
+		return
+	}
+
+	if limit > 0 || after != "" {
+		if limit == 0 {
+			limit = MaxPageSize
+		}
+		items, next, err := p.ListPage(after, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if next != "" {
+			w.Header().Set(NextCursorHeader, next)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(items)
+		return
+	}
+
+	// Stream the full list. Errors after the first item can only end the
+	// response early, leaving the array unterminated.
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	n := 0
+	err := p.ListFunc(func(item T) error {
+		sep := ","
+		if n == 0 {
+			sep = "["
+		}
+		n++
+		if _, err := w.Write([]byte(sep)); err != nil {
+			return err
+		}
+		return enc.Encode(item)
+	})
+	if err != nil {
+		if n == 0 {
+			w.Header().Del("Content-Type")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if n == 0 {
+		w.Write([]byte("[]\n"))
+		return
+	}
+	w.Write([]byte("]\n"))
+}
@@ -0,0 +1,108 @@
+// internal/api/merge_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"tig/internal/merge"
+	"tig/internal/stream"
+)
+
+// MergeHandler exposes the merge queue of each stream
+type MergeHandler struct {
+	queue   *merge.Queue
+	streams stream.Box
+}
+
+func NewMergeHandler(queue *merge.Queue, streams stream.Box) *MergeHandler {
+	return &MergeHandler{queue: queue, streams: streams}
+}
+
+// Queue lists the intents waiting to land on a stream
+func (h *MergeHandler) Queue(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.queue.Entries(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []merge.Entry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// Enqueue adds an intent to a stream's merge queue and runs the queue.
+// Intents that do not meet the stream's protection rules are rejected,
+// including during a freeze window unless the request overrides it and
+// comes from someone in the stream's override_by list.
+func (h *MergeHandler) Enqueue(w http.ResponseWriter, r *http.Request) {
+	streamID := r.PathValue("id")
+
+	var req struct {
+		IntentID string `json:"intent_id"`
+		By       string `json:"by"` // Recorded in the merge log
+		Override bool   `json:"override"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.IntentID == "" {
+		http.Error(w, "intent_id is required", http.StatusBadRequest)
+		return
+	}
+
+	st, err := h.streams.Get(streamID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	protection := st.Config.Protection
+	if req.Override && !protection.CanOverride(req.By) {
+		http.Error(w, fmt.Sprintf("%q may not override the schedules of stream %s", req.By, st.Name), http.StatusForbidden)
+		return
+	}
+	intents, err := h.streams.GetIntents(streamID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var found bool
+	for _, i := range intents {
+		if i.ID != req.IntentID {
+			continue
+		}
+		found = true
+		unmet := protection.UnmetAt(i, time.Now())
+		if req.Override {
+			unmet = protection.Unmet(i)
+		}
+		if len(unmet) > 0 {
+			http.Error(w, fmt.Sprintf("%s: %s", merge.ErrProtected, strings.Join(unmet, ", ")), http.StatusConflict)
+			return
+		}
+	}
+	if !found {
+		http.Error(w, "intent is not part of the stream", http.StatusBadRequest)
+		return
+	}
+
+	enqueue := h.queue.EnqueueBy
+	if req.Override {
+		enqueue = h.queue.EnqueueOverride
+	}
+	if err := enqueue(streamID, req.IntentID, req.By); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	results, err := h.queue.Run(streamID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
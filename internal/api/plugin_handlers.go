@@ -0,0 +1,77 @@
+// internal/api/plugin_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+
+	"tig/internal/intent"
+	"tig/internal/plugin"
+	"tig/internal/safe"
+	"tig/internal/storage"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// PluginHandler runs the repository's WebAssembly plugins on intents
+type PluginHandler struct {
+	db      *badger.DB
+	safe    *safe.Safe
+	intents intent.Box
+	root    string
+	runtime *plugin.Runtime
+}
+
+func NewPluginHandler(db *badger.DB, s *safe.Safe, intents intent.Box, root string, runtime *plugin.Runtime) *PluginHandler {
+	return &PluginHandler{db: db, safe: s, intents: intents, root: root, runtime: runtime}
+}
+
+// List returns the names of the repository's plugins
+func (h *PluginHandler) List(w http.ResponseWriter, r *http.Request) {
+	names, err := plugin.List(h.root)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(names)
+}
+
+// Run runs the plugin {name} on the intent {id}. A plugin that fails
+// still answers 200; the result says whether it passed.
+func (h *PluginHandler) Run(w http.ResponseWriter, r *http.Request) {
+	wasm, err := plugin.Load(h.root, r.PathValue("name"))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	i, err := h.intents.Get(pathID(r))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	in, err := plugin.Gather(h.db, h.safe, i)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := h.runtime.Run(r.Context(), r.PathValue("name"), wasm, in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
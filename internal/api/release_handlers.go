@@ -0,0 +1,54 @@
+// internal/api/release_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	tigerrors "tig/internal/errors"
+	"tig/internal/release"
+	"tig/internal/safe"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ReleaseHandler serves release tags and their manifests
+type ReleaseHandler struct {
+	db   *badger.DB
+	safe *safe.Safe
+}
+
+func NewReleaseHandler(db *badger.DB, s *safe.Safe) *ReleaseHandler {
+	return &ReleaseHandler{db: db, safe: s}
+}
+
+// List returns every release tag, oldest first
+func (h *ReleaseHandler) List(w http.ResponseWriter, r *http.Request) {
+	tags, err := release.List(h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tags == nil {
+		tags = []release.Tag{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// Get returns the manifest of a release tag. Tags may contain slashes.
+func (h *ReleaseHandler) Get(w http.ResponseWriter, r *http.Request) {
+	m, err := release.Get(h.db, h.safe, r.PathValue("tag"))
+	var apiErr *tigerrors.Error
+	switch {
+	case errors.As(err, &apiErr):
+		http.Error(w, err.Error(), apiErr.Code)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
@@ -0,0 +1,99 @@
+// internal/api/report_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	tigerrors "tig/internal/errors"
+	"tig/internal/incident"
+	"tig/internal/intent"
+	"tig/internal/report"
+	"tig/internal/storage"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ReportHandler serves reports for CI and deploy pipelines
+type ReportHandler struct {
+	db      *badger.DB
+	intents intent.Box
+}
+
+func NewReportHandler(db *badger.DB, intents intent.Box) *ReportHandler {
+	return &ReportHandler{db: db, intents: intents}
+}
+
+// Contents lists the changesets, intents, files, authors and breaking
+// changes after ?from= up to and including ?to=. Both are changeset or
+// intent IDs.
+func (h *ReportHandler) Contents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("from") == "" || q.Get("to") == "" {
+		http.Error(w, "from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	var c *report.Contents
+	err := h.db.View(func(txn *badger.Txn) error {
+		from, err := report.ChangeSetOf(txn, q.Get("from"), h.intents.Get)
+		if err != nil {
+			return err
+		}
+		to, err := report.ChangeSetOf(txn, q.Get("to"), h.intents.Get)
+		if err != nil {
+			return err
+		}
+		c, err = report.BuildContents(txn, from, to, h.intents.Get)
+		return err
+	})
+	var apiErr *tigerrors.Error
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.As(err, &apiErr):
+		http.Error(w, err.Error(), apiErr.Code)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// Hotspots ranks paths by the incidents their changes caused, the top
+// ?limit= of them when given
+func (h *ReportHandler) Hotspots(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "limit must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	links, err := incident.List(h.db, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var hs *report.Hotspots
+	err = h.db.View(func(txn *badger.Txn) error {
+		hs, err = report.BuildHotspots(txn, links, h.intents.Get, limit)
+		return err
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hs)
+}
@@ -0,0 +1,156 @@
+// internal/api/review_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"tig/internal/events"
+	"tig/internal/intent"
+	"tig/internal/junit"
+)
+
+// AddReview records a review on an intent
+func (h *IntentHandler) AddReview(w http.ResponseWriter, r *http.Request) {
+	var review intent.Review
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if review.Reviewer == "" {
+		http.Error(w, "reviewer is required", http.StatusBadRequest)
+		return
+	}
+	review.CreatedAt = time.Now()
+
+	i, ok := h.modify(w, r, func(i *intent.Intent) {
+		i.Reviews = append(i.Reviews, review)
+		// Reviews move drafts along as far as the workflow allows
+		path := []string{intent.StateInReview}
+		if review.Approved {
+			path = append(path, intent.StateApproved)
+		}
+		i.Advance(h.workflow, path, review.Reviewer, review.CreatedAt)
+	})
+	if !ok {
+		return
+	}
+
+	h.publish(events.Event{
+		Type:     events.IntentReviewed,
+		IntentID: i.ID,
+		Summary:  i.Description,
+		Data: map[string]string{
+			"reviewer": review.Reviewer,
+			"approved": fmt.Sprint(review.Approved),
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(i)
+}
+
+// SetCheck records the result of a check run against an intent
+func (h *IntentHandler) SetCheck(w http.ResponseWriter, r *http.Request) {
+	var check intent.Check
+	if err := json.NewDecoder(r.Body).Decode(&check); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if check.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	// A test summary decides the status when none is given
+	if check.Status == "" && check.Tests != nil {
+		check.Status = check.Tests.Status()
+	}
+	switch check.Status {
+	case intent.CheckPending, intent.CheckPassed, intent.CheckFailed:
+	default:
+		http.Error(w, "status must be pending, passed or failed", http.StatusBadRequest)
+		return
+	}
+	h.setCheck(w, r, check)
+}
+
+// SetJUnitCheck records the results of a JUnit XML report, sent as the
+// request body, as a check named by the name query parameter, "tests" by
+// default. The check passes when no test failed.
+func (h *IntentHandler) SetJUnitCheck(w http.ResponseWriter, r *http.Request) {
+	summary, err := junit.Parse(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = intent.TestsCheck
+	}
+	h.setCheck(w, r, intent.Check{
+		Name:   name,
+		Status: summary.Status(),
+		URL:    r.URL.Query().Get("url"),
+		Tests:  summary,
+	})
+}
+
+// setCheck stores a validated check result and announces it
+func (h *IntentHandler) setCheck(w http.ResponseWriter, r *http.Request, check intent.Check) {
+	check.UpdatedAt = time.Now()
+
+	i, ok := h.modify(w, r, func(i *intent.Intent) {
+		i.SetCheck(check)
+	})
+	if !ok {
+		return
+	}
+
+	e := events.Event{
+		IntentID: i.ID,
+		Summary:  i.Description,
+		Data:     map[string]string{"check": check.Name, "url": check.URL},
+	}
+	switch check.Status {
+	case intent.CheckPassed:
+		e.Type = events.CheckPassed
+		h.publish(e)
+	case intent.CheckFailed:
+		e.Type = events.CheckFailed
+		h.publish(e)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(i)
+}
+
+// modify loads the intent named in the path, applies fn and saves it,
+// writing an error response and returning false on failure
+func (h *IntentHandler) modify(w http.ResponseWriter, r *http.Request, fn func(*intent.Intent)) (*intent.Intent, bool) {
+	id := pathID(r)
+	if id == "" {
+		http.Error(w, "missing id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	i, err := h.box.Get(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+
+	fn(i)
+	if err := h.box.Update(i); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return i, true
+}
+
+func (h *IntentHandler) publish(e events.Event) {
+	if h.events != nil {
+		h.events.Publish(e)
+	}
+}
@@ -0,0 +1,81 @@
+// internal/api/risk_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"tig/internal/intent"
+	"tig/internal/risk"
+	"tig/internal/safe"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// RiskHandler scores intents so reviewers can prioritize them
+type RiskHandler struct {
+	db      *badger.DB
+	safe    *safe.Safe
+	intents intent.Box
+}
+
+func NewRiskHandler(db *badger.DB, s *safe.Safe, intents intent.Box) *RiskHandler {
+	return &RiskHandler{db: db, safe: s, intents: intents}
+}
+
+// Get returns the risk score of intent {id}
+func (h *RiskHandler) Get(w http.ResponseWriter, r *http.Request) {
+	i, err := h.intents.Get(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	all, err := h.intents.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sc, err := risk.NewScorer(h.db, h.safe, all)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s, err := sc.Score(i)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+// List returns the risk scores of every intent, or those in ?state=,
+// riskiest first
+func (h *RiskHandler) List(w http.ResponseWriter, r *http.Request) {
+	all, err := h.intents.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	intents := all
+	if state := r.URL.Query().Get("state"); state != "" {
+		if !intent.ValidState(state) {
+			http.Error(w, fmt.Sprintf("unknown state %q", state), http.StatusBadRequest)
+			return
+		}
+		intents = intent.FilterState(all, state)
+	}
+	sc, err := risk.NewScorer(h.db, h.safe, all)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	scores, err := sc.ScoreAll(intents)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scores)
+}
@@ -0,0 +1,68 @@
+// internal/api/state_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"tig/internal/config"
+	tigerrors "tig/internal/errors"
+	"tig/internal/intent"
+)
+
+// WithWorkflow sets the transitions intents may make between lifecycle
+// states
+func (h *IntentHandler) WithWorkflow(w config.Workflow) *IntentHandler {
+	h.workflow = w
+	return h
+}
+
+// SetState moves an intent to another lifecycle state. Unknown states are
+// rejected with 400 and transitions the workflow does not allow with 409.
+func (h *IntentHandler) SetState(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		State string `json:"state"`
+		By    string `json:"by,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !intent.ValidState(req.State) {
+		http.Error(w, "unknown state "+req.State, http.StatusBadRequest)
+		return
+	}
+
+	i, err := h.box.Get(pathID(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := i.Transition(h.workflow, req.State, req.By, time.Now()); err != nil {
+		if errors.Is(err, tigerrors.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.box.Update(i); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(i)
+}
+
+// Workflow returns the lifecycle states and the transitions allowed
+// between them
+func (h *IntentHandler) Workflow(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"states":      intent.States,
+		"transitions": intent.Transitions(h.workflow),
+	})
+}
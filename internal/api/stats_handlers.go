@@ -0,0 +1,127 @@
+// internal/api/stats_handlers.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tig/internal/intent"
+	"tig/internal/query"
+	"tig/internal/safe"
+	"tig/internal/stats"
+	"tig/internal/storage"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// StatsHandler serves repository statistics
+type StatsHandler struct {
+	db      *badger.DB
+	safe    *safe.Safe
+	intents intent.Box
+	streams stream.Box
+}
+
+func NewStatsHandler(db *badger.DB) *StatsHandler {
+	return &StatsHandler{db: db}
+}
+
+// WithSafe sets the content safe whose cache is reported by Cache
+func (h *StatsHandler) WithSafe(s *safe.Safe) *StatsHandler {
+	h.safe = s
+	return h
+}
+
+// WithStores sets the intent and stream stores Authors reports on
+func (h *StatsHandler) WithStores(intents intent.Box, streams stream.Box) *StatsHandler {
+	h.intents = intents
+	h.streams = streams
+	return h
+}
+
+// Churn reports the most frequently changed files. ?limit=N caps the
+// number of files returned (default 20, 0 for all).
+func (h *StatsHandler) Churn(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	report, err := stats.Churn(h.db, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// Cache reports the size and hit rate of the content cache and the
+// Badger block and index caches since the server started
+func (h *StatsHandler) Cache(w http.ResponseWriter, r *http.Request) {
+	var caches []storage.CacheStats
+	if h.safe != nil {
+		caches = append(caches, h.safe.CacheStats())
+	}
+	caches = append(caches, storage.BadgerCacheStats(h.db)...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(caches)
+}
+
+// Authors reports contributions per author. ?since= is a duration before
+// now such as 90d, or an RFC 3339 time (default 90d); ?bucket= is day,
+// week or month (default week).
+func (h *StatsHandler) Authors(w http.ResponseWriter, r *http.Request) {
+	if h.safe == nil || h.intents == nil || h.streams == nil {
+		http.Error(w, "author statistics are not available", http.StatusNotImplemented)
+		return
+	}
+
+	opts := stats.AuthorOptions{Since: time.Now().Add(-90 * 24 * time.Hour), Bucket: stats.BucketWeek}
+	if v := r.URL.Query().Get("since"); v != "" {
+		if d, err := query.ParseDuration(v); err == nil && d >= 0 {
+			opts.Since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, v); err == nil {
+			opts.Since = t
+		} else {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("bucket"); v != "" {
+		opts.Bucket = v
+	}
+	if _, err := stats.BucketStart(time.Time{}, opts.Bucket); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	intents, err := h.intents.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	streams, err := h.streams.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report, err := stats.Authors(h.db, h.safe, intents, streams, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,595 @@
+// internal/api/sync_handlers.go
+package api
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"tig/internal/change"
+	"tig/internal/config"
+	tigerrors "tig/internal/errors"
+	"tig/internal/hooks"
+	"tig/internal/intent"
+	"tig/internal/remote"
+	"tig/internal/safe"
+	"tig/internal/storage"
+	"tig/internal/stream"
+	streamStorage "tig/internal/stream/storage"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// SyncHandler serves repository metadata and content to clones and
+// stores what they push. Pushed intents and streams are written through
+// the stores, so the server records their history itself.
+type SyncHandler struct {
+	db       *badger.DB
+	safe     *safe.Safe
+	intents  TxIntents
+	streams  *streamStorage.Store
+	fields   config.IntentFields
+	hooks    *hooks.Runner
+	workflow config.Workflow
+}
+
+// IntentWriter is an intent store bound to a unit of work
+type IntentWriter interface {
+	Create(i *intent.Intent) error
+	Get(id string) (*intent.Intent, error)
+	Update(i *intent.Intent) error
+}
+
+// TxIntents binds the intent store to a unit of work. The server supplies
+// it, since the intent store's tests import this package.
+type TxIntents func(u *storage.UnitOfWork) IntentWriter
+
+func NewSyncHandler(db *badger.DB, contentSafe *safe.Safe, intents TxIntents, streams *streamStorage.Store) *SyncHandler {
+	return &SyncHandler{db: db, safe: contentSafe, intents: intents, streams: streams}
+}
+
+// WithFields sets the repository's intent metadata schema that pushed
+// intents must follow
+func (h *SyncHandler) WithFields(fields config.IntentFields) *SyncHandler {
+	h.fields = fields
+	return h
+}
+
+// WithHooks sets the server-side hooks run as pushed intents are received
+func (h *SyncHandler) WithHooks(r *hooks.Runner) *SyncHandler {
+	h.hooks = r
+	return h
+}
+
+// WithWorkflow sets the lifecycle workflow that state changes of pushed
+// intents must follow
+func (h *SyncHandler) WithWorkflow(w config.Workflow) *SyncHandler {
+	h.workflow = w
+	return h
+}
+
+// Metadata returns intents, streams, changesets and the tracked tree
+func (h *SyncHandler) Metadata(w http.ResponseWriter, r *http.Request) {
+	records, err := remote.ExportMetadata(h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
+
+// Content returns the raw bytes stored under {hash}
+func (h *SyncHandler) Content(w http.ResponseWriter, r *http.Request) {
+	data, err := h.safe.Get(r.PathValue("hash"))
+	switch {
+	case errors.Is(err, safe.ErrInvalidHash):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, safe.ErrContentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Write(data)
+}
+
+// maxLookupHashes bounds the hashes a single lookup may ask about
+const maxLookupHashes = 1000
+
+// ContentMeta describes the content stored under {hash} without
+// returning it
+func (h *SyncHandler) ContentMeta(w http.ResponseWriter, r *http.Request) {
+	info, err := h.lookup(r.PathValue("hash"))
+	switch {
+	case errors.Is(err, safe.ErrInvalidHash):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	case !info.Present:
+		http.Error(w, safe.ErrContentNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// Lookup reports which of the hashes given as ?hash= the server holds, in
+// the order asked. Hashes may be repeated or comma-separated.
+func (h *SyncHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	var hashes []string
+	for _, v := range r.URL.Query()["hash"] {
+		for _, hash := range strings.Split(v, ",") {
+			if hash = strings.TrimSpace(hash); hash != "" {
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	if len(hashes) == 0 {
+		http.Error(w, "hash is required", http.StatusBadRequest)
+		return
+	}
+	if len(hashes) > maxLookupHashes {
+		http.Error(w, fmt.Sprintf("at most %d hashes per lookup", maxLookupHashes), http.StatusBadRequest)
+		return
+	}
+
+	infos := make([]remote.ContentInfo, 0, len(hashes))
+	for _, hash := range hashes {
+		info, err := h.lookup(hash)
+		if errors.Is(err, safe.ErrInvalidHash) {
+			http.Error(w, fmt.Sprintf("%s: %v", hash, err), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		infos = append(infos, info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(infos)
+}
+
+// lookup describes one hash. Content a partial clone has not fetched is
+// not present.
+func (h *SyncHandler) lookup(hash string) (remote.ContentInfo, error) {
+	info := remote.ContentInfo{Hash: hash}
+	meta, err := h.safe.Meta(hash)
+	if errors.Is(err, safe.ErrContentNotFound) {
+		return info, nil
+	}
+	if err != nil {
+		return info, err
+	}
+	if meta.Remote {
+		return info, nil
+	}
+	info.Present = true
+	info.Size = meta.Size
+	info.RefCount = meta.RefCount
+	info.Tier = safe.Tier(meta)
+	return info, nil
+}
+
+// maxManifestSize bounds the JSON manifest a client may send
+const maxManifestSize = 64 << 20
+
+// Manifest replies with the subset of the offered hashes this server lacks
+func (h *SyncHandler) Manifest(w http.ResponseWriter, r *http.Request) {
+	var m remote.Manifest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxManifestSize)).Decode(&m); err != nil {
+		http.Error(w, "invalid manifest", http.StatusBadRequest)
+		return
+	}
+
+	missing, err := h.safe.Missing(m.Hashes)
+	if errors.Is(err, safe.ErrInvalidHash) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if missing == nil {
+		missing = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(remote.MissingSet{Missing: missing})
+}
+
+// Blobs stores every blob in an upload stream. Each blob is checked
+// against its hash; content that is already present is not stored again.
+func (h *SyncHandler) Blobs(w http.ResponseWriter, r *http.Request) {
+	res, status, err := h.storeBlobs(remote.NewBlobReader(r.Body))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// Push stores a changeset and its content sent in one push stream,
+// optionally gzip-compressed. Every file the changeset adds or modifies
+// must be on the server once the stream's blobs are stored. Pushing a
+// changeset the server already has stores only the blobs.
+func (h *SyncHandler) Push(w http.ResponseWriter, r *http.Request) {
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, "invalid gzip stream", http.StatusBadRequest)
+			return
+		}
+		defer zr.Close()
+		body = zr
+	}
+
+	meta, err := remote.ReadPushHeader(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var cs change.ChangeSet
+	if err := json.Unmarshal(meta, &cs); err != nil || cs.ID == "" {
+		http.Error(w, "invalid changeset", http.StatusBadRequest)
+		return
+	}
+
+	res := remote.PushResult{ChangeSetID: cs.ID}
+	var status int
+	if res.UploadResult, status, err = h.storeBlobs(remote.NewBlobReader(body)); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var needed []string
+	for _, c := range cs.Changes {
+		if c.NewHash != "" && c.Type != "delete" {
+			needed = append(needed, c.NewHash)
+		}
+	}
+	missing, err := h.safe.Missing(needed)
+	if errors.Is(err, safe.ErrInvalidHash) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(missing) > 0 {
+		http.Error(w, fmt.Sprintf("changeset %s references %d blob(s) the server lacks, e.g. %s", cs.ID, len(missing), missing[0]), http.StatusBadRequest)
+		return
+	}
+
+	err = h.db.Update(func(txn *badger.Txn) error {
+		if _, err := change.GetChangeSet(txn, cs.ID); err == nil {
+			return nil
+		}
+		res.Created = true
+		return change.PutChangeSet(txn, &cs)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// storeBlobs stores every blob read from br, returning the HTTP status to
+// fail with on error
+func (h *SyncHandler) storeBlobs(br *remote.BlobReader) (remote.UploadResult, int, error) {
+	var res remote.UploadResult
+	for {
+		hash, data, err := br.Next()
+		if err == io.EOF {
+			return res, http.StatusOK, nil
+		}
+		if err != nil {
+			return res, http.StatusBadRequest, err
+		}
+
+		missing, err := h.safe.Missing([]string{hash})
+		if err != nil {
+			return res, http.StatusInternalServerError, err
+		}
+		if len(missing) == 0 {
+			res.Skipped++
+			continue
+		}
+
+		stored, err := h.safe.Store(data)
+		if err != nil {
+			return res, http.StatusInternalServerError, err
+		}
+		if stored != hash {
+			// Undo the store so mislabeled content doesn't linger
+			h.safe.Delete(stored)
+			return res, http.StatusBadRequest, fmt.Errorf("content for %s hashes to %s", hash, stored)
+		}
+		res.Stored++
+		res.Bytes += int64(len(data))
+	}
+}
+
+// maxBundleSize bounds the JSON of a sync bundle or pull request
+const maxBundleSize = 256 << 20
+
+// Negotiate compares a client's inventory of changesets and records with
+// the server's and replies with what each side should send the other
+func (h *SyncHandler) Negotiate(w http.ResponseWriter, r *http.Request) {
+	var client remote.Inventory
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxManifestSize)).Decode(&client); err != nil {
+		http.Error(w, "invalid inventory", http.StatusBadRequest)
+		return
+	}
+	server, err := remote.BuildInventory(h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(remote.Negotiate(server, &client))
+}
+
+// PushBundle stores the changesets, intents and streams a client pushes.
+// Every file the changesets add or modify must already be on the server,
+// sent beforehand through the transfer endpoints. Pushed intents pass the
+// same checks and hooks as intents created through the API; other
+// records, such as the mutation log, release tags and attestations, are
+// only ever written by the server and are refused.
+func (h *SyncHandler) PushBundle(w http.ResponseWriter, r *http.Request) {
+	var b remote.Bundle
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBundleSize)).Decode(&b); err != nil {
+		http.Error(w, "invalid bundle", http.StatusBadRequest)
+		return
+	}
+	intents, streams, err := decodePush(b.Records)
+	if err != nil {
+		writePushError(w, err)
+		return
+	}
+
+	missing, err := h.safe.Missing(b.Hashes())
+	if errors.Is(err, safe.ErrInvalidHash) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(missing) > 0 {
+		http.Error(w, fmt.Sprintf("bundle references %d blob(s) the server lacks, e.g. %s", len(missing), missing[0]), http.StatusBadRequest)
+		return
+	}
+
+	// Pre-receive hooks may reject an intent the server does not have yet
+	received, err := h.newIntents(intents)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, i := range received {
+		if err := h.hooks.Run(r.Context(), hooks.Payload{Event: hooks.PreReceiveIntent, Intent: i}); err != nil {
+			writePushError(w, err)
+			return
+		}
+	}
+
+	res, created, err := h.applyPush(&b, intents, streams)
+	if err != nil {
+		writePushError(w, err)
+		return
+	}
+	for _, i := range created {
+		h.hooks.Run(r.Context(), hooks.Payload{Event: hooks.PostReceiveIntent, Intent: i})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// decodePush decodes the intents and streams of a pushed bundle, refusing
+// records a client may not push and records not stored under their own ID
+func decodePush(records []remote.Record) ([]*intent.Intent, []*stream.Stream, error) {
+	var intents []*intent.Intent
+	var streams []*stream.Stream
+	for _, rec := range records {
+		if !remote.Pushable(rec.Key) {
+			return nil, nil, tigerrors.ValidationError(fmt.Sprintf("cannot push %s: only intents and streams are accepted", rec.Key), nil)
+		}
+		if strings.HasPrefix(rec.Key, "intent:") {
+			var i intent.Intent
+			if err := json.Unmarshal(rec.Value, &i); err != nil || "intent:"+i.ID != rec.Key {
+				return nil, nil, tigerrors.ValidationError(fmt.Sprintf("invalid intent record %s", rec.Key), nil)
+			}
+			intents = append(intents, &i)
+			continue
+		}
+		var st stream.Stream
+		if err := json.Unmarshal(rec.Value, &st); err != nil || "stream:"+st.ID != rec.Key {
+			return nil, nil, tigerrors.ValidationError(fmt.Sprintf("invalid stream record %s", rec.Key), nil)
+		}
+		streams = append(streams, &st)
+	}
+	return intents, streams, nil
+}
+
+// newIntents returns the pushed intents the server does not have
+func (h *SyncHandler) newIntents(intents []*intent.Intent) ([]*intent.Intent, error) {
+	u := storage.Begin(h.db)
+	defer u.Rollback()
+	var out []*intent.Intent
+	for _, i := range intents {
+		_, err := h.intents(u).Get(i.ID)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			out = append(out, i)
+		case err != nil:
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// applyPush stores a pushed bundle in one unit of work and returns the
+// intents it created. An intent or stream replaces the server's copy only
+// when it is newer. A pushed stream keeps the server's protection rules,
+// which change only through the protection endpoint.
+func (h *SyncHandler) applyPush(b *remote.Bundle, intents []*intent.Intent, streams []*stream.Stream) (*remote.ApplyResult, []*intent.Intent, error) {
+	res := &remote.ApplyResult{}
+	var created []*intent.Intent
+	err := storage.Run(h.db, func(u *storage.UnitOfWork) error {
+		*res = remote.ApplyResult{}
+		created = nil
+		n, err := remote.ApplyChangeSets(u.Txn(), b.ChangeSets)
+		if err != nil {
+			return err
+		}
+		res.ChangeSets = n
+
+		intentTx := h.intents(u)
+		for _, pushed := range intents {
+			i := *pushed
+			existing, err := intentTx.Get(i.ID)
+			switch {
+			case errors.Is(err, storage.ErrNotFound):
+				if err := h.checkIntent(&i, nil); err != nil {
+					return err
+				}
+				if err := intentTx.Create(&i); err != nil {
+					return err
+				}
+				created = append(created, &i)
+			case err != nil:
+				return err
+			case !i.UpdatedAt.After(existing.UpdatedAt):
+				res.Skipped++
+				continue
+			default:
+				if err := h.checkIntent(&i, existing); err != nil {
+					return err
+				}
+				i.CreatedAt = existing.CreatedAt
+				if err := intentTx.Update(&i); err != nil {
+					return err
+				}
+			}
+			res.Records++
+		}
+
+		streamTx := h.streams.With(u)
+		for _, pushed := range streams {
+			st := *pushed
+			if st.Name == "" {
+				return tigerrors.ValidationError(fmt.Sprintf("stream %s: name is required", st.ID), nil)
+			}
+			existing, err := streamTx.Get(st.ID)
+			switch {
+			case errors.Is(err, storage.ErrNotFound):
+				if err := st.Config.Protection.Validate(); err != nil {
+					return tigerrors.ValidationError(fmt.Sprintf("stream %s: %v", st.ID, err), nil)
+				}
+				if err := streamTx.Create(&st); err != nil {
+					return err
+				}
+			case err != nil:
+				return err
+			case !st.UpdatedAt.After(existing.UpdatedAt):
+				res.Skipped++
+				continue
+			default:
+				st.Config.Protection = existing.Config.Protection
+				st.CreatedAt = existing.CreatedAt
+				if err := streamTx.Update(&st); err != nil {
+					return err
+				}
+			}
+			res.Records++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, created, nil
+}
+
+// checkIntent makes the checks the intent API makes of a pushed intent:
+// required fields, the metadata schema and, for an intent the server
+// already has, that a change of state is one the workflow allows
+func (h *SyncHandler) checkIntent(i, existing *intent.Intent) error {
+	if i.Description == "" {
+		return tigerrors.ValidationError(fmt.Sprintf("intent %s: description is required", i.ID), nil)
+	}
+	if err := intent.CheckExtensions(i.Extensions, h.fields); err != nil {
+		return fmt.Errorf("intent %s: %w", i.ID, err)
+	}
+	if i.State != "" && !intent.ValidState(i.State) {
+		return tigerrors.ValidationError(fmt.Sprintf("intent %s: unknown state %s", i.ID, i.State), nil)
+	}
+	if existing == nil {
+		return nil
+	}
+	// existing is only read back for the check, so moving it is harmless
+	return existing.Transition(h.workflow, i.CurrentState(), "", i.UpdatedAt)
+}
+
+func writePushError(w http.ResponseWriter, err error) {
+	var apiErr *tigerrors.Error
+	var rejection *hooks.Rejection
+	switch {
+	case errors.As(err, &apiErr):
+		http.Error(w, err.Error(), apiErr.Code)
+	case errors.As(err, &rejection):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, tigerrors.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// PullBundle returns the changesets and records a client asks for
+func (h *SyncHandler) PullBundle(w http.ResponseWriter, r *http.Request) {
+	var req remote.PullRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBundleSize)).Decode(&req); err != nil {
+		http.Error(w, "invalid pull request", http.StatusBadRequest)
+		return
+	}
+	b, err := remote.ExportBundle(h.db, req.ChangeSets, req.Records)
+	switch {
+	case errors.Is(err, badger.ErrKeyNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b)
+}
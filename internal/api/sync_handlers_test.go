@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tig/internal/config"
+	"tig/internal/hooks"
+	"tig/internal/intent"
+	intentStorage "tig/internal/intent/storage"
+	"tig/internal/remote"
+	"tig/internal/safe"
+	"tig/internal/storage"
+	"tig/internal/stream"
+	streamStorage "tig/internal/stream/storage"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSyncHandler_PushBundle(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir()})
+	require.NoError(t, err)
+	defer s.Close()
+
+	intents := intentStorage.NewStore(db, nil)
+	streams := streamStorage.NewStore(db, intents)
+	runner, err := hooks.New([]config.Hook{{
+		Name:    "no-wip",
+		Event:   hooks.PreReceiveIntent,
+		Command: []string{"sh", "-c", `grep -q '"description":"wip' || exit 0; echo "no WIP intents"; exit 1`},
+	}}, t.TempDir(), zap.NewNop())
+	require.NoError(t, err)
+	bind := func(u *storage.UnitOfWork) IntentWriter { return intents.With(u) }
+	handler := NewSyncHandler(db, s, bind, streams).WithHooks(runner)
+
+	push := func(records ...remote.Record) *httptest.ResponseRecorder {
+		body, err := json.Marshal(remote.Bundle{Records: records})
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		handler.PushBundle(rec, httptest.NewRequest("POST", "/api/sync/push", bytes.NewReader(body)))
+		return rec
+	}
+	record := func(key string, v any) remote.Record {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return remote.Record{Key: key, Value: data}
+	}
+
+	// The server writes its own history; a client cannot
+	rec := push(record("events:forged", map[string]string{"id": "i1"}))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "only intents and streams")
+	rec = push(record("release:v1", map[string]string{"tag": "v1"}))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Pre-receive hooks run on pushed intents
+	created := time.Now().Add(-time.Hour)
+	wip := &intent.Intent{ID: "i0", Type: "feature", Description: "wip: billing", CreatedAt: created, UpdatedAt: created}
+	rec = push(record("intent:i0", wip))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no WIP intents")
+	_, err = intents.Get("i0")
+	assert.Error(t, err)
+
+	i := &intent.Intent{ID: "i1", Type: "feature", Description: "Add billing", CreatedAt: created, UpdatedAt: created}
+	st := &stream.Stream{ID: "s1", Name: "main", Type: "main", CreatedAt: created, UpdatedAt: created}
+	st.Config.Protection.RequiredReviewers = 2
+	rec = push(record("intent:i1", i), record("stream:s1", st))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var res remote.ApplyResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, 2, res.Records)
+
+	history, err := intents.History("i1")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	// A newer stream cannot loosen the server's protection rules
+	st.UpdatedAt = time.Now()
+	st.Config.Protection.RequiredReviewers = 0
+	st.Config.FeatureFlags = []stream.FeatureFlag{{Name: "billing", Enabled: true}}
+	rec = push(record("stream:s1", st))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	stored, err := streams.Get("s1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Config.Protection.RequiredReviewers)
+	assert.Len(t, stored.Config.FeatureFlags, 1)
+
+	// State changes follow the workflow
+	i.UpdatedAt = time.Now()
+	i.State = intent.StateLanded
+	rec = push(record("intent:i1", i))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// Extension fields follow the repository's schema
+	i.State = ""
+	i.Extensions = map[string]any{"risk": "high"}
+	rec = push(record("intent:i1", i))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
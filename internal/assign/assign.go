@@ -0,0 +1,413 @@
+// internal/assign/assign.go
+package assign
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/errors"
+	"tig/internal/intent"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Key prefixes: groups are stored under "review_group:<name>" and
+// assignments under "review_assignment:<intent>:<reviewer>"
+const (
+	groupPrefix      = "review_group:"
+	assignmentPrefix = "review_assignment:"
+)
+
+// Assignment strategies
+const (
+	RoundRobin = "round-robin" // Members take turns
+	Load       = "load"        // Members with the fewest open assignments first
+)
+
+// Group is a set of reviewers that reviews are assigned from
+type Group struct {
+	Name     string   `json:"name"`
+	Members  []string `json:"members"`
+	Strategy string   `json:"strategy"`       // round-robin or load
+	Next     int      `json:"next,omitempty"` // Member whose turn is next
+}
+
+// Assignment asks a reviewer to review an intent. It is open until the
+// reviewer reviews the intent or the intent lands.
+type Assignment struct {
+	IntentID      string    `json:"intent_id"`
+	Reviewer      string    `json:"reviewer"`
+	Group         string    `json:"group,omitempty"`
+	DelegatedFrom string    `json:"delegated_from,omitempty"` // Reviewer who handed the review on
+	AssignedAt    time.Time `json:"assigned_at"`
+	Done          bool      `json:"done,omitempty"`
+}
+
+// SetGroup creates or replaces a reviewer group, keeping its place in
+// the rotation
+func SetGroup(db *badger.DB, g *Group) error {
+	if g.Name == "" || strings.Contains(g.Name, ":") {
+		return errors.ValidationError(fmt.Sprintf("invalid group name %q", g.Name), nil)
+	}
+	if len(g.Members) == 0 {
+		return errors.ValidationError("a group needs at least one member", nil)
+	}
+	switch g.Strategy {
+	case "":
+		g.Strategy = RoundRobin
+	case RoundRobin, Load:
+	default:
+		return errors.ValidationError(fmt.Sprintf("strategy must be %s or %s", RoundRobin, Load), nil)
+	}
+	return db.Update(func(txn *badger.Txn) error {
+		if old, err := getGroup(txn, g.Name); err == nil {
+			g.Next = old.Next % len(g.Members)
+		}
+		return put(txn, groupPrefix+g.Name, g)
+	})
+}
+
+// GetGroup returns a reviewer group
+func GetGroup(db *badger.DB, name string) (*Group, error) {
+	var g *Group
+	err := db.View(func(txn *badger.Txn) error {
+		var err error
+		g, err = getGroup(txn, name)
+		return err
+	})
+	return g, err
+}
+
+// Groups returns every reviewer group by name
+func Groups(db *badger.DB) ([]Group, error) {
+	groups := []Group{}
+	err := scan(db, groupPrefix, func(val []byte) error {
+		var g Group
+		if err := json.Unmarshal(val, &g); err != nil {
+			return err
+		}
+		groups = append(groups, g)
+		return nil
+	})
+	return groups, err
+}
+
+// Assign assigns up to n members of a group to review an intent, chosen
+// by the group's strategy. The intent's author and anyone already
+// assigned to or reviewing it are passed over.
+func Assign(db *badger.DB, group string, i *intent.Intent, n int) ([]Assignment, error) {
+	var assigned []Assignment
+	err := db.Update(func(txn *badger.Txn) error {
+		g, err := getGroup(txn, group)
+		if err != nil {
+			return err
+		}
+		existing, err := forIntent(txn, i.ID)
+		if err != nil {
+			return err
+		}
+		reviewers, err := pick(txn, g, excluded(i, existing), n)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		for _, r := range reviewers {
+			a := Assignment{IntentID: i.ID, Reviewer: r, Group: g.Name, AssignedAt: now}
+			if err := put(txn, assignmentKey(i.ID, r), &a); err != nil {
+				return err
+			}
+			assigned = append(assigned, a)
+		}
+		return put(txn, groupPrefix+g.Name, g)
+	})
+	return assigned, err
+}
+
+// Add assigns a named reviewer to an intent, reopening an earlier
+// assignment of theirs
+func Add(db *badger.DB, intentID, reviewer string) (*Assignment, error) {
+	if reviewer == "" {
+		return nil, errors.ValidationError("reviewer is required", nil)
+	}
+	a := &Assignment{IntentID: intentID, Reviewer: reviewer, AssignedAt: time.Now().UTC()}
+	err := db.Update(func(txn *badger.Txn) error {
+		return put(txn, assignmentKey(intentID, reviewer), a)
+	})
+	return a, err
+}
+
+// Reassign hands a reviewer's open assignment on to someone else. With no
+// one named, the next member of the assignment's group by its strategy
+// takes it. The new assignment records whom it was delegated from.
+func Reassign(db *badger.DB, i *intent.Intent, from, to string) (*Assignment, error) {
+	var a *Assignment
+	err := db.Update(func(txn *badger.Txn) error {
+		existing, err := forIntent(txn, i.ID)
+		if err != nil {
+			return err
+		}
+		var old *Assignment
+		for n := range existing {
+			if existing[n].Reviewer == from && !existing[n].Done {
+				old = &existing[n]
+			}
+		}
+		if old == nil {
+			return errors.NotFound(fmt.Sprintf("%s has no open review of intent %s", from, i.ID))
+		}
+
+		if to == "" {
+			if old.Group == "" {
+				return errors.ValidationError(fmt.Sprintf("%s was not assigned from a group; name who to reassign to", from), nil)
+			}
+			g, err := getGroup(txn, old.Group)
+			if err != nil {
+				return err
+			}
+			picked, err := pick(txn, g, excluded(i, existing), 1)
+			if err != nil {
+				return err
+			}
+			to = picked[0]
+			if err := put(txn, groupPrefix+g.Name, g); err != nil {
+				return err
+			}
+		} else if to == from {
+			return errors.ValidationError("cannot reassign a review to the same reviewer", nil)
+		}
+
+		a = &Assignment{IntentID: i.ID, Reviewer: to, Group: old.Group, DelegatedFrom: from, AssignedAt: time.Now().UTC()}
+		if err := txn.Delete([]byte(assignmentKey(i.ID, from))); err != nil {
+			return err
+		}
+		return put(txn, assignmentKey(i.ID, to), a)
+	})
+	return a, err
+}
+
+// Complete closes a reviewer's assignment to an intent, if they have one
+func Complete(db *badger.DB, intentID, reviewer string) error {
+	return update(db, intentID, func(a *Assignment) bool { return a.Reviewer == reviewer })
+}
+
+// CompleteIntent closes every assignment to an intent
+func CompleteIntent(db *badger.DB, intentID string) error {
+	return update(db, intentID, func(*Assignment) bool { return true })
+}
+
+func update(db *badger.DB, intentID string, match func(*Assignment) bool) error {
+	return db.Update(func(txn *badger.Txn) error {
+		existing, err := forIntent(txn, intentID)
+		if err != nil {
+			return err
+		}
+		for _, a := range existing {
+			if a.Done || !match(&a) {
+				continue
+			}
+			a.Done = true
+			if err := put(txn, assignmentKey(a.IntentID, a.Reviewer), &a); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ForIntent returns an intent's assignments in the order they were made
+func ForIntent(db *badger.DB, intentID string) ([]Assignment, error) {
+	var assignments []Assignment
+	err := db.View(func(txn *badger.Txn) error {
+		var err error
+		assignments, err = forIntent(txn, intentID)
+		return err
+	})
+	return assignments, err
+}
+
+// Open returns the open assignments of a reviewer, or of everyone when
+// reviewer is empty, oldest first
+func Open(db *badger.DB, reviewer string) ([]Assignment, error) {
+	open := []Assignment{}
+	err := scan(db, assignmentPrefix, func(val []byte) error {
+		var a Assignment
+		if err := json.Unmarshal(val, &a); err != nil {
+			return err
+		}
+		if !a.Done && (reviewer == "" || a.Reviewer == reviewer) {
+			open = append(open, a)
+		}
+		return nil
+	})
+	sort.SliceStable(open, func(x, y int) bool { return open[x].AssignedAt.Before(open[y].AssignedAt) })
+	return open, err
+}
+
+// ForStream assigns reviewers from a stream's reviewer group to an
+// intent entering review on it, enough that its approvals and open
+// assignments together cover the stream's required reviewers
+func ForStream(db *badger.DB, st *stream.Stream, i *intent.Intent) ([]Assignment, error) {
+	group := st.Config.Protection.ReviewerGroup
+	if group == "" {
+		return nil, nil
+	}
+	existing, err := ForIntent(db, i.ID)
+	if err != nil {
+		return nil, err
+	}
+	covered := make(map[string]bool)
+	for _, r := range i.Approvals() {
+		covered[r] = true
+	}
+	for _, a := range existing {
+		if !a.Done {
+			covered[a.Reviewer] = true
+		}
+	}
+	need := st.Config.Protection.RequiredReviewers - len(covered)
+	if need <= 0 {
+		return nil, nil
+	}
+	return Assign(db, group, i, need)
+}
+
+// excluded returns who may not be assigned to review an intent
+func excluded(i *intent.Intent, existing []Assignment) map[string]bool {
+	skip := map[string]bool{i.Metadata.Author: true}
+	for _, a := range existing {
+		skip[a.Reviewer] = true
+	}
+	for _, r := range i.Reviews {
+		skip[r.Reviewer] = true
+	}
+	return skip
+}
+
+// pick chooses up to n eligible members of g by its strategy and moves
+// the group's turn past them
+func pick(txn *badger.Txn, g *Group, skip map[string]bool, n int) ([]string, error) {
+	// Members in turn order, starting with whoever is next
+	type candidate struct {
+		name  string
+		index int
+		load  int
+	}
+	var candidates []candidate
+	for k := range g.Members {
+		index := (g.Next + k) % len(g.Members)
+		if name := g.Members[index]; !skip[name] {
+			candidates = append(candidates, candidate{name: name, index: index})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, errors.ValidationError(fmt.Sprintf("no eligible reviewers left in group %s", g.Name), nil)
+	}
+
+	if g.Strategy == Load {
+		load, err := openCounts(txn)
+		if err != nil {
+			return nil, err
+		}
+		for k := range candidates {
+			candidates[k].load = load[candidates[k].name]
+		}
+		sort.SliceStable(candidates, func(x, y int) bool { return candidates[x].load < candidates[y].load })
+	}
+
+	var picked []string
+	for _, c := range candidates[:min(n, len(candidates))] {
+		picked = append(picked, c.name)
+		if g.Strategy == RoundRobin {
+			g.Next = (c.index + 1) % len(g.Members)
+		}
+	}
+	if g.Strategy == Load {
+		// Ties go to whoever has waited longest for a turn
+		last := slices.Index(g.Members, picked[len(picked)-1])
+		g.Next = (last + 1) % len(g.Members)
+	}
+	return picked, nil
+}
+
+// openCounts counts the open assignments of each reviewer
+func openCounts(txn *badger.Txn) (map[string]int, error) {
+	counts := make(map[string]int)
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(assignmentPrefix)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		var a Assignment
+		if err := it.Item().Value(func(val []byte) error { return json.Unmarshal(val, &a) }); err != nil {
+			return nil, err
+		}
+		if !a.Done {
+			counts[a.Reviewer]++
+		}
+	}
+	return counts, nil
+}
+
+func forIntent(txn *badger.Txn, intentID string) ([]Assignment, error) {
+	assignments := []Assignment{}
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(assignmentPrefix + intentID + ":")
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		var a Assignment
+		if err := it.Item().Value(func(val []byte) error { return json.Unmarshal(val, &a) }); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	sort.SliceStable(assignments, func(x, y int) bool { return assignments[x].AssignedAt.Before(assignments[y].AssignedAt) })
+	return assignments, nil
+}
+
+func getGroup(txn *badger.Txn, name string) (*Group, error) {
+	item, err := txn.Get([]byte(groupPrefix + name))
+	if err == badger.ErrKeyNotFound {
+		return nil, errors.NotFound(fmt.Sprintf("reviewer group %s not found", name))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var g Group
+	if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &g) }); err != nil {
+		return nil, fmt.Errorf("decoding reviewer group %s: %w", name, err)
+	}
+	return &g, nil
+}
+
+func assignmentKey(intentID, reviewer string) string {
+	return assignmentPrefix + intentID + ":" + reviewer
+}
+
+func put(txn *badger.Txn, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return txn.Set([]byte(key), data)
+}
+
+func scan(db *badger.DB, prefix string, fn func(val []byte) error) error {
+	return db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := it.Item().Value(fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,134 @@
+// internal/assign/assign_test.go
+package assign
+
+import (
+	"testing"
+
+	"tig/internal/intent"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openDB(t *testing.T) *badger.DB {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func reviewers(assignments []Assignment) []string {
+	var names []string
+	for _, a := range assignments {
+		names = append(names, a.Reviewer)
+	}
+	return names
+}
+
+func TestRoundRobin(t *testing.T) {
+	db := openDB(t)
+	require.NoError(t, SetGroup(db, &Group{Name: "backend", Members: []string{"alice", "bob", "carol"}}))
+
+	var got []string
+	for _, id := range []string{"i1", "i2", "i3", "i4"} {
+		assigned, err := Assign(db, "backend", &intent.Intent{ID: id}, 1)
+		require.NoError(t, err)
+		got = append(got, reviewers(assigned)...)
+	}
+	assert.Equal(t, []string{"alice", "bob", "carol", "alice"}, got)
+
+	// The author is passed over
+	assigned, err := Assign(db, "backend", &intent.Intent{ID: "i5", Metadata: intent.Metadata{Author: "bob"}}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"carol"}, reviewers(assigned))
+
+	// Replacing the group keeps its turn
+	require.NoError(t, SetGroup(db, &Group{Name: "backend", Members: []string{"alice", "bob", "carol"}}))
+	assigned, err = Assign(db, "backend", &intent.Intent{ID: "i6"}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, reviewers(assigned))
+}
+
+func TestLoadBased(t *testing.T) {
+	db := openDB(t)
+	require.NoError(t, SetGroup(db, &Group{Name: "web", Members: []string{"alice", "bob"}, Strategy: Load}))
+	_, err := Add(db, "busy1", "alice")
+	require.NoError(t, err)
+	_, err = Add(db, "busy2", "alice")
+	require.NoError(t, err)
+
+	assigned, err := Assign(db, "web", &intent.Intent{ID: "i1"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, reviewers(assigned))
+
+	// Closed assignments no longer count
+	require.NoError(t, CompleteIntent(db, "busy1"))
+	require.NoError(t, Complete(db, "busy2", "alice"))
+	assigned, err = Assign(db, "web", &intent.Intent{ID: "i2"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, reviewers(assigned))
+
+	open, err := Open(db, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob", "alice"}, reviewers(open))
+}
+
+func TestSetGroupValidation(t *testing.T) {
+	db := openDB(t)
+	assert.Error(t, SetGroup(db, &Group{Name: "empty"}))
+	assert.Error(t, SetGroup(db, &Group{Name: "odd", Members: []string{"a"}, Strategy: "random"}))
+	assert.Error(t, SetGroup(db, &Group{Name: "a:b", Members: []string{"a"}}))
+	_, err := Assign(db, "missing", &intent.Intent{ID: "i1"}, 1)
+	assert.Error(t, err)
+}
+
+func TestReassign(t *testing.T) {
+	db := openDB(t)
+	require.NoError(t, SetGroup(db, &Group{Name: "backend", Members: []string{"alice", "bob", "carol"}}))
+	i := &intent.Intent{ID: "i1"}
+	_, err := Assign(db, "backend", i, 1)
+	require.NoError(t, err)
+
+	// To the next member of the group
+	a, err := Reassign(db, i, "alice", "")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", a.Reviewer)
+	assert.Equal(t, "alice", a.DelegatedFrom)
+
+	// To someone named
+	a, err = Reassign(db, i, "bob", "dave")
+	require.NoError(t, err)
+	assert.Equal(t, "dave", a.Reviewer)
+	assert.Equal(t, "backend", a.Group)
+
+	all, err := ForIntent(db, i.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dave"}, reviewers(all))
+
+	_, err = Reassign(db, i, "alice", "erin")
+	assert.Error(t, err, "alice no longer has the review")
+}
+
+func TestForStream(t *testing.T) {
+	db := openDB(t)
+	require.NoError(t, SetGroup(db, &Group{Name: "backend", Members: []string{"alice", "bob", "carol"}}))
+	st := &stream.Stream{Config: stream.Config{Protection: stream.Protection{RequiredReviewers: 2, ReviewerGroup: "backend"}}}
+	i := &intent.Intent{ID: "i1", Reviews: []intent.Review{{Reviewer: "alice", Approved: true}}}
+
+	// One approval is in, so one more reviewer is needed
+	assigned, err := ForStream(db, st, i)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, reviewers(assigned))
+
+	assigned, err = ForStream(db, st, i)
+	require.NoError(t, err)
+	assert.Empty(t, assigned)
+
+	assigned, err = ForStream(db, &stream.Stream{}, i)
+	require.NoError(t, err)
+	assert.Empty(t, assigned)
+}
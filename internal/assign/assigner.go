@@ -0,0 +1,88 @@
+// internal/assign/assigner.go
+package assign
+
+import (
+	"tig/internal/events"
+	"tig/internal/intent"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.uber.org/zap"
+)
+
+// Assigner assigns reviewers when intents join streams with a reviewer
+// group and closes assignments as reviews come in
+type Assigner struct {
+	db      *badger.DB
+	streams stream.Box
+	intents intent.Box
+	events  events.Publisher
+	logger  *zap.Logger
+}
+
+// NewAssigner creates an assigner
+func NewAssigner(db *badger.DB, streams stream.Box, intents intent.Box, logger *zap.Logger) *Assigner {
+	return &Assigner{db: db, streams: streams, intents: intents, logger: logger}
+}
+
+// Subscribe assigns reviewers to intents added to streams, closes a
+// reviewer's assignment when they review and all of an intent's when it
+// lands. Assignments are published on the bus.
+func (a *Assigner) Subscribe(bus *events.Bus) {
+	a.events = bus
+	bus.Subscribe(events.IntentAdded, a.HandleAdded)
+	bus.Subscribe(events.IntentReviewed, a.HandleReviewed)
+	bus.Subscribe(events.StreamMerged, a.HandleMerged)
+}
+
+// HandleAdded assigns reviewers from the stream's reviewer group
+func (a *Assigner) HandleAdded(e events.Event) {
+	st, err := a.streams.Get(e.StreamID)
+	if err != nil {
+		a.logger.Warn("assigning reviewers failed", zap.String("stream", e.StreamID), zap.Error(err))
+		return
+	}
+	if st.Config.Protection.ReviewerGroup == "" {
+		return
+	}
+	i, err := a.intents.Get(e.IntentID)
+	if err != nil {
+		a.logger.Warn("assigning reviewers failed", zap.String("intent", e.IntentID), zap.Error(err))
+		return
+	}
+	assigned, err := ForStream(a.db, st, i)
+	if err != nil {
+		a.logger.Warn("assigning reviewers failed",
+			zap.String("intent", i.ID), zap.String("stream", st.Name), zap.Error(err))
+		return
+	}
+	for _, as := range assigned {
+		a.publish(events.Event{
+			Type:     events.ReviewAssigned,
+			StreamID: st.ID,
+			IntentID: i.ID,
+			Summary:  i.Description,
+			Data:     map[string]string{"reviewer": as.Reviewer, "group": as.Group},
+		})
+	}
+}
+
+// HandleReviewed closes the reviewer's assignment
+func (a *Assigner) HandleReviewed(e events.Event) {
+	if err := Complete(a.db, e.IntentID, e.Data["reviewer"]); err != nil {
+		a.logger.Warn("closing review assignment failed", zap.String("intent", e.IntentID), zap.Error(err))
+	}
+}
+
+// HandleMerged closes the landed intent's assignments
+func (a *Assigner) HandleMerged(e events.Event) {
+	if err := CompleteIntent(a.db, e.IntentID); err != nil {
+		a.logger.Warn("closing review assignments failed", zap.String("intent", e.IntentID), zap.Error(err))
+	}
+}
+
+func (a *Assigner) publish(e events.Event) {
+	if a.events != nil {
+		a.events.Publish(e)
+	}
+}
@@ -0,0 +1,189 @@
+// internal/attest/attest.go
+package attest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/storage"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Key prefixes of attestations. Each is stored under
+// "attest:<changeset>:<artifact hash>" and indexed by artifact under
+// "attest_artifact:<artifact hash>:<changeset>".
+const (
+	attestPrefix   = "attest:"
+	artifactPrefix = "attest_artifact:"
+)
+
+// Attestation records that a build of a changeset produced an artifact
+type Attestation struct {
+	ChangeSetID string    `json:"changeset_id"`
+	IntentID    string    `json:"intent_id,omitempty"`
+	Tree        string    `json:"tree"`     // Hash of the changeset's changes when attested
+	Artifact    string    `json:"artifact"` // File name of the artifact
+	Hash        string    `json:"hash"`     // SHA-256 of the artifact
+	Size        int64     `json:"size"`
+	Builder     string    `json:"builder,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// HashFile returns the SHA-256 and size of a file
+func HashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("hashing %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// Record attests that the artifact at path was built from a changeset.
+// Attesting the same artifact again replaces the earlier record.
+func Record(db *badger.DB, cs *change.ChangeSet, path, builder string) (*Attestation, error) {
+	hash, size, err := HashFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &Attestation{
+		ChangeSetID: cs.ID,
+		IntentID:    cs.IntentID,
+		Tree:        change.HashChanges(cs.Changes),
+		Artifact:    filepath.Base(path),
+		Hash:        hash,
+		Size:        size,
+		Builder:     builder,
+		CreatedAt:   time.Now().UTC(),
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling attestation: %w", err)
+	}
+	err = db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte(attestPrefix+cs.ID+":"+hash), data); err != nil {
+			return err
+		}
+		return txn.Set([]byte(artifactPrefix+hash+":"+cs.ID), nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("recording attestation: %w", err)
+	}
+	return a, nil
+}
+
+// ForChangeSet returns the artifacts attested for a changeset, by name
+func ForChangeSet(db *badger.DB, changeSetID string) ([]Attestation, error) {
+	var attestations []Attestation
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(attestPrefix + changeSetID + ":")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var a Attestation
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &a)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			attestations = append(attestations, a)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading attestations: %w", err)
+	}
+	sort.Slice(attestations, func(x, y int) bool { return attestations[x].Artifact < attestations[y].Artifact })
+	return attestations, nil
+}
+
+// ForArtifact returns every attestation of an artifact hash, oldest first
+func ForArtifact(db *badger.DB, hash string) ([]Attestation, error) {
+	var attestations []Attestation
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(artifactPrefix + hash + ":")
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			changeSetID := string(it.Item().Key()[len(opts.Prefix):])
+			item, err := txn.Get([]byte(attestPrefix + changeSetID + ":" + hash))
+			if err != nil {
+				return fmt.Errorf("reading attestation of %s: %w", changeSetID, err)
+			}
+			var a Attestation
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &a)
+			}); err != nil {
+				return fmt.Errorf("decoding attestation of %s: %w", changeSetID, err)
+			}
+			attestations = append(attestations, a)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading attestations: %w", err)
+	}
+	sort.Slice(attestations, func(x, y int) bool { return attestations[x].CreatedAt.Before(attestations[y].CreatedAt) })
+	return attestations, nil
+}
+
+// Verify proves which source state produced the artifact at path. It
+// returns the attestations of the artifact, restricted to one changeset
+// unless changeSetID is empty, after checking that each changeset still
+// holds the changes it was attested with.
+func Verify(db *badger.DB, path, changeSetID string) ([]Attestation, error) {
+	hash, _, err := HashFile(path)
+	if err != nil {
+		return nil, err
+	}
+	all, err := ForArtifact(db, hash)
+	if err != nil {
+		return nil, err
+	}
+	var matched []Attestation
+	for _, a := range all {
+		if changeSetID == "" || a.ChangeSetID == changeSetID {
+			matched = append(matched, a)
+		}
+	}
+	if len(matched) == 0 {
+		if changeSetID != "" {
+			return nil, fmt.Errorf("%w: %s (sha256 %s) is not attested for changeset %s", storage.ErrNotFound, path, hash, changeSetID)
+		}
+		return nil, fmt.Errorf("%w: %s (sha256 %s) is not attested for any changeset", storage.ErrNotFound, path, hash)
+	}
+
+	err = db.View(func(txn *badger.Txn) error {
+		for _, a := range matched {
+			cs, err := change.GetChangeSet(txn, a.ChangeSetID)
+			if err != nil {
+				return fmt.Errorf("changeset %s of the attestation: %w", a.ChangeSetID, err)
+			}
+			if tree := change.HashChanges(cs.Changes); tree != a.Tree {
+				return fmt.Errorf("changeset %s no longer matches its attestation: tree %s, attested %s", a.ChangeSetID, tree, a.Tree)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
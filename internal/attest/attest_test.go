@@ -0,0 +1,67 @@
+// internal/attest/attest_test.go
+package attest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tig/internal/change"
+	"tig/internal/storage"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndVerify(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	cs := &change.ChangeSet{ID: "cs1", IntentID: "i1", Changes: []shared.Change{{Path: "main.go", Type: "add", NewHash: "aa"}}}
+	other := &change.ChangeSet{ID: "cs2", Changes: []shared.Change{{Path: "main.go", Type: "modify", NewHash: "bb"}}}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		if err := change.PutChangeSet(txn, cs); err != nil {
+			return err
+		}
+		return change.PutChangeSet(txn, other)
+	}))
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "app")
+	require.NoError(t, os.WriteFile(binary, []byte("binary"), 0755))
+	a, err := Record(db, cs, binary, "ci")
+	require.NoError(t, err)
+	assert.Equal(t, "app", a.Artifact)
+	assert.Equal(t, int64(6), a.Size)
+
+	attested, err := ForChangeSet(db, "cs1")
+	require.NoError(t, err)
+	require.Len(t, attested, 1)
+	assert.Equal(t, a.Hash, attested[0].Hash)
+
+	verified, err := Verify(db, binary, "")
+	require.NoError(t, err)
+	require.Len(t, verified, 1)
+	assert.Equal(t, "cs1", verified[0].ChangeSetID)
+	_, err = Verify(db, binary, "cs2")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// Another build of different source is not attested
+	rebuilt := filepath.Join(dir, "app2")
+	require.NoError(t, os.WriteFile(rebuilt, []byte("binary2"), 0755))
+	_, err = Verify(db, rebuilt, "")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// Rewriting the changeset invalidates the attestation
+	cs.Changes[0].NewHash = "cc"
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return change.PutChangeSet(txn, cs)
+	}))
+	_, err = Verify(db, binary, "")
+	assert.ErrorContains(t, err, "no longer matches")
+}
@@ -0,0 +1,281 @@
+// internal/blame/blame.go
+package blame
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/diff"
+	"tig/internal/safe"
+	"tig/internal/storage"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Options controls how lines are attributed
+type Options struct {
+	// FirstParent attributes lines to the changeset that brought them in,
+	// without following parent links back to the changeset they were
+	// merged or cherry-picked from
+	FirstParent bool
+	// Reverse, if set, is a changeset ID. The file is shown as of that
+	// changeset and each line is attributed to the last changeset it
+	// survived instead of the one that introduced it.
+	Reverse string
+}
+
+// Line is a line of the file with the changeset it is attributed to
+type Line struct {
+	Num         int       `json:"line"`
+	Content     string    `json:"content"`
+	Path        string    `json:"path"` // path of the file in that changeset; differs across moves and copies
+	ChangeSetID string    `json:"changeset_id"`
+	IntentID    string    `json:"intent_id"`
+	Author      string    `json:"author"`
+	Time        time.Time `json:"time"`
+}
+
+// version is one changeset's content of the file
+type version struct {
+	cs     *change.ChangeSet
+	change shared.Change
+}
+
+// File attributes each committed line of path to a changeset. History
+// follows moves and copies to the file they came from, and lines that
+// reached the file through a cherry-pick or merge are attributed to the
+// original changeset unless opts.FirstParent is set.
+func File(db *badger.DB, s *safe.Safe, path string, opts Options) ([]Line, error) {
+	var lines []Line
+	err := db.View(func(txn *badger.Txn) error {
+		versions, err := history(txn, path)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return fmt.Errorf("%w: no history for %s", storage.ErrNotFound, path)
+		}
+
+		// Resolve every version's attribution up front
+		attrs := make([]Line, len(versions))
+		for n, v := range versions {
+			cs := v.cs
+			if !opts.FirstParent {
+				if cs, err = origin(txn, v); err != nil {
+					return err
+				}
+			}
+			attrs[n] = Line{
+				Path:        v.change.Path,
+				ChangeSetID: cs.ID,
+				IntentID:    cs.IntentID,
+				Author:      cs.Author,
+				Time:        cs.CreatedAt,
+			}
+		}
+
+		if opts.Reverse == "" {
+			lines, err = forward(s, versions, attrs)
+			return err
+		}
+		start := -1
+		for n, v := range versions {
+			if v.cs.ID == opts.Reverse || attrs[n].ChangeSetID == opts.Reverse {
+				start = n
+				break
+			}
+		}
+		if start < 0 {
+			return fmt.Errorf("%w: changeset %s does not change %s", storage.ErrNotFound, opts.Reverse, path)
+		}
+		lines, err = reverse(s, versions[start:], attrs[start:])
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("blaming %s: %w", path, err)
+	}
+	return lines, nil
+}
+
+// forward replays the versions oldest first. Lines kept from the previous
+// version keep their attribution; added lines take the version's.
+func forward(s *safe.Safe, versions []version, attrs []Line) ([]Line, error) {
+	engine := diff.NewEngine(0)
+	var prev []byte
+	var lines []Line
+	for n, v := range versions {
+		content, err := s.Get(v.change.NewHash)
+		if err != nil {
+			return nil, fmt.Errorf("loading content of %s: %w", v.change.Path, err)
+		}
+
+		var next []Line
+		for _, l := range engine.Align(prev, content) {
+			switch l.Type {
+			case diff.Context:
+				line := lines[l.OldNum-1]
+				line.Num = l.NewNum
+				next = append(next, line)
+			case diff.Addition:
+				line := attrs[n]
+				line.Num, line.Content = l.NewNum, l.Content
+				next = append(next, line)
+			}
+		}
+		prev, lines = content, next
+	}
+	return lines, nil
+}
+
+// reverse shows the first version's lines, each attributed to the last
+// version that still contained it
+func reverse(s *safe.Safe, versions []version, attrs []Line) ([]Line, error) {
+	engine := diff.NewEngine(0)
+	prev, err := s.Get(versions[0].change.NewHash)
+	if err != nil {
+		return nil, fmt.Errorf("loading content of %s: %w", versions[0].change.Path, err)
+	}
+
+	var lines []Line
+	var live []int // index into lines of each line still present in prev
+	for _, l := range engine.Align(nil, prev) {
+		line := attrs[0]
+		line.Num, line.Content = l.NewNum, l.Content
+		lines = append(lines, line)
+		live = append(live, len(lines)-1)
+	}
+
+	for n, v := range versions[1:] {
+		content, err := s.Get(v.change.NewHash)
+		if err != nil {
+			return nil, fmt.Errorf("loading content of %s: %w", v.change.Path, err)
+		}
+		var next []int
+		for _, l := range engine.Align(prev, content) {
+			switch l.Type {
+			case diff.Context:
+				idx := live[l.OldNum-1]
+				if idx >= 0 {
+					num, text := lines[idx].Num, lines[idx].Content
+					lines[idx] = attrs[n+1]
+					lines[idx].Num, lines[idx].Content = num, text
+				}
+				next = append(next, idx)
+			case diff.Addition:
+				next = append(next, -1) // not in the blamed version
+			}
+		}
+		prev, live = content, next
+	}
+	return lines, nil
+}
+
+// history returns the versions of path oldest first. It follows a move or
+// copy to its source and stops at a deletion, since content from before it
+// cannot reach the current file.
+func history(txn *badger.Txn, path string) ([]version, error) {
+	var versions []version
+	var before *change.ChangeSet // only older changesets belong to a source's history
+	for path != "" {
+		css, err := changeSets(txn, path)
+		if err != nil {
+			return nil, err
+		}
+
+		next := ""
+		for _, cs := range css {
+			if before != nil && !older(cs, before) {
+				continue
+			}
+			c, _ := changeOf(cs, path)
+			if c.Type == "delete" {
+				if len(versions) == 0 {
+					return nil, fmt.Errorf("%s was deleted by changeset %s", path, cs.ID)
+				}
+				break
+			}
+			versions = append(versions, version{cs: cs, change: c})
+			if (c.Type == "rename" || c.Type == "copy") && c.OldPath != "" {
+				next, before = c.OldPath, cs
+				break
+			}
+		}
+		path = next
+	}
+
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	return versions, nil
+}
+
+// changeSets loads the changesets that change path, newest first
+func changeSets(txn *badger.Txn, path string) ([]*change.ChangeSet, error) {
+	prefix := "cs_path:" + path + ":"
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefix)
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	var ids []string
+	for it.Rewind(); it.Valid(); it.Next() {
+		id := strings.TrimPrefix(string(it.Item().Key()), prefix)
+		if !strings.Contains(id, ":") { // skip paths that merely share the prefix
+			ids = append(ids, id)
+		}
+	}
+	it.Close()
+
+	var css []*change.ChangeSet
+	for _, id := range ids {
+		cs, err := change.GetChangeSet(txn, id)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := changeOf(cs, path); ok {
+			css = append(css, cs)
+		}
+	}
+	sort.Slice(css, func(i, j int) bool { return older(css[j], css[i]) })
+	return css, nil
+}
+
+// origin follows parent links while the parent made the same change, so
+// merged and cherry-picked lines are attributed to where they were written
+func origin(txn *badger.Txn, v version) (*change.ChangeSet, error) {
+	cs := v.cs
+	for cs.ParentID != "" {
+		parent, err := change.GetChangeSet(txn, cs.ParentID)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := changeOf(parent, v.change.Path); !ok || c.NewHash != v.change.NewHash {
+			break
+		}
+		cs = parent
+	}
+	return cs, nil
+}
+
+func changeOf(cs *change.ChangeSet, path string) (shared.Change, bool) {
+	for _, c := range cs.Changes {
+		if c.Path == path {
+			return c, true
+		}
+	}
+	return shared.Change{}, false
+}
+
+func older(a, b *change.ChangeSet) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
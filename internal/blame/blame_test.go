@@ -0,0 +1,131 @@
+// internal/blame/blame_test.go
+package blame
+
+import (
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/safe"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	dbOpts := badger.DefaultOptions("").WithInMemory(true)
+	dbOpts.Logger = nil
+	db, err := badger.Open(dbOpts)
+	require.NoError(t, err)
+	defer db.Close()
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	start := time.Now()
+	n := 0
+	commit := func(id, parent string, changes ...shared.Change) {
+		n++
+		cs := &change.ChangeSet{
+			ID:        id,
+			ParentID:  parent,
+			IntentID:  "intent-" + id,
+			Changes:   changes,
+			CreatedAt: start.Add(time.Duration(n) * time.Second),
+			Author:    "author-" + id,
+		}
+		require.NoError(t, db.Update(func(txn *badger.Txn) error {
+			return change.PutChangeSet(txn, cs)
+		}))
+	}
+	store := func(content string) string {
+		hash, err := s.Store([]byte(content))
+		require.NoError(t, err)
+		return hash
+	}
+
+	commit("cs1", "", shared.Change{Path: "a.go", Type: "add", NewHash: store("one\ntwo\nthree\n")})
+	commit("cs2", "", shared.Change{Path: "a.go", Type: "modify", NewHash: store("one\nTWO\nthree\n")})
+	// A fix cherry-picked from another stream
+	fixed := store("one\nTWO\nthree\nfour\n")
+	commit("orig", "", shared.Change{Path: "a.go", Type: "modify", NewHash: fixed})
+	commit("pick", "orig", shared.Change{Path: "a.go", Type: "modify", NewHash: fixed})
+	// Moved, then edited at its new path
+	commit("cs5", "", shared.Change{Path: "b.go", Type: "rename", OldPath: "a.go", NewHash: fixed})
+	commit("cs6", "", shared.Change{Path: "b.go", Type: "modify", NewHash: store("one\nTWO\nfour\n")})
+
+	lines, err := File(db, s, "b.go", Options{})
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "cs1", lines[0].ChangeSetID)
+	assert.Equal(t, "a.go", lines[0].Path)
+	assert.Equal(t, "intent-cs1", lines[0].IntentID)
+	assert.Equal(t, "cs2", lines[1].ChangeSetID)
+	assert.Equal(t, "orig", lines[2].ChangeSetID)
+	assert.Equal(t, 3, lines[2].Num)
+
+	// Each line of the file as of the pick, with the last changeset
+	// that still had it
+	picked, err := File(db, s, "b.go", Options{Reverse: "pick"})
+	require.NoError(t, err)
+	require.Len(t, picked, 4)
+	assert.Equal(t, "cs5", picked[2].ChangeSetID, "three was removed after cs5")
+	assert.Equal(t, "cs6", picked[0].ChangeSetID)
+	assert.Equal(t, "cs6", picked[3].ChangeSetID)
+
+	_, err = File(db, s, "missing.go", Options{})
+	assert.Error(t, err)
+}
+
+func TestFileFollowsParents(t *testing.T) {
+	dbOpts := badger.DefaultOptions("").WithInMemory(true)
+	dbOpts.Logger = nil
+	db, err := badger.Open(dbOpts)
+	require.NoError(t, err)
+	defer db.Close()
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	hash, err := s.Store([]byte("line\n"))
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		// The original lives on another stream and never touched this
+		// history before the pick
+		if err := change.PutChangeSet(txn, &change.ChangeSet{
+			ID: "orig", IntentID: "intent-orig", CreatedAt: now.Add(-time.Hour),
+			Changes: []shared.Change{{Path: "elsewhere.go", Type: "add", NewHash: hash}},
+		}); err != nil {
+			return err
+		}
+		return change.PutChangeSet(txn, &change.ChangeSet{
+			ID: "pick", ParentID: "orig", IntentID: "intent-pick", CreatedAt: now,
+			Changes: []shared.Change{{Path: "elsewhere.go", Type: "add", NewHash: hash}},
+		})
+	}))
+
+	lines, err := File(db, s, "elsewhere.go", Options{})
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "orig", lines[0].ChangeSetID)
+	assert.Equal(t, "intent-orig", lines[0].IntentID)
+
+	lines, err = File(db, s, "elsewhere.go", Options{FirstParent: true})
+	require.NoError(t, err)
+	assert.Equal(t, "orig", lines[0].ChangeSetID, "added by the older changeset")
+
+	// Without the original in this file's history, only parent links
+	// reach it
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte("cs_path:elsewhere.go:orig"))
+	}))
+	lines, err = File(db, s, "elsewhere.go", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "orig", lines[0].ChangeSetID)
+	lines, err = File(db, s, "elsewhere.go", Options{FirstParent: true})
+	require.NoError(t, err)
+	assert.Equal(t, "pick", lines[0].ChangeSetID)
+}
@@ -0,0 +1,120 @@
+// internal/buildcache/buildcache.go
+package buildcache
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/errors"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// entryPrefix is the key prefix of build cache entries, stored under
+// "build_cache:<tree hash>"
+const entryPrefix = "build_cache:"
+
+// Artifact locates one output of a build, e.g. an object store URL
+type Artifact struct {
+	Name     string `json:"name,omitempty"`
+	Location string `json:"location"`
+	Hash     string `json:"hash,omitempty"` // SHA-256 of the artifact, if known
+	Size     int64  `json:"size,omitempty"`
+}
+
+// Entry maps an exact source state to the artifacts built from it
+type Entry struct {
+	Key         string     `json:"key"` // Tree hash of the changes built
+	ChangeSetID string     `json:"changeset_id,omitempty"`
+	Artifacts   []Artifact `json:"artifacts"`
+	Builder     string     `json:"builder,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Key returns the cache key of a changeset: the hash of its changes, so
+// changesets with identical source share cached builds
+func Key(cs *change.ChangeSet) string {
+	return change.HashChanges(cs.Changes)
+}
+
+// Resolve turns a changeset ID or tree hash into a cache key. It also
+// returns the changeset ID when ref names one.
+func Resolve(db *badger.DB, ref string) (key, changeSetID string, err error) {
+	err = db.View(func(txn *badger.Txn) error {
+		cs, err := change.GetChangeSet(txn, ref)
+		if err == nil {
+			key, changeSetID = Key(cs), cs.ID
+			return nil
+		}
+		if !isTreeHash(ref) {
+			return errors.ValidationError(fmt.Sprintf("%q is neither a changeset nor a tree hash", ref), nil)
+		}
+		key = ref
+		return nil
+	})
+	return key, changeSetID, err
+}
+
+// Set records the artifacts built from the source state e.Key, replacing
+// any earlier entry
+func Set(db *badger.DB, e *Entry) error {
+	if !isTreeHash(e.Key) {
+		return errors.ValidationError(fmt.Sprintf("invalid build cache key %q", e.Key), nil)
+	}
+	if len(e.Artifacts) == 0 {
+		return errors.ValidationError("at least one artifact is required", nil)
+	}
+	for n, a := range e.Artifacts {
+		if a.Location == "" {
+			return errors.ValidationError(fmt.Sprintf("artifact %d has no location", n), nil)
+		}
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshaling build cache entry: %w", err)
+	}
+	err = db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(entryPrefix+e.Key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("recording build cache entry: %w", err)
+	}
+	return nil
+}
+
+// Get returns the cached build of a source state, or a not found error
+// if it was never built
+func Get(db *badger.DB, key string) (*Entry, error) {
+	var e Entry
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(entryPrefix + key))
+		if err == badger.ErrKeyNotFound {
+			return errors.NotFound(fmt.Sprintf("no cached build of %s", key))
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &e)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// isTreeHash reports whether s looks like a SHA-256 tree hash
+func isTreeHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
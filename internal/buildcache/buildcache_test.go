@@ -0,0 +1,74 @@
+// internal/buildcache/buildcache_test.go
+package buildcache
+
+import (
+	"strings"
+	"testing"
+
+	"tig/internal/change"
+	tigerrors "tig/internal/errors"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAndGet(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	changes := []shared.Change{{Path: "main.go", Type: "add", NewHash: "aa"}}
+	cs := &change.ChangeSet{ID: "cs1", Changes: changes}
+	same := &change.ChangeSet{ID: "cs2", Changes: changes}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		if err := change.PutChangeSet(txn, cs); err != nil {
+			return err
+		}
+		return change.PutChangeSet(txn, same)
+	}))
+
+	key, id, err := Resolve(db, "cs1")
+	require.NoError(t, err)
+	assert.Equal(t, Key(cs), key)
+	assert.Equal(t, "cs1", id)
+
+	_, err = Get(db, key)
+	var apiErr *tigerrors.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, tigerrors.ErrorTypeNotFound, apiErr.Type)
+
+	e := &Entry{Key: key, ChangeSetID: id, Artifacts: []Artifact{{Name: "app", Location: "s3://builds/app"}}}
+	require.NoError(t, Set(db, e))
+	assert.False(t, e.CreatedAt.IsZero())
+
+	// A changeset with identical changes hits the same cache entry
+	key2, _, err := Resolve(db, "cs2")
+	require.NoError(t, err)
+	got, err := Get(db, key2)
+	require.NoError(t, err)
+	assert.Equal(t, "s3://builds/app", got.Artifacts[0].Location)
+
+	// Setting again replaces the entry
+	require.NoError(t, Set(db, &Entry{Key: key, Artifacts: []Artifact{{Location: "s3://builds/app2"}}}))
+	got, err = Get(db, key)
+	require.NoError(t, err)
+	require.Len(t, got.Artifacts, 1)
+	assert.Equal(t, "s3://builds/app2", got.Artifacts[0].Location)
+
+	// Tree hashes resolve to themselves; anything else is rejected
+	tree := strings.Repeat("ab", 32)
+	key, id, err = Resolve(db, tree)
+	require.NoError(t, err)
+	assert.Equal(t, tree, key)
+	assert.Empty(t, id)
+	_, _, err = Resolve(db, "unknown")
+	assert.Error(t, err)
+
+	assert.Error(t, Set(db, &Entry{Key: "bad", Artifacts: []Artifact{{Location: "x"}}}))
+	assert.Error(t, Set(db, &Entry{Key: tree}))
+	assert.Error(t, Set(db, &Entry{Key: tree, Artifacts: []Artifact{{Name: "app"}}}))
+}
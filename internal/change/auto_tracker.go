@@ -9,9 +9,13 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"tig/internal/config"
 	"tig/internal/diff"
+	"tig/internal/ignore"
+	"tig/internal/stats"
 	"tig/shared/types"
 	"tig/shared/utils"
 
@@ -51,85 +55,218 @@ func (t *LocalTracker) saveTrackedFiles() error {
 	})
 }
 
+// loadGatedChanges reads persisted gated changes from the database
+func (t *LocalTracker) loadGatedChanges() error {
+	return t.DB.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte("gated:")
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			path := strings.TrimPrefix(string(item.Key()), "gated:")
+			err := item.Value(func(val []byte) error {
+				var change shared.Change
+				if err := json.Unmarshal(val, &change); err != nil {
+					return err
+				}
+				t.GatedChanges[path] = change
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // AutoTracker wraps the LocalTracker with automatic tracking capabilities
 type AutoTracker struct {
 	*LocalTracker
-	watcher    *fsnotify.Watcher
-	ignoreDirs map[string]bool
-	mu         sync.RWMutex
-	logger     *zap.Logger
+	watcher *fsnotify.Watcher
+	mu      sync.RWMutex
+	logger  *zap.Logger
+
+	// Filesystem events are coalesced per path and applied in batches
+	pending  map[string]fsnotify.Op
+	interval time.Duration
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	subs map[chan WatchEvent]struct{}
+
+	// The files present when tracking began, listed on first use
+	listed sync.Once
+	listErr error
 }
 
-// NewAutoTracker creates a new AutoTracker instance
-func NewAutoTracker(tracker *LocalTracker, logger *zap.Logger) (*AutoTracker, error) {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return nil, fmt.Errorf("creating file watcher: %w", err)
+// WatchEvent describes a change to a tracked file, emitted once per path
+// per flush
+type WatchEvent struct {
+	Path string    `json:"path"`
+	Op   string    `json:"op"` // create, modify, delete
+	Time time.Time `json:"time"`
+}
+
+// Watch operations reported in WatchEvent.Op
+const (
+	WatchCreate = "create"
+	WatchModify = "modify"
+	WatchDelete = "delete"
+)
+
+// WatchOptions configures an AutoTracker
+type WatchOptions struct {
+	// Glob patterns, relative to the root, that are never watched or
+	// tracked. Patterns without a slash match any path segment.
+	Exclude []string
+	// Shared with the workspace so both agree on what is ignored; when
+	// nil, one is built from Exclude
+	Ignore *ignore.Matcher
+	// How long events are batched before tracked files are saved
+	FlushInterval time.Duration
+	// Largest file, in bytes, diffed line by line; zero keeps
+	// diff.DefaultMaxSize
+	MaxDiffSize int
+	// Leave watching until Start is called, for callers that only read
+	// and gate. The tree is then listed on first use instead of being
+	// walked and watched up front.
+	Deferred bool
+}
+
+// NewAutoTracker creates a new AutoTracker instance, watching the tree
+// unless opts.Deferred is set
+func NewAutoTracker(tracker *LocalTracker, logger *zap.Logger, opts WatchOptions) (*AutoTracker, error) {
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 500 * time.Millisecond
+	}
+
+	if opts.Ignore == nil {
+		opts.Ignore = ignore.New(tracker.Root, opts.Exclude)
+	}
+	tracker.Ignore = opts.Ignore
+	if opts.MaxDiffSize != 0 {
+		tracker.DiffEngine.WithMaxSize(opts.MaxDiffSize)
 	}
 
 	at := &AutoTracker{
 		LocalTracker: tracker,
-		watcher:      watcher,
-		ignoreDirs: map[string]bool{
-			".git":         true,
-			".tig":         true,
-			"node_modules": true,
-			"vendor":       true,
-			"dist":         true,
-			"build":        true,
-		},
-		logger: logger,
-	}
-
-	// Start watching goroutine
-	go at.watchLoop()
+		logger:       logger,
+		pending:  make(map[string]fsnotify.Op),
+		interval: opts.FlushInterval,
+		done:     make(chan struct{}),
+		subs:     make(map[chan WatchEvent]struct{}),
+	}
+
+	if !opts.Deferred {
+		if err := at.Start(); err != nil {
+			return nil, err
+		}
+	}
+	return at, nil
+}
+
+// Start begins watching the tree. Files created or deleted from then on
+// are tracked as they change. Starting a tracker that is already watching
+// does nothing.
+func (at *AutoTracker) Start() error {
+	// The walk below lists the files, so they need no separate listing
+	at.listed.Do(func() {})
+
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	if at.watcher != nil {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating file watcher: %w", err)
+	}
+	at.watcher = watcher
 
 	// Initialize tracking for all files
-	if err := at.initializeTracking(); err != nil {
+	files, err := at.watchTree(at.Root)
+	if err != nil {
 		watcher.Close()
-		return nil, fmt.Errorf("initializing tracking: %w", err)
+		at.watcher = nil
+		return fmt.Errorf("initializing tracking: %w", err)
+	}
+	for _, relPath := range files {
+		at.Tracked[relPath] = true
 	}
 
-	return at, nil
+	// Start watching and flushing goroutines
+	at.wg.Add(2)
+	go at.watchLoop()
+	go at.flushLoop()
+	return nil
+}
+
+// list tracks the files in the tree the first time it is called on a
+// tracker that is not watching. Callers must not hold at.mu.
+func (at *AutoTracker) list() error {
+	at.listed.Do(func() {
+		files, err := at.watchTree(at.Root)
+		if err != nil {
+			at.listErr = fmt.Errorf("listing tracked files: %w", err)
+			return
+		}
+		at.mu.Lock()
+		for _, relPath := range files {
+			at.Tracked[relPath] = true
+		}
+		at.mu.Unlock()
+	})
+	return at.listErr
 }
 
-// initializeTracking sets up initial tracking for all files
-func (at *AutoTracker) initializeTracking() error {
-	return filepath.Walk(at.Root, func(path string, info os.FileInfo, err error) error {
+// watchTree registers a watch on dir and every directory below it that is
+// not ignored, returning the relative paths of the files it contains.
+// Without a watcher it only lists the files.
+func (at *AutoTracker) watchTree(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
+			// The tree may change while we walk it
+			if os.IsNotExist(err) {
+				return nil
+			}
 			return err
 		}
 
-		// Skip ignored directories
-		if info.IsDir() {
-			if at.ShouldIgnore(path) {
+		relPath, err := filepath.Rel(at.Root, path)
+		if err != nil {
+			return fmt.Errorf("getting relative path: %w", err)
+		}
+
+		if d.IsDir() {
+			if relPath != "." && at.ShouldIgnore(relPath) {
 				return filepath.SkipDir
 			}
-			// Add directory to watcher
+			if at.watcher == nil {
+				return nil
+			}
 			if err := at.watcher.Add(path); err != nil {
 				return fmt.Errorf("adding directory to watcher: %w", err)
 			}
 			return nil
 		}
 
-		// Track the file
-		relPath, err := filepath.Rel(at.Root, path)
-		if err != nil {
-			return fmt.Errorf("getting relative path: %w", err)
-		}
-
 		if !at.ShouldIgnore(relPath) {
-			at.mu.Lock()
-			at.Tracked[relPath] = true
-			at.mu.Unlock()
+			files = append(files, relPath)
 		}
-
 		return nil
 	})
+	return files, err
 }
 
 // watchLoop processes filesystem events
 func (at *AutoTracker) watchLoop() {
+	defer at.wg.Done()
 	for {
 		select {
 		case event, ok := <-at.watcher.Events:
@@ -146,7 +283,25 @@ func (at *AutoTracker) watchLoop() {
 	}
 }
 
-// handleFSEvent processes individual filesystem events
+// flushLoop periodically applies batched events
+func (at *AutoTracker) flushLoop() {
+	defer at.wg.Done()
+	ticker := time.NewTicker(at.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-at.done:
+			return
+		case <-ticker.C:
+			if err := at.Flush(); err != nil {
+				at.logger.Error("saving tracked files", zap.Error(err))
+			}
+		}
+	}
+}
+
+// handleFSEvent records a filesystem event to be applied on the next flush
 func (at *AutoTracker) handleFSEvent(event fsnotify.Event) {
 	// Get relative path
 	relPath, err := filepath.Rel(at.Root, event.Name)
@@ -155,59 +310,140 @@ func (at *AutoTracker) handleFSEvent(event fsnotify.Event) {
 		return
 	}
 
+	// Edited ignore files change what is ignored from now on
+	if filepath.Base(relPath) == ignore.File {
+		at.Ignore.Reload()
+	}
+
 	// Skip ignored paths
 	if at.ShouldIgnore(relPath) {
 		return
 	}
 
-	at.mu.Lock()
-	defer at.mu.Unlock()
-
-	switch {
-	case event.Op&fsnotify.Create == fsnotify.Create:
-		// Handle directory creation
+	// Register watches for new directory trees straight away so events
+	// inside them aren't missed. Files created before the watch was added
+	// are picked up by the walk.
+	var created []string
+	if event.Op&fsnotify.Create == fsnotify.Create {
 		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
-			if err := at.watcher.Add(event.Name); err != nil {
+			created, err = at.watchTree(event.Name)
+			if err != nil {
 				at.logger.Error("adding new directory to watcher", zap.Error(err))
 			}
+			relPath = ""
 		}
-		at.Tracked[relPath] = true
+	}
+
+	at.mu.Lock()
+	defer at.mu.Unlock()
 
-	case event.Op&fsnotify.Remove == fsnotify.Remove:
-		delete(at.Tracked, relPath)
+	if relPath != "" {
+		at.pending[relPath] |= event.Op
+	}
+	for _, p := range created {
+		at.pending[p] |= fsnotify.Create
+	}
+}
 
-	case event.Op&fsnotify.Write == fsnotify.Write:
-		at.Tracked[relPath] = true
+// Flush applies pending filesystem events and saves the tracked files
+func (at *AutoTracker) Flush() error {
+	at.mu.Lock()
+	defer at.mu.Unlock()
 
-	case event.Op&fsnotify.Rename == fsnotify.Rename:
-		delete(at.Tracked, relPath)
+	if len(at.pending) == 0 {
+		return nil
 	}
 
-	// Save tracked files state
-	if err := at.saveTrackedFiles(); err != nil {
-		at.logger.Error("saving tracked files", zap.Error(err))
+	now := time.Now()
+	var changes []WatchEvent
+	for relPath, op := range at.pending {
+		// Several events may have been coalesced; the file's presence on
+		// disk decides the outcome
+		if op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+			if _, err := os.Stat(filepath.Join(at.Root, relPath)); err != nil {
+				if at.Tracked[relPath] {
+					changes = append(changes, WatchEvent{Path: relPath, Op: WatchDelete, Time: now})
+				}
+				delete(at.Tracked, relPath)
+				continue
+			}
+		}
+
+		// Directories are watched but not tracked
+		if info, err := os.Stat(filepath.Join(at.Root, relPath)); err == nil && info.IsDir() {
+			continue
+		}
+
+		change := WatchEvent{Path: relPath, Op: WatchModify, Time: now}
+		if !at.Tracked[relPath] {
+			change.Op = WatchCreate
+		}
+		changes = append(changes, change)
+		at.Tracked[relPath] = true
 	}
+	at.pending = make(map[string]fsnotify.Op)
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	at.publish(changes)
+
+	return at.saveTrackedFiles()
 }
 
-// shouldIgnore checks if a path should be ignored
-func (at *AutoTracker) ShouldIgnore(path string) bool {
-	if path == "" {
-		return true
+// Subscribe returns a channel receiving change events after each flush
+// and a function that cancels the subscription. Events are dropped for
+// subscribers that fall behind.
+func (at *AutoTracker) Subscribe() (<-chan WatchEvent, func()) {
+	ch := make(chan WatchEvent, 256)
+
+	at.mu.Lock()
+	at.subs[ch] = struct{}{}
+	at.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			at.mu.Lock()
+			delete(at.subs, ch)
+			at.mu.Unlock()
+			close(ch)
+		})
 	}
+}
 
-	parts := strings.Split(path, string(filepath.Separator))
-	for _, part := range parts {
-		if at.ignoreDirs[part] {
-			return true
+// publish sends events to subscribers; callers must hold at.mu
+func (at *AutoTracker) publish(changes []WatchEvent) {
+	for ch := range at.subs {
+		for _, change := range changes {
+			select {
+			case ch <- change:
+			default:
+				at.logger.Warn("watch subscriber is behind, dropping event",
+					zap.String("path", change.Path))
+			}
 		}
 	}
+}
 
-	return false
+// ShouldIgnore reports whether path, relative to the root, is never
+// tracked: the built-in rules, watch.exclude and .tigignore files decide
+func (lt *LocalTracker) ShouldIgnore(path string) bool {
+	return lt.Ignore.Ignored(path)
 }
 
-// Close cleans up resources
+// Close stops watching and saves any pending events. A tracker that never
+// started watching has nothing to save.
 func (at *AutoTracker) Close() error {
-	return at.watcher.Close()
+	if at.watcher == nil {
+		return nil
+	}
+	close(at.done)
+	err := at.watcher.Close()
+	at.wg.Wait()
+
+	if ferr := at.Flush(); ferr != nil && err == nil {
+		err = ferr
+	}
+	return err
 }
 
 func (lt *LocalTracker) Track(paths []string) error {
@@ -277,6 +513,9 @@ func (lt *LocalTracker) Untrack(paths []string) error {
 
 // Untrack removes files from tracking
 func (at *AutoTracker) Untrack(paths []string) error {
+	if err := at.list(); err != nil {
+		return err
+	}
 	at.mu.Lock()
 	defer at.mu.Unlock()
 
@@ -287,31 +526,6 @@ func (at *AutoTracker) Untrack(paths []string) error {
 	return at.saveTrackedFiles()
 }
 
-
-
-// Helper function for checking if a path should be ignored
-func shouldIgnore(path string) bool {
-	if path == "" {
-		return true
-	}
-
-	base := filepath.Base(path)
-	if base[0] == '.' {
-		return true
-	}
-
-	ignoreList := map[string]bool{
-		".git":         true,
-		".tig":         true,
-		"node_modules": true,
-		"vendor":       true,
-		"dist":         true,
-		"build":        true,
-	}
-
-	return ignoreList[base]
-}
-
 func (lt *LocalTracker) ShowFileDiff(path string) (*diff.DiffResult, error) {
 	lt.Mu.RLock()
 	defer lt.Mu.RUnlock()
@@ -340,7 +554,7 @@ func (lt *LocalTracker) ShowFileDiff(path string) (*diff.DiffResult, error) {
 	}
 
 	// Generate diff using the DiffEngine
-	return lt.DiffEngine.Diff(oldContent, currentContent)
+	return lt.DiffEngine.DiffFile(path, oldContent, currentContent)
 }
 
 // ShowFileDiff computes the diff for a specific file
@@ -361,7 +575,7 @@ func (at *AutoTracker) ShowFileDiff(path string) (*diff.DiffResult, error) {
 		oldContent = []byte{}
 	}
 
-	return at.DiffEngine.Diff(oldContent, currentContent)
+	return at.DiffEngine.DiffFile(path, oldContent, currentContent)
 }
 
 // Add this helper function for generating IDs
@@ -383,6 +597,11 @@ func (at *AutoTracker) GetGatedChange(relPath string) (shared.Change, error) {
 }
 
 func (lt *LocalTracker) hashChangeSet(changes []shared.Change) string {
+	return HashChanges(changes)
+}
+
+// HashChanges returns the verification hash of a changeset's changes
+func HashChanges(changes []shared.Change) string {
 	h := sha256.New()
 	for _, change := range changes {
 		// Include all relevant fields in hash calculation
@@ -395,34 +614,122 @@ func (lt *LocalTracker) hashChangeSet(changes []shared.Change) string {
 }
 
 func (lt *LocalTracker) storeChangeSet(cs *ChangeSet) error {
+	return lt.DB.Update(func(txn *badger.Txn) error {
+		return PutChangeSet(txn, cs)
+	})
+}
+
+// PutChangeSet stores a changeset with its time and path indices and
+// updates churn statistics inside txn
+func PutChangeSet(txn *badger.Txn, cs *ChangeSet) error {
 	data, err := json.Marshal(cs)
 	if err != nil {
 		return fmt.Errorf("marshaling changeset: %w", err)
 	}
 
-	return lt.DB.Update(func(txn *badger.Txn) error {
-		// Store main changeset data
-		key := []byte(fmt.Sprintf("changeset:%s", cs.ID))
-		if err := txn.Set(key, data); err != nil {
-			return fmt.Errorf("storing changeset: %w", err)
-		}
+	// Store main changeset data
+	key := []byte(fmt.Sprintf("changeset:%s", cs.ID))
+	if err := txn.Set(key, data); err != nil {
+		return fmt.Errorf("storing changeset: %w", err)
+	}
+
+	// Store time index
+	timeKey := []byte(fmt.Sprintf("cs_time:%d:%s", cs.CreatedAt.Unix(), cs.ID))
+	if err := txn.Set(timeKey, nil); err != nil {
+		return fmt.Errorf("storing time index: %w", err)
+	}
 
-		// Store time index
-		timeKey := []byte(fmt.Sprintf("cs_time:%d:%s", cs.CreatedAt.Unix(), cs.ID))
-		if err := txn.Set(timeKey, nil); err != nil {
-			return fmt.Errorf("storing time index: %w", err)
+	// Store path indices for each changed file
+	paths := make([]string, 0, len(cs.Changes))
+	for _, change := range cs.Changes {
+		pathKey := []byte(fmt.Sprintf("cs_path:%s:%s", change.Path, cs.ID))
+		if err := txn.Set(pathKey, nil); err != nil {
+			return fmt.Errorf("storing path index: %w", err)
 		}
+		paths = append(paths, change.Path)
+	}
 
-		// Store path indices for each changed file
-		for _, change := range cs.Changes {
-			pathKey := []byte(fmt.Sprintf("cs_path:%s:%s", change.Path, cs.ID))
-			if err := txn.Set(pathKey, nil); err != nil {
-				return fmt.Errorf("storing path index: %w", err)
-			}
+	// Keep churn statistics current
+	if err := stats.Record(txn, cs.Author, paths, cs.CreatedAt); err != nil {
+		return fmt.Errorf("updating churn statistics: %w", err)
+	}
+
+	return nil
+}
+
+// GetChangeSet loads a changeset inside txn
+func GetChangeSet(txn *badger.Txn, id string) (*ChangeSet, error) {
+	item, err := txn.Get([]byte("changeset:" + id))
+	if err != nil {
+		return nil, fmt.Errorf("getting changeset %s: %w", id, err)
+	}
+	var cs ChangeSet
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &cs)
+	}); err != nil {
+		return nil, fmt.Errorf("decoding changeset %s: %w", id, err)
+	}
+	return &cs, nil
+}
+
+// StateHash returns the content hash last committed for path inside txn,
+// or "" if the file is not tracked
+func StateHash(txn *badger.Txn, path string) (string, error) {
+	item, err := txn.Get([]byte("file_state:" + path))
+	if err == badger.ErrKeyNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var state FileState
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &state)
+	}); err != nil {
+		return "", fmt.Errorf("decoding file state for %s: %w", path, err)
+	}
+	return state.Hash, nil
+}
+
+// ApplyChange records a committed change inside txn: the file's tracked
+// state is updated (or removed for deletions, and moved for renames) and
+// it is no longer gated
+func ApplyChange(txn *badger.Txn, change shared.Change) error {
+	stateKey := []byte("file_state:" + change.Path)
+	if change.Type == "delete" {
+		if err := txn.Delete(stateKey); err != nil {
+			return fmt.Errorf("removing file state for %s: %w", change.Path, err)
+		}
+	} else {
+		data, err := json.Marshal(FileState{
+			Hash:    change.NewHash,
+			ModTime: change.ModTime,
+			Size:    change.Size,
+			Mode:    change.Mode,
+		})
+		if err != nil {
+			return fmt.Errorf("marshaling file state: %w", err)
+		}
+		if err := txn.Set(stateKey, data); err != nil {
+			return fmt.Errorf("storing file state for %s: %w", change.Path, err)
 		}
+	}
+	if change.Type == "rename" && change.OldPath != "" {
+		if err := txn.Delete([]byte("file_state:" + change.OldPath)); err != nil {
+			return fmt.Errorf("removing file state for %s: %w", change.OldPath, err)
+		}
+	}
+	return txn.Delete([]byte("gated:" + change.Path))
+}
 
-		return nil
-	})
+// ReloadGated replaces the in-memory gated changes with those persisted,
+// after they were changed by another writer
+func (lt *LocalTracker) ReloadGated() error {
+	lt.Mu.Lock()
+	defer lt.Mu.Unlock()
+
+	lt.GatedChanges = make(map[string]shared.Change)
+	return lt.loadGatedChanges()
 }
 
 func (lt *LocalTracker) updateFileState(change shared.Change) error {
@@ -430,6 +737,7 @@ func (lt *LocalTracker) updateFileState(change shared.Change) error {
 		Hash:    change.NewHash,
 		ModTime: change.ModTime,
 		Size:    change.Size,
+		Mode:    change.Mode,
 	}
 
 	data, err := json.Marshal(state)
@@ -448,6 +756,7 @@ type FileState struct {
 	Hash    string    `json:"hash"`
 	ModTime time.Time `json:"mod_time"`
 	Size    int64     `json:"size"`
+	Mode    int       `json:"mode,omitempty"` // os.FileMode as gated; 0 for states recorded before modes were kept
 }
 
 // getFileState retrieves the last known state of a file
@@ -531,6 +840,7 @@ func (lt *LocalTracker) CreateChangeSet(description string) (*ChangeSet, error)
         Changes:     changes,
         CreatedAt:   time.Now(),
         Description: description,
+        Author:      config.Author(),
         Hash:        lt.hashChangeSet(changes),
     }
 
@@ -604,6 +914,9 @@ func (lt *LocalTracker) Gate(path string) error {
 
 // Status retrieves the current status of the workspace.
 func (at *AutoTracker) Status() ([]shared.Change, error) {
+    if err := at.list(); err != nil {
+        return nil, err
+    }
     at.mu.RLock()
     defer at.mu.RUnlock()
 
@@ -674,6 +987,17 @@ func (at *AutoTracker) Status() ([]shared.Change, error) {
         return nil, fmt.Errorf("walking workspace: %w", err)
     }
 
+    // Gated deletions have no file left to walk
+    for path, gatedChange := range at.GatedChanges {
+        if gatedChange.Type != "delete" {
+            continue
+        }
+        if _, err := os.Stat(filepath.Join(at.Root, path)); os.IsNotExist(err) {
+            gatedChange.Gated = true
+            changes = append(changes, gatedChange)
+        }
+    }
+
     // Check for deleted files
     for path, wasTracked := range at.Tracked {
         if wasTracked {
@@ -693,6 +1017,9 @@ func (at *AutoTracker) Status() ([]shared.Change, error) {
 
 // Gate implements Tracker.Gate
 func (at *AutoTracker) Gate(path string) error {
+    if err := at.list(); err != nil {
+        return err
+    }
     at.mu.Lock()
     defer at.mu.Unlock()
 
@@ -0,0 +1,157 @@
+// internal/change/auto_tracker_test.go
+package change
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tig/internal/safe"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestAutoTracker(t *testing.T, root string, opts WatchOptions) *AutoTracker {
+	dbOpts := badger.DefaultOptions("").WithInMemory(true)
+	dbOpts.Logger = nil
+	db, err := badger.Open(dbOpts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	lt, err := NewLocalTracker(root, db, s)
+	require.NoError(t, err)
+	at, err := NewAutoTracker(lt, zap.NewNop(), opts)
+	require.NoError(t, err)
+	return at
+}
+
+func (at *AutoTracker) isTracked(path string) bool {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+	return at.Tracked[path]
+}
+
+func write(t *testing.T, root, rel string) {
+	path := filepath.Join(root, rel)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(rel), 0644))
+}
+
+func TestAutoTrackerExcludeAndBatching(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, "main.go")
+	write(t, root, "app.log")
+	write(t, root, "target/debug/bin")
+
+	at := newTestAutoTracker(t, root, WatchOptions{
+		Exclude:       []string{"*.log", "target/**"},
+		FlushInterval: time.Hour, // flush manually
+	})
+
+	assert.True(t, at.isTracked("main.go"))
+	assert.False(t, at.isTracked("app.log"))
+	assert.False(t, at.isTracked("target/debug/bin"))
+
+	// Events are held until the next flush
+	write(t, root, "new.go")
+	write(t, root, "other.log")
+	require.Eventually(t, func() bool {
+		at.mu.RLock()
+		defer at.mu.RUnlock()
+		return at.pending["new.go"] != 0
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.False(t, at.isTracked("new.go"))
+
+	require.NoError(t, at.Flush())
+	assert.True(t, at.isTracked("new.go"))
+	assert.False(t, at.isTracked("other.log"))
+
+	require.NoError(t, os.Remove(filepath.Join(root, "new.go")))
+	require.Eventually(t, func() bool {
+		require.NoError(t, at.Flush())
+		return !at.isTracked("new.go")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, at.Close())
+}
+
+func TestAutoTrackerNestedDirectories(t *testing.T) {
+	root := t.TempDir()
+	at := newTestAutoTracker(t, root, WatchOptions{FlushInterval: 10 * time.Millisecond})
+	defer at.Close()
+
+	// Files created together with their directories are found by the
+	// recursive registration, later writes by the new watches
+	write(t, root, "a/b/c/one.txt")
+	require.Eventually(t, func() bool {
+		return at.isTracked(filepath.Join("a", "b", "c", "one.txt"))
+	}, 2*time.Second, 10*time.Millisecond)
+
+	write(t, root, "a/b/c/two.txt")
+	require.Eventually(t, func() bool {
+		return at.isTracked(filepath.Join("a", "b", "c", "two.txt"))
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestAutoTrackerSubscribe(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, "existing.txt")
+	at := newTestAutoTracker(t, root, WatchOptions{FlushInterval: time.Hour})
+	defer at.Close()
+
+	events, cancel := at.Subscribe()
+	defer cancel()
+
+	write(t, root, "existing.txt")
+	write(t, root, "new.txt")
+	require.Eventually(t, func() bool {
+		at.mu.RLock()
+		defer at.mu.RUnlock()
+		return at.pending["existing.txt"] != 0 && at.pending["new.txt"] != 0
+	}, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, at.Flush())
+
+	var got []string
+	for i := 0; i < 2; i++ {
+		e := <-events
+		got = append(got, e.Op+" "+e.Path)
+	}
+	assert.Equal(t, []string{"modify existing.txt", "create new.txt"}, got)
+}
+
+func TestAutoTrackerDeferred(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, "main.go")
+	at := newTestAutoTracker(t, root, WatchOptions{FlushInterval: time.Hour, Deferred: true})
+
+	// Nothing is watched or listed until the tracker is used
+	assert.Nil(t, at.watcher)
+	assert.False(t, at.isTracked("main.go"))
+	changes, err := at.Status()
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "modify", changes[0].Type)
+	assert.True(t, at.isTracked("main.go"))
+
+	write(t, root, "new.go")
+	require.NoError(t, at.Flush())
+	assert.False(t, at.isTracked("new.go"))
+
+	// Start watches from then on
+	require.NoError(t, at.Start())
+	require.NoError(t, at.Start())
+	write(t, root, "later.go")
+	require.Eventually(t, func() bool {
+		require.NoError(t, at.Flush())
+		return at.isTracked("later.go")
+	}, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, at.Close())
+}
@@ -0,0 +1,174 @@
+// internal/change/copies.go
+package change
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"tig/internal/safe"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DefaultCopyThreshold is the share of chunks a new file must have in
+// common with an existing one to be recorded as a copy of it
+const DefaultCopyThreshold = 0.5
+
+// minCopyChunks keeps tiny files, which match too easily, from being
+// reported as copies
+const minCopyChunks = 3
+
+// copySource is a file a new file may have been copied from
+type copySource struct {
+	path   string
+	hash   string
+	size   int64
+	chunks map[uint64]int // loaded on first comparison
+}
+
+// DetectCopies turns additions in changes whose content is substantially
+// copied from another file into "copy" changes with OldPath and OldHash
+// set to the source. Sources are the files committed as of txn and the
+// new content of files modified in the same changes. Similarity is the
+// share of line chunks the two files have in common, relative to the
+// larger of them.
+func DetectCopies(txn *badger.Txn, s *safe.Safe, changes []shared.Change, threshold float64) error {
+	var added []int
+	for n, c := range changes {
+		if c.Type == "add" && c.NewHash != "" {
+			added = append(added, n)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	sources, err := copySources(txn, changes)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range added {
+		c := &changes[n]
+		content, err := s.Get(c.NewHash)
+		if err != nil {
+			return fmt.Errorf("loading content of %s: %w", c.Path, err)
+		}
+		chunks := chunkHashes(content)
+		if countChunks(chunks) < minCopyChunks {
+			continue
+		}
+
+		var best *copySource
+		var bestScore float64
+		for _, src := range sources {
+			if src.path == c.Path || !sizesCompatible(int64(len(content)), src.size, threshold) {
+				continue
+			}
+			if src.chunks == nil {
+				data, err := s.Get(src.hash)
+				if err != nil {
+					return fmt.Errorf("loading content of %s: %w", src.path, err)
+				}
+				src.chunks = chunkHashes(data)
+			}
+			if score := similarity(chunks, src.chunks); score >= threshold && score > bestScore {
+				best, bestScore = src, score
+			}
+		}
+		if best != nil {
+			c.Type = "copy"
+			c.OldPath = best.path
+			c.OldHash = best.hash
+		}
+	}
+	return nil
+}
+
+// copySources lists the committed files and the files modified in changes
+func copySources(txn *badger.Txn, changes []shared.Change) ([]*copySource, error) {
+	byPath := make(map[string]*copySource)
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte("file_state:")
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		path := strings.TrimPrefix(string(item.Key()), "file_state:")
+		var state FileState
+		if err := item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &state)
+		}); err != nil {
+			return nil, fmt.Errorf("decoding file state for %s: %w", path, err)
+		}
+		if state.Hash != "" {
+			byPath[path] = &copySource{path: path, hash: state.Hash, size: state.Size}
+		}
+	}
+
+	for _, c := range changes {
+		switch c.Type {
+		case "delete":
+			delete(byPath, c.Path)
+		case "modify":
+			byPath[c.Path] = &copySource{path: c.Path, hash: c.NewHash, size: c.Size}
+		}
+	}
+
+	sources := make([]*copySource, 0, len(byPath))
+	for _, src := range byPath {
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// chunkHashes hashes each non-blank line, ignoring surrounding whitespace
+// so reindented copies still match, and counts how often each occurs
+func chunkHashes(content []byte) map[uint64]int {
+	chunks := make(map[uint64]int)
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		h := fnv.New64a()
+		h.Write(line)
+		chunks[h.Sum64()]++
+	}
+	return chunks
+}
+
+func countChunks(chunks map[uint64]int) int {
+	total := 0
+	for _, n := range chunks {
+		total += n
+	}
+	return total
+}
+
+// similarity returns the chunks shared by a and b relative to the larger
+func similarity(a, b map[uint64]int) float64 {
+	shared := 0
+	for h, n := range a {
+		shared += min(n, b[h])
+	}
+	larger := max(countChunks(a), countChunks(b))
+	if larger == 0 {
+		return 0
+	}
+	return float64(shared) / float64(larger)
+}
+
+// sizesCompatible rules out files too different in size to reach the
+// threshold without reading them. Sizes of 0 are unknown.
+func sizesCompatible(a, b int64, threshold float64) bool {
+	if a == 0 || b == 0 {
+		return true
+	}
+	return float64(min(a, b)) >= float64(max(a, b))*threshold/2
+}
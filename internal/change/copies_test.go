@@ -0,0 +1,56 @@
+// internal/change/copies_test.go
+package change
+
+import (
+	"testing"
+
+	"tig/internal/safe"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectCopies(t *testing.T) {
+	dbOpts := badger.DefaultOptions("").WithInMemory(true)
+	dbOpts.Logger = nil
+	db, err := badger.Open(dbOpts)
+	require.NoError(t, err)
+	defer db.Close()
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	store := func(content string) string {
+		hash, err := s.Store([]byte(content))
+		require.NoError(t, err)
+		return hash
+	}
+	original := "func a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 2\n}\n"
+	committed := store(original)
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return ApplyChange(txn, shared.Change{Path: "a.go", Type: "add", NewHash: committed})
+	}))
+
+	changes := []shared.Change{
+		// Reindented with one line changed
+		{Path: "copy.go", Type: "add", NewHash: store("func a() {\n    return 1\n}\n\nfunc c() {\n    return 2\n}\n")},
+		{Path: "other.go", Type: "add", NewHash: store("package other\n\nvar x = 1\nvar y = 2\n")},
+		{Path: "tiny.go", Type: "add", NewHash: store("}\n")},
+		// Copied from a file modified in the same changeset
+		{Path: "b.go", Type: "modify", NewHash: store("one\ntwo\nthree\nfour\n")},
+		{Path: "b_copy.go", Type: "add", NewHash: store("one\ntwo\nthree\nfour\nfive\n")},
+	}
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		return DetectCopies(txn, s, changes, DefaultCopyThreshold)
+	}))
+
+	assert.Equal(t, "copy", changes[0].Type)
+	assert.Equal(t, "a.go", changes[0].OldPath)
+	assert.Equal(t, committed, changes[0].OldHash)
+	assert.Equal(t, "add", changes[1].Type)
+	assert.Equal(t, "add", changes[2].Type)
+	assert.Equal(t, "copy", changes[4].Type)
+	assert.Equal(t, "b.go", changes[4].OldPath)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"tig/internal/diff"
+	"tig/internal/ignore"
 	"tig/internal/safe"
 	"tig/shared/types"
 
@@ -28,7 +29,7 @@ func NewLocalTracker(root string, db *badger.DB, contentSafe *safe.Safe) (*Local
 		return nil, fmt.Errorf("initializing logger: %w", err)
 	}
 
-	return &LocalTracker{
+	lt := &LocalTracker{
 		Root:         root,
 		DB:           db,
 		ContentSafe:  contentSafe,
@@ -36,11 +37,19 @@ func NewLocalTracker(root string, db *badger.DB, contentSafe *safe.Safe) (*Local
 		Tracked:      make(map[string]bool),
 		GatedChanges: make(map[string]shared.Change),
 		Logger:       logger,
-	}, nil
+		Ignore:       ignore.New(root, nil),
+	}
+
+	// Pick up changes gated by the workspace in earlier invocations
+	if err := lt.loadGatedChanges(); err != nil {
+		return nil, fmt.Errorf("loading gated changes: %w", err)
+	}
+
+	return lt, nil
 }
 
 // NewTracker creates a new tracker with automatic tracking enabled
-func NewTracker(root string, db *badger.DB, contentSafe *safe.Safe, logger *zap.Logger) (Tracker, error) {
+func NewTracker(root string, db *badger.DB, contentSafe *safe.Safe, logger *zap.Logger, opts WatchOptions) (Tracker, error) {
 	// Create base LocalTracker
 	localTracker, err := NewLocalTracker(root, db, contentSafe)
 	if err != nil {
@@ -48,7 +57,7 @@ func NewTracker(root string, db *badger.DB, contentSafe *safe.Safe, logger *zap.
 	}
 
 	// Wrap with AutoTracker
-	autoTracker, err := NewAutoTracker(localTracker, logger)
+	autoTracker, err := NewAutoTracker(localTracker, logger, opts)
 	if err != nil {
 		return nil, fmt.Errorf("creating auto tracker: %w", err)
 	}
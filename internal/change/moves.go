@@ -0,0 +1,177 @@
+// internal/change/moves.go
+package change
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tig/shared/types"
+	"tig/shared/utils"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Mover is implemented by trackers that can record moves and removals
+// made through tig itself, without relying on rename detection
+type Mover interface {
+	// GateMove gates the move of oldPath to newPath. newPath must already
+	// exist on disk. Files never committed are gated as additions.
+	GateMove(oldPath, newPath string) error
+	// GateRemove gates the deletion of path. Files never committed are
+	// only ungated.
+	GateRemove(path string) error
+}
+
+var (
+	_ Mover = (*LocalTracker)(nil)
+	_ Mover = (*AutoTracker)(nil)
+)
+
+// GateMove implements Mover
+func (lt *LocalTracker) GateMove(oldPath, newPath string) error {
+	lt.Mu.Lock()
+	defer lt.Mu.Unlock()
+	return lt.gateMove(oldPath, newPath)
+}
+
+// GateRemove implements Mover
+func (lt *LocalTracker) GateRemove(path string) error {
+	lt.Mu.Lock()
+	defer lt.Mu.Unlock()
+	return lt.gateRemove(path)
+}
+
+// GateMove implements Mover
+func (at *AutoTracker) GateMove(oldPath, newPath string) error {
+	if err := at.list(); err != nil {
+		return err
+	}
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	return at.gateMove(oldPath, newPath)
+}
+
+// GateRemove implements Mover
+func (at *AutoTracker) GateRemove(path string) error {
+	if err := at.list(); err != nil {
+		return err
+	}
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	return at.gateRemove(path)
+}
+
+// origin returns where the content at path was last committed and its
+// hash, following a move gated earlier. committed is false for files
+// that were never committed.
+func (lt *LocalTracker) origin(path string) (from, hash string, committed bool, err error) {
+	if prev, ok := lt.GatedChanges[path]; ok && prev.Type == "rename" {
+		return prev.OldPath, prev.OldHash, true, nil
+	}
+	err = lt.DB.View(func(txn *badger.Txn) error {
+		hash, err = StateHash(txn, path)
+		return err
+	})
+	if err != nil {
+		return "", "", false, fmt.Errorf("reading file state for %s: %w", path, err)
+	}
+	return path, hash, hash != "", nil
+}
+
+func (lt *LocalTracker) gateMove(oldPath, newPath string) error {
+	from, oldHash, committed, err := lt.origin(oldPath)
+	if err != nil {
+		return err
+	}
+
+	absPath := filepath.Join(lt.Root, newPath)
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return fmt.Errorf("accessing file %s: %w", newPath, err)
+	}
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("reading file %s: %w", newPath, err)
+	}
+	if _, err := lt.ContentSafe.Store(content); err != nil {
+		return fmt.Errorf("storing content: %w", err)
+	}
+
+	change := shared.Change{
+		Path:    newPath,
+		Type:    "rename",
+		OldPath: from,
+		OldHash: oldHash,
+		NewHash: utils.HashContent(content),
+		Mode:    int(info.Mode()),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Gated:   true,
+	}
+	switch {
+	case !committed:
+		// A file that was never committed is added at its new path
+		change.Type, change.OldPath = "add", ""
+	case from == newPath:
+		// Moved back to where it was committed
+		change.Type, change.OldPath = "modify", ""
+	}
+
+	if err := lt.replaceGated([]string{oldPath}, &change); err != nil {
+		return err
+	}
+	delete(lt.Tracked, oldPath)
+	lt.Tracked[newPath] = true
+	return lt.saveTrackedFiles()
+}
+
+func (lt *LocalTracker) gateRemove(path string) error {
+	from, oldHash, committed, err := lt.origin(path)
+	if err != nil {
+		return err
+	}
+
+	// Removing a file that was never committed just drops it
+	var change *shared.Change
+	if committed {
+		change = &shared.Change{Path: from, Type: "delete", OldHash: oldHash, Gated: true}
+	}
+	if err := lt.replaceGated([]string{path}, change); err != nil {
+		return err
+	}
+	delete(lt.Tracked, path)
+	return lt.saveTrackedFiles()
+}
+
+// replaceGated drops the gated changes of paths and gates change, if not
+// nil, in one transaction
+func (lt *LocalTracker) replaceGated(paths []string, change *shared.Change) error {
+	err := lt.DB.Update(func(txn *badger.Txn) error {
+		for _, path := range paths {
+			if err := txn.Delete([]byte("gated:" + path)); err != nil {
+				return err
+			}
+		}
+		if change == nil {
+			return nil
+		}
+		data, err := json.Marshal(change)
+		if err != nil {
+			return fmt.Errorf("marshaling change: %w", err)
+		}
+		return txn.Set([]byte("gated:"+change.Path), data)
+	})
+	if err != nil {
+		return fmt.Errorf("saving gated changes: %w", err)
+	}
+
+	for _, path := range paths {
+		delete(lt.GatedChanges, path)
+	}
+	if change != nil {
+		lt.GatedChanges[change.Path] = *change
+	}
+	return nil
+}
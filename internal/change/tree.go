@@ -0,0 +1,63 @@
+// internal/change/tree.go
+package change
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// TreeAt returns the files as of a changeset, by replaying every
+// changeset recorded up to and including it in creation order
+func TreeAt(txn *badger.Txn, changeSetID string) (map[string]FileState, error) {
+	target, err := GetChangeSet(txn, changeSetID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sets []*ChangeSet
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte("changeset:")
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		var cs ChangeSet
+		if err := it.Item().Value(func(val []byte) error {
+			return json.Unmarshal(val, &cs)
+		}); err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+		}
+		if !cs.CreatedAt.After(target.CreatedAt) {
+			sets = append(sets, &cs)
+		}
+	}
+	sort.Slice(sets, func(x, y int) bool {
+		if !sets[x].CreatedAt.Equal(sets[y].CreatedAt) {
+			return sets[x].CreatedAt.Before(sets[y].CreatedAt)
+		}
+		// The target goes last among changesets created at the same time
+		return sets[y].ID == target.ID || sets[x].ID != target.ID && sets[x].ID < sets[y].ID
+	})
+
+	files := make(map[string]FileState)
+	for _, cs := range sets {
+		for _, c := range cs.Changes {
+			path := filepath.ToSlash(c.Path)
+			switch c.Type {
+			case "delete":
+				delete(files, path)
+				continue
+			case "rename":
+				delete(files, filepath.ToSlash(c.OldPath))
+			}
+			files[path] = FileState{Hash: c.NewHash, ModTime: c.ModTime, Size: c.Size, Mode: c.Mode}
+		}
+		if cs.ID == target.ID {
+			break
+		}
+	}
+	return files, nil
+}
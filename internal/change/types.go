@@ -4,6 +4,7 @@ package change
 import (
 	"sync"
 	"tig/internal/diff"
+	"tig/internal/ignore"
 	"tig/internal/safe"
 	"time"
 	"tig/shared/types"
@@ -23,6 +24,7 @@ type LocalTracker struct {
 	Mu           sync.RWMutex
 	GatedChanges map[string]shared.Change
 	Logger       *zap.Logger
+	Ignore       *ignore.Matcher // Decides which paths are never tracked
 }
 
 // ChangeSet groups related changes together
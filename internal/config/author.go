@@ -0,0 +1,26 @@
+// internal/config/author.go
+package config
+
+import (
+	"os"
+	"os/user"
+)
+
+// AuthorEnv overrides the author recorded on changesets and intents
+const AuthorEnv = "TIG_AUTHOR"
+
+// Author returns the name recorded as the author of new changesets: the
+// TIG_AUTHOR environment variable, the author in the user config, or the
+// current OS user.
+func Author() string {
+	if a := os.Getenv(AuthorEnv); a != "" {
+		return a
+	}
+	if cfg, err := LoadUser(); err == nil && cfg.Author != "" {
+		return cfg.Author
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 )
 
 type Config struct {
@@ -19,6 +20,101 @@ type Config struct {
     
     Environment string `json:"environment"` // dev, prod
     LogLevel    string `json:"log_level"`  // debug, info, warn, error
+
+    Notifications Notifications `json:"notifications"`
+    Scrub         Scrub         `json:"scrub"`
+    Stale         Stale         `json:"stale"`
+    Cache         Cache         `json:"cache"`
+    Diff          Diff          `json:"diff"`
+    Health        Health        `json:"health"`
+    License       License       `json:"license"`
+    Hooks         []Hook        `json:"hooks"`
+
+    // IntentFields is the intent metadata schema checked by the API. tig
+    // serve takes it from the repository config.
+    IntentFields IntentFields `json:"intent_fields,omitempty"`
+    // Workflow limits intent state changes made through the API. tig serve
+    // takes it from the repository config.
+    Workflow Workflow `json:"workflow,omitempty"`
+}
+
+// License locates the signed license enabling enterprise feature groups
+type License struct {
+    Path string `json:"path"` // default .tig/license.json in the repository
+}
+
+// LicenseFile is the default license file inside .tig
+const LicenseFile = "license.json"
+
+// File returns the license path for a repository root
+func (l License) File(root string) string {
+    if l.Path != "" {
+        return l.Path
+    }
+    return filepath.Join(root, ".tig", LicenseFile)
+}
+
+// Health configures the deep health check
+type Health struct {
+    MinFreeMB int64 `json:"min_free_mb"` // free space below which the safe's disk is degraded, default 1024
+}
+
+// DefaultMinFreeMB is the free disk space the deep health check expects
+const DefaultMinFreeMB = 1024
+
+// MinFree returns the free space threshold in bytes
+func (h Health) MinFree() uint64 {
+    if h.MinFreeMB <= 0 {
+        return DefaultMinFreeMB << 20
+    }
+    return uint64(h.MinFreeMB) << 20
+}
+
+// Scrub configures background integrity verification of stored content
+type Scrub struct {
+    Enabled        bool   `json:"enabled"`
+    ObjectsPerHour int    `json:"objects_per_hour"` // verification budget
+    Interval       string `json:"interval"`         // e.g. 1m; budget is spread across intervals
+}
+
+// Stale configures reminders about intents left in review and streams
+// left unsynced
+type Stale struct {
+    Enabled     bool   `json:"enabled"`
+    Interval    string `json:"interval"`     // time between checks, e.g. 1h
+    IntentAge   string `json:"intent_age"`   // idle time before an intent in review is stale, e.g. 7d
+    StreamAge   string `json:"stream_age"`   // time since a stream synced before it is stale, e.g. 30d
+    RemindEvery string `json:"remind_every"` // time between reminders about the same item, e.g. 1d
+}
+
+// Notifications configures chat webhook delivery of repository events
+type Notifications struct {
+    Webhooks []Webhook `json:"webhooks"`
+}
+
+// Webhook is a single chat destination. Events and Streams act as filters;
+// leaving either empty matches everything.
+type Webhook struct {
+    Name      string            `json:"name"`
+    Kind      string            `json:"kind"`      // slack, teams
+    URL       string            `json:"url"`
+    Channel   string            `json:"channel"`   // Slack channel override
+    Events    []string          `json:"events"`    // e.g. intent.breaking_change, stream.merged
+    Streams   []string          `json:"streams"`   // stream IDs routed to this webhook
+    Templates map[string]string `json:"templates"` // event type -> text/template
+}
+
+// Hook is a server-side hook run when the server receives an intent or is
+// about to merge one. It either runs Command or posts to URL, with the
+// event as JSON; a pre- hook rejects by exiting non-zero or answering with
+// a non-2xx status, and its output is relayed to the client.
+type Hook struct {
+    Name    string   `json:"name"`
+    Event   string   `json:"event"`   // pre-receive-intent, post-receive-intent, pre-merge
+    Command []string `json:"command"` // program and arguments
+    URL     string   `json:"url"`
+    Timeout string   `json:"timeout"` // e.g. 30s, default 10s
+    Streams []string `json:"streams"` // pre-merge only: stream names or IDs, empty for all
 }
 
 func getConfigPath() string {
@@ -30,6 +126,16 @@ func getConfigPath() string {
 }
 
 
+// Default returns the configuration used when no config file is given
+func Default() *Config {
+    var config Config
+    config.Server.Host = "localhost"
+    config.Server.Port = 8080
+    config.Environment = "development"
+    config.LogLevel = "info"
+    return &config
+}
+
 func Load(path string) (*Config, error) {
     file, err := os.Open(path)
     if err != nil {
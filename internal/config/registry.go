@@ -0,0 +1,118 @@
+// internal/config/registry.go
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Registry lists the repositories the user created or cloned, for tig
+// repos list. It is kept next to the user config.
+type Registry struct {
+	Repos []KnownRepo `json:"repos"`
+}
+
+// KnownRepo is a registered repository
+type KnownRepo struct {
+	Path     string    `json:"path"` // absolute path of the working tree
+	AddedAt  time.Time `json:"added_at"`
+	LastUsed time.Time `json:"last_used"` // last init, clone or registration
+}
+
+// RegistryPath returns the location of the repository registry
+func RegistryPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating user config directory: %w", err)
+	}
+	return filepath.Join(dir, "tig", "repos.json"), nil
+}
+
+// LoadRegistry reads the repository registry. A missing file yields an
+// empty registry.
+func LoadRegistry() (*Registry, error) {
+	var r Registry
+
+	path, err := RegistryPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading repository registry: %w", err)
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// SaveRegistry writes the repository registry, replacing the file in one
+// step so concurrent readers never see it half written
+func SaveRegistry(r *Registry) error {
+	path, err := RegistryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating user config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling repository registry: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing repository registry: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing repository registry: %w", err)
+	}
+	return nil
+}
+
+// Add registers the repository at path, or marks it used at now if it is
+// already known. Repositories are kept sorted by path.
+func (r *Registry) Add(path string, now time.Time) {
+	for n := range r.Repos {
+		if r.Repos[n].Path == path {
+			r.Repos[n].LastUsed = now
+			return
+		}
+	}
+	r.Repos = append(r.Repos, KnownRepo{Path: path, AddedAt: now, LastUsed: now})
+	sort.Slice(r.Repos, func(a, b int) bool { return r.Repos[a].Path < r.Repos[b].Path })
+}
+
+// Remove forgets the repository at path, reporting whether it was known
+func (r *Registry) Remove(path string) bool {
+	for n := range r.Repos {
+		if r.Repos[n].Path == path {
+			r.Repos = append(r.Repos[:n], r.Repos[n+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterRepo adds the repository at dir to the user's registry
+func RegisterRepo(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	r, err := LoadRegistry()
+	if err != nil {
+		return err
+	}
+	r.Add(abs, time.Now())
+	return SaveRegistry(r)
+}
@@ -0,0 +1,552 @@
+// internal/config/repo.go
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"tig/internal/glob"
+)
+
+// RepoConfigFile is the per-repository settings file inside .tig
+const RepoConfigFile = "config.json"
+
+// RepoConfig holds settings for a single repository, stored in
+// .tig/config.json
+type RepoConfig struct {
+	Watch       Watch       `json:"watch"`
+	Remote      Remote      `json:"remote,omitempty"`
+	Compression Compression `json:"compression,omitempty"`
+	Tiers       Tiers       `json:"tiers,omitempty"`
+	Cache       Cache       `json:"cache,omitempty"`
+	Diff        Diff        `json:"diff,omitempty"`
+	Gate        Gate        `json:"gate,omitempty"`
+	Copies      Copies      `json:"copies,omitempty"`
+	// Projects are named scopes of a monorepo, e.g. one per service
+	Projects Projects `json:"projects,omitempty"`
+	// Routes pick the default stream of new intents by the paths they touch
+	Routes Routes `json:"routes,omitempty"`
+	// IntentFields are extra metadata fields the repository's intents carry
+	IntentFields IntentFields `json:"intent_fields,omitempty"`
+	// Describe lints intent descriptions and can have a tool suggest them
+	Describe Describe `json:"describe,omitempty"`
+	// Workflow limits how intents move between lifecycle states
+	Workflow Workflow `json:"workflow,omitempty"`
+}
+
+// Workflow maps each intent lifecycle state to the states an intent in it
+// may be moved to, e.g. {"draft": ["in-review"]}. An empty workflow uses
+// the default one.
+type Workflow struct {
+	Transitions map[string][]string `json:"transitions,omitempty"`
+}
+
+// Describe configures the checks run on an intent's description when it
+// is created, and an optional external command or endpoint that suggests
+// a description from the gated diff
+type Describe struct {
+	MaxLength      int      `json:"max_length,omitempty"`      // longest allowed first line; 0 is unlimited
+	Imperative     bool     `json:"imperative,omitempty"`      // first line starts with a verb in the imperative, e.g. "Add", not "Added"
+	TicketPattern  string   `json:"ticket_pattern,omitempty"`  // regular expression a ticket reference must match, e.g. "[A-Z]+-[0-9]+"
+	Action         string   `json:"action,omitempty"`          // warn or refuse, default warn
+	SuggestCommand []string `json:"suggest_command,omitempty"` // program and arguments; reads the draft as JSON on stdin, prints a description
+	SuggestURL     string   `json:"suggest_url,omitempty"`     // receives the draft as a JSON POST, returns {"description": "..."}
+	SuggestTimeout string   `json:"suggest_timeout,omitempty"` // how long a suggestion may take, default 30s
+}
+
+// DefaultSuggestTimeout bounds a description suggestion when no timeout
+// is configured
+const DefaultSuggestTimeout = 30 * time.Second
+
+// Validate checks the description rules
+func (d Describe) Validate() error {
+	if d.MaxLength < 0 {
+		return fmt.Errorf("describe max_length must not be negative")
+	}
+	switch d.Action {
+	case "", GateWarn, GateRefuse:
+	default:
+		return fmt.Errorf("invalid describe action %q: must be warn or refuse", d.Action)
+	}
+	if d.TicketPattern != "" {
+		if _, err := regexp.Compile(d.TicketPattern); err != nil {
+			return fmt.Errorf("invalid describe ticket_pattern: %w", err)
+		}
+	}
+	if len(d.SuggestCommand) > 0 && d.SuggestURL != "" {
+		return fmt.Errorf("describe must set suggest_command or suggest_url, not both")
+	}
+	_, err := d.Timeout()
+	return err
+}
+
+// Timeout returns the parsed suggestion timeout
+func (d Describe) Timeout() (time.Duration, error) {
+	if d.SuggestTimeout == "" {
+		return DefaultSuggestTimeout, nil
+	}
+	t, err := time.ParseDuration(d.SuggestTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid describe suggest_timeout: %w", err)
+	}
+	if t <= 0 {
+		return 0, fmt.Errorf("describe suggest_timeout must be positive")
+	}
+	return t, nil
+}
+
+// IntentField is a typed metadata field added to intents, e.g. a risk
+// level or a rollout plan
+type IntentField struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`             // string, int, bool or enum
+	Values      []string `json:"values,omitempty"` // allowed values of an enum
+	Required    bool     `json:"required,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Intent field types
+const (
+	FieldString = "string"
+	FieldInt    = "int"
+	FieldBool   = "bool"
+	FieldEnum   = "enum"
+)
+
+// IntentFields is the repository's intent metadata schema
+type IntentFields []IntentField
+
+// Validate checks the field definitions
+func (f IntentFields) Validate() error {
+	seen := make(map[string]bool, len(f))
+	for _, field := range f {
+		if !validFieldName(field.Name) {
+			return fmt.Errorf("invalid intent field name %q: use lowercase letters, digits and underscores", field.Name)
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("intent field %q is defined twice", field.Name)
+		}
+		seen[field.Name] = true
+
+		switch field.Type {
+		case FieldString, FieldInt, FieldBool:
+		case FieldEnum:
+			if len(field.Values) == 0 {
+				return fmt.Errorf("intent field %q is an enum without values", field.Name)
+			}
+		default:
+			return fmt.Errorf("intent field %q has invalid type %q: must be string, int, bool or enum", field.Name, field.Type)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the definition of a field
+func (f IntentFields) Lookup(name string) (IntentField, bool) {
+	for _, field := range f {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return IntentField{}, false
+}
+
+func validFieldName(name string) bool {
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// Project is a named set of paths within the repository. Each path is a
+// glob pattern; a plain directory covers everything beneath it.
+type Project struct {
+	Name        string   `json:"name"`
+	Paths       []string `json:"paths"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Contains reports whether a slash-separated path relative to the repo
+// root lies within the project
+func (p Project) Contains(path string) bool {
+	for _, pattern := range p.Paths {
+		if glob.Match(pattern, path) || glob.Match(strings.TrimSuffix(pattern, "/")+"/**", path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Projects is the repository's list of project scopes
+type Projects []Project
+
+// Validate checks the project definitions
+func (ps Projects) Validate() error {
+	seen := make(map[string]bool, len(ps))
+	for _, p := range ps {
+		if p.Name == "" || strings.ContainsAny(p.Name, " \t/") {
+			return fmt.Errorf("invalid project name %q: must be non-empty without spaces or slashes", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("project %q is defined twice", p.Name)
+		}
+		seen[p.Name] = true
+		if len(p.Paths) == 0 {
+			return fmt.Errorf("project %q has no paths", p.Name)
+		}
+		for _, pattern := range p.Paths {
+			if pattern == "" || filepath.IsAbs(pattern) || strings.HasPrefix(pattern, "..") {
+				return fmt.Errorf("project %q has invalid path %q: must be relative to the repository root", p.Name, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup returns the project with the given name
+func (ps Projects) Lookup(name string) (Project, bool) {
+	for _, p := range ps {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Project{}, false
+}
+
+// Matching returns the names of the projects containing any of the paths,
+// in configuration order
+func (ps Projects) Matching(paths []string) []string {
+	var names []string
+	for _, p := range ps {
+		for _, path := range paths {
+			if p.Contains(path) {
+				names = append(names, p.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// Route sends new intents to a default stream when all of their changes
+// lie within its paths or project, e.g. documentation to a docs stream
+type Route struct {
+	Paths   []string `json:"paths,omitempty"`   // glob patterns, as in Project
+	Project string   `json:"project,omitempty"` // name of a configured project
+	Stream  string   `json:"stream"`            // stream name, ID or ID prefix
+}
+
+// Routes are tried in order; the first matching route wins
+type Routes []Route
+
+// Validate checks the routes against the configured projects
+func (rs Routes) Validate(projects Projects) error {
+	for n, r := range rs {
+		if r.Stream == "" {
+			return fmt.Errorf("route %d has no stream", n+1)
+		}
+		if (len(r.Paths) == 0) == (r.Project == "") {
+			return fmt.Errorf("route to stream %q must set either paths or a project", r.Stream)
+		}
+		if r.Project != "" {
+			if _, ok := projects.Lookup(r.Project); !ok {
+				return fmt.Errorf("route to stream %q names unknown project %q", r.Stream, r.Project)
+			}
+		}
+	}
+	return nil
+}
+
+// Match returns the first route covering every one of the paths
+func (rs Routes) Match(projects Projects, paths []string) (Route, bool) {
+	if len(paths) == 0 {
+		return Route{}, false
+	}
+	for _, r := range rs {
+		scope := Project{Paths: r.Paths}
+		if r.Project != "" {
+			scope, _ = projects.Lookup(r.Project)
+		}
+		covered := true
+		for _, path := range paths {
+			if !scope.Contains(path) {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return r, true
+		}
+	}
+	return Route{}, false
+}
+
+// Copies configures detection of new files copied from existing ones when
+// an intent is committed
+type Copies struct {
+	Disable   bool    `json:"disable,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"` // share of lines in common, default 0.5
+}
+
+// Validate checks the copy detection settings
+func (c Copies) Validate() error {
+	if c.Threshold < 0 || c.Threshold > 1 {
+		return fmt.Errorf("copies threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// Gate flags files that usually should not be gated: files above a size
+// threshold and generated code. Each rule warns by default.
+type Gate struct {
+	MaxFileSizeMB    int64    `json:"max_file_size_mb,omitempty"`  // default 10
+	SizeAction       string   `json:"size_action,omitempty"`       // warn, refuse or off
+	GeneratedMarkers []string `json:"generated_markers,omitempty"` // default DefaultGeneratedMarkers
+	GeneratedAction  string   `json:"generated_action,omitempty"`  // warn, refuse or off
+}
+
+// Gate rule actions
+const (
+	GateWarn   = "warn"
+	GateRefuse = "refuse"
+	GateOff    = "off"
+)
+
+// DefaultMaxFileSizeMB is the size above which gating a file is flagged
+const DefaultMaxFileSizeMB = 10
+
+// DefaultGeneratedMarkers identify generated files near their start
+var DefaultGeneratedMarkers = []string{"Code generated by", "DO NOT EDIT", "@generated"}
+
+// Validate checks the gate rules
+func (g Gate) Validate() error {
+	if g.MaxFileSizeMB < 0 {
+		return fmt.Errorf("gate max_file_size_mb must not be negative")
+	}
+	for _, action := range []string{g.SizeAction, g.GeneratedAction} {
+		switch action {
+		case "", GateWarn, GateRefuse, GateOff:
+		default:
+			return fmt.Errorf("invalid gate action %q: must be warn, refuse or off", action)
+		}
+	}
+	return nil
+}
+
+// MaxFileSize returns the size threshold in bytes
+func (g Gate) MaxFileSize() int64 {
+	if g.MaxFileSizeMB == 0 {
+		return DefaultMaxFileSizeMB << 20
+	}
+	return g.MaxFileSizeMB << 20
+}
+
+// Markers returns the generated-file markers in use
+func (g Gate) Markers() []string {
+	if len(g.GeneratedMarkers) == 0 {
+		return DefaultGeneratedMarkers
+	}
+	return g.GeneratedMarkers
+}
+
+// Diff configures how diffs are displayed
+type Diff struct {
+	DisableHighlight bool     `json:"disable_highlight,omitempty"` // no syntax highlighting in tig diff, review pages and the web UI
+	Structural       []string `json:"structural,omitempty"`        // patterns of JSON and YAML files diffed key by key rather than line by line
+	NotebookOutputs  bool     `json:"notebook_outputs,omitempty"`  // show output changes of Jupyter notebooks, not only their cell sources
+	MaxFileSizeMB    int      `json:"max_file_size_mb,omitempty"`  // larger files are reported as differing, not diffed; default 1
+}
+
+// DefaultDiffMaxFileSizeMB is the size above which files are not diffed
+const DefaultDiffMaxFileSizeMB = 1
+
+// Validate checks the diff settings
+func (d Diff) Validate() error {
+	if d.MaxFileSizeMB < 0 {
+		return fmt.Errorf("diff max_file_size_mb must not be negative")
+	}
+	return nil
+}
+
+// MaxFileSize returns the largest file diffed line by line, in bytes
+func (d Diff) MaxFileSize() int {
+	if d.MaxFileSizeMB == 0 {
+		return DefaultDiffMaxFileSizeMB << 20
+	}
+	return d.MaxFileSizeMB << 20
+}
+
+// IsStructural reports whether path matches one of the structural diff
+// patterns
+func (d Diff) IsStructural(path string) bool {
+	for _, pattern := range d.Structural {
+		if glob.MatchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cache sizes the in-memory caches. Zero values keep the defaults.
+type Cache struct {
+	ContentItems   int   `json:"content_items,omitempty"`   // safe LRU entries, default 1000
+	DisableContent bool  `json:"disable_content,omitempty"` // no content cache, e.g. on memory-constrained CI runners
+	BlockCacheMB   int64 `json:"block_cache_mb,omitempty"`  // Badger block cache, default 256
+	IndexCacheMB   int64 `json:"index_cache_mb,omitempty"`  // Badger index cache; by default all indices stay in memory
+}
+
+// DefaultContentCacheItems is the safe LRU size when none is configured
+const DefaultContentCacheItems = 1000
+
+// Validate checks the cache settings
+func (c Cache) Validate() error {
+	if c.ContentItems < 0 || c.BlockCacheMB < 0 || c.IndexCacheMB < 0 {
+		return fmt.Errorf("cache sizes must not be negative")
+	}
+	return nil
+}
+
+// ContentCacheItems returns the safe LRU size, or 0 when it is disabled
+func (c Cache) ContentCacheItems() int {
+	switch {
+	case c.DisableContent:
+		return 0
+	case c.ContentItems == 0:
+		return DefaultContentCacheItems
+	}
+	return c.ContentItems
+}
+
+// Compression configures how new content is stored in the safe. Content
+// already stored keeps the settings it was written with.
+type Compression struct {
+	Level      int    `json:"level,omitempty"`       // zstd level 1-22; 0 stores content uncompressed
+	WindowSize int    `json:"window_size,omitempty"` // encoder window in bytes, a power of two
+	Dictionary uint32 `json:"dictionary,omitempty"`  // ID of the trained dictionary in .tig/dict, see tig admin retrain-dictionary
+}
+
+// Tiers moves stored content between storage tiers by how long ago it was
+// last read: hot content stays uncompressed, warm content is compressed
+// and cold content is moved to a secondary directory. Zero days never
+// move content to that tier; tig admin tiers applies the policy.
+type Tiers struct {
+	WarmAfterDays int    `json:"warm_after_days,omitempty"`
+	ColdAfterDays int    `json:"cold_after_days,omitempty"`
+	ColdDir       string `json:"cold_dir,omitempty"`         // absolute, or relative to the repository root
+	ColdMinSizeKB int64  `json:"cold_min_size_kb,omitempty"` // smaller content stays in the safe
+	Level         int    `json:"level,omitempty"`            // zstd level of warm and cold content; defaults to compression.level, or 3
+}
+
+// Validate checks the tier policy
+func (t Tiers) Validate() error {
+	if t.WarmAfterDays < 0 || t.ColdAfterDays < 0 || t.ColdMinSizeKB < 0 {
+		return fmt.Errorf("tier ages and sizes must not be negative")
+	}
+	if t.ColdAfterDays > 0 && t.ColdDir == "" {
+		return fmt.Errorf("tiers cold_after_days needs a cold_dir")
+	}
+	if t.Level < 0 || t.Level > MaxCompressionLevel {
+		return fmt.Errorf("tiers level must be between 0 and %d", MaxCompressionLevel)
+	}
+	return nil
+}
+
+// Enabled reports whether the policy moves any content
+func (t Tiers) Enabled() bool {
+	return t.WarmAfterDays > 0 || t.ColdAfterDays > 0
+}
+
+// ColdPath returns the cold storage directory of the repository at root,
+// or "" if there is none
+func (t Tiers) ColdPath(root string) string {
+	if t.ColdDir == "" || filepath.IsAbs(t.ColdDir) {
+		return t.ColdDir
+	}
+	return filepath.Join(root, t.ColdDir)
+}
+
+// MaxCompressionLevel is the highest zstd level
+const MaxCompressionLevel = 22
+
+// Validate checks the compression settings
+func (c Compression) Validate() error {
+	if c.Level < 0 || c.Level > MaxCompressionLevel {
+		return fmt.Errorf("compression level must be between 0 and %d", MaxCompressionLevel)
+	}
+	if c.WindowSize < 0 || c.WindowSize&(c.WindowSize-1) != 0 {
+		return fmt.Errorf("compression window_size must be a power of two")
+	}
+	return nil
+}
+
+// Remote is the server a repository was cloned from
+type Remote struct {
+	URL     string `json:"url,omitempty"`
+	Partial bool   `json:"partial,omitempty"` // content is fetched on demand
+}
+
+// Watch configures the filesystem watcher used for automatic tracking
+type Watch struct {
+	Exclude       []string `json:"exclude"`        // glob patterns relative to the repo root, e.g. "target/**" or "*.log"
+	FlushInterval string   `json:"flush_interval"` // how long events are batched before being saved, e.g. 500ms
+}
+
+// DefaultFlushInterval is used when no flush interval is configured
+const DefaultFlushInterval = 500 * time.Millisecond
+
+// Interval returns the parsed flush interval
+func (w Watch) Interval() (time.Duration, error) {
+	if w.FlushInterval == "" {
+		return DefaultFlushInterval, nil
+	}
+	d, err := time.ParseDuration(w.FlushInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid watch flush_interval: %w", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("watch flush_interval must be positive")
+	}
+	return d, nil
+}
+
+// RepoConfigPath returns the location of the config file for a repo root
+func RepoConfigPath(root string) string {
+	return filepath.Join(root, ".tig", RepoConfigFile)
+}
+
+// LoadRepo reads the repository config. A missing file yields defaults.
+func LoadRepo(root string) (*RepoConfig, error) {
+	var cfg RepoConfig
+
+	data, err := os.ReadFile(RepoConfigPath(root))
+	if os.IsNotExist(err) {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading repo config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", RepoConfigPath(root), err)
+	}
+	return &cfg, nil
+}
+
+// SaveRepo writes the repository config
+func SaveRepo(root string, cfg *RepoConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling repo config: %w", err)
+	}
+	return os.WriteFile(RepoConfigPath(root), append(data, '\n'), 0644)
+}
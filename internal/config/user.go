@@ -0,0 +1,134 @@
+// internal/config/user.go
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// UserConfig holds per-user settings that apply to every repository,
+// stored in the user's configuration directory
+type UserConfig struct {
+	// Name recorded as the author of new changesets and intents, unless
+	// TIG_AUTHOR is set
+	Author string `json:"author,omitempty"`
+
+	// Replace color-only signals with textual markers, for colorblind
+	// users and monochrome terminals
+	Accessible bool `json:"accessible,omitempty"`
+
+	// Opt-in usage statistics, see tig stats usage
+	Telemetry Telemetry `json:"telemetry"`
+
+	// Don't check for new releases in the background
+	DisableUpdateCheck bool `json:"disable_update_check,omitempty"`
+}
+
+// Telemetry configures local usage statistics. Nothing is recorded unless
+// Enabled is set, and nothing leaves the machine unless UploadURL is set
+// and an upload is requested.
+type Telemetry struct {
+	Enabled bool `json:"enabled"`
+	// Endpoint that tig stats usage --upload posts the aggregated
+	// statistics to, for teams that collect them centrally
+	UploadURL string `json:"upload_url,omitempty"`
+}
+
+// TelemetryEnv turns usage statistics on or off, overriding the user
+// config, e.g. TIG_TELEMETRY=0 on shared CI machines
+const TelemetryEnv = "TIG_TELEMETRY"
+
+// UpdateCheckEnv turns the background check for new releases on or off,
+// overriding the user config
+const UpdateCheckEnv = "TIG_UPDATE_CHECK"
+
+// AccessibleEnv turns accessible output on or off, overriding the user
+// config
+const AccessibleEnv = "TIG_ACCESSIBLE"
+
+// UserConfigPath returns the location of the user config file, e.g.
+// ~/.config/tig/config.json on Linux
+func UserConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating user config directory: %w", err)
+	}
+	return filepath.Join(dir, "tig", "config.json"), nil
+}
+
+// LoadUser reads the user config. A missing file yields defaults.
+func LoadUser() (*UserConfig, error) {
+	var cfg UserConfig
+
+	path, err := UserConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading user config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// SaveUser writes the user config, creating its directory if needed
+func SaveUser(cfg *UserConfig) error {
+	path, err := UserConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating user config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling user config: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing user config: %w", err)
+	}
+	return nil
+}
+
+// Accessible reports whether output should avoid relying on color: the
+// TIG_ACCESSIBLE environment variable if set, otherwise the user config
+func Accessible() bool {
+	if v := os.Getenv(AccessibleEnv); v != "" {
+		on, err := strconv.ParseBool(v)
+		return err == nil && on
+	}
+	cfg, err := LoadUser()
+	return err == nil && cfg.Accessible
+}
+
+// TelemetryEnabled reports whether usage statistics are recorded: the
+// TIG_TELEMETRY environment variable if set, otherwise the user config
+func TelemetryEnabled() bool {
+	if v := os.Getenv(TelemetryEnv); v != "" {
+		on, err := strconv.ParseBool(v)
+		return err == nil && on
+	}
+	cfg, err := LoadUser()
+	return err == nil && cfg.Telemetry.Enabled
+}
+
+// UpdateCheckEnabled reports whether commands check for new releases in
+// the background: the TIG_UPDATE_CHECK environment variable if set,
+// otherwise the user config
+func UpdateCheckEnabled() bool {
+	if v := os.Getenv(UpdateCheckEnv); v != "" {
+		on, err := strconv.ParseBool(v)
+		return err == nil && on
+	}
+	cfg, err := LoadUser()
+	return err == nil && !cfg.DisableUpdateCheck
+}
@@ -0,0 +1,160 @@
+// internal/conflict/conflict.go
+package conflict
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Ref identifies an intent touching a path
+type Ref struct {
+	IntentID    string `json:"intent_id"`
+	Description string `json:"description"`
+	StreamID    string `json:"stream_id"`
+	StreamName  string `json:"stream_name"`
+	Hash        string `json:"hash"` // Content the intent leaves at the path
+}
+
+// Conflict is a path modified differently by intents on more than one
+// stream, or by gated changes and an intent on any stream. Changes that
+// leave identical content behind are not conflicts.
+type Conflict struct {
+	Path    string `json:"path"`
+	Intents []Ref  `json:"intents"`
+	Gated   bool   `json:"gated"` // Also modified by gated working tree changes
+}
+
+// Detector finds paths that open intents on different streams both modify
+type Detector struct {
+	db      *badger.DB
+	streams stream.Box
+}
+
+// New creates a detector reading changesets and gated changes from db
+func New(db *badger.DB, streams stream.Box) *Detector {
+	return &Detector{db: db, streams: streams}
+}
+
+// Detect returns potential conflicts ordered by path. Only intents on
+// active streams are considered.
+func (d *Detector) Detect() ([]Conflict, error) {
+	streams, err := d.streams.FindActive()
+	if err != nil {
+		return nil, fmt.Errorf("listing streams: %w", err)
+	}
+
+	touched := make(map[string][]Ref)
+	for _, s := range streams {
+		intents, err := d.streams.GetIntents(s.ID)
+		if err != nil {
+			return nil, fmt.Errorf("listing intents of stream %s: %w", s.Name, err)
+		}
+		for _, i := range intents {
+			if i.ChangeSetID == "" {
+				continue
+			}
+			paths, err := d.changeSetPaths(i.ChangeSetID)
+			if err != nil {
+				return nil, err
+			}
+			for path, hash := range paths {
+				touched[path] = append(touched[path], Ref{
+					IntentID:    i.ID,
+					Description: i.Description,
+					StreamID:    s.ID,
+					StreamName:  s.Name,
+					Hash:        hash,
+				})
+			}
+		}
+	}
+
+	gated, err := d.gatedPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []Conflict
+	for path, refs := range touched {
+		streamIDs := make(map[string]bool)
+		hashes := make(map[string]bool)
+		for _, r := range refs {
+			streamIDs[r.StreamID] = true
+			hashes[r.Hash] = true
+		}
+		gatedHash, isGated := gated[path]
+		gatedDiffers := isGated && !hashes[gatedHash]
+		if !gatedDiffers && (len(streamIDs) < 2 || len(hashes) < 2) {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{Path: path, Intents: refs, Gated: gatedDiffers})
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Path < conflicts[j].Path })
+	return conflicts, nil
+}
+
+// change is the part of a stored change the detector compares
+type change struct {
+	Path    string `json:"path"`
+	NewHash string `json:"new_hash"`
+}
+
+// changeSetPaths maps each path changed by a changeset to its new hash
+func (d *Detector) changeSetPaths(id string) (map[string]string, error) {
+	var cs struct {
+		Changes []change `json:"changes"`
+	}
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte("changeset:" + id))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &cs)
+		})
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading changeset %s: %w", id, err)
+	}
+
+	paths := make(map[string]string, len(cs.Changes))
+	for _, c := range cs.Changes {
+		paths[c.Path] = c.NewHash
+	}
+	return paths, nil
+}
+
+// gatedPaths maps paths with gated working tree changes to their new hash
+func (d *Detector) gatedPaths() (map[string]string, error) {
+	paths := make(map[string]string)
+	err := d.db.View(func(txn *badger.Txn) error {
+		prefix := []byte("gated:")
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix, PrefetchValues: true, PrefetchSize: 100})
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var c change
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &c)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			paths[string(it.Item().Key()[len(prefix):])] = c.NewHash
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading gated changes: %w", err)
+	}
+	return paths, nil
+}
@@ -0,0 +1,97 @@
+// internal/conflict/conflict_test.go
+package conflict
+
+import (
+	"encoding/json"
+	"testing"
+
+	"tig/internal/intent"
+	intentStorage "tig/internal/intent/storage"
+	"tig/internal/stream"
+	streamStorage "tig/internal/stream/storage"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	t       *testing.T
+	db      *badger.DB
+	streams *streamStorage.Store
+	intents *intentStorage.Store
+}
+
+func setup(t *testing.T) *fixture {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	intents := intentStorage.NewStore(db, nil)
+	return &fixture{t: t, db: db, streams: streamStorage.NewStore(db, intents), intents: intents}
+}
+
+func (f *fixture) stream(id string) {
+	require.NoError(f.t, f.streams.Create(&stream.Stream{ID: id, Name: id, Type: "feature"}))
+}
+
+// intent stores a changeset setting each path to the given hash and adds
+// an intent for it to the stream
+func (f *fixture) intent(id, streamID string, changes map[string]string) {
+	var cs struct {
+		Changes []change `json:"changes"`
+	}
+	for path, hash := range changes {
+		cs.Changes = append(cs.Changes, change{Path: path, NewHash: hash})
+	}
+	data, _ := json.Marshal(cs)
+	require.NoError(f.t, f.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("changeset:cs-"+id), data)
+	}))
+
+	require.NoError(f.t, f.intents.Create(&intent.Intent{ID: id, Type: "feature", Description: id, ChangeSetID: "cs-" + id}))
+	require.NoError(f.t, f.streams.AddIntent(streamID, id))
+}
+
+func (f *fixture) gate(path, hash string) {
+	data, _ := json.Marshal(change{Path: path, NewHash: hash})
+	require.NoError(f.t, f.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("gated:"+path), data)
+	}))
+}
+
+func TestDetect(t *testing.T) {
+	f := setup(t)
+	f.stream("s1")
+	f.stream("s2")
+	f.intent("i1", "s1", map[string]string{"main.go": "aaa", "a.go": "aaa"})
+	f.intent("i2", "s2", map[string]string{"main.go": "bbb", "b.go": "bbb"})
+	f.intent("i3", "s2", map[string]string{"a.go": "aaa"}) // same result as i1
+	f.intent("i4", "s1", map[string]string{"b.go": "ccc"})
+
+	conflicts, err := New(f.db, f.streams).Detect()
+	require.NoError(t, err)
+	require.Len(t, conflicts, 2)
+	assert.Equal(t, "b.go", conflicts[0].Path)
+	assert.Equal(t, "main.go", conflicts[1].Path)
+	assert.Len(t, conflicts[1].Intents, 2)
+	assert.False(t, conflicts[1].Gated)
+}
+
+func TestDetectGated(t *testing.T) {
+	f := setup(t)
+	f.stream("s1")
+	f.intent("i1", "s1", map[string]string{"main.go": "aaa", "a.go": "aaa"})
+	f.gate("main.go", "bbb")
+	f.gate("a.go", "aaa") // already part of i1
+	f.gate("new.go", "ccc")
+
+	conflicts, err := New(f.db, f.streams).Detect()
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "main.go", conflicts[0].Path)
+	assert.True(t, conflicts[0].Gated)
+	assert.Equal(t, "s1", conflicts[0].Intents[0].StreamName)
+}
@@ -0,0 +1,315 @@
+// internal/coverage/coverage.go
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/intent"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Report formats
+const (
+	FormatGo   = "go"   // go test -coverprofile
+	FormatLCOV = "lcov" // lcov tracefiles, written by most other tools
+)
+
+// latestPrefix keys the last coverage recorded for each file
+const latestPrefix = "coverage:"
+
+// Profile is the coverage of each file in a report, by slash path
+type Profile map[string]intent.CoverageCounts
+
+// Parse reads a coverage report. With no format given, it is detected
+// from the report's first line.
+func Parse(r io.Reader, format string) (Profile, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading coverage report: %w", err)
+	}
+	if format == "" {
+		format = detect(data)
+	}
+	var p Profile
+	switch format {
+	case FormatGo:
+		p, err = parseGo(data)
+	case FormatLCOV:
+		p, err = parseLCOV(data)
+	default:
+		return nil, "", fmt.Errorf("unknown coverage format %q; use go or lcov", format)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("parsing %s coverage report: %w", format, err)
+	}
+	if len(p) == 0 {
+		return nil, "", fmt.Errorf("coverage report lists no files")
+	}
+	return p, format, nil
+}
+
+func detect(data []byte) string {
+	line, _, _ := bytes.Cut(bytes.TrimSpace(data), []byte("\n"))
+	if bytes.HasPrefix(line, []byte("mode:")) {
+		return FormatGo
+	}
+	return FormatLCOV
+}
+
+// parseGo reads a Go coverprofile. Blocks listed more than once, as in
+// merged profiles, count once, covered if any run covered them.
+func parseGo(data []byte) (Profile, error) {
+	type block struct {
+		stmts   int
+		covered bool
+	}
+	blocks := make(map[string]map[string]*block)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// name.go:line.column,line.column statements count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("line %d: malformed block %q", n, line)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: malformed block %q", n, line)
+		}
+		stmts, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("line %d: malformed block %q", n, line)
+		}
+		file := line[:colon]
+		if blocks[file] == nil {
+			blocks[file] = make(map[string]*block)
+		}
+		b := blocks[file][fields[0]]
+		if b == nil {
+			b = &block{stmts: stmts}
+			blocks[file][fields[0]] = b
+		}
+		b.covered = b.covered || count > 0
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	p := make(Profile, len(blocks))
+	for file, bs := range blocks {
+		var c intent.CoverageCounts
+		for _, b := range bs {
+			c.Total += b.stmts
+			if b.covered {
+				c.Covered += b.stmts
+			}
+		}
+		p[file] = c
+	}
+	return p, nil
+}
+
+// parseLCOV reads an lcov tracefile, counting the lines of each source
+// file (SF) found by DA records
+func parseLCOV(data []byte) (Profile, error) {
+	lines := make(map[string]map[int]bool)
+	var file string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file = strings.TrimPrefix(line, "SF:")
+			if lines[file] == nil {
+				lines[file] = make(map[int]bool)
+			}
+		case strings.HasPrefix(line, "DA:"):
+			if file == "" {
+				return nil, fmt.Errorf("line %d: DA record outside a source file", n)
+			}
+			fields := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: malformed DA record %q", n, line)
+			}
+			num, err1 := strconv.Atoi(fields[0])
+			count, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("line %d: malformed DA record %q", n, line)
+			}
+			lines[file][num] = lines[file][num] || count > 0
+		case line == "end_of_record":
+			file = ""
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	p := make(Profile, len(lines))
+	for file, ls := range lines {
+		var c intent.CoverageCounts
+		for _, covered := range ls {
+			c.Total++
+			if covered {
+				c.Covered++
+			}
+		}
+		p[file] = c
+	}
+	return p, nil
+}
+
+// Resolve maps the paths of a report onto the repository's files. Reports
+// name files by absolute path or, for Go, by import path; a report path
+// resolves to the known path it ends with, at a segment boundary, or is
+// dropped.
+func (p Profile) Resolve(known []string) Profile {
+	bySuffix := make(map[string][]string)
+	for _, k := range known {
+		k = filepath.ToSlash(k)
+		bySuffix[path.Base(k)] = append(bySuffix[path.Base(k)], k)
+	}
+
+	out := make(Profile, len(p))
+	for name, c := range p {
+		name = filepath.ToSlash(name)
+		var match string
+		for _, k := range bySuffix[path.Base(name)] {
+			if (name == k || strings.HasSuffix(name, "/"+k)) && len(k) > len(match) {
+				match = k
+			}
+		}
+		if match != "" {
+			out[match] = c
+		}
+	}
+	return out
+}
+
+// latest is the last coverage recorded for a file, with what was
+// recorded before the intent it was recorded for, so recording an intent
+// again compares against the same coverage
+type latest struct {
+	IntentID string `json:"intent_id"`
+	intent.CoverageCounts
+	Before     *intent.CoverageCounts `json:"before,omitempty"`
+	RecordedAt time.Time              `json:"recorded_at"`
+}
+
+// Record works out the coverage of the files an intent's changeset
+// changed from a report, against the coverage last recorded for each of
+// them, and makes the report's coverage the latest for all its files. The
+// intent itself is left for the caller to update.
+func Record(db *badger.DB, i *intent.Intent, p Profile, format string, now time.Time) (*intent.Coverage, error) {
+	if i.ChangeSetID == "" {
+		return nil, fmt.Errorf("intent %s has no changeset to measure coverage of", i.ID)
+	}
+	cov := &intent.Coverage{Format: format, Files: []intent.FileCoverage{}, RecordedAt: now}
+	err := db.Update(func(txn *badger.Txn) error {
+		cs, err := change.GetChangeSet(txn, i.ChangeSetID)
+		if err != nil {
+			return err
+		}
+		tree, err := change.TreeAt(txn, cs.ID)
+		if err != nil {
+			return err
+		}
+		known := make([]string, 0, len(tree))
+		for path := range tree {
+			known = append(known, path)
+		}
+		p = p.Resolve(known)
+
+		var previous intent.CoverageCounts
+		var baseline bool
+		for _, c := range cs.Changes {
+			counts, ok := p[filepath.ToSlash(c.Path)]
+			if c.Type == "delete" || !ok {
+				continue
+			}
+			f := intent.FileCoverage{Path: filepath.ToSlash(c.Path), CoverageCounts: counts}
+			if f.Previous, err = before(txn, i.ID, f.Path); err != nil {
+				return err
+			}
+			cov.Covered += counts.Covered
+			cov.Total += counts.Total
+			if f.Previous != nil {
+				baseline = true
+				previous.Covered += f.Previous.Covered
+				previous.Total += f.Previous.Total
+			}
+			cov.Files = append(cov.Files, f)
+		}
+		sort.Slice(cov.Files, func(a, b int) bool { return cov.Files[a].Path < cov.Files[b].Path })
+
+		if baseline {
+			var current intent.CoverageCounts
+			for _, f := range cov.Files {
+				if f.Previous != nil {
+					current.Covered += f.Covered
+					current.Total += f.Total
+				}
+			}
+			cov.Previous = &previous
+			cov.Delta = current.Percent() - previous.Percent()
+		}
+
+		for path, counts := range p {
+			prior, err := before(txn, i.ID, path)
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(latest{IntentID: i.ID, CoverageCounts: counts, Before: prior, RecordedAt: now})
+			if err != nil {
+				return err
+			}
+			if err := txn.Set([]byte(latestPrefix+path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("recording coverage: %w", err)
+	}
+	return cov, nil
+}
+
+// before returns the coverage of a file recorded before the intent, or
+// nil if there is none
+func before(txn *badger.Txn, intentID, path string) (*intent.CoverageCounts, error) {
+	item, err := txn.Get([]byte(latestPrefix + path))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var last latest
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &last)
+	}); err != nil {
+		return nil, fmt.Errorf("reading coverage of %s: %w", path, err)
+	}
+	if last.IntentID == intentID {
+		return last.Before, nil
+	}
+	return &last.CoverageCounts, nil
+}
@@ -0,0 +1,107 @@
+// internal/coverage/coverage_test.go
+package coverage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/intent"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGo(t *testing.T) {
+	report := `mode: set
+tig/internal/diff/diff.go:10.2,12.3 2 1
+tig/internal/diff/diff.go:14.2,16.3 3 0
+tig/internal/diff/diff.go:14.2,16.3 3 1
+tig/internal/safe/safe.go:5.1,6.2 4 0
+`
+	p, format, err := Parse(strings.NewReader(report), "")
+	require.NoError(t, err)
+	assert.Equal(t, FormatGo, format)
+	assert.Equal(t, Profile{
+		"tig/internal/diff/diff.go": {Covered: 5, Total: 5},
+		"tig/internal/safe/safe.go": {Covered: 0, Total: 4},
+	}, p)
+
+	_, _, err = Parse(strings.NewReader("mode: set\nnot a block\n"), "")
+	assert.Error(t, err)
+}
+
+func TestParseLCOV(t *testing.T) {
+	report := `TN:
+SF:/home/ci/app/src/index.js
+DA:1,1
+DA:2,0
+DA:3,5
+LF:3
+LH:2
+end_of_record
+SF:/home/ci/app/src/util.js
+DA:1,0
+end_of_record
+`
+	p, format, err := Parse(strings.NewReader(report), "")
+	require.NoError(t, err)
+	assert.Equal(t, FormatLCOV, format)
+	assert.Equal(t, intent.CoverageCounts{Covered: 2, Total: 3}, p["/home/ci/app/src/index.js"])
+
+	resolved := p.Resolve([]string{"src/index.js", "index.js", "lib/util.js"})
+	assert.Equal(t, Profile{"src/index.js": {Covered: 2, Total: 3}}, resolved)
+
+	_, _, err = Parse(strings.NewReader(report), "cobertura")
+	assert.Error(t, err)
+}
+
+func TestRecord(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	commit := func(id string, at time.Time, paths ...string) *intent.Intent {
+		cs := &change.ChangeSet{ID: "cs-" + id, IntentID: id, CreatedAt: at}
+		for _, p := range paths {
+			cs.Changes = append(cs.Changes, shared.Change{Path: p, Type: "modify", NewHash: "h"})
+		}
+		require.NoError(t, db.Update(func(txn *badger.Txn) error { return change.PutChangeSet(txn, cs) }))
+		return &intent.Intent{ID: id, ChangeSetID: cs.ID}
+	}
+	profile := func(lines ...string) Profile {
+		p, _, err := Parse(strings.NewReader("mode: set\n"+strings.Join(lines, "\n")), "")
+		require.NoError(t, err)
+		return p
+	}
+
+	first := commit("one", now.Add(-time.Hour), "a.go", "b.go")
+	cov, err := Record(db, first, profile("tig/a.go:1.1,2.2 8 1", "tig/a.go:3.1,4.2 2 0", "tig/b.go:1.1,2.2 5 1"), FormatGo, now)
+	require.NoError(t, err)
+	assert.Equal(t, intent.CoverageCounts{Covered: 13, Total: 15}, cov.CoverageCounts)
+	assert.Nil(t, cov.Previous, "nothing recorded before")
+	assert.Len(t, cov.Files, 2)
+
+	// The second intent changes a.go and loses coverage of it
+	second := commit("two", now, "a.go")
+	report := profile("tig/a.go:1.1,2.2 8 0", "tig/a.go:3.1,4.2 2 1", "tig/b.go:1.1,2.2 5 1")
+	cov, err = Record(db, second, report, FormatGo, now)
+	require.NoError(t, err)
+	require.Len(t, cov.Files, 1)
+	assert.Equal(t, &intent.CoverageCounts{Covered: 8, Total: 10}, cov.Files[0].Previous)
+	assert.InDelta(t, -60.0, cov.Delta, 0.001)
+
+	// Recording it again compares with the same coverage as before
+	cov, err = Record(db, second, report, FormatGo, now)
+	require.NoError(t, err)
+	assert.InDelta(t, -60.0, cov.Delta, 0.001)
+
+	_, err = Record(db, &intent.Intent{ID: "draft"}, report, FormatGo, now)
+	assert.Error(t, err)
+}
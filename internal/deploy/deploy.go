@@ -0,0 +1,192 @@
+// internal/deploy/deploy.go
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"tig/internal/errors"
+	"tig/internal/intent"
+	"tig/internal/release"
+	"tig/internal/safe"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// keyPrefix keys deployments, "deploy:<environment>:<nanoseconds>", so
+// each environment's deployments sort oldest first
+const keyPrefix = "deploy:"
+
+var validEnvironment = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Deployment records what was running in an environment from a point in
+// time: a changeset and, when a release was deployed, its manifest
+type Deployment struct {
+	Environment string    `json:"environment"`
+	Release     string    `json:"release,omitempty"`  // Tag of the deployed release
+	Manifest    string    `json:"manifest,omitempty"` // Safe hash of its manifest
+	StreamID    string    `json:"stream_id"`
+	Stream      string    `json:"stream"`
+	ChangeSetID string    `json:"changeset_id"`
+	Intents     []string  `json:"intents"`               // Landed on the stream, in order
+	URL         string    `json:"url,omitempty"`         // Link to the deploy job
+	RollbackOf  string    `json:"rollback_of,omitempty"` // Source of the deployment this one rolled back
+	RolledBack  bool      `json:"rolled_back,omitempty"` // A later rollback replaced it
+	DeployedAt  time.Time `json:"deployed_at"`
+}
+
+// FromRelease describes deploying a release tag to an environment
+func FromRelease(db *badger.DB, s *safe.Safe, env, tag string) (*Deployment, error) {
+	t, err := release.GetTag(db, tag)
+	if err != nil {
+		return nil, err
+	}
+	m, err := release.Load(s, t.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	return &Deployment{
+		Environment: env,
+		Release:     m.Tag,
+		Manifest:    t.Manifest,
+		StreamID:    m.StreamID,
+		Stream:      m.Stream,
+		ChangeSetID: m.ChangeSetID,
+		Intents:     m.Intents,
+	}, nil
+}
+
+// FromStream describes deploying a stream, as of its head changeset, to
+// an environment
+func FromStream(env string, st *stream.Stream, head string) (*Deployment, error) {
+	if head == "" {
+		return nil, errors.ValidationError(fmt.Sprintf("stream %s has no changesets to deploy", st.Name), nil)
+	}
+	return &Deployment{
+		Environment: env,
+		StreamID:    st.ID,
+		Stream:      st.Name,
+		ChangeSetID: head,
+		Intents:     st.State.Merged,
+	}, nil
+}
+
+// Source names what was deployed: the release tag, or the stream and
+// changeset
+func (d *Deployment) Source() string {
+	if d.Release != "" {
+		return d.Release
+	}
+	id := d.ChangeSetID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return d.Stream + "@" + id
+}
+
+// Record stores a deployment and marks each of its intents as deployed
+// to the environment, unless it reached it earlier. It returns the
+// intents deployed there for the first time. Intents that no longer
+// exist are skipped.
+func Record(db *badger.DB, intents intent.Box, d *Deployment) ([]string, error) {
+	if !validEnvironment.MatchString(d.Environment) {
+		return nil, errors.ValidationError(fmt.Sprintf("invalid environment name %q", d.Environment), nil)
+	}
+	if d.DeployedAt.IsZero() {
+		d.DeployedAt = time.Now().UTC()
+	}
+	if d.Intents == nil {
+		d.Intents = []string{}
+	}
+
+	if err := put(db, d); err != nil {
+		return nil, fmt.Errorf("recording deployment: %w", err)
+	}
+
+	var first []string
+	for _, id := range d.Intents {
+		i, err := intents.Get(id)
+		if err != nil {
+			continue
+		}
+		if _, ok := i.Deployed[d.Environment]; ok {
+			continue
+		}
+		if i.Deployed == nil {
+			i.Deployed = make(map[string]time.Time)
+		}
+		i.Deployed[d.Environment] = d.DeployedAt
+		if err := intents.Update(i); err != nil {
+			return first, fmt.Errorf("marking intent %s deployed: %w", id, err)
+		}
+		first = append(first, id)
+	}
+	return first, nil
+}
+
+// put stores a deployment under its environment and time, replacing an
+// earlier version of it
+func put(db *badger.DB, d *Deployment) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s%s:%020d", keyPrefix, d.Environment, d.DeployedAt.UnixNano())
+	return db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(key), data)
+	})
+}
+
+// List returns the deployments to an environment, or to every environment
+// when env is empty, newest first
+func List(db *badger.DB, env string) ([]Deployment, error) {
+	prefix := keyPrefix
+	if env != "" {
+		prefix += env + ":"
+	}
+	var out []Deployment
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var d Deployment
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &d)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			out = append(out, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+	sort.SliceStable(out, func(x, y int) bool { return out[x].DeployedAt.After(out[y].DeployedAt) })
+	return out, nil
+}
+
+// Current returns the latest deployment to each environment, by
+// environment name
+func Current(db *badger.DB) ([]Deployment, error) {
+	all, err := List(db, "")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var out []Deployment
+	for _, d := range all {
+		if !seen[d.Environment] {
+			seen[d.Environment] = true
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(x, y int) bool { return out[x].Environment < out[y].Environment })
+	return out, nil
+}
@@ -0,0 +1,151 @@
+// internal/deploy/deploy_test.go
+package deploy
+
+import (
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/errors"
+	"tig/internal/intent"
+	"tig/internal/release"
+	"tig/internal/safe"
+	"tig/internal/stream"
+	tigtest "tig/testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	intents := tigtest.NewIntentBox()
+	for _, id := range []string{"i1", "i2"} {
+		require.NoError(t, intents.Create(&intent.Intent{ID: id, Type: "feature", Description: "change " + id}))
+	}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return change.PutChangeSet(txn, &change.ChangeSet{ID: "cs1", CreatedAt: time.Now()})
+	}))
+
+	st := &stream.Stream{ID: "s1", Name: "main", State: stream.State{Merged: []string{"i1"}, Head: "cs1"}}
+	_, err = release.Create(db, s, "v1.0", st, "cs1")
+	require.NoError(t, err)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	d, err := FromRelease(db, s, "staging", "v1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0", d.Source())
+	d.DeployedAt = start
+	first, err := Record(db, intents, d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i1"}, first)
+
+	// A later deployment only marks intents new to the environment; the
+	// deleted i3 is skipped
+	st.State.Merged = []string{"i1", "i2", "i3"}
+	d, err = FromStream("staging", st, "cs1")
+	require.NoError(t, err)
+	d.DeployedAt = start.Add(time.Hour)
+	first, err = Record(db, intents, d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i2"}, first)
+
+	d, err = FromStream("prod", st, "cs1")
+	require.NoError(t, err)
+	d.DeployedAt = start.Add(2 * time.Hour)
+	_, err = Record(db, intents, d)
+	require.NoError(t, err)
+
+	i1, err := intents.Get("i1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Time{"staging": start, "prod": start.Add(2 * time.Hour)}, i1.Deployed)
+
+	staging, err := List(db, "staging")
+	require.NoError(t, err)
+	require.Len(t, staging, 2)
+	assert.Equal(t, "main@cs1", staging[0].Source())
+	assert.Equal(t, "v1.0", staging[1].Release)
+
+	current, err := Current(db)
+	require.NoError(t, err)
+	require.Len(t, current, 2)
+	assert.Equal(t, "prod", current[0].Environment)
+	assert.Equal(t, "staging", current[1].Environment)
+	assert.Equal(t, start.Add(time.Hour), current[1].DeployedAt)
+
+	_, err = Record(db, intents, &Deployment{Environment: "bad env", ChangeSetID: "cs1"})
+	var apiErr *errors.Error
+	assert.ErrorAs(t, err, &apiErr)
+	_, err = FromStream("prod", &stream.Stream{Name: "empty"}, "")
+	assert.Error(t, err)
+}
+
+func TestRollback(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	intents := tigtest.NewIntentBox()
+	for _, id := range []string{"i1", "i2", "i3"} {
+		require.NoError(t, intents.Create(&intent.Intent{ID: id, Type: "feature", Description: "change " + id}))
+	}
+
+	_, err = PlanRollback(db, "prod", nil)
+	assert.Error(t, err)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	deployments := []struct {
+		changeset string
+		merged    []string
+	}{
+		{"cs1", []string{"i1"}},
+		{"cs2", []string{"i1", "i2"}},
+		{"cs3", []string{"i1", "i2", "i3"}},
+	}
+	for n, c := range deployments {
+		st := &stream.Stream{ID: "s1", Name: "main", State: stream.State{Merged: c.merged}}
+		d, err := FromStream("prod", st, c.changeset)
+		require.NoError(t, err)
+		d.DeployedAt = start.Add(time.Duration(n) * time.Hour)
+		_, err = Record(db, intents, d)
+		require.NoError(t, err)
+	}
+
+	plan, err := PlanRollback(db, "prod", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "cs3", plan.From.ChangeSetID)
+	assert.Equal(t, "cs2", plan.To.ChangeSetID)
+	assert.Equal(t, []string{"i3"}, plan.Intents)
+
+	d, err := ApplyRollback(db, intents, plan, "https://ci.example.com/9", start.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "main@cs3", d.RollbackOf)
+	i3, err := intents.Get("i3")
+	require.NoError(t, err)
+	assert.Empty(t, i3.Deployed)
+
+	// The rolled back deployment is skipped as a target, so a second
+	// rollback goes to cs1
+	list, err := List(db, "prod")
+	require.NoError(t, err)
+	require.Len(t, list, 4)
+	assert.True(t, list[1].RolledBack)
+	plan, err = PlanRollback(db, "prod", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "cs1", plan.To.ChangeSetID)
+	assert.Equal(t, []string{"i2"}, plan.Intents)
+
+	_, err = PlanRollback(db, "prod", &Deployment{ChangeSetID: "cs2"})
+	assert.Error(t, err, "prod already runs cs2")
+}
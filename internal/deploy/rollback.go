@@ -0,0 +1,101 @@
+// internal/deploy/rollback.go
+package deploy
+
+import (
+	"fmt"
+	"time"
+
+	"tig/internal/errors"
+	"tig/internal/intent"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Rollback is the plan for returning an environment to an earlier
+// deployment. It is what a deploy pipeline needs to act: the deployment
+// to replace, the one to restore, and the intents that leave the
+// environment.
+type Rollback struct {
+	Environment string     `json:"environment"`
+	From        Deployment `json:"from"`    // Running now
+	To          Deployment `json:"to"`      // Restored
+	Intents     []string   `json:"intents"` // Deployed since To, in landing order
+	Reverted    []string   `json:"reverted,omitempty"`
+}
+
+// PlanRollback works out how to roll an environment back to target or,
+// when target is nil, to the last good deployment: the latest one before
+// the current that was not itself rolled back and ran another changeset
+func PlanRollback(db *badger.DB, env string, target *Deployment) (*Rollback, error) {
+	list, err := List(db, env)
+	if err != nil {
+		return nil, err
+	}
+	if env == "" || len(list) == 0 {
+		return nil, errors.NotFound(fmt.Sprintf("nothing has been deployed to %q", env))
+	}
+	current := list[0]
+
+	if target == nil {
+		for n := range list[1:] {
+			d := list[1+n]
+			if !d.RolledBack && d.ChangeSetID != current.ChangeSetID {
+				target = &d
+				break
+			}
+		}
+		if target == nil {
+			return nil, errors.ValidationError(fmt.Sprintf("no earlier good deployment to %s to roll back to", env), nil)
+		}
+	}
+	if target.ChangeSetID == current.ChangeSetID {
+		return nil, errors.ValidationError(fmt.Sprintf("%s already runs %s", env, target.Source()), nil)
+	}
+
+	r := &Rollback{Environment: env, From: current, To: *target, Intents: []string{}}
+	kept := make(map[string]bool, len(target.Intents))
+	for _, id := range target.Intents {
+		kept[id] = true
+	}
+	for _, id := range current.Intents {
+		if !kept[id] {
+			r.Intents = append(r.Intents, id)
+		}
+	}
+	return r, nil
+}
+
+// ApplyRollback records the plan's target as deployed again, marks the
+// deployment it replaces as rolled back, and clears the environment from
+// the intents leaving it, so deploying them later records a new date
+func ApplyRollback(db *badger.DB, intents intent.Box, r *Rollback, url string, now time.Time) (*Deployment, error) {
+	r.From.RolledBack = true
+	if err := put(db, &r.From); err != nil {
+		return nil, fmt.Errorf("marking deployment rolled back: %w", err)
+	}
+
+	d := r.To
+	d.Environment = r.Environment
+	d.URL = url
+	d.RollbackOf = r.From.Source()
+	d.RolledBack = false
+	d.DeployedAt = now
+	if _, err := Record(db, intents, &d); err != nil {
+		return nil, err
+	}
+
+	for _, id := range r.Intents {
+		i, err := intents.Get(id)
+		if err != nil {
+			continue
+		}
+		if _, ok := i.Deployed[r.Environment]; !ok {
+			continue
+		}
+		delete(i.Deployed, r.Environment)
+		if err := intents.Update(i); err != nil {
+			return nil, fmt.Errorf("clearing deployment of intent %s: %w", id, err)
+		}
+	}
+	return &d, nil
+}
@@ -4,6 +4,8 @@ package diff
 import (
 	"bytes"
 	"fmt"
+	"path"
+	"strings"
 )
 
 // Line represents a single line in a diff with its type and content
@@ -23,9 +25,14 @@ const (
 	Deletion
 )
 
-// DiffResult contains the complete diff information
+// DiffResult contains the complete diff information. Binary and
+// truncated results have no hunks.
 type DiffResult struct {
-	Hunks []Hunk
+	Binary    bool // either side is binary
+	Truncated bool // either side is larger than the engine's maximum size
+	OldSize   int
+	NewSize   int
+	Hunks     []Hunk
 	Stats struct {
 		Additions int
 		Deletions int
@@ -42,30 +49,91 @@ type Hunk struct {
 	Lines    []Line
 }
 
+// DefaultMaxSize is the largest content, in bytes, diffed line by line
+// unless the engine is given another limit
+const DefaultMaxSize = 1 << 20
+
+// binarySniffLen is how much of the content is searched for a NUL byte,
+// as Git does
+const binarySniffLen = 8000
+
+// binaryExtensions are diffed as binary whatever their content
+var binaryExtensions = map[string]bool{
+	".7z": true, ".a": true, ".avi": true, ".bin": true, ".bmp": true,
+	".class": true, ".dll": true, ".dylib": true, ".eot": true, ".exe": true,
+	".gif": true, ".gz": true, ".ico": true, ".jar": true, ".jpeg": true,
+	".jpg": true, ".mov": true, ".mp3": true, ".mp4": true, ".o": true,
+	".otf": true, ".pdf": true, ".png": true, ".pyc": true, ".so": true,
+	".sqlite": true, ".tar": true, ".tgz": true, ".ttf": true, ".wasm": true,
+	".webp": true, ".woff": true, ".woff2": true, ".xz": true, ".zip": true,
+	".zst": true,
+}
+
 // Engine provides diffing capabilities
 type Engine struct {
 	contextLines int
+	maxSize      int
+	algorithm    Algorithm
 }
 
-// NewEngine creates a new diff engine with specified context lines
+// NewEngine creates a new diff engine with specified context lines,
+// matching lines with the Myers algorithm
 func NewEngine(contextLines int) *Engine {
 	return &Engine{
 		contextLines: contextLines,
+		maxSize:      DefaultMaxSize,
 	}
 }
 
-// Diff generates a line-by-line diff between two contents
+// WithMaxSize sets the largest content, in bytes, the engine diffs line
+// by line; larger contents give a truncated result. Zero or less means no
+// limit.
+func (e *Engine) WithMaxSize(n int) *Engine {
+	e.maxSize = n
+	return e
+}
+
+// IsBinary reports whether content looks binary, i.e. has a NUL byte in
+// its first 8000 bytes
+func IsBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), binarySniffLen)], 0) >= 0
+}
+
+// IsBinaryName reports whether a file is binary by its extension
+func IsBinaryName(name string) bool {
+	return binaryExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// DiffFile diffs two versions of the named file, treating files with a
+// binary extension as binary whatever their content
+func (e *Engine) DiffFile(name string, oldContent, newContent []byte) (*DiffResult, error) {
+	if IsBinaryName(name) && !bytes.Equal(oldContent, newContent) {
+		return &DiffResult{Binary: true, OldSize: len(oldContent), NewSize: len(newContent)}, nil
+	}
+	return e.Diff(oldContent, newContent)
+}
+
+// Diff generates a line-by-line diff between two contents. Binary
+// contents, and contents over the engine's maximum size, are only
+// compared for equality.
 func (e *Engine) Diff(oldContent, newContent []byte) (*DiffResult, error) {
+	if bytes.Equal(oldContent, newContent) {
+		return &DiffResult{OldSize: len(oldContent), NewSize: len(newContent)}, nil
+	}
+	if IsBinary(oldContent) || IsBinary(newContent) {
+		return &DiffResult{Binary: true, OldSize: len(oldContent), NewSize: len(newContent)}, nil
+	}
+	if e.maxSize > 0 && (len(oldContent) > e.maxSize || len(newContent) > e.maxSize) {
+		return &DiffResult{Truncated: true, OldSize: len(oldContent), NewSize: len(newContent)}, nil
+	}
+
 	oldLines := bytes.Split(bytes.TrimSuffix(oldContent, []byte{'\n'}), []byte{'\n'})
 	newLines := bytes.Split(bytes.TrimSuffix(newContent, []byte{'\n'}), []byte{'\n'})
 
-	result := &DiffResult{}
+	result := &DiffResult{OldSize: len(oldContent), NewSize: len(newContent)}
 	
-	// Generate LCS (Longest Common Subsequence) matrix
-	lcs := e.computeLCS(oldLines, newLines)
-	
-	// Extract hunks from LCS
-	hunks := e.extractHunks(oldLines, newLines, lcs)
+	// Match lines, then make a hunk of each change
+	hunks := extractHunks(e.script(oldLines, newLines))
 	
 	// Add context lines
 	result.Hunks = e.addContextLines(hunks, oldLines, newLines)
@@ -86,6 +154,21 @@ func (e *Engine) Diff(oldContent, newContent []byte) (*DiffResult, error) {
 	return result, nil
 }
 
+// Align returns every line of both contents in order, numbered and
+// marked as context, addition or deletion. Deletions come before the
+// additions that replace them.
+func (e *Engine) Align(oldContent, newContent []byte) []Line {
+	return e.script(splitLines(oldContent), splitLines(newContent))
+}
+
+// splitLines splits content into lines; empty content has none
+func splitLines(content []byte) [][]byte {
+	if len(content) == 0 {
+		return nil
+	}
+	return bytes.Split(bytes.TrimSuffix(content, []byte{'\n'}), []byte{'\n'})
+}
+
 // computeLCS creates a matrix for longest common subsequence
 func (e *Engine) computeLCS(oldLines, newLines [][]byte) [][]int {
 	matrix := make([][]int, len(oldLines)+1)
@@ -106,56 +189,60 @@ func (e *Engine) computeLCS(oldLines, newLines [][]byte) [][]int {
 	return matrix
 }
 
-// extractHunks generates diff hunks from the LCS matrix
-func (e *Engine) extractHunks(oldLines, newLines [][]byte, lcs [][]int) []Hunk {
-	var hunks []Hunk
-	var currentHunk *Hunk
-
+// lcsScript walks an LCS matrix backwards, then reverses the result
+func lcsScript(oldLines, newLines [][]byte, lcs [][]int) []Line {
+	var rev []Line
 	i, j := len(oldLines), len(newLines)
 	for i > 0 || j > 0 {
-		if i > 0 && j > 0 && bytes.Equal(oldLines[i-1], newLines[j-1]) {
-			if currentHunk != nil {
-				currentHunk.Lines = append([]Line{{
-					Type:    Context,
-					Content: string(oldLines[i-1]),
-				}}, currentHunk.Lines...)
-			}
+		switch {
+		case i > 0 && j > 0 && bytes.Equal(oldLines[i-1], newLines[j-1]):
+			rev = append(rev, Line{Type: Context, Content: string(oldLines[i-1]), OldNum: i, NewNum: j})
 			i--
 			j--
-		} else if j > 0 && (i == 0 || lcs[i][j-1] >= lcs[i-1][j]) {
-			if currentHunk == nil {
-				currentHunk = &Hunk{
-					OldStart: i,
-					NewStart: j,
-				}
-			}
-			currentHunk.Lines = append([]Line{{
-				Type:    Addition,
-				Content: string(newLines[j-1]),
-			}}, currentHunk.Lines...)
-			currentHunk.NewLines++
+		case j > 0 && (i == 0 || lcs[i][j-1] >= lcs[i-1][j]):
+			rev = append(rev, Line{Type: Addition, Content: string(newLines[j-1]), NewNum: j})
 			j--
-		} else if i > 0 && (j == 0 || lcs[i][j-1] < lcs[i-1][j]) {
-			if currentHunk == nil {
-				currentHunk = &Hunk{
-					OldStart: i,
-					NewStart: j,
-				}
-			}
-			currentHunk.Lines = append([]Line{{
-				Type:    Deletion,
-				Content: string(oldLines[i-1]),
-			}}, currentHunk.Lines...)
-			currentHunk.OldLines++
+		default:
+			rev = append(rev, Line{Type: Deletion, Content: string(oldLines[i-1]), OldNum: i})
 			i--
 		}
+	}
 
-		if currentHunk != nil && len(currentHunk.Lines) > 0 {
-			hunks = append([]Hunk{*currentHunk}, hunks...)
-			currentHunk = nil
-		}
+	lines := make([]Line, len(rev))
+	for n, l := range rev {
+		lines[len(rev)-1-n] = l
 	}
+	return lines
+}
 
+// extractHunks makes a hunk of each added or deleted line of a script.
+// A hunk starts at the line changed on its own side and after the lines
+// already passed on the other.
+func extractHunks(lines []Line) []Hunk {
+	var hunks []Hunk
+	var oldSeen, newSeen int
+	for _, l := range lines {
+		switch l.Type {
+		case Context:
+			oldSeen, newSeen = l.OldNum, l.NewNum
+		case Addition:
+			hunks = append(hunks, Hunk{
+				OldStart: oldSeen,
+				NewStart: l.NewNum,
+				NewLines: 1,
+				Lines:    []Line{{Type: Addition, Content: l.Content}},
+			})
+			newSeen = l.NewNum
+		case Deletion:
+			hunks = append(hunks, Hunk{
+				OldStart: l.OldNum,
+				NewStart: newSeen,
+				OldLines: 1,
+				Lines:    []Line{{Type: Deletion, Content: l.Content}},
+			})
+			oldSeen = l.OldNum
+		}
+	}
 	return hunks
 }
 
@@ -195,6 +282,13 @@ func (e *Engine) addContextLines(hunks []Hunk, oldLines, _ [][]byte) []Hunk {
 
 // Format returns a string representation of the diff
 func (r *DiffResult) Format() string {
+	switch {
+	case r.Binary:
+		return "Binary files differ\n"
+	case r.Truncated:
+		return fmt.Sprintf("Files differ but are too large to diff (%d and %d bytes)\n", r.OldSize, r.NewSize)
+	}
+
 	var buf bytes.Buffer
 
 	for _, hunk := range r.Hunks {
@@ -0,0 +1,55 @@
+// internal/diff/diff_test.go
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffBinary(t *testing.T) {
+	e := NewEngine(3)
+
+	r, err := e.Diff([]byte("a\nb\n"), []byte("a\x00b\n"))
+	require.NoError(t, err)
+	assert.True(t, r.Binary)
+	assert.Empty(t, r.Hunks)
+	assert.Equal(t, "Binary files differ\n", r.Format())
+
+	// Past the sniffed prefix, a NUL byte does not make content binary
+	late := append(bytes.Repeat([]byte("x"), binarySniffLen), 0)
+	assert.False(t, IsBinary(late))
+
+	r, err = e.DiffFile("assets/logo.PNG", []byte("one\n"), []byte("two\n"))
+	require.NoError(t, err)
+	assert.True(t, r.Binary)
+
+	r, err = e.DiffFile("logo.png", []byte("same"), []byte("same"))
+	require.NoError(t, err)
+	assert.False(t, r.Binary)
+	assert.Empty(t, r.Format())
+
+	r, err = e.DiffFile("main.go", []byte("one\n"), []byte("two\n"))
+	require.NoError(t, err)
+	assert.False(t, r.Binary)
+	assert.Equal(t, 1, r.Stats.Additions)
+	assert.Equal(t, 1, r.Stats.Deletions)
+}
+
+func TestDiffMaxSize(t *testing.T) {
+	big := bytes.Repeat([]byte("line\n"), 10)
+	e := NewEngine(3).WithMaxSize(len(big) - 1)
+
+	r, err := e.Diff([]byte("line\n"), big)
+	require.NoError(t, err)
+	assert.True(t, r.Truncated)
+	assert.Empty(t, r.Hunks)
+	assert.Equal(t, "Files differ but are too large to diff (5 and 50 bytes)\n", r.Format())
+
+	r, err = e.WithMaxSize(0).Diff([]byte("line\n"), big)
+	require.NoError(t, err)
+	assert.False(t, r.Truncated)
+	assert.Equal(t, 9, r.Stats.Additions)
+}
@@ -0,0 +1,199 @@
+// internal/diff/image.go
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // register JPEG decoding
+	"image/png"
+	"math/bits"
+	"path"
+	"strings"
+)
+
+// ImageInfo describes one side of a changed image
+type ImageInfo struct {
+	Format string `json:"format"` // png or jpeg
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int64  `json:"size"`
+	Hash   string `json:"hash,omitempty"` // Content hash, for fetching thumbnails
+}
+
+// ImageSummary describes an image change in place of a line diff. Old or
+// New is nil when the image was added or deleted.
+type ImageSummary struct {
+	Old       *ImageInfo `json:"old,omitempty"`
+	New       *ImageInfo `json:"new,omitempty"`
+	SizeDelta int64      `json:"size_delta"`
+	// Distance is the number of differing bits of the two images'
+	// 64-bit perceptual hashes: 0 looks the same, above 10 or so looks
+	// different. It is -1 unless both sides exist.
+	Distance int `json:"distance"`
+}
+
+// IsImage reports whether a file is a PNG or JPEG image by its extension
+func IsImage(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	}
+	return false
+}
+
+// Image summarizes the change between two PNG or JPEG images. Empty
+// content is a missing side. It fails if either side does not decode.
+func Image(oldContent, newContent []byte) (*ImageSummary, error) {
+	s := &ImageSummary{Distance: -1, SizeDelta: int64(len(newContent) - len(oldContent))}
+	var oldImg, newImg image.Image
+	var err error
+	if len(oldContent) > 0 {
+		if s.Old, oldImg, err = decodeImage(oldContent); err != nil {
+			return nil, fmt.Errorf("decoding old image: %w", err)
+		}
+	}
+	if len(newContent) > 0 {
+		if s.New, newImg, err = decodeImage(newContent); err != nil {
+			return nil, fmt.Errorf("decoding new image: %w", err)
+		}
+	}
+	if oldImg != nil && newImg != nil {
+		s.Distance = bits.OnesCount64(perceptualHash(oldImg) ^ perceptualHash(newImg))
+	}
+	return s, nil
+}
+
+func decodeImage(content []byte) (*ImageInfo, image.Image, error) {
+	img, format, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, nil, err
+	}
+	b := img.Bounds()
+	return &ImageInfo{Format: format, Width: b.Dx(), Height: b.Dy(), Size: int64(len(content))}, img, nil
+}
+
+// perceptualHash computes a difference hash: the image is shrunk to 9x8
+// grey levels and each bit records whether a pixel is brighter than its
+// right neighbour, so re-encoding or resizing barely changes it
+func perceptualHash(img image.Image) uint64 {
+	grey := shrink(img, 9, 8)
+	var h uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			h <<= 1
+			if grey[y*9+x] > grey[y*9+x+1] {
+				h |= 1
+			}
+		}
+	}
+	return h
+}
+
+// maxSamples bounds the pixels read per cell when shrinking large images
+const maxSamples = 16
+
+// shrink averages the grey level of each cell of a w by h grid over img
+func shrink(img image.Image, w, h int) []float64 {
+	b := img.Bounds()
+	out := make([]float64, w*h)
+	for cy := 0; cy < h; cy++ {
+		y0, y1 := b.Min.Y+cy*b.Dy()/h, b.Min.Y+(cy+1)*b.Dy()/h
+		for cx := 0; cx < w; cx++ {
+			x0, x1 := b.Min.X+cx*b.Dx()/w, b.Min.X+(cx+1)*b.Dx()/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			if y1 <= y0 {
+				y1 = y0 + 1
+			}
+			xStep, yStep := max(1, (x1-x0)/maxSamples), max(1, (y1-y0)/maxSamples)
+			var sum float64
+			var n int
+			for y := y0; y < y1; y += yStep {
+				for x := x0; x < x1; x += xStep {
+					sum += float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+					n++
+				}
+			}
+			out[cy*w+cx] = sum / float64(n)
+		}
+	}
+	return out
+}
+
+// Thumbnail decodes a PNG or JPEG image and returns it scaled down to fit
+// within size by size pixels, encoded as PNG. Smaller images keep their
+// size.
+func Thumbnail(content []byte, size int) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			thumb.Set(x, y, img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, thumb); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Format renders the summary as diff output
+func (s *ImageSummary) Format() string {
+	side := func(i *ImageInfo) string {
+		if i == nil {
+			return "(none)"
+		}
+		return fmt.Sprintf("%s %dx%d, %s", i.Format, i.Width, i.Height, formatBytes(i.Size))
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "- %s\n", side(s.Old))
+	fmt.Fprintf(&buf, "+ %s\n", side(s.New))
+	sign := "+"
+	if s.SizeDelta < 0 {
+		sign = "-"
+	}
+	fmt.Fprintf(&buf, "  size %s%s", sign, formatBytes(abs(s.SizeDelta)))
+	if s.Distance >= 0 {
+		fmt.Fprintf(&buf, ", perceptual distance %d/64", s.Distance)
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+// formatBytes renders a size with a binary unit, e.g. 1.5KB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+// internal/diff/image_test.go
+package diff
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gradient draws a w by h image that brightens left to right, or right to
+// left when reversed
+func gradient(w, h int, reversed bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(x * 255 / (w - 1))
+			if reversed {
+				v = 255 - v
+			}
+			img.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestImage(t *testing.T) {
+	small := encodePNG(t, gradient(64, 32, false))
+	var jpg bytes.Buffer
+	require.NoError(t, jpeg.Encode(&jpg, gradient(128, 64, false), nil))
+
+	// Re-encoding at another size looks the same
+	s, err := Image(small, jpg.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, &ImageInfo{Format: "png", Width: 64, Height: 32, Size: int64(len(small))}, s.Old)
+	assert.Equal(t, "jpeg", s.New.Format)
+	assert.Equal(t, 128, s.New.Width)
+	assert.Equal(t, int64(jpg.Len()-len(small)), s.SizeDelta)
+	assert.LessOrEqual(t, s.Distance, 4)
+
+	s, err = Image(small, encodePNG(t, gradient(64, 32, true)))
+	require.NoError(t, err)
+	assert.Greater(t, s.Distance, 32)
+	assert.Contains(t, s.Format(), "perceptual distance")
+
+	s, err = Image(nil, small)
+	require.NoError(t, err)
+	assert.Nil(t, s.Old)
+	assert.Equal(t, -1, s.Distance)
+	assert.Equal(t, "- (none)\n+ png 64x32, "+formatBytes(int64(len(small)))+"\n  size +"+formatBytes(int64(len(small)))+"\n", s.Format())
+
+	_, err = Image([]byte("not an image"), small)
+	assert.Error(t, err)
+}
+
+func TestThumbnail(t *testing.T) {
+	thumb, err := Thumbnail(encodePNG(t, gradient(200, 100, false)), 50)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(thumb))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 50, 25), img.Bounds())
+
+	// Small images keep their size
+	thumb, err = Thumbnail(encodePNG(t, gradient(20, 10, false)), 50)
+	require.NoError(t, err)
+	img, err = png.Decode(bytes.NewReader(thumb))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 20, 10), img.Bounds())
+
+	_, err = Thumbnail([]byte("not an image"), 50)
+	assert.Error(t, err)
+}
+
+func TestIsImage(t *testing.T) {
+	assert.True(t, IsImage("assets/logo.PNG"))
+	assert.True(t, IsImage("photo.jpeg"))
+	assert.True(t, IsImage("photo.jpg"))
+	assert.False(t, IsImage("logo.svg"))
+}
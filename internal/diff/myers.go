@@ -0,0 +1,203 @@
+// internal/diff/myers.go
+package diff
+
+// Algorithm selects how an engine matches the lines of two contents
+type Algorithm int
+
+const (
+	// Myers finds a shortest edit script with Myers' O(ND) algorithm in
+	// linear space, D being the number of changed lines
+	Myers Algorithm = iota
+	// LCS fills an n by m longest common subsequence matrix; quadratic
+	// in time and memory
+	LCS
+)
+
+// WithAlgorithm sets how the engine matches lines
+func (e *Engine) WithAlgorithm(a Algorithm) *Engine {
+	e.algorithm = a
+	return e
+}
+
+// script returns every line of both sides in order, numbered and marked
+// as context, addition or deletion, matched by the engine's algorithm
+func (e *Engine) script(oldLines, newLines [][]byte) []Line {
+	if e.algorithm == LCS {
+		return lcsScript(oldLines, newLines, e.computeLCS(oldLines, newLines))
+	}
+	return myersScript(oldLines, newLines)
+}
+
+// myersScript marks the changed lines of each side, then merges the two
+// sides, putting each run of deletions before the additions replacing it
+func myersScript(oldLines, newLines [][]byte) []Line {
+	// Compare lines by number rather than content
+	ids := make(map[string]int)
+	intern := func(lines [][]byte) []int {
+		out := make([]int, len(lines))
+		for n, l := range lines {
+			id, ok := ids[string(l)]
+			if !ok {
+				id = len(ids)
+				ids[string(l)] = id
+			}
+			out[n] = id
+		}
+		return out
+	}
+	m := &myers{a: intern(oldLines), b: intern(newLines)}
+	m.deleted = make([]bool, len(m.a))
+	m.added = make([]bool, len(m.b))
+	m.compare(0, len(m.a), 0, len(m.b))
+
+	lines := make([]Line, 0, max(len(oldLines), len(newLines)))
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		if i < len(oldLines) && j < len(newLines) && !m.deleted[i] && !m.added[j] {
+			lines = append(lines, Line{Type: Context, Content: string(oldLines[i]), OldNum: i + 1, NewNum: j + 1})
+			i++
+			j++
+			continue
+		}
+		for ; i < len(oldLines) && m.deleted[i]; i++ {
+			lines = append(lines, Line{Type: Deletion, Content: string(oldLines[i]), OldNum: i + 1})
+		}
+		for ; j < len(newLines) && m.added[j]; j++ {
+			lines = append(lines, Line{Type: Addition, Content: string(newLines[j]), NewNum: j + 1})
+		}
+	}
+	return lines
+}
+
+// myers holds the two sides being compared and which of their lines are
+// not part of the common subsequence
+type myers struct {
+	a, b    []int
+	deleted []bool
+	added   []bool
+}
+
+// compare marks the changes between a[aLo:aHi] and b[bLo:bHi], splitting
+// the problem at the middle snake of a shortest edit path
+func (m *myers) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && m.a[aLo] == m.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && m.a[aHi-1] == m.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			m.added[j] = true
+		}
+		return
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			m.deleted[i] = true
+		}
+		return
+	}
+
+	x, y, ok := m.middle(aLo, aHi, bLo, bHi)
+	if !ok {
+		for i := aLo; i < aHi; i++ {
+			m.deleted[i] = true
+		}
+		for j := bLo; j < bHi; j++ {
+			m.added[j] = true
+		}
+		return
+	}
+	m.compare(aLo, x, bLo, y)
+	m.compare(x, aHi, y, bHi)
+}
+
+// middle searches forwards from the start and backwards from the end of
+// the two ranges at once until the paths meet, returning where they do.
+// Each half then needs at most half the edits of the whole. The ranges
+// must not share a first or last line, so at least two edits separate
+// them.
+func (m *myers) middle(aLo, aHi, bLo, bHi int) (x, y int, ok bool) {
+	n, k := aHi-aLo, bHi-bLo
+	maxD := (n + k + 1) / 2
+	offset := maxD + 1
+	size := 2*maxD + 3
+	fwd := make([]int, size)
+	bwd := make([]int, size)
+	for d := range fwd {
+		fwd[d] = -1
+		bwd[d] = -1
+	}
+	fwd[offset+1] = 0
+	bwd[offset+1] = 0
+
+	delta := n - k
+	// With an odd delta, the forward path reaches the overlap first
+	front := delta%2 != 0
+	// Diagonals that ran off an edge of the grid are not extended again
+	var fStart, fEnd, bStart, bEnd int
+
+	for d := 0; d < maxD; d++ {
+		for diag := -d + fStart; diag <= d-fEnd; diag += 2 {
+			at := offset + diag
+			var x1 int
+			if diag == -d || (diag != d && fwd[at-1] < fwd[at+1]) {
+				x1 = fwd[at+1]
+			} else {
+				x1 = fwd[at-1] + 1
+			}
+			y1 := x1 - diag
+			for x1 < n && y1 < k && m.a[aLo+x1] == m.b[bLo+y1] {
+				x1++
+				y1++
+			}
+			fwd[at] = x1
+			switch {
+			case x1 > n:
+				fEnd += 2
+			case y1 > k:
+				fStart += 2
+			case front:
+				back := offset + delta - diag
+				if back >= 0 && back < size && bwd[back] != -1 && x1 >= n-bwd[back] {
+					return aLo + x1, bLo + y1, true
+				}
+			}
+		}
+
+		for diag := -d + bStart; diag <= d-bEnd; diag += 2 {
+			at := offset + diag
+			var x2 int
+			if diag == -d || (diag != d && bwd[at-1] < bwd[at+1]) {
+				x2 = bwd[at+1]
+			} else {
+				x2 = bwd[at-1] + 1
+			}
+			y2 := x2 - diag
+			for x2 < n && y2 < k && m.a[aHi-x2-1] == m.b[bHi-y2-1] {
+				x2++
+				y2++
+			}
+			bwd[at] = x2
+			switch {
+			case x2 > n:
+				bEnd += 2
+			case y2 > k:
+				bStart += 2
+			case !front:
+				ahead := offset + delta - diag
+				if ahead >= 0 && ahead < size && fwd[ahead] != -1 {
+					x1 := fwd[ahead]
+					y1 := x1 - (ahead - offset)
+					if x1 >= n-x2 {
+						return aLo + x1, bLo + y1, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,102 @@
+// internal/diff/myers_test.go
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomLines writes up to n lines drawn from a small alphabet, so
+// matches are frequent
+func randomLines(r *rand.Rand, n int) []byte {
+	var sb strings.Builder
+	for i := r.Intn(n + 1); i > 0; i-- {
+		fmt.Fprintf(&sb, "%c\n", 'a'+r.Intn(5))
+	}
+	return []byte(sb.String())
+}
+
+func TestMyersIsMinimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	myers, lcs := NewEngine(3), NewEngine(3).WithAlgorithm(LCS)
+	for n := 0; n < 2000; n++ {
+		a, b := randomLines(r, 40), randomLines(r, 40)
+
+		got, err := myers.Diff(a, b)
+		require.NoError(t, err)
+		want, err := lcs.Diff(a, b)
+		require.NoError(t, err)
+		require.Equal(t, want.Stats.Changes, got.Stats.Changes, "%q -> %q", a, b)
+
+		// The script spells out both sides
+		var oldSide, newSide strings.Builder
+		for _, l := range myers.Align(a, b) {
+			if l.Type != Addition {
+				oldSide.WriteString(l.Content + "\n")
+			}
+			if l.Type != Deletion {
+				newSide.WriteString(l.Content + "\n")
+			}
+		}
+		require.Equal(t, string(a), oldSide.String())
+		require.Equal(t, string(b), newSide.String())
+	}
+}
+
+func TestMyersDeletesBeforeAdding(t *testing.T) {
+	lines := NewEngine(3).Align([]byte("a\nb\nc\n"), []byte("a\nx\ny\nc\n"))
+	var types []LineType
+	for _, l := range lines {
+		types = append(types, l.Type)
+	}
+	assert.Equal(t, []LineType{Context, Deletion, Addition, Addition, Context}, types)
+	assert.Equal(t, Line{Type: Addition, Content: "y", NewNum: 3}, lines[3])
+}
+
+// largeFile writes n numbered lines and a copy with a line changed in
+// every thousand
+func largeFile(n int) (old, changed []byte) {
+	var a, b strings.Builder
+	for i := 0; i < n; i++ {
+		line := fmt.Sprintf("line %d of the generated file\n", i)
+		a.WriteString(line)
+		if i%1000 == 500 {
+			line = fmt.Sprintf("changed %d\n", i)
+		}
+		b.WriteString(line)
+	}
+	return []byte(a.String()), []byte(b.String())
+}
+
+// BenchmarkDiff compares the algorithms on a small file; LCS is left out
+// for the large ones, whose n*m matrices would take tens of gigabytes
+func BenchmarkDiff(b *testing.B) {
+	cases := []struct {
+		lines     int
+		algorithm Algorithm
+	}{
+		{2000, LCS},
+		{2000, Myers},
+		{100000, Myers},
+		{200000, Myers},
+	}
+	for _, c := range cases {
+		name := map[Algorithm]string{LCS: "lcs", Myers: "myers"}[c.algorithm]
+		b.Run(fmt.Sprintf("%s/%d", name, c.lines), func(b *testing.B) {
+			old, changed := largeFile(c.lines)
+			e := NewEngine(3).WithAlgorithm(c.algorithm).WithMaxSize(0)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := e.Diff(old, changed); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
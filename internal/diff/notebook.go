@@ -0,0 +1,107 @@
+// internal/diff/notebook.go
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// IsNotebook reports whether a file is a Jupyter notebook by its
+// extension
+func IsNotebook(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".ipynb")
+}
+
+// notebook is the part of the nbformat 4 schema a diff shows
+type notebook struct {
+	Cells []struct {
+		CellType string          `json:"cell_type"`
+		Source   json.RawMessage `json:"source"`
+		Outputs  []struct {
+			OutputType string                     `json:"output_type"`
+			Name       string                     `json:"name"`
+			Text       json.RawMessage            `json:"text"`
+			Data       map[string]json.RawMessage `json:"data"`
+			EName      string                     `json:"ename"`
+			EValue     string                     `json:"evalue"`
+		} `json:"outputs"`
+	} `json:"cells"`
+}
+
+// Notebook renders a Jupyter notebook as plain text for line diffing:
+// each cell's source under a header naming its number and type. Execution
+// counts and metadata are left out, and so are outputs unless outputs is
+// set, so re-running a notebook is not a change. Empty content renders
+// as nothing.
+func Notebook(content []byte, outputs bool) ([]byte, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+	var nb notebook
+	if err := json.Unmarshal(content, &nb); err != nil {
+		return nil, fmt.Errorf("parsing notebook: %w", err)
+	}
+
+	var buf bytes.Buffer
+	for n, cell := range nb.Cells {
+		fmt.Fprintf(&buf, "## cell %d [%s]\n", n+1, cell.CellType)
+		writeText(&buf, notebookText(cell.Source))
+		if !outputs {
+			continue
+		}
+		for _, out := range cell.Outputs {
+			switch out.OutputType {
+			case "stream":
+				fmt.Fprintf(&buf, "## cell %d output [%s]\n", n+1, out.Name)
+				writeText(&buf, notebookText(out.Text))
+			case "execute_result", "display_data":
+				fmt.Fprintf(&buf, "## cell %d output [%s]\n", n+1, out.OutputType)
+				if text, ok := out.Data["text/plain"]; ok {
+					writeText(&buf, notebookText(text))
+				}
+				// Rich outputs are summarized rather than shown
+				var kinds []string
+				for kind := range out.Data {
+					if kind != "text/plain" {
+						kinds = append(kinds, kind)
+					}
+				}
+				sort.Strings(kinds)
+				for _, kind := range kinds {
+					fmt.Fprintf(&buf, "<%s, %d bytes>\n", kind, len(notebookText(out.Data[kind])))
+				}
+			case "error":
+				fmt.Fprintf(&buf, "## cell %d output [error]\n", n+1)
+				fmt.Fprintf(&buf, "%s: %s\n", out.EName, out.EValue)
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// notebookText decodes a multiline notebook string, stored either as one
+// string or as a list of lines
+func notebookText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err == nil {
+		return strings.Join(lines, "")
+	}
+	return ""
+}
+
+// writeText writes text ending with exactly one newline
+func writeText(buf *bytes.Buffer, text string) {
+	if text == "" {
+		return
+	}
+	buf.WriteString(strings.TrimSuffix(text, "\n"))
+	buf.WriteByte('\n')
+}
@@ -0,0 +1,66 @@
+// internal/diff/notebook_test.go
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const notebookBefore = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Analysis\n"]},
+  {"cell_type": "code", "execution_count": 3, "metadata": {"scrolled": true},
+   "source": ["import pandas as pd\n", "df = pd.read_csv(\"data.csv\")\n", "df.describe()"],
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["loaded 10 rows\n"]},
+    {"output_type": "execute_result", "execution_count": 3,
+     "data": {"text/plain": ["count 10"], "text/html": ["<table></table>"]}}
+   ]}
+ ],
+ "metadata": {"kernelspec": {"name": "python3"}},
+ "nbformat": 4, "nbformat_minor": 5
+}`
+
+// Re-run with new outputs and execution counts but the same sources
+const notebookRerun = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": "# Analysis\n"},
+  {"cell_type": "code", "execution_count": 12, "metadata": {},
+   "source": ["import pandas as pd\n", "df = pd.read_csv(\"data.csv\")\n", "df.describe()"],
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["loaded 12 rows\n"]},
+    {"output_type": "error", "ename": "KeyError", "evalue": "'x'", "traceback": []}
+   ]}
+ ],
+ "metadata": {"kernelspec": {"name": "python3"}},
+ "nbformat": 4, "nbformat_minor": 5
+}`
+
+func TestNotebook(t *testing.T) {
+	before, err := Notebook([]byte(notebookBefore), false)
+	require.NoError(t, err)
+	assert.Equal(t, "## cell 1 [markdown]\n# Analysis\n## cell 2 [code]\nimport pandas as pd\ndf = pd.read_csv(\"data.csv\")\ndf.describe()\n", string(before))
+
+	// Re-running a notebook is not a change of its sources
+	rerun, err := Notebook([]byte(notebookRerun), false)
+	require.NoError(t, err)
+	assert.Equal(t, before, rerun)
+
+	before, err = Notebook([]byte(notebookBefore), true)
+	require.NoError(t, err)
+	assert.Contains(t, string(before), "## cell 2 output [stdout]\nloaded 10 rows\n## cell 2 output [execute_result]\ncount 10\n<text/html, 15 bytes>\n")
+	rerun, err = Notebook([]byte(notebookRerun), true)
+	require.NoError(t, err)
+	assert.Contains(t, string(rerun), "## cell 2 output [error]\nKeyError: 'x'\n")
+
+	empty, err := Notebook(nil, true)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	_, err = Notebook([]byte("{"), false)
+	assert.Error(t, err)
+	assert.True(t, IsNotebook("analysis/Report.IPYNB"))
+	assert.False(t, IsNotebook("report.py"))
+}
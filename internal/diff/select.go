@@ -0,0 +1,104 @@
+// internal/diff/select.go
+package diff
+
+import (
+	"bytes"
+)
+
+// Hunks splits two contents into one hunk per run of changed lines, each
+// with up to the engine's context lines on either side. Hunks are
+// numbered by their position in the result, as Select expects.
+func (e *Engine) Hunks(oldContent, newContent []byte) []Hunk {
+	lines := e.Align(oldContent, newContent)
+	var hunks []Hunk
+	for _, b := range changeBlocks(lines) {
+		start := max(0, b[0]-e.contextLines)
+		end := min(len(lines), b[1]+e.contextLines)
+		// Stop context at a neighbouring change
+		for n := b[0] - 1; n >= start; n-- {
+			if lines[n].Type != Context {
+				start = n + 1
+				break
+			}
+		}
+		for n := b[1]; n < end; n++ {
+			if lines[n].Type != Context {
+				end = n
+				break
+			}
+		}
+
+		h := Hunk{Lines: lines[start:end]}
+		for _, l := range h.Lines {
+			if l.Type != Addition {
+				if h.OldStart == 0 {
+					h.OldStart = l.OldNum
+				}
+				h.OldLines++
+			}
+			if l.Type != Deletion {
+				if h.NewStart == 0 {
+					h.NewStart = l.NewNum
+				}
+				h.NewLines++
+			}
+		}
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+// Select rebuilds content from two versions, taking the new side of the
+// hunks for which take returns true and the old side of the rest
+func (e *Engine) Select(oldContent, newContent []byte, take func(n int) bool) []byte {
+	lines := e.Align(oldContent, newContent)
+	blocks := changeBlocks(lines)
+
+	var buf bytes.Buffer
+	var last *Line
+	n := 0
+	for i := range lines {
+		for n < len(blocks) && i >= blocks[n][1] {
+			n++
+		}
+		l := &lines[i]
+		if l.Type != Context {
+			taken := take(n)
+			if l.Type == Addition && !taken || l.Type == Deletion && taken {
+				continue
+			}
+		}
+		buf.WriteString(l.Content)
+		buf.WriteByte('\n')
+		last = l
+	}
+
+	// The final newline follows the side the last line came from
+	if last != nil {
+		from := newContent
+		if last.Type == Deletion {
+			from = oldContent
+		}
+		if !bytes.HasSuffix(from, []byte{'\n'}) {
+			buf.Truncate(buf.Len() - 1)
+		}
+	}
+	return buf.Bytes()
+}
+
+// changeBlocks returns the start and end of each run of added and deleted
+// lines
+func changeBlocks(lines []Line) [][2]int {
+	var blocks [][2]int
+	for i := 0; i < len(lines); i++ {
+		if lines[i].Type == Context {
+			continue
+		}
+		start := i
+		for i < len(lines) && lines[i].Type != Context {
+			i++
+		}
+		blocks = append(blocks, [2]int{start, i})
+	}
+	return blocks
+}
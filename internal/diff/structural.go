@@ -0,0 +1,216 @@
+// internal/diff/structural.go
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats a structural diff understands
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Kinds of key-level change
+const (
+	KeyAdded    = "add"
+	KeyRemoved  = "delete"
+	KeyModified = "modify"
+)
+
+// KeyChange is one key or array element added, removed or changed
+// between two documents. Path is rooted at "$", e.g. $.server.ports[0].
+type KeyChange struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// StructuredFormat returns the structural diff format of a file by its
+// extension, or "" if it has none
+func StructuredFormat(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	}
+	return ""
+}
+
+// Structural parses two JSON or YAML documents and reports the keys that
+// differ, so reordering keys or reformatting is not a change. Empty
+// content is an empty document. It fails if either side does not parse.
+func Structural(format string, oldContent, newContent []byte) ([]KeyChange, error) {
+	oldDoc, err := parseDocument(format, oldContent)
+	if err != nil {
+		return nil, fmt.Errorf("parsing old %s: %w", format, err)
+	}
+	newDoc, err := parseDocument(format, newContent)
+	if err != nil {
+		return nil, fmt.Errorf("parsing new %s: %w", format, err)
+	}
+
+	var changes []KeyChange
+	compareValues("$", oldDoc, newDoc, &changes)
+	return changes, nil
+}
+
+// compareValues appends the changes between two decoded values at p
+func compareValues(p string, oldVal, newVal any, changes *[]KeyChange) {
+	switch o := oldVal.(type) {
+	case map[string]any:
+		if n, ok := newVal.(map[string]any); ok {
+			keys := make([]string, 0, len(o)+len(n))
+			for k := range o {
+				keys = append(keys, k)
+			}
+			for k := range n {
+				if _, ok := o[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				ov, inOld := o[k]
+				nv, inNew := n[k]
+				switch {
+				case !inOld:
+					*changes = append(*changes, KeyChange{Path: keyPath(p, k), Kind: KeyAdded, New: nv})
+				case !inNew:
+					*changes = append(*changes, KeyChange{Path: keyPath(p, k), Kind: KeyRemoved, Old: ov})
+				default:
+					compareValues(keyPath(p, k), ov, nv, changes)
+				}
+			}
+			return
+		}
+	case []any:
+		if n, ok := newVal.([]any); ok {
+			for i := 0; i < max(len(o), len(n)); i++ {
+				ip := p + "[" + strconv.Itoa(i) + "]"
+				switch {
+				case i >= len(o):
+					*changes = append(*changes, KeyChange{Path: ip, Kind: KeyAdded, New: n[i]})
+				case i >= len(n):
+					*changes = append(*changes, KeyChange{Path: ip, Kind: KeyRemoved, Old: o[i]})
+				default:
+					compareValues(ip, o[i], n[i], changes)
+				}
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(oldVal, newVal) {
+		*changes = append(*changes, KeyChange{Path: p, Kind: KeyModified, Old: oldVal, New: newVal})
+	}
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// keyPath appends a map key to a path, quoting keys that are not plain
+// identifiers
+func keyPath(p, key string) string {
+	if identifier.MatchString(key) {
+		return p + "." + key
+	}
+	return p + "[" + strconv.Quote(key) + "]"
+}
+
+// parseDocument decodes content into maps, slices and scalars. A YAML
+// stream of several documents becomes an array of them.
+func parseDocument(format string, content []byte) (any, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return map[string]any{}, nil
+	}
+	switch format {
+	case FormatJSON:
+		var doc any
+		if err := json.Unmarshal(content, &doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	case FormatYAML:
+		var docs []any
+		dec := yaml.NewDecoder(bytes.NewReader(content))
+		for {
+			var doc any
+			err := dec.Decode(&doc)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, normalizeYAML(doc))
+		}
+		if len(docs) == 1 {
+			return docs[0], nil
+		}
+		return docs, nil
+	}
+	return nil, fmt.Errorf("unknown structured format %q", format)
+}
+
+// normalizeYAML turns maps with non-string keys into string-keyed maps
+// so YAML and JSON documents compare alike
+func normalizeYAML(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = normalizeYAML(e)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalizeYAML(e)
+		}
+		return m
+	case []any:
+		for i, e := range v {
+			v[i] = normalizeYAML(e)
+		}
+		return v
+	}
+	return v
+}
+
+// FormatKeyChanges renders key-level changes one per line, marked + for
+// added, - for removed and ~ for changed keys
+func FormatKeyChanges(changes []KeyChange) string {
+	var buf bytes.Buffer
+	for _, c := range changes {
+		switch c.Kind {
+		case KeyAdded:
+			fmt.Fprintf(&buf, "+ %s: %s\n", c.Path, formatValue(c.New))
+		case KeyRemoved:
+			fmt.Fprintf(&buf, "- %s: %s\n", c.Path, formatValue(c.Old))
+		default:
+			fmt.Fprintf(&buf, "~ %s: %s -> %s\n", c.Path, formatValue(c.Old), formatValue(c.New))
+		}
+	}
+	return buf.String()
+}
+
+// formatValue renders a decoded value as compact JSON
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
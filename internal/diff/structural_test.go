@@ -0,0 +1,62 @@
+// internal/diff/structural_test.go
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuralJSON(t *testing.T) {
+	oldDoc := []byte(`{"name": "app", "ports": [80, 443], "env": {"DEBUG": "1", "my key": true}}`)
+	newDoc := []byte(`{
+  "env": {"my key": false},
+  "ports": [80],
+  "name": "app",
+  "replicas": 3
+}`)
+
+	changes, err := Structural(FormatJSON, oldDoc, newDoc)
+	require.NoError(t, err)
+	assert.Equal(t, []KeyChange{
+		{Path: "$.env.DEBUG", Kind: KeyRemoved, Old: "1"},
+		{Path: `$.env["my key"]`, Kind: KeyModified, Old: true, New: false},
+		{Path: "$.ports[1]", Kind: KeyRemoved, Old: float64(443)},
+		{Path: "$.replicas", Kind: KeyAdded, New: float64(3)},
+	}, changes)
+	assert.Equal(t, "- $.env.DEBUG: \"1\"\n~ $.env[\"my key\"]: true -> false\n- $.ports[1]: 443\n+ $.replicas: 3\n",
+		FormatKeyChanges(changes))
+
+	// Reordering keys is not a change
+	changes, err = Structural(FormatJSON, []byte(`{"a": 1, "b": 2}`), []byte(`{"b": 2, "a": 1}`))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = Structural(FormatJSON, []byte(`{"a": `), newDoc)
+	assert.Error(t, err)
+}
+
+func TestStructuralYAML(t *testing.T) {
+	oldDoc := []byte("server:\n  host: localhost\n  port: 80\n")
+	newDoc := []byte("server:\n  port: 8080\n  host: localhost\n1: one\n")
+
+	changes, err := Structural(FormatYAML, oldDoc, newDoc)
+	require.NoError(t, err)
+	assert.Equal(t, []KeyChange{
+		{Path: `$["1"]`, Kind: KeyAdded, New: "one"},
+		{Path: "$.server.port", Kind: KeyModified, Old: 80, New: 8080},
+	}, changes)
+
+	// A new file adds every top-level key
+	changes, err = Structural(FormatYAML, nil, []byte("a: 1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []KeyChange{{Path: "$.a", Kind: KeyAdded, New: 1}}, changes)
+}
+
+func TestStructuredFormat(t *testing.T) {
+	assert.Equal(t, FormatJSON, StructuredFormat("config/app.JSON"))
+	assert.Equal(t, FormatYAML, StructuredFormat("deploy.yml"))
+	assert.Equal(t, FormatYAML, StructuredFormat("deploy.yaml"))
+	assert.Empty(t, StructuredFormat("main.go"))
+}
@@ -1,6 +1,7 @@
 package errors
 
 import (
+    stderrors "errors"
     "net/http"
 )
 
@@ -11,6 +12,7 @@ const (
     ErrorTypeValidation   ErrorType = "VALIDATION"
     ErrorTypeInternal     ErrorType = "INTERNAL"
     ErrorTypeUnauthorized ErrorType = "UNAUTHORIZED"
+    ErrorTypeUnlicensed   ErrorType = "UNLICENSED"
 )
 
 type Error struct {
@@ -39,4 +41,21 @@ func ValidationError(message string, details any) *Error {
         Code:    http.StatusBadRequest,
         Details: details,
     }
-}
\ No newline at end of file
+}
+// Unlicensed reports a server feature the installed license does not cover
+func Unlicensed(message string, details any) *Error {
+    return &Error{
+        Type:    ErrorTypeUnlicensed,
+        Message: message,
+        Code:    http.StatusForbidden,
+        Details: details,
+    }
+}
+
+// Failures the CLI reports with dedicated exit codes. Wrap them with %w so
+// callers can tell them apart with errors.Is.
+var (
+    ErrConflict      = stderrors.New("conflict")
+    ErrDirtyTree     = stderrors.New("working tree has uncommitted changes")
+    ErrNotRepository = stderrors.New("not a tig repository")
+)
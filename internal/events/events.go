@@ -0,0 +1,96 @@
+// internal/events/events.go
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of event being published
+type Type string
+
+const (
+	IntentCreated    Type = "intent.created"
+	BreakingChange   Type = "intent.breaking_change"
+	StreamMerged     Type = "stream.merged"
+	CheckFailed      Type = "check.failed"
+	ContentCorrupt   Type = "content.corrupt"
+	IntentReviewed   Type = "intent.reviewed"
+	CheckPassed      Type = "check.passed"
+	IntentAdded      Type = "stream.intent_added"
+	IntentQueued     Type = "stream.intent_queued"
+	ReviewAssigned   Type = "intent.review_assigned"
+	IntentStale      Type = "intent.stale"
+	StreamStale      Type = "stream.stale"
+	CoverageRecorded Type = "intent.coverage_recorded"
+	Deployed         Type = "deployment.recorded"
+	RolledBack       Type = "deployment.rolled_back"
+	IncidentLinked   Type = "intent.incident_linked"
+)
+
+// Event describes something that happened in a repository
+type Event struct {
+	Type     Type              `json:"type"`
+	StreamID string            `json:"stream_id,omitempty"`
+	IntentID string            `json:"intent_id,omitempty"`
+	Summary  string            `json:"summary"`
+	Data     map[string]string `json:"data,omitempty"`
+	Time     time.Time         `json:"time"`
+}
+
+// Handler receives published events
+type Handler func(Event)
+
+// Publisher is implemented by anything events can be sent to
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Bus is a simple in-process event dispatcher. Handlers are invoked
+// synchronously, so slow consumers should queue work themselves.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+	all      []Handler
+}
+
+// NewBus creates an empty event bus
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[Type][]Handler),
+	}
+}
+
+// Subscribe registers a handler for a single event type
+func (b *Bus) Subscribe(t Type, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[t] = append(b.handlers[t], h)
+}
+
+// SubscribeAll registers a handler for every event type
+func (b *Bus) SubscribeAll(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.all = append(b.all, h)
+}
+
+// Publish delivers an event to all matching handlers
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[e.Type])+len(b.all))
+	handlers = append(handlers, b.handlers[e.Type]...)
+	handlers = append(handlers, b.all...)
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+}
@@ -0,0 +1,213 @@
+// internal/fsck/fsck.go
+package fsck
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"tig/internal/change"
+	"tig/internal/release"
+	"tig/internal/safe"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Issue kinds reported by a check
+const (
+	KindRefCount = "refcount" // stored count differs from live references
+	KindOrphan   = "orphan"   // content with no live references
+	KindMissing  = "missing"  // referenced content not in the safe
+	KindCorrupt  = "corrupt"  // content file unreadable or hash mismatch
+	KindTampered = "tampered" // .tig changed outside tig or restored in part
+)
+
+// Issue is a single invariant violation
+type Issue struct {
+	Kind     string `json:"kind"`
+	Hash     string `json:"hash"`
+	Detail   string `json:"detail"`
+	Repaired bool   `json:"repaired"`
+}
+
+// Report summarizes a repository check
+type Report struct {
+	Objects    int     `json:"objects"`
+	References int     `json:"references"`
+	Issues     []Issue `json:"issues"`
+}
+
+// OK reports whether the check found no problems
+func (r *Report) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Checker verifies the Safe against the references held in the database
+type Checker struct {
+	db   *badger.DB
+	safe *safe.Safe
+}
+
+// New creates a checker
+func New(db *badger.DB, s *safe.Safe) *Checker {
+	return &Checker{db: db, safe: s}
+}
+
+// Run checks the repository invariants:
+//   - every stored object's ref count equals its live references
+//   - every referenced hash is present in the safe
+//   - every stored object is readable and matches its hash
+//
+// With repair set, ref counts are rebuilt from live references and
+// unreferenced content is removed. Missing and corrupt content cannot be
+// repaired locally and is only reported.
+func (c *Checker) Run(repair bool) (*Report, error) {
+	live, err := c.LiveRefs()
+	if err != nil {
+		return nil, fmt.Errorf("collecting references: %w", err)
+	}
+
+	report := &Report{}
+	for _, n := range live {
+		report.References += int(n)
+	}
+
+	stored := make(map[string]bool)
+	err = c.safe.Walk(func(meta safe.ContentMeta) error {
+		report.Objects++
+		stored[meta.Hash] = true
+		if meta.Remote {
+			// Partial clones fetch this on demand
+			return nil
+		}
+		if err := c.safe.Verify(meta.Hash); err != nil {
+			report.Issues = append(report.Issues, Issue{
+				Kind: KindCorrupt, Hash: meta.Hash, Detail: err.Error(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking safe: %w", err)
+	}
+
+	for hash, n := range live {
+		if !stored[hash] {
+			report.Issues = append(report.Issues, Issue{
+				Kind: KindMissing, Hash: hash, Detail: fmt.Sprintf("%d references", n),
+			})
+		}
+	}
+
+	fixes, err := c.safe.RebuildRefCounts(live, !repair)
+	if err != nil {
+		return nil, fmt.Errorf("rebuilding ref counts: %w", err)
+	}
+	for _, fix := range fixes {
+		issue := Issue{
+			Kind:     KindRefCount,
+			Hash:     fix.Hash,
+			Detail:   fmt.Sprintf("ref count %d, live references %d", fix.Old, fix.New),
+			Repaired: repair,
+		}
+		if fix.New == 0 {
+			issue.Kind = KindOrphan
+			issue.Detail = fmt.Sprintf("ref count %d, no live references", fix.Old)
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	return report, nil
+}
+
+// LiveRefs counts references to each content hash held by changesets,
+// gated changes, tracked file states, stashed changes and release
+// manifests
+func (c *Checker) LiveRefs() (map[string]uint32, error) {
+	live := make(map[string]uint32)
+	add := func(hash string) {
+		if hash != "" {
+			live[hash]++
+		}
+	}
+
+	err := c.db.View(func(txn *badger.Txn) error {
+		if err := scan(txn, "changeset:", func(val []byte) error {
+			var cs change.ChangeSet
+			if err := json.Unmarshal(val, &cs); err != nil {
+				return err
+			}
+			for _, ch := range cs.Changes {
+				add(ch.NewHash)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if err := scan(txn, "gated:", func(val []byte) error {
+			var ch shared.Change
+			if err := json.Unmarshal(val, &ch); err != nil {
+				return err
+			}
+			add(ch.NewHash)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if err := scan(txn, "file_state:", func(val []byte) error {
+			var state change.FileState
+			if err := json.Unmarshal(val, &state); err != nil {
+				return err
+			}
+			add(state.Hash)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		// Stashes are parcel records; only their content hashes matter here
+		if err := scan(txn, "stash:", func(val []byte) error {
+			var stash struct {
+				Files map[string]string `json:"files"`
+			}
+			if err := json.Unmarshal(val, &stash); err != nil {
+				return err
+			}
+			for _, hash := range stash.Files {
+				add(hash)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		return scan(txn, "release:", func(val []byte) error {
+			var tag release.Tag
+			if err := json.Unmarshal(val, &tag); err != nil {
+				return err
+			}
+			add(tag.Manifest)
+			return nil
+		})
+	})
+
+	return live, err
+}
+
+// scan decodes every value under a key prefix
+func scan(txn *badger.Txn, prefix string, fn func(val []byte) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefix)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		if err := item.Value(fn); err != nil {
+			return fmt.Errorf("decoding %s: %w", item.Key(), err)
+		}
+	}
+	return nil
+}
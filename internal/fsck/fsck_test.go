@@ -0,0 +1,121 @@
+// internal/fsck/fsck_test.go
+package fsck
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tig/internal/change"
+	"tig/internal/safe"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func put(t *testing.T, db *badger.DB, key string, v any) {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(key), data)
+	}))
+}
+
+func kinds(r *Report) map[string]int {
+	out := make(map[string]int)
+	for _, issue := range r.Issues {
+		out[issue.Kind]++
+	}
+	return out
+}
+
+func TestRunAndRepair(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	root := t.TempDir()
+	s, err := safe.New(db, safe.Options{Root: root, CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	// Stored three times but referenced once by a changeset and once by
+	// a file state
+	kept, err := s.StoreBatch([][]byte{[]byte("kept"), []byte("kept"), []byte("kept")})
+	require.NoError(t, err)
+	orphan, err := s.Store([]byte("orphan"))
+	require.NoError(t, err)
+	missing := "ab" + kept[0][2:]
+
+	put(t, db, "changeset:cs1", &change.ChangeSet{ID: "cs1", Changes: []shared.Change{
+		{Path: "a.txt", NewHash: kept[0]},
+		{Path: "b.txt", NewHash: missing},
+	}})
+	put(t, db, "file_state:a.txt", &change.FileState{Hash: kept[0]})
+	put(t, db, "gated:c.txt", &shared.Change{Path: "c.txt", Type: "delete"})
+
+	c := New(db, s)
+	report, err := c.Run(false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Objects)
+	assert.Equal(t, 3, report.References)
+	assert.Equal(t, map[string]int{KindRefCount: 1, KindOrphan: 1, KindMissing: 1}, kinds(report))
+
+	report, err = c.Run(true)
+	require.NoError(t, err)
+	for _, issue := range report.Issues {
+		assert.Equal(t, issue.Kind != KindMissing, issue.Repaired, issue.Kind)
+	}
+
+	exists, err := s.Exists(orphan)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Only the missing object remains after repair
+	report, err = c.Run(false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{KindMissing: 1}, kinds(report))
+
+	// Corrupt content is detected
+	require.NoError(t, os.WriteFile(filepath.Join(root, kept[0][:2], kept[0][2:]), []byte("tampered"), 0644))
+	// A fresh safe so the content is not served from cache
+	s, err = safe.New(db, safe.Options{Root: root, CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+	report, err = New(db, s).Run(false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, kinds(report)[KindCorrupt])
+}
+
+func TestStashedContentIsLive(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir()})
+	require.NoError(t, err)
+	defer s.Close()
+
+	stashed, err := s.Store([]byte("uncommitted edit"))
+	require.NoError(t, err)
+	put(t, db, "stash:s1", map[string]any{"stream_id": "s1", "files": map[string]string{"a.txt": stashed}})
+
+	c := New(db, s)
+	live, err := c.LiveRefs()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), live[stashed])
+
+	report, err := c.Run(true)
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	exists, err := s.Exists(stashed)
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
@@ -0,0 +1,97 @@
+// internal/glob/glob.go
+package glob
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Match reports whether name matches the slash-separated pattern.
+// In addition to the path.Match syntax, a "**" segment matches zero
+// or more whole path segments, so "internal/**/*.go" matches both
+// "internal/a.go" and "internal/safe/safe.go".
+func Match(pattern, name string) bool {
+	pattern = filepath.ToSlash(pattern)
+	name = filepath.ToSlash(name)
+	return matchSegments(split(pattern), split(name))
+}
+
+// MatchPath is like Match, except that a pattern without a slash matches
+// any single segment of name, as in .gitignore: "*.log" matches
+// "logs/app.log" and "target" matches "crates/foo/target".
+func MatchPath(pattern, name string) bool {
+	pattern = filepath.ToSlash(pattern)
+	if strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		return Match(pattern, name)
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+	for _, seg := range split(filepath.ToSlash(name)) {
+		if ok, err := path.Match(pattern, seg); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Prefix returns the literal leading directory of a pattern, i.e. the
+// part before the first segment containing a wildcard. It can be used to
+// narrow index scans before applying Match.
+func Prefix(pattern string) string {
+	segs := split(filepath.ToSlash(pattern))
+	var literal []string
+	for _, seg := range segs {
+		if HasMeta(seg) {
+			if len(literal) == 0 {
+				return ""
+			}
+			return strings.Join(literal, "/") + "/"
+		}
+		literal = append(literal, seg)
+	}
+	return strings.Join(literal, "/")
+}
+
+// HasMeta reports whether s contains any glob metacharacters
+func HasMeta(s string) bool {
+	return strings.ContainsAny(s, `*?[\`)
+}
+
+func split(s string) []string {
+	s = strings.Trim(s, "/")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "/")
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse consecutive ** segments
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,48 @@
+// internal/glob/glob_test.go
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"internal/safe/**", "internal/safe/safe.go", true},
+		{"internal/safe/**", "internal/safe/sub/x.go", true},
+		{"internal/safe/**", "internal/safer/x.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/tig/main.go", true},
+		{"**/*.go", "README.md", false},
+		{"internal/**/types.go", "internal/types.go", true},
+		{"internal/**/types.go", "internal/intent/types.go", true},
+		{"*.md", "docs/README.md", false},
+		{"docs/*", "docs/README.md", true},
+		{"cmd/?ig/main.go", "cmd/tig/main.go", true},
+		{"[", "x", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Match(tt.pattern, tt.name), "%s ~ %s", tt.name, tt.pattern)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	assert.True(t, MatchPath("*.log", "logs/app.log"))
+	assert.True(t, MatchPath("target", "crates/foo/target"))
+	assert.True(t, MatchPath("target/", "target"))
+	assert.True(t, MatchPath("build/**", "build/out/a.o"))
+	assert.False(t, MatchPath("build/**", "src/build/a.o"))
+	assert.False(t, MatchPath("*.log", "logs/app.txt"))
+}
+
+func TestPrefix(t *testing.T) {
+	assert.Equal(t, "internal/safe/", Prefix("internal/safe/**"))
+	assert.Equal(t, "", Prefix("**/*.go"))
+	assert.Equal(t, "docs/README.md", Prefix("docs/README.md"))
+}
@@ -0,0 +1,9 @@
+// internal/health/disk_other.go
+//go:build !unix && !windows
+
+package health
+
+// freeSpace is unavailable; the disk check is skipped
+func freeSpace(path string) (uint64, error) {
+	return 0, errUnsupported
+}
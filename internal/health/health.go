@@ -0,0 +1,193 @@
+// internal/health/health.go
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tig/internal/safe"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
+)
+
+// Check and report states, from best to worst
+const (
+	StatusOK       = "ok"
+	StatusSkipped  = "skipped" // The subsystem is not in use
+	StatusDegraded = "degraded"
+	StatusFailing  = "failing"
+)
+
+// errUnsupported is returned where free space cannot be measured
+var errUnsupported = errors.New("unsupported platform")
+
+// Check is the result of probing one subsystem
+type Check struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Message    string  `json:"message,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Report is the outcome of a deep health check. Status is the worst
+// status of any check.
+type Report struct {
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+	Checks []Check   `json:"checks"`
+}
+
+// Healthy reports whether the repository can serve requests. Degraded
+// subsystems still count as healthy.
+func (r *Report) Healthy() bool {
+	return r.Status != StatusFailing
+}
+
+// Checker probes the subsystems a repository depends on
+type Checker struct {
+	db      *badger.DB
+	safe    *safe.Safe
+	minFree uint64
+}
+
+// New creates a checker. The safe's disk is degraded when it has less
+// than minFree bytes available.
+func New(db *badger.DB, s *safe.Safe, minFree uint64) *Checker {
+	return &Checker{db: db, safe: s, minFree: minFree}
+}
+
+// Run performs every check. Each check stops waiting at the context's
+// deadline and is reported as failing.
+func (c *Checker) Run(ctx context.Context) *Report {
+	r := &Report{Status: StatusOK, Time: time.Now()}
+	checks := []struct {
+		name string
+		fn   func() (string, string)
+	}{
+		{"badger", c.badger},
+		{"disk", c.disk},
+		{"content", c.content},
+		{"replication", c.replication},
+	}
+
+	for _, check := range checks {
+		start := time.Now()
+		done := make(chan struct{})
+		var status, message string
+		go func() {
+			status, message = check.fn()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			status, message = StatusFailing, fmt.Sprintf("timed out: %v", ctx.Err())
+		}
+
+		r.Checks = append(r.Checks, Check{
+			Name:       check.name,
+			Status:     status,
+			Message:    message,
+			DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		})
+		if rank(status) > rank(r.Status) {
+			r.Status = status
+		}
+	}
+	return r
+}
+
+func rank(status string) int {
+	switch status {
+	case StatusDegraded:
+		return 1
+	case StatusFailing:
+		return 2
+	}
+	return 0
+}
+
+// badger writes, reads back and deletes a probe key
+func (c *Checker) badger() (string, string) {
+	key := []byte("health:probe:" + uuid.New().String())
+	value := []byte(time.Now().Format(time.RFC3339Nano))
+
+	err := c.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry(key, value).WithTTL(time.Minute))
+	})
+	if err != nil {
+		return StatusFailing, fmt.Sprintf("write: %v", err)
+	}
+
+	err = c.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			if !bytes.Equal(val, value) {
+				return errors.New("value mismatch")
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return StatusFailing, fmt.Sprintf("read: %v", err)
+	}
+
+	if err := c.db.Update(func(txn *badger.Txn) error { return txn.Delete(key) }); err != nil {
+		return StatusDegraded, fmt.Sprintf("delete: %v", err)
+	}
+	return StatusOK, ""
+}
+
+// disk checks the free space where the safe stores content
+func (c *Checker) disk() (string, string) {
+	free, err := freeSpace(c.safe.Root())
+	if errors.Is(err, errUnsupported) {
+		return StatusSkipped, "free space cannot be measured on this platform"
+	}
+	if err != nil {
+		return StatusFailing, err.Error()
+	}
+
+	message := fmt.Sprintf("%d MB free", free>>20)
+	if free < c.minFree {
+		return StatusDegraded, fmt.Sprintf("%s, below the %d MB threshold", message, c.minFree>>20)
+	}
+	return StatusOK, message
+}
+
+// content stores, reads back and deletes a unique blob in the safe
+func (c *Checker) content() (string, string) {
+	probe := []byte("tig health probe " + uuid.New().String())
+
+	hash, err := c.safe.Store(probe)
+	if err != nil {
+		return StatusFailing, fmt.Sprintf("store: %v", err)
+	}
+	data, err := c.safe.Get(hash)
+	if err != nil {
+		c.safe.Delete(hash)
+		return StatusFailing, fmt.Sprintf("read: %v", err)
+	}
+	if !bytes.Equal(data, probe) {
+		c.safe.Delete(hash)
+		return StatusFailing, "read back different content"
+	}
+	if err := c.safe.Delete(hash); err != nil {
+		return StatusDegraded, fmt.Sprintf("delete: %v", err)
+	}
+	return StatusOK, ""
+}
+
+// replication reports on replicas of the repository. Tig does not
+// replicate repositories yet, so the check is always skipped.
+func (c *Checker) replication() (string, string) {
+	return StatusSkipped, "no replicas configured"
+}
@@ -0,0 +1,72 @@
+// internal/health/health_test.go
+package health
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"tig/internal/safe"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newChecker(t *testing.T, minFree uint64) (*Checker, *badger.DB) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return New(db, s, minFree), db
+}
+
+func statuses(r *Report) map[string]string {
+	out := make(map[string]string)
+	for _, c := range r.Checks {
+		out[c.Name] = c.Status
+	}
+	return out
+}
+
+func TestRun(t *testing.T) {
+	c, _ := newChecker(t, 0)
+	r := c.Run(context.Background())
+
+	assert.Equal(t, StatusOK, r.Status)
+	assert.True(t, r.Healthy())
+	assert.Equal(t, map[string]string{
+		"badger":      StatusOK,
+		"disk":        StatusOK,
+		"content":     StatusOK,
+		"replication": StatusSkipped,
+	}, statuses(r))
+
+	// Probes leave nothing behind
+	assert.NoError(t, c.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: []byte("health:")})
+		defer it.Close()
+		it.Rewind()
+		assert.False(t, it.Valid())
+		return nil
+	}))
+}
+
+func TestDegradedAndFailing(t *testing.T) {
+	c, db := newChecker(t, math.MaxUint64)
+	r := c.Run(context.Background())
+	assert.Equal(t, StatusDegraded, r.Status)
+	assert.Equal(t, StatusDegraded, statuses(r)["disk"])
+	assert.True(t, r.Healthy())
+
+	require.NoError(t, db.Close())
+	r = c.Run(context.Background())
+	assert.Equal(t, StatusFailing, r.Status)
+	assert.Equal(t, StatusFailing, statuses(r)["badger"])
+	assert.False(t, r.Healthy())
+}
@@ -0,0 +1,130 @@
+// internal/highlight/highlight.go
+package highlight
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// Style is the chroma style used for terminal colors and the stylesheet
+const Style = "github"
+
+// Highlighter colors source code by language. Languages are detected
+// from file extensions; files in unknown languages and all files of a
+// disabled Highlighter are left plain.
+type Highlighter struct {
+	enabled bool
+	style   *chroma.Style
+}
+
+// New creates a highlighter; enabled false turns highlighting off
+func New(enabled bool) *Highlighter {
+	return &Highlighter{enabled: enabled, style: styles.Get(Style)}
+}
+
+// Enabled reports whether the highlighter colors anything
+func (h *Highlighter) Enabled() bool {
+	return h != nil && h.enabled
+}
+
+// Language returns the name of the language of path, or "" if it is not
+// recognised
+func Language(path string) string {
+	if l := lexers.Match(path); l != nil {
+		return l.Config().Name
+	}
+	return ""
+}
+
+// lines tokenises content as the language of path and splits the tokens
+// into one slice per line, without line endings. It returns nil when the
+// language is unknown.
+func (h *Highlighter) lines(path string, content string) [][]chroma.Token {
+	if !h.Enabled() {
+		return nil
+	}
+	lexer := lexers.Match(path)
+	if lexer == nil {
+		return nil
+	}
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, content)
+	if err != nil {
+		return nil
+	}
+
+	var out [][]chroma.Token
+	for _, line := range chroma.SplitTokensIntoLines(it.Tokens()) {
+		trimmed := line[:0:0]
+		for _, tok := range line {
+			tok.Value = strings.TrimRight(tok.Value, "\r\n")
+			if tok.Value != "" {
+				trimmed = append(trimmed, tok)
+			}
+		}
+		out = append(out, trimmed)
+	}
+	return out
+}
+
+// HTML highlights content as a whole, so constructs spanning lines are
+// colored correctly, and returns one escaped HTML fragment per line.
+// Tokens are wrapped in spans with chroma's class names; see CSS.
+func (h *Highlighter) HTML(path string, content []byte) []template.HTML {
+	text := strings.TrimSuffix(string(content), "\n")
+	plain := strings.Split(text, "\n")
+	if len(content) == 0 {
+		plain = nil
+	}
+
+	out := make([]template.HTML, len(plain))
+	tokens := h.lines(path, text)
+	for n := range out {
+		if tokens == nil || n >= len(tokens) {
+			out[n] = template.HTML(html.EscapeString(plain[n]))
+			continue
+		}
+		var b strings.Builder
+		for _, tok := range tokens[n] {
+			class := chroma.StandardTypes[tok.Type]
+			if class == "" {
+				b.WriteString(html.EscapeString(tok.Value))
+				continue
+			}
+			b.WriteString(`<span class="` + class + `">`)
+			b.WriteString(html.EscapeString(tok.Value))
+			b.WriteString("</span>")
+		}
+		out[n] = template.HTML(b.String())
+	}
+	return out
+}
+
+// Terminal colors a single line with ANSI escapes. Lines are highlighted
+// on their own, so constructs spanning lines may be colored wrongly.
+func (h *Highlighter) Terminal(path, line string) string {
+	tokens := h.lines(path, line)
+	if len(tokens) == 0 {
+		return line
+	}
+	var buf bytes.Buffer
+	if err := formatters.TTY256.Format(&buf, h.style, chroma.Literator(tokens[0]...)); err != nil {
+		return line
+	}
+	return buf.String()
+}
+
+// CSS returns the stylesheet for the classes used by HTML. Highlighted
+// code must sit inside an element with the class "chroma".
+func CSS() template.CSS {
+	var buf bytes.Buffer
+	chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(&buf, styles.Get(Style))
+	return template.CSS(buf.String())
+}
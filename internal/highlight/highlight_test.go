@@ -0,0 +1,52 @@
+// internal/highlight/highlight_test.go
+package highlight
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTML(t *testing.T) {
+	h := New(true)
+	src := "package main\n\n/* a <b>\n   c */\nvar s = \"&\"\n"
+
+	lines := h.HTML("main.go", []byte(src))
+	require.Len(t, lines, 5)
+	assert.Contains(t, string(lines[0]), `<span class="kn">package</span>`)
+	assert.Equal(t, "", string(lines[1]))
+	// The comment spans two lines and is colored on both
+	assert.Contains(t, string(lines[2]), `<span class="cm">/* a &lt;b&gt;</span>`)
+	assert.Contains(t, string(lines[3]), `<span class="cm">`)
+	assert.Contains(t, string(lines[4]), "&amp;")
+	assert.NotContains(t, string(lines[4]), `"&"`)
+}
+
+func TestPlain(t *testing.T) {
+	src := []byte("<x>\n")
+
+	for name, h := range map[string]*Highlighter{"disabled": New(false), "nil": nil} {
+		lines := h.HTML("main.go", src)
+		assert.Equal(t, "&lt;x&gt;", string(lines[0]), name)
+		assert.Equal(t, "x := 1", h.Terminal("main.go", "x := 1"), name)
+	}
+
+	// Unknown languages are escaped but not colored
+	lines := New(true).HTML("notes.unknownext", src)
+	assert.Equal(t, "&lt;x&gt;", string(lines[0]))
+	assert.Empty(t, New(true).HTML("empty.go", nil))
+}
+
+func TestLanguage(t *testing.T) {
+	assert.Equal(t, "Go", Language("cmd/main.go"))
+	assert.Equal(t, "Python", Language("x.py"))
+	assert.Equal(t, "", Language("README.unknownext"))
+}
+
+func TestTerminalAndCSS(t *testing.T) {
+	colored := New(true).Terminal("main.go", "func main() {}")
+	assert.Contains(t, colored, "\x1b[")
+	assert.True(t, strings.Contains(string(CSS()), ".chroma"))
+}
@@ -0,0 +1,253 @@
+// internal/hooks/hooks.go
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"tig/internal/config"
+	"tig/internal/intent"
+	"tig/internal/stream"
+
+	"go.uber.org/zap"
+)
+
+// Events a server-side hook can run on
+const (
+	PreReceiveIntent  = "pre-receive-intent"  // before a pushed intent is stored; may reject it
+	PostReceiveIntent = "post-receive-intent" // after a pushed intent is stored
+	PreMerge          = "pre-merge"           // before an intent lands on a stream; may reject it
+)
+
+// DefaultTimeout bounds a hook without a configured timeout
+const DefaultTimeout = 10 * time.Second
+
+// maxOutput bounds how much of a hook's output is kept as its message
+const maxOutput = 64 << 10
+
+// Payload is what a hook receives as JSON: on stdin for commands, as the
+// request body for webhooks
+type Payload struct {
+	Event  string         `json:"event"`
+	Intent *intent.Intent `json:"intent"`
+	Stream *stream.Stream `json:"stream,omitempty"` // pre-merge only
+	By     string         `json:"by,omitempty"`     // who asked for the merge
+}
+
+// Rejection is returned when a pre- hook rejects an intent. Message is
+// the hook's output, meant to be shown to whoever pushed or merged.
+type Rejection struct {
+	Hook    string
+	Event   string
+	Message string
+}
+
+func (r *Rejection) Error() string {
+	if r.Message == "" {
+		return fmt.Sprintf("rejected by %s hook %s", r.Event, r.Hook)
+	}
+	return fmt.Sprintf("rejected by %s hook %s: %s", r.Event, r.Hook, r.Message)
+}
+
+// hook is a configured hook with its timeout parsed
+type hook struct {
+	cfg     config.Hook
+	timeout time.Duration
+	streams map[string]bool
+}
+
+// Runner runs the server's configured hooks
+type Runner struct {
+	hooks  []hook
+	root   string
+	client *http.Client
+	logger *zap.Logger
+}
+
+// New checks the configured hooks and returns a runner for them. root is
+// the repository, passed to commands as TIG_REPO.
+func New(cfg []config.Hook, root string, logger *zap.Logger) (*Runner, error) {
+	r := &Runner{root: root, client: &http.Client{}, logger: logger}
+	for n, c := range cfg {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", n+1)
+			c.Name = name
+		}
+		switch c.Event {
+		case PreReceiveIntent, PostReceiveIntent, PreMerge:
+		default:
+			return nil, fmt.Errorf("hook %s: unknown event %q", name, c.Event)
+		}
+		if (len(c.Command) == 0) == (c.URL == "") {
+			return nil, fmt.Errorf("hook %s: exactly one of command and url is required", name)
+		}
+		if len(c.Streams) > 0 && c.Event != PreMerge {
+			return nil, fmt.Errorf("hook %s: streams only apply to %s hooks", name, PreMerge)
+		}
+		h := hook{cfg: c, timeout: DefaultTimeout}
+		if c.Timeout != "" {
+			d, err := time.ParseDuration(c.Timeout)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("hook %s: invalid timeout %q", name, c.Timeout)
+			}
+			h.timeout = d
+		}
+		if len(c.Streams) > 0 {
+			h.streams = make(map[string]bool)
+			for _, s := range c.Streams {
+				h.streams[s] = true
+			}
+		}
+		r.hooks = append(r.hooks, h)
+	}
+	return r, nil
+}
+
+// Has reports whether any hook runs on event
+func (r *Runner) Has(event string) bool {
+	if r == nil {
+		return false
+	}
+	for _, h := range r.hooks {
+		if h.cfg.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs the hooks for p.Event in the order they are configured. For
+// pre- events the first hook to fail stops the rest and its *Rejection
+// is returned; a hook that cannot be run rejects too, so a broken hook
+// never waves intents through. Post- hooks cannot reject: their failures
+// are logged and Run returns nil.
+func (r *Runner) Run(ctx context.Context, p Payload) error {
+	if r == nil {
+		return nil
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	for _, h := range r.hooks {
+		if h.cfg.Event != p.Event || !h.matches(p.Stream) {
+			continue
+		}
+		msg, err := r.run(ctx, h, body)
+		if err == nil {
+			continue
+		}
+		if p.Event == PostReceiveIntent {
+			r.logger.Warn("hook failed", zap.String("hook", h.cfg.Name), zap.String("event", p.Event), zap.Error(err), zap.String("output", msg))
+			continue
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return &Rejection{Hook: h.cfg.Name, Event: p.Event, Message: msg}
+	}
+	return nil
+}
+
+func (h hook) matches(st *stream.Stream) bool {
+	if h.streams == nil || st == nil {
+		return true
+	}
+	return h.streams[st.ID] || h.streams[st.Name]
+}
+
+// run runs one hook, returning its trimmed output
+func (r *Runner) run(ctx context.Context, h hook, body []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	if h.cfg.URL != "" {
+		return r.post(ctx, h, body)
+	}
+	return r.exec(ctx, h, body)
+}
+
+// exec runs a command hook in a scratch directory with a minimal
+// environment, so hooks cannot rely on, or disturb, the server's own
+// working directory and credentials. The process is killed when the
+// timeout passes.
+func (r *Runner) exec(ctx context.Context, h hook, body []byte) (string, error) {
+	dir, err := os.MkdirTemp("", "tig-hook-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.CommandContext(ctx, h.cfg.Command[0], h.cfg.Command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"TIG_HOOK=" + h.cfg.Name,
+		"TIG_HOOK_EVENT=" + h.cfg.Event,
+		"TIG_REPO=" + r.root,
+	}
+	cmd.Stdin = bytes.NewReader(body)
+	out := &limitedBuffer{max: maxOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return out.String(), fmt.Errorf("timed out after %s", h.timeout)
+	}
+	return out.String(), err
+}
+
+// post calls a webhook hook, which accepts with any 2xx status. The
+// response body is the hook's message.
+func (r *Runner) post(ctx context.Context, h hook, body []byte) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tig-Hook", h.cfg.Name)
+	req.Header.Set("X-Tig-Event", h.cfg.Event)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxOutput))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return strings.TrimSpace(string(msg)), fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return strings.TrimSpace(string(msg)), nil
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the
+// rest, so a chatty hook cannot exhaust memory
+type limitedBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string { return strings.TrimSpace(b.buf.String()) }
@@ -0,0 +1,95 @@
+// internal/hooks/hooks_test.go
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tig/internal/config"
+	"tig/internal/intent"
+	"tig/internal/stream"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCommandHook(t *testing.T) {
+	r, err := New([]config.Hook{{
+		Name:    "lint",
+		Event:   PreReceiveIntent,
+		Command: []string{"sh", "-c", `grep -q '"type":"fix"' && exit 0; echo "only fixes accepted ($TIG_HOOK_EVENT)"; exit 1`},
+	}}, t.TempDir(), zap.NewNop())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, r.Run(ctx, Payload{Event: PreReceiveIntent, Intent: &intent.Intent{Type: "fix"}}))
+
+	err = r.Run(ctx, Payload{Event: PreReceiveIntent, Intent: &intent.Intent{Type: "feature"}})
+	var rejected *Rejection
+	require.ErrorAs(t, err, &rejected)
+	assert.Equal(t, "lint", rejected.Hook)
+	assert.Equal(t, "only fixes accepted (pre-receive-intent)", rejected.Message)
+
+	// Hooks for other events do not run
+	assert.NoError(t, r.Run(ctx, Payload{Event: PreMerge, Intent: &intent.Intent{Type: "feature"}}))
+}
+
+func TestCommandHookTimeout(t *testing.T) {
+	r, err := New([]config.Hook{{Event: PreMerge, Command: []string{"sleep", "10"}, Timeout: "50ms"}}, t.TempDir(), zap.NewNop())
+	require.NoError(t, err)
+	err = r.Run(context.Background(), Payload{Event: PreMerge, Intent: &intent.Intent{}})
+	assert.ErrorContains(t, err, "timed out after 50ms")
+}
+
+func TestPostHooksCannotReject(t *testing.T) {
+	r, err := New([]config.Hook{{Event: PostReceiveIntent, Command: []string{"false"}}}, t.TempDir(), zap.NewNop())
+	require.NoError(t, err)
+	assert.NoError(t, r.Run(context.Background(), Payload{Event: PostReceiveIntent, Intent: &intent.Intent{}}))
+}
+
+func TestWebhook(t *testing.T) {
+	var got Payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, PreMerge, req.Header.Get("X-Tig-Event"))
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&got))
+		if got.By != "alice" {
+			http.Error(w, "only alice merges to release", http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	r, err := New([]config.Hook{{Name: "release-gate", Event: PreMerge, URL: srv.URL, Streams: []string{"release"}}}, t.TempDir(), zap.NewNop())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	release := &stream.Stream{ID: "s1", Name: "release"}
+	assert.NoError(t, r.Run(ctx, Payload{Event: PreMerge, Intent: &intent.Intent{ID: "i1"}, Stream: release, By: "alice"}))
+	assert.Equal(t, "i1", got.Intent.ID)
+
+	err = r.Run(ctx, Payload{Event: PreMerge, Intent: &intent.Intent{ID: "i1"}, Stream: release, By: "bob"})
+	assert.EqualError(t, err, "rejected by pre-merge hook release-gate: only alice merges to release")
+
+	// Other streams are not gated
+	assert.NoError(t, r.Run(ctx, Payload{Event: PreMerge, Intent: &intent.Intent{ID: "i1"}, Stream: &stream.Stream{Name: "main"}, By: "bob"}))
+}
+
+func TestNewValidation(t *testing.T) {
+	for _, h := range []config.Hook{
+		{Event: "post-merge", Command: []string{"true"}},
+		{Event: PreMerge},
+		{Event: PreMerge, Command: []string{"true"}, URL: "http://example.com"},
+		{Event: PreMerge, Command: []string{"true"}, Timeout: "soon"},
+		{Event: PreReceiveIntent, Command: []string{"true"}, Streams: []string{"main"}},
+	} {
+		_, err := New([]config.Hook{h}, "", zap.NewNop())
+		assert.Error(t, err, "%+v", h)
+	}
+
+	var r *Runner
+	assert.False(t, r.Has(PreMerge))
+	assert.NoError(t, r.Run(context.Background(), Payload{Event: PreMerge}))
+}
@@ -0,0 +1,238 @@
+// internal/ignore/ignore.go
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"tig/internal/glob"
+)
+
+// File is the name of the ignore files read from the repository root and
+// any directory below it
+const File = ".tigignore"
+
+// Defaults are the patterns ignored in every repository, evaluated before
+// watch.exclude and .tigignore files so those can re-include them with a
+// negated pattern. Hidden files are ignored, except ignore files.
+var Defaults = []string{
+	".*",
+	"!" + File,
+	"node_modules/",
+	"vendor/",
+	"dist/",
+	"build/",
+}
+
+// alwaysIgnored directories hold repository metadata and can't be
+// re-included
+var alwaysIgnored = map[string]bool{".git": true, ".tig": true}
+
+// rule is one pattern of an ignore file
+type rule struct {
+	base    string // Slash path of the directory the pattern is relative to
+	pattern string
+	negate  bool
+	dirOnly bool
+	// Patterns with a slash match the whole path below base; others match
+	// the last segment of it at any depth
+	anchored bool
+}
+
+// Matcher decides which paths of a working tree are ignored, using
+// gitignore rules: the built-in defaults, then extra patterns such as
+// watch.exclude, then the .tigignore files of the root and of each
+// directory down to the path. The last matching pattern wins, a "!"
+// pattern re-includes what an earlier one ignored, and nothing below an
+// ignored directory can be re-included.
+//
+// Ignore files are read the first time a path below them is matched and
+// cached until Reload.
+type Matcher struct {
+	root  string
+	rules []rule // Defaults and extra patterns
+
+	mu    sync.RWMutex
+	files map[string][]rule // Directory slash path to its ignore file's rules
+}
+
+// New returns a matcher for the working tree at root, with extra patterns
+// evaluated after the defaults, as if at the top of the root .tigignore
+func New(root string, extra []string) *Matcher {
+	m := &Matcher{root: root, files: make(map[string][]rule)}
+	for _, line := range append(append([]string{}, Defaults...), extra...) {
+		if r, ok := parse("", line); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+	return m
+}
+
+// Reload drops the cached ignore files, so they are read again when next
+// needed. Call it when a .tigignore file changes.
+func (m *Matcher) Reload() {
+	m.mu.Lock()
+	m.files = make(map[string][]rule)
+	m.mu.Unlock()
+}
+
+// Ignored reports whether path, relative to the root or absolute, is
+// ignored. Whether the path is a directory, which patterns ending in "/"
+// depend on, is looked up in the working tree.
+func (m *Matcher) Ignored(name string) bool {
+	return m.ignored(name, nil)
+}
+
+// IgnoredDir is like Ignored for a path known to be a directory or not,
+// as when walking the tree
+func (m *Matcher) IgnoredDir(name string, isDir bool) bool {
+	return m.ignored(name, &isDir)
+}
+
+func (m *Matcher) ignored(name string, isDir *bool) bool {
+	if filepath.IsAbs(name) {
+		rel, err := filepath.Rel(m.root, name)
+		if err != nil {
+			return true
+		}
+		name = rel
+	}
+	name = filepath.ToSlash(filepath.Clean(name))
+	if name == "." {
+		return false
+	}
+	if name == "" || name == ".." || strings.HasPrefix(name, "../") {
+		return true
+	}
+
+	segs := strings.Split(name, "/")
+	for i, seg := range segs {
+		if alwaysIgnored[seg] {
+			return true
+		}
+		last := i == len(segs)-1
+		dir := !last
+		if last {
+			if isDir != nil {
+				dir = *isDir
+			} else if info, err := os.Stat(filepath.Join(m.root, filepath.FromSlash(name))); err == nil {
+				dir = info.IsDir()
+			}
+		}
+		// A directory that is ignored takes everything below it along
+		if m.match(strings.Join(segs[:i+1], "/"), segs[:i], dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// match applies the rules to one path, given the directories above it
+func (m *Matcher) match(name string, parents []string, isDir bool) bool {
+	ignored := false
+	apply := func(rules []rule) {
+		for _, r := range rules {
+			if r.matches(name, isDir) {
+				ignored = !r.negate
+			}
+		}
+	}
+
+	apply(m.rules)
+	for i := 0; i <= len(parents); i++ {
+		apply(m.file(strings.Join(parents[:i], "/")))
+	}
+	return ignored
+}
+
+func (r rule) matches(name string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(name, r.base+"/") {
+			return false
+		}
+		name = strings.TrimPrefix(name, r.base+"/")
+	}
+	if r.anchored {
+		return glob.Match(r.pattern, name)
+	}
+	ok, err := path.Match(r.pattern, path.Base(name))
+	return err == nil && ok
+}
+
+// file returns the rules of the ignore file in dir, a slash path relative
+// to the root, reading it on first use
+func (m *Matcher) file(dir string) []rule {
+	m.mu.RLock()
+	rules, ok := m.files[dir]
+	m.mu.RUnlock()
+	if ok {
+		return rules
+	}
+
+	// A missing or unreadable ignore file ignores nothing
+	patterns, _ := ReadFile(filepath.Join(m.root, filepath.FromSlash(dir), File))
+	for _, line := range patterns {
+		if r, ok := parse(dir, line); ok {
+			rules = append(rules, r)
+		}
+	}
+
+	m.mu.Lock()
+	m.files[dir] = rules
+	m.mu.Unlock()
+	return rules
+}
+
+// parse reads one line of an ignore file found in the directory base
+func parse(base, line string) (rule, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{base: base}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	r.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return rule{}, false
+	}
+	r.pattern = line
+	return r, true
+}
+
+// ReadFile returns the patterns of an ignore file, skipping blank lines
+// and # comments
+func ReadFile(name string) ([]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, sc.Err()
+}
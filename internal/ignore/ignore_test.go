@@ -0,0 +1,86 @@
+// internal/ignore/ignore_test.go
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write(File, "# build output\n*.log\n!keep.log\n/out/\ndocs/**/*.tmp\n!vendor/\nvendor/cache/\n")
+	write("src/"+File, "generated.go\n/local.txt\n")
+	write("out/a.txt", "")
+	write("src/out/a.txt", "")
+
+	m := New(root, []string{"target"})
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{File, false},
+		{"src/" + File, false},
+		{".env", true},
+		{".tig/config.json", true},
+		{".git/HEAD", true},
+		{"node_modules/x/index.js", true},
+		{"dist/app.js", true},
+		{"app.log", true},
+		{"logs/app.log", true},
+		{"logs/keep.log", false},
+		{"out/a.txt", true},
+		{"src/out/a.txt", false}, // Anchored to the root
+		{"docs/a/b/c.tmp", true},
+		{"docs/c.md", false},
+		{"vendor/lib/lib.go", false}, // Re-included by !vendor/
+		{"vendor/cache/x", true},
+		{"target/debug/app", true},
+		{"crates/foo/target", true},
+		{"src/generated.go", true},
+		{"src/pkg/generated.go", true},
+		{"generated.go", false}, // Only below src
+		{"src/local.txt", true},
+		{"src/pkg/local.txt", false},
+		{filepath.Join(root, "app.log"), true},
+		{"../outside.txt", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, m.Ignored(filepath.FromSlash(tt.path)), tt.path)
+	}
+
+	// A file named like a directory-only pattern is not ignored
+	assert.False(t, m.IgnoredDir("out", false))
+	assert.True(t, m.IgnoredDir("out", true))
+
+	// Ignore files are cached until reloaded
+	write(File, "*.md\n")
+	assert.False(t, m.Ignored("README.md"))
+	m.Reload()
+	assert.True(t, m.Ignored("README.md"))
+	assert.False(t, m.Ignored("app.log"))
+}
+
+func TestParse(t *testing.T) {
+	r, ok := parse("", "!/build/")
+	require.True(t, ok)
+	assert.Equal(t, rule{pattern: "build", negate: true, dirOnly: true, anchored: true}, r)
+
+	r, ok = parse("sub", `\#notes`)
+	require.True(t, ok)
+	assert.Equal(t, rule{base: "sub", pattern: "#notes"}, r)
+
+	for _, line := range []string{"", "   ", "# comment", "/"} {
+		_, ok := parse("", line)
+		assert.False(t, ok, line)
+	}
+}
@@ -0,0 +1,134 @@
+// internal/importer/hg.go
+package importer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HgDefault is Mercurial's default branch
+const HgDefault = "default"
+
+// hgTemplate prints one record per changeset, fields separated by US and
+// file names by RS, so that messages may contain anything but NUL
+const hgTemplate = `{node}\x1f{branch}\x1f{author}\x1f{date|hgdate}\x1f{desc}\x1f` +
+	`{file_adds % "{file}\x1e"}\x1f{file_mods % "{file}\x1e"}\x1f{file_dels % "{file}\x1e"}\x00`
+
+// HgRepo reads revisions from a Mercurial repository with the hg command
+type HgRepo struct {
+	Dir string
+
+	// run executes hg with the given arguments; tests replace it
+	run func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// NewHgRepo returns a source reading the repository in dir
+func NewHgRepo(dir string) *HgRepo {
+	r := &HgRepo{Dir: dir}
+	r.run = func(ctx context.Context, args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "hg", append([]string{"--repository", r.Dir, "--noninteractive"}, args...)...)
+		cmd.Env = append(cmd.Environ(), "HGPLAIN=1")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("hg %s: %s", args[0], msg)
+			}
+			return nil, fmt.Errorf("hg %s: %w", args[0], err)
+		}
+		return out, nil
+	}
+	return r
+}
+
+// ID identifies the repository by its absolute path
+func (r *HgRepo) ID() string {
+	if abs, err := filepath.Abs(r.Dir); err == nil {
+		return "hg:" + abs
+	}
+	return "hg:" + r.Dir
+}
+
+// DefaultBranch is Mercurial's default branch
+func (r *HgRepo) DefaultBranch() string { return HgDefault }
+
+// Revisions lists changesets in revision order with hg log and reads the
+// added and modified files of each with hg cat
+func (r *HgRepo) Revisions(ctx context.Context, after string, fn func(Revision) error) error {
+	revset := "all()"
+	if after != "" {
+		revset = fmt.Sprintf("%s: and not %s", after, after)
+	}
+	out, err := r.run(ctx, "log", "--rev", revset, "--template", hgTemplate)
+	if err != nil {
+		return err
+	}
+	revs, err := parseHgLog(out)
+	if err != nil {
+		return err
+	}
+
+	for _, rev := range revs {
+		for n, c := range rev.Changes {
+			if c.Delete {
+				continue
+			}
+			content, err := r.run(ctx, "cat", "--rev", rev.ID, "path:"+c.Path)
+			if err != nil {
+				return fmt.Errorf("reading %s at %s: %w", c.Path, rev.ID, err)
+			}
+			rev.Changes[n].Content = content
+		}
+		if err := fn(rev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseHgLog decodes the output of hg log with hgTemplate
+func parseHgLog(out []byte) ([]Revision, error) {
+	var revs []Revision
+	for _, record := range bytes.Split(out, []byte{0}) {
+		if len(bytes.TrimSpace(record)) == 0 {
+			continue
+		}
+		fields := strings.Split(string(record), "\x1f")
+		if len(fields) != 8 {
+			return nil, fmt.Errorf("malformed hg log record %q", record)
+		}
+
+		// hgdate is "<unix seconds> <offset west of UTC in seconds>"
+		secs, offset, _ := strings.Cut(fields[3], " ")
+		unix, err := strconv.ParseFloat(secs, 64)
+		if err != nil {
+			return nil, fmt.Errorf("changeset %s: invalid date %q", fields[0], fields[3])
+		}
+		west, _ := strconv.Atoi(offset)
+		at := time.Unix(int64(unix), 0).In(time.FixedZone("", -west))
+
+		rev := Revision{
+			ID:      fields[0],
+			Branch:  fields[1],
+			Author:  fields[2],
+			Time:    at,
+			Message: fields[4],
+		}
+		for n, del := range []bool{false, false, true} {
+			for _, path := range strings.Split(fields[5+n], "\x1e") {
+				if path != "" {
+					rev.Changes = append(rev.Changes, FileChange{Path: path, Delete: del})
+				}
+			}
+		}
+		revs = append(revs, rev)
+	}
+	return revs, nil
+}
@@ -0,0 +1,57 @@
+// internal/importer/hg_test.go
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHgRepo(t *testing.T) {
+	log := "1111\x1fdefault\x1fAlice <alice@example.com>\x1f1577880000 -3600\x1fInitial\x1fa.txt\x1eb.txt\x1e\x1f\x1f\x00" +
+		"2222\x1fstable\x1fBob <bob@example.com>\x1f1577883600 0\x1fFix\nwith details\x1f\x1fa.txt\x1e\x1fb.txt\x1e\x00"
+
+	var calls []string
+	r := NewHgRepo("repo")
+	r.run = func(ctx context.Context, args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args[:3], " "))
+		switch args[0] {
+		case "log":
+			return []byte(log), nil
+		case "cat":
+			return []byte(fmt.Sprintf("%s@%s", args[3], args[2])), nil
+		}
+		return nil, fmt.Errorf("unexpected hg %s", args[0])
+	}
+
+	var revs []Revision
+	require.NoError(t, r.Revisions(context.Background(), "", func(rev Revision) error {
+		revs = append(revs, rev)
+		return nil
+	}))
+	require.Len(t, revs, 2)
+	assert.Equal(t, "Alice <alice@example.com>", revs[0].Author)
+	assert.True(t, revs[0].Time.Equal(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)))
+	_, offset := revs[0].Time.Zone()
+	assert.Equal(t, 3600, offset)
+	assert.Equal(t, []FileChange{
+		{Path: "a.txt", Content: []byte("path:a.txt@1111")},
+		{Path: "b.txt", Content: []byte("path:b.txt@1111")},
+	}, revs[0].Changes)
+	assert.Equal(t, "stable", revs[1].Branch)
+	assert.Equal(t, "Fix\nwith details", revs[1].Message)
+	assert.Equal(t, []FileChange{
+		{Path: "a.txt", Content: []byte("path:a.txt@2222")},
+		{Path: "b.txt", Delete: true},
+	}, revs[1].Changes)
+	assert.Equal(t, []string{"log --rev all()", "cat --rev 1111", "cat --rev 1111", "cat --rev 2222"}, calls)
+
+	calls = nil
+	require.NoError(t, r.Revisions(context.Background(), "1111", func(Revision) error { return nil }))
+	assert.Equal(t, "log --rev 1111: and not 1111", calls[0])
+}
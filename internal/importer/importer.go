@@ -0,0 +1,374 @@
+// internal/importer/importer.go
+package importer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/parcel"
+	"tig/internal/safe"
+	"tig/internal/storage"
+	"tig/shared/types"
+	"tig/shared/utils"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Key prefixes of import checkpoints. An import's progress is stored under
+// "import:<id>" and the files of each branch, as last imported, under
+// "import_tree:<id>\x00<branch>\x00<path>".
+const (
+	checkpointPrefix = "import:"
+	treePrefix       = "import_tree:"
+)
+
+// Revision is one commit of the repository being imported
+type Revision struct {
+	ID      string // Revision identifier in the source, e.g. an hg node or svn revision number
+	Branch  string
+	Author  string
+	Time    time.Time
+	Message string
+	Changes []FileChange
+}
+
+// FileChange is a file added, modified or deleted by a revision. Content
+// is the file's new content, unless Hash names content that an earlier
+// revision of the same import already stored.
+type FileChange struct {
+	Path    string // Slash-separated, relative to the branch root
+	Delete  bool
+	Content []byte
+	Hash    string
+	Mode    int
+}
+
+// Source reads revisions from another version control system
+type Source interface {
+	// ID identifies the repository being imported, so an interrupted
+	// import can resume
+	ID() string
+	// DefaultBranch is the branch whose files become the tracked files
+	DefaultBranch() string
+	// Revisions calls fn with every revision after the one with ID after,
+	// or from the first if after is empty, oldest first
+	Revisions(ctx context.Context, after string, fn func(Revision) error) error
+}
+
+// Options controls an import
+type Options struct {
+	Type     string         // Type of the generated intents, "import" by default
+	StreamID string         // Stream for the default branch; by default the one named after it
+	Checkout bool           // Write the default branch into the working tree, which must be clean
+	Progress func(Progress) // Called after each revision
+}
+
+// Progress reports an import's advance
+type Progress struct {
+	Revision Revision
+	Imported int // Revisions imported so far, including earlier runs
+	Skipped  bool
+	IntentID string
+}
+
+// Result summarizes an import
+type Result struct {
+	Imported int               `json:"imported"` // Revisions imported by this run
+	Resumed  string            `json:"resumed,omitempty"`
+	Streams  map[string]string `json:"streams"` // Stream ID per branch
+}
+
+type checkpoint struct {
+	Source    string    `json:"source"`
+	Last      string    `json:"last"`
+	Revisions int       `json:"revisions"`
+	Updated   time.Time `json:"updated"`
+}
+
+// Run imports the revisions of src into the repository, resuming after
+// the last revision a previous run imported. Each revision becomes a
+// changeset with an intent, added to the stream named after its branch,
+// which is created if needed. Only the default branch updates the tracked
+// files; other branches are recorded as history.
+func Run(ctx context.Context, p *parcel.Parcel, src Source, opts Options) (*Result, error) {
+	if opts.Type == "" {
+		opts.Type = "import"
+	}
+	var before map[string]change.FileState
+	if opts.Checkout {
+		if err := p.RequireCleanTree(); err != nil {
+			return nil, err
+		}
+		var err error
+		if before, err = p.FileStates(); err != nil {
+			return nil, err
+		}
+	}
+
+	id := sourceKey(src.ID())
+	cp, trees, err := loadCheckpoint(p.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	cp.Source = src.ID()
+	result := &Result{Resumed: cp.Last, Streams: make(map[string]string)}
+	if opts.StreamID != "" {
+		result.Streams[src.DefaultBranch()] = opts.StreamID
+	}
+
+	err = src.Revisions(ctx, cp.Last, func(rev Revision) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if rev.Branch == "" {
+			rev.Branch = src.DefaultBranch()
+		}
+		tree := trees[rev.Branch]
+		if tree == nil {
+			tree = make(map[string]string)
+			trees[rev.Branch] = tree
+		}
+
+		changes, err := toChanges(p, rev, tree)
+		if err != nil {
+			return fmt.Errorf("revision %s: %w", rev.ID, err)
+		}
+
+		cp.Last = rev.ID
+		cp.Revisions++
+		cp.Updated = time.Now()
+		save := func(txn *badger.Txn) error {
+			if err := saveTree(txn, id, rev.Branch, changes); err != nil {
+				return err
+			}
+			return putCheckpoint(txn, id, cp)
+		}
+
+		progress := Progress{Revision: rev, Imported: cp.Revisions}
+		if len(changes) == 0 {
+			progress.Skipped = true
+			if err := p.DB.Update(save); err != nil {
+				return fmt.Errorf("saving import checkpoint: %w", err)
+			}
+		} else {
+			streamID, err := branchStream(p, rev.Branch, result.Streams)
+			if err != nil {
+				return err
+			}
+			description := firstLine(rev.Message)
+			if description == "" {
+				description = "Import revision " + rev.ID
+			}
+			i, _, err := p.CommitChanges(changes, parcel.CommitOptions{
+				Description: description,
+				Type:        opts.Type,
+				StreamID:    streamID,
+				Time:        rev.Time,
+				Author:      rev.Author,
+				Detached:    rev.Branch != src.DefaultBranch(),
+				Also:        func(u *storage.UnitOfWork) error { return save(u.Txn()) },
+			})
+			if err != nil {
+				return fmt.Errorf("importing revision %s: %w", rev.ID, err)
+			}
+			progress.IntentID = i.ID
+		}
+		for _, c := range changes {
+			if c.Type == "delete" {
+				delete(tree, filepath.ToSlash(c.Path))
+			} else {
+				tree[filepath.ToSlash(c.Path)] = c.NewHash
+			}
+		}
+
+		result.Imported++
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if opts.Checkout {
+		if err := p.CheckoutTracked(before); err != nil {
+			return result, fmt.Errorf("checking out %s: %w", src.DefaultBranch(), err)
+		}
+	}
+	return result, nil
+}
+
+// toChanges turns a revision's file changes into tig changes against the
+// branch's files, storing new content in the safe in one atomic batch
+func toChanges(p *parcel.Parcel, rev Revision, tree map[string]string) ([]shared.Change, error) {
+	var changes []shared.Change
+	var paths []string
+	var contents [][]byte
+	for _, fc := range rev.Changes {
+		old := tree[fc.Path]
+		c := shared.Change{
+			Path:    filepath.FromSlash(fc.Path),
+			OldHash: old,
+			Mode:    fc.Mode,
+			ModTime: rev.Time,
+			Gated:   true,
+		}
+		if fc.Delete {
+			if old == "" {
+				continue
+			}
+			c.Type = "delete"
+			changes = append(changes, c)
+			continue
+		}
+
+		content := fc.Content
+		if content == nil && fc.Hash != "" {
+			var err error
+			if content, err = p.Safe.Get(fc.Hash); err != nil {
+				return nil, fmt.Errorf("reading %s: %w", fc.Path, err)
+			}
+		}
+		c.NewHash = utils.HashContent(content)
+		c.Size = int64(len(content))
+		switch {
+		case old == "":
+			c.Type = "add"
+		case old != c.NewHash:
+			c.Type = "modify"
+		default:
+			continue
+		}
+		// Every stored reference counts, so unchanged content is not stored again
+		paths = append(paths, fc.Path)
+		contents = append(contents, content)
+		if c.Mode == 0 {
+			c.Mode = 0644
+		}
+		changes = append(changes, c)
+	}
+
+	_, err := p.Safe.StoreBatchWithOptions(contents, safe.BatchOptions{Atomic: true})
+	var batchErr *safe.BatchError
+	if errors.As(err, &batchErr) {
+		n := batchErr.Failed()[0]
+		return nil, fmt.Errorf("storing %s: %w", paths[n], batchErr.Errors[n])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storing content: %w", err)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// branchStream returns the stream a branch's intents are added to,
+// creating it on first use
+func branchStream(p *parcel.Parcel, branch string, streams map[string]string) (string, error) {
+	if id, ok := streams[branch]; ok {
+		return id, nil
+	}
+	existing, err := p.ListStreams()
+	if err != nil {
+		return "", err
+	}
+	for _, s := range existing {
+		if s.Name == branch {
+			streams[branch] = s.ID
+			return s.ID, nil
+		}
+	}
+	s, err := p.CreateStream(branch, "feature")
+	if err != nil {
+		return "", fmt.Errorf("creating stream for branch %s: %w", branch, err)
+	}
+	streams[branch] = s.ID
+	return s.ID, nil
+}
+
+func sourceKey(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:8])
+}
+
+func loadCheckpoint(db *badger.DB, id string) (checkpoint, map[string]map[string]string, error) {
+	var cp checkpoint
+	trees := make(map[string]map[string]string)
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(checkpointPrefix + id))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &cp)
+		}); err != nil {
+			return fmt.Errorf("decoding import checkpoint: %w", err)
+		}
+
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(treePrefix + id + "\x00")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			parts := bytes.SplitN(bytes.TrimPrefix(it.Item().Key(), opts.Prefix), []byte{0}, 2)
+			if len(parts) != 2 {
+				continue
+			}
+			hash, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			branch := string(parts[0])
+			if trees[branch] == nil {
+				trees[branch] = make(map[string]string)
+			}
+			trees[branch][string(parts[1])] = string(hash)
+		}
+		return nil
+	})
+	if err != nil {
+		return cp, nil, fmt.Errorf("reading import checkpoint: %w", err)
+	}
+	return cp, trees, nil
+}
+
+func putCheckpoint(txn *badger.Txn, id string, cp checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("marshaling import checkpoint: %w", err)
+	}
+	return txn.Set([]byte(checkpointPrefix+id), data)
+}
+
+func saveTree(txn *badger.Txn, id, branch string, changes []shared.Change) error {
+	for _, c := range changes {
+		key := []byte(treePrefix + id + "\x00" + branch + "\x00" + filepath.ToSlash(c.Path))
+		var err error
+		if c.Type == "delete" {
+			err = txn.Delete(key)
+		} else {
+			err = txn.Set(key, []byte(c.NewHash))
+		}
+		if err != nil {
+			return fmt.Errorf("saving import checkpoint: %w", err)
+		}
+	}
+	return nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := bytes.Cut(bytes.TrimSpace([]byte(s)), []byte("\n"))
+	return string(bytes.TrimSpace(line))
+}
@@ -0,0 +1,255 @@
+// internal/importer/importer_test.go
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/parcel"
+	"tig/shared/utils"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// dump builds an SVN dump file from revisions of node records
+type dump struct{ strings.Builder }
+
+func newDump() *dump {
+	d := &dump{}
+	d.WriteString("SVN-fs-dump-format-version: 2\n\nUUID: 7bf7a5ef-cabf-0310-b7d4-93df341afa7e\n\n")
+	return d
+}
+
+func props(kv ...string) string {
+	var b strings.Builder
+	for n := 0; n < len(kv); n += 2 {
+		fmt.Fprintf(&b, "K %d\n%s\nV %d\n%s\n", len(kv[n]), kv[n], len(kv[n+1]), kv[n+1])
+	}
+	b.WriteString("PROPS-END\n")
+	return b.String()
+}
+
+func (d *dump) revision(n int, author, log string) {
+	p := props("svn:author", author, "svn:date", fmt.Sprintf("2019-0%d-01T12:00:00.000000Z", n), "svn:log", log)
+	fmt.Fprintf(d, "Revision-number: %d\nProp-content-length: %d\nContent-length: %d\n\n%s\n", n, len(p), len(p), p)
+}
+
+func (d *dump) node(headers string, text *string) {
+	d.WriteString(headers)
+	if text != nil {
+		fmt.Fprintf(d, "Text-content-length: %d\nContent-length: %d\n\n%s\n\n", len(*text), len(*text), *text)
+		return
+	}
+	d.WriteString("\n\n")
+}
+
+func text(s string) *string { return &s }
+
+func newParcel(t *testing.T) *parcel.Parcel {
+	root := t.TempDir()
+	require.NoError(t, parcel.Initialize(root))
+	p, err := parcel.New(root, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func tracked(t *testing.T, p *parcel.Parcel) map[string]string {
+	states, err := p.FileStates()
+	require.NoError(t, err)
+	files := make(map[string]string, len(states))
+	for path, state := range states {
+		content, err := p.Safe.Get(state.Hash)
+		require.NoError(t, err)
+		files[filepath.ToSlash(path)] = string(content)
+	}
+	return files
+}
+
+func TestSVNDump(t *testing.T) {
+	d := newDump()
+	d.revision(1, "alice", "Create layout")
+	d.node("Node-path: trunk\nNode-kind: dir\nNode-action: add\n", nil)
+	d.node("Node-path: branches\nNode-kind: dir\nNode-action: add\n", nil)
+	d.node("Node-path: tags\nNode-kind: dir\nNode-action: add\n", nil)
+	d.revision(2, "alice", "Add sources\n\nFirst cut.")
+	d.node("Node-path: trunk/main.c\nNode-kind: file\nNode-action: add\n", text("int main() {}\n"))
+	d.node("Node-path: trunk/lib/util.c\nNode-kind: file\nNode-action: add\n", text("void util() {}\n"))
+	d.node("Node-path: trunk/run.sh\nNode-kind: file\nNode-action: add\nProp-content-length: 36\n", nil)
+	d.revision(3, "bob", "Branch for 1.0")
+	d.node("Node-path: branches/1.0\nNode-kind: dir\nNode-action: add\nNode-copyfrom-rev: 2\nNode-copyfrom-path: trunk\n", nil)
+	d.node("Node-path: tags/v1\nNode-kind: dir\nNode-action: add\nNode-copyfrom-rev: 2\nNode-copyfrom-path: trunk\n", nil)
+	d.revision(4, "bob", "Fix on trunk")
+	d.node("Node-path: trunk/main.c\nNode-kind: file\nNode-action: change\n", text("int main() { return 0; }\n"))
+	d.node("Node-path: trunk/lib\nNode-action: delete\n", nil)
+	d.revision(5, "carol", "Fix on the branch")
+	d.node("Node-path: branches/1.0/main.c\nNode-kind: file\nNode-action: change\n", text("int main() { return 1; }\n"))
+
+	// run.sh carries a property block and no text
+	dumped := strings.Replace(d.String(), "Prop-content-length: 36\n\n\n",
+		"Prop-content-length: 36\nContent-length: 36\n\n"+props("svn:executable", "*")+"\n", 1)
+	require.Len(t, props("svn:executable", "*"), 36)
+	path := filepath.Join(t.TempDir(), "repo.dump")
+	require.NoError(t, os.WriteFile(path, []byte(dumped), 0644))
+	src := &SVNDump{Path: path}
+	assert.Equal(t, "svn:7bf7a5ef-cabf-0310-b7d4-93df341afa7e", src.ID())
+
+	var revs []Revision
+	require.NoError(t, src.Revisions(context.Background(), "", func(r Revision) error {
+		revs = append(revs, r)
+		return nil
+	}))
+	require.Len(t, revs, 5)
+	assert.Empty(t, revs[0].Changes)
+	assert.Equal(t, "alice", revs[1].Author)
+	assert.Equal(t, time.Date(2019, 2, 1, 12, 0, 0, 0, time.UTC), revs[1].Time)
+	assert.Equal(t, []FileChange{
+		{Path: "main.c", Hash: revs[1].Changes[0].Hash, Content: []byte("int main() {}\n"), Mode: 0644},
+		{Path: "lib/util.c", Hash: revs[1].Changes[1].Hash, Content: []byte("void util() {}\n"), Mode: 0644},
+		{Path: "run.sh", Hash: revs[1].Changes[2].Hash, Content: []byte{}, Mode: 0755},
+	}, revs[1].Changes)
+	assert.Equal(t, "1.0", revs[2].Branch, "tags are skipped")
+	assert.Len(t, revs[2].Changes, 3)
+	assert.Nil(t, revs[2].Changes[0].Content, "copies refer to stored content")
+	assert.Equal(t, []FileChange{{Path: "lib/util.c", Delete: true}}, revs[3].Changes[1:])
+
+	p := newParcel(t)
+	var progress []Progress
+	result, err := Run(context.Background(), p, src, Options{Checkout: true, Progress: func(pr Progress) {
+		progress = append(progress, pr)
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Imported)
+	assert.Len(t, result.Streams, 2)
+	assert.True(t, progress[0].Skipped)
+	assert.Equal(t, 5, progress[4].Imported)
+
+	// Only trunk becomes the tracked files
+	assert.Equal(t, map[string]string{"main.c": "int main() { return 0; }\n", "run.sh": ""}, tracked(t, p))
+	data, err := os.ReadFile(filepath.Join(p.Root, "main.c"))
+	require.NoError(t, err)
+	assert.Equal(t, "int main() { return 0; }\n", string(data))
+
+	// The branch's fix is recorded against the branch's own file
+	i, err := p.IntentStore.Get(progress[4].IntentID)
+	require.NoError(t, err)
+	assert.Equal(t, "Fix on the branch", i.Description)
+	assert.Equal(t, "carol", i.Metadata.Author)
+	require.NoError(t, p.DB.View(func(txn *badger.Txn) error {
+		cs, err := change.GetChangeSet(txn, i.ChangeSetID)
+		require.NoError(t, err)
+		require.Len(t, cs.Changes, 1)
+		assert.Equal(t, revs[1].Changes[0].Hash, cs.Changes[0].OldHash)
+		return nil
+	}))
+	branch, err := p.GetStream(result.Streams["1.0"])
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", branch.Name)
+	intents, err := p.GetStreamIntents(branch.ID)
+	require.NoError(t, err)
+	assert.Len(t, intents, 2)
+
+	// Running again finds nothing new
+	again, err := Run(context.Background(), p, src, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.Imported)
+	assert.Equal(t, "5", again.Resumed)
+}
+
+// flakySource fails once after yielding some revisions
+type flakySource struct {
+	revs   []Revision
+	failAt int
+}
+
+func (s *flakySource) ID() string            { return "test:flaky" }
+func (s *flakySource) DefaultBranch() string { return "main" }
+
+func (s *flakySource) Revisions(ctx context.Context, after string, fn func(Revision) error) error {
+	skipping := after != ""
+	for n, r := range s.revs {
+		if skipping {
+			skipping = r.ID != after
+			continue
+		}
+		if n == s.failAt {
+			s.failAt = -1
+			return errors.New("connection reset")
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRunResumes(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := &flakySource{failAt: 2, revs: []Revision{
+		{ID: "a", Time: at, Message: "one", Changes: []FileChange{{Path: "f", Content: []byte("1")}}},
+		{ID: "b", Time: at.Add(time.Hour), Message: "two", Changes: []FileChange{{Path: "g", Content: []byte("2")}}},
+		{ID: "c", Time: at.Add(2 * time.Hour), Message: "three", Changes: []FileChange{{Path: "f", Delete: true}}},
+	}}
+	p := newParcel(t)
+
+	result, err := Run(context.Background(), p, src, Options{})
+	require.Error(t, err)
+	assert.Equal(t, 2, result.Imported)
+
+	result, err = Run(context.Background(), p, src, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "b", result.Resumed)
+	assert.Equal(t, 1, result.Imported)
+	assert.Equal(t, map[string]string{"g": "2"}, tracked(t, p))
+
+	intents, err := p.GetStreamIntents(result.Streams["main"])
+	require.NoError(t, err)
+	assert.Len(t, intents, 3)
+}
+
+func TestToChanges(t *testing.T) {
+	p := newParcel(t)
+
+	same := []byte("same\n")
+	tree := map[string]string{
+		"b.txt": utils.HashContent([]byte("old\n")),
+		"c.txt": utils.HashContent(same),
+		"d.txt": utils.HashContent([]byte("gone\n")),
+	}
+	rev := Revision{ID: "1", Time: time.Now(), Changes: []FileChange{
+		{Path: "c.txt", Content: same},
+		{Path: "b.txt", Content: []byte("new\n"), Mode: 0755},
+		{Path: "a.txt", Content: []byte("a\n")},
+		{Path: "d.txt", Delete: true},
+		{Path: "e.txt", Delete: true},
+	}}
+	changes, err := toChanges(p, rev, tree)
+	require.NoError(t, err)
+
+	var kinds []string
+	for _, c := range changes {
+		kinds = append(kinds, c.Path+" "+c.Type)
+	}
+	assert.Equal(t, []string{"a.txt add", "b.txt modify", "d.txt delete"}, kinds)
+	assert.Equal(t, 0755, changes[1].Mode)
+
+	// New content is stored once; unchanged content is not stored again
+	for _, c := range changes[:2] {
+		meta, err := p.Safe.Meta(c.NewHash)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), meta.RefCount)
+	}
+	exists, err := p.Safe.Exists(tree["c.txt"])
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
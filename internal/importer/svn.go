@@ -0,0 +1,476 @@
+// internal/importer/svn.go
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tig/internal/errors"
+	"tig/shared/utils"
+)
+
+// SVNTrunk is the branch that files under trunk/, or every file of a
+// repository without the standard layout, are imported onto
+const SVNTrunk = "trunk"
+
+// SVNDump reads revisions from a Subversion dump file, as written by
+// svnadmin dump or svnrdump dump without --deltas. Files under trunk/ and
+// branches/<name>/ become the branches' files; tags/ is skipped.
+type SVNDump struct {
+	Path string
+}
+
+// ID identifies the dump by its repository UUID, or by its path if it
+// has none
+func (d *SVNDump) ID() string {
+	f, err := os.Open(d.Path)
+	if err != nil {
+		return "svn:" + d.Path
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		headers, err := readHeaders(r)
+		if err != nil || headers == nil {
+			break
+		}
+		if uuid := headers["UUID"]; uuid != "" {
+			return "svn:" + uuid
+		}
+		if _, ok := headers["Revision-number"]; ok {
+			break
+		}
+	}
+	if abs, err := filepath.Abs(d.Path); err == nil {
+		return "svn:" + abs
+	}
+	return "svn:" + d.Path
+}
+
+// DefaultBranch is trunk
+func (d *SVNDump) DefaultBranch() string { return SVNTrunk }
+
+// Revisions reads the whole dump, since a revision may copy files from
+// any earlier one, and calls fn with the revisions after after
+func (d *SVNDump) Revisions(ctx context.Context, after string, fn func(Revision) error) error {
+	f, err := os.Open(d.Path)
+	if err != nil {
+		return fmt.Errorf("opening dump: %w", err)
+	}
+	defer f.Close()
+
+	p := &svnParser{files: make(map[string][]svnVersion), skipping: after != ""}
+	err = p.parse(ctx, bufio.NewReader(f), func(rev Revision) error {
+		if p.skipping {
+			if rev.ID == after {
+				p.skipping = false
+			}
+			return nil
+		}
+		return fn(rev)
+	})
+	if err != nil {
+		return err
+	}
+	if p.skipping {
+		return errors.NotFound(fmt.Sprintf("revision %s not found in %s", after, d.Path))
+	}
+	return nil
+}
+
+// svnVersion is a file's content as of a revision; an empty hash means
+// it was deleted
+type svnVersion struct {
+	rev  int
+	hash string
+	mode int
+}
+
+type svnParser struct {
+	files    map[string][]svnVersion // Every version of every file, by repository path
+	standard bool                    // Whether the repository has a trunk
+	skipping bool
+
+	rev     int
+	props   map[string]string
+	changes map[string][]FileChange // Changes of the current revision by branch
+	order   []string                // Branches in the order the revision touched them
+}
+
+func (p *svnParser) parse(ctx context.Context, r *bufio.Reader, emit func(Revision) error) error {
+	started := false
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		headers, err := readHeaders(r)
+		if err != nil {
+			return err
+		}
+		if headers == nil {
+			break
+		}
+
+		if v, ok := headers["SVN-fs-dump-format-version"]; ok {
+			if v != "2" && v != "3" {
+				return fmt.Errorf("unsupported dump format version %s", v)
+			}
+			continue
+		}
+		if _, ok := headers["UUID"]; ok {
+			continue
+		}
+
+		props, text, hasText, err := readContent(r, headers)
+		if err != nil {
+			return err
+		}
+
+		if n, ok := headers["Revision-number"]; ok {
+			if started {
+				if err := p.flush(emit); err != nil {
+					return err
+				}
+			}
+			started = true
+			if p.rev, err = strconv.Atoi(n); err != nil {
+				return fmt.Errorf("invalid revision number %q", n)
+			}
+			p.props = props
+			p.changes = make(map[string][]FileChange)
+			p.order = nil
+			continue
+		}
+		if path, ok := headers["Node-path"]; ok {
+			if headers["Text-delta"] == "true" || headers["Prop-delta"] == "true" {
+				return fmt.Errorf("revision %d: deltified dumps are not supported, dump without --deltas", p.rev)
+			}
+			if err := p.node(path, headers, props, text, hasText); err != nil {
+				return fmt.Errorf("revision %d, %s: %w", p.rev, path, err)
+			}
+			continue
+		}
+		return fmt.Errorf("unexpected dump record %v", headers)
+	}
+	if started {
+		return p.flush(emit)
+	}
+	return nil
+}
+
+// node applies one node record to the current revision
+func (p *svnParser) node(path string, headers, props map[string]string, text []byte, hasText bool) error {
+	path = strings.Trim(path, "/")
+	kind, action := headers["Node-kind"], headers["Node-action"]
+
+	switch action {
+	case "delete":
+		p.remove(path)
+		return nil
+	case "replace":
+		p.remove(path)
+	case "add", "change":
+	default:
+		return fmt.Errorf("unknown node action %q", action)
+	}
+
+	if kind == "dir" {
+		if path == SVNTrunk {
+			p.standard = true
+		}
+		from, ok := headers["Node-copyfrom-path"]
+		if !ok {
+			return nil
+		}
+		fromRev, err := strconv.Atoi(headers["Node-copyfrom-rev"])
+		if err != nil {
+			return fmt.Errorf("invalid copy source revision %q", headers["Node-copyfrom-rev"])
+		}
+		from = strings.Trim(from, "/")
+		for _, src := range p.under(from, fromRev) {
+			v, _ := p.at(src, fromRev)
+			p.set(path+strings.TrimPrefix(src, from), v.hash, v.mode, nil)
+		}
+		return nil
+	}
+
+	var current svnVersion
+	if from, ok := headers["Node-copyfrom-path"]; ok {
+		fromRev, err := strconv.Atoi(headers["Node-copyfrom-rev"])
+		if err != nil {
+			return fmt.Errorf("invalid copy source revision %q", headers["Node-copyfrom-rev"])
+		}
+		if current, ok = p.at(strings.Trim(from, "/"), fromRev); !ok {
+			return fmt.Errorf("copy source %s@%d not found", from, fromRev)
+		}
+	} else if action == "change" {
+		current, _ = p.at(path, p.rev)
+	}
+
+	mode := current.mode
+	if mode == 0 {
+		mode = 0644
+	}
+	if props != nil {
+		mode = 0644
+		if _, ok := props["svn:executable"]; ok {
+			mode = 0755
+		}
+	}
+
+	switch {
+	case hasText:
+		p.set(path, utils.HashContent(text), mode, text)
+	case current.hash != "":
+		p.set(path, current.hash, mode, nil)
+	default:
+		p.set(path, utils.HashContent(nil), mode, []byte{})
+	}
+	return nil
+}
+
+// set records a file's new content
+func (p *svnParser) set(path, hash string, mode int, content []byte) {
+	p.files[path] = append(p.files[path], svnVersion{rev: p.rev, hash: hash, mode: mode})
+	p.change(path, FileChange{Hash: hash, Content: content, Mode: mode})
+}
+
+// remove deletes a file, or every file under a directory
+func (p *svnParser) remove(path string) {
+	for _, file := range p.under(path, p.rev) {
+		p.files[file] = append(p.files[file], svnVersion{rev: p.rev})
+		p.change(file, FileChange{Delete: true})
+	}
+}
+
+// change adds a file change to its branch's changes in this revision
+func (p *svnParser) change(path string, fc FileChange) {
+	branch, rel, ok := p.branchOf(path)
+	if !ok {
+		return
+	}
+	fc.Path = rel
+	changes, seen := p.changes[branch]
+	if !seen {
+		p.order = append(p.order, branch)
+	}
+	// A file replaced within the revision keeps only its last change
+	for n, c := range changes {
+		if c.Path == rel {
+			changes = append(changes[:n], changes[n+1:]...)
+			break
+		}
+	}
+	p.changes[branch] = append(changes, fc)
+}
+
+// branchOf maps a repository path to a branch and a path within it
+func (p *svnParser) branchOf(path string) (string, string, bool) {
+	if !p.standard {
+		return SVNTrunk, path, true
+	}
+	if rel, ok := strings.CutPrefix(path, SVNTrunk+"/"); ok {
+		return SVNTrunk, rel, true
+	}
+	if rest, ok := strings.CutPrefix(path, "branches/"); ok {
+		if branch, rel, ok := strings.Cut(rest, "/"); ok {
+			return branch, rel, true
+		}
+	}
+	return "", "", false
+}
+
+// at returns a file's version as of a revision
+func (p *svnParser) at(path string, rev int) (svnVersion, bool) {
+	versions := p.files[path]
+	for n := len(versions) - 1; n >= 0; n-- {
+		if versions[n].rev <= rev {
+			return versions[n], versions[n].hash != ""
+		}
+	}
+	return svnVersion{}, false
+}
+
+// under returns the path itself, if it is a file, or the files under it
+// that existed as of a revision, sorted
+func (p *svnParser) under(path string, rev int) []string {
+	var files []string
+	for file := range p.files {
+		if file != path && !strings.HasPrefix(file, path+"/") && path != "" {
+			continue
+		}
+		if _, ok := p.at(file, rev); ok {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// flush emits the current revision, once per branch it changed
+func (p *svnParser) flush(emit func(Revision) error) error {
+	at, _ := time.Parse(time.RFC3339Nano, p.props["svn:date"])
+	rev := Revision{
+		ID:      strconv.Itoa(p.rev),
+		Author:  p.props["svn:author"],
+		Time:    at,
+		Message: p.props["svn:log"],
+	}
+	if len(p.order) == 0 {
+		// Keep the checkpoint moving past revisions that only touch
+		// directories, properties or tags
+		rev.Branch = SVNTrunk
+		return emit(rev)
+	}
+	for n, branch := range p.order {
+		r := rev
+		r.Branch = branch
+		r.Changes = p.changes[branch]
+		if n > 0 {
+			r.ID = fmt.Sprintf("%d:%s", p.rev, branch)
+		}
+		if err := emit(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readHeaders reads a block of "Name: value" lines up to a blank line. It
+// returns nil at the end of the dump.
+func readHeaders(r *bufio.Reader) (map[string]string, error) {
+	var headers map[string]string
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return headers, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("reading dump: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if headers != nil {
+				return headers, nil
+			}
+			continue
+		}
+		name, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("malformed dump header %q", line)
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[name] = value
+	}
+}
+
+// readContent reads a record's properties and text. Properties are nil
+// if the record has none.
+func readContent(r *bufio.Reader, headers map[string]string) (map[string]string, []byte, bool, error) {
+	length := func(name string) (int, error) {
+		v, ok := headers[name]
+		if !ok {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid %s %q", name, v)
+		}
+		return n, nil
+	}
+	propLen, err := length("Prop-content-length")
+	if err != nil {
+		return nil, nil, false, err
+	}
+	textLen, err := length("Text-content-length")
+	if err != nil {
+		return nil, nil, false, err
+	}
+	total, err := length("Content-length")
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var props map[string]string
+	if _, ok := headers["Prop-content-length"]; ok {
+		data := make([]byte, propLen)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, nil, false, fmt.Errorf("reading properties: %w", err)
+		}
+		if props, err = parseProps(data); err != nil {
+			return nil, nil, false, err
+		}
+	}
+	_, hasText := headers["Text-content-length"]
+	text := make([]byte, textLen)
+	if _, err := io.ReadFull(r, text); err != nil {
+		return nil, nil, false, fmt.Errorf("reading text: %w", err)
+	}
+	if extra := total - propLen - textLen; extra > 0 {
+		if _, err := r.Discard(extra); err != nil {
+			return nil, nil, false, fmt.Errorf("reading dump: %w", err)
+		}
+	}
+	return props, text, hasText, nil
+}
+
+// parseProps decodes a property block of "K <len>", key, "V <len>",
+// value entries ending in PROPS-END. Deleted properties ("D <len>") are
+// dropped.
+func parseProps(data []byte) (map[string]string, error) {
+	props := make(map[string]string)
+	r := bufio.NewReader(bytes.NewReader(data))
+	readField := func(line string) (string, error) {
+		n, err := strconv.Atoi(line[2:])
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("malformed property length %q", line)
+		}
+		buf := make([]byte, n+1) // Value and its trailing newline
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", fmt.Errorf("reading property: %w", err)
+		}
+		return string(buf[:n]), nil
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("property block is missing PROPS-END")
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "PROPS-END":
+			return props, nil
+		case strings.HasPrefix(line, "K "):
+			key, err := readField(line)
+			if err != nil {
+				return nil, err
+			}
+			line, err = r.ReadString('\n')
+			if err != nil || !strings.HasPrefix(line, "V ") {
+				return nil, fmt.Errorf("property %q has no value", key)
+			}
+			value, err := readField(strings.TrimRight(line, "\n"))
+			if err != nil {
+				return nil, err
+			}
+			props[key] = value
+		case strings.HasPrefix(line, "D "):
+			if _, err := readField(line); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("malformed property line %q", line)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// internal/incident/incident.go
+package incident
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"tig/internal/errors"
+	"tig/internal/intent"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// keyPrefix keys causal links, "incident:<incident>:<intent>", so an
+// incident's links are stored together and linking twice replaces
+const keyPrefix = "incident:"
+
+// validID accepts the IDs of incident trackers, e.g. INC-1042 or PD#Q3F7
+var validID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.#/-]*$`)
+
+// Link records that an intent caused an incident or defect
+type Link struct {
+	IncidentID string    `json:"incident_id"`
+	IntentID   string    `json:"intent_id"`
+	Note       string    `json:"note,omitempty"`
+	LinkedBy   string    `json:"linked_by,omitempty"`
+	LinkedAt   time.Time `json:"linked_at"`
+}
+
+// Add stores a link from an incident to the intent that caused it,
+// replacing an earlier link between the two
+func Add(db *badger.DB, intents intent.Box, l *Link) error {
+	if !validID.MatchString(l.IncidentID) {
+		return errors.ValidationError(fmt.Sprintf("invalid incident ID %q", l.IncidentID), nil)
+	}
+	if _, err := intents.Get(l.IntentID); err != nil {
+		return errors.NotFound(fmt.Sprintf("intent %s not found", l.IntentID))
+	}
+	if l.LinkedAt.IsZero() {
+		l.LinkedAt = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	err = db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key(l.IncidentID, l.IntentID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("linking incident %s: %w", l.IncidentID, err)
+	}
+	return nil
+}
+
+// Remove deletes the link between an incident and an intent
+func Remove(db *badger.DB, incidentID, intentID string) error {
+	return db.Update(func(txn *badger.Txn) error {
+		k := key(incidentID, intentID)
+		if _, err := txn.Get(k); err == badger.ErrKeyNotFound {
+			return errors.NotFound(fmt.Sprintf("incident %s is not linked to intent %s", incidentID, intentID))
+		} else if err != nil {
+			return err
+		}
+		return txn.Delete(k)
+	})
+}
+
+// List returns the links of an incident, or of every incident when
+// incidentID is empty, newest first
+func List(db *badger.DB, incidentID string) ([]Link, error) {
+	prefix := keyPrefix
+	if incidentID != "" {
+		prefix += incidentID + ":"
+	}
+	var out []Link
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var l Link
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &l)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			out = append(out, l)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing incidents: %w", err)
+	}
+	sort.SliceStable(out, func(x, y int) bool { return out[x].LinkedAt.After(out[y].LinkedAt) })
+	return out, nil
+}
+
+// Causes maps each intent linked to an incident to the incidents it
+// caused, in ID order
+func Causes(db *badger.DB) (map[string][]string, error) {
+	links, err := List(db, "")
+	if err != nil {
+		return nil, err
+	}
+	causes := make(map[string][]string)
+	for _, l := range links {
+		causes[l.IntentID] = append(causes[l.IntentID], l.IncidentID)
+	}
+	for _, ids := range causes {
+		sort.Strings(ids)
+	}
+	return causes, nil
+}
+
+func key(incidentID, intentID string) []byte {
+	return []byte(keyPrefix + incidentID + ":" + intentID)
+}
@@ -0,0 +1,59 @@
+// internal/incident/incident_test.go
+package incident
+
+import (
+	"testing"
+	"time"
+
+	"tig/internal/errors"
+	"tig/internal/intent"
+	tigtest "tig/testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinks(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	intents := tigtest.NewIntentBox()
+	for _, id := range []string{"i1", "i2"} {
+		require.NoError(t, intents.Create(&intent.Intent{ID: id, Type: "feature", Description: "change " + id}))
+	}
+
+	start := time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)
+	require.NoError(t, Add(db, intents, &Link{IncidentID: "INC-2", IntentID: "i1", LinkedAt: start}))
+	require.NoError(t, Add(db, intents, &Link{IncidentID: "INC-1", IntentID: "i1", LinkedAt: start.Add(time.Hour)}))
+	require.NoError(t, Add(db, intents, &Link{IncidentID: "INC-1", IntentID: "i2", LinkedAt: start.Add(2 * time.Hour)}))
+	// Linking again replaces the link
+	require.NoError(t, Add(db, intents, &Link{IncidentID: "INC-1", IntentID: "i2", Note: "null config", LinkedAt: start.Add(3 * time.Hour)}))
+
+	all, err := List(db, "")
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "null config", all[0].Note)
+	assert.Equal(t, "INC-2", all[2].IncidentID)
+
+	inc1, err := List(db, "INC-1")
+	require.NoError(t, err)
+	assert.Len(t, inc1, 2)
+
+	causes, err := Causes(db)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"i1": {"INC-1", "INC-2"}, "i2": {"INC-1"}}, causes)
+
+	require.NoError(t, Remove(db, "INC-1", "i2"))
+	inc1, err = List(db, "INC-1")
+	require.NoError(t, err)
+	assert.Len(t, inc1, 1)
+
+	var apiErr *errors.Error
+	assert.ErrorAs(t, Remove(db, "INC-1", "i2"), &apiErr)
+	assert.ErrorAs(t, Add(db, intents, &Link{IncidentID: "INC 3", IntentID: "i1"}), &apiErr)
+	assert.ErrorAs(t, Add(db, intents, &Link{IncidentID: "INC-3", IntentID: "missing"}), &apiErr)
+}
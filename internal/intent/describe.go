@@ -0,0 +1,94 @@
+// internal/intent/describe.go
+package intent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Draft is an intent's description before the intent is created, with
+// the changes it describes
+type Draft struct {
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Paths       []string `json:"paths"` // gated paths, sorted
+	Diff        string   `json:"diff"`  // unified diff of the gated changes, when a suggestion is wanted
+	Suggest     bool     `json:"-"`     // processors that propose descriptions only run when set
+}
+
+// DescriptionReview is what a processor makes of a draft
+type DescriptionReview struct {
+	Problems   []string // rules the description breaks
+	Suggestion string   // a proposed description; empty when there is none
+}
+
+// DescriptionProcessor checks or proposes intent descriptions when an
+// intent is created. Linters report problems; assistants suggest a
+// description from the diff.
+type DescriptionProcessor interface {
+	Name() string
+	Process(ctx context.Context, d Draft) (DescriptionReview, error)
+}
+
+// DescriptionLinter checks a description against a repository's rules
+type DescriptionLinter struct {
+	MaxLength  int            // longest allowed first line; 0 is unlimited
+	Imperative bool           // first word is a verb in the imperative
+	Ticket     *regexp.Regexp // a ticket reference the description must contain; nil for none
+}
+
+// Name implements DescriptionProcessor
+func (l *DescriptionLinter) Name() string { return "lint" }
+
+// Process implements DescriptionProcessor
+func (l *DescriptionLinter) Process(ctx context.Context, d Draft) (DescriptionReview, error) {
+	var review DescriptionReview
+	subject, _, _ := strings.Cut(strings.TrimSpace(d.Description), "\n")
+	if n := utf8.RuneCountInString(subject); l.MaxLength > 0 && n > l.MaxLength {
+		review.Problems = append(review.Problems, fmt.Sprintf("first line is %d characters, more than %d", n, l.MaxLength))
+	}
+	if l.Imperative {
+		if word := firstWord(subject); word != "" && !imperative(word) {
+			review.Problems = append(review.Problems, fmt.Sprintf("start with a verb in the imperative, e.g. \"Add\" rather than %q", word))
+		}
+	}
+	if l.Ticket != nil && !l.Ticket.MatchString(d.Description) {
+		review.Problems = append(review.Problems, fmt.Sprintf("no ticket reference matching %s", l.Ticket))
+	}
+	return review, nil
+}
+
+// firstWord returns the first word of a subject line, after a
+// conventional "scope:" prefix
+func firstWord(subject string) string {
+	fields := strings.Fields(subject)
+	if len(fields) > 1 && strings.HasSuffix(fields[0], ":") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.Trim(fields[0], `"'()[],.`)
+}
+
+// notPastTense are imperative verbs that end like a past tense
+var notPastTense = map[string]bool{"embed": true, "shed": true, "shred": true, "bed": true}
+
+// imperative guesses whether a word is a verb in the imperative mood by
+// rejecting past tenses, gerunds and third-person forms: "Added",
+// "Adding" and "Adds"
+func imperative(word string) bool {
+	w := strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(w, "ed"):
+		return strings.HasSuffix(w, "eed") || notPastTense[w]
+	case strings.HasSuffix(w, "ing"):
+		return len(w) <= 5 // bring, sing, ring
+	case strings.HasSuffix(w, "s"):
+		return strings.HasSuffix(w, "ss") || strings.HasSuffix(w, "us") || strings.HasSuffix(w, "is")
+	}
+	return true
+}
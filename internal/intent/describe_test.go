@@ -0,0 +1,30 @@
+// internal/intent/describe_test.go
+package intent
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescriptionLinter(t *testing.T) {
+	l := &DescriptionLinter{MaxLength: 30, Imperative: true, Ticket: regexp.MustCompile(`[A-Z]+-[0-9]+`)}
+
+	review, err := l.Process(context.Background(), Draft{Description: "api: Add retries to the client\n\nFixes OPS-12 for good"})
+	require.NoError(t, err)
+	assert.Empty(t, review.Problems)
+
+	review, err = l.Process(context.Background(), Draft{Description: "Added retries to the HTTP client"})
+	require.NoError(t, err)
+	assert.Len(t, review.Problems, 3)
+
+	for word, want := range map[string]bool{
+		"Add": true, "Fix": true, "Embed": true, "Proceed": true, "Bring": true, "Address": true, "Focus": true,
+		"Added": false, "Adding": false, "Adds": false, "Fixes": false,
+	} {
+		assert.Equal(t, want, imperative(word), word)
+	}
+}
@@ -0,0 +1,103 @@
+// internal/intent/extensions.go
+package intent
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tig/internal/config"
+	"tig/internal/errors"
+)
+
+// CheckExtensions validates an intent's extension fields against the
+// repository's schema. Values decoded from JSON are normalized in place,
+// e.g. whole numbers become ints.
+func CheckExtensions(ext map[string]any, fields config.IntentFields) error {
+	for name, value := range ext {
+		field, ok := fields.Lookup(name)
+		if !ok {
+			return errors.ValidationError(fmt.Sprintf("unknown intent field %q", name), nil)
+		}
+		v, err := checkValue(field, value)
+		if err != nil {
+			return errors.ValidationError(err.Error(), map[string]string{"field": name})
+		}
+		ext[name] = v
+	}
+	for _, field := range fields {
+		if _, ok := ext[field.Name]; field.Required && !ok {
+			return errors.ValidationError(fmt.Sprintf("intent field %q is required", field.Name), map[string]string{"field": field.Name})
+		}
+	}
+	return nil
+}
+
+// ParseExtension converts a value given on the command line to the
+// field's type
+func ParseExtension(fields config.IntentFields, name, raw string) (any, error) {
+	field, ok := fields.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown intent field %q", name)
+	}
+	switch field.Type {
+	case config.FieldInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("intent field %q expects a whole number, got %q", name, raw)
+		}
+		return n, nil
+	case config.FieldBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("intent field %q expects true or false, got %q", name, raw)
+		}
+		return b, nil
+	}
+	return checkValue(field, raw)
+}
+
+func checkValue(field config.IntentField, value any) (any, error) {
+	switch field.Type {
+	case config.FieldString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case config.FieldEnum:
+		if s, ok := value.(string); ok {
+			for _, allowed := range field.Values {
+				if s == allowed {
+					return s, nil
+				}
+			}
+			return nil, fmt.Errorf("intent field %q must be one of %s, got %q", field.Name, strings.Join(field.Values, ", "), s)
+		}
+	case config.FieldInt:
+		switch n := value.(type) {
+		case int:
+			return n, nil
+		case float64:
+			if n == math.Trunc(n) {
+				return int(n), nil
+			}
+		}
+	case config.FieldBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("intent field %q expects a %s value, got %v", field.Name, field.Type, value)
+}
+
+// ExtensionNames returns the names of the intent's extension fields,
+// sorted for display
+func (i *Intent) ExtensionNames() []string {
+	names := make([]string, 0, len(i.Extensions))
+	for name := range i.Extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
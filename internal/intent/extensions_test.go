@@ -0,0 +1,50 @@
+// internal/intent/extensions_test.go
+package intent
+
+import (
+	"testing"
+
+	"tig/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckExtensions(t *testing.T) {
+	fields := config.IntentFields{
+		{Name: "risk", Type: config.FieldEnum, Values: []string{"low", "high"}, Required: true},
+		{Name: "rollout", Type: config.FieldString},
+		{Name: "canary_pct", Type: config.FieldInt},
+		{Name: "flagged", Type: config.FieldBool},
+	}
+	require.NoError(t, fields.Validate())
+
+	// Values as decoded from JSON
+	ext := map[string]any{"risk": "high", "canary_pct": float64(10), "flagged": true}
+	require.NoError(t, CheckExtensions(ext, fields))
+	assert.Equal(t, 10, ext["canary_pct"])
+
+	for name, bad := range map[string]map[string]any{
+		"missing required": {"rollout": "all at once"},
+		"unknown field":    {"risk": "low", "owner": "alice"},
+		"not in enum":      {"risk": "medium"},
+		"fractional int":   {"risk": "low", "canary_pct": 2.5},
+		"wrong type":       {"risk": "low", "flagged": "yes"},
+	} {
+		assert.Error(t, CheckExtensions(bad, fields), name)
+	}
+
+	v, err := ParseExtension(fields, "canary_pct", "25")
+	require.NoError(t, err)
+	assert.Equal(t, 25, v)
+	v, err = ParseExtension(fields, "flagged", "false")
+	require.NoError(t, err)
+	assert.Equal(t, false, v)
+	_, err = ParseExtension(fields, "risk", "medium")
+	assert.Error(t, err)
+
+	assert.Error(t, config.IntentFields{{Name: "Risk", Type: config.FieldString}}.Validate())
+	assert.Error(t, config.IntentFields{{Name: "risk", Type: config.FieldEnum}}.Validate())
+	assert.Error(t, config.IntentFields{{Name: "risk", Type: "float"}}.Validate())
+	assert.Error(t, config.IntentFields{{Name: "a", Type: config.FieldInt}, {Name: "a", Type: config.FieldBool}}.Validate())
+}
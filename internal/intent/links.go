@@ -0,0 +1,57 @@
+// internal/intent/links.go
+package intent
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Link references an intent in another repository, e.g. the matching
+// change to a service this one calls. The repository is the base URL of
+// its `tig serve` API.
+type Link struct {
+	Repo     string `json:"repo"`
+	IntentID string `json:"intent_id"`
+}
+
+// String writes the link as ParseLink reads it
+func (l Link) String() string {
+	return l.Repo + "#" + l.IntentID
+}
+
+// ParseLink reads a link written as <repository URL>#<intent ID>
+func ParseLink(s string) (Link, error) {
+	repo, id, ok := strings.Cut(s, "#")
+	if !ok || id == "" {
+		return Link{}, fmt.Errorf("invalid link %q: expected <repository URL>#<intent ID>", s)
+	}
+	u, err := url.Parse(repo)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Link{}, fmt.Errorf("invalid link %q: repository must be an http(s) URL", s)
+	}
+	return Link{Repo: strings.TrimRight(repo, "/"), IntentID: id}, nil
+}
+
+// AddLink adds a link unless the intent already has it, reporting
+// whether it was added
+func (i *Intent) AddLink(l Link) bool {
+	for _, existing := range i.Links {
+		if existing == l {
+			return false
+		}
+	}
+	i.Links = append(i.Links, l)
+	return true
+}
+
+// RemoveLink removes a link, reporting whether the intent had it
+func (i *Intent) RemoveLink(l Link) bool {
+	for n, existing := range i.Links {
+		if existing == l {
+			i.Links = append(i.Links[:n], i.Links[n+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+// internal/intent/links_test.go
+package intent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinks(t *testing.T) {
+	l, err := ParseLink("https://tig.example.com/billing/#3f2a")
+	require.NoError(t, err)
+	assert.Equal(t, Link{Repo: "https://tig.example.com/billing", IntentID: "3f2a"}, l)
+	assert.Equal(t, "https://tig.example.com/billing#3f2a", l.String())
+
+	for _, bad := range []string{"3f2a", "https://tig.example.com#", "ftp://host#1", "billing#1"} {
+		_, err := ParseLink(bad)
+		assert.Error(t, err, bad)
+	}
+
+	i := &Intent{}
+	assert.True(t, i.AddLink(l))
+	assert.False(t, i.AddLink(l))
+	assert.Len(t, i.Links, 1)
+	assert.True(t, i.RemoveLink(l))
+	assert.False(t, i.RemoveLink(l))
+	assert.Empty(t, i.Links)
+}
@@ -0,0 +1,157 @@
+// internal/intent/state.go
+package intent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/config"
+	"tig/internal/errors"
+)
+
+// Lifecycle states of an intent
+const (
+	StateDraft    = "draft"
+	StateInReview = "in-review"
+	StateApproved = "approved"
+	StateLanded   = "landed"
+	StateReverted = "reverted"
+)
+
+// States lists the lifecycle states in the order intents usually move
+// through them
+var States = []string{StateDraft, StateInReview, StateApproved, StateLanded, StateReverted}
+
+// DefaultTransitions is the workflow of repositories that do not
+// configure one
+var DefaultTransitions = map[string][]string{
+	StateDraft:    {StateInReview},
+	StateInReview: {StateDraft, StateApproved},
+	StateApproved: {StateInReview, StateLanded},
+	StateLanded:   {StateReverted},
+	StateReverted: {StateDraft},
+}
+
+// StateChange records an intent moving between lifecycle states
+type StateChange struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	By   string    `json:"by,omitempty"`
+	At   time.Time `json:"at"`
+}
+
+// ValidState reports whether s is a lifecycle state
+func ValidState(s string) bool {
+	for _, state := range States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Transitions returns the transitions a workflow allows
+func Transitions(w config.Workflow) map[string][]string {
+	if len(w.Transitions) == 0 {
+		return DefaultTransitions
+	}
+	return w.Transitions
+}
+
+// CheckWorkflow validates a repository's workflow: every state named must
+// be a lifecycle state
+func CheckWorkflow(w config.Workflow) error {
+	for from, targets := range w.Transitions {
+		if !ValidState(from) {
+			return fmt.Errorf("workflow names unknown state %q; states are %s", from, strings.Join(States, ", "))
+		}
+		for _, to := range targets {
+			if !ValidState(to) {
+				return fmt.Errorf("workflow moves %s to unknown state %q; states are %s", from, to, strings.Join(States, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// CanTransition reports whether a workflow lets an intent move from one
+// state to another
+func CanTransition(w config.Workflow, from, to string) bool {
+	for _, s := range Transitions(w)[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentState returns the intent's lifecycle state. Intents recorded
+// before states existed are drafts.
+func (i *Intent) CurrentState() string {
+	if i.State == "" {
+		return StateDraft
+	}
+	return i.State
+}
+
+// Transition moves the intent to another lifecycle state if the workflow
+// allows it, recording who moved it. A move the workflow does not allow
+// is a conflict.
+func (i *Intent) Transition(w config.Workflow, to, by string, at time.Time) error {
+	if !ValidState(to) {
+		return errors.ValidationError(fmt.Sprintf("unknown state %q; states are %s", to, strings.Join(States, ", ")), map[string]string{"state": to})
+	}
+	from := i.CurrentState()
+	if from == to {
+		return nil
+	}
+	if !CanTransition(w, from, to) {
+		allowed := append([]string(nil), Transitions(w)[from]...)
+		sort.Strings(allowed)
+		next := "none"
+		if len(allowed) > 0 {
+			next = strings.Join(allowed, ", ")
+		}
+		return fmt.Errorf("%w: intent %s cannot move from %s to %s (allowed: %s)", errors.ErrConflict, i.ID, from, to, next)
+	}
+	i.setState(to, by, at)
+	return nil
+}
+
+// Advance moves the intent towards a state, one allowed transition at a
+// time, as far as the workflow lets it. It is used for the transitions
+// tig makes itself, such as a review moving a draft to in-review and an
+// approval on to approved.
+func (i *Intent) Advance(w config.Workflow, path []string, by string, at time.Time) {
+	for _, to := range path {
+		if CanTransition(w, i.CurrentState(), to) {
+			i.setState(to, by, at)
+		}
+	}
+}
+
+// MarkLanded records that the intent landed on a stream. Landing is a
+// fact rather than a request, so it is recorded whatever the workflow.
+func (i *Intent) MarkLanded(by string, at time.Time) {
+	if i.CurrentState() != StateLanded {
+		i.setState(StateLanded, by, at)
+	}
+}
+
+func (i *Intent) setState(to, by string, at time.Time) {
+	i.StateHistory = append(i.StateHistory, StateChange{From: i.CurrentState(), To: to, By: by, At: at})
+	i.State = to
+}
+
+// FilterState returns the intents in a lifecycle state
+func FilterState(intents []*Intent, state string) []*Intent {
+	var matched []*Intent
+	for _, i := range intents {
+		if i.CurrentState() == state {
+			matched = append(matched, i)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,55 @@
+// internal/intent/state_test.go
+package intent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tig/internal/config"
+	tigerrors "tig/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransition(t *testing.T) {
+	now := time.Now()
+	i := &Intent{ID: "i1"}
+	assert.Equal(t, StateDraft, i.CurrentState())
+
+	err := i.Transition(config.Workflow{}, StateLanded, "alice", now)
+	assert.True(t, errors.Is(err, tigerrors.ErrConflict))
+	assert.Contains(t, err.Error(), "allowed: in-review")
+	assert.Error(t, i.Transition(config.Workflow{}, "shipped", "alice", now))
+
+	require.NoError(t, i.Transition(config.Workflow{}, StateInReview, "alice", now))
+	assert.Equal(t, StateInReview, i.State)
+	assert.Equal(t, []StateChange{{From: StateDraft, To: StateInReview, By: "alice", At: now}}, i.StateHistory)
+
+	// Repositories may allow skipping review
+	w := config.Workflow{Transitions: map[string][]string{StateDraft: {StateApproved}}}
+	j := &Intent{ID: "i2"}
+	require.NoError(t, j.Transition(w, StateApproved, "bob", now))
+	assert.Error(t, j.Transition(w, StateLanded, "bob", now))
+
+	// Approval advances as far as the workflow allows
+	k := &Intent{ID: "i3"}
+	k.Advance(config.Workflow{}, []string{StateInReview, StateApproved}, "carol", now)
+	assert.Equal(t, StateApproved, k.State)
+	assert.Len(t, k.StateHistory, 2)
+	k.Advance(w, []string{StateInReview}, "carol", now)
+	assert.Equal(t, StateApproved, k.State)
+
+	// Landing is recorded whatever the workflow
+	j.MarkLanded("queue", now)
+	assert.Equal(t, StateLanded, j.State)
+	assert.Len(t, FilterState([]*Intent{i, j, k}, StateLanded), 1)
+}
+
+func TestCheckWorkflow(t *testing.T) {
+	require.NoError(t, CheckWorkflow(config.Workflow{}))
+	require.NoError(t, CheckWorkflow(config.Workflow{Transitions: DefaultTransitions}))
+	assert.Error(t, CheckWorkflow(config.Workflow{Transitions: map[string][]string{"shipped": {StateDraft}}}))
+	assert.Error(t, CheckWorkflow(config.Workflow{Transitions: map[string][]string{StateDraft: {"done"}}}))
+}
@@ -12,26 +12,17 @@ import (
 )
 
 type Store struct {
-    store     *storage.BadgerStore
+    store     *storage.Store[*intent.Intent]
     workspace shared.Workspace
 }
 
 func NewStore(db *badger.DB, ws shared.Workspace) *Store {
     return &Store{
-        store:     storage.NewBadgerStore(db, "intent"),
+        store:     storage.New[*intent.Intent](db, "intent").WithHistory(),
         workspace: ws,
     }
 }
 
-// intentEntity wraps intent.Intent to implement storage.Entity
-type intentEntity struct {
-    *intent.Intent
-}
-
-func (i *intentEntity) GetID() string {
-    return i.ID
-}
-
 func validate(i *intent.Intent) error {
     if i.Description == "" {
         return fmt.Errorf("description is required")
@@ -42,7 +33,8 @@ func validate(i *intent.Intent) error {
     return nil
 }
 
-func (s *Store) Create(i *intent.Intent) error {
+// prepareCreate validates a new intent and fills in its timestamps
+func prepareCreate(i *intent.Intent) error {
     if err := validate(i); err != nil {
         return fmt.Errorf("invalid intent: %w", err)
     }
@@ -54,66 +46,100 @@ func (s *Store) Create(i *intent.Intent) error {
     if i.UpdatedAt.IsZero() {
         i.UpdatedAt = i.CreatedAt
     }
+    return nil
+}
 
-    // Create an intentEntity wrapper
-    entity := &intentEntity{Intent: i}
-    return s.store.Create(entity)
+// prepareUpdate validates a changed intent and bumps its timestamp
+func prepareUpdate(i *intent.Intent) error {
+    if err := validate(i); err != nil {
+        return fmt.Errorf("invalid intent: %w", err)
+    }
+
+    i.UpdatedAt = time.Now()
+    return nil
+}
+
+func (s *Store) Create(i *intent.Intent) error {
+    if err := prepareCreate(i); err != nil {
+        return err
+    }
+    return s.store.Create(i)
 }
 
 func (s *Store) Get(id string) (*intent.Intent, error) {
-    var entity intentEntity
-    entity.Intent = &intent.Intent{}
-    
-    if err := s.store.Get(id, &entity); err != nil {
+    i, err := s.store.Get(id)
+    if err != nil {
         return nil, fmt.Errorf("getting intent: %w", err)
     }
-
-    return entity.Intent, nil
+    return i, nil
 }
 
 func (s *Store) Update(i *intent.Intent) error {
-    if err := validate(i); err != nil {
-        return fmt.Errorf("invalid intent: %w", err)
+    if err := prepareUpdate(i); err != nil {
+        return err
     }
-
-    i.UpdatedAt = time.Now()
-    return s.store.Update(&intentEntity{Intent: i})
+    return s.store.Update(i)
 }
 
 func (s *Store) Delete(id string) error {
     return s.store.Delete(id)
 }
 
-func (s *Store) List() ([]*intent.Intent, error) {
-    var entities []intentEntity
-    if err := s.store.List(&entities); err != nil {
-        return nil, fmt.Errorf("listing intents: %w", err)
+// History returns every recorded mutation of an intent, oldest first
+func (s *Store) History(id string) ([]storage.Mutation, error) {
+    return s.store.History(id)
+}
+
+// GetAt reconstructs an intent as it was at the given time
+func (s *Store) GetAt(id string, at time.Time) (*intent.Intent, error) {
+    i, err := s.store.GetAt(id, at)
+    if err != nil {
+        return nil, fmt.Errorf("getting intent: %w", err)
     }
+    return i, nil
+}
 
-    intents := make([]*intent.Intent, len(entities))
-    for i, entity := range entities {
-        intents[i] = entity.Intent
+func (s *Store) List() ([]*intent.Intent, error) {
+    intents, err := s.store.List()
+    if err != nil {
+        return nil, fmt.Errorf("listing intents: %w", err)
     }
     return intents, nil
 }
 
-func (s *Store) FindByType(intentType string) ([]*intent.Intent, error) {
-    if intentType == "" {
-        return nil, fmt.Errorf("intent type is required")
+// ListFunc calls fn for each intent in ID order, decoding one at a time
+func (s *Store) ListFunc(fn func(*intent.Intent) error) error {
+    return s.ListPrefix("", fn)
+}
+
+// ListPrefix calls fn for each intent whose ID starts with prefix
+func (s *Store) ListPrefix(prefix string, fn func(*intent.Intent) error) error {
+    if err := s.store.ListFunc(prefix, fn); err != nil {
+        return fmt.Errorf("listing intents: %w", err)
     }
+    return nil
+}
 
-    intents, err := s.List()
+// ListPage returns up to limit intents with IDs after the given one and
+// the cursor for the next page, which is empty on the last page
+func (s *Store) ListPage(after string, limit int) ([]*intent.Intent, string, error) {
+    intents := make([]*intent.Intent, 0, limit)
+    next, err := s.store.ListPage("", after, limit, func(i *intent.Intent) error {
+        intents = append(intents, i)
+        return nil
+    })
     if err != nil {
-        return nil, err
+        return nil, "", fmt.Errorf("listing intents: %w", err)
     }
+    return intents, next, nil
+}
 
-    var result []*intent.Intent
-    for _, i := range intents {
-        if i.Type == intentType {
-            result = append(result, i)
-        }
+func (s *Store) FindByType(intentType string) ([]*intent.Intent, error) {
+    if intentType == "" {
+        return nil, fmt.Errorf("intent type is required")
     }
-    return result, nil
+
+    return s.store.Query(func(i *intent.Intent) bool { return i.Type == intentType })
 }
 
 func (s *Store) FindByAuthor(author string) ([]*intent.Intent, error) {
@@ -121,18 +147,7 @@ func (s *Store) FindByAuthor(author string) ([]*intent.Intent, error) {
         return nil, fmt.Errorf("author is required")
     }
 
-    intents, err := s.List()
-    if err != nil {
-        return nil, err
-    }
-
-    var result []*intent.Intent
-    for _, i := range intents {
-        if i.Metadata.Author == author {
-            result = append(result, i)
-        }
-    }
-    return result, nil
+    return s.store.Query(func(i *intent.Intent) bool { return i.Metadata.Author == author })
 }
 
 func (s *Store) FindByTimeRange(start, end time.Time) ([]*intent.Intent, error) {
@@ -143,31 +158,48 @@ func (s *Store) FindByTimeRange(start, end time.Time) ([]*intent.Intent, error)
         return nil, fmt.Errorf("end time cannot be before start time")
     }
 
-    intents, err := s.List()
-    if err != nil {
-        return nil, err
-    }
+    return s.store.Query(func(i *intent.Intent) bool {
+        return !i.CreatedAt.Before(start) && !i.CreatedAt.After(end)
+    })
+}
+
+func (s *Store) FindWithBreakingChanges() ([]*intent.Intent, error) {
+    return s.store.Query(func(i *intent.Intent) bool { return i.Impact.Breaking })
+}
+
+// Tx is a view of the store whose reads and writes go through a unit of
+// work, so they commit or roll back together with other stores
+type Tx struct {
+    txn *storage.Txn[*intent.Intent]
+}
 
-    var result []*intent.Intent
-    for _, i := range intents {
-        if !i.CreatedAt.Before(start) && !i.CreatedAt.After(end) {
-            result = append(result, i)
-        }
+// With returns a view of the store bound to u
+func (s *Store) With(u *storage.UnitOfWork) *Tx {
+    return &Tx{txn: s.store.With(u)}
+}
+
+func (t *Tx) Create(i *intent.Intent) error {
+    if err := prepareCreate(i); err != nil {
+        return err
     }
-    return result, nil
+    return t.txn.Create(i)
 }
 
-func (s *Store) FindWithBreakingChanges() ([]*intent.Intent, error) {
-    intents, err := s.List()
+func (t *Tx) Get(id string) (*intent.Intent, error) {
+    i, err := t.txn.Get(id)
     if err != nil {
-        return nil, err
+        return nil, fmt.Errorf("getting intent: %w", err)
     }
+    return i, nil
+}
 
-    var result []*intent.Intent
-    for _, i := range intents {
-        if i.Impact.Breaking {
-            result = append(result, i)
-        }
+func (t *Tx) Update(i *intent.Intent) error {
+    if err := prepareUpdate(i); err != nil {
+        return err
     }
-    return result, nil
-}
\ No newline at end of file
+    return t.txn.Update(i)
+}
+
+func (t *Tx) Delete(id string) error {
+    return t.txn.Delete(id)
+}
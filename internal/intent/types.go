@@ -2,6 +2,7 @@
 package intent
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -13,10 +14,164 @@ type Intent struct {
     Impact      Impact    `json:"impact"`
     Metadata    Metadata  `json:"metadata"`
     ChangeSetID string    `json:"changeset_id"` // Added field
+    Reviews     []Review  `json:"reviews,omitempty"`
+    Checks      []Check   `json:"checks,omitempty"`
+    Coverage    *Coverage `json:"coverage,omitempty"` // Test coverage of the changed files
+    Deployed    map[string]time.Time `json:"deployed,omitempty"` // When the intent first reached each environment
+    NoAutoMerge bool      `json:"no_auto_merge,omitempty"` // Opt out of automatic merging
+    DependsOn   []string  `json:"depends_on,omitempty"`    // IDs of intents that must land first
+    Links       []Link    `json:"links,omitempty"`         // Related intents in other repositories
+    Projects    []string  `json:"projects,omitempty"`      // Repository projects the changes touch
+    Extensions  map[string]any `json:"extensions,omitempty"` // Repository-defined metadata fields
+    State       string    `json:"state,omitempty"`           // Lifecycle state; empty is draft
+    StateHistory []StateChange `json:"state_history,omitempty"`
     CreatedAt   time.Time `json:"created_at"`
     UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// Review records a reviewer's verdict on an intent
+type Review struct {
+	Reviewer  string    `json:"reviewer"`
+	Approved  bool      `json:"approved"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Check status values
+const (
+	CheckPending = "pending"
+	CheckPassed  = "passed"
+	CheckFailed  = "failed"
+)
+
+// Check records the latest result of a named check run against an intent
+type Check struct {
+	Name      string       `json:"name"`
+	Status    string       `json:"status"`
+	URL       string       `json:"url,omitempty"`
+	Tests     *TestSummary `json:"tests,omitempty"` // Of checks that ran a test suite
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// TestSummary counts the outcome of a test run, as recorded from a JUnit
+// report
+type TestSummary struct {
+	Total    int      `json:"total"`
+	Passed   int      `json:"passed"`
+	Failed   int      `json:"failed"` // Failures and errors
+	Skipped  int      `json:"skipped"`
+	Duration float64  `json:"duration,omitempty"` // Seconds
+	Failures []string `json:"failures,omitempty"` // Names of failed tests, up to MaxTestFailures
+}
+
+// TestsCheck is the check test results are recorded as unless named
+// otherwise
+const TestsCheck = "tests"
+
+// MaxTestFailures bounds how many failed test names a summary keeps
+const MaxTestFailures = 20
+
+// Status returns the check status of a test run: failed if any test
+// failed, passed otherwise
+func (s *TestSummary) Status() string {
+	if s.Failed > 0 {
+		return CheckFailed
+	}
+	return CheckPassed
+}
+
+// String describes the counts, e.g. "120 tests, 2 failed, 3 skipped"
+func (s *TestSummary) String() string {
+	out := fmt.Sprintf("%d tests", s.Total)
+	if s.Failed > 0 {
+		out += fmt.Sprintf(", %d failed", s.Failed)
+	}
+	if s.Skipped > 0 {
+		out += fmt.Sprintf(", %d skipped", s.Skipped)
+	}
+	return out
+}
+
+// CoverageCounts counts the statements or lines of code tests cover
+type CoverageCounts struct {
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+}
+
+// Percent returns the share covered, 100 for no code at all
+func (c CoverageCounts) Percent() float64 {
+	if c.Total == 0 {
+		return 100
+	}
+	return 100 * float64(c.Covered) / float64(c.Total)
+}
+
+// FileCoverage is the coverage of one changed file
+type FileCoverage struct {
+	Path string `json:"path"`
+	CoverageCounts
+	Previous *CoverageCounts `json:"previous,omitempty"` // Last recorded before this intent, if ever
+}
+
+// Coverage records test coverage of the files an intent changed, from a
+// coverage report, against the coverage last recorded for those files
+type Coverage struct {
+	Format string         `json:"format"` // go or lcov
+	Files  []FileCoverage `json:"files"`
+	CoverageCounts
+	// Of the files with earlier coverage: what it was, and the change in
+	// percentage points since
+	Previous   *CoverageCounts `json:"previous,omitempty"`
+	Delta      float64         `json:"delta"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// GetID implements storage.Entity
+func (i *Intent) GetID() string { return i.ID }
+
+// Approvals returns the reviewers whose latest review approves the intent
+func (i *Intent) Approvals() []string {
+	latest := make(map[string]bool)
+	var order []string
+	for _, r := range i.Reviews {
+		if _, seen := latest[r.Reviewer]; !seen {
+			order = append(order, r.Reviewer)
+		}
+		latest[r.Reviewer] = r.Approved
+	}
+
+	var approved []string
+	for _, reviewer := range order {
+		if latest[reviewer] {
+			approved = append(approved, reviewer)
+		}
+	}
+	return approved
+}
+
+// SetCheck records a check result, replacing any earlier result for the
+// same check
+func (i *Intent) SetCheck(c Check) {
+	for n := range i.Checks {
+		if i.Checks[n].Name == c.Name {
+			i.Checks[n] = c
+			return
+		}
+	}
+	i.Checks = append(i.Checks, c)
+}
+
+// CheckStatus returns the status of a named check, or pending if it has
+// not reported
+func (i *Intent) CheckStatus(name string) string {
+	for _, c := range i.Checks {
+		if c.Name == name {
+			return c.Status
+		}
+	}
+	return CheckPending
+}
+
 type Impact struct {
 	Scope        []string `json:"scope"`        // Affected components
 	Breaking     bool     `json:"breaking"`     // Is this a breaking change?
@@ -42,3 +197,10 @@ type Box interface {
 	FindByTimeRange(start, end time.Time) ([]*Intent, error)
 	FindWithBreakingChanges() ([]*Intent, error)
 }
+
+// Pager is implemented by boxes that can stream intents or return them a
+// page at a time instead of loading all of them
+type Pager interface {
+	ListFunc(fn func(*Intent) error) error
+	ListPage(after string, limit int) ([]*Intent, string, error)
+}
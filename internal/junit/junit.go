@@ -0,0 +1,102 @@
+// internal/junit/junit.go
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"tig/internal/intent"
+)
+
+// suite is a <testsuite> element; test suites may nest
+type suite struct {
+	Name   string     `xml:"name,attr"`
+	Time   string     `xml:"time,attr"`
+	Suites []suite    `xml:"testsuite"`
+	Cases  []testCase `xml:"testcase"`
+}
+
+type testCase struct {
+	Name      string    `xml:"name,attr"`
+	ClassName string    `xml:"classname,attr"`
+	Time      string    `xml:"time,attr"`
+	Failures  []element `xml:"failure"`
+	Errors    []element `xml:"error"`
+	Skipped   *element  `xml:"skipped"`
+}
+
+type element struct {
+	Message string `xml:"message,attr"`
+}
+
+// Parse reads a JUnit XML report, with a <testsuites> or a <testsuite>
+// root, and summarizes its test cases. A test case with a failure or an
+// error has failed.
+func Parse(r io.Reader) (*intent.TestSummary, error) {
+	var root struct {
+		XMLName xml.Name
+		suite
+	}
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("parsing JUnit report: %w", err)
+	}
+	switch root.XMLName.Local {
+	case "testsuites", "testsuite":
+	default:
+		return nil, fmt.Errorf("parsing JUnit report: unexpected root element <%s>", root.XMLName.Local)
+	}
+
+	s := &intent.TestSummary{}
+	add(s, &root.suite)
+	// Without case times, fall back to the suites' own
+	if s.Duration == 0 {
+		s.Duration = seconds(root.Time)
+	}
+	if s.Duration == 0 {
+		for _, st := range root.Suites {
+			s.Duration += seconds(st.Time)
+		}
+	}
+	return s, nil
+}
+
+// add counts the test cases of a suite and those nested in it
+func add(s *intent.TestSummary, st *suite) {
+	for _, c := range st.Cases {
+		s.Total++
+		s.Duration += seconds(c.Time)
+		switch {
+		case len(c.Failures) > 0 || len(c.Errors) > 0:
+			s.Failed++
+			if len(s.Failures) < intent.MaxTestFailures {
+				s.Failures = append(s.Failures, c.fullName())
+			}
+		case c.Skipped != nil:
+			s.Skipped++
+		default:
+			s.Passed++
+		}
+	}
+	for n := range st.Suites {
+		add(s, &st.Suites[n])
+	}
+}
+
+func (c *testCase) fullName() string {
+	if c.ClassName == "" {
+		return c.Name
+	}
+	return c.ClassName + "." + c.Name
+}
+
+// seconds parses a time attribute, ignoring malformed ones
+func seconds(s string) float64 {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
@@ -0,0 +1,57 @@
+// internal/junit/junit_test.go
+package junit
+
+import (
+	"strings"
+	"testing"
+
+	"tig/internal/intent"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	report := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites time="9.5">
+  <testsuite name="diff" tests="3">
+    <testcase classname="diff" name="TestLines" time="0.25"/>
+    <testcase classname="diff" name="TestWords" time="0.5">
+      <failure message="expected 2 hunks">diff_test.go:40</failure>
+    </testcase>
+    <testcase classname="diff" name="TestBinary"><skipped/></testcase>
+  </testsuite>
+  <testsuite name="safe">
+    <testsuite name="safe/scrub">
+      <testcase name="TestScrub" time="1,000.25"><error message="panic"/></testcase>
+    </testsuite>
+    <testcase classname="safe" name="TestStore" time="0.25"/>
+  </testsuite>
+</testsuites>`
+
+	s, err := Parse(strings.NewReader(report))
+	require.NoError(t, err)
+	assert.Equal(t, &intent.TestSummary{
+		Total:    5,
+		Passed:   2,
+		Failed:   2,
+		Skipped:  1,
+		Duration: 1001.25,
+		Failures: []string{"diff.TestWords", "TestScrub"},
+	}, s)
+	assert.Equal(t, intent.CheckFailed, s.Status())
+	assert.Equal(t, "5 tests, 2 failed, 1 skipped", s.String())
+}
+
+func TestParseSingleSuite(t *testing.T) {
+	s, err := Parse(strings.NewReader(`<testsuite name="unit" time="2.5"><testcase name="a"/><testcase name="b"/></testsuite>`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Passed)
+	assert.Equal(t, 2.5, s.Duration)
+	assert.Equal(t, intent.CheckPassed, s.Status())
+
+	_, err = Parse(strings.NewReader(`<html><body/></html>`))
+	assert.Error(t, err)
+	_, err = Parse(strings.NewReader(`not xml`))
+	assert.Error(t, err)
+}
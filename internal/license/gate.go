@@ -0,0 +1,124 @@
+// internal/license/gate.go
+package license
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	tigerrors "tig/internal/errors"
+)
+
+// enforce is set to "true" in enterprise builds with
+// -ldflags "-X tig/internal/license.enforce=true". Other builds report the
+// license but enable every feature group.
+var enforce string
+
+// Enforced reports whether this build requires a license for feature groups
+func Enforced() bool { return enforce == "true" }
+
+// Gate decides which feature groups the server enables. When enforced,
+// every feature group is disabled without a valid license.
+type Gate struct {
+	path    string
+	enforce bool
+	license *License
+	err     error // why the license could not be used, if one was given
+	now     func() time.Time
+}
+
+// Status describes the installed license for tig admin license status and
+// the admin API
+type Status struct {
+	Path      string          `json:"path,omitempty"`
+	Enforced  bool            `json:"enforced"`
+	Licensed  bool            `json:"licensed"`
+	ID        string          `json:"id,omitempty"`
+	Licensee  string          `json:"licensee,omitempty"`
+	ExpiresAt time.Time       `json:"expires_at,omitempty"`
+	Expired   bool            `json:"expired,omitempty"`
+	Features  map[string]bool `json:"features"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// Open loads the license at path for the gate. A missing file leaves the
+// server unlicensed; an invalid one is reported by Status.
+func Open(path string, keys []ed25519.PublicKey) *Gate {
+	g := &Gate{path: path, enforce: Enforced(), now: time.Now}
+	l, err := Load(path, keys)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		g.err = err
+	default:
+		g.license = l
+	}
+	return g
+}
+
+// NewGate creates a gate for an already verified license, or for none
+func NewGate(l *License, enforce bool) *Gate {
+	return &Gate{license: l, enforce: enforce, now: time.Now}
+}
+
+// Allow returns an unlicensed error unless the license grants feature or
+// licenses are not enforced
+func (g *Gate) Allow(feature string) error {
+	details := map[string]string{"feature": feature}
+	switch {
+	case !g.enforce:
+	case g.err != nil:
+		return tigerrors.Unlicensed(fmt.Sprintf("%s is disabled: the installed license is invalid: %v", feature, g.err), details)
+	case g.license == nil:
+		return tigerrors.Unlicensed(fmt.Sprintf("%s requires a license; install one with tig admin license install", feature), details)
+	case g.license.Expired(g.now()):
+		return tigerrors.Unlicensed(fmt.Sprintf("%s is disabled: the license expired on %s", feature, g.license.ExpiresAt.Format("2006-01-02")), details)
+	case !g.license.Has(feature):
+		return tigerrors.Unlicensed(fmt.Sprintf("%s is not included in the license for %s", feature, g.license.Licensee), details)
+	}
+	return nil
+}
+
+// Status reports the license and which feature groups it enables
+func (g *Gate) Status() Status {
+	s := Status{Path: g.path, Enforced: g.enforce, Features: make(map[string]bool, len(AllFeatures))}
+	for _, f := range AllFeatures {
+		s.Features[f] = g.Allow(f) == nil
+	}
+	if g.err != nil {
+		s.Error = g.err.Error()
+	}
+	if l := g.license; l != nil {
+		s.Licensed = true
+		s.ID = l.ID
+		s.Licensee = l.Licensee
+		s.ExpiresAt = l.ExpiresAt
+		s.Expired = l.Expired(g.now())
+	}
+	return s
+}
+
+// Require wraps a handler so it answers 403 with a JSON error while the
+// license does not grant feature
+func (g *Gate) Require(feature string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := g.Allow(feature); err != nil {
+			e := err.(*tigerrors.Error)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(e.Code)
+			json.NewEncoder(w).Encode(e)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Handler serves the license status as JSON
+func (g *Gate) Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(g.Status())
+}
@@ -0,0 +1,73 @@
+// internal/license/license_test.go
+package license
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicense(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	l := &License{ID: "lic-1", Licensee: "Acme", Features: []string{FeatureReplication}, ExpiresAt: time.Now().Add(24 * time.Hour)}
+	data, err := Sign(l, priv)
+	require.NoError(t, err)
+
+	got, err := Parse(data, []ed25519.PublicKey{otherPub, pub})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", got.Licensee)
+	_, err = Parse(data, []ed25519.PublicKey{otherPub})
+	assert.ErrorContains(t, err, "not signed by a trusted key")
+
+	// A modified payload no longer verifies
+	var f file
+	require.NoError(t, json.Unmarshal(data, &f))
+	f.Payload = f.Payload[:len(f.Payload)-4] + "AAAA"
+	tampered, err := json.Marshal(f)
+	require.NoError(t, err)
+	_, err = Parse(tampered, []ed25519.PublicKey{pub})
+	assert.Error(t, err)
+
+	g := NewGate(got, true)
+	assert.NoError(t, g.Allow(FeatureReplication))
+	assert.ErrorContains(t, g.Allow(FeatureWebUI), "not included in the license for Acme")
+	g.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	assert.ErrorContains(t, g.Allow(FeatureReplication), "expired")
+	assert.True(t, g.Status().Expired)
+
+	assert.NoError(t, NewGate(nil, false).Allow(FeatureWebUI), "unenforced builds enable everything")
+	assert.ErrorContains(t, NewGate(nil, true).Allow(FeatureWebUI), "requires a license")
+
+	// Handlers answer with a JSON error naming the feature
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	rec := httptest.NewRecorder()
+	NewGate(got, true).Require(FeatureWebUI, ok).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"type":"UNLICENSED"`)
+	assert.Contains(t, rec.Body.String(), `"feature":"web_ui"`)
+	rec = httptest.NewRecorder()
+	NewGate(got, true).Require(FeatureReplication, ok).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Open reports missing and invalid files through the status
+	dir := t.TempDir()
+	status := Open(filepath.Join(dir, "missing.json"), []ed25519.PublicKey{pub}).Status()
+	assert.False(t, status.Licensed)
+	assert.Empty(t, status.Error)
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, tampered, 0644))
+	status = Open(bad, []ed25519.PublicKey{pub}).Status()
+	assert.NotEmpty(t, status.Error)
+}
@@ -0,0 +1,157 @@
+// internal/merge/auto.go
+package merge
+
+import (
+	"fmt"
+
+	"tig/internal/events"
+	"tig/internal/intent"
+	"tig/internal/stream"
+
+	"go.uber.org/zap"
+)
+
+// AutoMerger lands intents on streams with AutoMerge enabled as soon as
+// they satisfy the stream's protection requirements
+type AutoMerger struct {
+	streams stream.Box
+	intents intent.Box
+	queue   *Queue
+	logger  *zap.Logger
+}
+
+// NewAutoMerger creates an auto-merger feeding the given queue
+func NewAutoMerger(streams stream.Box, intents intent.Box, queue *Queue, logger *zap.Logger) *AutoMerger {
+	return &AutoMerger{streams: streams, intents: intents, queue: queue, logger: logger}
+}
+
+// Subscribe re-evaluates intents whenever a review, passing check, stream
+// membership change or landed dependency could make them mergeable
+func (a *AutoMerger) Subscribe(bus *events.Bus) {
+	for _, t := range []events.Type{events.IntentReviewed, events.CheckPassed, events.CoverageRecorded, events.IntentAdded} {
+		bus.Subscribe(t, a.Handle)
+	}
+	bus.Subscribe(events.StreamMerged, a.HandleMerged)
+}
+
+// Sweep lands any queued entries left over from a previous run and
+// evaluates every unmerged intent on auto-merge streams
+func (a *AutoMerger) Sweep() error {
+	streams, err := a.streams.FindActive()
+	if err != nil {
+		return fmt.Errorf("listing streams: %w", err)
+	}
+	for _, st := range streams {
+		if _, err := a.queue.Run(st.ID); err != nil {
+			return err
+		}
+	}
+	for _, st := range streams {
+		if !st.Config.AutoMerge {
+			continue
+		}
+		for _, id := range st.State.Intents {
+			if _, err := a.Evaluate(id); err != nil {
+				a.logger.Warn("auto-merge failed", zap.String("intent", id), zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
+
+// HandleMerged evaluates the intents on the stream an intent just landed
+// on that depend on it
+func (a *AutoMerger) HandleMerged(e events.Event) {
+	st, err := a.streams.Get(e.StreamID)
+	if err != nil {
+		a.logger.Warn("auto-merge failed", zap.String("stream", e.StreamID), zap.Error(err))
+		return
+	}
+	intents, err := a.streams.GetIntents(st.ID)
+	if err != nil {
+		a.logger.Warn("auto-merge failed", zap.String("stream", e.StreamID), zap.Error(err))
+		return
+	}
+	for _, i := range intents {
+		if !contains(i.DependsOn, e.IntentID) || st.State.IsMerged(i.ID) {
+			continue
+		}
+		if _, err := a.Evaluate(i.ID); err != nil {
+			a.logger.Warn("auto-merge failed", zap.String("intent", i.ID), zap.Error(err))
+		}
+	}
+}
+
+// Handle evaluates the intent an event refers to
+func (a *AutoMerger) Handle(e events.Event) {
+	if e.IntentID == "" {
+		return
+	}
+	if _, err := a.Evaluate(e.IntentID); err != nil {
+		a.logger.Warn("auto-merge failed", zap.String("intent", e.IntentID), zap.Error(err))
+	}
+}
+
+// Evaluate enqueues and lands an intent on every active auto-merge stream
+// it belongs to whose protection requirements it meets. It returns the
+// IDs of the streams it landed on.
+func (a *AutoMerger) Evaluate(intentID string) ([]string, error) {
+	i, err := a.intents.Get(intentID)
+	if err != nil {
+		return nil, err
+	}
+	if i.NoAutoMerge {
+		return nil, nil
+	}
+
+	streams, err := a.streams.FindActive()
+	if err != nil {
+		return nil, fmt.Errorf("listing streams: %w", err)
+	}
+
+	var landed []string
+	for _, st := range streams {
+		if !st.Config.AutoMerge || !contains(st.State.Intents, i.ID) || st.State.IsMerged(i.ID) {
+			continue
+		}
+		if unmet := st.Config.Protection.UnmetAt(i, a.queue.now()); len(unmet) > 0 {
+			a.logger.Debug("intent not ready for auto-merge",
+				zap.String("intent", i.ID), zap.String("stream", st.Name), zap.Error(protectedError(unmet)))
+			continue
+		}
+		// Wait for dependencies rather than dropping the entry; landing
+		// one re-evaluates its dependents
+		missing, err := a.queue.missingDependencies(st, i)
+		if err != nil {
+			return landed, err
+		}
+		if len(missing) > 0 {
+			a.logger.Debug("intent waiting for dependencies",
+				zap.String("intent", i.ID), zap.String("stream", st.Name), zap.Stringers("missing", missing))
+			continue
+		}
+
+		if err := a.queue.Enqueue(st.ID, i.ID, true); err != nil {
+			return landed, err
+		}
+		results, err := a.queue.Run(st.ID)
+		if err != nil {
+			return landed, err
+		}
+		for _, r := range results {
+			if r.IntentID == i.ID && r.Landed {
+				landed = append(landed, st.ID)
+			}
+		}
+	}
+	return landed, nil
+}
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
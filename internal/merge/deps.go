@@ -0,0 +1,109 @@
+// internal/merge/deps.go
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"tig/internal/intent"
+	"tig/internal/stream"
+)
+
+// ErrDependencyCycle is returned when queued intents depend on each other
+var ErrDependencyCycle = errors.New("queued intents depend on each other")
+
+// MissingDependency is an intent that must land before a queued one but
+// has neither landed on the target stream nor been queued ahead of it
+type MissingDependency struct {
+	IntentID string   `json:"intent_id"`
+	Streams  []string `json:"streams,omitempty"` // Names of the streams it belongs to
+}
+
+func (m MissingDependency) String() string {
+	if len(m.Streams) == 0 {
+		return fmt.Sprintf("depends on %s, which belongs to no stream", m.IntentID)
+	}
+	return fmt.Sprintf("depends on %s, which is unmerged in stream %s", m.IntentID, strings.Join(m.Streams, ", "))
+}
+
+// order sorts a queue so every intent lands after the queued intents it
+// depends on, keeping enqueue order otherwise
+func (q *Queue) order(entries []Entry) ([]Entry, error) {
+	deps := make(map[string][]string, len(entries))
+	queued := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		queued[e.IntentID] = true
+	}
+	for _, e := range entries {
+		i, err := q.intents.Get(e.IntentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range i.DependsOn {
+			if queued[dep] && dep != e.IntentID {
+				deps[e.IntentID] = append(deps[e.IntentID], dep)
+			}
+		}
+	}
+
+	ordered := make([]Entry, 0, len(entries))
+	placed := make(map[string]bool, len(entries))
+	for len(ordered) < len(entries) {
+		progress := false
+		for _, e := range entries {
+			if placed[e.IntentID] || !allPlaced(deps[e.IntentID], placed) {
+				continue
+			}
+			ordered = append(ordered, e)
+			placed[e.IntentID] = true
+			progress = true
+			break
+		}
+		if !progress {
+			var stuck []string
+			for _, e := range entries {
+				if !placed[e.IntentID] {
+					stuck = append(stuck, e.IntentID)
+				}
+			}
+			return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(stuck, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+func allPlaced(ids []string, placed map[string]bool) bool {
+	for _, id := range ids {
+		if !placed[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// missingDependencies lists the intents i depends on that have not landed
+// on st, with the streams each belongs to
+func (q *Queue) missingDependencies(st *stream.Stream, i *intent.Intent) ([]MissingDependency, error) {
+	var missing []MissingDependency
+	var all []*stream.Stream
+	for _, dep := range i.DependsOn {
+		if st.State.IsMerged(dep) {
+			continue
+		}
+		if all == nil {
+			var err error
+			if all, err = q.streams.List(); err != nil {
+				return nil, fmt.Errorf("listing streams: %w", err)
+			}
+		}
+		m := MissingDependency{IntentID: dep}
+		for _, other := range all {
+			if contains(other.State.Intents, dep) {
+				m.Streams = append(m.Streams, other.Name)
+			}
+		}
+		missing = append(missing, m)
+	}
+	return missing, nil
+}
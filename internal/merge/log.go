@@ -0,0 +1,97 @@
+// internal/merge/log.go
+package merge
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"tig/internal/events"
+	"tig/internal/intent"
+	"tig/internal/storage"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// logPrefix keys the merge log, "merge_log:<time>:<stream>:<intent>", so
+// that it reads in the order intents landed
+const logPrefix = "merge_log:"
+
+// Landing records an intent merged into a stream: who asked for it and
+// the approvals and checks it was merged on
+type Landing struct {
+	StreamID    string            `json:"stream_id"`
+	Stream      string            `json:"stream"`
+	IntentID    string            `json:"intent_id"`
+	ChangeSetID string            `json:"changeset_id,omitempty"`
+	Description string            `json:"description"`
+	By          string            `json:"by,omitempty"`
+	Auto        bool              `json:"auto"`
+	Protection  stream.Protection `json:"protection"`         // The stream's rules when the intent landed
+	Override    bool              `json:"override,omitempty"` // Merged during a scheduled window by someone allowed to override it
+	Approvals   []string          `json:"approvals"`
+	Checks      []intent.Check    `json:"checks"`
+	EnqueuedAt  time.Time         `json:"enqueued_at"`
+	MergedAt    time.Time         `json:"merged_at"`
+}
+
+// event is the event published when the intent lands
+func (l *Landing) event() *events.Event {
+	summary := l.Description
+	if l.Auto {
+		summary = "auto-merged " + summary
+	}
+	return &events.Event{
+		Type:     events.StreamMerged,
+		StreamID: l.StreamID,
+		IntentID: l.IntentID,
+		Summary:  summary,
+		Data: map[string]string{
+			"stream": l.Stream,
+			"auto":   fmt.Sprint(l.Auto),
+		},
+	}
+}
+
+func putLanding(u *storage.UnitOfWork, l *Landing) error {
+	key := fmt.Sprintf("%s%020d:%s:%s", logPrefix, l.MergedAt.UnixNano(), l.StreamID, l.IntentID)
+	if err := u.Set(key, l); err != nil {
+		return fmt.Errorf("recording merge: %w", err)
+	}
+	return nil
+}
+
+// Landings returns the intents merged after since and up to until, in
+// the order they landed. A zero until means now.
+func Landings(db *badger.DB, since, until time.Time) ([]Landing, error) {
+	var landings []Landing
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(logPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		start := []byte(fmt.Sprintf("%s%020d", logPrefix, since.UnixNano()+1))
+		if since.IsZero() {
+			start = opts.Prefix
+		}
+		for it.Seek(start); it.ValidForPrefix(opts.Prefix); it.Next() {
+			var l Landing
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &l)
+			}); err != nil {
+				return fmt.Errorf("decoding merge log entry %s: %w", it.Item().Key(), err)
+			}
+			if !until.IsZero() && l.MergedAt.After(until) {
+				break
+			}
+			landings = append(landings, l)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading merge log: %w", err)
+	}
+	return landings, nil
+}
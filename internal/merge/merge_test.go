@@ -0,0 +1,324 @@
+// internal/merge/merge_test.go
+package merge
+
+import (
+	"testing"
+	"time"
+
+	"tig/internal/events"
+	"tig/internal/intent"
+	intentStorage "tig/internal/intent/storage"
+	"tig/internal/stream"
+	streamStorage "tig/internal/stream/storage"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fixture struct {
+	t       *testing.T
+	db      *badger.DB
+	intents *intentStorage.Store
+	streams *streamStorage.Store
+	bus     *events.Bus
+	merged  []events.Event
+	queue   *Queue
+	auto    *AutoMerger
+}
+
+func setup(t *testing.T) *fixture {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	f := &fixture{t: t, db: db, bus: events.NewBus()}
+	f.intents = intentStorage.NewStore(db, nil)
+	f.streams = streamStorage.NewStore(db, f.intents)
+	f.bus.Subscribe(events.StreamMerged, func(e events.Event) { f.merged = append(f.merged, e) })
+	f.queue = NewQueue(db, f.streams, f.intents).WithEvents(f.bus)
+	f.auto = NewAutoMerger(f.streams, f.intents, f.queue, zap.NewNop())
+	f.auto.Subscribe(f.bus)
+	return f
+}
+
+func (f *fixture) stream(id string, autoMerge bool) {
+	require.NoError(f.t, f.streams.Create(&stream.Stream{
+		ID:   id,
+		Name: id,
+		Type: "feature",
+		Config: stream.Config{
+			AutoMerge:  autoMerge,
+			Protection: stream.Protection{RequiredReviewers: 1, RequiredChecks: []string{"ci"}},
+		},
+	}))
+}
+
+func (f *fixture) intent(id, streamID string, noAutoMerge bool) {
+	require.NoError(f.t, f.intents.Create(&intent.Intent{
+		ID: id, Type: "feature", Description: id, ChangeSetID: "cs-" + id, NoAutoMerge: noAutoMerge,
+	}))
+	require.NoError(f.t, f.streams.AddIntent(streamID, id))
+	f.bus.Publish(events.Event{Type: events.IntentAdded, StreamID: streamID, IntentID: id})
+}
+
+// satisfy approves the intent and passes its required check
+func (f *fixture) satisfy(id string) {
+	i, err := f.intents.Get(id)
+	require.NoError(f.t, err)
+	i.Reviews = append(i.Reviews, intent.Review{Reviewer: "alice", Approved: true})
+	f.bus.Publish(events.Event{Type: events.IntentReviewed, IntentID: id})
+
+	i.SetCheck(intent.Check{Name: "ci", Status: intent.CheckPassed})
+	require.NoError(f.t, f.intents.Update(i))
+	f.bus.Publish(events.Event{Type: events.CheckPassed, IntentID: id})
+}
+
+func TestAutoMerge(t *testing.T) {
+	f := setup(t)
+	f.stream("main", true)
+	f.intent("i1", "main", false)
+	assert.Empty(t, f.merged)
+
+	f.satisfy("i1")
+	st, err := f.streams.Get("main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i1"}, st.State.Merged)
+	assert.Equal(t, "cs-i1", st.State.Head)
+
+	require.Len(t, f.merged, 1)
+	assert.Equal(t, "i1", f.merged[0].IntentID)
+	assert.Equal(t, "true", f.merged[0].Data["auto"])
+
+	entries, err := f.queue.Entries("main")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Landed intents are not merged again
+	f.bus.Publish(events.Event{Type: events.CheckPassed, IntentID: "i1"})
+	assert.Len(t, f.merged, 1)
+}
+
+func TestAutoMergeOptOut(t *testing.T) {
+	f := setup(t)
+	f.stream("manual", false)
+	f.stream("main", true)
+	f.intent("i1", "manual", false)
+	f.intent("i2", "main", true)
+
+	f.satisfy("i1")
+	f.satisfy("i2")
+	assert.Empty(t, f.merged)
+
+	// Opted-out intents can still be merged through the queue
+	require.NoError(t, f.queue.EnqueueBy("main", "i2", "bob"))
+	results, err := f.queue.Run("main")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Landed)
+	assert.Len(t, f.merged, 1)
+
+	landings, err := Landings(f.db, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, landings, 1)
+	l := landings[0]
+	assert.Equal(t, "bob", l.By)
+	assert.False(t, l.Auto)
+	assert.True(t, l.Protection.Enabled())
+	assert.Equal(t, []string{"alice"}, l.Approvals)
+	assert.Equal(t, "cs-i2", l.ChangeSetID)
+
+	landings, err = Landings(f.db, l.MergedAt, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, landings)
+}
+
+func TestQueueDropsUnmetEntries(t *testing.T) {
+	f := setup(t)
+	f.stream("main", false)
+	f.intent("i1", "main", false)
+	f.intent("i2", "main", false)
+	f.satisfy("i2")
+
+	require.NoError(t, f.queue.Enqueue("main", "i1", false))
+	require.NoError(t, f.queue.Enqueue("main", "i2", false))
+	require.NoError(t, f.queue.Enqueue("main", "i2", false))
+
+	// The queue survives reopening
+	q := NewQueue(f.db, f.streams, f.intents)
+	entries, err := q.Entries("main")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	results, err := q.Run("main")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.False(t, results[0].Landed)
+	assert.Equal(t, []string{"0 of 1 required approvals", "check ci is pending"}, results[0].Unmet)
+	assert.True(t, results[1].Landed)
+
+	st, err := f.streams.Get("main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i2"}, st.State.Merged)
+}
+
+// depends declares the intents id must land after
+func (f *fixture) depends(id string, deps ...string) {
+	i, err := f.intents.Get(id)
+	require.NoError(f.t, err)
+	i.DependsOn = deps
+	require.NoError(f.t, f.intents.Update(i))
+}
+
+func TestQueueOrdersDependencies(t *testing.T) {
+	f := setup(t)
+	f.stream("main", false)
+	f.stream("other", false)
+	for _, id := range []string{"i1", "i2", "i3"} {
+		f.intent(id, "main", false)
+		f.satisfy(id)
+	}
+	f.intent("elsewhere", "other", false)
+	f.depends("i2", "i1")
+	f.depends("i3", "elsewhere")
+
+	// Queued ahead of its dependency, i2 moves back behind it
+	for _, id := range []string{"i2", "i3", "i1"} {
+		require.NoError(t, f.queue.Enqueue("main", id, false))
+	}
+	results, err := f.queue.Run("main")
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, "i3", results[0].IntentID)
+	assert.False(t, results[0].Landed)
+	assert.Equal(t, []MissingDependency{{IntentID: "elsewhere", Streams: []string{"other"}}}, results[0].Missing)
+	assert.Equal(t, "depends on elsewhere, which is unmerged in stream other", results[0].Missing[0].String())
+	assert.Equal(t, "i1", results[1].IntentID)
+	assert.True(t, results[1].Landed)
+	assert.Equal(t, "i2", results[2].IntentID)
+	assert.True(t, results[2].Landed)
+
+	st, err := f.streams.Get("main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i1", "i2"}, st.State.Merged)
+
+	// Intents depending on each other are refused
+	f.intent("a", "main", false)
+	f.intent("b", "main", false)
+	f.depends("a", "b")
+	f.depends("b", "a")
+	require.NoError(t, f.queue.Enqueue("main", "a", false))
+	require.NoError(t, f.queue.Enqueue("main", "b", false))
+	_, err = f.queue.Run("main")
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+}
+
+func TestAutoMergeWaitsForDependencies(t *testing.T) {
+	f := setup(t)
+	f.stream("main", true)
+	f.intent("base", "main", true)
+	f.intent("feature", "main", false)
+	f.depends("feature", "base")
+
+	f.satisfy("feature")
+	assert.Empty(t, f.merged, "feature waits for base")
+	entries, err := f.queue.Entries("main")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Landing the dependency by hand lets the dependent follow
+	f.satisfy("base")
+	require.NoError(t, f.queue.Enqueue("main", "base", false))
+	_, err = f.queue.Run("main")
+	require.NoError(t, err)
+	st, err := f.streams.Get("main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base", "feature"}, st.State.Merged)
+}
+
+func TestSpeculation(t *testing.T) {
+	f := setup(t)
+	f.stream("main", false)
+	for _, id := range []string{"i1", "i2", "i3"} {
+		f.intent(id, "main", false)
+		f.satisfy(id)
+	}
+	q := NewQueue(f.db, f.streams, f.intents).WithSpeculation()
+
+	require.NoError(t, q.Enqueue("main", "i1", false))
+	require.NoError(t, q.Enqueue("main", "i2", false))
+	q.spec.running.Wait()
+
+	// The first lands from its premerge; the second was computed against
+	// the head the first moved
+	results, err := q.Run("main")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Landed)
+	assert.True(t, results[1].Landed)
+	assert.Equal(t, SpeculationStats{Hits: 1, Misses: 1}, q.SpeculationStats())
+
+	// A premerge of an intent that changed since is not used
+	require.NoError(t, q.Enqueue("main", "i3", false))
+	q.spec.running.Wait()
+	i, err := f.intents.Get("i3")
+	require.NoError(t, err)
+	i.Checks = nil
+	require.NoError(t, f.intents.Update(i))
+	results, err = q.Run("main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"check ci is pending"}, results[0].Unmet)
+	assert.Equal(t, SpeculationStats{Hits: 1, Misses: 2}, q.SpeculationStats())
+}
+
+func TestQueueFreezeWindow(t *testing.T) {
+	f := setup(t)
+	require.NoError(t, f.streams.Create(&stream.Stream{
+		ID: "main", Name: "main", Type: "feature",
+		Config: stream.Config{Protection: stream.Protection{
+			RequiredReviewers: 1,
+			Schedules: []stream.Schedule{
+				{Name: "weekend", Start: "Fri 18:00", End: "Mon 08:00", Freeze: true},
+			},
+			OverrideBy: []string{"rm"},
+		}},
+	}))
+	for _, id := range []string{"i1", "i2"} {
+		f.intent(id, "main", false)
+		f.satisfy(id)
+	}
+	q := NewQueue(f.db, f.streams, f.intents).WithSpeculation()
+	q.now = func() time.Time { return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC) } // Saturday
+
+	require.NoError(t, q.EnqueueBy("main", "i1", "alice"))
+	require.NoError(t, q.EnqueueOverride("main", "i2", "alice"))
+	results, err := q.Run("main")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	frozen := []string{"stream frozen until Mon Oct 19 08:00 UTC (weekend)"}
+	assert.Equal(t, frozen, results[0].Unmet)
+	assert.Equal(t, frozen, results[1].Unmet, "only override_by may override")
+
+	// The override role lands through the freeze
+	require.NoError(t, q.EnqueueOverride("main", "i2", "rm"))
+	q.spec.running.Wait()
+	results, err = q.Run("main")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Landed)
+	landings, err := Landings(f.db, time.Time{}, time.Now().AddDate(10, 0, 0))
+	require.NoError(t, err)
+	require.Len(t, landings, 1)
+	assert.True(t, landings[0].Override)
+
+	// A premerge computed during the freeze is not used once it ends
+	require.NoError(t, q.EnqueueBy("main", "i1", "alice"))
+	q.spec.running.Wait()
+	q.now = func() time.Time { return time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC) }
+	results, err = q.Run("main")
+	require.NoError(t, err)
+	assert.True(t, results[0].Landed)
+}
@@ -0,0 +1,375 @@
+// internal/merge/queue.go
+package merge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"tig/internal/events"
+	"tig/internal/intent"
+	"tig/internal/storage"
+	"tig/internal/stream"
+	streamStorage "tig/internal/stream/storage"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+const queuePrefix = "merge_queue:"
+
+// ErrProtected is returned when an intent does not satisfy the protection
+// rules of the stream it is being merged into
+var ErrProtected = errors.New("protection requirements not met")
+
+// Entry is an intent waiting to land on a stream
+type Entry struct {
+	IntentID   string    `json:"intent_id"`
+	StreamID   string    `json:"stream_id"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	Auto       bool      `json:"auto"`         // Enqueued by the auto-merger
+	By         string    `json:"by,omitempty"` // Who asked for the merge
+	// Override merges regardless of the stream's schedules, if By is
+	// allowed to
+	Override bool `json:"override,omitempty"`
+}
+
+// Result describes what happened to a queue entry when the queue ran
+type Result struct {
+	IntentID string   `json:"intent_id"`
+	Landed   bool     `json:"landed"`
+	Unmet    []string `json:"unmet,omitempty"`
+	// Dependencies that have not landed; the entry is dropped until they do
+	Missing []MissingDependency `json:"missing,omitempty"`
+}
+
+// Queue lands intents on streams one at a time, in the order they were
+// enqueued. Entries are persisted so a restart does not lose them.
+type Queue struct {
+	db      *badger.DB
+	streams stream.Box
+	intents intent.Box
+	events  events.Publisher
+	mu      sync.Mutex
+	now     func() time.Time
+	spec    *speculation // Premerges of queued intents, when enabled
+	gate    Gate
+}
+
+// Gate is asked about each intent that meets its stream's protection
+// rules just before it lands. Returning an error keeps the intent off the
+// stream; the error is reported as unmet.
+type Gate func(st *stream.Stream, i *intent.Intent, by string) error
+
+// NewQueue creates a merge queue
+func NewQueue(db *badger.DB, streams stream.Box, intents intent.Box) *Queue {
+	return &Queue{db: db, streams: streams, intents: intents, now: time.Now}
+}
+
+// WithEvents sets the publisher notified when intents are queued and merged
+func (q *Queue) WithEvents(p events.Publisher) *Queue {
+	q.events = p
+	return q
+}
+
+// WithGate sets the gate intents must pass to land, such as the server's
+// pre-merge hooks
+func (q *Queue) WithGate(g Gate) *Queue {
+	q.gate = g
+	return q
+}
+
+// Enqueue adds an intent to the end of a stream's queue. Enqueuing an
+// intent that is already queued is a no-op.
+func (q *Queue) Enqueue(streamID, intentID string, auto bool) error {
+	return q.enqueue(Entry{IntentID: intentID, StreamID: streamID, Auto: auto})
+}
+
+// EnqueueBy adds an intent to the end of a stream's queue on behalf of
+// someone, who is recorded in the merge log when it lands
+func (q *Queue) EnqueueBy(streamID, intentID, by string) error {
+	return q.enqueue(Entry{IntentID: intentID, StreamID: streamID, By: by})
+}
+
+// EnqueueOverride adds an intent to the end of a stream's queue on behalf
+// of someone merging it regardless of the stream's freeze windows and
+// scheduled rules. The override only applies if the stream's protection
+// lets them.
+func (q *Queue) EnqueueOverride(streamID, intentID, by string) error {
+	return q.enqueue(Entry{IntentID: intentID, StreamID: streamID, By: by, Override: true})
+}
+
+func (q *Queue) enqueue(entry Entry) error {
+	streamID, intentID := entry.StreamID, entry.IntentID
+	q.mu.Lock()
+	entries, err := q.load(streamID)
+	if err != nil {
+		q.mu.Unlock()
+		return err
+	}
+	for _, e := range entries {
+		if e.IntentID == intentID {
+			q.mu.Unlock()
+			return nil
+		}
+	}
+
+	entry.EnqueuedAt = q.now()
+	entries = append(entries, entry)
+	err = q.save(streamID, entries)
+	q.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	q.speculate(streamID)
+	q.publish(events.Event{
+		Type:     events.IntentQueued,
+		StreamID: streamID,
+		IntentID: intentID,
+		Summary:  fmt.Sprintf("queued at position %d", len(entries)),
+	})
+	return nil
+}
+
+// Entries returns the intents queued on a stream in landing order
+func (q *Queue) Entries(streamID string) ([]Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.load(streamID)
+}
+
+// Run lands queued intents in order, moving each after any queued
+// intents it depends on. Entries whose intent no longer meets the
+// stream's protection rules, or depends on an intent that has not landed
+// on the stream, are dropped from the queue.
+func (q *Queue) Run(streamID string) ([]Result, error) {
+	// Events are published once the queue is unlocked so handlers may
+	// use the queue themselves
+	var merged []events.Event
+	defer func() {
+		for _, e := range merged {
+			q.publish(e)
+		}
+	}()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries, err := q.load(streamID)
+	if err != nil {
+		return nil, err
+	}
+	if entries, err = q.order(entries); err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for len(entries) > 0 {
+		res, e, err := q.landNext(streamID, entries)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res)
+		if e != nil {
+			merged = append(merged, *e)
+			q.invalidate(streamID)
+			if err := q.markLanded(res.IntentID, entries[0].By); err != nil {
+				return results, err
+			}
+		}
+		entries = entries[1:]
+	}
+	return results, nil
+}
+
+// streamWriter is the part of a stream store landing an intent needs
+type streamWriter interface {
+	Get(id string) (*stream.Stream, error)
+	Update(s *stream.Stream) error
+}
+
+// txStreams is implemented by stream stores whose writes can join a unit
+// of work
+type txStreams interface {
+	With(u *storage.UnitOfWork) *streamStorage.Tx
+}
+
+// landNext lands the first entry and removes it from the queue. When the
+// stream store supports units of work both happen in one transaction with
+// the merge log entry, so an intent is never recorded as merged while
+// still queued.
+func (q *Queue) landNext(streamID string, entries []Entry) (Result, *events.Event, error) {
+	streams, ok := q.streams.(txStreams)
+	if !ok {
+		res, l, err := q.land(q.streams, entries[0])
+		if err != nil || l == nil {
+			return res, nil, err
+		}
+		if err := storage.Run(q.db, func(u *storage.UnitOfWork) error {
+			return putLanding(u, l)
+		}); err != nil {
+			return res, nil, err
+		}
+		return res, l.event(), q.save(streamID, entries[1:])
+	}
+
+	var res Result
+	var l *Landing
+	err := storage.Run(q.db, func(u *storage.UnitOfWork) error {
+		var err error
+		res, l, err = q.land(streams.With(u), entries[0])
+		if err != nil {
+			return err
+		}
+		if l != nil {
+			if err := putLanding(u, l); err != nil {
+				return err
+			}
+		}
+		return putEntries(u, streamID, entries[1:])
+	})
+	if err != nil || l == nil {
+		return res, nil, err
+	}
+	return res, l.event(), nil
+}
+
+// land merges a single intent into its stream, returning the record of
+// the merge when it lands
+func (q *Queue) land(streams streamWriter, e Entry) (Result, *Landing, error) {
+	res := Result{IntentID: e.IntentID}
+
+	st, err := streams.Get(e.StreamID)
+	if err != nil {
+		return res, nil, err
+	}
+	if st.State.IsMerged(e.IntentID) {
+		res.Landed = true
+		return res, nil, nil
+	}
+
+	i, err := q.intents.Get(e.IntentID)
+	if err != nil {
+		return res, nil, err
+	}
+	override := overrides(st, e)
+	p, err := q.evaluate(st, i, override)
+	if err != nil {
+		return res, nil, err
+	}
+	if res.Missing = p.missing; len(res.Missing) > 0 {
+		return res, nil, nil
+	}
+	if res.Unmet = p.unmet; len(res.Unmet) > 0 {
+		return res, nil, nil
+	}
+	if q.gate != nil {
+		if err := q.gate(st, i, e.By); err != nil {
+			res.Unmet = []string{err.Error()}
+			return res, nil, nil
+		}
+	}
+
+	st.State.Merged = append(st.State.Merged, i.ID)
+	if i.ChangeSetID != "" {
+		st.State.Head = i.ChangeSetID
+	}
+	st.State.LastSync = q.now()
+	if err := streams.Update(st); err != nil {
+		return res, nil, fmt.Errorf("updating stream %s: %w", st.Name, err)
+	}
+	res.Landed = true
+
+	return res, &Landing{
+		StreamID:    st.ID,
+		Stream:      st.Name,
+		IntentID:    i.ID,
+		ChangeSetID: i.ChangeSetID,
+		Description: i.Description,
+		By:          e.By,
+		Auto:        e.Auto,
+		Protection:  st.Config.Protection,
+		Override:    override && len(st.Config.Protection.Active(st.State.LastSync)) > 0,
+		Approvals:   i.Approvals(),
+		Checks:      i.Checks,
+		EnqueuedAt:  e.EnqueuedAt,
+		MergedAt:    st.State.LastSync,
+	}, nil
+}
+
+// markLanded moves a landed intent to the landed state
+func (q *Queue) markLanded(intentID, by string) error {
+	i, err := q.intents.Get(intentID)
+	if err != nil {
+		return err
+	}
+	if i.CurrentState() == intent.StateLanded {
+		return nil
+	}
+	i.MarkLanded(by, q.now())
+	if err := q.intents.Update(i); err != nil {
+		return fmt.Errorf("marking intent %s landed: %w", intentID, err)
+	}
+	return nil
+}
+
+func (q *Queue) publish(e events.Event) {
+	if q.events != nil {
+		q.events.Publish(e)
+	}
+}
+
+func (q *Queue) load(streamID string) ([]Entry, error) {
+	var entries []Entry
+	err := q.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(queuePrefix + streamID))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &entries)
+		})
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading merge queue: %w", err)
+	}
+	return entries, nil
+}
+
+func (q *Queue) save(streamID string, entries []Entry) error {
+	return storage.Run(q.db, func(u *storage.UnitOfWork) error {
+		return putEntries(u, streamID, entries)
+	})
+}
+
+// putEntries writes a stream's queue inside u
+func putEntries(u *storage.UnitOfWork, streamID string, entries []Entry) error {
+	key := queuePrefix + streamID
+	var err error
+	if len(entries) == 0 {
+		err = u.Delete(key)
+	} else {
+		err = u.Set(key, entries)
+	}
+	if err != nil {
+		return fmt.Errorf("saving merge queue: %w", err)
+	}
+	return nil
+}
+
+// overrides reports whether an entry merges into st regardless of its
+// schedules
+func overrides(st *stream.Stream, e Entry) bool {
+	return e.Override && st.Config.Protection.CanOverride(e.By)
+}
+
+// protectedError explains why an intent cannot be merged
+func protectedError(unmet []string) error {
+	return fmt.Errorf("%w: %s", ErrProtected, strings.Join(unmet, ", "))
+}
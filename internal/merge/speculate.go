@@ -0,0 +1,165 @@
+// internal/merge/speculate.go
+package merge
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"tig/internal/intent"
+	"tig/internal/stream"
+)
+
+// premerge is the outcome of landing an intent, computed ahead of its turn
+// against one state of the stream and one revision of the intent
+type premerge struct {
+	head       string
+	landed     int
+	protection stream.Protection
+	active     []stream.Schedule // Schedules in force when computed
+	override   bool
+	updated    time.Time
+
+	missing []MissingDependency
+	unmet   []string
+}
+
+// valid reports whether p still describes landing i on st at now
+func (p premerge) valid(st *stream.Stream, i *intent.Intent, override bool, now time.Time) bool {
+	return p.head == st.State.Head && p.landed == len(st.State.Merged) &&
+		p.updated.Equal(i.UpdatedAt) && reflect.DeepEqual(p.protection, st.Config.Protection) &&
+		p.override == override && reflect.DeepEqual(p.active, st.Config.Protection.Active(now))
+}
+
+// speculation caches premerges by stream and intent
+type speculation struct {
+	mu      sync.Mutex
+	running sync.WaitGroup // Background runs
+	results map[string]map[string]premerge
+	hits    int
+	misses  int
+}
+
+// SpeculationStats counts how often landing found a valid premerge
+type SpeculationStats struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// WithSpeculation makes the queue compute the outcome of each queued
+// intent in the background as soon as it is enqueued, so landing it is
+// nearly instant if the stream has not moved in the meantime
+func (q *Queue) WithSpeculation() *Queue {
+	q.spec = &speculation{results: make(map[string]map[string]premerge)}
+	return q
+}
+
+// SpeculationStats returns the premerge hit and miss counts
+func (q *Queue) SpeculationStats() SpeculationStats {
+	if q.spec == nil {
+		return SpeculationStats{}
+	}
+	q.spec.mu.Lock()
+	defer q.spec.mu.Unlock()
+	return SpeculationStats{Hits: q.spec.hits, Misses: q.spec.misses}
+}
+
+// Speculate computes the outcome of landing every intent queued on a
+// stream against its current head. It runs without holding the queue, so
+// a result may be stale by the time it is used; landing checks it first.
+func (q *Queue) Speculate(streamID string) error {
+	if q.spec == nil {
+		return nil
+	}
+	entries, err := q.Entries(streamID)
+	if err != nil {
+		return err
+	}
+	st, err := q.streams.Get(streamID)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if st.State.IsMerged(e.IntentID) {
+			continue
+		}
+		i, err := q.intents.Get(e.IntentID)
+		if err != nil {
+			return err
+		}
+		p, err := q.premerge(st, i, overrides(st, e))
+		if err != nil {
+			return err
+		}
+		q.spec.mu.Lock()
+		if q.spec.results[streamID] == nil {
+			q.spec.results[streamID] = make(map[string]premerge)
+		}
+		q.spec.results[streamID][i.ID] = p
+		q.spec.mu.Unlock()
+	}
+	return nil
+}
+
+// speculate refreshes a stream's premerges in the background
+func (q *Queue) speculate(streamID string) {
+	if q.spec == nil {
+		return
+	}
+	q.spec.running.Add(1)
+	go func() {
+		defer q.spec.running.Done()
+		q.Speculate(streamID)
+	}()
+}
+
+// evaluate returns the outcome of landing i on st, from a valid premerge
+// when there is one
+func (q *Queue) evaluate(st *stream.Stream, i *intent.Intent, override bool) (premerge, error) {
+	if q.spec != nil {
+		q.spec.mu.Lock()
+		p, ok := q.spec.results[st.ID][i.ID]
+		if ok && p.valid(st, i, override, q.now()) {
+			q.spec.hits++
+			q.spec.mu.Unlock()
+			return p, nil
+		}
+		q.spec.misses++
+		q.spec.mu.Unlock()
+	}
+	return q.premerge(st, i, override)
+}
+
+// premerge computes the outcome of landing i on st, bypassing the
+// stream's schedules when override is set
+func (q *Queue) premerge(st *stream.Stream, i *intent.Intent, override bool) (premerge, error) {
+	now := q.now()
+	p := premerge{
+		head:       st.State.Head,
+		landed:     len(st.State.Merged),
+		protection: st.Config.Protection,
+		active:     st.Config.Protection.Active(now),
+		override:   override,
+		updated:    i.UpdatedAt,
+	}
+	var err error
+	if p.missing, err = q.missingDependencies(st, i); err != nil {
+		return p, err
+	}
+	if override {
+		p.unmet = st.Config.Protection.Unmet(i)
+	} else {
+		p.unmet = st.Config.Protection.UnmetAt(i, now)
+	}
+	return p, nil
+}
+
+// invalidate drops a stream's premerges once its head has moved
+func (q *Queue) invalidate(streamID string) {
+	if q.spec == nil {
+		return
+	}
+	q.spec.mu.Lock()
+	delete(q.spec.results, streamID)
+	q.spec.mu.Unlock()
+}
@@ -0,0 +1,198 @@
+// internal/merge/threeway.go
+package merge
+
+import (
+	"bytes"
+	"fmt"
+
+	"tig/internal/diff"
+)
+
+// Outcomes of merging a file
+const (
+	FileTaken    = "taken"    // Only the source changed the file
+	FileMerged   = "merged"   // Both sides changed it and the changes combined cleanly
+	FileDeleted  = "deleted"  // The source deleted a file the target left alone
+	FileConflict = "conflict" // Both sides changed it in ways that do not combine
+)
+
+// Labels name the two sides of a merge in conflict markers
+type Labels struct {
+	Ours   string // The side merged into
+	Theirs string // The side merged from
+}
+
+// FileChange is the source side of a file merge: the file's content hash
+// before and after the source changed it, empty where it did not exist
+type FileChange struct {
+	Path   string // Slash-separated
+	Base   string
+	Theirs string
+	Mode   int
+}
+
+// FileResult is the outcome of merging one file
+type FileResult struct {
+	Path    string `json:"path"`
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"` // Why a conflict could not be merged line by line
+	// Content to write to the working tree; nil when the file is deleted
+	// or, for some conflicts, the target's version is kept
+	Content []byte `json:"-"`
+	Mode    int    `json:"-"`
+}
+
+// MergeFiles applies source changes to the target's files, given by
+// content hash and keyed by slash-separated path, loading content with
+// load. Changes the target already has are skipped; changes to files the
+// target left alone are taken; files both sides changed are merged line
+// by line, with conflicting regions between conflict markers.
+func MergeFiles(changes []FileChange, ours map[string]string, labels Labels, load func(hash string) ([]byte, error)) ([]FileResult, error) {
+	var results []FileResult
+	for _, c := range changes {
+		current := ours[c.Path]
+		res := FileResult{Path: c.Path, Mode: c.Mode}
+		switch {
+		case current == c.Theirs:
+			continue
+		case current == c.Base && c.Theirs == "":
+			res.Outcome = FileDeleted
+		case current == c.Base:
+			content, err := load(c.Theirs)
+			if err != nil {
+				return nil, fmt.Errorf("loading %s: %w", c.Path, err)
+			}
+			res.Outcome, res.Content = FileTaken, content
+		case c.Theirs == "":
+			res.Outcome, res.Reason = FileConflict, fmt.Sprintf("deleted on %s, modified on %s", labels.Theirs, labels.Ours)
+		case current == "":
+			content, err := load(c.Theirs)
+			if err != nil {
+				return nil, fmt.Errorf("loading %s: %w", c.Path, err)
+			}
+			res.Outcome, res.Content = FileConflict, content
+			res.Reason = fmt.Sprintf("modified on %s, deleted on %s", labels.Theirs, labels.Ours)
+		default:
+			var contents [3][]byte
+			for n, hash := range []string{c.Base, current, c.Theirs} {
+				if hash == "" {
+					continue
+				}
+				content, err := load(hash)
+				if err != nil {
+					return nil, fmt.Errorf("loading %s: %w", c.Path, err)
+				}
+				contents[n] = content
+			}
+			if diff.IsBinary(contents[0]) || diff.IsBinary(contents[1]) || diff.IsBinary(contents[2]) {
+				res.Outcome, res.Reason = FileConflict, "binary file changed on both sides"
+				break
+			}
+			merged, conflicted := Merge3(contents[0], contents[1], contents[2], labels)
+			res.Outcome, res.Content = FileMerged, merged
+			if conflicted {
+				res.Outcome = FileConflict
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Merge3 combines the changes from base to ours and from base to theirs,
+// line by line. Regions both sides changed differently are written
+// between conflict markers, and conflicted reports whether there were any.
+func Merge3(base, ours, theirs []byte, labels Labels) (merged []byte, conflicted bool) {
+	baseLines := lines(base)
+	oursLines := lines(ours)
+	theirsLines := lines(theirs)
+	engine := diff.NewEngine(0)
+	toOurs := matches(engine.Align(base, ours), len(baseLines))
+	toTheirs := matches(engine.Align(base, theirs), len(baseLines))
+
+	var out bytes.Buffer
+	chunk := func(b, o, t []string) {
+		switch {
+		case equal(o, b):
+			writeLines(&out, t)
+		case equal(t, b), equal(o, t):
+			writeLines(&out, o)
+		default:
+			conflicted = true
+			fmt.Fprintf(&out, "<<<<<<< %s\n", labels.Ours)
+			writeLines(&out, o)
+			out.WriteString("=======\n")
+			writeLines(&out, t)
+			fmt.Fprintf(&out, ">>>>>>> %s\n", labels.Theirs)
+		}
+	}
+
+	// Base lines both sides kept split the files into chunks merged on
+	// their own
+	b, o, t := 0, 0, 0
+	for k := range baseLines {
+		if toOurs[k] < 0 || toTheirs[k] < 0 {
+			continue
+		}
+		chunk(baseLines[b:k], oursLines[o:toOurs[k]], theirsLines[t:toTheirs[k]])
+		writeLines(&out, baseLines[k:k+1])
+		b, o, t = k+1, toOurs[k]+1, toTheirs[k]+1
+	}
+	chunk(baseLines[b:], oursLines[o:], theirsLines[t:])
+
+	merged = out.Bytes()
+	// Keep a missing final newline when neither side added one
+	if !endsWithNewline(ours) && !endsWithNewline(theirs) && len(merged) > 0 && !conflicted {
+		merged = bytes.TrimSuffix(merged, []byte{'\n'})
+	}
+	return merged, conflicted
+}
+
+// matches maps each base line to the index of the line it is kept as on
+// the other side, or -1 when it was removed or replaced
+func matches(aligned []diff.Line, n int) []int {
+	m := make([]int, n)
+	for i := range m {
+		m[i] = -1
+	}
+	for _, l := range aligned {
+		if l.Type == diff.Context {
+			m[l.OldNum-1] = l.NewNum - 1
+		}
+	}
+	return m
+}
+
+func lines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	var out []string
+	for _, l := range bytes.Split(bytes.TrimSuffix(content, []byte{'\n'}), []byte{'\n'}) {
+		out = append(out, string(l))
+	}
+	return out
+}
+
+func writeLines(out *bytes.Buffer, lines []string) {
+	for _, l := range lines {
+		out.WriteString(l)
+		out.WriteByte('\n')
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for n := range a {
+		if a[n] != b[n] {
+			return false
+		}
+	}
+	return true
+}
+
+func endsWithNewline(content []byte) bool {
+	return len(content) == 0 || content[len(content)-1] == '\n'
+}
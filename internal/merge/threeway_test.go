@@ -0,0 +1,78 @@
+// internal/merge/threeway_test.go
+package merge
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge3(t *testing.T) {
+	labels := Labels{Ours: "main", Theirs: "feature"}
+	base := []byte("a\nb\nc\nd\ne\n")
+
+	// Changes to different lines combine
+	merged, conflicted := Merge3(base, []byte("A\nb\nc\nd\ne\n"), []byte("a\nb\nc\nd\nE\nf\n"), labels)
+	assert.False(t, conflicted)
+	assert.Equal(t, "A\nb\nc\nd\nE\nf\n", string(merged))
+
+	// The same change on both sides is not a conflict
+	merged, conflicted = Merge3(base, []byte("a\nB\nc\nd\ne\n"), []byte("a\nB\nc\nd\ne\n"), labels)
+	assert.False(t, conflicted)
+	assert.Equal(t, "a\nB\nc\nd\ne\n", string(merged))
+
+	merged, conflicted = Merge3(base, []byte("a\nours\nc\nd\ne\n"), []byte("a\ntheirs\nc\nd\ne\n"), labels)
+	assert.True(t, conflicted)
+	assert.Equal(t, "a\n<<<<<<< main\nours\n=======\ntheirs\n>>>>>>> feature\nc\nd\ne\n", string(merged))
+
+	merged, conflicted = Merge3([]byte("x"), []byte("x"), []byte("y"), labels)
+	assert.False(t, conflicted)
+	assert.Equal(t, "y", string(merged))
+}
+
+func TestMergeFiles(t *testing.T) {
+	content := map[string]string{
+		"base": "a\nb\nc\n", "ours": "A\nb\nc\n", "theirs": "a\nb\nC\n", "other": "x\nb\nc\n",
+		"new": "new\n", "bin": "\x00\x01",
+	}
+	load := func(hash string) ([]byte, error) {
+		c, ok := content[hash]
+		if !ok {
+			return nil, fmt.Errorf("no content %s", hash)
+		}
+		return []byte(c), nil
+	}
+	ours := map[string]string{
+		"same.txt": "theirs", "untouched.txt": "base", "both.txt": "ours",
+		"clash.txt": "other", "gone.txt": "base", "edited.txt": "ours", "bin.dat": "base",
+	}
+	changes := []FileChange{
+		{Path: "same.txt", Base: "base", Theirs: "theirs"},
+		{Path: "untouched.txt", Base: "base", Theirs: "theirs"},
+		{Path: "both.txt", Base: "base", Theirs: "theirs"},
+		{Path: "clash.txt", Base: "base", Theirs: "ours"},
+		{Path: "gone.txt", Base: "base"},
+		{Path: "edited.txt", Base: "base"},
+		{Path: "added.txt", Theirs: "new"},
+		{Path: "bin.dat", Base: "base", Theirs: "bin"},
+	}
+
+	results, err := MergeFiles(changes, ours, Labels{Ours: "main", Theirs: "feature"}, load)
+	require.NoError(t, err)
+	outcomes := make(map[string]string)
+	for _, r := range results {
+		outcomes[r.Path] = r.Outcome
+	}
+	assert.Equal(t, map[string]string{
+		"untouched.txt": FileTaken,
+		"both.txt":      FileMerged,
+		"clash.txt":     FileConflict,
+		"gone.txt":      FileDeleted,
+		"edited.txt":    FileConflict,
+		"added.txt":     FileTaken,
+		"bin.dat":       FileTaken,
+	}, outcomes)
+	assert.Equal(t, "A\nb\nC\n", string(results[1].Content))
+}
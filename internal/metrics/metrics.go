@@ -0,0 +1,147 @@
+// internal/metrics/metrics.go
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	v atomic.Uint64
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() { c.v.Add(1) }
+
+// Add adds n to the counter
+func (c *Counter) Add(n uint64) { c.v.Add(n) }
+
+// Value returns the current count
+func (c *Counter) Value() uint64 { return c.v.Load() }
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set replaces the gauge value
+func (g *Gauge) Set(v float64) { g.bits.Store(math.Float64bits(v)) }
+
+// Value returns the current value
+func (g *Gauge) Value() float64 { return math.Float64frombits(g.bits.Load()) }
+
+type metric struct {
+	name  string
+	help  string
+	kind  string // counter, gauge
+	value func() float64
+	inst  any // the *Counter or *Gauge
+}
+
+// Registry holds named metrics and renders them in the Prometheus text
+// exposition format
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]metric
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// Default is the process-wide registry served at /metrics
+var Default = NewRegistry()
+
+// Counter returns the counter registered under name, creating it if needed
+func (r *Registry) Counter(name, help string) *Counter {
+	c := &Counter{}
+	return register[Counter](r, metric{name: name, help: help, kind: "counter", inst: c, value: func() float64 {
+		return float64(c.Value())
+	}})
+}
+
+// Gauge returns the gauge registered under name, creating it if needed
+func (r *Registry) Gauge(name, help string) *Gauge {
+	g := &Gauge{}
+	return register[Gauge](r, metric{name: name, help: help, kind: "gauge", inst: g, value: g.Value})
+}
+
+// CounterFunc registers a counter whose value is read from fn when the
+// registry is rendered, for counts kept by other libraries. Registering
+// the same name again replaces fn.
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = metric{name: name, help: help, kind: "counter", value: fn}
+}
+
+func register[T any](r *Registry, m metric) *T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[m.name]; ok {
+		inst, ok := existing.inst.(*T)
+		if !ok {
+			panic(fmt.Sprintf("metrics: %s already registered as a %s", m.name, existing.kind))
+		}
+		return inst
+	}
+	r.metrics[m.name] = m
+	return m.inst.(*T)
+}
+
+// NewCounter registers a counter in the default registry
+func NewCounter(name, help string) *Counter {
+	return Default.Counter(name, help)
+}
+
+// NewGauge registers a gauge in the default registry
+func NewGauge(name, help string) *Gauge {
+	return Default.Gauge(name, help)
+}
+
+// NewCounterFunc registers a counter function in the default registry
+func NewCounterFunc(name, help string, fn func() float64) {
+	Default.CounterFunc(name, help, fn)
+}
+
+// WriteTo renders all metrics sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ms := make([]metric, len(names))
+	for i, name := range names {
+		ms[i] = r.metrics[name]
+	}
+	r.mu.RUnlock()
+
+	var total int64
+	for _, m := range ms {
+		n, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n",
+			m.name, m.help, m.name, m.kind, m.name, m.value())
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Handler serves the registry in the Prometheus text format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.WriteTo(w)
+	})
+}
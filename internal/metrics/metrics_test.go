@@ -0,0 +1,30 @@
+// internal/metrics/metrics_test.go
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("tig_test_total", "Test counter")
+	c.Add(2)
+	r.Counter("tig_test_total", "Test counter").Inc()
+	r.Gauge("tig_test_gauge", "Test gauge").Set(1.5)
+
+	assert.Equal(t, uint64(3), c.Value())
+	assert.Panics(t, func() { r.Gauge("tig_test_total", "") })
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, `# HELP tig_test_gauge Test gauge
+# TYPE tig_test_gauge gauge
+tig_test_gauge 1.5
+# HELP tig_test_total Test counter
+# TYPE tig_test_total counter
+tig_test_total 3
+`, rec.Body.String())
+}
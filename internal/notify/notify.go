@@ -0,0 +1,245 @@
+// internal/notify/notify.go
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"tig/internal/config"
+	"tig/internal/events"
+
+	"go.uber.org/zap"
+)
+
+// Default message templates per event type
+var defaultTemplates = map[events.Type]string{
+	events.IntentCreated:    `New intent {{.IntentID}}: {{.Summary}}`,
+	events.BreakingChange:   `:warning: Breaking change in intent {{.IntentID}}: {{.Summary}}`,
+	events.StreamMerged:     `Stream {{.StreamID}} merged: {{.Summary}}`,
+	events.CheckFailed:      `:x: Check {{index .Data "check"}} failed for intent {{.IntentID}}: {{.Summary}}`,
+	events.IntentQueued:     `Intent {{.IntentID}} queued for merge into stream {{.StreamID}}`,
+	events.ReviewAssigned:   `{{index .Data "reviewer"}} was asked to review intent {{.IntentID}}: {{.Summary}}`,
+	events.IntentStale:      `:hourglass: Intent {{.IntentID}} has been in review with no activity for {{index .Data "idle"}}: {{.Summary}}`,
+	events.StreamStale:      `:hourglass: Stream {{index .Data "name"}} has not synced for {{index .Data "idle"}}`,
+	events.CoverageRecorded: `Coverage of intent {{.IntentID}} is {{index .Data "percent"}}% ({{index .Data "delta"}} points): {{.Summary}}`,
+	events.Deployed:         `:rocket: Deployed {{index .Data "source"}} to {{index .Data "environment"}}: {{.Summary}}`,
+	events.RolledBack:       `:rewind: Rolled {{index .Data "environment"}} back from {{index .Data "from"}} to {{index .Data "to"}}: {{.Summary}}`,
+	events.IncidentLinked:   `:fire: Intent {{.IntentID}} caused incident {{index .Data "incident"}}: {{.Summary}}`,
+	events.ContentCorrupt:   `:rotating_light: Corrupt object {{index .Data "hash"}} in content safe: {{index .Data "error"}}`,
+}
+
+const fallbackTemplate = `[{{.Type}}] {{.Summary}}`
+
+// target is a webhook with its templates parsed
+type target struct {
+	cfg       config.Webhook
+	events    map[string]bool
+	streams   map[string]bool
+	templates map[events.Type]*template.Template
+	fallback  *template.Template
+}
+
+type delivery struct {
+	target *target
+	event  events.Event
+}
+
+// Notifier posts formatted event messages to chat webhooks
+type Notifier struct {
+	targets []*target
+	client  *http.Client
+	logger  *zap.Logger
+	queue   chan delivery
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// New creates a notifier from configuration and starts its delivery worker
+func New(cfg config.Notifications, logger *zap.Logger) (*Notifier, error) {
+	n := &Notifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+		queue:  make(chan delivery, 100),
+	}
+
+	for _, wh := range cfg.Webhooks {
+		t, err := newTarget(wh)
+		if err != nil {
+			return nil, fmt.Errorf("configuring webhook %q: %w", wh.Name, err)
+		}
+		n.targets = append(n.targets, t)
+	}
+
+	n.wg.Add(1)
+	go n.run()
+
+	return n, nil
+}
+
+func newTarget(wh config.Webhook) (*target, error) {
+	if wh.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	switch wh.Kind {
+	case "slack", "teams":
+	case "":
+		wh.Kind = "slack"
+	default:
+		return nil, fmt.Errorf("unsupported webhook kind: %s", wh.Kind)
+	}
+
+	t := &target{
+		cfg:       wh,
+		events:    toSet(wh.Events),
+		streams:   toSet(wh.Streams),
+		templates: make(map[events.Type]*template.Template),
+	}
+
+	for typ, text := range defaultTemplates {
+		if custom, ok := wh.Templates[string(typ)]; ok {
+			text = custom
+		}
+		tmpl, err := template.New(string(typ)).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template for %s: %w", typ, err)
+		}
+		t.templates[typ] = tmpl
+	}
+	for typ, text := range wh.Templates {
+		if _, ok := t.templates[events.Type(typ)]; ok {
+			continue
+		}
+		tmpl, err := template.New(typ).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template for %s: %w", typ, err)
+		}
+		t.templates[events.Type(typ)] = tmpl
+	}
+
+	fallback, err := template.New("fallback").Parse(fallbackTemplate)
+	if err != nil {
+		return nil, err
+	}
+	t.fallback = fallback
+
+	return t, nil
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// matches reports whether an event should be routed to this target
+func (t *target) matches(e events.Event) bool {
+	if len(t.events) > 0 && !t.events[string(e.Type)] {
+		return false
+	}
+	if len(t.streams) > 0 && !t.streams[e.StreamID] {
+		return false
+	}
+	return true
+}
+
+// render formats the event message using the target's templates
+func (t *target) render(e events.Event) (string, error) {
+	tmpl, ok := t.templates[e.Type]
+	if !ok {
+		tmpl = t.fallback
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, e); err != nil {
+		return "", fmt.Errorf("rendering %s: %w", e.Type, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// payload builds the webhook request body for the target's chat platform
+func (t *target) payload(text string) ([]byte, error) {
+	switch t.cfg.Kind {
+	case "teams":
+		return json.Marshal(map[string]string{
+			"@type":    "MessageCard",
+			"@context": "http://schema.org/extensions",
+			"summary":  text,
+			"text":     text,
+		})
+	default:
+		body := map[string]string{"text": text}
+		if t.cfg.Channel != "" {
+			body["channel"] = t.cfg.Channel
+		}
+		return json.Marshal(body)
+	}
+}
+
+// Handle queues an event for delivery to every matching webhook.
+// It is safe to register directly as an events.Handler.
+func (n *Notifier) Handle(e events.Event) {
+	for _, t := range n.targets {
+		if !t.matches(e) {
+			continue
+		}
+		select {
+		case n.queue <- delivery{target: t, event: e}:
+		default:
+			n.logger.Warn("notification queue full, dropping event",
+				zap.String("webhook", t.cfg.Name),
+				zap.String("event", string(e.Type)))
+		}
+	}
+}
+
+func (n *Notifier) run() {
+	defer n.wg.Done()
+	for d := range n.queue {
+		if err := n.send(d.target, d.event); err != nil {
+			n.logger.Error("sending notification",
+				zap.String("webhook", d.target.cfg.Name),
+				zap.String("event", string(d.event.Type)),
+				zap.Error(err))
+		}
+	}
+}
+
+// send renders and posts a single event to a webhook
+func (n *Notifier) send(t *target, e events.Event) error {
+	text, err := t.render(e)
+	if err != nil {
+		return err
+	}
+
+	body, err := t.payload(text)
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+
+	resp, err := n.client.Post(t.cfg.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("posting webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// Close stops accepting events and waits for queued deliveries to finish
+func (n *Notifier) Close() {
+	n.once.Do(func() {
+		close(n.queue)
+		n.wg.Wait()
+	})
+}
@@ -0,0 +1,102 @@
+// internal/notify/notify_test.go
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"tig/internal/config"
+	"tig/internal/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type recorder struct {
+	mu       sync.Mutex
+	payloads []map[string]string
+}
+
+func (r *recorder) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		r.mu.Lock()
+		r.payloads = append(r.payloads, body)
+		r.mu.Unlock()
+	}
+}
+
+func TestNotifier_RoutesAndFormats(t *testing.T) {
+	slack := &recorder{}
+	slackSrv := httptest.NewServer(slack.handler(t))
+	defer slackSrv.Close()
+
+	teams := &recorder{}
+	teamsSrv := httptest.NewServer(teams.handler(t))
+	defer teamsSrv.Close()
+
+	n, err := New(config.Notifications{
+		Webhooks: []config.Webhook{
+			{
+				Name:    "release-channel",
+				Kind:    "slack",
+				URL:     slackSrv.URL,
+				Channel: "#releases",
+				Events:  []string{string(events.StreamMerged)},
+				Streams: []string{"release-1"},
+				Templates: map[string]string{
+					string(events.StreamMerged): "merged {{.StreamID}}: {{.Summary}}",
+				},
+			},
+			{
+				Name:   "breaking",
+				Kind:   "teams",
+				URL:    teamsSrv.URL,
+				Events: []string{string(events.BreakingChange)},
+			},
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	bus := events.NewBus()
+	bus.SubscribeAll(n.Handle)
+
+	bus.Publish(events.Event{Type: events.StreamMerged, StreamID: "release-1", Summary: "v1.2"})
+	bus.Publish(events.Event{Type: events.StreamMerged, StreamID: "feature-9", Summary: "ignored"})
+	bus.Publish(events.Event{Type: events.BreakingChange, IntentID: "abc", Summary: "drop v1 API"})
+	n.Close()
+
+	require.Len(t, slack.payloads, 1)
+	assert.Equal(t, "merged release-1: v1.2", slack.payloads[0]["text"])
+	assert.Equal(t, "#releases", slack.payloads[0]["channel"])
+
+	require.Len(t, teams.payloads, 1)
+	assert.Equal(t, "MessageCard", teams.payloads[0]["@type"])
+	assert.Contains(t, teams.payloads[0]["text"], "drop v1 API")
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(config.Notifications{
+		Webhooks: []config.Webhook{{Name: "no-url", Kind: "slack"}},
+	}, zap.NewNop())
+	assert.Error(t, err)
+
+	_, err = New(config.Notifications{
+		Webhooks: []config.Webhook{{Name: "bad", Kind: "irc", URL: "http://example"}},
+	}, zap.NewNop())
+	assert.Error(t, err)
+
+	_, err = New(config.Notifications{
+		Webhooks: []config.Webhook{{
+			Name:      "bad-template",
+			URL:       "http://example",
+			Templates: map[string]string{"stream.merged": "{{.Missing"},
+		}},
+	}, zap.NewNop())
+	assert.Error(t, err)
+}
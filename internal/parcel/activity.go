@@ -0,0 +1,47 @@
+// internal/parcel/activity.go
+package parcel
+
+import (
+	"fmt"
+
+	"tig/internal/intent"
+	"tig/internal/stream"
+)
+
+// LatestIntent returns the most recently created intent and the stream it
+// was added to, if any. Both are nil in a repository without intents.
+func (p *Parcel) LatestIntent() (*intent.Intent, *stream.Stream, error) {
+	intents, err := p.IntentStore.List()
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing intents: %w", err)
+	}
+	var latest *intent.Intent
+	for _, i := range intents {
+		if latest == nil || i.CreatedAt.After(latest.CreatedAt) {
+			latest = i
+		}
+	}
+	if latest == nil {
+		return nil, nil, nil
+	}
+
+	streams, err := p.StreamStore.List()
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing streams: %w", err)
+	}
+	for _, s := range streams {
+		for _, id := range s.State.Intents {
+			if id == latest.ID {
+				return latest, s, nil
+			}
+		}
+	}
+	return latest, nil, nil
+}
+
+// Head returns the changeset a stream's tree ends at: its last landed
+// changeset, or else the newest changeset of its intents. It is empty for
+// a stream without any.
+func (p *Parcel) Head(st *stream.Stream) (string, error) {
+	return p.newestChangeSet(st.ID, st.State.Head)
+}
@@ -0,0 +1,39 @@
+// internal/parcel/attest.go
+package parcel
+
+import (
+	"tig/internal/attest"
+	"tig/internal/config"
+)
+
+// Attest records the artifacts at paths as built from a changeset, given
+// by changeset or intent reference
+func (p *Parcel) Attest(ref string, paths []string) ([]attest.Attestation, error) {
+	cs, err := p.ResolveChangeSet(ref)
+	if err != nil {
+		return nil, err
+	}
+	attestations := make([]attest.Attestation, 0, len(paths))
+	for _, path := range paths {
+		a, err := attest.Record(p.DB, cs, path, config.Author())
+		if err != nil {
+			return nil, err
+		}
+		attestations = append(attestations, *a)
+	}
+	return attestations, nil
+}
+
+// VerifyArtifact returns the attestations proving which changeset built
+// the artifact at path, optionally requiring a particular changeset
+func (p *Parcel) VerifyArtifact(path, ref string) ([]attest.Attestation, error) {
+	var changeSetID string
+	if ref != "" {
+		cs, err := p.ResolveChangeSet(ref)
+		if err != nil {
+			return nil, err
+		}
+		changeSetID = cs.ID
+	}
+	return attest.Verify(p.DB, path, changeSetID)
+}
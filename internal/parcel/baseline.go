@@ -0,0 +1,139 @@
+// internal/parcel/baseline.go
+package parcel
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tig/internal/change"
+	"tig/internal/errors"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Baseline is a recorded tree the working tree can be compared against
+type Baseline struct {
+	Kind        string                      `json:"kind"` // "intent" or "stream"
+	ID          string                      `json:"id"`
+	Name        string                      `json:"name"` // Intent description or stream name
+	ChangeSetID string                      `json:"changeset_id"`
+	Files       map[string]change.FileState `json:"-"` // Keyed by slash-separated path
+}
+
+// ResolveBaseline finds the tree of an intent, as of the changeset that
+// committed it, or of a stream, as of the last intent landed on it. The
+// reference may be prefixed with "intent:" or "stream:" when an ID or
+// name could mean either.
+func (p *Parcel) ResolveBaseline(ref string) (*Baseline, error) {
+	kind, id, qualified := strings.Cut(ref, ":")
+	if !qualified || (kind != "intent" && kind != "stream") {
+		kind, id = "", ref
+	}
+
+	var b *Baseline
+	if kind == "" || kind == "intent" {
+		if i, err := p.ResolveIntent(id); err == nil {
+			if i.ChangeSetID == "" {
+				return nil, errors.ValidationError(fmt.Sprintf("intent %s has no changeset", i.ID), nil)
+			}
+			b = &Baseline{Kind: "intent", ID: i.ID, Name: i.Description, ChangeSetID: i.ChangeSetID}
+		} else if kind == "intent" {
+			return nil, err
+		}
+	}
+	if kind == "" || kind == "stream" {
+		if s, err := p.ResolveStream(id); err == nil {
+			if b != nil {
+				return nil, errors.ValidationError(fmt.Sprintf("%s names both an intent and a stream; prefix it with intent: or stream:", ref), nil)
+			}
+			head, err := p.streamHead(s.ID, s.State.Head)
+			if err != nil {
+				return nil, err
+			}
+			b = &Baseline{Kind: "stream", ID: s.ID, Name: s.Name, ChangeSetID: head}
+		} else if kind == "stream" {
+			return nil, err
+		}
+	}
+	if b == nil {
+		return nil, errors.NotFound(fmt.Sprintf("no intent or stream matches %s", ref))
+	}
+
+	err := p.DB.View(func(txn *badger.Txn) error {
+		var err error
+		b.Files, err = change.TreeAt(txn, b.ChangeSetID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// streamHead returns the changeset a stream's tree ends at: its last
+// landed changeset, or else the newest changeset of its intents
+func (p *Parcel) streamHead(streamID, head string) (string, error) {
+	newest, err := p.newestChangeSet(streamID, head)
+	if err != nil {
+		return "", err
+	}
+	if newest == "" {
+		return "", errors.ValidationError("stream has no changesets to compare against", nil)
+	}
+	return newest, nil
+}
+
+// newestChangeSet is streamHead for streams that may have no changesets,
+// returning an empty ID for them
+func (p *Parcel) newestChangeSet(streamID, head string) (string, error) {
+	if head != "" {
+		return head, nil
+	}
+	intents, err := p.GetStreamIntents(streamID)
+	if err != nil {
+		return "", err
+	}
+	var newest string
+	sort.Slice(intents, func(x, y int) bool { return intents[x].CreatedAt.Before(intents[y].CreatedAt) })
+	for _, i := range intents {
+		if i.ChangeSetID != "" {
+			newest = i.ChangeSetID
+		}
+	}
+	return newest, nil
+}
+
+// StatusAgainst lists how the working tree differs from a baseline rather
+// than from the tracked file states. Changes to gated files are marked
+// gated.
+func (p *Parcel) StatusAgainst(b *Baseline) ([]shared.Change, error) {
+	current, _, err := p.readSnapshot(p.Root)
+	if err != nil {
+		return nil, fmt.Errorf("reading working tree: %w", err)
+	}
+	status, err := p.Status()
+	if err != nil {
+		return nil, err
+	}
+	gated := make(map[string]bool)
+	for _, c := range status {
+		if c.Gated {
+			gated[filepath.ToSlash(c.Path)] = true
+		}
+	}
+
+	base := make(map[string]change.FileState, len(b.Files))
+	for path, state := range b.Files {
+		if !p.ignored(filepath.FromSlash(path)) {
+			base[path] = state
+		}
+	}
+	changes := diffSnapshots(base, current, &ImportedSnapshot{})
+	for n := range changes {
+		changes[n].Gated = gated[filepath.ToSlash(changes[n].Path)]
+	}
+	return changes, nil
+}
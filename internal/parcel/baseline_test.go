@@ -0,0 +1,60 @@
+// internal/parcel/baseline_test.go
+package parcel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tigerrors "tig/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestStatusAgainst(t *testing.T) {
+	snapshots := t.TempDir()
+	for name, content := range map[string]string{"v1/a.txt": "one", "v1/gone.txt": "x", "v2/a.txt": "two", "v2/b.txt": "b"} {
+		p := filepath.Join(snapshots, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	imported, err := p.ImportSnapshots(snapshots, SnapshotOptions{Checkout: true}, nil)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "c.txt"), []byte("new"), 0644))
+
+	// Against the latest intent only the new file shows
+	b, err := p.ResolveBaseline("intent:" + imported[1].IntentID)
+	require.NoError(t, err)
+	assert.Equal(t, "intent", b.Kind)
+	assert.Len(t, b.Files, 2)
+	changes, err := p.StatusAgainst(b)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "c.txt", changes[0].Path)
+	assert.Equal(t, "add", changes[0].Type)
+
+	// Against the first, the later history shows as well
+	b, err = p.ResolveBaseline(imported[0].IntentID)
+	require.NoError(t, err)
+	changes, err = p.StatusAgainst(b)
+	require.NoError(t, err)
+	types := make(map[string]string)
+	for _, c := range changes {
+		types[c.Path] = c.Type
+		assert.False(t, c.Gated)
+	}
+	assert.Equal(t, map[string]string{"a.txt": "modify", "b.txt": "add", "c.txt": "add", "gone.txt": "delete"}, types)
+
+	_, err = p.ResolveBaseline("no-such-thing")
+	var tigErr *tigerrors.Error
+	assert.ErrorAs(t, err, &tigErr)
+}
@@ -0,0 +1,449 @@
+// internal/parcel/bisect.go
+package parcel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"tig/internal/change"
+	tigerrors "tig/internal/errors"
+	"tig/internal/intent"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+const bisectKey = "bisect"
+
+// Bisect verdicts
+const (
+	BisectGood = "good"
+	BisectBad  = "bad"
+	BisectSkip = "skip"
+)
+
+// Bisect is a binary search through the committed intents for the one
+// that introduced a regression
+type Bisect struct {
+	Candidates []string                    `json:"candidates"` // Committed intent IDs in the order they were committed
+	Good       int                         `json:"good"`       // Index of the latest intent known good, -1 until one is marked
+	Bad        int                         `json:"bad"`        // Index of the earliest intent known bad
+	Skipped    []string                    `json:"skipped,omitempty"`
+	Current    string                      `json:"current,omitempty"` // Intent whose tree is checked out
+	Original   map[string]change.FileState `json:"original"`          // Tracked files before the bisect, restored by reset
+	Log        []BisectStep                `json:"log,omitempty"`
+	StartedAt  time.Time                   `json:"started_at"`
+}
+
+// BisectStep records an intent being marked
+type BisectStep struct {
+	IntentID string    `json:"intent_id"`
+	Verdict  string    `json:"verdict"`
+	At       time.Time `json:"at"`
+}
+
+// BisectStatus is where a bisect stands after a step
+type BisectStatus struct {
+	Next      *intent.Intent `json:"next,omitempty"`     // Checked out for testing
+	Culprit   *intent.Intent `json:"culprit,omitempty"`  // The first bad intent, once found
+	Suspects  []string       `json:"suspects,omitempty"` // Intents that may be the first bad one when skipped intents leave it open
+	Remaining int            `json:"remaining"`          // Intents left to test
+	Waiting   bool           `json:"waiting,omitempty"`  // No good intent has been marked yet
+}
+
+// Done reports whether the bisect has narrowed the regression down as
+// far as it can
+func (s *BisectStatus) Done() bool {
+	return s.Culprit != nil || len(s.Suspects) > 0
+}
+
+// Bisecting returns the bisect in progress, or nil without one
+func (p *Parcel) Bisecting() (*Bisect, error) {
+	var b *Bisect
+	err := p.DB.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(bisectKey))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		b = &Bisect{}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, b)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading bisect: %w", err)
+	}
+	return b, nil
+}
+
+func (p *Parcel) saveBisect(b *Bisect) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("marshaling bisect: %w", err)
+	}
+	return p.DB.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(bisectKey), data)
+	})
+}
+
+// BisectStart starts searching the committed intents, or those of a
+// stream when streamRef is set, for the one that introduced a
+// regression. bad defaults to the most recently committed intent and good
+// may be left for BisectMark. With both known, the intent halfway between
+// is checked out. The working tree must be clean; BisectReset brings it
+// back.
+func (p *Parcel) BisectStart(bad, good, streamRef string) (*BisectStatus, error) {
+	if b, err := p.Bisecting(); err != nil {
+		return nil, err
+	} else if b != nil {
+		return nil, fmt.Errorf("%w: a bisect is already in progress; reset it first", tigerrors.ErrConflict)
+	}
+	if err := p.RequireCleanTree(); err != nil {
+		return nil, err
+	}
+
+	candidates, err := p.bisectCandidates(streamRef)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, tigerrors.ValidationError("no committed intents to bisect", nil)
+	}
+	states, err := p.fileStates()
+	if err != nil {
+		return nil, err
+	}
+	b := &Bisect{
+		Candidates: candidates,
+		Good:       -1,
+		Bad:        len(candidates) - 1,
+		Original:   make(map[string]change.FileState, len(states)),
+		StartedAt:  time.Now(),
+	}
+	for path, state := range states {
+		b.Original[filepath.ToSlash(path)] = state
+	}
+
+	if bad != "" {
+		if err := b.mark(p, BisectBad, bad); err != nil {
+			return nil, err
+		}
+	}
+	if good != "" {
+		if err := b.mark(p, BisectGood, good); err != nil {
+			return nil, err
+		}
+	}
+	return p.bisectNext(b)
+}
+
+// BisectMark records whether an intent, by default the one checked out,
+// has the regression, or that it cannot be tested, and checks out the
+// next intent to test
+func (p *Parcel) BisectMark(verdict, ref string) (*BisectStatus, error) {
+	b, err := p.requireBisect()
+	if err != nil {
+		return nil, err
+	}
+	if err := b.mark(p, verdict, ref); err != nil {
+		return nil, err
+	}
+	return p.bisectNext(b)
+}
+
+// BisectState returns where the bisect in progress stands
+func (p *Parcel) BisectState() (*BisectStatus, error) {
+	b, err := p.requireBisect()
+	if err != nil {
+		return nil, err
+	}
+	return p.bisectNext(b)
+}
+
+// BisectRecorded marks intents from the recorded results of a check,
+// such as the test results RecordTests stores, for as long as the intent
+// the search needs next has one: a passed check is good and a failed one
+// bad. Without a good intent yet, the latest one committed before the bad
+// intent whose check passed is marked good. It returns the steps taken
+// and where the bisect stands, with the first intent lacking a result
+// checked out.
+func (p *Parcel) BisectRecorded(check string) ([]BisectStep, *BisectStatus, error) {
+	if check == "" {
+		check = intent.TestsCheck
+	}
+	b, err := p.requireBisect()
+	if err != nil {
+		return nil, nil, err
+	}
+	verdict := func(n int) (string, error) {
+		i, err := p.IntentStore.Get(b.Candidates[n])
+		if err != nil {
+			return "", err
+		}
+		switch i.CheckStatus(check) {
+		case intent.CheckPassed:
+			return BisectGood, nil
+		case intent.CheckFailed:
+			return BisectBad, nil
+		}
+		return "", nil
+	}
+
+	logged := len(b.Log)
+	if b.Good < 0 {
+		for n := b.Bad - 1; n >= 0; n-- {
+			v, err := verdict(n)
+			if err != nil {
+				return nil, nil, err
+			}
+			if v == BisectGood {
+				if err := b.mark(p, v, b.Candidates[n]); err != nil {
+					return nil, nil, err
+				}
+				break
+			}
+		}
+	}
+	for b.Good >= 0 {
+		testable := b.testable()
+		if len(testable) == 0 {
+			break
+		}
+		n := b.pick(testable)
+		v, err := verdict(n)
+		if err != nil {
+			return nil, nil, err
+		}
+		if v == "" {
+			break
+		}
+		if err := b.mark(p, v, b.Candidates[n]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	status, err := p.bisectNext(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b.Log[logged:], status, nil
+}
+
+// BisectReset ends the bisect in progress and restores the tracked files
+// it started from
+func (p *Parcel) BisectReset() error {
+	b, err := p.requireBisect()
+	if err != nil {
+		return err
+	}
+	before, err := p.fileStates()
+	if err != nil {
+		return err
+	}
+	if err := p.replaceFileStates(before, b.Original); err != nil {
+		return err
+	}
+	if err := p.CheckoutTracked(before); err != nil {
+		return fmt.Errorf("restoring working tree: %w", err)
+	}
+	return p.DB.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(bisectKey))
+	})
+}
+
+func (p *Parcel) requireBisect() (*Bisect, error) {
+	b, err := p.Bisecting()
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, tigerrors.ValidationError("no bisect in progress; start one with tig bisect start", nil)
+	}
+	return b, nil
+}
+
+// bisectCandidates lists the committed intents, of a stream when
+// streamRef is set, in the order their changesets were recorded
+func (p *Parcel) bisectCandidates(streamRef string) ([]string, error) {
+	var intents []*intent.Intent
+	var err error
+	if streamRef != "" {
+		st, err := p.ResolveStream(streamRef)
+		if err != nil {
+			return nil, err
+		}
+		intents, err = p.GetStreamIntents(st.ID)
+		if err != nil {
+			return nil, err
+		}
+	} else if intents, err = p.ListIntents(); err != nil {
+		return nil, err
+	}
+
+	type committed struct {
+		id string
+		at time.Time
+	}
+	var list []committed
+	err = p.DB.View(func(txn *badger.Txn) error {
+		for _, i := range intents {
+			if i.ChangeSetID == "" {
+				continue
+			}
+			cs, err := change.GetChangeSet(txn, i.ChangeSetID)
+			if err != nil {
+				return fmt.Errorf("reading changeset of intent %s: %w", i.ID, err)
+			}
+			list = append(list, committed{i.ID, cs.CreatedAt})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(list, func(x, y int) bool { return list[x].at.Before(list[y].at) })
+
+	ids := make([]string, len(list))
+	for n, c := range list {
+		ids[n] = c.id
+	}
+	return ids, nil
+}
+
+// mark records a verdict on the intent ref names, or the one checked out
+func (b *Bisect) mark(p *Parcel, verdict, ref string) error {
+	id := b.Current
+	if ref != "" {
+		i, err := p.ResolveIntent(ref)
+		if err != nil {
+			return err
+		}
+		id = i.ID
+	}
+	if id == "" {
+		return tigerrors.ValidationError("no intent is checked out; name the intent to mark", nil)
+	}
+	n := slices.Index(b.Candidates, id)
+	if n < 0 {
+		return tigerrors.ValidationError(fmt.Sprintf("intent %s is not among the intents being bisected", id), nil)
+	}
+
+	switch verdict {
+	case BisectGood:
+		if n >= b.Bad {
+			return tigerrors.ValidationError(fmt.Sprintf("intent %s cannot be good: it was committed after bad intent %s", id, b.Candidates[b.Bad]), nil)
+		}
+		b.Good = max(b.Good, n)
+	case BisectBad:
+		if n <= b.Good {
+			return tigerrors.ValidationError(fmt.Sprintf("intent %s cannot be bad: it was committed before good intent %s", id, b.Candidates[b.Good]), nil)
+		}
+		b.Bad = min(b.Bad, n)
+	case BisectSkip:
+		if !slices.Contains(b.Skipped, id) {
+			b.Skipped = append(b.Skipped, id)
+		}
+	default:
+		return tigerrors.ValidationError(fmt.Sprintf("unknown verdict %q", verdict), nil)
+	}
+	b.Log = append(b.Log, BisectStep{IntentID: id, Verdict: verdict, At: time.Now()})
+	return nil
+}
+
+// bisectNext checks out the untested intent nearest the middle of the
+// range still in question and saves the bisect
+func (p *Parcel) bisectNext(b *Bisect) (*BisectStatus, error) {
+	status := &BisectStatus{}
+	if b.Good < 0 {
+		status.Waiting = true
+		status.Remaining = b.Bad
+		return status, p.saveBisect(b)
+	}
+
+	testable := b.testable()
+	status.Remaining = len(testable)
+
+	if len(testable) == 0 {
+		if b.Bad-b.Good > 1 {
+			// Only skipped intents lie between good and bad
+			status.Suspects = append([]string(nil), b.Candidates[b.Good+1:b.Bad+1]...)
+		} else {
+			culprit, err := p.IntentStore.Get(b.Candidates[b.Bad])
+			if err != nil {
+				return nil, err
+			}
+			status.Culprit = culprit
+		}
+		return status, p.saveBisect(b)
+	}
+
+	next := b.pick(testable)
+	if b.Candidates[next] != b.Current {
+		if err := p.checkoutIntent(b, b.Candidates[next]); err != nil {
+			return nil, err
+		}
+	}
+	i, err := p.IntentStore.Get(b.Current)
+	if err != nil {
+		return nil, err
+	}
+	status.Next = i
+	return status, p.saveBisect(b)
+}
+
+// testable returns the indexes of the intents between the good and the
+// bad one that have not been skipped
+func (b *Bisect) testable() []int {
+	var testable []int
+	for n := b.Good + 1; n < b.Bad; n++ {
+		if !slices.Contains(b.Skipped, b.Candidates[n]) {
+			testable = append(testable, n)
+		}
+	}
+	return testable
+}
+
+// pick returns the testable intent nearest the middle of the range still
+// in question
+func (b *Bisect) pick(testable []int) int {
+	mid := (b.Good + b.Bad) / 2
+	next := testable[0]
+	for _, n := range testable[1:] {
+		if abs(n-mid) < abs(next-mid) {
+			next = n
+		}
+	}
+	return next
+}
+
+// checkoutIntent writes the files as of an intent's changeset into the
+// working tree
+func (p *Parcel) checkoutIntent(b *Bisect, id string) error {
+	i, err := p.IntentStore.Get(id)
+	if err != nil {
+		return err
+	}
+	before, err := p.fileStates()
+	if err != nil {
+		return err
+	}
+	if _, err := p.checkoutChangeSet(before, i.ChangeSetID); err != nil {
+		return fmt.Errorf("checking out intent %s: %w", id, err)
+	}
+	b.Current = id
+	return nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,148 @@
+// internal/parcel/bisect_test.go
+package parcel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tig/internal/intent"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestBisect(t *testing.T) {
+	// Versions 1 to 8 of a file; the regression appears in version 5
+	snapshots := t.TempDir()
+	for v := 1; v <= 8; v++ {
+		content := "ok"
+		if v >= 5 {
+			content = "broken"
+		}
+		dir := filepath.Join(snapshots, fmt.Sprintf("v%d", v))
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "app.txt"), []byte(fmt.Sprintf("%s %d", content, v)), 0644))
+	}
+
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	imported, err := p.ImportSnapshots(snapshots, SnapshotOptions{Checkout: true}, nil)
+	require.NoError(t, err)
+	require.Len(t, imported, 8)
+
+	read := func() string {
+		data, err := os.ReadFile(filepath.Join(root, "app.txt"))
+		require.NoError(t, err)
+		return string(data)
+	}
+	require.Equal(t, "broken 8", read())
+
+	_, err = p.BisectMark(BisectGood, "")
+	assert.Error(t, err, "marking without a bisect in progress")
+
+	status, err := p.BisectStart("", "", "")
+	require.NoError(t, err)
+	assert.True(t, status.Waiting)
+	status, err = p.BisectMark(BisectGood, imported[0].IntentID)
+	require.NoError(t, err)
+	require.NotNil(t, status.Next)
+	assert.Equal(t, 6, status.Remaining)
+
+	_, err = p.BisectStart("", "", "")
+	assert.Error(t, err, "starting a second bisect")
+
+	steps := 0
+	for !status.Done() {
+		steps++
+		require.Less(t, steps, 5)
+		verdict := BisectGood
+		if strings.HasPrefix(read(), "broken") {
+			verdict = BisectBad
+		}
+		status, err = p.BisectMark(verdict, "")
+		require.NoError(t, err)
+	}
+	require.NotNil(t, status.Culprit)
+	assert.Equal(t, imported[4].IntentID, status.Culprit.ID)
+
+	require.NoError(t, p.BisectReset())
+	assert.Equal(t, "broken 8", read())
+	b, err := p.Bisecting()
+	require.NoError(t, err)
+	assert.Nil(t, b)
+
+	// Skipping the intents around the regression leaves it open
+	_, err = p.BisectStart(imported[5].IntentID, imported[2].IntentID, "")
+	require.NoError(t, err)
+	_, err = p.BisectMark(BisectSkip, imported[3].IntentID)
+	require.NoError(t, err)
+	status, err = p.BisectMark(BisectSkip, imported[4].IntentID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{imported[3].IntentID, imported[4].IntentID, imported[5].IntentID}, status.Suspects)
+
+	_, err = p.BisectMark(BisectGood, imported[6].IntentID)
+	assert.Error(t, err, "good after bad")
+	require.NoError(t, p.BisectReset())
+}
+
+func TestBisectRecorded(t *testing.T) {
+	snapshots := t.TempDir()
+	for v := 1; v <= 8; v++ {
+		dir := filepath.Join(snapshots, fmt.Sprintf("v%d", v))
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "app.txt"), []byte(fmt.Sprint(v)), 0644))
+	}
+
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	imported, err := p.ImportSnapshots(snapshots, SnapshotOptions{Checkout: true}, nil)
+	require.NoError(t, err)
+
+	// CI ran the tests on some intents; the regression is in version 5,
+	// whose results are missing
+	record := func(n int, failed int) {
+		_, err := p.RecordTests(imported[n].IntentID, "", &intent.TestSummary{Total: 10, Passed: 10 - failed, Failed: failed}, "")
+		require.NoError(t, err)
+	}
+	record(1, 0)
+	record(3, 0)
+	record(5, 1)
+	record(7, 2)
+
+	i, err := p.GetIntent(imported[5].IntentID)
+	require.NoError(t, err)
+	require.Len(t, i.Checks, 1)
+	assert.Equal(t, intent.TestsCheck, i.Checks[0].Name)
+	assert.Equal(t, intent.CheckFailed, i.Checks[0].Status)
+	assert.Equal(t, 1, i.Checks[0].Tests.Failed)
+
+	_, err = p.BisectStart("", "", "")
+	require.NoError(t, err)
+	steps, status, err := p.BisectRecorded("")
+	require.NoError(t, err)
+	require.NotEmpty(t, steps)
+	assert.Equal(t, BisectStep{IntentID: imported[3].IntentID, Verdict: BisectGood}, BisectStep{IntentID: steps[0].IntentID, Verdict: steps[0].Verdict})
+
+	// Only version 5 is left, and it is checked out for testing
+	require.NotNil(t, status.Next)
+	assert.Equal(t, imported[4].IntentID, status.Next.ID)
+	assert.Equal(t, 1, status.Remaining)
+
+	status, err = p.BisectMark(BisectBad, "")
+	require.NoError(t, err)
+	require.NotNil(t, status.Culprit)
+	assert.Equal(t, imported[4].IntentID, status.Culprit.ID)
+	require.NoError(t, p.BisectReset())
+}
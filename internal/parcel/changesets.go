@@ -0,0 +1,51 @@
+// internal/parcel/changesets.go
+package parcel
+
+import (
+	"fmt"
+	"strings"
+
+	"tig/internal/change"
+	"tig/internal/storage"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ResolveChangeSet looks up a changeset by full ID or unique ID prefix,
+// or as the changeset of an intent
+func (p *Parcel) ResolveChangeSet(ref string) (*change.ChangeSet, error) {
+	var found *change.ChangeSet
+	err := p.DB.View(func(txn *badger.Txn) error {
+		if cs, err := change.GetChangeSet(txn, ref); err == nil {
+			found = cs
+			return nil
+		}
+
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte("changeset:" + ref)
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		var id string
+		for it.Rewind(); it.Valid(); it.Next() {
+			if id != "" {
+				return fmt.Errorf("changeset prefix %s is ambiguous", ref)
+			}
+			id = strings.TrimPrefix(string(it.Item().Key()), "changeset:")
+		}
+		if id == "" {
+			return nil
+		}
+		cs, err := change.GetChangeSet(txn, id)
+		found = cs
+		return err
+	})
+	if err != nil || found != nil {
+		return found, err
+	}
+
+	if i, err := p.ResolveIntent(ref); err == nil && i.ChangeSetID != "" {
+		return p.ResolveChangeSet(i.ChangeSetID)
+	}
+	return nil, fmt.Errorf("%w: no changeset or intent matches %s", storage.ErrNotFound, ref)
+}
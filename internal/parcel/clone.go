@@ -0,0 +1,255 @@
+// internal/parcel/clone.go
+package parcel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"tig/internal/change"
+	"tig/internal/config"
+	"tig/internal/fsck"
+	"tig/internal/glob"
+	"tig/internal/remote"
+	"tig/internal/safe"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.uber.org/zap"
+)
+
+// CloneOptions configures Clone
+type CloneOptions struct {
+	Partial    bool // Copy metadata only and fetch content on demand
+	NoCheckout bool // Don't write the tracked tree into the working directory
+	Workers    int  // Parallel content downloads
+	ReadOnly   bool // Check out files read-only, allowing hardlinks into the safe
+}
+
+// Clone creates a repository at root from the server at url. The returned
+// parcel must be closed by the caller.
+func Clone(ctx context.Context, url, root string, opts CloneOptions, logger *zap.Logger) (*Parcel, error) {
+	if entries, err := os.ReadDir(root); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("destination %s is not empty", root)
+	}
+	if err := Initialize(root); err != nil {
+		return nil, fmt.Errorf("initializing directories: %w", err)
+	}
+
+	client := remote.NewClient(url)
+	records, err := client.Metadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching metadata: %w", err)
+	}
+
+	// Import before the tracker starts so it sees the remote's tracked files
+	db, err := openDB(root, config.Cache{})
+	if err != nil {
+		return nil, err
+	}
+	err = remote.ImportMetadata(db, records)
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.LoadRepo(root)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Remote = config.Remote{URL: client.BaseURL, Partial: opts.Partial}
+	if err := config.SaveRepo(root, cfg); err != nil {
+		return nil, fmt.Errorf("saving repo config: %w", err)
+	}
+
+	p, err := New(root, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every referenced object starts out remote with its final ref count
+	live, err := fsck.New(p.DB, p.Safe).LiveRefs()
+	if err != nil {
+		p.Close()
+		return nil, fmt.Errorf("collecting references: %w", err)
+	}
+	hashes := make([]string, 0, len(live))
+	for hash, refs := range live {
+		if err := p.Safe.AddRemote(hash, refs); err != nil {
+			p.Close()
+			return nil, fmt.Errorf("recording %s: %w", hash, err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	if !opts.Partial {
+		if _, err := p.Safe.Prefetch(hashes, safe.BatchOptions{Workers: opts.Workers}); err != nil {
+			p.Close()
+			return nil, fmt.Errorf("fetching content: %w", err)
+		}
+	}
+
+	if !opts.NoCheckout {
+		if _, err := p.Materialize(safe.CheckoutOptions{ReadOnly: opts.ReadOnly}); err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// CheckoutStats counts how materialized files were written
+type CheckoutStats struct {
+	Files      int `json:"files"`
+	Cloned     int `json:"cloned"`
+	Hardlinked int `json:"hardlinked"`
+	Copied     int `json:"copied"`
+}
+
+// Materialize writes every tracked file's recorded content into the working
+// tree, cloning it from the safe where the filesystem allows and fetching
+// it from the remote in partial clones, and refreshes the recorded file
+// states to match.
+func (p *Parcel) Materialize(opts safe.CheckoutOptions) (CheckoutStats, error) {
+	var stats CheckoutStats
+
+	states, err := p.fileStates()
+	if err != nil {
+		return stats, err
+	}
+
+	paths := make([]string, 0, len(states))
+	for path := range states {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		// Never let remote metadata write outside the working tree
+		if !filepath.IsLocal(path) {
+			return stats, fmt.Errorf("refusing to write %q outside the repository", path)
+		}
+
+		absPath := filepath.Join(p.Root, path)
+		if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+			return stats, fmt.Errorf("creating directory for %s: %w", path, err)
+		}
+
+		state := states[path]
+		fileOpts := opts
+		fileOpts.Mode = os.FileMode(state.Mode)
+		method, err := p.Safe.Checkout(state.Hash, absPath, fileOpts)
+		if err != nil {
+			return stats, fmt.Errorf("checking out %s: %w", path, err)
+		}
+		switch method {
+		case safe.Cloned:
+			stats.Cloned++
+		case safe.Hardlinked:
+			stats.Hardlinked++
+		default:
+			stats.Copied++
+		}
+		stats.Files++
+
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return stats, err
+		}
+		state.ModTime = info.ModTime()
+		state.Size = info.Size()
+		if err := p.putFileState(path, state); err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+// FetchBlobs downloads remote content for every version of the files
+// matching pattern, as recorded in changesets and the tracked tree. It
+// returns the number of objects fetched.
+func (p *Parcel) FetchBlobs(pattern string, opts safe.BatchOptions) (int, error) {
+	seen := make(map[string]bool)
+	var hashes []string
+	add := func(path, hash string) {
+		if hash != "" && !seen[hash] && glob.MatchPath(pattern, path) {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+	}
+
+	states, err := p.fileStates()
+	if err != nil {
+		return 0, err
+	}
+	for path, state := range states {
+		add(path, state.Hash)
+	}
+
+	err = p.DB.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte("changeset:")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var cs change.ChangeSet
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &cs)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			for _, c := range cs.Changes {
+				add(c.Path, c.NewHash)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("reading changesets: %w", err)
+	}
+
+	return p.Safe.Prefetch(hashes, opts)
+}
+
+// fileStates returns the tracked state of every file
+func (p *Parcel) fileStates() (map[string]change.FileState, error) {
+	states := make(map[string]change.FileState)
+	err := p.DB.View(func(txn *badger.Txn) error {
+		prefix := []byte("file_state:")
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var state change.FileState
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &state)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			states[string(bytes.TrimPrefix(it.Item().Key(), prefix))] = state
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading file states: %w", err)
+	}
+	return states, nil
+}
+
+func (p *Parcel) putFileState(path string, state change.FileState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshaling file state: %w", err)
+	}
+	return p.DB.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("file_state:"+path), data)
+	})
+}
@@ -0,0 +1,114 @@
+// internal/parcel/clone_test.go
+package parcel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/config"
+	"tig/internal/logging"
+	"tig/internal/safe"
+	"tig/internal/server"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// serveRepo creates a repository whose tracked tree holds main.go and
+// whose history holds an older version of it, and serves it over HTTP
+func serveRepo(t *testing.T) (url, oldHash, newHash string) {
+	src, err := New(t.TempDir(), zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { src.Close() })
+
+	oldHash, err = src.Safe.Store([]byte("package main // v1\n"))
+	require.NoError(t, err)
+	newHash, err = src.Safe.Store([]byte("package main // v2\n"))
+	require.NoError(t, err)
+
+	cs := change.ChangeSet{ID: "cs1", Changes: []shared.Change{
+		{Path: "cmd/main.go", NewHash: oldHash},
+	}}
+	require.NoError(t, src.DB.Update(func(txn *badger.Txn) error {
+		data, _ := json.Marshal(cs)
+		if err := txn.Set([]byte("changeset:cs1"), data); err != nil {
+			return err
+		}
+		data, _ = json.Marshal(change.FileState{Hash: newHash, ModTime: time.Now()})
+		return txn.Set([]byte("file_state:cmd/main.go"), data)
+	}))
+
+	srv, err := server.New(config.Default(), src.DB, src.Safe, src.Root, &logging.Logger{Logger: zap.NewNop()})
+	require.NoError(t, err)
+	t.Cleanup(func() { srv.Close() })
+
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts.URL, oldHash, newHash
+}
+
+func TestPartialClone(t *testing.T) {
+	url, oldHash, newHash := serveRepo(t)
+
+	root := filepath.Join(t.TempDir(), "clone")
+	p, err := Clone(context.Background(), url, root, CloneOptions{Partial: true}, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	// The checkout fetched the current version only
+	data, err := os.ReadFile(filepath.Join(root, "cmd", "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main // v2\n", string(data))
+
+	meta, err := p.Safe.Meta(newHash)
+	require.NoError(t, err)
+	assert.False(t, meta.Remote)
+	meta, err = p.Safe.Meta(oldHash)
+	require.NoError(t, err)
+	assert.True(t, meta.Remote)
+
+	n, err := p.FetchBlobs("*.go", safe.BatchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	meta, err = p.Safe.Meta(oldHash)
+	require.NoError(t, err)
+	assert.False(t, meta.Remote)
+	assert.Equal(t, uint32(1), meta.RefCount)
+
+	cfg, err := config.LoadRepo(root)
+	require.NoError(t, err)
+	assert.Equal(t, config.Remote{URL: url, Partial: true}, cfg.Remote)
+}
+
+func TestFullClone(t *testing.T) {
+	url, oldHash, _ := serveRepo(t)
+
+	root := filepath.Join(t.TempDir(), "clone")
+	p, err := Clone(context.Background(), url, root, CloneOptions{}, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	meta, err := p.Safe.Meta(oldHash)
+	require.NoError(t, err)
+	assert.False(t, meta.Remote)
+
+	stats, err := p.Materialize(safe.CheckoutOptions{ReadOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Files)
+	assert.Zero(t, stats.Copied)
+	info, err := os.Stat(filepath.Join(root, "cmd", "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0444), info.Mode().Perm())
+
+	_, err = Clone(context.Background(), url, root, CloneOptions{}, zap.NewNop())
+	assert.ErrorContains(t, err, "not empty")
+}
@@ -0,0 +1,288 @@
+// internal/parcel/commit.go
+package parcel
+
+import (
+	"fmt"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/config"
+	"tig/internal/intent"
+	intentStorage "tig/internal/intent/storage"
+	"tig/internal/storage"
+	"tig/internal/stream"
+	streamStorage "tig/internal/stream/storage"
+	"tig/shared/types"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CommitOptions describes the intent created by CommitIntent
+type CommitOptions struct {
+	Description string
+	Type        string
+	NoAutoMerge bool
+	StreamID    string // Stream the intent is added to, if any
+	Impact      intent.Impact
+	Extensions  map[string]any // Values of the repository's intent fields
+	Time        time.Time      // When the changes were made; zero means now
+	Author      string         // Recorded author; the current user by default
+	DependsOn   []string       // IDs of intents that must land before this one
+
+	// Detached records the changeset without updating the tracked file
+	// states, e.g. for history imported onto another branch. Each change's
+	// OldHash is kept as given.
+	Detached bool
+	// Also runs inside the unit of work that records the intent, for
+	// writes that must commit or fail with it
+	Also func(u *storage.UnitOfWork) error
+}
+
+// CommitIntent records the gated changes as a changeset and creates an
+// intent for it. The changeset, the intent, its stream membership and the
+// cleared gated changes are written in a single unit of work, so a failure
+// leaves none of them behind.
+func (p *Parcel) CommitIntent(opts CommitOptions) (*intent.Intent, *change.ChangeSet, error) {
+	changes, err := p.gatedChanges()
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.commit(changes, opts)
+}
+
+// gatedChanges returns the changes CommitIntent records
+func (p *Parcel) gatedChanges() ([]shared.Change, error) {
+	status, err := p.Tracker.Status()
+	if err != nil {
+		return nil, fmt.Errorf("reading gated changes: %w", err)
+	}
+	var changes []shared.Change
+	for _, c := range status {
+		if c.Gated {
+			changes = append(changes, c)
+		}
+	}
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("no changes to commit")
+	}
+	return changes, nil
+}
+
+// CommitChanges records the given changes, rather than the gated ones, as
+// a changeset with a new intent for it. Importers use it to replay history
+// whose content is already in the safe.
+func (p *Parcel) CommitChanges(changes []shared.Change, opts CommitOptions) (*intent.Intent, *change.ChangeSet, error) {
+	if len(changes) == 0 {
+		return nil, nil, fmt.Errorf("no changes to commit")
+	}
+	return p.commit(changes, opts)
+}
+
+// commit records changes as a changeset with a new intent for it
+func (p *Parcel) commit(changes []shared.Change, opts CommitOptions) (*intent.Intent, *change.ChangeSet, error) {
+	i, cs, err := p.newCommit(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = storage.Run(p.DB, func(u *storage.UnitOfWork) error {
+		return p.record(u, i, cs, changes, opts)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return i, cs, nil
+}
+
+// newCommit creates the intent and changeset a commit records
+func (p *Parcel) newCommit(opts CommitOptions) (*intent.Intent, *change.ChangeSet, error) {
+	if err := intent.CheckExtensions(opts.Extensions, p.IntentFields); err != nil {
+		return nil, nil, err
+	}
+	at := opts.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	author := opts.Author
+	if author == "" {
+		author = config.Author()
+	}
+
+	i := &intent.Intent{
+		ID:          uuid.New().String(),
+		Type:        opts.Type,
+		Description: opts.Description,
+		Impact:      opts.Impact,
+		Extensions:  opts.Extensions,
+		NoAutoMerge: opts.NoAutoMerge,
+		DependsOn:   opts.DependsOn,
+		Metadata:    intent.Metadata{Author: opts.Author},
+		CreatedAt:   at,
+	}
+	cs := &change.ChangeSet{
+		ID:          uuid.New().String(),
+		IntentID:    i.ID,
+		CreatedAt:   at,
+		Description: opts.Description,
+		Author:      author,
+	}
+	i.ChangeSetID = cs.ID
+	return i, cs, nil
+}
+
+// record writes the changeset for changes, its intent and their stream
+// membership in u
+func (p *Parcel) record(u *storage.UnitOfWork, i *intent.Intent, cs *change.ChangeSet, changes []shared.Change, opts CommitOptions) error {
+	intents, streams, err := p.txStores(u)
+	if err != nil {
+		return err
+	}
+
+	// Record the content each file replaces, or was copied from, so
+	// the changeset can be diffed later
+	cs.Changes = make([]shared.Change, len(changes))
+	copy(cs.Changes, changes)
+	for n, c := range cs.Changes {
+		if c.OldHash != "" || opts.Detached {
+			continue
+		}
+		if cs.Changes[n].OldHash, err = change.StateHash(u.Txn(), c.Path); err != nil {
+			return err
+		}
+	}
+	if !p.Copies.Disable {
+		threshold := p.Copies.Threshold
+		if threshold == 0 {
+			threshold = change.DefaultCopyThreshold
+		}
+		if err := change.DetectCopies(u.Txn(), p.Safe, cs.Changes, threshold); err != nil {
+			return fmt.Errorf("detecting copies: %w", err)
+		}
+	}
+	cs.Hash = change.HashChanges(cs.Changes)
+	i.Projects = p.Projects.Matching(changedPaths(cs.Changes))
+
+	if err := change.PutChangeSet(u.Txn(), cs); err != nil {
+		return fmt.Errorf("storing changeset: %w", err)
+	}
+	if err := intents.Create(i); err != nil {
+		return fmt.Errorf("creating intent: %w", err)
+	}
+	if opts.StreamID != "" {
+		if err := streams.AddIntent(opts.StreamID, i.ID); err != nil {
+			return fmt.Errorf("adding intent to stream: %w", err)
+		}
+		// Committing to a stream a merge left in conflict records the
+		// resolution
+		st, err := streams.Get(opts.StreamID)
+		if err != nil {
+			return err
+		}
+		if st.State.Status == stream.StatusConflict {
+			st.State.Status = stream.StatusStable
+			if err := streams.Update(st); err != nil {
+				return fmt.Errorf("updating stream: %w", err)
+			}
+		}
+	}
+	if opts.Also != nil {
+		if err := opts.Also(u); err != nil {
+			return err
+		}
+	}
+	if opts.Detached {
+		return nil
+	}
+	for _, c := range changes {
+		if err := change.ApplyChange(u.Txn(), c); err != nil {
+			return err
+		}
+	}
+
+	u.OnCommit(p.reloadGated)
+	return nil
+}
+
+// CherryPick copies an intent and its changeset onto another stream. The
+// copy, its changeset and the stream update commit together.
+func (p *Parcel) CherryPick(intentID, streamID string) (*intent.Intent, error) {
+	var picked *intent.Intent
+	err := storage.Run(p.DB, func(u *storage.UnitOfWork) error {
+		intents, streams, err := p.txStores(u)
+		if err != nil {
+			return err
+		}
+
+		src, err := intents.Get(intentID)
+		if err != nil {
+			return err
+		}
+		picked = &intent.Intent{
+			ID:          uuid.New().String(),
+			Type:        src.Type,
+			Description: src.Description,
+			Impact:      src.Impact,
+			Metadata:    src.Metadata,
+			NoAutoMerge: src.NoAutoMerge,
+			DependsOn:   src.DependsOn,
+		}
+
+		if src.ChangeSetID != "" {
+			cs, err := change.GetChangeSet(u.Txn(), src.ChangeSetID)
+			if err != nil {
+				return err
+			}
+			copied := *cs
+			copied.ID = uuid.New().String()
+			copied.ParentID = cs.ID
+			copied.IntentID = picked.ID
+			copied.CreatedAt = time.Now()
+			copied.Author = config.Author()
+			if err := change.PutChangeSet(u.Txn(), &copied); err != nil {
+				return fmt.Errorf("storing changeset: %w", err)
+			}
+			picked.ChangeSetID = copied.ID
+		}
+
+		if err := intents.Create(picked); err != nil {
+			return fmt.Errorf("creating intent: %w", err)
+		}
+		if err := streams.AddIntent(streamID, picked.ID); err != nil {
+			return fmt.Errorf("adding intent to stream: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cherry-picking intent %s: %w", intentID, err)
+	}
+	return picked, nil
+}
+
+// txStores returns the intent and stream stores bound to u. Only the
+// Badger-backed stores can join a unit of work.
+func (p *Parcel) txStores(u *storage.UnitOfWork) (*intentStorage.Tx, *streamStorage.Tx, error) {
+	intents, ok := p.IntentStore.(*intentStorage.Store)
+	if !ok {
+		return nil, nil, fmt.Errorf("intent store does not support transactions")
+	}
+	streams, ok := p.StreamStore.(*streamStorage.Store)
+	if !ok {
+		return nil, nil, fmt.Errorf("stream store does not support transactions")
+	}
+	return intents.With(u), streams.With(u), nil
+}
+
+// reloadGated brings the tracker's and workspace's in-memory gated
+// changes in line with the database after a commit cleared them
+func (p *Parcel) reloadGated() {
+	if reloader, ok := p.Tracker.(interface{ ReloadGated() error }); ok {
+		if err := reloader.ReloadGated(); err != nil {
+			p.Logger.Warn("Failed to reload gated changes", zap.Error(err))
+		}
+	}
+	if loader, ok := p.Workspace.(interface{ LoadGatedChanges() error }); ok {
+		if err := loader.LoadGatedChanges(); err != nil {
+			p.Logger.Warn("Failed to reload gated changes", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// internal/parcel/coverage.go
+package parcel
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"tig/internal/coverage"
+	"tig/internal/intent"
+)
+
+// RecordCoverage reads a coverage report, in the given format or one
+// detected from it, and records on an intent the coverage of the files it
+// changed and how it moved since coverage was last recorded for them
+func (p *Parcel) RecordCoverage(id string, r io.Reader, format string) (*intent.Intent, error) {
+	i, err := p.ResolveIntent(id)
+	if err != nil {
+		return nil, err
+	}
+	profile, format, err := coverage.Parse(r, format)
+	if err != nil {
+		return nil, err
+	}
+	if i.Coverage, err = coverage.Record(p.DB, i, profile, format, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := p.IntentStore.Update(i); err != nil {
+		return nil, fmt.Errorf("updating intent: %w", err)
+	}
+	return i, nil
+}
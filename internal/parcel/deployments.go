@@ -0,0 +1,103 @@
+// internal/parcel/deployments.go
+package parcel
+
+import (
+	"fmt"
+	"time"
+
+	"tig/internal/deploy"
+	"tig/internal/errors"
+	"tig/internal/intent"
+)
+
+// Deploy records that a release tag, or else the head of a stream, is
+// running in an environment, and marks its intents as deployed there. It
+// returns the deployment and the intents that reached the environment for
+// the first time.
+func (p *Parcel) Deploy(env, tag, streamRef, url string) (*deploy.Deployment, []string, error) {
+	d, err := p.deployment(env, tag, streamRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	d.URL = url
+	first, err := deploy.Record(p.DB, p.IntentStore, d)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, first, nil
+}
+
+// deployment describes deploying either a release or a stream
+func (p *Parcel) deployment(env, tag, streamRef string) (*deploy.Deployment, error) {
+	if (tag == "") == (streamRef == "") {
+		return nil, errors.ValidationError("deploy either a release or a stream", nil)
+	}
+	if tag != "" {
+		return deploy.FromRelease(p.DB, p.Safe, env, tag)
+	}
+	st, err := p.ResolveStream(streamRef)
+	if err != nil {
+		return nil, err
+	}
+	head, err := p.newestChangeSet(st.ID, st.State.Head)
+	if err != nil {
+		return nil, err
+	}
+	return deploy.FromStream(env, st, head)
+}
+
+// Deployments lists the deployments to an environment, or to all of them
+// when env is empty, newest first
+func (p *Parcel) Deployments(env string) ([]deploy.Deployment, error) {
+	return deploy.List(p.DB, env)
+}
+
+// CurrentDeployments returns what is running in each environment
+func (p *Parcel) CurrentDeployments() ([]deploy.Deployment, error) {
+	return deploy.Current(p.DB)
+}
+
+// PlanRollback works out how to roll an environment back to a release
+// or, when tag is empty, to its last good deployment
+func (p *Parcel) PlanRollback(env, tag string) (*deploy.Rollback, error) {
+	var target *deploy.Deployment
+	if tag != "" {
+		var err error
+		if target, err = deploy.FromRelease(p.DB, p.Safe, env, tag); err != nil {
+			return nil, err
+		}
+	}
+	return deploy.PlanRollback(p.DB, env, target)
+}
+
+// Rollback redeploys the plan's target to its environment. With revert,
+// the intents leaving the environment also move to the reverted state;
+// the workflow must allow that for every one of them, or nothing is
+// changed.
+func (p *Parcel) Rollback(r *deploy.Rollback, revert bool, url, by string) (*deploy.Deployment, error) {
+	if revert {
+		for _, id := range r.Intents {
+			i, err := p.ResolveIntent(id)
+			if err != nil {
+				return nil, err
+			}
+			if state := i.CurrentState(); state != intent.StateReverted && !intent.CanTransition(p.Workflow, state, intent.StateReverted) {
+				return nil, fmt.Errorf("%w: intent %s cannot move from %s to reverted", errors.ErrConflict, i.ID, state)
+			}
+		}
+	}
+
+	d, err := deploy.ApplyRollback(p.DB, p.IntentStore, r, url, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if revert {
+		for _, id := range r.Intents {
+			if _, err := p.SetIntentState(id, intent.StateReverted, by); err != nil {
+				return d, fmt.Errorf("reverting intent %s: %w", id, err)
+			}
+			r.Reverted = append(r.Reverted, id)
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,251 @@
+// internal/parcel/describe.go
+package parcel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"tig/internal/config"
+	"tig/internal/diff"
+	"tig/internal/intent"
+	"tig/shared/types"
+)
+
+// maxSuggestionBytes bounds what a suggestion command or endpoint may
+// return
+const maxSuggestionBytes = 64 << 10
+
+// DescriptionProblem is a rule an intent description breaks
+type DescriptionProblem struct {
+	Processor string
+	Message   string
+}
+
+func (p DescriptionProblem) String() string {
+	return fmt.Sprintf("%s: %s", p.Processor, p.Message)
+}
+
+// DescriptionSuggestion is a description a processor proposes
+type DescriptionSuggestion struct {
+	Processor   string
+	Description string
+}
+
+// DescriptionReview collects what the processors made of a description
+type DescriptionReview struct {
+	Problems    []DescriptionProblem
+	Suggestions []DescriptionSuggestion
+	Refuse      bool // the repository refuses descriptions with problems
+}
+
+// DescriptionRefusedError is returned by CheckDescription when the
+// repository's rules refuse a description
+type DescriptionRefusedError struct {
+	Problems []DescriptionProblem
+}
+
+func (e *DescriptionRefusedError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for n, p := range e.Problems {
+		msgs[n] = p.String()
+	}
+	return fmt.Sprintf("description refused: %s; reword it or create the intent with --no-lint", strings.Join(msgs, "; "))
+}
+
+// DescriptionProcessors builds the processors configured in the
+// repository's describe settings: a linter when any rule is set, then the
+// suggestion command or endpoint
+func DescriptionProcessors(cfg config.Describe) ([]intent.DescriptionProcessor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var processors []intent.DescriptionProcessor
+	if cfg.MaxLength > 0 || cfg.Imperative || cfg.TicketPattern != "" {
+		l := &intent.DescriptionLinter{MaxLength: cfg.MaxLength, Imperative: cfg.Imperative}
+		if cfg.TicketPattern != "" {
+			l.Ticket = regexp.MustCompile(cfg.TicketPattern)
+		}
+		processors = append(processors, l)
+	}
+	switch {
+	case len(cfg.SuggestCommand) > 0:
+		processors = append(processors, &commandSuggester{argv: cfg.SuggestCommand})
+	case cfg.SuggestURL != "":
+		processors = append(processors, &endpointSuggester{url: cfg.SuggestURL, client: http.DefaultClient})
+	}
+	return processors, nil
+}
+
+// DescriptionDraft builds the draft of an intent over the gated changes.
+// With suggest set, processors that propose descriptions are asked for
+// one.
+func (p *Parcel) DescriptionDraft(description, intentType string, suggest bool) (intent.Draft, error) {
+	d := intent.Draft{Description: description, Type: intentType, Suggest: suggest}
+	changes, err := p.gatedChanges()
+	if err != nil {
+		return d, err
+	}
+	sort.Slice(changes, func(a, b int) bool { return changes[a].Path < changes[b].Path })
+
+	// Only suggestions read the diff, which means loading every change
+	var sb strings.Builder
+	engine := diff.NewEngine(3)
+	for _, c := range changes {
+		d.Paths = append(d.Paths, c.Path)
+		if !suggest {
+			continue
+		}
+		if err := p.writeDraftDiff(&sb, engine, c); err != nil {
+			return d, err
+		}
+	}
+	d.Diff = sb.String()
+	return d, nil
+}
+
+// writeDraftDiff appends the unified diff of one gated change
+func (p *Parcel) writeDraftDiff(w io.Writer, engine *diff.Engine, c shared.Change) error {
+	var oldContent, newContent []byte
+	var err error
+	if c.OldHash != "" {
+		if oldContent, err = p.Safe.Get(c.OldHash); err != nil {
+			return fmt.Errorf("loading previous content of %s: %w", c.Path, err)
+		}
+	}
+	if c.Type != "delete" && c.NewHash != "" {
+		if newContent, err = p.Safe.Get(c.NewHash); err != nil {
+			return fmt.Errorf("loading content of %s: %w", c.Path, err)
+		}
+	}
+	fmt.Fprintf(w, "diff --tig a/%s b/%s\n", c.Path, c.Path)
+	result, err := engine.DiffFile(c.Path, oldContent, newContent)
+	if err != nil {
+		return fmt.Errorf("diffing %s: %w", c.Path, err)
+	}
+	fmt.Fprint(w, result.Format())
+	return nil
+}
+
+// ReviewDescription runs the description processors over a draft
+func (p *Parcel) ReviewDescription(ctx context.Context, d intent.Draft) (*DescriptionReview, error) {
+	review := &DescriptionReview{Refuse: p.Describe.Action == config.GateRefuse}
+	if d.Suggest {
+		timeout, err := p.Describe.Timeout()
+		if err != nil {
+			return nil, err
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	for _, proc := range p.DescriptionProcessors {
+		r, err := proc.Process(ctx, d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", proc.Name(), err)
+		}
+		for _, msg := range r.Problems {
+			review.Problems = append(review.Problems, DescriptionProblem{Processor: proc.Name(), Message: msg})
+		}
+		if s := strings.TrimSpace(r.Suggestion); s != "" {
+			review.Suggestions = append(review.Suggestions, DescriptionSuggestion{Processor: proc.Name(), Description: s})
+		}
+	}
+	return review, nil
+}
+
+// CheckDescription lints a description and fails if the repository
+// refuses it. Problems that only warn go to warn.
+func (p *Parcel) CheckDescription(ctx context.Context, d intent.Draft, warn func(DescriptionProblem)) error {
+	d.Suggest = false
+	review, err := p.ReviewDescription(ctx, d)
+	if err != nil {
+		return err
+	}
+	if review.Refuse && len(review.Problems) > 0 {
+		return &DescriptionRefusedError{Problems: review.Problems}
+	}
+	for _, problem := range review.Problems {
+		warn(problem)
+	}
+	return nil
+}
+
+// commandSuggester asks an external program for a description. It
+// receives the draft as JSON on stdin and prints the description.
+type commandSuggester struct {
+	argv []string
+}
+
+func (s *commandSuggester) Name() string { return "suggest" }
+
+func (s *commandSuggester) Process(ctx context.Context, d intent.Draft) (intent.DescriptionReview, error) {
+	if !d.Suggest {
+		return intent.DescriptionReview{}, nil
+	}
+	input, err := json.Marshal(d)
+	if err != nil {
+		return intent.DescriptionReview{}, err
+	}
+	cmd := exec.CommandContext(ctx, s.argv[0], s.argv[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return intent.DescriptionReview{}, fmt.Errorf("running %s: %w: %s", s.argv[0], err, msg)
+		}
+		return intent.DescriptionReview{}, fmt.Errorf("running %s: %w", s.argv[0], err)
+	}
+	if len(out) > maxSuggestionBytes {
+		return intent.DescriptionReview{}, fmt.Errorf("%s printed more than %d bytes", s.argv[0], maxSuggestionBytes)
+	}
+	return intent.DescriptionReview{Suggestion: string(out)}, nil
+}
+
+// endpointSuggester asks an HTTP endpoint for a description. The draft is
+// POSTed as JSON and the response is {"description": "..."}.
+type endpointSuggester struct {
+	url    string
+	client *http.Client
+}
+
+func (s *endpointSuggester) Name() string { return "suggest" }
+
+func (s *endpointSuggester) Process(ctx context.Context, d intent.Draft) (intent.DescriptionReview, error) {
+	if !d.Suggest {
+		return intent.DescriptionReview{}, nil
+	}
+	body, err := json.Marshal(d)
+	if err != nil {
+		return intent.DescriptionReview{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return intent.DescriptionReview{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return intent.DescriptionReview{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return intent.DescriptionReview{}, fmt.Errorf("%s returned %s", s.url, resp.Status)
+	}
+	var reply struct {
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxSuggestionBytes)).Decode(&reply); err != nil {
+		return intent.DescriptionReview{}, fmt.Errorf("decoding suggestion from %s: %w", s.url, err)
+	}
+	return intent.DescriptionReview{Suggestion: reply.Description}, nil
+}
@@ -0,0 +1,118 @@
+// internal/parcel/detect.go
+package parcel
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"tig/internal/diff"
+	"tig/internal/ignore"
+)
+
+// LargeBinarySize is the size from which binary files are suggested for
+// exclusion
+const LargeBinarySize = 1 << 20
+
+// generatedDirs are directory names that usually hold dependencies or
+// build output. Directories that are always ignored, such as node_modules,
+// are not listed.
+var generatedDirs = map[string]string{
+	"target":           "build output",
+	"out":              "build output",
+	"bin":              "build output",
+	"obj":              "build output",
+	"coverage":         "coverage reports",
+	"__pycache__":      "Python bytecode",
+	"venv":             "Python virtual environment",
+	"bower_components": "dependencies",
+}
+
+// IgnoreSuggestion is a pattern worth adding to watch.exclude, with why
+// it was suggested
+type IgnoreSuggestion struct {
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason"`
+	Matches int    `json:"matches"` // Occurrences found in the tree
+}
+
+// DetectIgnores scans the working tree at root for dependency and build
+// directories and large binary files that would otherwise be tracked
+func DetectIgnores(root string) ([]IgnoreSuggestion, error) {
+	found := make(map[string]*IgnoreSuggestion)
+	ignored := ignore.New(root, nil)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if ignored.IgnoredDir(rel, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if reason, ok := generatedDirs[d.Name()]; ok {
+				pattern := d.Name() + "/"
+				if s, ok := found[pattern]; ok {
+					s.Matches++
+				} else {
+					found[pattern] = &IgnoreSuggestion{Pattern: pattern, Reason: reason, Matches: 1}
+				}
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Size() < LargeBinarySize {
+			return err
+		}
+		binary, err := isBinary(path)
+		if err != nil {
+			return err
+		}
+		if binary {
+			pattern := filepath.ToSlash(rel)
+			found[pattern] = &IgnoreSuggestion{
+				Pattern: pattern,
+				Reason:  fmt.Sprintf("binary file of %.1f MB", float64(info.Size())/(1<<20)),
+				Matches: 1,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning working tree: %w", err)
+	}
+
+	suggestions := make([]IgnoreSuggestion, 0, len(found))
+	for _, s := range found {
+		suggestions = append(suggestions, *s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].Pattern < suggestions[j].Pattern })
+	return suggestions, nil
+}
+
+// isBinary reports whether a file looks binary by its first 8000 bytes
+func isBinary(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	buf := make([]byte, 8000)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return diff.IsBinary(buf[:n]), nil
+}
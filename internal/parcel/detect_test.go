@@ -0,0 +1,34 @@
+// internal/parcel/detect_test.go
+package parcel
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectIgnores(t *testing.T) {
+	root := t.TempDir()
+	write := func(name string, data []byte) {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, data, 0644))
+	}
+	write("main.go", []byte("package main\n"))
+	write("target/debug/app", []byte("x"))
+	write("crates/cli/target/release/app", []byte("x"))
+	write("node_modules/big/blob.bin", make([]byte, LargeBinarySize))
+	write("assets/video.mp4", append([]byte{0}, make([]byte, LargeBinarySize)...))
+	write("data/large.csv", bytes.Repeat([]byte("a,b\n"), LargeBinarySize/4+1))
+
+	suggestions, err := DetectIgnores(root)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, "assets/video.mp4", suggestions[0].Pattern)
+	assert.Contains(t, suggestions[0].Reason, "binary file")
+	assert.Equal(t, IgnoreSuggestion{Pattern: "target/", Reason: "build output", Matches: 2}, suggestions[1])
+}
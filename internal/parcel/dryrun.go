@@ -0,0 +1,90 @@
+// internal/parcel/dryrun.go
+package parcel
+
+import (
+	"fmt"
+
+	"tig/internal/change"
+	"tig/internal/intent"
+	"tig/internal/storage"
+	"tig/internal/workspace"
+)
+
+// planner is implemented by workspaces that report what gating, ungating
+// and cleanup would change without changing it
+type planner interface {
+	PlanGate(paths []string) (*workspace.GatePlan, error)
+	PlanUngate(paths []string) []string
+	PlanCleanup() []string
+}
+
+func (p *Parcel) planner() (planner, error) {
+	pl, ok := p.Workspace.(planner)
+	if !ok {
+		return nil, fmt.Errorf("workspace does not support dry runs")
+	}
+	return pl, nil
+}
+
+// PlanGate reports what GateWith would gate for paths, applying the gate
+// rules the same way, without storing anything
+func (p *Parcel) PlanGate(paths []string, opts GateOptions) (*workspace.GatePlan, error) {
+	pl, err := p.planner()
+	if err != nil {
+		return nil, err
+	}
+	files, err := p.gatePaths(paths)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.applyGateRules(files, opts); err != nil {
+		return nil, err
+	}
+	return pl.PlanGate(files)
+}
+
+// PlanUngate lists the gated paths Ungate would remove
+func (p *Parcel) PlanUngate(paths []string) ([]string, error) {
+	pl, err := p.planner()
+	if err != nil {
+		return nil, err
+	}
+	return pl.PlanUngate(paths), nil
+}
+
+// PlanCleanup lists the gated changes the workspace cleanup would drop
+func (p *Parcel) PlanCleanup() ([]string, error) {
+	pl, err := p.planner()
+	if err != nil {
+		return nil, err
+	}
+	return pl.PlanCleanup(), nil
+}
+
+// CommitPlan is what CommitIntent would record: the intent and changeset
+// it would create and the keys it would write
+type CommitPlan struct {
+	Intent    *intent.Intent
+	ChangeSet *change.ChangeSet
+	Writes    []storage.Write
+}
+
+// PlanCommitIntent runs CommitIntent in a unit of work that is rolled
+// back, reporting what it would have written
+func (p *Parcel) PlanCommitIntent(opts CommitOptions) (*CommitPlan, error) {
+	changes, err := p.gatedChanges()
+	if err != nil {
+		return nil, err
+	}
+	i, cs, err := p.newCommit(opts)
+	if err != nil {
+		return nil, err
+	}
+	writes, err := storage.Preview(p.DB, func(u *storage.UnitOfWork) error {
+		return p.record(u, i, cs, changes, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CommitPlan{Intent: i, ChangeSet: cs, Writes: writes}, nil
+}
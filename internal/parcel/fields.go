@@ -0,0 +1,52 @@
+// internal/parcel/fields.go
+package parcel
+
+import (
+	"fmt"
+	"strings"
+
+	"tig/internal/intent"
+)
+
+// ParseIntentFields turns name=value arguments into extension values of
+// the repository's intent fields
+func (p *Parcel) ParseIntentFields(args []string) (map[string]any, error) {
+	values := make(map[string]any, len(args))
+	for _, arg := range args {
+		name, raw, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid field %q: expected name=value", arg)
+		}
+		v, err := intent.ParseExtension(p.IntentFields, name, raw)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// SetIntentFields sets and removes extension fields of an intent and
+// stores it once the result matches the repository's schema
+func (p *Parcel) SetIntentFields(id string, set map[string]any, unset []string) (*intent.Intent, error) {
+	i, err := p.ResolveIntent(id)
+	if err != nil {
+		return nil, err
+	}
+	if i.Extensions == nil {
+		i.Extensions = make(map[string]any)
+	}
+	for name, v := range set {
+		i.Extensions[name] = v
+	}
+	for _, name := range unset {
+		delete(i.Extensions, name)
+	}
+	if err := intent.CheckExtensions(i.Extensions, p.IntentFields); err != nil {
+		return nil, err
+	}
+	if err := p.IntentStore.Update(i); err != nil {
+		return nil, fmt.Errorf("updating intent: %w", err)
+	}
+	return i, nil
+}
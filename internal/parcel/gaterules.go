@@ -0,0 +1,151 @@
+// internal/parcel/gaterules.go
+package parcel
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tig/internal/config"
+	"tig/internal/safe"
+
+	"go.uber.org/zap"
+)
+
+// generatedScanBytes is how much of a file is searched for generated-file
+// markers. Generators put them in a header comment.
+const generatedScanBytes = 4096
+
+// GateViolation is a file flagged by the repository's gate rules
+type GateViolation struct {
+	Path   string
+	Rule   string // "size" or "generated"
+	Action string // config.GateWarn or config.GateRefuse
+	Reason string
+}
+
+func (v GateViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Reason)
+}
+
+// GateRefusedError is returned by Gate when rules refuse files. Nothing
+// is gated in that case.
+type GateRefusedError struct {
+	Violations []GateViolation
+}
+
+func (e *GateRefusedError) Error() string {
+	paths := make([]string, len(e.Violations))
+	for n, v := range e.Violations {
+		paths[n] = v.String()
+	}
+	return fmt.Sprintf("gate rules refused %d file(s): %s; add them to watch.exclude or gate with --force",
+		len(e.Violations), strings.Join(paths, ", "))
+}
+
+// GateOptions adjusts how Gate applies the gate rules
+type GateOptions struct {
+	Force bool                  // gate files the rules refuse
+	Warn  func(GateViolation)   // receives files the rules flag; they are logged when nil
+	Stats func(safe.StoreStats) // receives what the gate read and stored, once it is done
+}
+
+// CheckGateRules returns the violations among paths, relative to root.
+// Missing files, e.g. deletions, are never flagged.
+func CheckGateRules(root string, rules config.Gate, paths []string) ([]GateViolation, error) {
+	var violations []GateViolation
+	for _, path := range paths {
+		info, err := os.Stat(filepath.Join(root, path))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("checking %s: %w", path, err)
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		if action := ruleAction(rules.SizeAction); action != config.GateOff && info.Size() > rules.MaxFileSize() {
+			violations = append(violations, GateViolation{
+				Path:   path,
+				Rule:   "size",
+				Action: action,
+				Reason: fmt.Sprintf("%.1f MB exceeds the %d MB limit", float64(info.Size())/(1<<20), rules.MaxFileSize()>>20),
+			})
+		}
+
+		if action := ruleAction(rules.GeneratedAction); action != config.GateOff {
+			marker, err := generatedMarker(filepath.Join(root, path), rules.Markers())
+			if err != nil {
+				return nil, fmt.Errorf("checking %s: %w", path, err)
+			}
+			if marker != "" {
+				violations = append(violations, GateViolation{
+					Path:   path,
+					Rule:   "generated",
+					Action: action,
+					Reason: fmt.Sprintf("looks generated (contains %q)", marker),
+				})
+			}
+		}
+	}
+	return violations, nil
+}
+
+func ruleAction(action string) string {
+	if action == "" {
+		return config.GateWarn
+	}
+	return action
+}
+
+// generatedMarker returns the first marker found at the start of the file,
+// or "" if there is none
+func generatedMarker(path string, markers []string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, generatedScanBytes)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	for _, m := range markers {
+		if bytes.Contains(head[:n], []byte(m)) {
+			return m, nil
+		}
+	}
+	return "", nil
+}
+
+// applyGateRules reports flagged paths and fails if any are refused
+func (p *Parcel) applyGateRules(paths []string, opts GateOptions) error {
+	violations, err := CheckGateRules(p.Root, p.GateRules, paths)
+	if err != nil {
+		return err
+	}
+
+	var refused []GateViolation
+	for _, v := range violations {
+		if v.Action == config.GateRefuse && !opts.Force {
+			refused = append(refused, v)
+			continue
+		}
+		if opts.Warn != nil {
+			opts.Warn(v)
+		} else {
+			p.Logger.Warn("Gating flagged file", zap.String("path", v.Path), zap.String("reason", v.Reason))
+		}
+	}
+	if len(refused) > 0 {
+		return &GateRefusedError{Violations: refused}
+	}
+	return nil
+}
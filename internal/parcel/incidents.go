@@ -0,0 +1,68 @@
+// internal/parcel/incidents.go
+package parcel
+
+import (
+	"tig/internal/config"
+	"tig/internal/incident"
+	"tig/internal/report"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// LinkIncident records that an intent, by ID or prefix, caused an
+// incident
+func (p *Parcel) LinkIncident(incidentID, intentRef, note, by string) (*incident.Link, error) {
+	i, err := p.ResolveIntent(intentRef)
+	if err != nil {
+		return nil, err
+	}
+	if by == "" {
+		by = config.Author()
+	}
+	l := &incident.Link{IncidentID: incidentID, IntentID: i.ID, Note: note, LinkedBy: by}
+	if err := incident.Add(p.DB, p.IntentStore, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// UnlinkIncident removes the link between an incident and an intent, by
+// ID or prefix
+func (p *Parcel) UnlinkIncident(incidentID, intentRef string) error {
+	i, err := p.ResolveIntent(intentRef)
+	if err != nil {
+		return err
+	}
+	return incident.Remove(p.DB, incidentID, i.ID)
+}
+
+// Incidents lists the causal links of an incident, or of all of them when
+// incidentID is empty, newest first
+func (p *Parcel) Incidents(incidentID string) ([]incident.Link, error) {
+	return incident.List(p.DB, incidentID)
+}
+
+// CausedIncidents returns the incidents an intent is linked to as their
+// cause
+func (p *Parcel) CausedIncidents(intentID string) ([]string, error) {
+	causes, err := incident.Causes(p.DB)
+	if err != nil {
+		return nil, err
+	}
+	return causes[intentID], nil
+}
+
+// Hotspots ranks paths by the incidents their changes caused, keeping the
+// top limit paths when limit is above zero
+func (p *Parcel) Hotspots(limit int) (*report.Hotspots, error) {
+	links, err := incident.List(p.DB, "")
+	if err != nil {
+		return nil, err
+	}
+	var r *report.Hotspots
+	err = p.DB.View(func(txn *badger.Txn) error {
+		r, err = report.BuildHotspots(txn, links, p.ResolveIntent, limit)
+		return err
+	})
+	return r, err
+}
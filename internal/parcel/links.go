@@ -0,0 +1,30 @@
+// internal/parcel/links.go
+package parcel
+
+import (
+	"fmt"
+
+	"tig/internal/intent"
+)
+
+// LinkIntent adds and removes an intent's links to intents in other
+// repositories and stores it. Links are not checked here; callers verify
+// them against the linked repository first.
+func (p *Parcel) LinkIntent(id string, add, remove []intent.Link) (*intent.Intent, error) {
+	i, err := p.ResolveIntent(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range add {
+		i.AddLink(l)
+	}
+	for _, l := range remove {
+		if !i.RemoveLink(l) {
+			return nil, fmt.Errorf("intent %s has no link to %s", i.ID, l)
+		}
+	}
+	if err := p.IntentStore.Update(i); err != nil {
+		return nil, fmt.Errorf("updating intent: %w", err)
+	}
+	return i, nil
+}
@@ -0,0 +1,165 @@
+// internal/parcel/moves.go
+package parcel
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"tig/internal/change"
+)
+
+// RemoveOptions controls Remove
+type RemoveOptions struct {
+	Recursive bool // allow removing directories
+	Cached    bool // gate the deletion but leave the files on disk
+}
+
+// Move moves src to dst on disk and gates the move, keeping the old path
+// and content hash of every file so history follows it. If dst is an
+// existing directory, src is moved into it. Paths are relative to the
+// root.
+func (p *Parcel) Move(src, dst string) error {
+	mover, err := p.mover()
+	if err != nil {
+		return err
+	}
+	src, dst = filepath.Clean(src), filepath.Clean(dst)
+
+	absDst := filepath.Join(p.Root, dst)
+	if info, err := os.Stat(absDst); err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("%s already exists", dst)
+		}
+		dst = filepath.Join(dst, filepath.Base(src))
+		absDst = filepath.Join(p.Root, dst)
+		if _, err := os.Lstat(absDst); err == nil {
+			return fmt.Errorf("%s already exists", dst)
+		}
+	}
+	if p.ignored(src) || p.ignored(dst) {
+		return fmt.Errorf("cannot move ignored path")
+	}
+
+	files, err := p.files(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(absDst), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(dst), err)
+	}
+	if err := os.Rename(filepath.Join(p.Root, src), absDst); err != nil {
+		return fmt.Errorf("moving %s: %w", src, err)
+	}
+
+	for _, file := range files {
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		if err := mover.GateMove(file, filepath.Join(dst, rel)); err != nil {
+			return fmt.Errorf("gating move of %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// Remove deletes paths from disk and gates their deletion. Only files Gate
+// would consider are removed; ignored files inside removed directories
+// stay. Paths are relative to the root.
+func (p *Parcel) Remove(paths []string, opts RemoveOptions) error {
+	mover, err := p.mover()
+	if err != nil {
+		return err
+	}
+
+	// Check every path before touching the disk
+	removals := make(map[string][]string, len(paths))
+	for n, path := range paths {
+		path = filepath.Clean(path)
+		paths[n] = path
+		if p.ignored(path) {
+			return fmt.Errorf("cannot remove ignored path %s", path)
+		}
+		info, err := os.Stat(filepath.Join(p.Root, path))
+		if err != nil {
+			return fmt.Errorf("accessing %s: %w", path, err)
+		}
+		if info.IsDir() && !opts.Recursive {
+			return fmt.Errorf("%s is a directory; use --recursive to remove it", path)
+		}
+		if removals[path], err = p.files(path); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range paths {
+		for _, file := range removals[path] {
+			if err := mover.GateRemove(file); err != nil {
+				return fmt.Errorf("gating removal of %s: %w", file, err)
+			}
+			if opts.Cached {
+				continue
+			}
+			if err := os.Remove(filepath.Join(p.Root, file)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("removing %s: %w", file, err)
+			}
+		}
+		if !opts.Cached {
+			removeEmptyDirs(filepath.Join(p.Root, path))
+		}
+	}
+	return nil
+}
+
+// removeEmptyDirs removes the directories at and below dir that are left
+// empty. Ignored files keep their directories in place.
+func removeEmptyDirs(dir string) {
+	var dirs []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for n := len(dirs) - 1; n >= 0; n-- {
+		os.Remove(dirs[n]) // fails for directories that are not empty
+	}
+}
+
+// files returns the files at or below path that Gate would consider
+func (p *Parcel) files(path string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(filepath.Join(p.Root, path), func(abs string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(p.Root, abs)
+		if err != nil {
+			return err
+		}
+		if rel != path && p.ignored(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", path, err)
+	}
+	return files, nil
+}
+
+func (p *Parcel) mover() (change.Mover, error) {
+	mover, ok := p.Tracker.(change.Mover)
+	if !ok {
+		return nil, fmt.Errorf("tracker cannot record moves")
+	}
+	return mover, nil
+}
@@ -6,18 +6,30 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
+	"tig/internal/change"
+	"tig/internal/config"
+	"tig/internal/conflict"
 	"tig/internal/diff"
+	"tig/internal/errors"
+	"tig/internal/highlight"
+	"tig/internal/ignore"
 	"tig/internal/intent"
+	"tig/internal/remote"
+	intentStorage "tig/internal/intent/storage"
+	streamStorage "tig/internal/stream/storage"
 
 	"tig/internal/safe"
+	"tig/internal/sentinel"
+	"tig/internal/storage"
 
 	"tig/shared/types"
 
 	"tig/internal/workspace"
 
 	"github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -26,6 +38,55 @@ func (p *Parcel) Ungate(paths []string) error {
 	return p.Workspace.Ungate(paths)
 }
 
+// hunkEditor is implemented by workspaces that act on single hunks
+type hunkEditor interface {
+	UngateHunks(path string, choose workspace.ChooseHunk) (int, error)
+	DiscardHunks(path string, choose workspace.ChooseHunk) (int, error)
+}
+
+// UngateHunks removes the chosen hunks of a file from its gated content
+func (p *Parcel) UngateHunks(path string, choose workspace.ChooseHunk) (int, error) {
+	e, ok := p.Workspace.(hunkEditor)
+	if !ok {
+		return 0, fmt.Errorf("workspace does not support hunk selection")
+	}
+	return e.UngateHunks(filepath.Clean(path), choose)
+}
+
+// DiscardHunks reverts the chosen hunks of a file in the working tree
+func (p *Parcel) DiscardHunks(path string, choose workspace.ChooseHunk) (int, error) {
+	e, ok := p.Workspace.(hunkEditor)
+	if !ok {
+		return 0, fmt.Errorf("workspace does not support hunk selection")
+	}
+	return e.DiscardHunks(filepath.Clean(path), choose)
+}
+
+// discarder is implemented by workspaces that restore files from the Safe
+type discarder interface {
+	PlanDiscard(paths []string, all bool) ([]workspace.Discarded, error)
+	Discard(plan []workspace.Discarded) error
+}
+
+// PlanDiscard lists the files whose working-tree edits discarding paths,
+// or every file when all is set, would throw away
+func (p *Parcel) PlanDiscard(paths []string, all bool) ([]workspace.Discarded, error) {
+	d, ok := p.Workspace.(discarder)
+	if !ok {
+		return nil, fmt.Errorf("workspace does not support discarding changes")
+	}
+	return d.PlanDiscard(paths, all)
+}
+
+// Discard restores the planned files to their gated or recorded content
+func (p *Parcel) Discard(plan []workspace.Discarded) error {
+	d, ok := p.Workspace.(discarder)
+	if !ok {
+		return fmt.Errorf("workspace does not support discarding changes")
+	}
+	return d.Discard(plan)
+}
+
 // UpdateIntent updates an existing intent
 func (p *Parcel) UpdateIntent(i *intent.Intent) error {
 	return p.IntentStore.Update(i)
@@ -34,6 +95,7 @@ func (p *Parcel) UpdateIntent(i *intent.Intent) error {
 // CreateIntent creates a new intent
 func (p *Parcel) CreateIntent(description string, intentType string) (*intent.Intent, error) {
 	i := &intent.Intent{
+		ID:          uuid.New().String(),
 		Description: description,
 		Type:        intentType,
 	}
@@ -45,6 +107,11 @@ func (p *Parcel) CreateIntent(description string, intentType string) (*intent.In
 	return i, nil
 }
 
+// Conflicts lists paths that open intents on different streams both modify
+func (p *Parcel) Conflicts() ([]conflict.Conflict, error) {
+	return conflict.New(p.DB, p.StreamStore).Detect()
+}
+
 // Untrack wraps the tracker's Untrack method
 func (p *Parcel) Untrack(paths []string) error {
 	return p.Tracker.Untrack(paths)
@@ -55,6 +122,89 @@ func (p *Parcel) ShowFileDiff(path string) (*diff.DiffResult, error) {
 	return p.Tracker.ShowFileDiff(path)
 }
 
+// KeyChanges diffs the working copy of a JSON or YAML file key by key
+// against its last committed content. ok is false when the file is not
+// JSON or YAML or either side does not parse, so it should be diffed by
+// line instead.
+func (p *Parcel) KeyChanges(path string) (changes []diff.KeyChange, ok bool, err error) {
+	format := diff.StructuredFormat(path)
+	if format == "" {
+		return nil, false, nil
+	}
+	previous, current, err := p.fileVersions(path)
+	if err != nil {
+		return nil, false, err
+	}
+	changes, err = diff.Structural(format, previous, current)
+	if err != nil {
+		return nil, false, nil
+	}
+	return changes, true, nil
+}
+
+// ImageDiff summarizes the change to a PNG or JPEG file in the working
+// tree since it was last committed. It returns nil when the file is not
+// an image or does not decode.
+func (p *Parcel) ImageDiff(path string) (*diff.ImageSummary, error) {
+	if !diff.IsImage(path) {
+		return nil, nil
+	}
+	previous, current, err := p.fileVersions(path)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := diff.Image(previous, current)
+	if err != nil {
+		return nil, nil
+	}
+	return summary, nil
+}
+
+// NotebookDiff diffs the cell sources of a Jupyter notebook in the
+// working tree against its last committed version, and its outputs too
+// if outputs is set. It returns nil when the file is not a notebook or
+// either version does not parse.
+func (p *Parcel) NotebookDiff(path string, outputs bool) (*diff.DiffResult, error) {
+	if !diff.IsNotebook(path) {
+		return nil, nil
+	}
+	previous, current, err := p.fileVersions(path)
+	if err != nil {
+		return nil, err
+	}
+	oldText, err := diff.Notebook(previous, outputs)
+	if err != nil {
+		return nil, nil
+	}
+	newText, err := diff.Notebook(current, outputs)
+	if err != nil {
+		return nil, nil
+	}
+	return diff.NewEngine(3).Diff(oldText, newText)
+}
+
+// fileVersions loads the last committed content of a file, if any, and
+// its working copy
+func (p *Parcel) fileVersions(path string) (previous, current []byte, err error) {
+	current, err = os.ReadFile(filepath.Join(p.Root, path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading file: %w", err)
+	}
+	var hash string
+	if err := p.DB.View(func(txn *badger.Txn) error {
+		hash, err = change.StateHash(txn, path)
+		return err
+	}); err != nil {
+		return nil, nil, err
+	}
+	if hash != "" {
+		if previous, err = p.Safe.Get(hash); err != nil {
+			return nil, nil, fmt.Errorf("getting previous content: %w", err)
+		}
+	}
+	return previous, current, nil
+}
+
 func Initialize(root string) error {
 	// Create .tig directory
 	tigDir := filepath.Join(root, ".tig")
@@ -77,7 +227,21 @@ func Initialize(root string) error {
 	return nil
 }
 
+// New opens the repository at path. It refuses repositories whose .tig
+// directory was changed outside tig or restored in part, and seals the
+// repository again when closed.
 func New(path string, logger *zap.Logger) (*Parcel, error) {
+	return open(path, logger, true)
+}
+
+// NewForRepair opens the repository at path without checking its
+// sentinel, for tig fsck. Closing it does not seal the repository; call
+// Reseal once it has been checked.
+func NewForRepair(path string, logger *zap.Logger) (*Parcel, error) {
+	return open(path, logger, false)
+}
+
+func open(path string, logger *zap.Logger, sealed bool) (*Parcel, error) {
 	// Convert path to absolute
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -91,38 +255,221 @@ func New(path string, logger *zap.Logger) (*Parcel, error) {
 
 	tigDir := filepath.Join(absPath, ".tig")
 
-	// Initialize BadgerDB with optimized settings
-	opts := badger.DefaultOptions(filepath.Join(tigDir, "db"))
-	opts.Logger = nil // Disable logging noise
+	repoConfig, err := config.LoadRepo(absPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := repoConfig.Cache.Validate(); err != nil {
+		return nil, err
+	}
+	if err := repoConfig.Diff.Validate(); err != nil {
+		return nil, err
+	}
+	if err := repoConfig.Gate.Validate(); err != nil {
+		return nil, err
+	}
+	if err := repoConfig.Copies.Validate(); err != nil {
+		return nil, err
+	}
+	if err := repoConfig.IntentFields.Validate(); err != nil {
+		return nil, err
+	}
+	if err := intent.CheckWorkflow(repoConfig.Workflow); err != nil {
+		return nil, err
+	}
+	if err := repoConfig.Projects.Validate(); err != nil {
+		return nil, err
+	}
+	if err := repoConfig.Routes.Validate(repoConfig.Projects); err != nil {
+		return nil, err
+	}
+	if err := repoConfig.Tiers.Validate(); err != nil {
+		return nil, err
+	}
+	processors, err := DescriptionProcessors(repoConfig.Describe)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := badger.Open(opts)
+	db, err := openDB(absPath, repoConfig.Cache)
 	if err != nil {
-		return nil, fmt.Errorf("opening database: %w", err)
+		return nil, err
+	}
+
+	// Everything opened so far is closed again if opening fails later
+	var contentSafe *safe.Safe
+	opened := false
+	defer func() {
+		if opened {
+			return
+		}
+		if contentSafe != nil {
+			contentSafe.Close()
+		}
+		db.Close()
+	}()
+
+	if sealed {
+		if err := sentinel.Verify(absPath, db); err != nil {
+			return nil, err
+		}
+		if err := sentinel.Begin(absPath, db); err != nil {
+			return nil, err
+		}
+	}
+	compression, err := CompressionOptions(absPath, repoConfig.Compression)
+	if err != nil {
+		return nil, err
 	}
 
 	// Initialize Safe
-	contentSafe, err := safe.New(db, safe.Options{
-		Root:      filepath.Join(tigDir, "content"),
-		CacheSize: 1000,
+	contentSafe, err = safe.New(db, safe.Options{
+		Root:        filepath.Join(tigDir, "content"),
+		CacheSize:   repoConfig.Cache.ContentCacheItems(),
+		Compression: compression,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("initializing content safe: %w", err)
 	}
 
+	// Content the tier policy moved out of the safe is read from here
+	if dir := repoConfig.Tiers.ColdPath(absPath); dir != "" {
+		contentSafe.SetColdStore(&safe.DirStore{Root: dir})
+	}
+
+	// Partial clones fetch content from the remote on first use
+	if repoConfig.Remote.URL != "" {
+		contentSafe.SetFetcher(remote.NewClient(repoConfig.Remote.URL))
+	}
+
 	workspace, err := workspace.NewLocalWorkspace(absPath, db, contentSafe)
 	if err != nil {
 		return nil, fmt.Errorf("creating local workspace: %w", err)
 	}
+	// The workspace, tracker and parcel agree on what is ignored
+	ignored := ignore.New(absPath, repoConfig.Watch.Exclude)
+	workspace.Ignore = ignored
+	flushInterval, err := repoConfig.Watch.Interval()
+	if err != nil {
+		return nil, err
+	}
+
+	// The tracker only watches the tree once Watch asks for events
+	tracker, err := change.NewTracker(absPath, db, contentSafe, logger, change.WatchOptions{
+		Exclude:       repoConfig.Watch.Exclude,
+		Ignore:        ignored,
+		FlushInterval: flushInterval,
+		MaxDiffSize:   repoConfig.Diff.MaxFileSize(),
+		Deferred:      true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating tracker: %w", err)
+	}
+
+	intentStore := intentStorage.NewStore(db, workspace)
 
 	p := &Parcel{
-		Root:      absPath,
-		Workspace: workspace,
-		Logger:    logger,
+		Root:         absPath,
+		DB:           db,
+		Safe:         contentSafe,
+		Workspace:    workspace,
+		IntentStore:  intentStore,
+		StreamStore:  streamStorage.NewStore(db, intentStore),
+		Tracker:      tracker,
+		Highlighter:  highlight.New(!repoConfig.Diff.DisableHighlight),
+		Diff:         repoConfig.Diff,
+		GateRules:    repoConfig.Gate,
+		Copies:       repoConfig.Copies,
+		IntentFields: repoConfig.IntentFields,
+		Workflow:     repoConfig.Workflow,
+		Projects:     repoConfig.Projects,
+		Routes:       repoConfig.Routes,
+		Describe:     repoConfig.Describe,
+		Tiers:        repoConfig.Tiers,
+		Ignore:       ignored,
+		Logger:       logger,
+
+		DescriptionProcessors: processors,
+
+		sealed: sealed,
 	}
 
+	opened = true
 	return p, nil
 }
 
+// Integrity lists signs that the .tig directory was changed outside tig
+// or restored in part
+func (p *Parcel) Integrity() ([]string, error) {
+	return sentinel.Check(p.Root, p.DB)
+}
+
+// Reseal accepts the repository's current state as intact
+func (p *Parcel) Reseal() error {
+	if err := sentinel.Seal(p.Root, p.DB); err != nil {
+		return fmt.Errorf("sealing repository: %w", err)
+	}
+	return nil
+}
+
+// DictionaryDir returns where trained compression dictionaries are kept
+func DictionaryDir(root string) string {
+	return filepath.Join(root, ".tig", "dict")
+}
+
+// CompressionOptions builds the safe's compression settings from the repo
+// config. Every dictionary on disk is loaded so older content stays
+// readable after retraining.
+func CompressionOptions(root string, cfg config.Compression) (*safe.CompressionOptions, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	dicts, err := safe.LoadDictionaries(DictionaryDir(root))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := safe.DefaultCompressionOptions()
+	opts.Level = cfg.Level
+	opts.WindowSize = cfg.WindowSize
+	for id, d := range dicts {
+		if id == cfg.Dictionary {
+			opts.Dictionary = d
+			continue
+		}
+		opts.Dictionaries = append(opts.Dictionaries, d)
+	}
+	if cfg.Dictionary != 0 && opts.Dictionary == nil {
+		return nil, errors.NotFound(fmt.Sprintf("compression dictionary %d not found in %s", cfg.Dictionary, DictionaryDir(root)))
+	}
+	return &opts, nil
+}
+
+// TierPolicy converts the repository's tier settings for the safe
+func TierPolicy(cfg config.Tiers) safe.TierPolicy {
+	const day = 24 * time.Hour
+	return safe.TierPolicy{
+		WarmAfter:   time.Duration(cfg.WarmAfterDays) * day,
+		ColdAfter:   time.Duration(cfg.ColdAfterDays) * day,
+		ColdMinSize: cfg.ColdMinSizeKB << 10,
+		Level:       cfg.Level,
+	}
+}
+
+// openDB opens the metadata database of the repository at root
+func openDB(root string, cache config.Cache) (*badger.DB, error) {
+	// Initialize BadgerDB with optimized settings
+	opts := storage.WithCache(badger.DefaultOptions(filepath.Join(root, ".tig", "db")), cache)
+	opts.Logger = nil // Disable logging noise
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	return db, nil
+}
+
 func (p *Parcel) gateDirectory(dirPath string) error {
     return filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
         if err != nil {
@@ -142,8 +489,7 @@ func (p *Parcel) gateDirectory(dirPath string) error {
             return nil
         }
 
-        // Skip hidden files and special system files
-        if strings.HasPrefix(filepath.Base(relPath), ".") {
+        if p.ignored(relPath) {
             return nil
         }
 
@@ -170,11 +516,7 @@ func (p *Parcel) gateAll() error {
             return nil
         }
 
-        // Skip hidden files, special system files, and ignored directories
-        if strings.HasPrefix(filepath.Base(relPath), ".") || 
-           strings.Contains(relPath, "node_modules/") ||
-           strings.Contains(relPath, "vendor/") ||
-           strings.Contains(relPath, ".git/") {
+        if p.ignored(relPath) {
             return nil
         }
 
@@ -186,12 +528,45 @@ func (p *Parcel) gateAll() error {
 
 // Gate gates files for tracking
 func (p *Parcel) Gate(paths []string) error {
+    return p.GateWith(paths, GateOptions{})
+}
+
+// GateWith gates paths after checking them against the gate rules. If any
+// file is refused, nothing is gated.
+func (p *Parcel) GateWith(paths []string, opts GateOptions) error {
     if p.Workspace == nil {
         return fmt.Errorf("workspace not initialized")
     }
 
     p.Logger.Info("Gating paths in workspace")
 
+    pathsToGate, err := p.gatePaths(paths)
+    if err != nil {
+        return err
+    }
+
+    if err := p.applyGateRules(pathsToGate, opts); err != nil {
+        return err
+    }
+
+    // Gate the collected paths
+    if err := p.Workspace.Gate(pathsToGate); err != nil {
+        return fmt.Errorf("gating paths: %w", err)
+    }
+
+    p.Logger.Info("Successfully gated paths", zap.Int("count", len(pathsToGate)))
+    // The tracker keeps its own copy of the gated changes, which a commit
+    // in this process reads
+    p.reloadGated()
+    if reporter, ok := p.Workspace.(interface{ LastGateStats() safe.StoreStats }); ok && opts.Stats != nil {
+        opts.Stats(reporter.LastGateStats())
+    }
+    return nil
+}
+
+// gatePaths resolves the paths given to Gate, expanding "." to every
+// file that is not ignored
+func (p *Parcel) gatePaths(paths []string) ([]string, error) {
     // Handle paths
     var pathsToGate []string
     for _, path := range paths {
@@ -214,59 +589,31 @@ func (p *Parcel) Gate(paths []string) error {
                 }
 
                 // Check if file should be ignored
-                if !shouldIgnorePath(relPath) {
+                if !p.ignored(relPath) {
                     pathsToGate = append(pathsToGate, relPath)
                 }
                 return nil
             })
             if err != nil {
-                return fmt.Errorf("collecting files: %w", err)
+                return nil, fmt.Errorf("collecting files: %w", err)
             }
             break // No need to process other paths if "." was specified
         }
 
         // For specific paths, add them directly
         cleanPath := filepath.Clean(path)
-        if !shouldIgnorePath(cleanPath) {
+        if !p.ignored(cleanPath) {
             pathsToGate = append(pathsToGate, cleanPath)
         }
     }
 
-    // Gate the collected paths
-    if err := p.Workspace.Gate(pathsToGate); err != nil {
-        return fmt.Errorf("gating paths: %w", err)
-    }
-
-    p.Logger.Info("Successfully gated paths", zap.Int("count", len(pathsToGate)))
-    return nil
+    return pathsToGate, nil
 }
 
-// shouldIgnorePath checks if a path should be ignored
-func shouldIgnorePath(path string) bool {
-    if path == "" {
-        return true
-    }
-
-    // Check each path component
-    components := strings.Split(path, string(filepath.Separator))
-    for _, comp := range components {
-        if comp == "" {
-            continue
-        }
-
-        // Ignore hidden files and directories
-        if strings.HasPrefix(comp, ".") {
-            return true
-        }
-
-        // Ignore common directories
-        switch comp {
-        case "node_modules", "vendor", "dist", "build", ".git", ".tig":
-            return true
-        }
-    }
-
-    return false
+// ignored reports whether Gate skips path: the built-in rules, the
+// watch.exclude patterns and .tigignore files decide
+func (p *Parcel) ignored(path string) bool {
+    return p.Ignore.Ignored(path)
 }
 
 // internal/parcel/parcel.go
@@ -308,6 +655,24 @@ func initParcel(root string) (*Parcel, error) {
     return p, nil
 }
 
+// Watch starts watching the working tree and subscribes to live file
+// change events from the tracker. The returned function ends the
+// subscription.
+func (p *Parcel) Watch() (<-chan change.WatchEvent, func(), error) {
+    watcher, ok := p.Tracker.(interface {
+        Start() error
+        Subscribe() (<-chan change.WatchEvent, func())
+    })
+    if !ok {
+        return nil, nil, fmt.Errorf("tracker does not support watching")
+    }
+    if err := watcher.Start(); err != nil {
+        return nil, nil, err
+    }
+    events, cancel := watcher.Subscribe()
+    return events, cancel, nil
+}
+
 // Close ensures proper cleanup of resources
 func (p *Parcel) Close() error {
     if p == nil {
@@ -316,7 +681,29 @@ func (p *Parcel) Close() error {
 
     var errs []error
 
-    // Close workspace if initialized
+    // Stop file watching if the tracker holds a watcher
+    if closer, ok := p.Tracker.(interface{ Close() error }); ok {
+        if err := closer.Close(); err != nil {
+            errs = append(errs, fmt.Errorf("closing tracker: %w", err))
+        }
+    }
+
+    // Record the state this session leaves behind, before the workspace
+    // closes the database
+    if p.sealed && p.DB != nil {
+        if err := p.Reseal(); err != nil {
+            errs = append(errs, err)
+        }
+    }
+
+    // Write batched access times while the database is still open
+    if p.Safe != nil {
+        if err := p.Safe.Close(); err != nil {
+            errs = append(errs, fmt.Errorf("closing safe: %w", err))
+        }
+    }
+
+    // Close workspace if initialized; this closes the database
     if p.Workspace != nil {
         if err := p.Workspace.Close(); err != nil {
             errs = append(errs, fmt.Errorf("closing workspace: %w", err))
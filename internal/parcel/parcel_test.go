@@ -0,0 +1,444 @@
+// internal/parcel/parcel_test.go
+package parcel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/config"
+	"tig/internal/storage"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCompressionConfig(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	require.NoError(t, config.SaveRepo(root, &config.RepoConfig{
+		Compression: config.Compression{Level: 3, WindowSize: 1 << 20},
+	}))
+
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	content := bytes.Repeat([]byte("func main() {}\n"), 200)
+	hash, err := p.Safe.Store(content)
+	require.NoError(t, err)
+	meta, err := p.Safe.Meta(hash)
+	require.NoError(t, err)
+	assert.True(t, meta.Compressed)
+
+	_, err = CompressionOptions(root, config.Compression{Level: 23})
+	assert.Error(t, err)
+	_, err = CompressionOptions(root, config.Compression{Level: 3, WindowSize: 3000})
+	assert.Error(t, err)
+	_, err = CompressionOptions(root, config.Compression{Level: 3, Dictionary: 40000})
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestOpenFailureReleasesRepository(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	require.NoError(t, config.SaveRepo(root, &config.RepoConfig{Watch: config.Watch{FlushInterval: "soon"}}))
+
+	// The flush interval is read after the database and safe are open
+	_, err := New(root, zap.NewNop())
+	require.ErrorContains(t, err, "flush_interval")
+
+	require.NoError(t, config.SaveRepo(root, &config.RepoConfig{}))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, p.Close())
+}
+
+func TestCommitIntentIsAtomic(t *testing.T) {
+	root := t.TempDir()
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, p.Tracker.Gate("main.go"))
+
+	// A missing stream rolls back the changeset and intent
+	_, _, err = p.CommitIntent(CommitOptions{Description: "Add main", Type: "feature", StreamID: "missing"})
+	require.Error(t, err)
+	intents, err := p.ListIntents()
+	require.NoError(t, err)
+	assert.Empty(t, intents)
+
+	main, err := p.CreateStream("main", "feature")
+	require.NoError(t, err)
+	i, cs, err := p.CommitIntent(CommitOptions{Description: "Add main", Type: "feature", StreamID: main.ID})
+	require.NoError(t, err)
+	assert.Equal(t, cs.ID, i.ChangeSetID)
+	require.Len(t, cs.Changes, 1)
+
+	st, err := p.GetStream(main.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{i.ID}, st.State.Intents)
+
+	// Committed changes are no longer gated
+	_, _, err = p.CommitIntent(CommitOptions{Description: "Again", Type: "feature"})
+	assert.ErrorContains(t, err, "no changes")
+
+	release, err := p.CreateStream("release", "release")
+	require.NoError(t, err)
+	picked, err := p.CherryPick(i.ID, release.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, i.ChangeSetID, picked.ChangeSetID)
+	assert.Equal(t, i.Description, picked.Description)
+
+	st, err = p.GetStream(release.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{picked.ID}, st.State.Intents)
+}
+
+func TestGateRules(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	require.NoError(t, config.SaveRepo(root, &config.RepoConfig{
+		Gate:  config.Gate{MaxFileSizeMB: 1, SizeAction: config.GateRefuse},
+		Watch: config.Watch{Exclude: []string{"*.log"}},
+	}))
+
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "big.bin"), make([]byte, 2<<20), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "api.pb.go"), []byte("// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644))
+
+	// Oversized files are refused and nothing is gated
+	err = p.Gate([]string{"big.bin", "main.go"})
+	var refused *GateRefusedError
+	require.ErrorAs(t, err, &refused)
+	require.Len(t, refused.Violations, 1)
+	assert.Equal(t, "big.bin", refused.Violations[0].Path)
+	assert.Equal(t, "size", refused.Violations[0].Rule)
+
+	// Generated files only warn by default
+	var warned []GateViolation
+	warn := func(v GateViolation) { warned = append(warned, v) }
+	require.NoError(t, p.GateWith([]string{"api.pb.go", "main.go"}, GateOptions{Warn: warn}))
+	require.Len(t, warned, 1)
+	assert.Equal(t, "generated", warned[0].Rule)
+	assert.Contains(t, warned[0].Reason, "Code generated by")
+
+	// Excluded files are skipped before the rules apply
+	require.NoError(t, os.WriteFile(filepath.Join(root, "build.log"), make([]byte, 2<<20), 0644))
+	require.NoError(t, p.Gate([]string{"build.log", "main.go"}))
+
+	// Force gates refused files with a warning
+	warned = nil
+	require.NoError(t, p.GateWith([]string{"big.bin"}, GateOptions{Force: true, Warn: warn}))
+	assert.Len(t, warned, 1)
+
+	assert.Error(t, config.Gate{SizeAction: "block"}.Validate())
+}
+
+func TestMoveAndRemove(t *testing.T) {
+	root := t.TempDir()
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pkg", "util.go"), []byte("package pkg\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "old.go"), []byte("package old\n"), 0644))
+	require.NoError(t, p.Tracker.Gate("pkg/util.go"))
+	require.NoError(t, p.Tracker.Gate("old.go"))
+	_, added, err := p.CommitIntent(CommitOptions{Description: "Add files", Type: "feature"})
+	require.NoError(t, err)
+	hashes := map[string]string{}
+	for _, c := range added.Changes {
+		hashes[c.Path] = c.NewHash
+	}
+
+	// Moving into an existing directory keeps the file name
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "lib"), 0755))
+	require.NoError(t, p.Move("pkg", "lib"))
+	assert.FileExists(t, filepath.Join(root, "lib", "pkg", "util.go"))
+	require.NoError(t, p.Remove([]string{"old.go"}, RemoveOptions{}))
+	assert.NoFileExists(t, filepath.Join(root, "old.go"))
+
+	_, cs, err := p.CommitIntent(CommitOptions{Description: "Reorganise", Type: "refactor"})
+	require.NoError(t, err)
+	byPath := map[string]shared.Change{}
+	for _, c := range cs.Changes {
+		byPath[c.Path] = c
+	}
+	require.Len(t, byPath, 2)
+	moved := byPath[filepath.Join("lib", "pkg", "util.go")]
+	assert.Equal(t, "rename", moved.Type)
+	assert.Equal(t, filepath.Join("pkg", "util.go"), moved.OldPath)
+	assert.Equal(t, hashes[filepath.Join("pkg", "util.go")], moved.OldHash)
+	assert.Equal(t, moved.OldHash, moved.NewHash)
+	assert.Equal(t, "delete", byPath["old.go"].Type)
+	assert.Equal(t, hashes["old.go"], byPath["old.go"].OldHash)
+
+	// The committed state follows the move
+	require.NoError(t, p.DB.View(func(txn *badger.Txn) error {
+		hash, err := change.StateHash(txn, filepath.Join("pkg", "util.go"))
+		assert.Empty(t, hash)
+		if err != nil {
+			return err
+		}
+		hash, err = change.StateHash(txn, moved.Path)
+		assert.Equal(t, moved.NewHash, hash)
+		return err
+	}))
+
+	// Directories need --recursive
+	assert.ErrorContains(t, p.Remove([]string{"lib"}, RemoveOptions{}), "directory")
+	require.NoError(t, p.Remove([]string{"lib"}, RemoveOptions{Recursive: true, Cached: true}))
+	assert.FileExists(t, filepath.Join(root, "lib", "pkg", "util.go"))
+}
+
+func TestProjects(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	require.NoError(t, config.SaveRepo(root, &config.RepoConfig{
+		Projects: config.Projects{
+			{Name: "billing", Paths: []string{"services/billing", "libs/money/*.go"}},
+			{Name: "web", Paths: []string{"web/"}},
+		},
+	}))
+
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	for _, path := range []string{"services/billing/invoice.go", "libs/money/money.go", "web/app.js"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(path+"\n"), 0644))
+	}
+	require.NoError(t, p.Tracker.Gate("services/billing/invoice.go"))
+	require.NoError(t, p.Tracker.Gate("libs/money/money.go"))
+	i, _, err := p.CommitIntent(CommitOptions{Description: "Bill in cents", Type: "feature"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing"}, i.Projects)
+
+	billing, err := p.Project("billing")
+	require.NoError(t, err)
+	assert.False(t, billing.Contains("services/billing-v2/main.go"))
+	changes := []shared.Change{{Path: "services/billing/tax.go"}, {Path: "web/app.js"}}
+	assert.Equal(t, changes[:1], InProject(billing, changes))
+
+	intents, err := p.ProjectIntents("billing")
+	require.NoError(t, err)
+	require.Len(t, intents, 1)
+	intents, err = p.ProjectIntents("web")
+	require.NoError(t, err)
+	assert.Empty(t, intents)
+
+	// The project log holds the changes to its intents
+	i.Description = "Bill in cents, rounding down"
+	require.NoError(t, p.UpdateIntent(i))
+	log, err := p.ProjectHistory("billing")
+	require.NoError(t, err)
+	require.NotEmpty(t, log)
+	for n, e := range log {
+		assert.Equal(t, i.ID, e.ID)
+		if n > 0 {
+			assert.False(t, e.Time.Before(log[n-1].Time))
+		}
+	}
+	assert.Equal(t, storage.MutationUpdate, log[len(log)-1].Kind)
+	log, err = p.ProjectHistory("web")
+	require.NoError(t, err)
+	assert.Empty(t, log)
+	_, err = p.ProjectHistory("missing")
+	assert.Error(t, err)
+	_, err = p.Project("missing")
+	assert.Error(t, err)
+
+	assert.Error(t, config.Projects{{Name: "a", Paths: []string{"x"}}, {Name: "a", Paths: []string{"y"}}}.Validate())
+	assert.Error(t, config.Projects{{Name: "a", Paths: []string{"../x"}}}.Validate())
+	assert.Error(t, config.Projects{{Name: "a"}}.Validate())
+}
+
+func TestRoutes(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	require.NoError(t, config.SaveRepo(root, &config.RepoConfig{
+		Projects: config.Projects{{Name: "billing", Paths: []string{"services/billing"}}},
+		Routes: config.Routes{
+			{Paths: []string{"docs", "*.md"}, Stream: "docs"},
+			{Project: "billing", Stream: "billing"},
+		},
+	}))
+
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+	docs, err := p.CreateStream("docs", "feature")
+	require.NoError(t, err)
+
+	st, err := p.Route([]shared.Change{{Path: "docs/guide.md"}, {Path: "README.md"}})
+	require.NoError(t, err)
+	require.NotNil(t, st)
+	assert.Equal(t, docs.ID, st.ID)
+
+	// Every change must lie within the route
+	st, err = p.Route([]shared.Change{{Path: "docs/guide.md"}, {Path: "main.go"}})
+	require.NoError(t, err)
+	assert.Nil(t, st)
+
+	// A route to a missing stream is reported
+	_, err = p.Route([]shared.Change{{Path: "services/billing/invoice.go"}})
+	assert.ErrorContains(t, err, `stream "billing"`)
+
+	assert.Error(t, config.Routes{{Project: "missing", Stream: "x"}}.Validate(p.Projects))
+	assert.Error(t, config.Routes{{Stream: "x"}}.Validate(p.Projects))
+}
+
+func TestLatestIntent(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	latest, st, err := p.LatestIntent()
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+	assert.Nil(t, st)
+
+	main, err := p.CreateStream("main", "feature")
+	require.NoError(t, err)
+	at := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	for n, streamID := range []string{main.ID, "", main.ID} {
+		_, _, err := p.CommitChanges([]shared.Change{{Path: fmt.Sprintf("f%d.txt", n), Type: "add"}}, CommitOptions{
+			Description: fmt.Sprint(n),
+			Type:        "feature",
+			StreamID:    streamID,
+			Time:        at.Add(time.Duration(n) * time.Hour),
+			Detached:    true,
+		})
+		require.NoError(t, err)
+	}
+
+	latest, st, err = p.LatestIntent()
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, "2", latest.Description)
+	require.NotNil(t, st)
+	assert.Equal(t, "main", st.Name)
+}
+
+func TestSummarize(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	now := time.Now()
+	commits := []struct {
+		paths  []string
+		author string
+		age    time.Duration
+	}{
+		{[]string{"api/a.go", "api/b.go", "web/x.js"}, "ann", 90 * 24 * time.Hour},
+		{[]string{"api/a.go"}, "bob", 48 * time.Hour},
+		{[]string{"api/v2/c.go", "web/x.js"}, "bob", time.Hour},
+		{[]string{"apiary/z.go"}, "cat", time.Minute},
+	}
+	for n, c := range commits {
+		var changes []shared.Change
+		for _, path := range c.paths {
+			changes = append(changes, shared.Change{Path: filepath.FromSlash(path), Type: "modify"})
+		}
+		_, _, err := p.CommitChanges(changes, CommitOptions{
+			Description: fmt.Sprint(n),
+			Type:        "feature",
+			Author:      c.author,
+			Time:        now.Add(-c.age),
+			Detached:    true,
+		})
+		require.NoError(t, err)
+	}
+
+	s, err := p.Summarize("api", SummaryOptions{Since: now.Add(-30 * 24 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, 3, s.ChangeSets)
+	require.NotNil(t, s.LastIntent)
+	assert.Equal(t, "2", s.LastIntent.Description)
+	assert.Equal(t, 2, s.Churn)
+	assert.Equal(t, []PathCount{
+		{Path: filepath.FromSlash("api/a.go"), Changes: 1},
+		{Path: filepath.FromSlash("api/v2/c.go"), Changes: 1},
+	}, s.ChurnFiles)
+	assert.Equal(t, []AuthorCount{{Author: "ann", Changes: 2}, {Author: "bob", Changes: 2}}, s.Contributors)
+
+	s, err = p.Summarize(".", SummaryOptions{Since: now.Add(-30 * 24 * time.Hour), Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 4, s.ChangeSets)
+	assert.Equal(t, "3", s.LastIntent.Description)
+	assert.Equal(t, 4, s.Churn)
+	assert.Equal(t, []PathCount{{Path: filepath.FromSlash("api/a.go"), Changes: 1}}, s.ChurnFiles)
+	assert.Equal(t, []AuthorCount{{Author: "ann", Changes: 3}}, s.Contributors)
+}
+
+func TestDescriptionProcessors(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	// The assistant echoes the gated paths it was sent
+	require.NoError(t, config.SaveRepo(root, &config.RepoConfig{
+		Describe: config.Describe{
+			MaxLength:      40,
+			Imperative:     true,
+			Action:         config.GateRefuse,
+			SuggestCommand: []string{"sh", "-c", `grep -o '"paths":\[[^]]*\]' | sed 's/^/Update /'`},
+		},
+	}))
+
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, p.Tracker.Gate("main.go"))
+
+	draft, err := p.DescriptionDraft("Added main", "feature", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go"}, draft.Paths)
+	assert.Contains(t, draft.Diff, "package main")
+
+	review, err := p.ReviewDescription(context.Background(), draft)
+	require.NoError(t, err)
+	require.Len(t, review.Suggestions, 1)
+	assert.Equal(t, `Update "paths":["main.go"]`, review.Suggestions[0].Description)
+	assert.Len(t, review.Problems, 1)
+
+	// Refused descriptions fail; without the refuse action they only warn
+	var refused *DescriptionRefusedError
+	require.ErrorAs(t, p.CheckDescription(context.Background(), draft, nil), &refused)
+	assert.Equal(t, "lint", refused.Problems[0].Processor)
+
+	p.Describe.Action = config.GateWarn
+	var warned []DescriptionProblem
+	require.NoError(t, p.CheckDescription(context.Background(), draft, func(d DescriptionProblem) { warned = append(warned, d) }))
+	assert.Len(t, warned, 1)
+
+	draft.Description = "Add main"
+	warned = nil
+	require.NoError(t, p.CheckDescription(context.Background(), draft, func(d DescriptionProblem) { warned = append(warned, d) }))
+	assert.Empty(t, warned)
+
+	assert.Error(t, config.Describe{TicketPattern: "("}.Validate())
+	assert.Error(t, config.Describe{SuggestCommand: []string{"x"}, SuggestURL: "http://x"}.Validate())
+}
@@ -0,0 +1,93 @@
+// internal/parcel/projects.go
+package parcel
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"tig/internal/config"
+	"tig/internal/errors"
+	"tig/internal/intent"
+	"tig/internal/storage"
+	"tig/shared/types"
+)
+
+// Project returns the project scope with the given name from the repo
+// config
+func (p *Parcel) Project(name string) (config.Project, error) {
+	project, ok := p.Projects.Lookup(name)
+	if !ok {
+		return config.Project{}, errors.NotFound(fmt.Sprintf("project %q is not defined in %s", name, config.RepoConfigPath(p.Root)))
+	}
+	return project, nil
+}
+
+// InProject returns the changes whose paths lie within the project
+func InProject(project config.Project, changes []shared.Change) []shared.Change {
+	var scoped []shared.Change
+	for _, c := range changes {
+		if project.Contains(filepath.ToSlash(c.Path)) {
+			scoped = append(scoped, c)
+		}
+	}
+	return scoped
+}
+
+// ProjectIntents returns the intents tagged with the project when they
+// were created
+func (p *Parcel) ProjectIntents(name string) ([]*intent.Intent, error) {
+	intents, err := p.ListIntents()
+	if err != nil {
+		return nil, err
+	}
+	var scoped []*intent.Intent
+	for _, i := range intents {
+		for _, tagged := range i.Projects {
+			if tagged == name {
+				scoped = append(scoped, i)
+				break
+			}
+		}
+	}
+	return scoped, nil
+}
+
+// ProjectHistory returns the log of a project: every recorded change to
+// the intents tagged with it, oldest first
+func (p *Parcel) ProjectHistory(name string) ([]storage.AuditEntry, error) {
+	if _, err := p.Project(name); err != nil {
+		return nil, err
+	}
+	intents, err := p.ProjectIntents(name)
+	if err != nil {
+		return nil, err
+	}
+	var entries []storage.AuditEntry
+	for _, i := range intents {
+		mutations, err := storage.History(p.DB, "intent", i.ID)
+		if err != nil {
+			return nil, err
+		}
+		audit, err := storage.Audit(mutations)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, audit...)
+	}
+	sort.SliceStable(entries, func(a, b int) bool { return entries[a].Time.Before(entries[b].Time) })
+	return entries, nil
+}
+
+// changedPaths lists the slash-separated paths a changeset touches,
+// including the old paths of moved files
+func changedPaths(changes []shared.Change) []string {
+	paths := make([]string, 0, len(changes))
+	for _, c := range changes {
+		paths = append(paths, filepath.ToSlash(c.Path))
+		if c.OldPath != "" {
+			paths = append(paths, filepath.ToSlash(c.OldPath))
+		}
+	}
+	return paths
+}
@@ -0,0 +1,39 @@
+// internal/parcel/releases.go
+package parcel
+
+import (
+	"tig/internal/release"
+)
+
+// CreateRelease tags the tree of a stream, as of the last intent landed
+// on it, with an immutable release manifest
+func (p *Parcel) CreateRelease(tag, streamRef string) (*release.Manifest, error) {
+	st, err := p.ResolveStream(streamRef)
+	if err != nil {
+		return nil, err
+	}
+	head, err := p.streamHead(st.ID, st.State.Head)
+	if err != nil {
+		return nil, err
+	}
+	return release.Create(p.DB, p.Safe, tag, st, head)
+}
+
+// Release loads the manifest of a release tag
+func (p *Parcel) Release(tag string) (*release.Manifest, error) {
+	return release.Get(p.DB, p.Safe, tag)
+}
+
+// Releases lists the release tags, oldest first
+func (p *Parcel) Releases() ([]release.Tag, error) {
+	return release.List(p.DB)
+}
+
+// ExportRelease writes the files of a release into an empty directory
+func (p *Parcel) ExportRelease(tag, dir string) (*release.Manifest, error) {
+	m, err := p.Release(tag)
+	if err != nil {
+		return nil, err
+	}
+	return m, release.Export(p.Safe, m, dir)
+}
@@ -0,0 +1,77 @@
+// internal/parcel/restore.go
+package parcel
+
+import (
+	"fmt"
+)
+
+// restorer is implemented by workspaces that write recorded content back
+// to the working tree
+type restorer interface {
+	Restore(changeSetID string, paths []string, force bool) ([]string, error)
+}
+
+// RestoreFrom copies files from the tree of another stream, as of its
+// head, into the working tree and gates them, so a single fix can be
+// ported without switching streams. Paths may name directories of that
+// tree. Files with uncommitted edits are refused unless force is set,
+// since they would be overwritten. It returns the files written; those
+// already matching the stream are left alone.
+func (p *Parcel) RestoreFrom(streamRef string, paths []string, force bool) ([]string, error) {
+	st, err := p.ResolveStream(streamRef)
+	if err != nil {
+		return nil, err
+	}
+	head, err := p.streamHead(st.ID, st.State.Head)
+	if err != nil {
+		return nil, err
+	}
+	restored, err := p.restore(head, paths, force)
+	if err != nil {
+		return restored, fmt.Errorf("restoring from stream %s: %w", st.Name, err)
+	}
+	return restored, nil
+}
+
+// Restore writes files back to the working tree as they were in a
+// changeset, named by ID, unique prefix or intent, and gates them. With
+// no changeset, files return to their last recorded content and their
+// gated changes are dropped. Files with uncommitted edits are refused
+// unless force is set. It returns the files written.
+func (p *Parcel) Restore(changeSetRef string, paths []string, force bool) ([]string, error) {
+	if changeSetRef == "" {
+		restored, err := p.restore("", paths, force)
+		if err != nil {
+			return restored, err
+		}
+		if err := p.Workspace.Ungate(restored); err != nil {
+			return restored, fmt.Errorf("ungating restored files: %w", err)
+		}
+		return restored, nil
+	}
+
+	cs, err := p.ResolveChangeSet(changeSetRef)
+	if err != nil {
+		return nil, err
+	}
+	return p.restore(cs.ID, paths, force)
+}
+
+// restore restores files through the workspace and gates those written
+// from a changeset
+func (p *Parcel) restore(changeSetID string, paths []string, force bool) ([]string, error) {
+	r, ok := p.Workspace.(restorer)
+	if !ok {
+		return nil, fmt.Errorf("workspace does not support restoring files")
+	}
+	restored, err := r.Restore(changeSetID, paths, force)
+	if err != nil {
+		return restored, err
+	}
+	if changeSetID != "" && len(restored) > 0 {
+		if err := p.Gate(restored); err != nil {
+			return restored, fmt.Errorf("gating restored files: %w", err)
+		}
+	}
+	return restored, nil
+}
@@ -0,0 +1,128 @@
+// internal/parcel/restore_test.go
+package parcel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tigerrors "tig/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRestoreFrom(t *testing.T) {
+	snapshots := t.TempDir()
+	for name, content := range map[string]string{
+		"v1/a.txt": "one", "v1/lib/b.txt": "b1",
+		"v2/a.txt": "two", "v2/lib/b.txt": "b2", "v2/lib/c.txt": "c",
+	} {
+		p := filepath.Join(snapshots, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	imported, err := p.ImportSnapshots(snapshots, SnapshotOptions{Checkout: true}, nil)
+	require.NoError(t, err)
+	require.Len(t, imported, 2)
+
+	// The other stream ends at the first snapshot
+	other, err := p.CreateStream("hotfix", "hotfix")
+	require.NoError(t, err)
+	require.NoError(t, p.AddIntentToStream(other.ID, imported[0].IntentID))
+
+	restored, err := p.RestoreFrom("hotfix", []string{"a.txt", "lib"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", filepath.Join("lib", "b.txt")}, restored)
+	data, err := os.ReadFile(filepath.Join(root, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(data))
+	// Files only on the current stream stay
+	assert.FileExists(t, filepath.Join(root, "lib", "c.txt"))
+
+	assert.ElementsMatch(t, []string{"a.txt", "lib/b.txt"}, gatedPaths(t, p))
+
+	// Restoring again changes nothing
+	restored, err = p.RestoreFrom("hotfix", []string{"a.txt"}, false)
+	require.NoError(t, err)
+	assert.Empty(t, restored)
+
+	// Uncommitted edits are not overwritten without force
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("local"), 0644))
+	_, err = p.RestoreFrom("hotfix", []string{"a.txt"}, false)
+	assert.ErrorIs(t, err, tigerrors.ErrDirtyTree)
+	restored, err = p.RestoreFrom("hotfix", []string{"a.txt"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt"}, restored)
+
+	_, err = p.RestoreFrom("hotfix", []string{"lib/c.txt"}, false)
+	var tigErr *tigerrors.Error
+	assert.ErrorAs(t, err, &tigErr)
+}
+
+func TestRestoreChangeSet(t *testing.T) {
+	snapshots := t.TempDir()
+	for name, content := range map[string]string{
+		"v1/a.txt": "one", "v1/lib/b.txt": "b1",
+		"v2/a.txt": "two", "v2/lib/b.txt": "b2", "v2/lib/c.txt": "c",
+	} {
+		p := filepath.Join(snapshots, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	imported, err := p.ImportSnapshots(snapshots, SnapshotOptions{Checkout: true}, nil)
+	require.NoError(t, err)
+	require.Len(t, imported, 2)
+
+	// By changeset prefix, gating what was written
+	restored, err := p.Restore(imported[0].ChangeSetID[:8], []string{"lib"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("lib", "b.txt")}, restored)
+	data, err := os.ReadFile(filepath.Join(root, "lib", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "b1", string(data))
+	assert.Equal(t, []string{"lib/b.txt"}, gatedPaths(t, p))
+
+	// By intent; files not in the changeset are refused
+	_, err = p.Restore(imported[0].IntentID, []string{"lib/c.txt"}, false)
+	var tigErr *tigerrors.Error
+	assert.ErrorAs(t, err, &tigErr)
+
+	// Back to the recorded content, which drops the gated change
+	_, err = p.Restore("", []string{"lib/b.txt"}, false)
+	assert.ErrorIs(t, err, tigerrors.ErrDirtyTree, "gated edits are uncommitted")
+	restored, err = p.Restore("", []string{"lib/b.txt"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("lib", "b.txt")}, restored)
+	data, err = os.ReadFile(filepath.Join(root, "lib", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "b2", string(data))
+	assert.Empty(t, gatedPaths(t, p))
+}
+
+func gatedPaths(t *testing.T, p *Parcel) []string {
+	status, err := p.Status()
+	require.NoError(t, err)
+	var gated []string
+	for _, c := range status {
+		if c.Gated {
+			gated = append(gated, filepath.ToSlash(c.Path))
+		}
+	}
+	return gated
+}
@@ -0,0 +1,37 @@
+// internal/parcel/risk.go
+package parcel
+
+import (
+	"tig/internal/intent"
+	"tig/internal/risk"
+)
+
+// RiskScorer scores intents against the history of every intent in the
+// repository
+func (p *Parcel) RiskScorer() (*risk.Scorer, error) {
+	intents, err := p.ListIntents()
+	if err != nil {
+		return nil, err
+	}
+	return risk.NewScorer(p.DB, p.Safe, intents)
+}
+
+// IntentRisk scores how much review attention an intent needs. Scores
+// only look back in time, so only the intents created up to i are loaded.
+func (p *Parcel) IntentRisk(i *intent.Intent) (*risk.Score, error) {
+	all, err := p.ListIntents()
+	if err != nil {
+		return nil, err
+	}
+	history := []*intent.Intent{i}
+	for _, other := range all {
+		if other.ID != i.ID && !other.CreatedAt.After(i.CreatedAt) {
+			history = append(history, other)
+		}
+	}
+	sc, err := risk.NewScorer(p.DB, p.Safe, history)
+	if err != nil {
+		return nil, err
+	}
+	return sc.Score(i)
+}
@@ -0,0 +1,39 @@
+// internal/parcel/routes.go
+package parcel
+
+import (
+	"fmt"
+
+	"tig/internal/stream"
+	"tig/shared/types"
+)
+
+// Route returns the default stream of an intent with the given changes
+// according to the repository's routes, or nil when no route matches
+func (p *Parcel) Route(changes []shared.Change) (*stream.Stream, error) {
+	r, ok := p.Routes.Match(p.Projects, changedPaths(changes))
+	if !ok {
+		return nil, nil
+	}
+	st, err := p.ResolveStream(r.Stream)
+	if err != nil {
+		return nil, fmt.Errorf("routing intent to stream %q: %w", r.Stream, err)
+	}
+	return st, nil
+}
+
+// RouteGated returns the default stream of an intent for the gated
+// changes, or nil when no route matches
+func (p *Parcel) RouteGated() (*stream.Stream, error) {
+	status, err := p.Tracker.Status()
+	if err != nil {
+		return nil, fmt.Errorf("reading gated changes: %w", err)
+	}
+	var gated []shared.Change
+	for _, c := range status {
+		if c.Gated {
+			gated = append(gated, c)
+		}
+	}
+	return p.Route(gated)
+}
@@ -0,0 +1,349 @@
+// internal/parcel/snapshots.go
+package parcel
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"tig/internal/change"
+	tigerrors "tig/internal/errors"
+	"tig/internal/ignore"
+	"tig/internal/safe"
+	"tig/shared/types"
+	"tig/shared/utils"
+)
+
+// Snapshot orders
+const (
+	SnapshotsByName  = "name"
+	SnapshotsByMTime = "mtime"
+)
+
+// SnapshotOptions controls ImportSnapshots
+type SnapshotOptions struct {
+	Order    string // SnapshotsByName (default) or SnapshotsByMTime
+	Type     string // Type of the generated intents, "import" by default
+	StreamID string // Stream the generated intents are added to, if any
+	DryRun   bool   // Compute the changes without storing anything
+	Checkout bool   // Write the last snapshot into the working tree, which must be clean
+}
+
+// ImportedSnapshot describes the changeset generated for one snapshot
+type ImportedSnapshot struct {
+	Name        string    `json:"name"`
+	Time        time.Time `json:"time"`
+	IntentID    string    `json:"intent_id,omitempty"` // Empty when nothing changed
+	ChangeSetID string    `json:"changeset_id,omitempty"`
+	Added       int       `json:"added"`
+	Modified    int       `json:"modified"`
+	Deleted     int       `json:"deleted"`
+}
+
+// ImportSnapshots treats each subdirectory of dir as a full copy of the
+// project at one point in time, e.g. dated backup folders, and records
+// each as a changeset against the one before, with an intent per
+// snapshot. The first snapshot is compared with the tracked files.
+// Changesets are dated by the newest file in their snapshot.
+func (p *Parcel) ImportSnapshots(dir string, opts SnapshotOptions, progress func(ImportedSnapshot)) ([]ImportedSnapshot, error) {
+	snapshots, err := listSnapshots(dir, opts.Order)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("no snapshot directories in %s", dir)
+	}
+	if opts.Type == "" {
+		opts.Type = "import"
+	}
+
+	states, err := p.fileStates()
+	if err != nil {
+		return nil, err
+	}
+	prev := make(map[string]change.FileState, len(states))
+	for path, state := range states {
+		prev[filepath.ToSlash(path)] = state
+	}
+
+	checkout := opts.Checkout && !opts.DryRun
+	if checkout {
+		if err := p.RequireCleanTree(); err != nil {
+			return nil, err
+		}
+	}
+
+	var imported []ImportedSnapshot
+	var last time.Time
+	for _, name := range snapshots {
+		root := filepath.Join(dir, name)
+		files, newest, err := p.readSnapshot(root)
+		if err != nil {
+			return imported, fmt.Errorf("reading snapshot %s: %w", name, err)
+		}
+		// Keep changesets in snapshot order even if file times disagree
+		if !newest.After(last) {
+			newest = last.Add(time.Second)
+		}
+		last = newest
+
+		result := ImportedSnapshot{Name: name, Time: newest}
+		changes := diffSnapshots(prev, files, &result)
+		if len(changes) > 0 && !opts.DryRun {
+			if err := p.storeSnapshotContent(root, changes); err != nil {
+				return imported, fmt.Errorf("storing snapshot %s: %w", name, err)
+			}
+			i, cs, err := p.commit(changes, CommitOptions{
+				Description: "Import snapshot " + name,
+				Type:        opts.Type,
+				StreamID:    opts.StreamID,
+				Time:        newest,
+			})
+			if err != nil {
+				return imported, fmt.Errorf("committing snapshot %s: %w", name, err)
+			}
+			result.IntentID, result.ChangeSetID = i.ID, cs.ID
+		}
+		prev = files
+
+		imported = append(imported, result)
+		if progress != nil {
+			progress(result)
+		}
+	}
+
+	if checkout {
+		if err := p.CheckoutTracked(states); err != nil {
+			return imported, fmt.Errorf("checking out the last snapshot: %w", err)
+		}
+	}
+	return imported, nil
+}
+
+// RequireCleanTree returns ErrDirtyTree unless the working tree holds
+// exactly the tracked files, with nothing gated
+func (p *Parcel) RequireCleanTree() error {
+	states, err := p.fileStates()
+	if err != nil {
+		return err
+	}
+	current := make(map[string]change.FileState, len(states))
+	for path, state := range states {
+		current[filepath.ToSlash(path)] = state
+	}
+	clean, err := p.treeMatches(current)
+	if err != nil {
+		return err
+	}
+	if !clean {
+		return fmt.Errorf("%w: commit or remove the changes first", tigerrors.ErrDirtyTree)
+	}
+	return nil
+}
+
+// CheckoutTracked brings the working tree in line with the tracked files
+// after they were changed without it: files tracked before, in before,
+// and no longer tracked are removed, and every tracked file is written.
+func (p *Parcel) CheckoutTracked(before map[string]change.FileState) error {
+	after, err := p.fileStates()
+	if err != nil {
+		return err
+	}
+	for path := range before {
+		if _, ok := after[path]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(p.Root, path)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", path, err)
+		}
+	}
+	_, err = p.Materialize(safe.CheckoutOptions{})
+	return err
+}
+
+// FileStates returns the tracked state of every file, keyed by path
+func (p *Parcel) FileStates() (map[string]change.FileState, error) {
+	return p.fileStates()
+}
+
+// treeMatches reports whether the working tree holds exactly the tracked
+// files, with nothing gated
+func (p *Parcel) treeMatches(states map[string]change.FileState) (bool, error) {
+	status, err := p.Status()
+	if err != nil {
+		return false, err
+	}
+	for _, c := range status {
+		if c.Gated {
+			return false, nil
+		}
+	}
+	current, _, err := p.readSnapshot(p.Root)
+	if err != nil {
+		return false, err
+	}
+	return len(diffSnapshots(states, current, &ImportedSnapshot{})) == 0, nil
+}
+
+// listSnapshots returns the snapshot directories in dir in import order
+func listSnapshots(dir, order string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshots: %w", err)
+	}
+	var names []string
+	mtimes := make(map[string]time.Time)
+	ignored := ignore.New(dir, nil)
+	for _, e := range entries {
+		if !e.IsDir() || ignored.IgnoredDir(e.Name(), true) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, e.Name())
+		mtimes[e.Name()] = info.ModTime()
+	}
+
+	switch order {
+	case "", SnapshotsByName:
+		sort.Strings(names)
+	case SnapshotsByMTime:
+		sort.SliceStable(names, func(i, j int) bool { return mtimes[names[i]].Before(mtimes[names[j]]) })
+	default:
+		return nil, fmt.Errorf("invalid snapshot order %q: use %s or %s", order, SnapshotsByName, SnapshotsByMTime)
+	}
+	return names, nil
+}
+
+// readSnapshot hashes every file of a snapshot and returns the newest
+// modification time
+func (p *Parcel) readSnapshot(root string) (map[string]change.FileState, time.Time, error) {
+	files := make(map[string]change.FileState)
+	var newest time.Time
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if p.ignored(rel) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = change.FileState{
+			Hash:    utils.HashContent(content),
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+			Mode:    int(info.Mode()),
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	return files, newest, err
+}
+
+// storeSnapshotContent saves the content of added and modified files in
+// the safe in one atomic batch, so a failed snapshot leaves no references
+// behind. Unchanged files are not stored again, as every store adds a
+// reference.
+func (p *Parcel) storeSnapshotContent(root string, changes []shared.Change) error {
+	var stored []shared.Change
+	var contents [][]byte
+	for _, c := range changes {
+		if c.Type == "delete" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(root, c.Path))
+		if err != nil {
+			return err
+		}
+		stored = append(stored, c)
+		contents = append(contents, content)
+	}
+
+	hashes, err := p.Safe.StoreBatchWithOptions(contents, safe.BatchOptions{Atomic: true})
+	var batchErr *safe.BatchError
+	if errors.As(err, &batchErr) {
+		n := batchErr.Failed()[0]
+		return fmt.Errorf("storing %s: %w", stored[n].Path, batchErr.Errors[n])
+	}
+	if err != nil {
+		return fmt.Errorf("storing content: %w", err)
+	}
+	for n, c := range stored {
+		if hashes[n] == c.NewHash {
+			continue
+		}
+		for _, hash := range hashes {
+			p.Safe.Delete(hash)
+		}
+		return fmt.Errorf("%s changed while importing", c.Path)
+	}
+	return nil
+}
+
+// diffSnapshots lists the changes from one snapshot to the next, sorted
+// by path, and counts them in result
+func diffSnapshots(prev, next map[string]change.FileState, result *ImportedSnapshot) []shared.Change {
+	var changes []shared.Change
+	for path, state := range next {
+		old, existed := prev[path]
+		c := shared.Change{
+			Path:    filepath.FromSlash(path),
+			NewHash: state.Hash,
+			Mode:    state.Mode,
+			Size:    state.Size,
+			ModTime: state.ModTime,
+			Gated:   true,
+		}
+		switch {
+		case !existed:
+			c.Type = "add"
+			result.Added++
+		case old.Hash != state.Hash || old.Mode != 0 && old.Mode != state.Mode:
+			c.Type = "modify"
+			c.OldHash = old.Hash
+			result.Modified++
+		default:
+			continue
+		}
+		changes = append(changes, c)
+	}
+	for path, old := range prev {
+		if _, ok := next[path]; !ok {
+			changes = append(changes, shared.Change{
+				Path:    filepath.FromSlash(path),
+				Type:    "delete",
+				OldHash: old.Hash,
+				Gated:   true,
+			})
+			result.Deleted++
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
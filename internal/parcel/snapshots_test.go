@@ -0,0 +1,159 @@
+// internal/parcel/snapshots_test.go
+package parcel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	tigerrors "tig/internal/errors"
+	"tig/shared/types"
+	"tig/shared/utils"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestImportSnapshots(t *testing.T) {
+	snapshots := t.TempDir()
+	write := func(name, content string) {
+		p := filepath.Join(snapshots, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+	write("2021-03-01/main.c", "int main() {}\n")
+	write("2021-03-01/README", "v1\n")
+	write("2021-03-01/build/main.o", "object")
+	write("2021-06-15/main.c", "int main() { return 0; }\n")
+	write("2021-06-15/README", "v1\n")
+	write("2021-06-15/util.c", "void util() {}\n")
+	write("2022-01-10/main.c", "int main() { return 0; }\n")
+	write("2022-01-10/util.c", "void util() {}\n")
+	write("2022-02-01/main.c", "int main() { return 0; }\n")
+	write("2022-02-01/util.c", "void util() {}\n")
+	// Backups copied later may carry newer times than their successors
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(snapshots, "2021-03-01", "main.c"), later, later))
+
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	dry, err := p.ImportSnapshots(snapshots, SnapshotOptions{DryRun: true}, nil)
+	require.NoError(t, err)
+	require.Len(t, dry, 4)
+	assert.Empty(t, dry[0].ChangeSetID)
+	states, err := p.fileStates()
+	require.NoError(t, err)
+	assert.Empty(t, states)
+
+	var seen []string
+	imported, err := p.ImportSnapshots(snapshots, SnapshotOptions{}, func(s ImportedSnapshot) {
+		seen = append(seen, s.Name)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2021-03-01", "2021-06-15", "2022-01-10", "2022-02-01"}, seen)
+	assert.Equal(t, ImportedSnapshot{Name: "2021-03-01", Added: 2}, stripIDs(imported[0]))
+	assert.Equal(t, ImportedSnapshot{Name: "2021-06-15", Added: 1, Modified: 1}, stripIDs(imported[1]))
+	assert.Equal(t, ImportedSnapshot{Name: "2022-01-10", Deleted: 1}, stripIDs(imported[2]))
+	assert.Empty(t, imported[3].ChangeSetID, "unchanged snapshots create no changeset")
+	assert.True(t, imported[1].Time.After(imported[0].Time))
+
+	i, err := p.GetIntent(imported[1].IntentID)
+	require.NoError(t, err)
+	assert.Equal(t, "Import snapshot 2021-06-15", i.Description)
+	assert.Equal(t, "import", i.Type)
+	require.NoError(t, p.DB.View(func(txn *badger.Txn) error {
+		cs, err := change.GetChangeSet(txn, imported[1].ChangeSetID)
+		require.NoError(t, err)
+		assert.Equal(t, imported[1].Time, cs.CreatedAt.Local())
+		require.Len(t, cs.Changes, 2)
+		assert.Equal(t, "main.c", cs.Changes[0].Path)
+		assert.NotEmpty(t, cs.Changes[0].OldHash)
+		return nil
+	}))
+
+	states, err = p.fileStates()
+	require.NoError(t, err)
+	assert.Len(t, states, 2)
+	content, err := p.Safe.Get(states["util.c"].Hash)
+	require.NoError(t, err)
+	assert.Equal(t, "void util() {}\n", string(content))
+
+	_, err = p.ImportSnapshots(snapshots, SnapshotOptions{Order: "size"}, nil)
+	assert.Error(t, err)
+
+}
+
+func TestImportSnapshotsCheckout(t *testing.T) {
+	snapshots := t.TempDir()
+	for name, content := range map[string]string{"v1/a.txt": "one", "v2/a.txt": "two", "v2/b.txt": "b"} {
+		p := filepath.Join(snapshots, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes.txt"), []byte("draft"), 0644))
+	_, err = p.ImportSnapshots(snapshots, SnapshotOptions{Checkout: true}, nil)
+	assert.ErrorIs(t, err, tigerrors.ErrDirtyTree)
+	require.NoError(t, os.Remove(filepath.Join(root, "notes.txt")))
+
+	_, err = p.ImportSnapshots(snapshots, SnapshotOptions{Checkout: true}, nil)
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(root, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(content))
+	states, err := p.fileStates()
+	require.NoError(t, err)
+	clean, err := p.treeMatches(states)
+	require.NoError(t, err)
+	assert.True(t, clean)
+}
+
+func stripIDs(s ImportedSnapshot) ImportedSnapshot {
+	s.IntentID, s.ChangeSetID, s.Time = "", "", time.Time{}
+	return s
+}
+
+func TestStoreSnapshotContentRollsBack(t *testing.T) {
+	snapshot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(snapshot, "a.c"), []byte("a\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(snapshot, "b.c"), []byte("b\n"), 0644))
+
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	// b.c changed after it was scanned, so none of the snapshot is kept
+	hashA := utils.HashContent([]byte("a\n"))
+	err = p.storeSnapshotContent(snapshot, []shared.Change{
+		{Path: "a.c", Type: "add", NewHash: hashA},
+		{Path: "b.c", Type: "add", NewHash: utils.HashContent([]byte("old b\n"))},
+	})
+	assert.ErrorContains(t, err, "b.c changed while importing")
+	has, err := p.Safe.Exists(hashA)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	require.NoError(t, p.storeSnapshotContent(snapshot, []shared.Change{
+		{Path: "a.c", Type: "add", NewHash: hashA},
+		{Path: "gone.c", Type: "delete"},
+	}))
+	meta, err := p.Safe.Meta(hashA)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), meta.RefCount)
+}
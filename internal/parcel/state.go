@@ -0,0 +1,29 @@
+// internal/parcel/state.go
+package parcel
+
+import (
+	"fmt"
+	"time"
+
+	"tig/internal/config"
+	"tig/internal/intent"
+)
+
+// SetIntentState moves an intent to another lifecycle state, if the
+// repository's workflow allows the transition
+func (p *Parcel) SetIntentState(id, state, by string) (*intent.Intent, error) {
+	i, err := p.ResolveIntent(id)
+	if err != nil {
+		return nil, err
+	}
+	if by == "" {
+		by = config.Author()
+	}
+	if err := i.Transition(p.Workflow, state, by, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := p.IntentStore.Update(i); err != nil {
+		return nil, fmt.Errorf("updating intent: %w", err)
+	}
+	return i, nil
+}
@@ -13,7 +13,7 @@ import (
 
 // Store implements parcel.Box interface
 type Store struct {
-    store *genericStorage.BadgerStore
+    store  *genericStorage.Store[*parcelEntity]
     config *parcel.ParcelConfig
     state  *parcel.ParcelState
 }
@@ -21,7 +21,7 @@ type Store struct {
 // NewStore creates a new parcel store
 func NewStore(db *badger.DB) *Store {
     return &Store{
-        store: genericStorage.NewBadgerStore(db, "parcel"),
+        store: genericStorage.New[*parcelEntity](db, "parcel"),
     }
 }
 
@@ -82,13 +82,13 @@ func (s *Store) Open(path string) error {
         return fmt.Errorf("resolving path: %w", err)
     }
 
-    var entity parcelEntity
-    if err := s.store.Get(absPath, &entity); err != nil {
+    entity, err := s.store.Get(absPath)
+    if err != nil {
         return fmt.Errorf("getting parcel: %w", err)
     }
 
     // Validate loaded entity
-    if entity.Config == nil || entity.State == nil {
+    if entity == nil || entity.Config == nil || entity.State == nil {
         return fmt.Errorf("invalid parcel data: missing config or state")
     }
 
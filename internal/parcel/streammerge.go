@@ -0,0 +1,303 @@
+// internal/parcel/streammerge.go
+package parcel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"tig/internal/change"
+	"tig/internal/errors"
+	"tig/internal/merge"
+	"tig/internal/storage"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// StreamMerge describes a merge of one stream into another
+type StreamMerge struct {
+	Source  *stream.Stream     `json:"source"`
+	Target  *stream.Stream     `json:"target"`
+	Intents []string           `json:"intents"` // Source intents brought into the target
+	Files   []merge.FileResult `json:"files"`
+}
+
+// Conflicts lists the files left with conflicts to resolve
+func (m *StreamMerge) Conflicts() []merge.FileResult {
+	var conflicts []merge.FileResult
+	for _, f := range m.Files {
+		if f.Outcome == merge.FileConflict {
+			conflicts = append(conflicts, f)
+		}
+	}
+	return conflicts
+}
+
+// MergeStream merges the changesets of source's intents that target does
+// not have into target. The working tree is switched to target, which
+// fails with ErrDirtyTree if it holds uncommitted changes, and each file
+// the changesets touch is merged three ways against the content they
+// changed: cleanly merged files are written and gated, and conflicting
+// ones are written with conflict markers and left ungated. The source
+// intents join target, whose status becomes "conflict" until a commit
+// to it records the resolution, or "stable"; both are written in one
+// unit of work, so target is never left half merged.
+func (p *Parcel) MergeStream(sourceRef, targetRef string) (*StreamMerge, error) {
+	source, target, changes, intents, err := p.streamMergeInputs(sourceRef, targetRef)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.SwitchStream(target.ID, SwitchOptions{}); err != nil {
+		return nil, err
+	}
+	states, err := p.fileStates()
+	if err != nil {
+		return nil, err
+	}
+	ours := make(map[string]string, len(states))
+	for path, state := range states {
+		ours[filepath.ToSlash(path)] = state.Hash
+	}
+
+	labels := merge.Labels{Ours: target.Name, Theirs: source.Name}
+	files, err := merge.MergeFiles(changes, ours, labels, p.Safe.Get)
+	if err != nil {
+		return nil, err
+	}
+	m := &StreamMerge{Source: source, Target: target, Intents: intents, Files: files}
+
+	var gate []string
+	for _, f := range files {
+		path := filepath.FromSlash(f.Path)
+		dst := filepath.Join(p.Root, path)
+		switch {
+		case f.Outcome == merge.FileDeleted:
+			if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+				return m, err
+			}
+		case f.Content != nil:
+			mode := os.FileMode(f.Mode).Perm()
+			if mode == 0 {
+				mode = 0644
+			}
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return m, err
+			}
+			if err := os.WriteFile(dst, f.Content, mode); err != nil {
+				return m, fmt.Errorf("writing %s: %w", f.Path, err)
+			}
+		}
+		if f.Outcome != merge.FileConflict {
+			gate = append(gate, path)
+		}
+	}
+	if len(gate) > 0 {
+		if err := p.GateWith(gate, GateOptions{Force: true}); err != nil {
+			return m, fmt.Errorf("gating merged files: %w", err)
+		}
+	}
+
+	err = storage.Run(p.DB, func(u *storage.UnitOfWork) error {
+		return p.recordStreamMerge(u, m)
+	})
+	if err != nil {
+		return m, fmt.Errorf("updating stream %s: %w", target.Name, err)
+	}
+	for _, id := range intents {
+		if err := p.assignStreamReviewers(m.Target, id); err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+// StreamMergePlan is what MergeStream would do: the merge of each file
+// and the keys the stream update would write
+type StreamMergePlan struct {
+	*StreamMerge
+	Writes []storage.Write `json:"writes"`
+}
+
+// PlanMergeStream works out what MergeStream would do without switching
+// the working tree, writing files or changing the database. Files are
+// merged against target's tree as of its head, which is what the switch
+// would check out.
+func (p *Parcel) PlanMergeStream(sourceRef, targetRef string) (*StreamMergePlan, error) {
+	source, target, changes, intents, err := p.streamMergeInputs(sourceRef, targetRef)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.RequireCleanTree(); err != nil {
+		return nil, err
+	}
+	ours, err := p.streamTree(target)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := merge.Labels{Ours: target.Name, Theirs: source.Name}
+	files, err := merge.MergeFiles(changes, ours, labels, p.Safe.Get)
+	if err != nil {
+		return nil, err
+	}
+	m := &StreamMerge{Source: source, Target: target, Intents: intents, Files: files}
+	writes, err := storage.Preview(p.DB, func(u *storage.UnitOfWork) error {
+		return p.recordStreamMerge(u, m)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &StreamMergePlan{StreamMerge: m, Writes: writes}, nil
+}
+
+// streamMergeInputs resolves the streams of a merge and collects what the
+// source's intents missing from the target change
+func (p *Parcel) streamMergeInputs(sourceRef, targetRef string) (*stream.Stream, *stream.Stream, []merge.FileChange, []string, error) {
+	source, err := p.ResolveStream(sourceRef)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	target, err := p.ResolveStream(targetRef)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if source.ID == target.ID {
+		return nil, nil, nil, nil, errors.ValidationError("cannot merge a stream into itself", nil)
+	}
+	changes, intents, err := p.streamChanges(source, target)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return source, target, changes, intents, nil
+}
+
+// recordStreamMerge adds the merged intents to the target and sets its
+// status from the merge's conflicts, in u
+func (p *Parcel) recordStreamMerge(u *storage.UnitOfWork, m *StreamMerge) error {
+	_, streams, err := p.txStores(u)
+	if err != nil {
+		return err
+	}
+	for _, id := range m.Intents {
+		if err := streams.AddIntent(m.Target.ID, id); err != nil {
+			return err
+		}
+	}
+	target, err := streams.Get(m.Target.ID)
+	if err != nil {
+		return err
+	}
+	target.State.Status = stream.StatusStable
+	if len(m.Conflicts()) > 0 {
+		target.State.Status = stream.StatusConflict
+	}
+	if err := streams.Update(target); err != nil {
+		return err
+	}
+	m.Target = target
+	return nil
+}
+
+// streamTree returns the content hash of each file of a stream as of its
+// head, by slash-separated path. A stream without changesets takes over
+// the tracked files as they are.
+func (p *Parcel) streamTree(st *stream.Stream) (map[string]string, error) {
+	head, err := p.Head(st)
+	if err != nil {
+		return nil, fmt.Errorf("finding head of stream %s: %w", st.Name, err)
+	}
+	var tree map[string]change.FileState
+	if head == "" {
+		if tree, err = p.fileStates(); err != nil {
+			return nil, err
+		}
+	} else {
+		err = p.DB.View(func(txn *badger.Txn) error {
+			tree, err = change.TreeAt(txn, head)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading tree of changeset %s: %w", head, err)
+		}
+	}
+	hashes := make(map[string]string, len(tree))
+	for path, state := range tree {
+		hashes[filepath.ToSlash(path)] = state.Hash
+	}
+	return hashes, nil
+}
+
+// streamChanges collects what the changesets of source's intents missing
+// from target change, per file, in the order they were made: the content
+// before the first of them and after the last. It also returns the IDs of
+// those intents.
+func (p *Parcel) streamChanges(source, target *stream.Stream) ([]merge.FileChange, []string, error) {
+	has := make(map[string]bool, len(target.State.Intents))
+	for _, id := range target.State.Intents {
+		has[id] = true
+	}
+	sourceIntents, err := p.GetStreamIntents(source.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(sourceIntents, func(x, y int) bool { return sourceIntents[x].CreatedAt.Before(sourceIntents[y].CreatedAt) })
+
+	var ids []string
+	byPath := make(map[string]*merge.FileChange)
+	var order []string
+	record := func(path, before, after string, mode int) {
+		path = filepath.ToSlash(path)
+		fc, ok := byPath[path]
+		if !ok {
+			fc = &merge.FileChange{Path: path, Base: before}
+			byPath[path] = fc
+			order = append(order, path)
+		}
+		fc.Theirs, fc.Mode = after, mode
+	}
+
+	err = p.DB.View(func(txn *badger.Txn) error {
+		for _, i := range sourceIntents {
+			if has[i.ID] {
+				continue
+			}
+			ids = append(ids, i.ID)
+			if i.ChangeSetID == "" {
+				continue
+			}
+			cs, err := change.GetChangeSet(txn, i.ChangeSetID)
+			if err != nil {
+				return fmt.Errorf("reading changeset of intent %s: %w", i.ID, err)
+			}
+			for _, c := range cs.Changes {
+				switch c.Type {
+				case "delete":
+					record(c.Path, c.OldHash, "", 0)
+				case "add", "copy":
+					// A copy's old hash is the content it was copied from
+					record(c.Path, "", c.NewHash, c.Mode)
+				case "rename":
+					record(c.OldPath, c.OldHash, "", 0)
+					record(c.Path, "", c.NewHash, c.Mode)
+				default:
+					record(c.Path, c.OldHash, c.NewHash, c.Mode)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes := make([]merge.FileChange, 0, len(order))
+	for _, path := range order {
+		if fc := byPath[path]; fc.Base != fc.Theirs {
+			changes = append(changes, *fc)
+		}
+	}
+	sort.Slice(changes, func(x, y int) bool { return changes[x].Path < changes[y].Path })
+	return changes, ids, nil
+}
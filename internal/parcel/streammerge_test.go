@@ -0,0 +1,99 @@
+// internal/parcel/streammerge_test.go
+package parcel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tig/internal/merge"
+	"tig/internal/storage"
+	"tig/internal/stream"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMergeStream(t *testing.T) {
+	root := t.TempDir()
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	commit := func(streamID string, files map[string]string) string {
+		for name, content := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+			require.NoError(t, p.Tracker.Gate(name))
+		}
+		i, _, err := p.CommitIntent(CommitOptions{Description: "Change", Type: "feature", StreamID: streamID})
+		require.NoError(t, err)
+		// Changesets are ordered by time
+		time.Sleep(5 * time.Millisecond)
+		return i.ID
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	main, err := p.CreateStream("main", "feature")
+	require.NoError(t, err)
+	feature, err := p.CreateStream("feature", "feature")
+	require.NoError(t, err)
+	first := commit(main.ID, map[string]string{"a.txt": "a\nb\nc\n", "b.txt": "x\n"})
+	require.NoError(t, p.AddIntentToStream(feature.ID, first))
+
+	// The streams change the same files
+	_, err = p.SwitchStream("feature", SwitchOptions{})
+	require.NoError(t, err)
+	commit(feature.ID, map[string]string{"a.txt": "A\nb\nc\n", "b.txt": "feature\n"})
+	_, err = p.SwitchStream("main", SwitchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\nc\n", read("a.txt"))
+	commit(main.ID, map[string]string{"a.txt": "a\nb\nC\n", "b.txt": "main\n"})
+
+	_, err = p.MergeStream("main", "main")
+	assert.Error(t, err)
+
+	// A dry run merges the same way but changes nothing
+	plan, err := p.PlanMergeStream("feature", "main")
+	require.NoError(t, err)
+	require.Len(t, plan.Files, 2)
+	assert.Equal(t, merge.FileMerged, plan.Files[0].Outcome)
+	assert.Equal(t, merge.FileConflict, plan.Files[1].Outcome)
+	assert.Equal(t, stream.StatusConflict, plan.Target.State.Status)
+	assert.Contains(t, plan.Writes, storage.Write{Key: "stream:" + main.ID})
+	assert.Equal(t, "a\nb\nC\n", read("a.txt"))
+	assert.Empty(t, gatedPaths(t, p))
+	unchanged, err := p.ResolveStream("main")
+	require.NoError(t, err)
+	assert.Len(t, unchanged.State.Intents, 2)
+
+	m, err := p.MergeStream("feature", "main")
+	require.NoError(t, err)
+	require.Len(t, m.Files, 2)
+	assert.Equal(t, merge.FileMerged, m.Files[0].Outcome)
+	assert.Equal(t, "A\nb\nC\n", read("a.txt"))
+	assert.Equal(t, merge.FileConflict, m.Files[1].Outcome)
+	assert.Equal(t, "<<<<<<< main\nmain\n=======\nfeature\n>>>>>>> feature\n", read("b.txt"))
+	assert.Equal(t, []string{"a.txt"}, gatedPaths(t, p))
+
+	main, err = p.ResolveStream("main")
+	require.NoError(t, err)
+	assert.Equal(t, stream.StatusConflict, main.State.Status)
+	assert.Len(t, main.State.Intents, 3)
+
+	// Committing the resolution makes the stream stable again
+	commit(main.ID, map[string]string{"b.txt": "both\n"})
+	main, err = p.ResolveStream("main")
+	require.NoError(t, err)
+	assert.Equal(t, stream.StatusStable, main.State.Status)
+
+	// Nothing is left to merge
+	m, err = p.MergeStream("feature", "main")
+	require.NoError(t, err)
+	assert.Empty(t, m.Files)
+}
@@ -0,0 +1,141 @@
+// internal/parcel/summary.go
+package parcel
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/intent"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// pathIndexPrefix is the prefix of the changeset path index,
+// cs_path:<path>:<changeset ID>
+const pathIndexPrefix = "cs_path:"
+
+// DirSummary orients a reader in a directory subtree: what last changed
+// it, how busy it has been lately, who works on it and what is gated
+type DirSummary struct {
+	Dir          string         `json:"dir"`
+	ChangeSets   int            `json:"changesets"` // changesets that ever touched the subtree
+	LastIntent   *intent.Intent `json:"last_intent,omitempty"`
+	Since        time.Time      `json:"since"`
+	Churn        int            `json:"churn"`       // file changes since Since
+	ChurnFiles   []PathCount    `json:"churn_files"` // most changed files since Since
+	Contributors []AuthorCount  `json:"contributors"`
+	Gated        []string       `json:"gated"` // paths of pending gated changes
+}
+
+// PathCount is how often a file changed
+type PathCount struct {
+	Path    string `json:"path"`
+	Changes int    `json:"changes"`
+}
+
+// AuthorCount is how many file changes an author made
+type AuthorCount struct {
+	Author  string `json:"author"`
+	Changes int    `json:"changes"`
+}
+
+// SummaryOptions bounds a directory summary
+type SummaryOptions struct {
+	Since time.Time // start of the churn window
+	Limit int       // files and contributors listed; 0 lists all
+}
+
+// Summarize summarizes the subtree at dir, relative to the repository
+// root, from the changeset path index and the gated changes. "." is the
+// whole repository.
+func (p *Parcel) Summarize(dir string, opts SummaryOptions) (*DirSummary, error) {
+	dir = filepath.Clean(dir)
+	prefix := pathIndexPrefix
+	if dir != "." {
+		prefix += dir + string(filepath.Separator)
+	}
+	s := &DirSummary{Dir: dir, Since: opts.Since, ChurnFiles: []PathCount{}, Contributors: []AuthorCount{}, Gated: []string{}}
+
+	var last *change.ChangeSet
+	churn := map[string]int{}
+	authors := map[string]int{}
+	err := p.DB.View(func(txn *badger.Txn) error {
+		// Index keys end in the changeset ID, which holds no colon
+		touched := map[string][]string{} // changeset ID to paths
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: []byte(prefix)})
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := strings.TrimPrefix(string(it.Item().Key()), pathIndexPrefix)
+			sep := strings.LastIndexByte(key, ':')
+			if sep < 0 {
+				continue
+			}
+			touched[key[sep+1:]] = append(touched[key[sep+1:]], key[:sep])
+		}
+		it.Close()
+
+		for id, paths := range touched {
+			cs, err := change.GetChangeSet(txn, id)
+			if err != nil {
+				return err
+			}
+			s.ChangeSets++
+			if last == nil || cs.CreatedAt.After(last.CreatedAt) {
+				last = cs
+			}
+			if cs.Author != "" {
+				authors[cs.Author] += len(paths)
+			}
+			if cs.CreatedAt.Before(opts.Since) {
+				continue
+			}
+			for _, path := range paths {
+				churn[path]++
+				s.Churn++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading path index: %w", err)
+	}
+
+	if last != nil && last.IntentID != "" {
+		if s.LastIntent, err = p.IntentStore.Get(last.IntentID); err != nil {
+			return nil, err
+		}
+	}
+	for path, n := range churn {
+		s.ChurnFiles = append(s.ChurnFiles, PathCount{Path: path, Changes: n})
+	}
+	sort.Slice(s.ChurnFiles, func(a, b int) bool {
+		x, y := s.ChurnFiles[a], s.ChurnFiles[b]
+		return x.Changes > y.Changes || x.Changes == y.Changes && x.Path < y.Path
+	})
+	for author, n := range authors {
+		s.Contributors = append(s.Contributors, AuthorCount{Author: author, Changes: n})
+	}
+	sort.Slice(s.Contributors, func(a, b int) bool {
+		x, y := s.Contributors[a], s.Contributors[b]
+		return x.Changes > y.Changes || x.Changes == y.Changes && x.Author < y.Author
+	})
+	if opts.Limit > 0 {
+		s.ChurnFiles = s.ChurnFiles[:min(opts.Limit, len(s.ChurnFiles))]
+		s.Contributors = s.Contributors[:min(opts.Limit, len(s.Contributors))]
+	}
+
+	status, err := p.Tracker.Status()
+	if err != nil {
+		return nil, fmt.Errorf("reading gated changes: %w", err)
+	}
+	for _, c := range status {
+		if c.Gated && (dir == "." || strings.HasPrefix(c.Path, dir+string(filepath.Separator))) {
+			s.Gated = append(s.Gated, c.Path)
+		}
+	}
+	sort.Strings(s.Gated)
+	return s, nil
+}
@@ -0,0 +1,352 @@
+// internal/parcel/switch.go
+package parcel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"tig/internal/change"
+	tigerrors "tig/internal/errors"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	stateKey    = "parcel_state"
+	stashPrefix = "stash:"
+)
+
+// Stash holds the uncommitted changes set aside when switching away from
+// a stream, until the working tree switches back to it
+type Stash struct {
+	StreamID  string            `json:"stream_id"`
+	Files     map[string]string `json:"files"`             // Slash-separated path to content hash of added and modified files
+	Deleted   []string          `json:"deleted,omitempty"` // Tracked files that had been removed
+	Gated     []string          `json:"gated,omitempty"`   // Paths that were gated
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Paths lists every path the stash holds a change to
+func (s *Stash) Paths() []string {
+	paths := append([]string(nil), s.Deleted...)
+	for path := range s.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// SwitchOptions controls SwitchStream
+type SwitchOptions struct {
+	// Stash sets uncommitted changes aside, to be brought back when the
+	// working tree switches back to the stream they were made on, instead
+	// of refusing to switch
+	Stash bool
+}
+
+// SwitchResult describes a completed stream switch
+type SwitchResult struct {
+	Stream    *stream.Stream `json:"stream"`
+	Head      string         `json:"head,omitempty"` // Empty when the stream has no changesets
+	Written   int            `json:"written"`
+	Removed   int            `json:"removed"`
+	Stashed   []string       `json:"stashed,omitempty"`   // Paths set aside from the stream left
+	Unstashed []string       `json:"unstashed,omitempty"` // Paths brought back from the stream's stash
+}
+
+// State returns the parcel's operational state
+func (p *Parcel) State() (*ParcelState, error) {
+	state := &ParcelState{GatedChanges: make(map[string]string)}
+	err := p.DB.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(stateKey))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, state)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading parcel state: %w", err)
+	}
+	return state, nil
+}
+
+func (p *Parcel) saveState(state *ParcelState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshaling parcel state: %w", err)
+	}
+	return p.DB.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(stateKey), data)
+	})
+}
+
+// CurrentStream returns the stream the working tree was last switched to.
+// Before any switch it is the stream the latest intent was added to, and
+// nil without one.
+func (p *Parcel) CurrentStream() (*stream.Stream, error) {
+	state, err := p.State()
+	if err != nil {
+		return nil, err
+	}
+	if state.CurrentStream != "" {
+		st, err := p.StreamStore.Get(state.CurrentStream)
+		if err == nil {
+			return st, nil
+		}
+		p.Logger.Debug("Current stream no longer exists")
+	}
+	_, st, err := p.LatestIntent()
+	return st, err
+}
+
+// SwitchStream makes a stream current and materializes its tree, as of
+// its head, into the working tree: tracked files are rewritten to match
+// it and those it does not have are removed. A stream without changesets
+// takes over the tree as it is. Uncommitted changes, gated or not, make
+// the switch fail with ErrDirtyTree unless opts.Stash sets them aside;
+// they are brought back when the working tree next switches to the
+// stream they were made on, the current stream. Without a current stream
+// there is nothing to stash them on and the switch fails.
+func (p *Parcel) SwitchStream(ref string, opts SwitchOptions) (*SwitchResult, error) {
+	st, err := p.ResolveStream(ref)
+	if err != nil {
+		return nil, err
+	}
+	head, err := p.Head(st)
+	if err != nil {
+		return nil, fmt.Errorf("finding head of stream %s: %w", st.Name, err)
+	}
+	state, err := p.State()
+	if err != nil {
+		return nil, err
+	}
+	result := &SwitchResult{Stream: st, Head: head}
+
+	before, err := p.fileStates()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.RequireCleanTree(); err != nil {
+		if !errors.Is(err, tigerrors.ErrDirtyTree) {
+			return nil, err
+		}
+		if !opts.Stash {
+			return nil, fmt.Errorf("%w: commit the changes or switch with --stash", tigerrors.ErrDirtyTree)
+		}
+		// Changes are stashed on the stream they were made on, which on a
+		// repository that never switched is the latest intent's
+		from, err := p.CurrentStream()
+		if err != nil {
+			return nil, err
+		}
+		if from == nil {
+			return nil, fmt.Errorf("%w: no current stream to stash them on; commit the changes first", tigerrors.ErrDirtyTree)
+		}
+		stash, err := p.stash(from.ID, before)
+		if err != nil {
+			return nil, fmt.Errorf("stashing changes: %w", err)
+		}
+		result.Stashed = stash.Paths()
+	}
+
+	if head != "" {
+		tree, err := p.checkoutChangeSet(before, head)
+		if err != nil {
+			return nil, fmt.Errorf("materializing stream %s: %w", st.Name, err)
+		}
+		for path := range before {
+			if _, ok := tree[filepath.ToSlash(path)]; !ok {
+				result.Removed++
+			}
+		}
+		result.Written = len(tree)
+	}
+
+	if result.Unstashed, err = p.unstash(st.ID); err != nil {
+		return result, fmt.Errorf("restoring stashed changes: %w", err)
+	}
+
+	state.CurrentStream = st.ID
+	state.LastSync = time.Now()
+	if err := p.saveState(state); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// checkoutChangeSet makes the files as of a changeset the tracked files,
+// in place of before, and writes them into the working tree. It returns
+// the changeset's tree.
+func (p *Parcel) checkoutChangeSet(before map[string]change.FileState, id string) (map[string]change.FileState, error) {
+	var tree map[string]change.FileState
+	err := p.DB.View(func(txn *badger.Txn) error {
+		var err error
+		tree, err = change.TreeAt(txn, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading tree of changeset %s: %w", id, err)
+	}
+	if err := p.replaceFileStates(before, tree); err != nil {
+		return nil, err
+	}
+	if err := p.CheckoutTracked(before); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// replaceFileStates records tree, keyed by slash-separated path, as the
+// tracked files in place of before
+func (p *Parcel) replaceFileStates(before, tree map[string]change.FileState) error {
+	return p.DB.Update(func(txn *badger.Txn) error {
+		for path := range before {
+			if err := txn.Delete([]byte("file_state:" + path)); err != nil {
+				return err
+			}
+		}
+		for path, state := range tree {
+			data, err := json.Marshal(state)
+			if err != nil {
+				return fmt.Errorf("marshaling file state: %w", err)
+			}
+			if err := txn.Set([]byte("file_state:"+filepath.FromSlash(path)), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// stash stores the working tree's changes against the tracked files in
+// the Safe, records them for streamID and returns the working tree to the
+// tracked files, with nothing gated
+func (p *Parcel) stash(streamID string, tracked map[string]change.FileState) (*Stash, error) {
+	status, err := p.Status()
+	if err != nil {
+		return nil, err
+	}
+	current, _, err := p.readSnapshot(p.Root)
+	if err != nil {
+		return nil, fmt.Errorf("reading working tree: %w", err)
+	}
+	base := make(map[string]change.FileState, len(tracked))
+	for path, state := range tracked {
+		base[filepath.ToSlash(path)] = state
+	}
+	changes := diffSnapshots(base, current, &ImportedSnapshot{})
+	if err := p.storeSnapshotContent(p.Root, changes); err != nil {
+		return nil, err
+	}
+
+	s := &Stash{StreamID: streamID, Files: make(map[string]string), CreatedAt: time.Now()}
+	for _, c := range changes {
+		if c.Type == "delete" {
+			s.Deleted = append(s.Deleted, filepath.ToSlash(c.Path))
+		} else {
+			s.Files[filepath.ToSlash(c.Path)] = c.NewHash
+		}
+	}
+	var gated []string
+	for _, c := range status {
+		if c.Gated {
+			gated = append(gated, c.Path)
+			s.Gated = append(s.Gated, filepath.ToSlash(c.Path))
+		}
+	}
+	sort.Strings(s.Gated)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling stash: %w", err)
+	}
+	if err := p.DB.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(stashPrefix+streamID), data)
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(gated) > 0 {
+		if err := p.Workspace.Ungate(gated); err != nil {
+			return nil, fmt.Errorf("ungating stashed files: %w", err)
+		}
+	}
+	for _, c := range changes {
+		if c.Type == "add" {
+			if err := os.Remove(filepath.Join(p.Root, c.Path)); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+	if err := p.CheckoutTracked(tracked); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// unstash brings back the changes stashed when the working tree left a
+// stream and removes the stash, returning the paths it changed
+func (p *Parcel) unstash(streamID string) ([]string, error) {
+	var s Stash
+	err := p.DB.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(stashPrefix + streamID))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &s)
+		})
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for path, hash := range s.Files {
+		content, err := p.Safe.Get(hash)
+		if err != nil {
+			return nil, fmt.Errorf("loading stashed %s: %w", path, err)
+		}
+		dst := filepath.Join(p.Root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(dst, content, 0644); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", path, err)
+		}
+	}
+	for _, path := range s.Deleted {
+		if err := os.Remove(filepath.Join(p.Root, filepath.FromSlash(path))); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if len(s.Gated) > 0 {
+		gated := make([]string, len(s.Gated))
+		for n, path := range s.Gated {
+			gated[n] = filepath.FromSlash(path)
+		}
+		if err := p.GateWith(gated, GateOptions{Force: true}); err != nil {
+			return nil, fmt.Errorf("gating stashed files: %w", err)
+		}
+	}
+
+	if err := p.DB.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(stashPrefix + streamID))
+	}); err != nil {
+		return nil, err
+	}
+	return s.Paths(), nil
+}
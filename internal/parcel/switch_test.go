@@ -0,0 +1,140 @@
+// internal/parcel/switch_test.go
+package parcel
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tigerrors "tig/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSwitchStream(t *testing.T) {
+	snapshots := t.TempDir()
+	for name, content := range map[string]string{
+		"v1/a.txt": "one", "v1/lib/b.txt": "b1",
+		"v2/a.txt": "two", "v2/lib/b.txt": "b2", "v2/lib/c.txt": "c",
+	} {
+		p := filepath.Join(snapshots, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	imported, err := p.ImportSnapshots(snapshots, SnapshotOptions{Checkout: true}, nil)
+	require.NoError(t, err)
+	require.Len(t, imported, 2)
+
+	main, err := p.CreateStream("main", "feature")
+	require.NoError(t, err)
+	require.NoError(t, p.AddIntentToStream(main.ID, imported[1].IntentID))
+	hotfix, err := p.CreateStream("hotfix", "hotfix")
+	require.NoError(t, err)
+	require.NoError(t, p.AddIntentToStream(hotfix.ID, imported[0].IntentID))
+
+	read := func(path string) string {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	res, err := p.SwitchStream("hotfix", SwitchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, imported[0].ChangeSetID, res.Head)
+	assert.Equal(t, 1, res.Removed)
+	assert.Equal(t, "one", read("a.txt"))
+	assert.NoFileExists(t, filepath.Join(root, "lib", "c.txt"))
+	require.NoError(t, p.RequireCleanTree())
+
+	current, err := p.CurrentStream()
+	require.NoError(t, err)
+	assert.Equal(t, hotfix.ID, current.ID)
+
+	// Uncommitted edits are refused without stashing
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("local"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "new.txt"), []byte("new"), 0644))
+	require.NoError(t, p.Gate([]string{"new.txt"}))
+	_, err = p.SwitchStream("main", SwitchOptions{})
+	assert.True(t, errors.Is(err, tigerrors.ErrDirtyTree))
+	assert.Equal(t, "local", read("a.txt"))
+
+	res, err = p.SwitchStream("main", SwitchOptions{Stash: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "new.txt"}, res.Stashed)
+	assert.Equal(t, "two", read("a.txt"))
+	assert.Equal(t, "c", read("lib/c.txt"))
+	assert.NoFileExists(t, filepath.Join(root, "new.txt"))
+	require.NoError(t, p.RequireCleanTree())
+
+	// Switching back brings the stashed changes with it
+	res, err = p.SwitchStream("hotfix", SwitchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "new.txt"}, res.Unstashed)
+	assert.Equal(t, "local", read("a.txt"))
+	assert.Equal(t, "new", read("new.txt"))
+	assert.Equal(t, []string{"new.txt"}, gatedPaths(t, p))
+}
+
+func TestSwitchStreamStashFromFreshRepo(t *testing.T) {
+	snapshots := t.TempDir()
+	for name, content := range map[string]string{"v1/a.txt": "one", "v2/a.txt": "two"} {
+		p := filepath.Join(snapshots, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	root := t.TempDir()
+	require.NoError(t, Initialize(root))
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+
+	imported, err := p.ImportSnapshots(snapshots, SnapshotOptions{Checkout: true}, nil)
+	require.NoError(t, err)
+	require.Len(t, imported, 2)
+	read := func(path string) string {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// Without a stream there is nothing to stash the changes on
+	_, err = p.CreateStream("empty", "feature")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("local"), 0644))
+	_, err = p.SwitchStream("empty", SwitchOptions{Stash: true})
+	assert.True(t, errors.Is(err, tigerrors.ErrDirtyTree))
+	assert.Equal(t, "local", read("a.txt"))
+
+	// The repository never switched, so the latest intent's stream is the
+	// one the changes were made on
+	main, err := p.CreateStream("main", "feature")
+	require.NoError(t, err)
+	require.NoError(t, p.AddIntentToStream(main.ID, imported[1].IntentID))
+	hotfix, err := p.CreateStream("hotfix", "hotfix")
+	require.NoError(t, err)
+	require.NoError(t, p.AddIntentToStream(hotfix.ID, imported[0].IntentID))
+	state, err := p.State()
+	require.NoError(t, err)
+	require.Empty(t, state.CurrentStream)
+
+	res, err := p.SwitchStream("hotfix", SwitchOptions{Stash: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt"}, res.Stashed)
+	assert.Equal(t, "one", read("a.txt"))
+
+	res, err = p.SwitchStream("main", SwitchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt"}, res.Unstashed)
+	assert.Equal(t, "local", read("a.txt"))
+}
@@ -0,0 +1,201 @@
+// internal/parcel/sync.go
+package parcel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tig/internal/config"
+	tigerrors "tig/internal/errors"
+	"tig/internal/remote"
+	"tig/internal/safe"
+)
+
+// SyncResult describes a push or pull
+type SyncResult struct {
+	Remote     string `json:"remote"`
+	ChangeSets int    `json:"changesets"` // Changesets the receiving side did not have
+	Records    int    `json:"records"`    // Intents, streams and other records written
+	Blobs      int    `json:"blobs"`      // Content objects sent or fetched
+	Bytes      int64  `json:"bytes"`
+	// Pull only: the working tree moved to the current stream's new head,
+	// or could not because it has uncommitted changes
+	Updated bool   `json:"updated,omitempty"`
+	Dirty   bool   `json:"dirty,omitempty"`
+	Head    string `json:"head,omitempty"`
+}
+
+// remoteURL returns url, or the repository's remote without one. The
+// first URL a repository without a remote syncs with becomes its remote.
+func (p *Parcel) remoteURL(url string) (string, *config.RepoConfig, error) {
+	cfg, err := config.LoadRepo(p.Root)
+	if err != nil {
+		return "", nil, err
+	}
+	if url == "" {
+		url = cfg.Remote.URL
+	}
+	if url == "" {
+		return "", nil, tigerrors.ValidationError("no remote configured; pass the URL of a tig server", nil)
+	}
+	if cfg.Remote.URL == "" {
+		cfg.Remote.URL = url
+		if err := config.SaveRepo(p.Root, cfg); err != nil {
+			return "", nil, fmt.Errorf("saving repo config: %w", err)
+		}
+	}
+	return url, cfg, nil
+}
+
+// Push sends the server at url, or the repository's remote, the
+// changesets, intents and streams it lacks, and the content those
+// changesets reference that it does not have. Of an intent or stream both
+// sides changed, the newer version wins. The server records its own
+// history of what it receives and checks pushed intents as it would
+// intents created through its API.
+func (p *Parcel) Push(ctx context.Context, url string) (*SyncResult, error) {
+	url, _, err := p.remoteURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := remote.NewClient(url)
+	res := &SyncResult{Remote: client.BaseURL}
+
+	plan, err := p.negotiate(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.PushChangeSets) == 0 && len(plan.PushRecords) == 0 {
+		return res, nil
+	}
+
+	b, err := remote.ExportBundle(p.DB, plan.PushChangeSets, plan.PushRecords)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := client.PushContent(ctx, b.Hashes(), p.Safe.Get)
+	if err != nil {
+		return nil, fmt.Errorf("pushing content: %w", err)
+	}
+	res.Blobs, res.Bytes = stats.Sent, stats.Bytes
+
+	applied, err := client.PushBundle(ctx, b)
+	if err != nil {
+		return nil, fmt.Errorf("pushing history: %w", err)
+	}
+	res.ChangeSets, res.Records = applied.ChangeSets, applied.Records
+	return res, nil
+}
+
+// Pull fetches from the server at url, or the repository's remote, the
+// changesets, intents, streams and records this repository lacks, with
+// the content of those changesets; partial clones record the content as
+// remote instead. When the current stream's head moves and the working
+// tree is clean, the tree is brought up to it.
+func (p *Parcel) Pull(ctx context.Context, url string) (*SyncResult, error) {
+	url, cfg, err := p.remoteURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := remote.NewClient(url)
+	res := &SyncResult{Remote: client.BaseURL}
+
+	plan, err := p.negotiate(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.PullChangeSets) == 0 && len(plan.PullRecords) == 0 {
+		return res, nil
+	}
+	b, err := client.PullBundle(ctx, &remote.PullRequest{ChangeSets: plan.PullChangeSets, Records: plan.PullRecords})
+	if err != nil {
+		return nil, fmt.Errorf("pulling history: %w", err)
+	}
+
+	// Content first, so no changeset is recorded without it
+	partial := cfg.Remote.Partial && cfg.Remote.URL == url
+	for _, hash := range b.Hashes() {
+		if _, err := p.Safe.Meta(hash); err == nil {
+			continue
+		} else if !errors.Is(err, safe.ErrContentNotFound) {
+			return nil, err
+		}
+		if partial {
+			if err := p.Safe.AddRemote(hash, 1); err != nil {
+				return nil, fmt.Errorf("recording %s: %w", hash, err)
+			}
+			continue
+		}
+		data, err := client.Blob(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		stored, err := p.Safe.Store(data)
+		if err != nil {
+			return nil, err
+		}
+		if stored != hash {
+			p.Safe.Delete(stored)
+			return nil, fmt.Errorf("content for %s hashes to %s", hash, stored)
+		}
+		res.Blobs++
+		res.Bytes += int64(len(data))
+	}
+
+	current, err := p.CurrentStream()
+	if err != nil {
+		return nil, err
+	}
+	var oldHead string
+	if current != nil {
+		if oldHead, err = p.Head(current); err != nil {
+			return nil, err
+		}
+	}
+
+	applied, err := remote.ApplyBundle(p.DB, b)
+	if err != nil {
+		return nil, err
+	}
+	res.ChangeSets, res.Records = applied.ChangeSets, applied.Records
+
+	if current == nil {
+		return res, nil
+	}
+	if current, err = p.StreamStore.Get(current.ID); err != nil {
+		return res, err
+	}
+	if res.Head, err = p.Head(current); err != nil || res.Head == "" || res.Head == oldHead {
+		return res, err
+	}
+	if err := p.RequireCleanTree(); err != nil {
+		if errors.Is(err, tigerrors.ErrDirtyTree) {
+			res.Dirty = true
+			return res, nil
+		}
+		return res, err
+	}
+	before, err := p.fileStates()
+	if err != nil {
+		return res, err
+	}
+	if _, err := p.checkoutChangeSet(before, res.Head); err != nil {
+		return res, fmt.Errorf("updating working tree: %w", err)
+	}
+	res.Updated = true
+	return res, nil
+}
+
+// negotiate sends this repository's inventory to the server
+func (p *Parcel) negotiate(ctx context.Context, client *remote.Client) (*remote.Plan, error) {
+	inv, err := remote.BuildInventory(p.DB)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := client.Negotiate(ctx, inv)
+	if err != nil {
+		return nil, fmt.Errorf("negotiating with %s: %w", client.BaseURL, err)
+	}
+	return plan, nil
+}
@@ -0,0 +1,101 @@
+// internal/parcel/sync_test.go
+package parcel
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tig/internal/config"
+	"tig/internal/logging"
+	"tig/internal/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPushPull(t *testing.T) {
+	origin, err := New(t.TempDir(), zap.NewNop())
+	require.NoError(t, err)
+	defer origin.Close()
+	srv, err := server.New(config.Default(), origin.DB, origin.Safe, origin.Root, &logging.Logger{Logger: zap.NewNop()})
+	require.NoError(t, err)
+	defer srv.Close()
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	open := func() *Parcel {
+		root := t.TempDir()
+		require.NoError(t, Initialize(root))
+		p, err := New(root, zap.NewNop())
+		require.NoError(t, err)
+		t.Cleanup(func() { p.Close() })
+		return p
+	}
+	snapshot := func(dir, content string) string {
+		snapshots := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(snapshots, dir), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(snapshots, dir, "app.txt"), []byte(content), 0644))
+		return snapshots
+	}
+	ctx := context.Background()
+
+	a := open()
+	imported, err := a.ImportSnapshots(snapshot("v1", "one"), SnapshotOptions{Checkout: true}, nil)
+	require.NoError(t, err)
+	main, err := a.CreateStream("main", "feature")
+	require.NoError(t, err)
+	require.NoError(t, a.AddIntentToStream(main.ID, imported[0].IntentID))
+
+	_, err = a.Push(ctx, "")
+	assert.Error(t, err, "no remote configured")
+
+	res, err := a.Push(ctx, ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.ChangeSets)
+	assert.Equal(t, 1, res.Blobs)
+	_, err = origin.IntentStore.Get(imported[0].IntentID)
+	require.NoError(t, err)
+	_, err = origin.StreamStore.Get(main.ID)
+	require.NoError(t, err)
+
+	// The URL became the remote
+	res, err = a.Push(ctx, "")
+	require.NoError(t, err)
+	assert.Zero(t, res.ChangeSets+res.Records)
+
+	b := open()
+	res, err = b.Pull(ctx, ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.ChangeSets)
+	assert.Equal(t, 1, res.Blobs)
+	st, err := b.ResolveStream("main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{imported[0].IntentID}, st.State.Intents)
+
+	// New history on a's side moves b's current stream and its tree
+	more, err := a.ImportSnapshots(snapshot("v2", "two"), SnapshotOptions{Checkout: true}, nil)
+	require.NoError(t, err)
+	require.NoError(t, a.AddIntentToStream(main.ID, more[0].IntentID))
+	i, err := a.IntentStore.Get(imported[0].IntentID)
+	require.NoError(t, err)
+	i.Description = "First version"
+	require.NoError(t, a.IntentStore.Update(i))
+	_, err = a.Push(ctx, "")
+	require.NoError(t, err)
+
+	res, err = b.Pull(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.ChangeSets)
+	assert.True(t, res.Updated)
+	assert.Equal(t, more[0].ChangeSetID, res.Head)
+	data, err := os.ReadFile(filepath.Join(b.Root, "app.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(data))
+	i, err = b.IntentStore.Get(imported[0].IntentID)
+	require.NoError(t, err)
+	assert.Equal(t, "First version", i.Description)
+}
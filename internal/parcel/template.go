@@ -0,0 +1,380 @@
+// internal/parcel/template.go
+package parcel
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tig/internal/config"
+	"tig/internal/errors"
+	"tig/internal/ignore"
+	"tig/internal/stream"
+
+	"go.uber.org/zap"
+)
+
+// maxTemplateSize bounds how much a remote template archive may unpack to
+const maxTemplateSize = 64 << 20
+
+// TemplateIgnoreFile lists glob patterns, one per line, that a template
+// adds to the repository's watch.exclude patterns
+const TemplateIgnoreFile = ignore.File
+
+// StreamTemplate is a stream a template creates, stored as
+// .tig/streams/<name>.json in the template
+type StreamTemplate struct {
+	Name   string         `json:"name"`
+	Type   string         `json:"type"`
+	Config *stream.Config `json:"config,omitempty"`
+}
+
+// TemplateDir returns the directory that named templates are read from,
+// e.g. ~/.config/tig/templates on Linux
+func TemplateDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating user config directory: %w", err)
+	}
+	return filepath.Join(dir, "tig", "templates"), nil
+}
+
+// InitializeFromTemplate initializes a new repository at root and seeds it
+// from a template: a directory laid out like a repository, given as a
+// path, the name of a directory in TemplateDir, or the URL of a .tar.gz or
+// .zip archive of one.
+//
+// Files outside the template's .tig directory are copied into the working
+// tree; existing files are never overwritten. Its .tig/config.json becomes
+// the repository config, with the patterns of a top-level .tigignore added
+// to watch.exclude. Streams described in .tig/streams/*.json are created
+// and any other files under .tig, such as hooks and policies, are copied
+// as is.
+func InitializeFromTemplate(ctx context.Context, root, src string, logger *zap.Logger) error {
+	if _, err := os.Stat(filepath.Join(root, ".tig")); err == nil {
+		return fmt.Errorf("%s is already a tig repository", root)
+	}
+
+	dir, cleanup, err := fetchTemplate(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	streams, err := copyTemplate(dir, root)
+	if err != nil {
+		return err
+	}
+
+	p, err := New(root, logger)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	for _, st := range streams {
+		s, err := p.CreateStream(st.Name, st.Type)
+		if err != nil {
+			return err
+		}
+		if st.Config != nil {
+			s.Config = *st.Config
+			if s.Config.FeatureFlags == nil {
+				s.Config.FeatureFlags = []stream.FeatureFlag{}
+			}
+			if s.Config.Protection.RequiredChecks == nil {
+				s.Config.Protection.RequiredChecks = []string{}
+			}
+			if err := p.StreamStore.Update(s); err != nil {
+				return fmt.Errorf("configuring stream %s: %w", st.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// copyTemplate copies a template directory into root and returns the
+// streams it describes. Nothing is written unless the whole template
+// applies cleanly.
+func copyTemplate(dir, root string) ([]StreamTemplate, error) {
+	cfg := &config.RepoConfig{}
+	var streams []StreamTemplate
+	var ignores []string
+	files := make(map[string]string) // destination -> source
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case d.IsDir():
+			if rel == ".tig/db" || rel == ".tig/content" {
+				return fs.SkipDir
+			}
+			return nil
+		case !d.Type().IsRegular():
+			return nil
+		case rel == ".tig/"+config.RepoConfigFile:
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(data, cfg); err != nil {
+				return fmt.Errorf("parsing template config: %w", err)
+			}
+		case path.Dir(rel) == ".tig/streams" && path.Ext(rel) == ".json":
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			var st StreamTemplate
+			if err := json.Unmarshal(data, &st); err != nil {
+				return fmt.Errorf("parsing stream template %s: %w", rel, err)
+			}
+			if st.Name == "" {
+				st.Name = strings.TrimSuffix(path.Base(rel), ".json")
+			}
+			if st.Type == "" {
+				st.Type = "feature"
+			}
+			if st.Config != nil {
+				if err := st.Config.Protection.Validate(); err != nil {
+					return fmt.Errorf("stream template %s: %w", rel, err)
+				}
+			}
+			streams = append(streams, st)
+		case rel == TemplateIgnoreFile:
+			patterns, err := ignore.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			ignores = patterns
+		default:
+			dst := filepath.Join(root, filepath.FromSlash(rel))
+			if !strings.HasPrefix(rel, ".tig/") {
+				if _, err := os.Lstat(dst); err == nil {
+					return fmt.Errorf("template file %s already exists in the working tree", rel)
+				}
+			}
+			files[dst] = p
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading template: %w", err)
+	}
+
+	cfg.Watch.Exclude = append(cfg.Watch.Exclude, ignores...)
+	for _, validate := range []func() error{cfg.Cache.Validate, cfg.Gate.Validate, cfg.Copies.Validate, cfg.IntentFields.Validate} {
+		if err := validate(); err != nil {
+			return nil, fmt.Errorf("template config: %w", err)
+		}
+	}
+
+	if err := Initialize(root); err != nil {
+		return nil, err
+	}
+	if err := config.SaveRepo(root, cfg); err != nil {
+		return nil, fmt.Errorf("writing repo config: %w", err)
+	}
+	for dst, src := range files {
+		if err := copyFile(src, dst); err != nil {
+			return nil, err
+		}
+	}
+	return streams, nil
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(dst), err)
+	}
+	if err := os.WriteFile(dst, data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("writing %s: %w", dst, err)
+	}
+	return nil
+}
+
+// fetchTemplate resolves a template reference to a local directory. The
+// returned cleanup removes any temporary download.
+func fetchTemplate(ctx context.Context, src string) (string, func(), error) {
+	noop := func() {}
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		dir, err := os.MkdirTemp("", "tig-template-*")
+		if err != nil {
+			return "", noop, err
+		}
+		cleanup := func() { os.RemoveAll(dir) }
+		if err := downloadTemplate(ctx, src, dir); err != nil {
+			cleanup()
+			return "", noop, err
+		}
+		return unwrapArchiveRoot(dir), cleanup, nil
+	}
+
+	if info, err := os.Stat(src); err == nil && info.IsDir() {
+		return src, noop, nil
+	}
+	templates, err := TemplateDir()
+	if err != nil {
+		return "", noop, err
+	}
+	dir := filepath.Join(templates, src)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", noop, errors.NotFound(fmt.Sprintf("template %q not found: not a directory, a template in %s or an http(s) URL", src, templates))
+	}
+	return dir, noop, nil
+}
+
+func downloadTemplate(ctx context.Context, url, dir string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("creating template request: %w", err)
+	}
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("downloading template: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading template: %s returned %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTemplateSize+1))
+	if err != nil {
+		return fmt.Errorf("downloading template: %w", err)
+	}
+	if len(data) > maxTemplateSize {
+		return fmt.Errorf("template archive exceeds %d MB", maxTemplateSize>>20)
+	}
+
+	name := strings.ToLower(strings.SplitN(url, "?", 2)[0])
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return extractZip(data, dir)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return extractTarGz(data, dir)
+	}
+	return fmt.Errorf("unsupported template archive %s: use .tar.gz, .tgz or .zip", url)
+}
+
+// archivePath returns where an archive entry is extracted, rejecting
+// entries that would escape dir
+func archivePath(dir, name string) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	clean := path.Clean(name)
+	if path.IsAbs(name) || filepath.VolumeName(clean) != "" || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("invalid path %q in template archive", name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(clean)), nil
+}
+
+func extractTarGz(data []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("reading template archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading template archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		dst, err := archivePath(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if total += hdr.Size; total > maxTemplateSize {
+			return fmt.Errorf("template archive exceeds %d MB", maxTemplateSize>>20)
+		}
+		if err := writeArchiveFile(dst, tr, fs.FileMode(hdr.Mode).Perm()); err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(data []byte, dir string) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("reading template archive: %w", err)
+	}
+	var total uint64
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		dst, err := archivePath(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		if total += f.UncompressedSize64; total > maxTemplateSize {
+			return fmt.Errorf("template archive exceeds %d MB", maxTemplateSize>>20)
+		}
+		r, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("reading template archive: %w", err)
+		}
+		err = writeArchiveFile(dst, r, f.Mode().Perm())
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeArchiveFile(dst string, r io.Reader, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, io.LimitReader(r, maxTemplateSize)); err != nil {
+		f.Close()
+		return fmt.Errorf("extracting %s: %w", dst, err)
+	}
+	return f.Close()
+}
+
+// unwrapArchiveRoot descends into the single top-level directory that
+// archives of a repository usually have, e.g. "template-main/"
+func unwrapArchiveRoot(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err == nil && len(entries) == 1 && entries[0].IsDir() && entries[0].Name() != ".tig" {
+		return filepath.Join(dir, entries[0].Name())
+	}
+	return dir
+}
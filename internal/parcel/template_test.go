@@ -0,0 +1,104 @@
+// internal/parcel/template_test.go
+package parcel
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tig/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestInitializeFromTemplate(t *testing.T) {
+	tmpl := t.TempDir()
+	files := map[string]string{
+		"README.md":                 "# New service\n",
+		".tigignore":                "# build output\n*.log\n\ntarget/**\n",
+		".tig/config.json":          `{"intent_fields":[{"name":"risk","type":"enum","values":["low","high"]}]}`,
+		".tig/streams/release.json": `{"type":"release","config":{"auto_merge":false,"protection":{"required_reviewers":2}}}`,
+		".tig/hooks/pre-commit":     "#!/bin/sh\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(tmpl, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	root := t.TempDir()
+	require.NoError(t, InitializeFromTemplate(context.Background(), root, tmpl, zap.NewNop()))
+
+	readme, err := os.ReadFile(filepath.Join(root, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# New service\n", string(readme))
+	assert.FileExists(t, filepath.Join(root, ".tig", "hooks", "pre-commit"))
+	assert.NoFileExists(t, filepath.Join(root, ".tigignore"))
+
+	cfg, err := config.LoadRepo(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.log", "target/**"}, cfg.Watch.Exclude)
+	require.Len(t, cfg.IntentFields, 1)
+
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	s, err := p.ResolveStream("release")
+	require.NoError(t, err)
+	assert.Equal(t, "release", s.Type)
+	assert.False(t, s.Config.AutoMerge)
+	assert.Equal(t, 2, s.Config.Protection.RequiredReviewers)
+	require.NoError(t, p.Close())
+
+	// An existing repository is never re-seeded
+	assert.Error(t, InitializeFromTemplate(context.Background(), root, tmpl, zap.NewNop()))
+
+	// Files in the working tree are never overwritten
+	dirty := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dirty, "README.md"), []byte("mine"), 0644))
+	assert.ErrorContains(t, InitializeFromTemplate(context.Background(), dirty, tmpl, zap.NewNop()), "already exists")
+	assert.NoDirExists(t, filepath.Join(dirty, ".tig"))
+}
+
+func TestRemoteTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	add := func(name, content string) {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	add("template-main/Makefile", "all:\n")
+	add("template-main/.tig/streams/main.json", `{}`)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	archive := buf.Bytes()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer srv.Close()
+
+	root := t.TempDir()
+	require.NoError(t, InitializeFromTemplate(context.Background(), root, srv.URL+"/template.tar.gz", zap.NewNop()))
+	assert.FileExists(t, filepath.Join(root, "Makefile"))
+
+	p, err := New(root, zap.NewNop())
+	require.NoError(t, err)
+	defer p.Close()
+	s, err := p.ResolveStream("main")
+	require.NoError(t, err)
+	assert.Equal(t, "feature", s.Type)
+
+	assert.Error(t, InitializeFromTemplate(context.Background(), t.TempDir(), srv.URL+"/template.rar", zap.NewNop()))
+	_, err = archivePath(root, "../escape")
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+// internal/parcel/tests.go
+package parcel
+
+import (
+	"fmt"
+	"time"
+
+	tigerrors "tig/internal/errors"
+	"tig/internal/intent"
+)
+
+// RecordTests stores the summary of a test run against an intent as the
+// result of the named check, replacing any earlier result of it. The
+// check passes when no test failed.
+func (p *Parcel) RecordTests(id, check string, summary *intent.TestSummary, url string) (*intent.Intent, error) {
+	if summary == nil {
+		return nil, tigerrors.ValidationError("no test results to record", nil)
+	}
+	if check == "" {
+		check = intent.TestsCheck
+	}
+	i, err := p.ResolveIntent(id)
+	if err != nil {
+		return nil, err
+	}
+	i.SetCheck(intent.Check{
+		Name:      check,
+		Status:    summary.Status(),
+		URL:       url,
+		Tests:     summary,
+		UpdatedAt: time.Now(),
+	})
+	if err := p.IntentStore.Update(i); err != nil {
+		return nil, fmt.Errorf("updating intent: %w", err)
+	}
+	return i, nil
+}
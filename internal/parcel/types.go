@@ -2,9 +2,15 @@ package parcel
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"tig/internal/assign"
+	"tig/internal/config"
 	"tig/internal/content"
+	"tig/internal/errors"
+	"tig/internal/highlight"
+	"tig/internal/ignore"
 	"tig/internal/intent"
 	"tig/internal/stream"
 	"tig/shared/types"
@@ -13,6 +19,7 @@ import (
 	"tig/internal/safe"
 
 	"github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -26,7 +33,23 @@ type Parcel struct {
 	StreamStore  stream.Box
 	Safe         *safe.Safe
 	Tracker      change.Tracker
+	Highlighter  *highlight.Highlighter
+	Diff         config.Diff         // How diffs are displayed
+	GateRules    config.Gate
+	Copies       config.Copies
+	IntentFields config.IntentFields // Metadata fields intents carry
+	Workflow     config.Workflow     // Allowed intent state transitions
+	Projects     config.Projects     // Named path scopes of a monorepo
+	Routes       config.Routes       // Default streams of new intents
+	Describe     config.Describe     // Rules for intent descriptions
+	Tiers        config.Tiers        // Storage tier policy of the safe
+	Ignore       *ignore.Matcher     // Shared by the workspace and tracker
 	Logger       *zap.Logger
+
+	// DescriptionProcessors lint and suggest descriptions of new intents
+	DescriptionProcessors []intent.DescriptionProcessor
+
+	sealed bool // seal the repository's sentinel on Close
 }
 
 // ParcelConfig defines the configuration settings for a parcel
@@ -56,6 +79,32 @@ type Box interface {
 }
 
 func (p *Parcel) GetIntent(id string) (*intent.Intent, error) { return p.IntentStore.Get(id) }
+
+// ResolveIntent looks up an intent by full ID or unique ID prefix
+func (p *Parcel) ResolveIntent(idOrPrefix string) (*intent.Intent, error) {
+	if i, err := p.IntentStore.Get(idOrPrefix); err == nil {
+		return i, nil
+	}
+
+	intents, err := p.IntentStore.List()
+	if err != nil {
+		return nil, fmt.Errorf("listing intents: %w", err)
+	}
+
+	var found *intent.Intent
+	for _, i := range intents {
+		if strings.HasPrefix(i.ID, idOrPrefix) {
+			if found != nil {
+				return nil, fmt.Errorf("intent prefix %s is ambiguous", idOrPrefix)
+			}
+			found = i
+		}
+	}
+	if found == nil {
+		return nil, errors.NotFound(fmt.Sprintf("intent not found: %s", idOrPrefix))
+	}
+	return found, nil
+}
 func (p *Parcel) ListIntents() ([]*intent.Intent, error)      { return p.IntentStore.List() }
 func (p *Parcel) FindIntentsByType(t string) ([]*intent.Intent, error) {
 	return p.IntentStore.FindByType(t)
@@ -64,6 +113,7 @@ func (p *Parcel) FindIntentsByType(t string) ([]*intent.Intent, error) {
 // Stream operations
 func (p *Parcel) CreateStream(name, streamType string) (*stream.Stream, error) {
 	s := &stream.Stream{
+		ID:   uuid.New().String(),
 		Name: name,
 		Type: streamType,
 		Config: stream.Config{
@@ -92,6 +142,35 @@ func (p *Parcel) CreateStream(name, streamType string) (*stream.Stream, error) {
 }
 
 func (p *Parcel) GetStream(id string) (*stream.Stream, error)  { return p.StreamStore.Get(id) }
+
+// ResolveStream looks up a stream by full ID, unique ID prefix, or name
+func (p *Parcel) ResolveStream(ref string) (*stream.Stream, error) {
+	if s, err := p.StreamStore.Get(ref); err == nil {
+		return s, nil
+	}
+
+	streams, err := p.StreamStore.List()
+	if err != nil {
+		return nil, fmt.Errorf("listing streams: %w", err)
+	}
+
+	var found *stream.Stream
+	for _, s := range streams {
+		if s.Name == ref {
+			return s, nil
+		}
+		if strings.HasPrefix(s.ID, ref) {
+			if found != nil {
+				return nil, fmt.Errorf("stream prefix %s is ambiguous", ref)
+			}
+			found = s
+		}
+	}
+	if found == nil {
+		return nil, errors.NotFound(fmt.Sprintf("stream not found: %s", ref))
+	}
+	return found, nil
+}
 func (p *Parcel) ListStreams() ([]*stream.Stream, error)       { return p.StreamStore.List() }
 func (p *Parcel) FindActiveStreams() ([]*stream.Stream, error) { return p.StreamStore.FindActive() }
 func (p *Parcel) FindStreamsByType(t string) ([]*stream.Stream, error) {
@@ -99,8 +178,34 @@ func (p *Parcel) FindStreamsByType(t string) ([]*stream.Stream, error) {
 }
 
 // Stream management operations
+
+// AddIntentToStream adds an intent to a stream, assigning it reviewers
+// when the stream has a reviewer group
 func (p *Parcel) AddIntentToStream(streamID, intentID string) error {
-	return p.StreamStore.AddIntent(streamID, intentID)
+	if err := p.StreamStore.AddIntent(streamID, intentID); err != nil {
+		return err
+	}
+	st, err := p.StreamStore.Get(streamID)
+	if err != nil {
+		return err
+	}
+	return p.assignStreamReviewers(st, intentID)
+}
+
+// assignStreamReviewers asks the stream's reviewer group, if it has one,
+// to review an intent that joined it
+func (p *Parcel) assignStreamReviewers(st *stream.Stream, intentID string) error {
+	if st.Config.Protection.ReviewerGroup == "" {
+		return nil
+	}
+	i, err := p.IntentStore.Get(intentID)
+	if err != nil {
+		return err
+	}
+	if _, err := assign.ForStream(p.DB, st, i); err != nil {
+		return fmt.Errorf("assigning reviewers: %w", err)
+	}
+	return nil
 }
 func (p *Parcel) RemoveIntentFromStream(streamID, intentID string) error {
 	return p.StreamStore.RemoveIntent(streamID, intentID)
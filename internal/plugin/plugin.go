@@ -0,0 +1,312 @@
+// internal/plugin/plugin.go
+
+// Package plugin runs repository plugins: WebAssembly modules under
+// .tig/plugins that validate, format or analyze intents. Plugins run in a
+// sandbox with no filesystem, network, environment or real clock, so a
+// plugin sees the same inputs, and gives the same result, in the CLI and
+// on the server.
+//
+// A plugin is a WASI command (GOOS=wasip1, TinyGo, Rust wasm32-wasip1 and
+// so on) whose output is what it writes to stdout. It reads its inputs
+// through functions imported from the "tig" module:
+//
+//	intent(ptr, cap u32) u32    the intent, as JSON
+//	changeset(ptr, cap u32) u32 the intent's changeset, as JSON, or null
+//	diff(ptr, cap u32) u32      the intent's diff as patches, as JSON
+//	fail(ptr, len u32)          record a failure message
+//
+// The read functions copy at most cap bytes to ptr and return the full
+// length, so a plugin whose buffer was too small can grow it and call
+// again. A plugin fails if it records a failure or exits non-zero.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/intent"
+	"tig/internal/review"
+	"tig/internal/safe"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// Dir is where plugins live in a repository, relative to .tig
+const Dir = "plugins"
+
+// Defaults for a runtime without options
+const (
+	DefaultTimeout     = 10 * time.Second
+	DefaultMemoryPages = 1024 // 64 MiB
+)
+
+// maxOutput bounds how much a plugin may write to stdout and stderr
+const maxOutput = 1 << 20
+
+// Options limit what a plugin run may use
+type Options struct {
+	Timeout     time.Duration // per run, default DefaultTimeout
+	MemoryPages uint32        // 64 KiB pages of memory, default DefaultMemoryPages
+}
+
+// Input is what a plugin can read about the intent it runs on
+type Input struct {
+	Intent    *intent.Intent    `json:"intent"`
+	ChangeSet *change.ChangeSet `json:"changeset"`
+	Patches   []review.Patch    `json:"patches"`
+}
+
+// Result is the outcome of running a plugin on an intent
+type Result struct {
+	Plugin   string   `json:"plugin"`
+	IntentID string   `json:"intent_id"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures,omitempty"`
+	Output   string   `json:"output,omitempty"`
+}
+
+// Gather reads the input of a plugin run on i. The CLI and the server
+// both build inputs here so plugins see the same thing in either.
+func Gather(db *badger.DB, s *safe.Safe, i *intent.Intent) (*Input, error) {
+	in := &Input{Intent: i}
+	if i.ChangeSetID != "" {
+		err := db.View(func(txn *badger.Txn) error {
+			var err error
+			in.ChangeSet, err = change.GetChangeSet(txn, i.ChangeSetID)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading changeset %s: %w", i.ChangeSetID, err)
+		}
+	}
+	patches, err := review.Patches(db, s, i, true, review.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("diffing intent %s: %w", i.ID, err)
+	}
+	in.Patches = patches
+	return in, nil
+}
+
+// List returns the names of the plugins in a repository
+func List(root string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(root, ".tig", Dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".wasm"); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load reads a repository's plugin by name. A missing plugin is an
+// os.ErrNotExist error.
+func Load(root, name string) ([]byte, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name != filepath.Clean(name) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid plugin name %q", name)
+	}
+	wasm, err := os.ReadFile(filepath.Join(root, ".tig", Dir, name+".wasm"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("plugin %s not found: %w", name, os.ErrNotExist)
+	}
+	return wasm, err
+}
+
+// Runtime compiles and runs plugins. Compiled plugins are kept, so a
+// long-lived runtime, such as the server's, compiles each plugin once.
+type Runtime struct {
+	rt      wazero.Runtime
+	timeout time.Duration
+
+	mu       sync.Mutex
+	compiled map[[sha256.Size]byte]wazero.CompiledModule
+}
+
+// New creates a plugin runtime
+func New(ctx context.Context, opts Options) (*Runtime, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MemoryPages == 0 {
+		opts.MemoryPages = DefaultMemoryPages
+	}
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(opts.MemoryPages))
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("instantiating WASI: %w", err)
+	}
+	_, err := rt.NewHostModuleBuilder("tig").
+		NewFunctionBuilder().WithFunc(read(func(c *call) []byte { return c.intent })).Export("intent").
+		NewFunctionBuilder().WithFunc(read(func(c *call) []byte { return c.changeset })).Export("changeset").
+		NewFunctionBuilder().WithFunc(read(func(c *call) []byte { return c.diff })).Export("diff").
+		NewFunctionBuilder().WithFunc(fail).Export("fail").
+		Instantiate(ctx)
+	if err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("instantiating host functions: %w", err)
+	}
+
+	return &Runtime{rt: rt, timeout: opts.Timeout, compiled: make(map[[sha256.Size]byte]wazero.CompiledModule)}, nil
+}
+
+// Close releases the runtime and its compiled plugins
+func (r *Runtime) Close(ctx context.Context) error {
+	return r.rt.Close(ctx)
+}
+
+// Run runs a plugin on an input. Errors are for plugins that cannot be
+// run at all; a plugin that fails, traps or runs out of time gives a
+// Result that did not pass.
+func (r *Runtime) Run(ctx context.Context, name string, wasm []byte, in *Input) (*Result, error) {
+	compiled, err := r.compile(ctx, wasm)
+	if err != nil {
+		return nil, fmt.Errorf("compiling plugin %s: %w", name, err)
+	}
+	c, err := newCall(in)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	var stdout, stderr limitedBuffer
+	cfg := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(name).
+		WithStdout(&stdout).
+		WithStderr(&stderr)
+	mod, err := r.rt.InstantiateModule(context.WithValue(ctx, callKey{}, c), compiled, cfg)
+	if mod != nil {
+		mod.Close(ctx)
+	}
+
+	res := &Result{Plugin: name, Output: stdout.String(), Failures: c.failures}
+	if in.Intent != nil {
+		res.IntentID = in.Intent.ID
+	}
+	var exit *sys.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		res.Failures = append(res.Failures, fmt.Sprintf("timed out after %s", r.timeout))
+	case errors.As(err, &exit) && exit.ExitCode() == 0:
+	case errors.As(err, &exit):
+		res.Failures = append(res.Failures, exited(exit.ExitCode(), stderr.String()))
+	case err != nil:
+		res.Failures = append(res.Failures, err.Error())
+	}
+	res.Passed = len(res.Failures) == 0
+	return res, nil
+}
+
+func exited(code uint32, stderr string) string {
+	if stderr == "" {
+		return fmt.Sprintf("exited with status %d", code)
+	}
+	return fmt.Sprintf("exited with status %d: %s", code, stderr)
+}
+
+func (r *Runtime) compile(ctx context.Context, wasm []byte) (wazero.CompiledModule, error) {
+	sum := sha256.Sum256(wasm)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if compiled, ok := r.compiled[sum]; ok {
+		return compiled, nil
+	}
+	compiled, err := r.rt.CompileModule(ctx, wasm)
+	if err != nil {
+		return nil, err
+	}
+	r.compiled[sum] = compiled
+	return compiled, nil
+}
+
+// call is the state of one plugin run, reached by host functions
+// through the context
+type call struct {
+	intent    []byte
+	changeset []byte
+	diff      []byte
+	failures  []string
+}
+
+type callKey struct{}
+
+func newCall(in *Input) (*call, error) {
+	var c call
+	var err error
+	if c.intent, err = json.Marshal(in.Intent); err != nil {
+		return nil, err
+	}
+	if c.changeset, err = json.Marshal(in.ChangeSet); err != nil {
+		return nil, err
+	}
+	if c.diff, err = json.Marshal(in.Patches); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// read builds a host function copying one of a call's inputs to the
+// plugin's memory
+func read(data func(*call) []byte) func(context.Context, api.Module, uint32, uint32) uint32 {
+	return func(ctx context.Context, m api.Module, ptr, cap uint32) uint32 {
+		b := data(ctx.Value(callKey{}).(*call))
+		if n := uint32(len(b)); n <= cap {
+			m.Memory().Write(ptr, b)
+		} else {
+			m.Memory().Write(ptr, b[:cap])
+		}
+		return uint32(len(b))
+	}
+}
+
+func fail(ctx context.Context, m api.Module, ptr, n uint32) {
+	c := ctx.Value(callKey{}).(*call)
+	msg, ok := m.Memory().Read(ptr, n)
+	if !ok {
+		c.failures = append(c.failures, "failure message out of range")
+		return
+	}
+	c.failures = append(c.failures, string(msg))
+}
+
+// limitedBuffer keeps the first maxOutput bytes written to it
+type limitedBuffer struct{ buf bytes.Buffer }
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string { return strings.TrimSpace(b.buf.String()) }
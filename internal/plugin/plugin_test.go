@@ -0,0 +1,101 @@
+// internal/plugin/plugin_test.go
+package plugin
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tig/internal/intent"
+	"tig/internal/review"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// guest builds the test plugin in testdata/guest
+func guest(t *testing.T) []byte {
+	if testing.Short() {
+		t.Skip("building a WebAssembly plugin")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+	out := filepath.Join(t.TempDir(), "guest.wasm")
+	cmd := exec.Command(goTool, "build", "-o", out, ".")
+	cmd.Dir = filepath.Join("testdata", "guest")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+	wasm, err := os.ReadFile(out)
+	require.NoError(t, err)
+	return wasm
+}
+
+func TestRun(t *testing.T) {
+	wasm := guest(t)
+	ctx := context.Background()
+	r, err := New(ctx, Options{Timeout: 5 * time.Second})
+	require.NoError(t, err)
+	defer r.Close(ctx)
+
+	in := &Input{
+		Intent:  &intent.Intent{ID: "i1", Description: "Add retries", Type: "feature"},
+		Patches: []review.Patch{{Path: "main.go"}, {Path: "retry.go"}},
+	}
+	res, err := r.Run(ctx, "lint", wasm, in)
+	require.NoError(t, err)
+	assert.True(t, res.Passed, res.Failures)
+	assert.Equal(t, "i1", res.IntentID)
+	assert.Equal(t, "changed main.go\nchanged retry.go", res.Output)
+
+	in.Intent.Description = "add retries"
+	res, err = r.Run(ctx, "lint", wasm, in)
+	require.NoError(t, err)
+	assert.False(t, res.Passed)
+	assert.Equal(t, []string{"description must start with a capital letter"}, res.Failures)
+
+	in.Intent.Type = "exit"
+	res, err = r.Run(ctx, "lint", wasm, in)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"exited with status 3: giving up"}, res.Failures)
+}
+
+func TestRunTimeout(t *testing.T) {
+	wasm := guest(t)
+	ctx := context.Background()
+	r, err := New(ctx, Options{Timeout: 200 * time.Millisecond})
+	require.NoError(t, err)
+	defer r.Close(ctx)
+
+	res, err := r.Run(ctx, "lint", wasm, &Input{Intent: &intent.Intent{Description: "Spin", Type: "loop"}})
+	require.NoError(t, err)
+	assert.False(t, res.Passed)
+	assert.Equal(t, []string{"timed out after 200ms"}, res.Failures)
+}
+
+func TestListAndLoad(t *testing.T) {
+	root := t.TempDir()
+	names, err := List(root)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	dir := filepath.Join(root, ".tig", Dir)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lint.wasm"), []byte("\x00asm"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), nil, 0644))
+	names, err = List(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lint"}, names)
+
+	_, err = Load(root, "lint")
+	assert.NoError(t, err)
+	_, err = Load(root, "format")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = Load(root, "../secrets")
+	assert.Error(t, err)
+}
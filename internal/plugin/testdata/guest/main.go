@@ -0,0 +1,62 @@
+// A test plugin: checks intent descriptions and counts changed files
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"unicode"
+	"unsafe"
+)
+
+//go:wasmimport tig intent
+func hostIntent(ptr, cap uint32) uint32
+
+//go:wasmimport tig diff
+func hostDiff(ptr, cap uint32) uint32
+
+//go:wasmimport tig fail
+func hostFail(ptr, n uint32)
+
+func read(f func(ptr, cap uint32) uint32) []byte {
+	buf := make([]byte, 16)
+	for {
+		n := f(uint32(uintptr(unsafe.Pointer(&buf[0]))), uint32(len(buf)))
+		if int(n) <= len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, n)
+	}
+}
+
+func fail(msg string) {
+	b := []byte(msg)
+	hostFail(uint32(uintptr(unsafe.Pointer(&b[0]))), uint32(len(b)))
+}
+
+func main() {
+	var i struct {
+		Description string `json:"description"`
+		Type        string `json:"type"`
+	}
+	json.Unmarshal(read(hostIntent), &i)
+	switch i.Type {
+	case "loop":
+		for {
+		}
+	case "exit":
+		fmt.Fprintln(os.Stderr, "giving up")
+		os.Exit(3)
+	}
+
+	if i.Description == "" || !unicode.IsUpper([]rune(i.Description)[0]) {
+		fail("description must start with a capital letter")
+	}
+	var patches []struct {
+		Path string `json:"path"`
+	}
+	json.Unmarshal(read(hostDiff), &patches)
+	for _, p := range patches {
+		fmt.Println("changed", p.Path)
+	}
+}
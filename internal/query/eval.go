@@ -0,0 +1,187 @@
+// internal/query/eval.go
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tig/internal/glob"
+)
+
+// record exposes an entity's queryable fields. Field values are one of
+// string, []string, time.Time, bool or int.
+type record struct {
+	fields map[string]any
+	item   any
+}
+
+// Match evaluates an expression against a record
+func match(e Expr, r record, now time.Time) (bool, error) {
+	switch e := e.(type) {
+	case nil:
+		return true, nil
+	case *And:
+		ok, err := match(e.Left, r, now)
+		if err != nil || !ok {
+			return false, err
+		}
+		return match(e.Right, r, now)
+	case *Or:
+		ok, err := match(e.Left, r, now)
+		if err != nil || ok {
+			return ok, err
+		}
+		return match(e.Right, r, now)
+	case *Not:
+		ok, err := match(e.Expr, r, now)
+		return !ok, err
+	case *Compare:
+		return compare(e, r.fields[e.Field], now)
+	default:
+		return false, fmt.Errorf("unsupported expression %T", e)
+	}
+}
+
+func compare(c *Compare, value any, now time.Time) (bool, error) {
+	switch v := value.(type) {
+	case string:
+		return compareString(v, c.Op, c.Value.Text)
+
+	case []string:
+		if c.Op == "!=" {
+			for _, s := range v {
+				if s == c.Value.Text {
+					return false, nil
+				}
+			}
+			return true, nil
+		}
+		for _, s := range v {
+			ok, err := compareString(s, c.Op, c.Value.Text)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case time.Time:
+		t, err := parseTime(c.Value, now)
+		if err != nil {
+			return false, fmt.Errorf("field %s: %w", c.Field, err)
+		}
+		return compareOrdered(v.Compare(t), c.Op)
+
+	case bool:
+		b, err := strconv.ParseBool(c.Value.Text)
+		if err != nil {
+			return false, fmt.Errorf("field %s expects true or false, got %q", c.Field, c.Value.Text)
+		}
+		switch c.Op {
+		case "=":
+			return v == b, nil
+		case "!=":
+			return v != b, nil
+		}
+		return false, fmt.Errorf("operator %s not supported for %s", c.Op, c.Field)
+
+	case int:
+		n, err := strconv.Atoi(c.Value.Text)
+		if err != nil {
+			return false, fmt.Errorf("field %s expects a number, got %q", c.Field, c.Value.Text)
+		}
+		return compareOrdered(v-n, c.Op)
+
+	case nil:
+		return c.Op == "!=", nil
+
+	default:
+		return false, fmt.Errorf("field %s cannot be compared", c.Field)
+	}
+}
+
+func compareString(v, op, lit string) (bool, error) {
+	switch op {
+	case "~":
+		return glob.Match(lit, v), nil
+	case "=":
+		return v == lit, nil
+	case "!=":
+		return v != lit, nil
+	default:
+		return compareOrdered(strings.Compare(v, lit), op)
+	}
+}
+
+func compareOrdered(cmp int, op string) (bool, error) {
+	switch op {
+	case "=":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return false, fmt.Errorf("operator %s not supported here", op)
+}
+
+// parseTime interprets a literal as an absolute time. Durations such as
+// -7d are relative to now.
+func parseTime(lit Literal, now time.Time) (time.Time, error) {
+	if lit.Kind == tokDuration {
+		d, err := ParseDuration(lit.Text)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, lit.Text, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use a date like 2024-01-31 or a duration like -7d)", lit.Text)
+}
+
+// ParseDuration parses durations with an optional sign and one of the
+// units s, m, h, d (days) or w (weeks), e.g. "-7d" or "12h".
+func ParseDuration(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	unit := s[len(s)-1]
+	n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	var base time.Duration
+	switch unit {
+	case 's':
+		base = time.Second
+	case 'm':
+		base = time.Minute
+	case 'h':
+		base = time.Hour
+	case 'd':
+		base = 24 * time.Hour
+	case 'w':
+		base = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("invalid duration unit in %q", s)
+	}
+
+	return time.Duration(n * float64(base)), nil
+}
@@ -0,0 +1,448 @@
+// internal/query/exec.go
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/glob"
+	"tig/internal/intent"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Key prefixes of the entities and indexes the executor reads
+const (
+	intentPrefix    = "intent:"
+	streamPrefix    = "stream:"
+	changeSetPrefix = "changeset:"
+	csTimePrefix    = "cs_time:"
+	csPathPrefix    = "cs_path:"
+)
+
+// Result holds the entities matched by a query
+type Result struct {
+	Entity  string     `json:"entity"`
+	Plan    string     `json:"plan"`
+	Items   []any      `json:"items"`
+	Columns []string   `json:"-"`
+	Rows    [][]string `json:"-"`
+}
+
+// Executor runs parsed queries against a repository database
+type Executor struct {
+	db  *badger.DB
+	now func() time.Time
+}
+
+// NewExecutor creates an executor for the given database
+func NewExecutor(db *badger.DB) *Executor {
+	return &Executor{db: db, now: time.Now}
+}
+
+// Run parses and executes a query string
+func (x *Executor) Run(input string) (*Result, error) {
+	q, err := Parse(input)
+	if err != nil {
+		return nil, fmt.Errorf("parsing query: %w", err)
+	}
+	return x.Execute(q)
+}
+
+// Execute runs a parsed query. Predicates on created time and paths that
+// appear at the top level of the where clause are answered from the
+// changeset indexes instead of scanning every record.
+func (x *Executor) Execute(q *Query) (*Result, error) {
+	now := x.now()
+	res := &Result{Entity: q.Entity}
+	var records []record
+
+	err := x.db.View(func(txn *badger.Txn) error {
+		var err error
+		switch q.Entity {
+		case ChangeSets:
+			records, res.Plan, err = x.scanChangeSets(txn, q, now)
+		case Intents:
+			records, res.Plan, err = x.scanIntents(txn, q)
+		case Streams:
+			records, err = scanPrefix(txn, streamPrefix, streamRecord)
+			res.Plan = "scan " + streamPrefix
+		default:
+			err = fmt.Errorf("unknown entity %q", q.Entity)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []record
+	for _, r := range records {
+		ok, err := match(q.Where, r, now)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, r)
+		}
+	}
+
+	orderBy := q.OrderBy
+	if orderBy == "" {
+		orderBy = "created"
+	}
+	sortRecords(matched, orderBy, q.Desc)
+
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+
+	res.Columns, res.Rows = table(q.Entity, matched)
+	res.Items = make([]any, len(matched))
+	for i, r := range matched {
+		res.Items[i] = r.item
+	}
+	return res, nil
+}
+
+// conjuncts flattens the top-level AND chain of an expression
+func conjuncts(e Expr) []Expr {
+	if a, ok := e.(*And); ok {
+		return append(conjuncts(a.Left), conjuncts(a.Right)...)
+	}
+	if e == nil {
+		return nil
+	}
+	return []Expr{e}
+}
+
+// pathIndexIDs returns changeset IDs whose path index entries satisfy a
+// top-level path predicate. ok is false when no such predicate exists.
+func pathIndexIDs(txn *badger.Txn, q *Query) (ids map[string]bool, plan string, ok bool) {
+	for _, e := range conjuncts(q.Where) {
+		c, isCmp := e.(*Compare)
+		if !isCmp || c.Field != "path" || (c.Op != "~" && c.Op != "=") {
+			continue
+		}
+
+		prefix := c.Value.Text
+		if c.Op == "~" {
+			prefix = glob.Prefix(c.Value.Text)
+		}
+
+		ids = make(map[string]bool)
+		seek := []byte(csPathPrefix + prefix)
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: false, Prefix: seek})
+		for it.Rewind(); it.Valid(); it.Next() {
+			rest := strings.TrimPrefix(string(it.Item().Key()), csPathPrefix)
+			sep := strings.LastIndex(rest, ":")
+			if sep < 0 {
+				continue
+			}
+			path, id := rest[:sep], rest[sep+1:]
+			if c.Op == "=" && path == c.Value.Text || c.Op == "~" && glob.Match(c.Value.Text, path) {
+				ids[id] = true
+			}
+		}
+		it.Close()
+		return ids, fmt.Sprintf("index %s%s", csPathPrefix, prefix), true
+	}
+	return nil, "", false
+}
+
+// timeIndexIDs returns changeset IDs created at or after a top-level
+// lower bound on the created field
+func timeIndexIDs(txn *badger.Txn, q *Query, now time.Time) (ids map[string]bool, plan string, ok bool) {
+	for _, e := range conjuncts(q.Where) {
+		c, isCmp := e.(*Compare)
+		if !isCmp || c.Field != "created" || (c.Op != ">" && c.Op != ">=") {
+			continue
+		}
+		since, err := parseTime(c.Value, now)
+		if err != nil {
+			return nil, "", false
+		}
+
+		ids = make(map[string]bool)
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: false, Prefix: []byte(csTimePrefix)})
+		seek := []byte(fmt.Sprintf("%s%d", csTimePrefix, since.Unix()))
+		for it.Seek(seek); it.Valid(); it.Next() {
+			parts := strings.SplitN(strings.TrimPrefix(string(it.Item().Key()), csTimePrefix), ":", 2)
+			if len(parts) == 2 {
+				ids[parts[1]] = true
+			}
+		}
+		it.Close()
+		return ids, fmt.Sprintf("index %s>=%d", csTimePrefix, since.Unix()), true
+	}
+	return nil, "", false
+}
+
+func intersect(a, b map[string]bool) map[string]bool {
+	out := make(map[string]bool)
+	for k := range a {
+		if b[k] {
+			out[k] = true
+		}
+	}
+	return out
+}
+
+func (x *Executor) scanChangeSets(txn *badger.Txn, q *Query, now time.Time) ([]record, string, error) {
+	pathIDs, pathPlan, byPath := pathIndexIDs(txn, q)
+	timeIDs, timePlan, byTime := timeIndexIDs(txn, q, now)
+
+	var ids map[string]bool
+	var plans []string
+	switch {
+	case byPath && byTime:
+		ids = intersect(pathIDs, timeIDs)
+		plans = []string{pathPlan, timePlan}
+	case byPath:
+		ids, plans = pathIDs, []string{pathPlan}
+	case byTime:
+		ids, plans = timeIDs, []string{timePlan}
+	default:
+		records, err := scanPrefix(txn, changeSetPrefix, changeSetRecord)
+		return records, "scan " + changeSetPrefix, err
+	}
+
+	var records []record
+	for id := range ids {
+		cs, err := getChangeSet(txn, id)
+		if err != nil {
+			return nil, "", err
+		}
+		if cs != nil {
+			records = append(records, changeSetRecord(cs))
+		}
+	}
+	return records, strings.Join(plans, " ∩ "), nil
+}
+
+func (x *Executor) scanIntents(txn *badger.Txn, q *Query) ([]record, string, error) {
+	csIDs, plan, byPath := pathIndexIDs(txn, q)
+	if !byPath {
+		plan = "scan " + intentPrefix
+	} else {
+		plan = "scan " + intentPrefix + " filtered by " + plan
+	}
+
+	var records []record
+	err := iterate(txn, intentPrefix, func(val []byte) error {
+		var i intent.Intent
+		if err := json.Unmarshal(val, &i); err != nil {
+			return fmt.Errorf("decoding intent: %w", err)
+		}
+		if byPath && !csIDs[i.ChangeSetID] {
+			return nil
+		}
+
+		var paths []string
+		if i.ChangeSetID != "" {
+			cs, err := getChangeSet(txn, i.ChangeSetID)
+			if err != nil {
+				return err
+			}
+			if cs != nil {
+				paths = changeSetPaths(cs)
+			}
+		}
+		records = append(records, intentRecord(&i, paths))
+		return nil
+	})
+	return records, plan, err
+}
+
+func iterate(txn *badger.Txn, prefix string, fn func(val []byte) error) error {
+	it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, PrefetchSize: 100, Prefix: []byte(prefix)})
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		if err := it.Item().Value(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanPrefix[T any](txn *badger.Txn, prefix string, toRecord func(*T) record) ([]record, error) {
+	var records []record
+	err := iterate(txn, prefix, func(val []byte) error {
+		v := new(T)
+		if err := json.Unmarshal(val, v); err != nil {
+			return fmt.Errorf("decoding %s entry: %w", strings.TrimSuffix(prefix, ":"), err)
+		}
+		records = append(records, toRecord(v))
+		return nil
+	})
+	return records, err
+}
+
+func getChangeSet(txn *badger.Txn, id string) (*change.ChangeSet, error) {
+	item, err := txn.Get([]byte(changeSetPrefix + id))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cs change.ChangeSet
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &cs)
+	}); err != nil {
+		return nil, fmt.Errorf("decoding changeset %s: %w", id, err)
+	}
+	return &cs, nil
+}
+
+func changeSetPaths(cs *change.ChangeSet) []string {
+	paths := make([]string, 0, len(cs.Changes))
+	for _, c := range cs.Changes {
+		paths = append(paths, c.Path)
+	}
+	return paths
+}
+
+func intentRecord(i *intent.Intent, paths []string) record {
+	r := record{
+		item: i,
+		fields: map[string]any{
+			"id":           i.ID,
+			"type":         i.Type,
+			"description":  i.Description,
+			"author":       i.Metadata.Author,
+			"breaking":     i.Impact.Breaking,
+			"created":      i.CreatedAt,
+			"updated":      i.UpdatedAt,
+			"changeset":    i.ChangeSetID,
+			"scope":        i.Impact.Scope,
+			"refs":         i.Metadata.Refs,
+			"dependencies": i.Impact.Dependencies,
+			"path":         paths,
+			"state":        i.CurrentState(),
+		},
+	}
+	for name, v := range i.Extensions {
+		r.fields[extensionPrefix+name] = extensionValue(v)
+	}
+	return r
+}
+
+// extensionValue converts an extension field decoded from JSON to a type
+// compare understands
+func extensionValue(v any) any {
+	switch v := v.(type) {
+	case string, bool, int:
+		return v
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func streamRecord(s *stream.Stream) record {
+	return record{
+		item: s,
+		fields: map[string]any{
+			"id":      s.ID,
+			"name":    s.Name,
+			"type":    s.Type,
+			"status":  s.State.Status,
+			"active":  s.State.Active,
+			"created": s.CreatedAt,
+			"updated": s.UpdatedAt,
+			"intent":  s.State.Intents,
+			"intents": len(s.State.Intents),
+		},
+	}
+}
+
+func changeSetRecord(cs *change.ChangeSet) record {
+	return record{
+		item: cs,
+		fields: map[string]any{
+			"id":          cs.ID,
+			"intent":      cs.IntentID,
+			"parent":      cs.ParentID,
+			"description": cs.Description,
+			"author":      cs.Author,
+			"created":     cs.CreatedAt,
+			"path":        changeSetPaths(cs),
+			"tag":         cs.Tags,
+			"changes":     len(cs.Changes),
+		},
+	}
+}
+
+func sortRecords(records []record, field string, desc bool) {
+	less := func(a, b any) bool {
+		switch av := a.(type) {
+		case time.Time:
+			bv, _ := b.(time.Time)
+			return av.Before(bv)
+		case string:
+			bv, _ := b.(string)
+			return av < bv
+		case int:
+			bv, _ := b.(int)
+			return av < bv
+		case bool:
+			bv, _ := b.(bool)
+			return !av && bv
+		}
+		return false
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i].fields[field], records[j].fields[field]
+		if desc {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// table renders matched records as rows for human-readable output
+func table(entity string, records []record) ([]string, [][]string) {
+	var columns []string
+	rows := make([][]string, 0, len(records))
+
+	switch entity {
+	case Intents:
+		columns = []string{"ID", "CREATED", "TYPE", "DESCRIPTION"}
+		for _, r := range records {
+			i := r.item.(*intent.Intent)
+			rows = append(rows, []string{shortID(i.ID), i.CreatedAt.Format(time.RFC3339), i.Type, i.Description})
+		}
+	case Streams:
+		columns = []string{"ID", "NAME", "TYPE", "STATUS", "INTENTS"}
+		for _, r := range records {
+			s := r.item.(*stream.Stream)
+			rows = append(rows, []string{shortID(s.ID), s.Name, s.Type, s.State.Status, strconv.Itoa(len(s.State.Intents))})
+		}
+	case ChangeSets:
+		columns = []string{"ID", "CREATED", "CHANGES", "DESCRIPTION"}
+		for _, r := range records {
+			cs := r.item.(*change.ChangeSet)
+			rows = append(rows, []string{shortID(cs.ID), cs.CreatedAt.Format(time.RFC3339), strconv.Itoa(len(cs.Changes)), cs.Description})
+		}
+	}
+
+	return columns, rows
+}
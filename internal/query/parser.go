@@ -0,0 +1,270 @@
+// internal/query/parser.go
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Entity kinds that can be queried
+const (
+	Intents    = "intents"
+	Streams    = "streams"
+	ChangeSets = "changesets"
+)
+
+// Query is a parsed query expression
+type Query struct {
+	Entity  string
+	Where   Expr
+	OrderBy string
+	Desc    bool
+	Limit   int
+}
+
+// Expr is a boolean filter expression
+type Expr interface {
+	String() string
+}
+
+// And matches when both sides match
+type And struct{ Left, Right Expr }
+
+// Or matches when either side matches
+type Or struct{ Left, Right Expr }
+
+// Not negates an expression
+type Not struct{ Expr Expr }
+
+// Compare tests a single field against a literal value
+type Compare struct {
+	Field string
+	Op    string
+	Value Literal
+}
+
+// Literal is a constant value in a query
+type Literal struct {
+	Kind tokenKind
+	Text string
+}
+
+func (e *And) String() string     { return fmt.Sprintf("(%s and %s)", e.Left, e.Right) }
+func (e *Or) String() string      { return fmt.Sprintf("(%s or %s)", e.Left, e.Right) }
+func (e *Not) String() string     { return fmt.Sprintf("not %s", e.Expr) }
+func (e *Compare) String() string { return fmt.Sprintf("%s %s %q", e.Field, e.Op, e.Value.Text) }
+
+// fields lists the queryable fields per entity
+var fields = map[string]map[string]bool{
+	Intents: {
+		"id": true, "type": true, "description": true, "author": true,
+		"breaking": true, "created": true, "updated": true, "changeset": true,
+		"scope": true, "refs": true, "dependencies": true, "path": true,
+		"state": true,
+	},
+	Streams: {
+		"id": true, "name": true, "type": true, "status": true, "active": true,
+		"created": true, "updated": true, "intent": true, "intents": true,
+	},
+	ChangeSets: {
+		"id": true, "intent": true, "parent": true, "description": true,
+		"author": true, "created": true, "path": true, "tag": true, "changes": true,
+	},
+}
+
+// extensionPrefix introduces an intent's repository-defined metadata
+// fields, e.g. meta.risk
+const extensionPrefix = "meta."
+
+// knownField reports whether entity has a field called name
+func knownField(entity, name string) bool {
+	if entity == Intents && strings.HasPrefix(name, extensionPrefix) && len(name) > len(extensionPrefix) {
+		return true
+	}
+	return fields[entity][name]
+}
+
+// entityAliases maps accepted spellings to canonical entity names
+var entityAliases = map[string]string{
+	"intent": Intents, "intents": Intents,
+	"stream": Streams, "streams": Streams,
+	"changeset": ChangeSets, "changesets": ChangeSets,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	entity string
+}
+
+// Parse parses a query such as
+//
+//	intents where type="fix" and created > -7d and path ~ "internal/safe/**"
+func Parse(input string) (*Query, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	q := &Query{}
+
+	first := p.next()
+	if first.kind != tokIdent {
+		return nil, fmt.Errorf("expected entity (intents, streams, changesets), got %s", first)
+	}
+	entity, ok := entityAliases[strings.ToLower(first.text)]
+	if !ok {
+		return nil, fmt.Errorf("unknown entity %q", first.text)
+	}
+	q.Entity = entity
+	p.entity = entity
+
+	if p.keyword("where") {
+		q.Where, err = p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.keyword("order") {
+		if !p.keyword("by") {
+			return nil, fmt.Errorf("expected 'by' after 'order', got %s", p.peek())
+		}
+		field := p.next()
+		if field.kind != tokIdent || !knownField(entity, strings.ToLower(field.text)) {
+			return nil, fmt.Errorf("cannot order %s by %s", entity, field)
+		}
+		q.OrderBy = strings.ToLower(field.text)
+		if p.keyword("desc") {
+			q.Desc = true
+		} else {
+			p.keyword("asc")
+		}
+	}
+
+	if p.keyword("limit") {
+		n := p.next()
+		if n.kind != tokNumber {
+			return nil, fmt.Errorf("expected number after 'limit', got %s", n)
+		}
+		q.Limit, err = strconv.Atoi(n.text)
+		if err != nil || q.Limit < 0 {
+			return nil, fmt.Errorf("invalid limit %s", n)
+		}
+	}
+
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos)
+	}
+
+	return q, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the given keyword
+func (p *parser) keyword(kw string) bool {
+	t := p.peek()
+	if t.kind == tokIdent && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.keyword("not") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: e}, nil
+	}
+
+	if p.peek().kind == tokLParen {
+		p.next()
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, fmt.Errorf("expected ')', got %s", t)
+		}
+		return e, nil
+	}
+
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (Expr, error) {
+	field := p.next()
+	if field.kind != tokIdent {
+		return nil, fmt.Errorf("expected field name, got %s", field)
+	}
+	name := strings.ToLower(field.text)
+	if !knownField(p.entity, name) {
+		return nil, fmt.Errorf("unknown field %q for %s", field.text, p.entity)
+	}
+
+	op := p.next()
+	if op.kind != tokOp {
+		return nil, fmt.Errorf("expected operator after %s, got %s", field.text, op)
+	}
+
+	val := p.next()
+	switch val.kind {
+	case tokString, tokNumber, tokDuration:
+	case tokIdent:
+		// Allow bare words such as true/false or unquoted identifiers
+	default:
+		return nil, fmt.Errorf("expected value after %s %s, got %s", field.text, op.text, val)
+	}
+
+	return &Compare{
+		Field: name,
+		Op:    op.text,
+		Value: Literal{Kind: val.kind, Text: val.text},
+	}, nil
+}
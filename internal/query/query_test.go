@@ -0,0 +1,174 @@
+// internal/query/query_test.go
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/intent"
+	"tig/internal/stream"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	q, err := Parse(`intents where type="fix" and created > -7d and path ~ "internal/safe/**" order by created desc limit 5`)
+	require.NoError(t, err)
+	assert.Equal(t, Intents, q.Entity)
+	assert.Equal(t, "created", q.OrderBy)
+	assert.True(t, q.Desc)
+	assert.Equal(t, 5, q.Limit)
+	assert.Len(t, conjuncts(q.Where), 3)
+
+	q, err = Parse(`streams where not (active = false or status = "conflict")`)
+	require.NoError(t, err)
+	assert.IsType(t, &Not{}, q.Where)
+
+	for _, bad := range []string{
+		``,
+		`commits`,
+		`intents where`,
+		`intents where color = "red"`,
+		`intents where meta. = "x"`,
+		`streams where meta.risk = "high"`,
+		`intents where type "fix"`,
+		`intents where (type = "fix"`,
+		`intents where type = "fix" limit x`,
+		`intents where description = "unterminated`,
+	} {
+		_, err := Parse(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	d, err := ParseDuration("-7d")
+	require.NoError(t, err)
+	assert.Equal(t, -7*24*time.Hour, d)
+
+	d, err = ParseDuration("2w")
+	require.NoError(t, err)
+	assert.Equal(t, 14*24*time.Hour, d)
+
+	_, err = ParseDuration("7y")
+	assert.Error(t, err)
+}
+
+func setupDB(t *testing.T) *badger.DB {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func put(t *testing.T, db *badger.DB, key string, v any) {
+	var data []byte
+	if v != nil {
+		var err error
+		data, err = json.Marshal(v)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(key), data)
+	}))
+}
+
+func putChangeSet(t *testing.T, db *badger.DB, cs *change.ChangeSet) {
+	put(t, db, changeSetPrefix+cs.ID, cs)
+	put(t, db, fmt.Sprintf("%s%d:%s", csTimePrefix, cs.CreatedAt.Unix(), cs.ID), nil)
+	for _, c := range cs.Changes {
+		put(t, db, fmt.Sprintf("%s%s:%s", csPathPrefix, c.Path, cs.ID), nil)
+	}
+}
+
+func TestExecutor(t *testing.T) {
+	db := setupDB(t)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	putChangeSet(t, db, &change.ChangeSet{
+		ID: "cs-old", CreatedAt: now.Add(-30 * 24 * time.Hour), Description: "old fix",
+		Changes: []shared.Change{{Path: "internal/safe/safe.go"}},
+	})
+	putChangeSet(t, db, &change.ChangeSet{
+		ID: "cs-new", CreatedAt: now.Add(-2 * 24 * time.Hour), Description: "new fix",
+		Changes: []shared.Change{{Path: "internal/safe/compression.go"}, {Path: "README.md"}},
+	})
+	putChangeSet(t, db, &change.ChangeSet{
+		ID: "cs-docs", CreatedAt: now.Add(-1 * time.Hour), Description: "docs",
+		Changes: []shared.Change{{Path: "README.md"}},
+	})
+
+	put(t, db, intentPrefix+"i1", &intent.Intent{ID: "i1", Type: "fix", Description: "old fix", ChangeSetID: "cs-old", CreatedAt: now.Add(-30 * 24 * time.Hour),
+		Extensions: map[string]any{"risk": "high", "rollout_pct": 50}})
+	put(t, db, intentPrefix+"i2", &intent.Intent{ID: "i2", Type: "fix", Description: "new fix", ChangeSetID: "cs-new", CreatedAt: now.Add(-2 * 24 * time.Hour),
+		Extensions: map[string]any{"risk": "low"}})
+	put(t, db, intentPrefix+"i3", &intent.Intent{ID: "i3", Type: "feature", Description: "docs", ChangeSetID: "cs-docs", CreatedAt: now.Add(-time.Hour), Impact: intent.Impact{Breaking: true}})
+
+	put(t, db, streamPrefix+"s1", &stream.Stream{ID: "s1", Name: "main", Type: "release", State: stream.State{Active: true, Status: "stable", Intents: []string{"i1", "i2"}}})
+	put(t, db, streamPrefix+"s2", &stream.Stream{ID: "s2", Name: "exp", Type: "feature", State: stream.State{Active: false, Status: "conflict"}})
+
+	x := NewExecutor(db)
+	x.now = func() time.Time { return now }
+
+	ids := func(res *Result) []string {
+		var out []string
+		for _, item := range res.Items {
+			switch v := item.(type) {
+			case *intent.Intent:
+				out = append(out, v.ID)
+			case *stream.Stream:
+				out = append(out, v.ID)
+			case *change.ChangeSet:
+				out = append(out, v.ID)
+			}
+		}
+		return out
+	}
+
+	res, err := x.Run(`intents where type="fix" and created > -7d and path ~ "internal/safe/**"`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i2"}, ids(res))
+	assert.Contains(t, res.Plan, "index cs_path:internal/safe/")
+
+	res, err = x.Run(`changesets where created > -7d order by created desc`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cs-docs", "cs-new"}, ids(res))
+	assert.Contains(t, res.Plan, "index cs_time:")
+
+	res, err = x.Run(`changesets where path = "README.md" and changes > 1`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cs-new"}, ids(res))
+
+	res, err = x.Run(`intents where breaking = true or description ~ "old*"`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i1", "i3"}, ids(res))
+
+	res, err = x.Run(`streams where intent = "i2" and active = true`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1"}, ids(res))
+	require.Len(t, res.Rows, 1)
+	assert.Equal(t, "main", res.Rows[0][1])
+
+	res, err = x.Run(`intents order by created desc limit 1`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i3"}, ids(res))
+
+	res, err = x.Run(`intents where meta.risk = "high" or meta.rollout_pct >= 25`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i1"}, ids(res))
+
+	res, err = x.Run(`intents where meta.risk ~ "*" order by meta.risk desc`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i2", "i1"}, ids(res))
+
+	_, err = x.Run(`intents where created > "yesterday"`)
+	assert.Error(t, err)
+}
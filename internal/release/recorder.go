@@ -0,0 +1,53 @@
+// internal/release/recorder.go
+package release
+
+import (
+	"fmt"
+
+	"tig/internal/events"
+	"tig/internal/safe"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.uber.org/zap"
+)
+
+// Recorder tags a release each time an intent lands on a release stream
+type Recorder struct {
+	db      *badger.DB
+	safe    *safe.Safe
+	streams stream.Box
+	logger  *zap.Logger
+}
+
+// NewRecorder creates a recorder writing manifests into the safe
+func NewRecorder(db *badger.DB, s *safe.Safe, streams stream.Box, logger *zap.Logger) *Recorder {
+	return &Recorder{db: db, safe: s, streams: streams, logger: logger}
+}
+
+// Subscribe records a release after every landing
+func (r *Recorder) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.StreamMerged, r.Handle)
+}
+
+// Handle tags the stream's new head as "<stream>/<n>", n being the number
+// of intents landed so far, when the stream is a release stream
+func (r *Recorder) Handle(e events.Event) {
+	st, err := r.streams.Get(e.StreamID)
+	if err != nil {
+		r.logger.Warn("recording release failed", zap.String("stream", e.StreamID), zap.Error(err))
+		return
+	}
+	if st.Type != "release" || st.State.Head == "" {
+		return
+	}
+	tag := AutoTag(st)
+	if _, err := Create(r.db, r.safe, tag, st, st.State.Head); err != nil {
+		r.logger.Warn("recording release failed", zap.String("tag", tag), zap.Error(err))
+	}
+}
+
+// AutoTag is the tag a release stream's head is recorded under
+func AutoTag(st *stream.Stream) string {
+	return fmt.Sprintf("%s/%d", st.Name, len(st.State.Merged))
+}
@@ -0,0 +1,216 @@
+// internal/release/release.go
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/errors"
+	"tig/internal/safe"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// tagPrefix keys release tags, "release:<tag>". Each tag names the safe
+// object holding its manifest.
+const tagPrefix = "release:"
+
+// Manifest is the full listing of a stream's tree when it was released.
+// It is stored in the safe, so it is addressed by its hash and never
+// changes once written.
+type Manifest struct {
+	Tag         string    `json:"tag"`
+	StreamID    string    `json:"stream_id"`
+	Stream      string    `json:"stream"`
+	ChangeSetID string    `json:"changeset_id"`
+	Files       []File    `json:"files"`   // Sorted by path
+	Intents     []string  `json:"intents"` // Landed on the stream, in order
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// File is one released file
+type File struct {
+	Path string `json:"path"` // Slash-separated, relative to the repo root
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+	Mode int    `json:"mode,omitempty"`
+}
+
+// Tag points a release name at its manifest
+type Tag struct {
+	Name      string    `json:"name"`
+	Manifest  string    `json:"manifest"` // Safe hash of the manifest
+	StreamID  string    `json:"stream_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Create records the tree of a stream as of its head changeset under a
+// new tag. Tags cannot be moved or reused.
+func Create(db *badger.DB, s *safe.Safe, tag string, st *stream.Stream, head string) (*Manifest, error) {
+	if err := validTag(tag); err != nil {
+		return nil, err
+	}
+	if _, err := GetTag(db, tag); err == nil {
+		return nil, fmt.Errorf("%w: release %s already exists", errors.ErrConflict, tag)
+	}
+
+	m := &Manifest{
+		Tag:         tag,
+		StreamID:    st.ID,
+		Stream:      st.Name,
+		ChangeSetID: head,
+		Intents:     st.State.Merged,
+		CreatedAt:   time.Now().UTC(),
+	}
+	err := db.View(func(txn *badger.Txn) error {
+		files, err := change.TreeAt(txn, head)
+		if err != nil {
+			return err
+		}
+		for path, state := range files {
+			m.Files = append(m.Files, File{Path: path, Hash: state.Hash, Size: state.Size, Mode: state.Mode})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading tree of %s: %w", st.Name, err)
+	}
+	sort.Slice(m.Files, func(x, y int) bool { return m.Files[x].Path < m.Files[y].Path })
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling manifest: %w", err)
+	}
+	hash, err := s.Store(data)
+	if err != nil {
+		return nil, fmt.Errorf("storing manifest: %w", err)
+	}
+
+	t := Tag{Name: tag, Manifest: hash, StreamID: st.ID, CreatedAt: m.CreatedAt}
+	err = db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get([]byte(tagPrefix + tag)); err == nil {
+			return fmt.Errorf("%w: release %s already exists", errors.ErrConflict, tag)
+		}
+		data, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		return txn.Set([]byte(tagPrefix+tag), data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tagging release %s: %w", tag, err)
+	}
+	return m, nil
+}
+
+// GetTag looks up a release tag
+func GetTag(db *badger.DB, tag string) (*Tag, error) {
+	var t Tag
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(tagPrefix + tag))
+		if err == badger.ErrKeyNotFound {
+			return errors.NotFound(fmt.Sprintf("no release tagged %s", tag))
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &t)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Get loads the manifest of a release tag
+func Get(db *badger.DB, s *safe.Safe, tag string) (*Manifest, error) {
+	t, err := GetTag(db, tag)
+	if err != nil {
+		return nil, err
+	}
+	return Load(s, t.Manifest)
+}
+
+// Load reads a manifest from the safe by its hash
+func Load(s *safe.Safe, hash string) (*Manifest, error) {
+	data, err := s.Get(hash)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest %s: %w", hash, err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("decoding manifest %s: %w", hash, err)
+	}
+	return &m, nil
+}
+
+// List returns every release tag, oldest first
+func List(db *badger.DB) ([]Tag, error) {
+	var tags []Tag
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(tagPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var t Tag
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &t)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			tags = append(tags, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing releases: %w", err)
+	}
+	sort.SliceStable(tags, func(x, y int) bool { return tags[x].CreatedAt.Before(tags[y].CreatedAt) })
+	return tags, nil
+}
+
+// Export writes the released files into dir, which must be empty or not
+// exist yet
+func Export(s *safe.Safe, m *Manifest, dir string) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("destination %s is not empty", dir)
+	}
+	for _, f := range m.Files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Path)) {
+			return fmt.Errorf("manifest %s lists unsafe path %q", m.Tag, f.Path)
+		}
+		content, err := s.Get(f.Hash)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", f.Path, err)
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		mode := os.FileMode(f.Mode).Perm()
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.WriteFile(dst, content, mode); err != nil {
+			return fmt.Errorf("writing %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+func validTag(tag string) error {
+	if tag == "" || strings.ContainsAny(tag, " \t\n") || strings.HasPrefix(tag, "-") {
+		return errors.ValidationError(fmt.Sprintf("invalid release tag %q", tag), nil)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// internal/release/release_test.go
+package release
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/errors"
+	"tig/internal/safe"
+	"tig/internal/stream"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndExport(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	hashes, err := s.StoreBatch([][]byte{[]byte("v1\n"), []byte("v2\n"), []byte("doc\n")})
+	require.NoError(t, err)
+	at := time.Now()
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		if err := change.PutChangeSet(txn, &change.ChangeSet{ID: "cs1", CreatedAt: at, Changes: []shared.Change{
+			{Path: "main.go", Type: "add", NewHash: hashes[0], Size: 3},
+			{Path: "docs/a.md", Type: "add", NewHash: hashes[2], Size: 4},
+		}}); err != nil {
+			return err
+		}
+		return change.PutChangeSet(txn, &change.ChangeSet{ID: "cs2", CreatedAt: at.Add(time.Second), Changes: []shared.Change{
+			{Path: "main.go", Type: "modify", NewHash: hashes[1], Size: 3},
+		}})
+	}))
+
+	st := &stream.Stream{ID: "s1", Name: "release-1", Type: "release", State: stream.State{Merged: []string{"i1", "i2"}, Head: "cs2"}}
+	assert.Equal(t, "release-1/2", AutoTag(st))
+
+	m, err := Create(db, s, "v1.0", st, "cs1")
+	require.NoError(t, err)
+	assert.Equal(t, []File{
+		{Path: "docs/a.md", Hash: hashes[2], Size: 4},
+		{Path: "main.go", Hash: hashes[0], Size: 3},
+	}, m.Files)
+
+	// Tags are immutable
+	_, err = Create(db, s, "v1.0", st, "cs2")
+	assert.ErrorIs(t, err, errors.ErrConflict)
+	_, err = Create(db, s, "bad tag", st, "cs2")
+	assert.Error(t, err)
+
+	_, err = Create(db, s, "v1.1", st, "cs2")
+	require.NoError(t, err)
+	tags, err := List(db)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "v1.0", tags[0].Name)
+
+	got, err := Get(db, s, "v1.0")
+	require.NoError(t, err)
+	assert.Equal(t, m.Files, got.Files)
+	assert.Equal(t, []string{"i1", "i2"}, got.Intents)
+	_, err = Get(db, s, "v9")
+	assert.Error(t, err)
+
+	dir := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, Export(s, got, dir))
+	data, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1\n", string(data))
+	assert.Error(t, Export(s, got, dir), "destination must be empty")
+}
@@ -0,0 +1,67 @@
+// internal/remote/buildcache.go
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"tig/internal/buildcache"
+)
+
+// BuildCache returns the cached build of a changeset ID or tree hash, or
+// nil if that source state was never built
+func (c *Client) BuildCache(ctx context.Context, key string) (*buildcache.Entry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/build-cache/"+url.PathEscape(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("contacting remote: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("GET %s: %s: %s", req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var e buildcache.Entry
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+		return nil, fmt.Errorf("decoding build cache entry: %w", err)
+	}
+	return &e, nil
+}
+
+// SetBuildCache records the artifacts built from a changeset ID or tree
+// hash and returns the stored entry
+func (c *Client) SetBuildCache(ctx context.Context, key string, e *buildcache.Entry) (*buildcache.Entry, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.BaseURL+"/api/build-cache/"+url.PathEscape(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var stored buildcache.Entry
+	if err := json.NewDecoder(resp.Body).Decode(&stored); err != nil {
+		return nil, fmt.Errorf("decoding build cache entry: %w", err)
+	}
+	return &stored, nil
+}
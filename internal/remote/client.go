@@ -0,0 +1,288 @@
+// internal/remote/client.go
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"tig/internal/errors"
+	"tig/internal/health"
+	"tig/internal/license"
+	"tig/internal/storage"
+)
+
+// Client talks to the HTTP API of a repository served with `tig serve`
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// NewClient creates a client for the server at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Metadata downloads the repository metadata records
+func (c *Client) Metadata(ctx context.Context) ([]Record, error) {
+	resp, err := c.get(ctx, "/api/sync/metadata")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var records []Record
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		return nil, fmt.Errorf("decoding metadata: %w", err)
+	}
+	return records, nil
+}
+
+// Blob downloads the content stored under hash
+func (c *Client) Blob(ctx context.Context, hash string) ([]byte, error) {
+	resp, err := c.get(ctx, "/api/content/"+hash)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading content %s: %w", hash, err)
+	}
+	return data, nil
+}
+
+// Fetch implements safe.Fetcher so a partial clone can load content on
+// demand
+func (c *Client) Fetch(hash string) ([]byte, error) {
+	return c.Blob(context.Background(), hash)
+}
+
+// CacheStats reports the server's content and Badger cache statistics
+func (c *Client) CacheStats(ctx context.Context) ([]storage.CacheStats, error) {
+	resp, err := c.get(ctx, "/api/stats/cache")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var caches []storage.CacheStats
+	if err := json.NewDecoder(resp.Body).Decode(&caches); err != nil {
+		return nil, fmt.Errorf("decoding cache stats: %w", err)
+	}
+	return caches, nil
+}
+
+// Status runs the server's deep health check. A report is returned even
+// when the server answers 503 because a subsystem is failing.
+func (c *Client) Status(ctx context.Context) (*health.Report, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/admin/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("contacting remote: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("GET /api/admin/status: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var report health.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("decoding status: %w", err)
+	}
+	return &report, nil
+}
+
+// License returns the license status of a running tig serve
+func (c *Client) License(ctx context.Context) (*license.Status, error) {
+	resp, err := c.get(ctx, "/api/admin/license")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var status license.Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("decoding license status: %w", err)
+	}
+	return &status, nil
+}
+
+// Negotiate sends the client's inventory and returns what each side
+// should send the other
+func (c *Client) Negotiate(ctx context.Context, inv *Inventory) (*Plan, error) {
+	var plan Plan
+	if err := c.postJSON(ctx, "/api/sync/negotiate", inv, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// PushBundle sends changesets and records to the server. The content
+// the changesets reference must already be there.
+func (c *Client) PushBundle(ctx context.Context, b *Bundle) (*ApplyResult, error) {
+	var res ApplyResult
+	if err := c.postJSON(ctx, "/api/sync/push", b, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// PullBundle downloads the changesets and records named in req
+func (c *Client) PullBundle(ctx context.Context, req *PullRequest) (*Bundle, error) {
+	var b Bundle
+	if err := c.postJSON(ctx, "/api/sync/pull", req, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// postJSON posts v as JSON and decodes the response into out
+func (c *Client) postJSON(ctx context.Context, path string, v, out any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	resp, err := c.post(ctx, path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s response: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+// do sends a request, turning non-200 responses into errors
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("contacting remote: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		err := fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, errors.NotFound(err.Error())
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// BlobSource supplies the content of a hash being uploaded
+type BlobSource func(hash string) ([]byte, error)
+
+// TransferStats describes a content push
+type TransferStats struct {
+	Offered int   `json:"offered"`
+	Sent    int   `json:"sent"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// Missing sends a manifest of hashes and returns those the server lacks
+func (c *Client) Missing(ctx context.Context, hashes []string) ([]string, error) {
+	body, err := json.Marshal(Manifest{Hashes: hashes})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, "/api/transfer/manifest", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var set MissingSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decoding missing set: %w", err)
+	}
+	return set.Missing, nil
+}
+
+// Upload streams the given blobs to the server in a single chunked request
+func (c *Client) Upload(ctx context.Context, hashes []string, src BlobSource) (*UploadResult, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		bw := NewBlobWriter(pw)
+		for _, hash := range hashes {
+			data, err := src(hash)
+			if err != nil {
+				pw.CloseWithError(fmt.Errorf("reading %s: %w", hash, err))
+				return
+			}
+			if err := bw.WriteBlob(hash, data); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.Close()
+	}()
+
+	resp, err := c.post(ctx, "/api/transfer/blobs", "application/octet-stream", pr)
+	pr.Close()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var res UploadResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("decoding upload result: %w", err)
+	}
+	return &res, nil
+}
+
+// PushContent negotiates which of hashes the server is missing and uploads
+// only those, in one request
+func (c *Client) PushContent(ctx context.Context, hashes []string, src BlobSource) (*TransferStats, error) {
+	stats := &TransferStats{Offered: len(hashes)}
+
+	missing, err := c.Missing(ctx, hashes)
+	if err != nil {
+		return stats, err
+	}
+	if len(missing) == 0 {
+		return stats, nil
+	}
+
+	res, err := c.Upload(ctx, missing, src)
+	if err != nil {
+		return stats, err
+	}
+	stats.Sent = res.Stored + res.Skipped
+	stats.Bytes = res.Bytes
+	return stats, nil
+}
+
+func (c *Client) post(ctx context.Context, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.do(req)
+}
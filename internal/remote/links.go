@@ -0,0 +1,57 @@
+// internal/remote/links.go
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"tig/internal/intent"
+)
+
+// Intent fetches an intent from the server
+func (c *Client) Intent(ctx context.Context, id string) (*intent.Intent, error) {
+	resp, err := c.get(ctx, "/api/intents/"+url.PathEscape(id))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var i intent.Intent
+	if err := json.NewDecoder(resp.Body).Decode(&i); err != nil {
+		return nil, fmt.Errorf("decoding intent %s: %w", id, err)
+	}
+	return &i, nil
+}
+
+// ResolvedLink is a link with the intent it points to, or the reason it
+// could not be fetched
+type ResolvedLink struct {
+	intent.Link
+	Intent *intent.Intent `json:"intent,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// ResolveLinks fetches linked intents from their repositories. A link
+// that cannot be resolved is reported on its own rather than failing the
+// rest.
+func ResolveLinks(ctx context.Context, links []intent.Link) []ResolvedLink {
+	clients := make(map[string]*Client)
+	resolved := make([]ResolvedLink, len(links))
+	for n, l := range links {
+		c, ok := clients[l.Repo]
+		if !ok {
+			c = NewClient(l.Repo)
+			clients[l.Repo] = c
+		}
+		resolved[n] = ResolvedLink{Link: l}
+		i, err := c.Intent(ctx, l.IntentID)
+		if err != nil {
+			resolved[n].Error = err.Error()
+			continue
+		}
+		resolved[n].Intent = i
+	}
+	return resolved
+}
@@ -0,0 +1,41 @@
+// internal/remote/links_test.go
+package remote_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tig/internal/intent"
+	"tig/internal/remote"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveLinks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/intents/i1" {
+			http.Error(w, "intent not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(&intent.Intent{ID: "i1", Description: "Add billing API",
+			Impact: intent.Impact{Breaking: true}})
+	}))
+	defer srv.Close()
+
+	resolved := remote.ResolveLinks(context.Background(), []intent.Link{
+		{Repo: srv.URL, IntentID: "i1"},
+		{Repo: srv.URL, IntentID: "gone"},
+		{Repo: "http://127.0.0.1:1", IntentID: "i1"},
+	})
+	require.Len(t, resolved, 3)
+	require.NotNil(t, resolved[0].Intent)
+	assert.Equal(t, "Add billing API", resolved[0].Intent.Description)
+	assert.Empty(t, resolved[0].Error)
+	assert.Nil(t, resolved[1].Intent)
+	assert.Contains(t, resolved[1].Error, "404")
+	assert.Contains(t, resolved[2].Error, "contacting remote")
+}
@@ -0,0 +1,88 @@
+// internal/remote/metadata.go
+package remote
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// MetadataPrefixes are the key prefixes copied by a clone: intents,
+// streams and their mutation log, changesets with their indexes, the
+// tracked tree, release tags and build attestations
+var MetadataPrefixes = []string{
+	"intent:",
+	"stream:",
+	"events:",
+	"events_time:",
+	"changeset:",
+	"cs_time:",
+	"cs_path:",
+	"file_state:",
+	"tracked:",
+	"release:",
+	"attest:",
+	"attest_artifact:",
+}
+
+// Record is a single metadata key/value pair
+type Record struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// ExportMetadata returns every record under MetadataPrefixes
+func ExportMetadata(db *badger.DB) ([]Record, error) {
+	var records []Record
+	err := db.View(func(txn *badger.Txn) error {
+		for _, prefix := range MetadataPrefixes {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = []byte(prefix)
+			it := txn.NewIterator(opts)
+			for it.Rewind(); it.Valid(); it.Next() {
+				value, err := it.Item().ValueCopy(nil)
+				if err != nil {
+					it.Close()
+					return fmt.Errorf("reading %s: %w", it.Item().Key(), err)
+				}
+				records = append(records, Record{Key: string(it.Item().KeyCopy(nil)), Value: value})
+			}
+			it.Close()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("exporting metadata: %w", err)
+	}
+	return records, nil
+}
+
+// ImportMetadata writes records into db, replacing existing keys
+func ImportMetadata(db *badger.DB, records []Record) error {
+	wb := db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, r := range records {
+		if !allowed(r.Key) {
+			return fmt.Errorf("unexpected metadata key %q", r.Key)
+		}
+		if err := wb.Set([]byte(r.Key), r.Value); err != nil {
+			return fmt.Errorf("importing %s: %w", r.Key, err)
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("importing metadata: %w", err)
+	}
+	return nil
+}
+
+// allowed reports whether a key falls under one of MetadataPrefixes, so a
+// remote cannot overwrite local-only state such as content metadata
+func allowed(key string) bool {
+	for _, prefix := range MetadataPrefixes {
+		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,341 @@
+// internal/remote/sync.go
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/change"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// SyncPrefixes are the record key prefixes pull exchanges besides
+// changesets: intents, streams and their mutation log, release tags and
+// build attestations. Push sends only intents and streams. The tracked
+// tree is local to each repository and is not exchanged.
+var SyncPrefixes = []string{
+	"intent:",
+	"stream:",
+	"events:",
+	"events_time:",
+	"release:",
+	"attest:",
+	"attest_artifact:",
+}
+
+// mutablePrefixes hold records that change after they are written; the
+// newer version wins when both sides have one. Records under the other
+// prefixes never change once written.
+var mutablePrefixes = []string{"intent:", "stream:"}
+
+// pushablePrefixes hold the records a client may push. The server stores
+// them through its own checks and writes the mutation log, release tags
+// and attestations itself.
+var pushablePrefixes = []string{"intent:", "stream:"}
+
+// Entry summarizes a record for negotiation without its value
+type Entry struct {
+	Key       string    `json:"key"`
+	Digest    string    `json:"digest"`               // Hex SHA-256 of the value
+	UpdatedAt time.Time `json:"updated_at,omitempty"` // Of intents and streams
+}
+
+// Inventory is what one side of a sync holds
+type Inventory struct {
+	ChangeSets []string `json:"changesets"`
+	Records    []Entry  `json:"records"`
+}
+
+// Plan is the server's answer to a client's inventory: what each side
+// lacks, or holds an older version of, from the other
+type Plan struct {
+	PushChangeSets []string `json:"push_changesets"` // The server lacks them
+	PushRecords    []string `json:"push_records"`
+	PullChangeSets []string `json:"pull_changesets"` // The client lacks them
+	PullRecords    []string `json:"pull_records"`
+}
+
+// PullRequest names the changesets and records a client wants
+type PullRequest struct {
+	ChangeSets []string `json:"changesets"`
+	Records    []string `json:"records"`
+}
+
+// Bundle carries changesets and records from one side to the other
+type Bundle struct {
+	ChangeSets []*change.ChangeSet `json:"changesets"`
+	Records    []Record            `json:"records"`
+}
+
+// Hashes returns the content hashes a bundle's changesets reference
+func (b *Bundle) Hashes() []string {
+	seen := make(map[string]bool)
+	var hashes []string
+	for _, cs := range b.ChangeSets {
+		for _, c := range cs.Changes {
+			if c.NewHash != "" && c.Type != "delete" && !seen[c.NewHash] {
+				seen[c.NewHash] = true
+				hashes = append(hashes, c.NewHash)
+			}
+		}
+	}
+	return hashes
+}
+
+// ApplyResult counts what applying a bundle wrote
+type ApplyResult struct {
+	ChangeSets int `json:"changesets"`
+	Records    int `json:"records"`
+	Skipped    int `json:"skipped"` // Records the receiver already had, or had a newer version of
+}
+
+// BuildInventory summarizes the changesets and records in db
+func BuildInventory(db *badger.DB) (*Inventory, error) {
+	inv := &Inventory{ChangeSets: []string{}, Records: []Entry{}}
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte("changeset:")
+		it := txn.NewIterator(opts)
+		for it.Rewind(); it.Valid(); it.Next() {
+			inv.ChangeSets = append(inv.ChangeSets, strings.TrimPrefix(string(it.Item().Key()), "changeset:"))
+		}
+		it.Close()
+
+		for _, prefix := range SyncPrefixes {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = []byte(prefix)
+			it := txn.NewIterator(opts)
+			for it.Rewind(); it.Valid(); it.Next() {
+				key := string(it.Item().KeyCopy(nil))
+				err := it.Item().Value(func(val []byte) error {
+					inv.Records = append(inv.Records, entryOf(key, val))
+					return nil
+				})
+				if err != nil {
+					it.Close()
+					return fmt.Errorf("reading %s: %w", key, err)
+				}
+			}
+			it.Close()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building inventory: %w", err)
+	}
+	return inv, nil
+}
+
+func entryOf(key string, val []byte) Entry {
+	sum := sha256.Sum256(val)
+	e := Entry{Key: key, Digest: hex.EncodeToString(sum[:])}
+	if mutable(key) {
+		var v struct {
+			UpdatedAt time.Time `json:"updated_at"`
+		}
+		if json.Unmarshal(val, &v) == nil {
+			e.UpdatedAt = v.UpdatedAt
+		}
+	}
+	return e
+}
+
+// Negotiate compares the server's inventory with a client's. Changesets
+// and records only one side has go to the other, except that a client
+// pushes only intents and streams; when both have a different version of
+// an intent or stream, the newer one wins.
+func Negotiate(server, client *Inventory) *Plan {
+	plan := &Plan{PushChangeSets: []string{}, PushRecords: []string{}, PullChangeSets: []string{}, PullRecords: []string{}}
+
+	serverSets := make(map[string]bool, len(server.ChangeSets))
+	for _, id := range server.ChangeSets {
+		serverSets[id] = true
+	}
+	clientSets := make(map[string]bool, len(client.ChangeSets))
+	for _, id := range client.ChangeSets {
+		clientSets[id] = true
+		if !serverSets[id] {
+			plan.PushChangeSets = append(plan.PushChangeSets, id)
+		}
+	}
+	for _, id := range server.ChangeSets {
+		if !clientSets[id] {
+			plan.PullChangeSets = append(plan.PullChangeSets, id)
+		}
+	}
+
+	serverRecords := make(map[string]Entry, len(server.Records))
+	for _, e := range server.Records {
+		serverRecords[e.Key] = e
+	}
+	clientRecords := make(map[string]bool, len(client.Records))
+	for _, e := range client.Records {
+		clientRecords[e.Key] = true
+		theirs, ok := serverRecords[e.Key]
+		switch {
+		case !ok:
+			if Pushable(e.Key) {
+				plan.PushRecords = append(plan.PushRecords, e.Key)
+			}
+		case theirs.Digest == e.Digest || !mutable(e.Key):
+		case e.UpdatedAt.After(theirs.UpdatedAt):
+			plan.PushRecords = append(plan.PushRecords, e.Key)
+		case theirs.UpdatedAt.After(e.UpdatedAt):
+			plan.PullRecords = append(plan.PullRecords, e.Key)
+		}
+	}
+	for _, e := range server.Records {
+		if !clientRecords[e.Key] {
+			plan.PullRecords = append(plan.PullRecords, e.Key)
+		}
+	}
+
+	for _, list := range [][]string{plan.PushChangeSets, plan.PushRecords, plan.PullChangeSets, plan.PullRecords} {
+		sort.Strings(list)
+	}
+	return plan
+}
+
+// ExportBundle reads the named changesets and records from db
+func ExportBundle(db *badger.DB, changeSets, keys []string) (*Bundle, error) {
+	b := &Bundle{ChangeSets: []*change.ChangeSet{}, Records: []Record{}}
+	err := db.View(func(txn *badger.Txn) error {
+		for _, id := range changeSets {
+			cs, err := change.GetChangeSet(txn, id)
+			if err != nil {
+				return fmt.Errorf("reading changeset %s: %w", id, err)
+			}
+			b.ChangeSets = append(b.ChangeSets, cs)
+		}
+		for _, key := range keys {
+			if !syncable(key) {
+				return fmt.Errorf("unexpected sync key %q", key)
+			}
+			item, err := txn.Get([]byte(key))
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", key, err)
+			}
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", key, err)
+			}
+			b.Records = append(b.Records, Record{Key: key, Value: value})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ApplyBundle writes a bundle pulled from the server into db in one
+// transaction. Changesets db already has are left alone. Records are
+// written unless db has the same key with a value that is newer, for
+// intents and streams, or that never changes. The server does not apply
+// pushed bundles this way; see Pushable.
+func ApplyBundle(db *badger.DB, b *Bundle) (*ApplyResult, error) {
+	res := &ApplyResult{}
+	err := db.Update(func(txn *badger.Txn) error {
+		*res = ApplyResult{}
+		n, err := ApplyChangeSets(txn, b.ChangeSets)
+		if err != nil {
+			return err
+		}
+		res.ChangeSets = n
+
+		for _, r := range b.Records {
+			if !syncable(r.Key) {
+				return fmt.Errorf("unexpected sync key %q", r.Key)
+			}
+			item, err := txn.Get([]byte(r.Key))
+			switch {
+			case errors.Is(err, badger.ErrKeyNotFound):
+			case err != nil:
+				return err
+			case !mutable(r.Key):
+				res.Skipped++
+				continue
+			default:
+				var current Entry
+				if err := item.Value(func(val []byte) error {
+					current = entryOf(r.Key, val)
+					return nil
+				}); err != nil {
+					return err
+				}
+				if !entryOf(r.Key, r.Value).UpdatedAt.After(current.UpdatedAt) {
+					res.Skipped++
+					continue
+				}
+			}
+			if err := txn.Set([]byte(r.Key), r.Value); err != nil {
+				return fmt.Errorf("writing %s: %w", r.Key, err)
+			}
+			res.Records++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("applying sync bundle: %w", err)
+	}
+	return res, nil
+}
+
+// ApplyChangeSets stores the changesets txn does not have yet and returns
+// how many it stored. Changesets never change once written, so those
+// already present are left alone.
+func ApplyChangeSets(txn *badger.Txn, changeSets []*change.ChangeSet) (int, error) {
+	n := 0
+	for _, cs := range changeSets {
+		if cs == nil || cs.ID == "" {
+			return n, fmt.Errorf("changeset without an ID")
+		}
+		if _, err := change.GetChangeSet(txn, cs.ID); err == nil {
+			continue
+		}
+		if err := change.PutChangeSet(txn, cs); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Pushable reports whether a client may push the record under key. Only
+// intents and streams are accepted; the mutation log, release tags and
+// attestations are written by the server itself.
+func Pushable(key string) bool {
+	for _, prefix := range pushablePrefixes {
+		if len(key) > len(prefix) && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func syncable(key string) bool {
+	for _, prefix := range SyncPrefixes {
+		if len(key) > len(prefix) && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func mutable(key string) bool {
+	for _, prefix := range mutablePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+// internal/remote/transfer.go
+package remote
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxBlobSize bounds a single blob in an upload stream
+const MaxBlobSize = 1 << 30
+
+// Manifest lists the content hashes a client intends to send
+type Manifest struct {
+	Hashes []string `json:"hashes"`
+}
+
+// MissingSet is the server's reply to a manifest: the hashes it lacks
+type MissingSet struct {
+	Missing []string `json:"missing"`
+}
+
+// ContentInfo describes content on the server without its bytes
+type ContentInfo struct {
+	Hash     string `json:"hash"`
+	Present  bool   `json:"present"`
+	Size     int64  `json:"size,omitempty"`
+	RefCount uint32 `json:"ref_count,omitempty"`
+	Tier     string `json:"tier,omitempty"` // hot, warm or cold storage
+}
+
+// UploadResult summarizes a blob upload
+type UploadResult struct {
+	Stored  int   `json:"stored"`
+	Skipped int   `json:"skipped"` // Already present when they arrived
+	Bytes   int64 `json:"bytes"`
+}
+
+// Blob upload streams are a sequence of frames, each a 32-byte SHA-256
+// digest, an 8-byte big-endian length and the content itself. The stream
+// ends at EOF, so any number of blobs share one chunked request.
+
+// BlobWriter encodes blobs into an upload stream
+type BlobWriter struct {
+	w io.Writer
+}
+
+// NewBlobWriter creates a writer framing blobs onto w
+func NewBlobWriter(w io.Writer) *BlobWriter {
+	return &BlobWriter{w: w}
+}
+
+// WriteBlob appends one blob to the stream
+func (bw *BlobWriter) WriteBlob(hash string, data []byte) error {
+	digest, err := hex.DecodeString(hash)
+	if err != nil || len(digest) != 32 {
+		return fmt.Errorf("invalid hash %q", hash)
+	}
+
+	var header [40]byte
+	copy(header[:32], digest)
+	binary.BigEndian.PutUint64(header[32:], uint64(len(data)))
+	if _, err := bw.w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err = bw.w.Write(data)
+	return err
+}
+
+// BlobReader decodes an upload stream
+type BlobReader struct {
+	r *bufio.Reader
+}
+
+// NewBlobReader creates a reader for the stream in r
+func NewBlobReader(r io.Reader) *BlobReader {
+	return &BlobReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next blob, or io.EOF at the end of the stream
+func (br *BlobReader) Next() (hash string, data []byte, err error) {
+	var header [40]byte
+	if _, err := io.ReadFull(br.r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return "", nil, fmt.Errorf("truncated blob header")
+		}
+		return "", nil, err
+	}
+
+	size := binary.BigEndian.Uint64(header[32:])
+	if size > MaxBlobSize {
+		return "", nil, fmt.Errorf("blob of %d bytes exceeds limit", size)
+	}
+
+	data = make([]byte, size)
+	if _, err := io.ReadFull(br.r, data); err != nil {
+		return "", nil, fmt.Errorf("truncated blob: %w", err)
+	}
+	return hex.EncodeToString(header[:32]), data, nil
+}
+
+// MaxPushHeader bounds the changeset metadata at the start of a push
+// stream
+const MaxPushHeader = 64 << 20
+
+// PushResult summarizes a changeset push
+type PushResult struct {
+	ChangeSetID string `json:"changeset_id"`
+	Created     bool   `json:"created"` // False when the server already had the changeset
+	UploadResult
+}
+
+// Push streams carry a changeset and its content in one request: an
+// 8-byte big-endian length and that many bytes of changeset JSON, then a
+// blob upload stream of the content the server lacks. They may be
+// gzip-compressed as a whole, sent with Content-Encoding: gzip.
+
+// WritePushHeader starts a push stream with a changeset's JSON
+func WritePushHeader(w io.Writer, meta []byte) error {
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], uint64(len(meta)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(meta)
+	return err
+}
+
+// ReadPushHeader reads the changeset JSON at the start of a push stream,
+// leaving r at its first blob
+func ReadPushHeader(r io.Reader) ([]byte, error) {
+	var size [8]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, fmt.Errorf("truncated push header: %w", err)
+	}
+	n := binary.BigEndian.Uint64(size[:])
+	if n > MaxPushHeader {
+		return nil, fmt.Errorf("push header of %d bytes exceeds limit", n)
+	}
+	meta := make([]byte, n)
+	if _, err := io.ReadFull(r, meta); err != nil {
+		return nil, fmt.Errorf("truncated push header: %w", err)
+	}
+	return meta, nil
+}
@@ -0,0 +1,118 @@
+// internal/remote/transfer_test.go
+package remote_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"tig/internal/config"
+	"tig/internal/logging"
+	"tig/internal/remote"
+	"tig/internal/safe"
+	"tig/internal/server"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestBlobStream(t *testing.T) {
+	var buf bytes.Buffer
+	bw := remote.NewBlobWriter(&buf)
+	blobs := [][]byte{[]byte("one"), {}, bytes.Repeat([]byte("x"), 70000)}
+	for _, b := range blobs {
+		require.NoError(t, bw.WriteBlob(hashOf(b), b))
+	}
+	assert.Error(t, bw.WriteBlob("nothex", nil))
+
+	br := remote.NewBlobReader(&buf)
+	for _, want := range blobs {
+		hash, data, err := br.Next()
+		require.NoError(t, err)
+		assert.Equal(t, hashOf(want), hash)
+		assert.Equal(t, len(want), len(data))
+	}
+	_, _, err := br.Next()
+	assert.Equal(t, io.EOF, err)
+
+	_, _, err = remote.NewBlobReader(bytes.NewReader(make([]byte, 20))).Next()
+	assert.ErrorContains(t, err, "truncated")
+}
+
+func TestPushContent(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+	srv, err := server.New(config.Default(), db, s, t.TempDir(), &logging.Logger{Logger: zap.NewNop()})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		srv.Handler().ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	// The server already has the first of many small files
+	local := make(map[string][]byte)
+	var hashes []string
+	for i := 0; i < 500; i++ {
+		data := []byte(fmt.Sprintf("module %d", i))
+		local[hashOf(data)] = data
+		hashes = append(hashes, hashOf(data))
+	}
+	_, err = s.Store(local[hashes[0]])
+	require.NoError(t, err)
+
+	c := remote.NewClient(ts.URL)
+	stats, err := c.PushContent(context.Background(), hashes, func(hash string) ([]byte, error) {
+		return local[hash], nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 500, stats.Offered)
+	assert.Equal(t, 499, stats.Sent)
+	assert.Equal(t, int32(2), requests.Load())
+
+	missing, err := s.Missing(hashes)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	// Nothing left to send
+	stats, err = c.PushContent(context.Background(), hashes, func(string) ([]byte, error) {
+		t.Fatal("no blobs should be read")
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Zero(t, stats.Sent)
+
+	// Content that doesn't match its hash is rejected
+	data := []byte("real")
+	_, err = c.Upload(context.Background(), []string{hashOf([]byte("claimed"))}, func(string) ([]byte, error) {
+		return data, nil
+	})
+	assert.ErrorContains(t, err, "400")
+	exists, err := s.Exists(hashOf(data))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
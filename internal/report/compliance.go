@@ -0,0 +1,342 @@
+// internal/report/compliance.go
+package report
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/intent"
+	"tig/internal/merge"
+	"tig/internal/stream"
+)
+
+// Compliance is an audit record of a period: every intent created in it
+// with its reviews and check results, and every merge into a protected
+// stream with who asked for it
+type Compliance struct {
+	Since      time.Time             `json:"since"`
+	Until      time.Time             `json:"until"`
+	Generated  time.Time             `json:"generated"`
+	Summary    ComplianceSummary     `json:"summary"`
+	Intents    []ComplianceIntent    `json:"intents"`
+	Merges     []merge.Landing       `json:"merges"`     // Into protected streams
+	Exceptions []ComplianceException `json:"exceptions"` // Merges that did not meet the stream's rules
+	Signature  *Signature            `json:"signature,omitempty"`
+}
+
+// ComplianceSummary counts what the report covers
+type ComplianceSummary struct {
+	Intents    int `json:"intents"`
+	Approved   int `json:"approved"`      // Intents with at least one approval
+	Unreviewed int `json:"unreviewed"`    // Intents with no reviews at all
+	ChecksFail int `json:"checks_failed"` // Intents with a failed check
+	Merges     int `json:"merges"`
+	Exceptions int `json:"exceptions"`
+}
+
+// ComplianceIntent is an intent with its review and check history
+type ComplianceIntent struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Description string          `json:"description"`
+	Author      string          `json:"author,omitempty"`
+	ChangeSetID string          `json:"changeset_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Streams     []string        `json:"streams"`
+	Reviews     []intent.Review `json:"reviews"`
+	Approvals   []string        `json:"approvals"`
+	Checks      []intent.Check  `json:"checks"`
+}
+
+// ComplianceException is a merge into a protected stream that an auditor
+// should look at
+type ComplianceException struct {
+	IntentID string    `json:"intent_id"`
+	Stream   string    `json:"stream"`
+	MergedAt time.Time `json:"merged_at"`
+	Reasons  []string  `json:"reasons"`
+}
+
+// BuildCompliance assembles the report for intents created and merges
+// landed after since and up to until
+func BuildCompliance(intents []*intent.Intent, streams []*stream.Stream, landings []merge.Landing, since, until time.Time) *Compliance {
+	c := &Compliance{
+		Since:      since,
+		Until:      until,
+		Generated:  time.Now(),
+		Intents:    []ComplianceIntent{},
+		Merges:     []merge.Landing{},
+		Exceptions: []ComplianceException{},
+	}
+
+	memberOf := make(map[string][]string)
+	for _, st := range streams {
+		for _, id := range st.State.Intents {
+			memberOf[id] = append(memberOf[id], st.Name)
+		}
+	}
+
+	sort.Slice(intents, func(x, y int) bool { return intents[x].CreatedAt.Before(intents[y].CreatedAt) })
+	for _, i := range intents {
+		if !i.CreatedAt.After(since) || i.CreatedAt.After(until) {
+			continue
+		}
+		ci := ComplianceIntent{
+			ID:          i.ID,
+			Type:        i.Type,
+			Description: i.Description,
+			Author:      i.Metadata.Author,
+			ChangeSetID: i.ChangeSetID,
+			CreatedAt:   i.CreatedAt,
+			Streams:     nonNil(memberOf[i.ID]),
+			Reviews:     i.Reviews,
+			Approvals:   nonNil(i.Approvals()),
+			Checks:      i.Checks,
+		}
+		if ci.Reviews == nil {
+			ci.Reviews = []intent.Review{}
+		}
+		if ci.Checks == nil {
+			ci.Checks = []intent.Check{}
+		}
+		sort.Strings(ci.Streams)
+		c.Intents = append(c.Intents, ci)
+
+		switch {
+		case len(ci.Approvals) > 0:
+			c.Summary.Approved++
+		case len(ci.Reviews) == 0:
+			c.Summary.Unreviewed++
+		}
+		for _, check := range ci.Checks {
+			if check.Status == intent.CheckFailed {
+				c.Summary.ChecksFail++
+				break
+			}
+		}
+	}
+
+	for _, l := range landings {
+		if !l.Protection.Enabled() || !l.MergedAt.After(since) || l.MergedAt.After(until) {
+			continue
+		}
+		c.Merges = append(c.Merges, l)
+
+		// Re-check the merge against the rules in force when it landed
+		merged := &intent.Intent{Checks: l.Checks}
+		for _, reviewer := range l.Approvals {
+			merged.Reviews = append(merged.Reviews, intent.Review{Reviewer: reviewer, Approved: true})
+		}
+		// An allowed override lifts the stream's schedules only
+		reasons := l.Protection.UnmetAt(merged, l.MergedAt)
+		if l.Override {
+			reasons = l.Protection.Unmet(merged)
+		}
+		if l.By == "" && !l.Auto {
+			reasons = append(reasons, "merged by an unrecorded user")
+		}
+		if len(reasons) > 0 {
+			c.Exceptions = append(c.Exceptions, ComplianceException{
+				IntentID: l.IntentID,
+				Stream:   l.Stream,
+				MergedAt: l.MergedAt,
+				Reasons:  reasons,
+			})
+		}
+	}
+
+	c.Summary.Intents = len(c.Intents)
+	c.Summary.Merges = len(c.Merges)
+	c.Summary.Exceptions = len(c.Exceptions)
+	return c
+}
+
+// Markdown renders the report for an auditor
+func (c *Compliance) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Compliance report\n\n")
+	fmt.Fprintf(&b, "Period %s to %s. Generated %s.\n\n",
+		c.Since.Format(time.RFC3339), c.Until.Format(time.RFC3339), c.Generated.Format(time.RFC3339))
+
+	fmt.Fprintf(&b, "## Summary\n\n")
+	fmt.Fprintf(&b, "- Intents created: %d\n", c.Summary.Intents)
+	fmt.Fprintf(&b, "- Approved: %d\n", c.Summary.Approved)
+	fmt.Fprintf(&b, "- Never reviewed: %d\n", c.Summary.Unreviewed)
+	fmt.Fprintf(&b, "- With failed checks: %d\n", c.Summary.ChecksFail)
+	fmt.Fprintf(&b, "- Merges into protected streams: %d\n", c.Summary.Merges)
+	fmt.Fprintf(&b, "- Exceptions: %d\n", c.Summary.Exceptions)
+
+	fmt.Fprintf(&b, "\n## Exceptions\n\n")
+	if len(c.Exceptions) == 0 {
+		fmt.Fprintf(&b, "None.\n")
+	} else {
+		fmt.Fprintf(&b, "| Merged | Stream | Intent | Reasons |\n")
+		fmt.Fprintf(&b, "|---|---|---|---|\n")
+		for _, e := range c.Exceptions {
+			fmt.Fprintf(&b, "| %s | %s | `%s` | %s |\n", e.MergedAt.Format(time.RFC3339), cell(e.Stream),
+				short(e.IntentID), cell(strings.Join(e.Reasons, "; ")))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Merges into protected streams\n\n")
+	if len(c.Merges) == 0 {
+		fmt.Fprintf(&b, "None.\n")
+	} else {
+		fmt.Fprintf(&b, "| Merged | Stream | Intent | Merged by | Approvals | Checks |\n")
+		fmt.Fprintf(&b, "|---|---|---|---|---|---|\n")
+		for _, l := range c.Merges {
+			by := l.By
+			if l.Auto {
+				by = "auto-merge"
+			}
+			fmt.Fprintf(&b, "| %s | %s | `%s` %s | %s | %s | %s |\n", l.MergedAt.Format(time.RFC3339), cell(l.Stream),
+				short(l.IntentID), cell(l.Description), cell(by), cell(strings.Join(l.Approvals, ", ")), cell(checkList(l.Checks)))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Intents\n\n")
+	if len(c.Intents) == 0 {
+		fmt.Fprintf(&b, "None.\n")
+	} else {
+		fmt.Fprintf(&b, "| Created | Intent | Type | Author | Streams | Approvals | Checks |\n")
+		fmt.Fprintf(&b, "|---|---|---|---|---|---|---|\n")
+		for _, i := range c.Intents {
+			fmt.Fprintf(&b, "| %s | `%s` %s | %s | %s | %s | %s | %s |\n", i.CreatedAt.Format(time.RFC3339),
+				short(i.ID), cell(i.Description), cell(i.Type), cell(i.Author), cell(strings.Join(i.Streams, ", ")),
+				cell(strings.Join(i.Approvals, ", ")), cell(checkList(i.Checks)))
+		}
+	}
+
+	if s := c.Signature; s != nil {
+		fmt.Fprintf(&b, "\n## Signature\n\n")
+		fmt.Fprintf(&b, "- Algorithm: %s\n", s.Algorithm)
+		fmt.Fprintf(&b, "- Public key: `%s`\n", s.PublicKey)
+		fmt.Fprintf(&b, "- SHA-256 of the JSON report: `%s`\n", s.Digest)
+		fmt.Fprintf(&b, "- Signature: `%s`\n", s.Value)
+	}
+	return b.String()
+}
+
+func checkList(checks []intent.Check) string {
+	parts := make([]string, len(checks))
+	for n, c := range checks {
+		parts[n] = c.Name + " " + c.Status
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Signature is an Ed25519 signature over the SHA-256 digest of a report's
+// JSON encoding without the signature
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"` // Base64
+	Digest    string `json:"digest"`     // Hex
+	Value     string `json:"value"`      // Base64
+}
+
+// ErrBadSignature is returned when a report does not match its signature
+var ErrBadSignature = errors.New("report signature does not match")
+
+// digest hashes the report as it was before signing
+func (c *Compliance) digest() ([]byte, error) {
+	unsigned := *c
+	unsigned.Signature = nil
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("encoding report: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// Sign signs the report with key
+func (c *Compliance) Sign(key ed25519.PrivateKey) error {
+	sum, err := c.digest()
+	if err != nil {
+		return err
+	}
+	c.Signature = &Signature{
+		Algorithm: "ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Digest:    hex.EncodeToString(sum),
+		Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(key, sum)),
+	}
+	return nil
+}
+
+// Verify checks the report against its signature and returns the key
+// that signed it
+func (c *Compliance) Verify() (ed25519.PublicKey, error) {
+	s := c.Signature
+	if s == nil {
+		return nil, fmt.Errorf("%w: the report is not signed", ErrBadSignature)
+	}
+	if s.Algorithm != "ed25519" {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", s.Algorithm)
+	}
+	key, err := base64.StdEncoding.DecodeString(s.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key in signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(s.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature value")
+	}
+	sum, err := c.digest()
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(sum) != s.Digest || !ed25519.Verify(key, sum, sig) {
+		return nil, ErrBadSignature
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// SigningKeyPath is where the user's report signing key is kept
+func SigningKeyPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating user config directory: %w", err)
+	}
+	return filepath.Join(dir, "tig", "signing.key"), nil
+}
+
+// LoadSigningKey reads a base64 Ed25519 seed from path, generating and
+// saving a new key if the file does not exist
+func LoadSigningKey(path string) (ed25519.PrivateKey, bool, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, false, fmt.Errorf("%s is not a base64 Ed25519 seed", path)
+		}
+		return ed25519.NewKeyFromSeed(seed), false, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("reading signing key: %w", err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, false, fmt.Errorf("generating signing key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, false, fmt.Errorf("saving signing key: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(key.Seed()) + "\n"
+	if err := os.WriteFile(path, []byte(encoded), 0600); err != nil {
+		return nil, false, fmt.Errorf("saving signing key: %w", err)
+	}
+	return key, true, nil
+}
@@ -0,0 +1,81 @@
+// internal/report/compliance_test.go
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tig/internal/intent"
+	"tig/internal/merge"
+	"tig/internal/stream"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCompliance(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-24 * time.Hour)
+	protection := stream.Protection{RequiredReviewers: 1, RequiredChecks: []string{"ci"}}
+	passed := []intent.Check{{Name: "ci", Status: intent.CheckPassed}}
+
+	intents := []*intent.Intent{
+		{ID: "before", CreatedAt: since.Add(-time.Hour)},
+		{ID: "approved", Type: "feature", Description: "Add export", CreatedAt: now.Add(-3 * time.Hour),
+			Metadata: intent.Metadata{Author: "alice"}, Checks: passed,
+			Reviews: []intent.Review{{Reviewer: "bob", Approved: true}}},
+		{ID: "failing", Type: "fix", CreatedAt: now.Add(-2 * time.Hour),
+			Reviews: []intent.Review{{Reviewer: "bob", Approved: false}},
+			Checks:  []intent.Check{{Name: "ci", Status: intent.CheckFailed}}},
+		{ID: "unreviewed", Type: "chore", CreatedAt: now.Add(-time.Hour)},
+	}
+	streams := []*stream.Stream{{Name: "main", State: stream.State{Intents: []string{"approved", "failing"}}}}
+	landings := []merge.Landing{
+		{IntentID: "approved", Stream: "main", By: "carol", Protection: protection,
+			Approvals: []string{"bob"}, Checks: passed, MergedAt: now.Add(-30 * time.Minute)},
+		{IntentID: "sneaky", Stream: "main", Protection: stream.Protection{RequiredReviewers: 2},
+			Approvals: []string{"bob"}, MergedAt: now.Add(-20 * time.Minute)},
+		{IntentID: "feature", Stream: "scratch", By: "dave", MergedAt: now.Add(-10 * time.Minute)},
+		{IntentID: "old", Stream: "main", By: "carol", Protection: protection, MergedAt: since},
+	}
+
+	c := BuildCompliance(intents, streams, landings, since, now)
+	assert.Equal(t, ComplianceSummary{Intents: 3, Approved: 1, Unreviewed: 1, ChecksFail: 1, Merges: 2, Exceptions: 1}, c.Summary)
+	assert.Equal(t, "approved", c.Intents[0].ID)
+	assert.Equal(t, []string{"main"}, c.Intents[0].Streams)
+	require.Len(t, c.Exceptions, 1)
+	assert.Equal(t, "sneaky", c.Exceptions[0].IntentID)
+	assert.Equal(t, []string{"1 of 2 required approvals", "merged by an unrecorded user"}, c.Exceptions[0].Reasons)
+
+	// The signature survives a round trip through JSON and catches edits
+	key, created, err := LoadSigningKey(filepath.Join(t.TempDir(), "tig", "signing.key"))
+	require.NoError(t, err)
+	assert.True(t, created)
+	require.NoError(t, c.Sign(key))
+	data, err := json.Marshal(c)
+	require.NoError(t, err)
+
+	var decoded Compliance
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	signer, err := decoded.Verify()
+	require.NoError(t, err)
+	assert.Equal(t, key.Public(), signer)
+
+	decoded.Merges[0].By = "mallory"
+	_, err = decoded.Verify()
+	assert.ErrorIs(t, err, ErrBadSignature)
+
+	md := c.Markdown()
+	assert.Contains(t, md, "- Merges into protected streams: 2")
+	assert.Contains(t, md, "| carol | bob | ci passed |")
+	assert.Contains(t, md, c.Signature.Digest)
+
+	pdf := PDF("Compliance report", md+strings.Repeat("line\n", 200))
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.Contains(t, string(pdf), "/Count 4")
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+}
@@ -0,0 +1,208 @@
+// internal/report/contents.go
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/errors"
+	"tig/internal/intent"
+	"tig/internal/storage"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Contents lists what landed between two changesets, so deploy pipelines
+// can annotate a build with what it contains
+type Contents struct {
+	From       string          `json:"from"` // Changeset ID, excluded
+	To         string          `json:"to"`   // Changeset ID, included
+	Generated  time.Time       `json:"generated"`
+	ChangeSets []string        `json:"changesets"`
+	Intents    []ContentIntent `json:"intents"`
+	Files      []ContentFile   `json:"files"`
+	Authors    []string        `json:"authors"`
+	Breaking   []Breaking      `json:"breaking"`
+}
+
+// ContentIntent is an intent committed in the range
+type ContentIntent struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	Type        string    `json:"type"`
+	Author      string    `json:"author,omitempty"`
+	ChangeSetID string    `json:"changeset_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Breaking    bool      `json:"breaking"`
+}
+
+// ContentFile is a file changed in the range. Change is the type of its
+// latest change, e.g. "modify" or "delete".
+type ContentFile struct {
+	Path       string `json:"path"`
+	Change     string `json:"change"`
+	ChangeSets int    `json:"changesets"`
+}
+
+// IntentResolver looks up an intent by ID or prefix
+type IntentResolver func(ref string) (*intent.Intent, error)
+
+// ChangeSetOf returns the ID of the changeset ref names, either directly
+// or through the intent it committed
+func ChangeSetOf(txn *badger.Txn, ref string, intents IntentResolver) (string, error) {
+	if _, err := txn.Get([]byte("changeset:" + ref)); err == nil {
+		return ref, nil
+	} else if err != badger.ErrKeyNotFound {
+		return "", err
+	}
+	i, err := intents(ref)
+	if err != nil {
+		return "", fmt.Errorf("%w: no changeset or intent matches %s", storage.ErrNotFound, ref)
+	}
+	if i.ChangeSetID == "" {
+		return "", fmt.Errorf("%w: intent %s has no changeset", storage.ErrNotFound, i.ID)
+	}
+	return i.ChangeSetID, nil
+}
+
+// BuildContents collects the changesets created after from and up to and
+// including to, with the intents, files and authors they involve
+func BuildContents(txn *badger.Txn, from, to string, intents IntentResolver) (*Contents, error) {
+	start, err := change.GetChangeSet(txn, from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := change.GetChangeSet(txn, to)
+	if err != nil {
+		return nil, err
+	}
+	if start.CreatedAt.After(end.CreatedAt) {
+		return nil, errors.ValidationError(fmt.Sprintf("changeset %s is newer than %s", short(from), short(to)), nil)
+	}
+
+	var sets []*change.ChangeSet
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte("changeset:")
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		var cs change.ChangeSet
+		if err := it.Item().Value(func(val []byte) error {
+			return json.Unmarshal(val, &cs)
+		}); err != nil {
+			return nil, fmt.Errorf("decoding changeset: %w", err)
+		}
+		if cs.CreatedAt.After(start.CreatedAt) && !cs.CreatedAt.After(end.CreatedAt) {
+			sets = append(sets, &cs)
+		}
+	}
+	sort.Slice(sets, func(x, y int) bool { return sets[x].CreatedAt.Before(sets[y].CreatedAt) })
+
+	c := &Contents{
+		From:       from,
+		To:         to,
+		Generated:  time.Now(),
+		ChangeSets: []string{},
+		Intents:    []ContentIntent{},
+		Files:      []ContentFile{},
+		Breaking:   []Breaking{},
+	}
+	files := make(map[string]*ContentFile)
+	authors := make(map[string]bool)
+	for _, cs := range sets {
+		c.ChangeSets = append(c.ChangeSets, cs.ID)
+		if cs.Author != "" {
+			authors[cs.Author] = true
+		}
+		for _, ch := range cs.Changes {
+			f, ok := files[ch.Path]
+			if !ok {
+				f = &ContentFile{Path: ch.Path}
+				files[ch.Path] = f
+			}
+			f.Change = ch.Type
+			f.ChangeSets++
+		}
+
+		if cs.IntentID == "" {
+			continue
+		}
+		i, err := intents(cs.IntentID)
+		if err != nil {
+			// The intent was deleted; the changeset still counts
+			continue
+		}
+		if i.Metadata.Author != "" {
+			authors[i.Metadata.Author] = true
+		}
+		c.Intents = append(c.Intents, ContentIntent{
+			ID:          i.ID,
+			Description: i.Description,
+			Type:        i.Type,
+			Author:      i.Metadata.Author,
+			ChangeSetID: cs.ID,
+			CreatedAt:   cs.CreatedAt,
+			Breaking:    i.Impact.Breaking,
+		})
+		if i.Impact.Breaking {
+			c.Breaking = append(c.Breaking, Breaking{
+				IntentID:     i.ID,
+				Description:  i.Description,
+				Author:       i.Metadata.Author,
+				Scope:        nonNil(i.Impact.Scope),
+				Dependencies: nonNil(i.Impact.Dependencies),
+				CreatedAt:    i.CreatedAt,
+			})
+		}
+	}
+
+	for _, f := range files {
+		c.Files = append(c.Files, *f)
+	}
+	sort.Slice(c.Files, func(x, y int) bool { return c.Files[x].Path < c.Files[y].Path })
+	c.Authors = make([]string, 0, len(authors))
+	for a := range authors {
+		c.Authors = append(c.Authors, a)
+	}
+	sort.Strings(c.Authors)
+	return c, nil
+}
+
+// Markdown renders the contents for a build or deployment annotation
+func (c *Contents) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Build contents: %s..%s\n\n", short(c.From), short(c.To))
+	fmt.Fprintf(&b, "%d changeset(s), %d intent(s), %d file(s), %d breaking change(s).\n",
+		len(c.ChangeSets), len(c.Intents), len(c.Files), len(c.Breaking))
+	if len(c.Authors) > 0 {
+		fmt.Fprintf(&b, "Authors: %s.\n", strings.Join(c.Authors, ", "))
+	}
+
+	fmt.Fprintf(&b, "\n## Intents\n\n")
+	if len(c.Intents) == 0 {
+		fmt.Fprintf(&b, "None.\n")
+	} else {
+		fmt.Fprintf(&b, "| Intent | Type | Description | Author |\n")
+		fmt.Fprintf(&b, "|---|---|---|---|\n")
+		for _, i := range c.Intents {
+			desc := cell(i.Description)
+			if i.Breaking {
+				desc = "**Breaking:** " + desc
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", short(i.ID), cell(i.Type), desc, cell(i.Author))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Files\n\n")
+	if len(c.Files) == 0 {
+		fmt.Fprintf(&b, "None.\n")
+	}
+	for _, f := range c.Files {
+		fmt.Fprintf(&b, "- `%s` (%s)\n", f.Path, f.Change)
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+// internal/report/contents_test.go
+package report
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/intent"
+	"tig/internal/storage"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildContents(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	intents := map[string]*intent.Intent{
+		"i2": {ID: "i2", Type: "feature", Description: "Add search", ChangeSetID: "cs2",
+			Metadata: intent.Metadata{Author: "ana"}},
+		"i3": {ID: "i3", Type: "feature", Description: "Drop v1", ChangeSetID: "cs3",
+			Metadata: intent.Metadata{Author: "bo"}, Impact: intent.Impact{Breaking: true, Scope: []string{"api"}}},
+	}
+	resolve := func(ref string) (*intent.Intent, error) {
+		if i, ok := intents[ref]; ok {
+			return i, nil
+		}
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotFound, ref)
+	}
+
+	sets := []*change.ChangeSet{
+		{ID: "cs1", CreatedAt: now.Add(-3 * time.Hour), Author: "ana",
+			Changes: []shared.Change{{Path: "old.go", Type: "add"}}},
+		{ID: "cs2", IntentID: "i2", CreatedAt: now.Add(-2 * time.Hour), Author: "ana",
+			Changes: []shared.Change{{Path: "search.go", Type: "add"}, {Path: "api.go", Type: "modify"}}},
+		{ID: "cs3", IntentID: "i3", CreatedAt: now.Add(-time.Hour), Author: "bo",
+			Changes: []shared.Change{{Path: "api.go", Type: "modify"}}},
+		{ID: "cs4", IntentID: "gone", CreatedAt: now, Author: "cy",
+			Changes: []shared.Change{{Path: "api.go", Type: "delete"}}},
+	}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		for _, cs := range sets {
+			if err := change.PutChangeSet(txn, cs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		to, err := ChangeSetOf(txn, "i3", resolve)
+		require.NoError(t, err)
+		assert.Equal(t, "cs3", to)
+		_, err = ChangeSetOf(txn, "missing", resolve)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		c, err := BuildContents(txn, "cs1", to, resolve)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cs2", "cs3"}, c.ChangeSets)
+		require.Len(t, c.Intents, 2)
+		assert.Equal(t, "i2", c.Intents[0].ID)
+		assert.Equal(t, []ContentFile{{Path: "api.go", Change: "modify", ChangeSets: 2}, {Path: "search.go", Change: "add", ChangeSets: 1}}, c.Files)
+		assert.Equal(t, []string{"ana", "bo"}, c.Authors)
+		require.Len(t, c.Breaking, 1)
+		assert.Equal(t, "i3", c.Breaking[0].IntentID)
+		assert.Contains(t, c.Markdown(), "**Breaking:** Drop v1")
+
+		// Changesets whose intent was deleted still contribute files
+		c, err = BuildContents(txn, "cs3", "cs4", resolve)
+		require.NoError(t, err)
+		assert.Empty(t, c.Intents)
+		assert.Equal(t, "delete", c.Files[0].Change)
+		assert.Equal(t, []string{"cy"}, c.Authors)
+
+		_, err = BuildContents(txn, "cs4", "cs1", resolve)
+		assert.Error(t, err)
+		return nil
+	}))
+}
@@ -0,0 +1,133 @@
+// internal/report/hotspots.go
+package report
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/incident"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Hotspots ranks paths by the incidents caused by changes to them
+type Hotspots struct {
+	Generated  time.Time `json:"generated"`
+	Incidents  int       `json:"incidents"` // Incidents linked to a cause
+	Paths      []Hotspot `json:"paths"`
+	Unresolved []string  `json:"unresolved"` // Causal intents without a changeset
+}
+
+// Hotspot is a path changed by intents that caused incidents
+type Hotspot struct {
+	Path      string   `json:"path"`
+	Incidents []string `json:"incidents"`
+	Intents   []string `json:"intents"` // The causal intents that changed it
+}
+
+// BuildHotspots ranks the paths changed by the causal intents of links,
+// most incidents first, then most causal intents. A limit above zero
+// keeps only the top paths.
+func BuildHotspots(txn *badger.Txn, links []incident.Link, intents IntentResolver, limit int) (*Hotspots, error) {
+	r := &Hotspots{Generated: time.Now(), Paths: []Hotspot{}, Unresolved: []string{}}
+
+	byIntent := make(map[string][]string)
+	var order []string
+	seen := make(map[string]bool)
+	for _, l := range links {
+		if _, ok := byIntent[l.IntentID]; !ok {
+			order = append(order, l.IntentID)
+		}
+		byIntent[l.IntentID] = append(byIntent[l.IntentID], l.IncidentID)
+		seen[l.IncidentID] = true
+	}
+	r.Incidents = len(seen)
+	sort.Strings(order)
+
+	incidents := make(map[string]map[string]bool)
+	causes := make(map[string][]string)
+	for _, id := range order {
+		i, err := intents(id)
+		if err != nil || i.ChangeSetID == "" {
+			r.Unresolved = append(r.Unresolved, id)
+			continue
+		}
+		cs, err := change.GetChangeSet(txn, i.ChangeSetID)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			r.Unresolved = append(r.Unresolved, id)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading changeset of intent %s: %w", id, err)
+		}
+		for _, c := range cs.Changes {
+			path := filepath.ToSlash(c.Path)
+			if incidents[path] == nil {
+				incidents[path] = make(map[string]bool)
+			}
+			for _, inc := range byIntent[id] {
+				incidents[path][inc] = true
+			}
+			if n := len(causes[path]); n == 0 || causes[path][n-1] != id {
+				causes[path] = append(causes[path], id)
+			}
+		}
+	}
+
+	for path, incs := range incidents {
+		r.Paths = append(r.Paths, Hotspot{Path: path, Incidents: sortedKeys(incs), Intents: causes[path]})
+	}
+	sort.Slice(r.Paths, func(x, y int) bool {
+		a, b := r.Paths[x], r.Paths[y]
+		if len(a.Incidents) != len(b.Incidents) {
+			return len(a.Incidents) > len(b.Incidents)
+		}
+		if len(a.Intents) != len(b.Intents) {
+			return len(a.Intents) > len(b.Intents)
+		}
+		return a.Path < b.Path
+	})
+	if limit > 0 && len(r.Paths) > limit {
+		r.Paths = r.Paths[:limit]
+	}
+	return r, nil
+}
+
+// Markdown renders the ranking as a table
+func (r *Hotspots) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Incident hotspots\n\n")
+	fmt.Fprintf(&b, "Generated %s from %d incident(s).\n\n", r.Generated.Format("2006-01-02 15:04"), r.Incidents)
+
+	if len(r.Paths) == 0 {
+		b.WriteString("No incidents are linked to changes yet. Link them with `tig incident link`.\n")
+	} else {
+		b.WriteString("| Path | Incidents | Causing intents |\n|---|---|---|\n")
+		for _, h := range r.Paths {
+			ids := make([]string, len(h.Intents))
+			for n, id := range h.Intents {
+				ids[n] = "`" + short(id) + "`"
+			}
+			fmt.Fprintf(&b, "| `%s` | %d (%s) | %s |\n", cell(h.Path), len(h.Incidents), cell(strings.Join(h.Incidents, ", ")), strings.Join(ids, ", "))
+		}
+	}
+
+	if len(r.Unresolved) > 0 {
+		fmt.Fprintf(&b, "\nCausal intents without a changeset, not ranked: %s\n", strings.Join(r.Unresolved, ", "))
+	}
+	return b.String()
+}
+
+func sortedKeys(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
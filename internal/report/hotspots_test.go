@@ -0,0 +1,74 @@
+// internal/report/hotspots_test.go
+package report
+
+import (
+	"fmt"
+	"testing"
+
+	"tig/internal/change"
+	"tig/internal/incident"
+	"tig/internal/intent"
+	"tig/internal/storage"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildHotspots(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	intents := map[string]*intent.Intent{
+		"i1": {ID: "i1", ChangeSetID: "cs1"},
+		"i2": {ID: "i2", ChangeSetID: "cs2"},
+		"i3": {ID: "i3"},
+	}
+	resolve := func(ref string) (*intent.Intent, error) {
+		if i, ok := intents[ref]; ok {
+			return i, nil
+		}
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotFound, ref)
+	}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		if err := change.PutChangeSet(txn, &change.ChangeSet{ID: "cs1",
+			Changes: []shared.Change{{Path: "api.go", Type: "modify"}, {Path: "db.go", Type: "modify"}}}); err != nil {
+			return err
+		}
+		return change.PutChangeSet(txn, &change.ChangeSet{ID: "cs2",
+			Changes: []shared.Change{{Path: "api.go", Type: "modify"}, {Path: "ui.go", Type: "add"}}})
+	}))
+
+	links := []incident.Link{
+		{IncidentID: "INC-1", IntentID: "i1"},
+		{IncidentID: "INC-2", IntentID: "i1"},
+		{IncidentID: "INC-3", IntentID: "i2"},
+		{IncidentID: "INC-4", IntentID: "i3"},
+		{IncidentID: "INC-5", IntentID: "gone"},
+	}
+	var r *Hotspots
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		r, err = BuildHotspots(txn, links, resolve, 0)
+		return err
+	}))
+
+	assert.Equal(t, 5, r.Incidents)
+	assert.Equal(t, []Hotspot{
+		{Path: "api.go", Incidents: []string{"INC-1", "INC-2", "INC-3"}, Intents: []string{"i1", "i2"}},
+		{Path: "db.go", Incidents: []string{"INC-1", "INC-2"}, Intents: []string{"i1"}},
+		{Path: "ui.go", Incidents: []string{"INC-3"}, Intents: []string{"i2"}},
+	}, r.Paths)
+	assert.Equal(t, []string{"gone", "i3"}, r.Unresolved)
+	assert.Contains(t, r.Markdown(), "| `api.go` | 3 (INC-1, INC-2, INC-3) | `i1`, `i2` |")
+
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		r, err = BuildHotspots(txn, links, resolve, 1)
+		return err
+	}))
+	require.Len(t, r.Paths, 1)
+	assert.Equal(t, "api.go", r.Paths[0].Path)
+}
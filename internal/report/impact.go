@@ -0,0 +1,236 @@
+// internal/report/impact.go
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/intent"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Impact summarizes what a set of intents declared about their impact,
+// for release readiness reviews
+type Impact struct {
+	Stream       string         `json:"stream"`
+	Since        string         `json:"since,omitempty"`
+	SinceTime    *time.Time     `json:"since_time,omitempty"`
+	Generated    time.Time      `json:"generated"`
+	Intents      int            `json:"intents"`
+	Types        map[string]int `json:"types"`
+	Scopes       []Scope        `json:"scopes"`
+	Breaking     []Breaking     `json:"breaking"`
+	Dependencies []string       `json:"dependencies"` // Impacted by any intent
+	Unscoped     []string       `json:"unscoped"`     // IDs of intents that declare no scope
+}
+
+// Scope counts the intents affecting a component
+type Scope struct {
+	Name    string   `json:"name"`
+	Intents []string `json:"intents"`
+}
+
+// Breaking is an intent marked as a breaking change
+type Breaking struct {
+	IntentID     string    `json:"intent_id"`
+	Description  string    `json:"description"`
+	Author       string    `json:"author,omitempty"`
+	Scope        []string  `json:"scope"`
+	Dependencies []string  `json:"dependencies"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// BuildImpact aggregates the impact of intents created after since. A zero
+// since includes every intent.
+func BuildImpact(stream string, intents []*intent.Intent, since time.Time) *Impact {
+	r := &Impact{
+		Stream:       stream,
+		Generated:    time.Now(),
+		Types:        make(map[string]int),
+		Scopes:       []Scope{},
+		Breaking:     []Breaking{},
+		Dependencies: []string{},
+		Unscoped:     []string{},
+	}
+
+	if !since.IsZero() {
+		r.SinceTime = &since
+	}
+
+	sort.Slice(intents, func(x, y int) bool { return intents[x].CreatedAt.Before(intents[y].CreatedAt) })
+	scopes := make(map[string][]string)
+	deps := make(map[string]bool)
+	for _, i := range intents {
+		if !since.IsZero() && !i.CreatedAt.After(since) {
+			continue
+		}
+		r.Intents++
+		r.Types[i.Type]++
+
+		if len(i.Impact.Scope) == 0 {
+			r.Unscoped = append(r.Unscoped, i.ID)
+		}
+		for _, s := range unique(i.Impact.Scope) {
+			scopes[s] = append(scopes[s], i.ID)
+		}
+		for _, d := range i.Impact.Dependencies {
+			deps[d] = true
+		}
+		if i.Impact.Breaking {
+			r.Breaking = append(r.Breaking, Breaking{
+				IntentID:     i.ID,
+				Description:  i.Description,
+				Author:       i.Metadata.Author,
+				Scope:        nonNil(i.Impact.Scope),
+				Dependencies: nonNil(i.Impact.Dependencies),
+				CreatedAt:    i.CreatedAt,
+			})
+		}
+	}
+
+	for name, ids := range scopes {
+		r.Scopes = append(r.Scopes, Scope{Name: name, Intents: ids})
+	}
+	// Most affected components first
+	sort.Slice(r.Scopes, func(x, y int) bool {
+		if len(r.Scopes[x].Intents) != len(r.Scopes[y].Intents) {
+			return len(r.Scopes[x].Intents) > len(r.Scopes[y].Intents)
+		}
+		return r.Scopes[x].Name < r.Scopes[y].Name
+	})
+	for d := range deps {
+		r.Dependencies = append(r.Dependencies, d)
+	}
+	sort.Strings(r.Dependencies)
+	return r
+}
+
+// Markdown renders the report for pasting into release notes or a
+// readiness review
+func (r *Impact) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Impact report: %s\n\n", r.Stream)
+	if r.Since != "" && r.SinceTime != nil {
+		fmt.Fprintf(&b, "Intents since `%s` (%s). ", r.Since, r.SinceTime.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "Generated %s.\n\n", r.Generated.Format(time.RFC3339))
+
+	fmt.Fprintf(&b, "## Summary\n\n")
+	fmt.Fprintf(&b, "- Intents: %d\n", r.Intents)
+	fmt.Fprintf(&b, "- Breaking changes: %d\n", len(r.Breaking))
+	if len(r.Types) > 0 {
+		types := make([]string, 0, len(r.Types))
+		for t := range r.Types {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		for n, t := range types {
+			types[n] = fmt.Sprintf("%s %d", orDash(t), r.Types[t])
+		}
+		fmt.Fprintf(&b, "- Types: %s\n", strings.Join(types, ", "))
+	}
+	if len(r.Dependencies) > 0 {
+		fmt.Fprintf(&b, "- Impacted dependencies: %s\n", strings.Join(r.Dependencies, ", "))
+	}
+
+	fmt.Fprintf(&b, "\n## Breaking changes\n\n")
+	if len(r.Breaking) == 0 {
+		fmt.Fprintf(&b, "None.\n")
+	} else {
+		fmt.Fprintf(&b, "| Intent | Description | Scope | Affected dependencies |\n")
+		fmt.Fprintf(&b, "|---|---|---|---|\n")
+		for _, br := range r.Breaking {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", short(br.IntentID), cell(br.Description),
+				cell(strings.Join(br.Scope, ", ")), cell(strings.Join(br.Dependencies, ", ")))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Scope\n\n")
+	if len(r.Scopes) == 0 {
+		fmt.Fprintf(&b, "No intents declare a scope.\n")
+	} else {
+		fmt.Fprintf(&b, "| Component | Intents |\n")
+		fmt.Fprintf(&b, "|---|---|\n")
+		for _, s := range r.Scopes {
+			ids := make([]string, len(s.Intents))
+			for n, id := range s.Intents {
+				ids[n] = "`" + short(id) + "`"
+			}
+			fmt.Fprintf(&b, "| %s | %d (%s) |\n", cell(s.Name), len(s.Intents), strings.Join(ids, ", "))
+		}
+	}
+	if len(r.Unscoped) > 0 {
+		fmt.Fprintf(&b, "\n%d intent(s) declare no scope.\n", len(r.Unscoped))
+	}
+	return b.String()
+}
+
+// TagTime returns when the newest changeset carrying tag was created
+func TagTime(txn *badger.Txn, tag string) (time.Time, bool, error) {
+	var latest time.Time
+	found := false
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte("changeset:")
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		var cs struct {
+			Tags      []string  `json:"tags"`
+			CreatedAt time.Time `json:"created_at"`
+		}
+		if err := it.Item().Value(func(val []byte) error {
+			return json.Unmarshal(val, &cs)
+		}); err != nil {
+			return time.Time{}, false, fmt.Errorf("decoding changeset: %w", err)
+		}
+		for _, t := range cs.Tags {
+			if t == tag && (!found || cs.CreatedAt.After(latest)) {
+				latest, found = cs.CreatedAt, true
+			}
+		}
+	}
+	return latest, found, nil
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func short(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// cell escapes a value for a Markdown table cell
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return orDash(strings.ReplaceAll(s, "\n", " "))
+}
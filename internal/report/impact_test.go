@@ -0,0 +1,74 @@
+// internal/report/impact_test.go
+package report
+
+import (
+	"testing"
+	"time"
+
+	"tig/internal/intent"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildImpact(t *testing.T) {
+	now := time.Now()
+	intents := []*intent.Intent{
+		{ID: "old", Type: "feature", CreatedAt: now.Add(-2 * time.Hour),
+			Impact: intent.Impact{Scope: []string{"api"}, Breaking: true}},
+		{ID: "a", Type: "feature", Description: "Drop v1 | endpoints", CreatedAt: now.Add(-30 * time.Minute),
+			Impact: intent.Impact{Scope: []string{"api", "cli"}, Breaking: true, Dependencies: []string{"sdk-go"}}},
+		{ID: "b", Type: "fix", CreatedAt: now.Add(-20 * time.Minute),
+			Impact: intent.Impact{Scope: []string{"api", "api"}, Dependencies: []string{"dashboard"}}},
+		{ID: "c", Type: "fix", CreatedAt: now.Add(-10 * time.Minute)},
+	}
+
+	r := BuildImpact("release", intents, now.Add(-time.Hour))
+	assert.Equal(t, 3, r.Intents)
+	assert.Equal(t, map[string]int{"feature": 1, "fix": 2}, r.Types)
+	require.Len(t, r.Scopes, 2)
+	assert.Equal(t, Scope{Name: "api", Intents: []string{"a", "b"}}, r.Scopes[0])
+	assert.Equal(t, "cli", r.Scopes[1].Name)
+	require.Len(t, r.Breaking, 1)
+	assert.Equal(t, "a", r.Breaking[0].IntentID)
+	assert.Equal(t, []string{"sdk-go"}, r.Breaking[0].Dependencies)
+	assert.Equal(t, []string{"dashboard", "sdk-go"}, r.Dependencies)
+	assert.Equal(t, []string{"c"}, r.Unscoped)
+
+	md := r.Markdown()
+	assert.Contains(t, md, "# Impact report: release")
+	assert.Contains(t, md, "| `a` | Drop v1 \\| endpoints | api, cli | sdk-go |")
+	assert.Contains(t, md, "| api | 2 (`a`, `b`) |")
+	assert.Contains(t, md, "1 intent(s) declare no scope.")
+
+	assert.Equal(t, 4, BuildImpact("release", intents, time.Time{}).Intents)
+}
+
+func TestTagTime(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte("changeset:1"), []byte(`{"tags":["v1.0"],"created_at":"2026-02-01T12:00:00Z"}`)); err != nil {
+			return err
+		}
+		return txn.Set([]byte("changeset:2"), []byte(`{"tags":["rc","v1.0"],"created_at":"2026-03-01T12:00:00Z"}`))
+	}))
+
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		got, ok, err := TagTime(txn, "v1.0")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.True(t, at.Equal(got))
+
+		_, ok, err = TagTime(txn, "v2.0")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		return nil
+	}))
+}
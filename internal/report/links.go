@@ -0,0 +1,113 @@
+// internal/report/links.go
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"tig/internal/intent"
+	"tig/internal/remote"
+)
+
+// LinkedImpact combines an intent's impact with that of the intents it
+// links to in other repositories, for changes spanning several services
+type LinkedImpact struct {
+	Intents      []LinkedIntent `json:"intents"` // The intent itself first, then its links
+	Breaking     bool           `json:"breaking"`
+	Scopes       []string       `json:"scopes"`
+	Dependencies []string       `json:"dependencies"`
+	Unresolved   int            `json:"unresolved"` // Links that could not be fetched
+}
+
+// LinkedIntent is one intent of a linked change
+type LinkedIntent struct {
+	Repo         string   `json:"repo,omitempty"` // Empty for the local intent
+	IntentID     string   `json:"intent_id"`
+	Description  string   `json:"description,omitempty"`
+	Type         string   `json:"type,omitempty"`
+	Breaking     bool     `json:"breaking"`
+	Scope        []string `json:"scope"`
+	Dependencies []string `json:"dependencies"`
+	Error        string   `json:"error,omitempty"` // Why a link could not be resolved
+}
+
+// BuildLinkedImpact combines an intent with its resolved links
+func BuildLinkedImpact(i *intent.Intent, links []remote.ResolvedLink) *LinkedImpact {
+	r := &LinkedImpact{Scopes: []string{}, Dependencies: []string{}}
+	scopes := make(map[string]bool)
+	deps := make(map[string]bool)
+	add := func(repo string, i *intent.Intent) {
+		r.Intents = append(r.Intents, LinkedIntent{
+			Repo:         repo,
+			IntentID:     i.ID,
+			Description:  i.Description,
+			Type:         i.Type,
+			Breaking:     i.Impact.Breaking,
+			Scope:        nonNil(i.Impact.Scope),
+			Dependencies: nonNil(i.Impact.Dependencies),
+		})
+		r.Breaking = r.Breaking || i.Impact.Breaking
+		for _, s := range i.Impact.Scope {
+			scopes[s] = true
+		}
+		for _, d := range i.Impact.Dependencies {
+			deps[d] = true
+		}
+	}
+
+	add("", i)
+	for _, l := range links {
+		if l.Intent == nil {
+			r.Intents = append(r.Intents, LinkedIntent{Repo: l.Repo, IntentID: l.IntentID,
+				Scope: []string{}, Dependencies: []string{}, Error: l.Error})
+			r.Unresolved++
+			continue
+		}
+		add(l.Repo, l.Intent)
+	}
+
+	for s := range scopes {
+		r.Scopes = append(r.Scopes, s)
+	}
+	sort.Strings(r.Scopes)
+	for d := range deps {
+		r.Dependencies = append(r.Dependencies, d)
+	}
+	sort.Strings(r.Dependencies)
+	return r
+}
+
+// Markdown renders the combined impact as a table of the linked intents
+// followed by what they affect together
+func (r *LinkedImpact) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Linked impact: %s\n\n", cell(r.Intents[0].Description))
+	fmt.Fprintf(&b, "| Repository | Intent | Description | Breaking | Scope |\n")
+	fmt.Fprintf(&b, "|---|---|---|---|---|\n")
+	for _, i := range r.Intents {
+		repo := i.Repo
+		if repo == "" {
+			repo = "(this repository)"
+		}
+		if i.Error != "" {
+			fmt.Fprintf(&b, "| %s | `%s` | unresolved: %s | - | - |\n", cell(repo), short(i.IntentID), cell(i.Error))
+			continue
+		}
+		breaking := "no"
+		if i.Breaking {
+			breaking = "yes"
+		}
+		fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s |\n", cell(repo), short(i.IntentID), cell(i.Description),
+			breaking, cell(strings.Join(i.Scope, ", ")))
+	}
+
+	fmt.Fprintf(&b, "\n## Combined\n\n")
+	fmt.Fprintf(&b, "- Breaking: %v\n", r.Breaking)
+	fmt.Fprintf(&b, "- Scope: %s\n", orDash(strings.Join(r.Scopes, ", ")))
+	fmt.Fprintf(&b, "- Impacted dependencies: %s\n", orDash(strings.Join(r.Dependencies, ", ")))
+	if r.Unresolved > 0 {
+		fmt.Fprintf(&b, "\n%d link(s) could not be resolved; the combined impact may be incomplete.\n", r.Unresolved)
+	}
+	return b.String()
+}
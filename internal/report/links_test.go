@@ -0,0 +1,35 @@
+// internal/report/links_test.go
+package report
+
+import (
+	"testing"
+
+	"tig/internal/intent"
+	"tig/internal/remote"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildLinkedImpact(t *testing.T) {
+	local := &intent.Intent{ID: "local1", Description: "Call the new billing API",
+		Impact: intent.Impact{Scope: []string{"checkout"}, Dependencies: []string{"billing-sdk"}}}
+	links := []remote.ResolvedLink{
+		{Link: intent.Link{Repo: "https://billing.example.com", IntentID: "b1"},
+			Intent: &intent.Intent{ID: "b1", Description: "Add billing API",
+				Impact: intent.Impact{Scope: []string{"api", "checkout"}, Breaking: true}}},
+		{Link: intent.Link{Repo: "https://gone.example.com", IntentID: "g1"}, Error: "contacting remote: refused"},
+	}
+
+	r := BuildLinkedImpact(local, links)
+	assert.Len(t, r.Intents, 3)
+	assert.True(t, r.Breaking)
+	assert.Equal(t, []string{"api", "checkout"}, r.Scopes)
+	assert.Equal(t, []string{"billing-sdk"}, r.Dependencies)
+	assert.Equal(t, 1, r.Unresolved)
+
+	md := r.Markdown()
+	assert.Contains(t, md, "| (this repository) | `local1` | Call the new billing API | no | checkout |")
+	assert.Contains(t, md, "| https://billing.example.com | `b1` | Add billing API | yes | api, checkout |")
+	assert.Contains(t, md, "unresolved: contacting remote: refused")
+	assert.Contains(t, md, "1 link(s) could not be resolved")
+}
@@ -0,0 +1,113 @@
+// internal/report/pdf.go
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page layout of PDF reports: A4 in points, monospaced 8pt text
+const (
+	pdfWidth     = 595
+	pdfHeight    = 842
+	pdfMargin    = 40
+	pdfFontSize  = 8
+	pdfLeading   = 10
+	pdfLineChars = (pdfWidth - 2*pdfMargin) * 10 / (pdfFontSize * 6) // Courier glyphs are 0.6em wide
+)
+
+// PDF lays text out as a plain monospaced PDF document, wrapping long
+// lines, so reports can be filed where Markdown is not accepted
+func PDF(title, text string) []byte {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		lines = append(lines, wrap(pdfText(line), pdfLineChars)...)
+	}
+	perPage := (pdfHeight - 2*pdfMargin) / pdfLeading
+	var pages [][]string
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects: 1 catalog, 2 page tree, 3 font, 4 info, then a page and
+	// its content stream per page
+	var objects []string
+	kids := make([]string, len(pages))
+	for n := range pages {
+		kids[n] = fmt.Sprintf("%d 0 R", 5+2*n)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (%s) /Producer (tig) >>", pdfEscape(pdfText(title))),
+	)
+	for n, page := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfHeight-pdfMargin-pdfFontSize)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfEscape(line))
+		}
+		fmt.Fprintf(&content, "ET\nBT /F1 %d Tf %d %d Td (Page %d of %d) Tj ET\n", pdfFontSize, pdfWidth-pdfMargin-60, pdfMargin/2, n+1, len(pages))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfWidth, pdfHeight, 6+2*n),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for n, obj := range objects {
+		offsets[n] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", n+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// pdfText maps text to the Latin-1 range the standard fonts cover
+func pdfText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 0x20 || r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
+
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
+
+// wrap splits a line into pieces of at most width bytes
+func wrap(line string, width int) []string {
+	if line == "" {
+		return []string{""}
+	}
+	var out []string
+	for len(line) > width {
+		cut := strings.LastIndexByte(line[:width], ' ')
+		if cut <= 0 {
+			cut = width
+		}
+		out = append(out, line[:cut])
+		line = "  " + strings.TrimLeft(line[cut:], " ")
+	}
+	return append(out, line)
+}
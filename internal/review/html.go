@@ -0,0 +1,38 @@
+// internal/review/html.go
+package review
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"tig/internal/highlight"
+)
+
+//go:embed bundle.html.tmpl
+var bundleTemplate string
+
+var page = template.Must(template.New("bundle").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"json": func(v any) string {
+		data, _ := json.Marshal(v)
+		return string(data)
+	},
+	"css":  highlight.CSS,
+	"date": func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+	"short": func(id string) string {
+		if len(id) > 8 {
+			return id[:8]
+		}
+		return id
+	},
+}).Parse(bundleTemplate))
+
+// Render writes the bundle as a standalone HTML page with its styles
+// inlined, so it can be opened without a server
+func Render(w io.Writer, b *Bundle) error {
+	return page.Execute(w, b)
+}
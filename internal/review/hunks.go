@@ -0,0 +1,205 @@
+// internal/review/hunks.go
+package review
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"tig/internal/change"
+	"tig/internal/diff"
+	"tig/internal/intent"
+	"tig/internal/safe"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Line kinds of a hunk
+const (
+	LineContext = "context"
+	LineAdd     = "add"
+	LineDelete  = "delete"
+)
+
+// Patch is the diff of one changed file as hunks, for clients that lay
+// out diffs themselves
+type Patch struct {
+	Path      string `json:"path"`
+	Type      string `json:"type"`
+	OldPath   string `json:"old_path,omitempty"`
+	Binary    bool   `json:"binary,omitempty"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Hunks     []Hunk `json:"hunks"`
+	Unified   string `json:"unified,omitempty"` // The hunks as unified diff text, when asked for
+
+	// Structural holds the key-level changes of a JSON or YAML file
+	// diffed structurally, in place of hunks
+	Structural []diff.KeyChange `json:"structural,omitempty"`
+	// Image summarizes a changed PNG or JPEG file
+	Image *diff.ImageSummary `json:"image,omitempty"`
+}
+
+// Hunk is a run of changes with up to ContextLines unchanged lines around
+// it. Changes whose context would overlap share a hunk. Starts follow
+// unified diff headers: with no lines on a side, the start is the line
+// before.
+type Hunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Lines    []HunkLine `json:"lines"`
+}
+
+// HunkLine is one line of a hunk. Line numbers are 1-based and omitted
+// for the side the line is not on.
+type HunkLine struct {
+	Kind    string `json:"kind"`
+	OldNum  int    `json:"old_num,omitempty"`
+	NewNum  int    `json:"new_num,omitempty"`
+	Content string `json:"content"`
+}
+
+// Patches diffs every file changed by an intent into hunks, adding the
+// unified text of each when unified is set and diffing other files as
+// opts choose
+func Patches(db *badger.DB, s *safe.Safe, i *intent.Intent, unified bool, opts Options) ([]Patch, error) {
+	patches := []Patch{}
+	if i.ChangeSetID == "" {
+		return patches, nil
+	}
+	var cs *change.ChangeSet
+	err := db.View(func(txn *badger.Txn) error {
+		var err error
+		cs, err = change.GetChangeSet(txn, i.ChangeSetID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	engine := diff.NewEngine(ContextLines)
+	for _, c := range cs.Changes {
+		p := Patch{Path: c.Path, Type: c.Type, OldPath: c.OldPath, Hunks: []Hunk{}}
+		oldContent, newContent, err := contents(s, c)
+		if err != nil {
+			return nil, err
+		}
+		if binary(oldContent, newContent) {
+			p.Binary = true
+			p.Image = imageSummary(c, oldContent, newContent)
+		} else if changes, ok := keyChanges(opts, c.Path, oldContent, newContent); ok {
+			p.Structural = changes
+			p.Additions, p.Deletions = countKeyChanges(changes)
+		} else {
+			if o, n, ok := notebookSources(opts, c.Path, oldContent, newContent); ok {
+				oldContent, newContent = o, n
+			}
+			p.Hunks = hunks(engine.Align(oldContent, newContent))
+			for _, h := range p.Hunks {
+				for _, l := range h.Lines {
+					switch l.Kind {
+					case LineAdd:
+						p.Additions++
+					case LineDelete:
+						p.Deletions++
+					}
+				}
+			}
+			if unified && len(p.Hunks) > 0 {
+				p.Unified = Unified(p)
+			}
+		}
+		patches = append(patches, p)
+	}
+	sort.Slice(patches, func(x, y int) bool { return patches[x].Path < patches[y].Path })
+	return patches, nil
+}
+
+// hunks groups aligned lines into hunks, keeping ContextLines unchanged
+// lines around each change
+func hunks(lines []diff.Line) []Hunk {
+	show := make([]bool, len(lines))
+	for n, l := range lines {
+		if l.Type == diff.Context {
+			continue
+		}
+		for k := max(0, n-ContextLines); k <= min(len(lines)-1, n+ContextLines); k++ {
+			show[k] = true
+		}
+	}
+
+	var result []Hunk
+	var cur *Hunk
+	oldPos, newPos := 0, 0 // Lines of each side before the current one
+	for n, l := range lines {
+		if !show[n] {
+			cur = nil
+		} else {
+			if cur == nil {
+				result = append(result, Hunk{OldStart: oldPos, NewStart: newPos})
+				cur = &result[len(result)-1]
+			}
+			hl := HunkLine{Content: l.Content, OldNum: l.OldNum, NewNum: l.NewNum}
+			switch l.Type {
+			case diff.Context:
+				hl.Kind = LineContext
+				cur.OldLines++
+				cur.NewLines++
+			case diff.Addition:
+				hl.Kind = LineAdd
+				cur.NewLines++
+			case diff.Deletion:
+				hl.Kind = LineDelete
+				cur.OldLines++
+			}
+			cur.Lines = append(cur.Lines, hl)
+		}
+		if l.Type != diff.Addition {
+			oldPos++
+		}
+		if l.Type != diff.Deletion {
+			newPos++
+		}
+	}
+	for n := range result {
+		if result[n].OldLines > 0 {
+			result[n].OldStart++
+		}
+		if result[n].NewLines > 0 {
+			result[n].NewStart++
+		}
+	}
+	return result
+}
+
+// Unified renders a patch as unified diff text
+func Unified(p Patch) string {
+	var b strings.Builder
+	oldPath, newPath := "a/"+p.Path, "b/"+p.Path
+	if p.OldPath != "" {
+		oldPath = "a/" + p.OldPath
+	}
+	switch p.Type {
+	case "add":
+		oldPath = "/dev/null"
+	case "delete":
+		newPath = "/dev/null"
+	}
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldPath, newPath)
+	for _, h := range p.Hunks {
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+		for _, l := range h.Lines {
+			marker := " "
+			switch l.Kind {
+			case LineAdd:
+				marker = "+"
+			case LineDelete:
+				marker = "-"
+			}
+			b.WriteString(marker + l.Content + "\n")
+		}
+	}
+	return b.String()
+}
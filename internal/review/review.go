@@ -0,0 +1,329 @@
+// internal/review/review.go
+package review
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/diff"
+	"tig/internal/highlight"
+	"tig/internal/intent"
+	"tig/internal/safe"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ContextLines is how many unchanged lines are shown around each change
+const ContextLines = 3
+
+// Bundle is everything a review page shows for one intent
+type Bundle struct {
+	Intent    *intent.Intent
+	ChangeSet *change.ChangeSet
+	Files     []File
+	Impact    Impact
+	Generated time.Time
+}
+
+// Impact combines what the author declared with what the changes touch
+type Impact struct {
+	intent.Impact
+	Files     int
+	Additions int
+	Deletions int
+	Areas     []string // Top-level directories touched, "." for the root
+}
+
+// File is the side-by-side diff of one changed file
+type File struct {
+	Path      string `json:"path"`
+	Type      string `json:"type"`               // add, modify, delete, rename, copy
+	OldPath   string `json:"old_path,omitempty"` // Path before a rename, or copied from
+	Language  string `json:"language,omitempty"`
+	Binary    bool   `json:"binary,omitempty"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Rows      []Row  `json:"rows"`
+
+	// Structural holds the key-level changes of a JSON or YAML file
+	// diffed structurally, in place of rows
+	Structural []diff.KeyChange `json:"structural,omitempty"`
+	// Image summarizes a changed PNG or JPEG file
+	Image *diff.ImageSummary `json:"image,omitempty"`
+}
+
+// Structural selects the files diffed key by key instead of line by line
+type Structural func(path string) bool
+
+// Options chooses how files other than plain text are diffed
+type Options struct {
+	Structural      Structural // JSON and YAML files diffed key by key; nil for none
+	NotebookOutputs bool       // show output changes of Jupyter notebooks, not only their sources
+}
+
+// keyChanges diffs a change structurally if opts select it and both
+// sides parse. ok is false when the file should be diffed by line.
+func keyChanges(opts Options, p string, oldContent, newContent []byte) (changes []diff.KeyChange, ok bool) {
+	format := diff.StructuredFormat(p)
+	if opts.Structural == nil || format == "" || !opts.Structural(p) {
+		return nil, false
+	}
+	changes, err := diff.Structural(format, oldContent, newContent)
+	if err != nil {
+		return nil, false
+	}
+	if changes == nil {
+		changes = []diff.KeyChange{}
+	}
+	return changes, true
+}
+
+// notebookSources renders both sides of a Jupyter notebook change as
+// cell sources, and outputs if opts ask for them. ok is false for other
+// files and notebooks that do not parse.
+func notebookSources(opts Options, p string, oldContent, newContent []byte) (oldText, newText []byte, ok bool) {
+	if !diff.IsNotebook(p) {
+		return nil, nil, false
+	}
+	oldText, err := diff.Notebook(oldContent, opts.NotebookOutputs)
+	if err != nil {
+		return nil, nil, false
+	}
+	newText, err = diff.Notebook(newContent, opts.NotebookOutputs)
+	if err != nil {
+		return nil, nil, false
+	}
+	return oldText, newText, true
+}
+
+// countKeyChanges counts added and changed keys as additions and removed
+// and changed keys as deletions
+func countKeyChanges(changes []diff.KeyChange) (additions, deletions int) {
+	for _, c := range changes {
+		if c.Kind != diff.KeyRemoved {
+			additions++
+		}
+		if c.Kind != diff.KeyAdded {
+			deletions++
+		}
+	}
+	return additions, deletions
+}
+
+// Row kinds
+const (
+	RowContext = "context"
+	RowChange  = "change" // Old and new sides differ; either may be empty
+	RowSkip    = "skip"   // Unchanged lines left out
+)
+
+// Row is one line of a side-by-side diff. Old and New are highlighted
+// HTML.
+type Row struct {
+	Kind    string        `json:"kind"`
+	OldNum  int           `json:"old_num,omitempty"`
+	NewNum  int           `json:"new_num,omitempty"`
+	Old     template.HTML `json:"old,omitempty"`
+	New     template.HTML `json:"new,omitempty"`
+	Skipped int           `json:"skipped,omitempty"` // Lines left out by a skip row
+}
+
+// Build loads an intent's changeset and diffs every file against the
+// content it replaced, highlighting code with h and diffing other files
+// as opts choose
+func Build(db *badger.DB, s *safe.Safe, h *highlight.Highlighter, i *intent.Intent, opts Options) (*Bundle, error) {
+	b := &Bundle{Intent: i, Generated: time.Now()}
+	b.Impact.Impact = i.Impact
+	if i.ChangeSetID == "" {
+		return b, nil
+	}
+
+	err := db.View(func(txn *badger.Txn) error {
+		var err error
+		b.ChangeSet, err = change.GetChangeSet(txn, i.ChangeSetID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	engine := diff.NewEngine(ContextLines)
+	areas := make(map[string]bool)
+	for _, c := range b.ChangeSet.Changes {
+		f, err := buildFile(engine, s, h, c, opts)
+		if err != nil {
+			return nil, err
+		}
+		b.Files = append(b.Files, f)
+		b.Impact.Additions += f.Additions
+		b.Impact.Deletions += f.Deletions
+		areas[area(c.Path)] = true
+	}
+	sort.Slice(b.Files, func(x, y int) bool { return b.Files[x].Path < b.Files[y].Path })
+
+	b.Impact.Files = len(b.Files)
+	for a := range areas {
+		b.Impact.Areas = append(b.Impact.Areas, a)
+	}
+	sort.Strings(b.Impact.Areas)
+	return b, nil
+}
+
+func buildFile(engine *diff.Engine, s *safe.Safe, h *highlight.Highlighter, c shared.Change, opts Options) (File, error) {
+	f := File{Path: c.Path, Type: c.Type, OldPath: c.OldPath, Language: highlight.Language(c.Path)}
+
+	oldContent, newContent, err := contents(s, c)
+	if err != nil {
+		return f, err
+	}
+	if binary(oldContent, newContent) {
+		f.Binary = true
+		f.Image = imageSummary(c, oldContent, newContent)
+		return f, nil
+	}
+	if changes, ok := keyChanges(opts, c.Path, oldContent, newContent); ok {
+		f.Structural = changes
+		f.Additions, f.Deletions = countKeyChanges(changes)
+		return f, nil
+	}
+	highlightPath := c.Path
+	if o, n, ok := notebookSources(opts, c.Path, oldContent, newContent); ok {
+		oldContent, newContent = o, n
+		highlightPath, f.Language = "", ""
+	}
+
+	lines := engine.Align(oldContent, newContent)
+	for _, l := range lines {
+		switch l.Type {
+		case diff.Addition:
+			f.Additions++
+		case diff.Deletion:
+			f.Deletions++
+		}
+	}
+	f.Rows = sideBySide(lines, h.HTML(highlightPath, oldContent), h.HTML(highlightPath, newContent))
+	return f, nil
+}
+
+// contents loads the content a change replaced and the content it wrote
+func contents(s *safe.Safe, c shared.Change) (oldContent, newContent []byte, err error) {
+	if c.OldHash != "" {
+		if oldContent, err = s.Get(c.OldHash); err != nil {
+			return nil, nil, fmt.Errorf("loading previous content of %s: %w", c.Path, err)
+		}
+	}
+	if c.Type != "delete" && c.NewHash != "" {
+		if newContent, err = s.Get(c.NewHash); err != nil {
+			return nil, nil, fmt.Errorf("loading content of %s: %w", c.Path, err)
+		}
+	}
+	return oldContent, newContent, nil
+}
+
+// imageSummary summarizes a change to a PNG or JPEG file, or returns nil
+// for other files
+func imageSummary(c shared.Change, oldContent, newContent []byte) *diff.ImageSummary {
+	if !diff.IsImage(c.Path) {
+		return nil
+	}
+	summary, err := diff.Image(oldContent, newContent)
+	if err != nil {
+		return nil
+	}
+	if summary.Old != nil {
+		summary.Old.Hash = c.OldHash
+	}
+	if summary.New != nil {
+		summary.New.Hash = c.NewHash
+	}
+	return summary
+}
+
+// binary reports whether either side of a change holds binary content
+func binary(oldContent, newContent []byte) bool {
+	return diff.IsBinary(oldContent) || diff.IsBinary(newContent)
+}
+
+// sideBySide pairs deletions with the additions that follow them and
+// leaves out unchanged lines far from any change. oldHTML and newHTML
+// hold the highlighted lines of each side.
+func sideBySide(lines []diff.Line, oldHTML, newHTML []template.HTML) []Row {
+	// Mark the context lines worth showing
+	show := make([]bool, len(lines))
+	for n, l := range lines {
+		if l.Type == diff.Context {
+			continue
+		}
+		for k := max(0, n-ContextLines); k <= min(len(lines)-1, n+ContextLines); k++ {
+			show[k] = true
+		}
+	}
+
+	var rows []Row
+	skipped := 0
+	flushSkip := func() {
+		if skipped > 0 {
+			rows = append(rows, Row{Kind: RowSkip, Skipped: skipped})
+			skipped = 0
+		}
+	}
+
+	for n := 0; n < len(lines); {
+		l := lines[n]
+		if l.Type == diff.Context {
+			if !show[n] {
+				skipped++
+				n++
+				continue
+			}
+			flushSkip()
+			rows = append(rows, Row{
+				Kind:   RowContext,
+				OldNum: l.OldNum,
+				NewNum: l.NewNum,
+				Old:    oldHTML[l.OldNum-1],
+				New:    newHTML[l.NewNum-1],
+			})
+			n++
+			continue
+		}
+
+		flushSkip()
+		var dels, adds []diff.Line
+		for ; n < len(lines) && lines[n].Type == diff.Deletion; n++ {
+			dels = append(dels, lines[n])
+		}
+		for ; n < len(lines) && lines[n].Type == diff.Addition; n++ {
+			adds = append(adds, lines[n])
+		}
+		for k := 0; k < max(len(dels), len(adds)); k++ {
+			row := Row{Kind: RowChange}
+			if k < len(dels) {
+				row.OldNum = dels[k].OldNum
+				row.Old = oldHTML[dels[k].OldNum-1]
+			}
+			if k < len(adds) {
+				row.NewNum = adds[k].NewNum
+				row.New = newHTML[adds[k].NewNum-1]
+			}
+			rows = append(rows, row)
+		}
+	}
+	flushSkip()
+	return rows
+}
+
+// area returns the top-level directory of a path
+func area(p string) string {
+	p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	if i := strings.Index(p, "/"); i >= 0 {
+		return p[:i]
+	}
+	return "."
+}
@@ -0,0 +1,260 @@
+// internal/review/review_test.go
+package review
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"tig/internal/change"
+	"tig/internal/diff"
+	"tig/internal/highlight"
+	"tig/internal/intent"
+	"tig/internal/safe"
+	"tig/shared/types"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAndRender(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	oldHash, err := s.Store([]byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"))
+	require.NoError(t, err)
+	newHash, err := s.Store([]byte("package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"))
+	require.NoError(t, err)
+	imgHash, err := s.Store([]byte{0x89, 'P', 'N', 'G', 0})
+	require.NoError(t, err)
+
+	cs := &change.ChangeSet{ID: "cs1", Changes: []shared.Change{
+		{Path: "cmd/main.go", Type: "modify", OldHash: oldHash, NewHash: newHash},
+		{Path: "logo.png", Type: "add", NewHash: imgHash},
+	}}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return change.PutChangeSet(txn, cs)
+	}))
+
+	i := &intent.Intent{ID: "i1", Type: "fix", Description: "Greet properly", ChangeSetID: "cs1",
+		Impact: intent.Impact{Breaking: true}}
+	b, err := Build(db, s, highlight.New(true), i, Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, b.Impact.Files)
+	assert.Equal(t, []string{".", "cmd"}, b.Impact.Areas)
+	require.Len(t, b.Files, 2)
+
+	f := b.Files[0]
+	assert.Equal(t, "Go", f.Language)
+	assert.Equal(t, 1, f.Additions)
+	assert.Equal(t, 1, f.Deletions)
+	// The changed line is paired side by side
+	var changed []Row
+	for _, r := range f.Rows {
+		if r.Kind == RowChange {
+			changed = append(changed, r)
+		}
+	}
+	require.Len(t, changed, 1)
+	assert.Equal(t, 4, changed[0].OldNum)
+	assert.Equal(t, 4, changed[0].NewNum)
+	assert.True(t, b.Files[1].Binary)
+	assert.Nil(t, b.Files[1].Image) // not a decodable PNG
+
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, b))
+	page := buf.String()
+	assert.Contains(t, page, "Greet properly")
+	assert.Contains(t, page, "breaking change")
+	assert.Contains(t, page, `<span class="kd">func</span> <span class="nf">main</span>`)
+	assert.Contains(t, page, "Binary file not shown")
+}
+
+func TestPatches(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	oldHash, err := s.Store([]byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"))
+	require.NoError(t, err)
+	newHash, err := s.Store([]byte("1\nTWO\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"))
+	require.NoError(t, err)
+	addHash, err := s.Store([]byte("new\n"))
+	require.NoError(t, err)
+
+	cs := &change.ChangeSet{ID: "cs1", Changes: []shared.Change{
+		{Path: "n.txt", Type: "modify", OldHash: oldHash, NewHash: newHash},
+		{Path: "a.txt", Type: "add", NewHash: addHash},
+	}}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return change.PutChangeSet(txn, cs)
+	}))
+
+	patches, err := Patches(db, s, &intent.Intent{ID: "i1", ChangeSetID: "cs1"}, true, Options{})
+	require.NoError(t, err)
+	require.Len(t, patches, 2)
+
+	assert.Equal(t, "--- /dev/null\n+++ b/a.txt\n@@ -0,0 +1,1 @@\n+new\n", patches[0].Unified)
+
+	p := patches[1]
+	assert.Equal(t, 2, p.Additions)
+	assert.Equal(t, 1, p.Deletions)
+	// Changes far apart get their own hunks
+	require.Len(t, p.Hunks, 2)
+	assert.Equal(t, Hunk{OldStart: 1, OldLines: 5, NewStart: 1, NewLines: 5}, Hunk{
+		OldStart: p.Hunks[0].OldStart, OldLines: p.Hunks[0].OldLines,
+		NewStart: p.Hunks[0].NewStart, NewLines: p.Hunks[0].NewLines,
+	})
+	assert.Equal(t, HunkLine{Kind: LineDelete, OldNum: 2, Content: "2"}, p.Hunks[0].Lines[1])
+	assert.Equal(t, HunkLine{Kind: LineAdd, NewNum: 13, Content: "13"}, p.Hunks[1].Lines[3])
+	assert.Contains(t, p.Unified, "@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n")
+
+	patches, err = Patches(db, s, &intent.Intent{ID: "i2"}, false, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, patches)
+}
+
+func TestStructuralPatches(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	oldHash, err := s.Store([]byte(`{"name": "app", "port": 80}`))
+	require.NoError(t, err)
+	newHash, err := s.Store([]byte("{\n  \"port\": 8080,\n  \"name\": \"app\"\n}\n"))
+	require.NoError(t, err)
+
+	cs := &change.ChangeSet{ID: "cs1", Changes: []shared.Change{
+		{Path: "config.json", Type: "modify", OldHash: oldHash, NewHash: newHash},
+	}}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return change.PutChangeSet(txn, cs)
+	}))
+	i := &intent.Intent{ID: "i1", ChangeSetID: "cs1"}
+
+	patches, err := Patches(db, s, i, false, Options{Structural: func(string) bool { return true }})
+	require.NoError(t, err)
+	require.Len(t, patches, 1)
+	assert.Empty(t, patches[0].Hunks)
+	assert.Equal(t, []diff.KeyChange{{Path: "$.port", Kind: diff.KeyModified, Old: float64(80), New: float64(8080)}}, patches[0].Structural)
+	assert.Equal(t, 1, patches[0].Additions)
+	assert.Equal(t, 1, patches[0].Deletions)
+
+	// Without a selector the reformatting shows as line changes
+	patches, err = Patches(db, s, i, false, Options{})
+	require.NoError(t, err)
+	assert.Nil(t, patches[0].Structural)
+	assert.NotEmpty(t, patches[0].Hunks)
+
+	b, err := Build(db, s, highlight.New(false), i, Options{Structural: func(string) bool { return true }})
+	require.NoError(t, err)
+	require.Len(t, b.Files, 1)
+	assert.Empty(t, b.Files[0].Rows)
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, b))
+	assert.Contains(t, buf.String(), "$.port")
+}
+
+func TestImagePatches(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 30, 20))))
+	hash, err := s.Store(buf.Bytes())
+	require.NoError(t, err)
+
+	cs := &change.ChangeSet{ID: "cs1", Changes: []shared.Change{
+		{Path: "logo.png", Type: "add", NewHash: hash},
+	}}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return change.PutChangeSet(txn, cs)
+	}))
+
+	patches, err := Patches(db, s, &intent.Intent{ID: "i1", ChangeSetID: "cs1"}, false, Options{})
+	require.NoError(t, err)
+	require.Len(t, patches, 1)
+	assert.True(t, patches[0].Binary)
+	require.NotNil(t, patches[0].Image)
+	assert.Nil(t, patches[0].Image.Old)
+	assert.Equal(t, &diff.ImageInfo{Format: "png", Width: 30, Height: 20, Size: int64(buf.Len()), Hash: hash}, patches[0].Image.New)
+}
+
+func TestNotebookPatches(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	oldHash, err := s.Store([]byte(`{"cells": [{"cell_type": "code", "execution_count": 1, "source": ["x = 1\n", "x"],
+  "outputs": [{"output_type": "execute_result", "execution_count": 1, "data": {"text/plain": ["1"]}}]}]}`))
+	require.NoError(t, err)
+	newHash, err := s.Store([]byte(`{"cells": [{"cell_type": "code", "execution_count": 7, "source": ["x = 2\n", "x"],
+  "outputs": [{"output_type": "execute_result", "execution_count": 7, "data": {"text/plain": ["2"]}}]}]}`))
+	require.NoError(t, err)
+
+	cs := &change.ChangeSet{ID: "cs1", Changes: []shared.Change{
+		{Path: "analysis.ipynb", Type: "modify", OldHash: oldHash, NewHash: newHash},
+	}}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return change.PutChangeSet(txn, cs)
+	}))
+	i := &intent.Intent{ID: "i1", ChangeSetID: "cs1"}
+
+	patches, err := Patches(db, s, i, false, Options{})
+	require.NoError(t, err)
+	require.Len(t, patches, 1)
+	assert.Equal(t, 1, patches[0].Additions)
+	assert.Equal(t, 1, patches[0].Deletions)
+	var changed []string
+	for _, l := range patches[0].Hunks[0].Lines {
+		if l.Kind != LineContext {
+			changed = append(changed, l.Kind+" "+l.Content)
+		}
+	}
+	assert.Equal(t, []string{"delete x = 1", "add x = 2"}, changed)
+
+	// Outputs are diffed only when asked for
+	patches, err = Patches(db, s, i, false, Options{NotebookOutputs: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, patches[0].Additions)
+
+	b, err := Build(db, s, highlight.New(true), i, Options{})
+	require.NoError(t, err)
+	require.Len(t, b.Files, 1)
+	assert.Equal(t, 1, b.Files[0].Additions)
+}
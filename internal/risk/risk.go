@@ -0,0 +1,253 @@
+// internal/risk/risk.go
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/incident"
+	"tig/internal/intent"
+	"tig/internal/safe"
+	"tig/internal/stats"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Risk levels, by score
+const (
+	LevelLow    = "low"    // below 30
+	LevelMedium = "medium" // 30 to 59
+	LevelHigh   = "high"   // 60 and above
+)
+
+// Weights of the signals in a score out of 100
+const (
+	weightLines      = 25
+	weightFiles      = 15
+	weightBreaking   = 20
+	weightDefects    = 20
+	weightUnfamiliar = 20
+)
+
+// Sizes at which the size signals count in full
+const (
+	fullLines = 1000
+	fullFiles = 20
+)
+
+// Signals are what a risk score is made of
+type Signals struct {
+	LinesChanged int  `json:"lines_changed"`
+	FilesTouched int  `json:"files_touched"`
+	Breaking     bool `json:"breaking"`
+	// Share of earlier intents touching the same files that were fixes or
+	// caused incidents
+	DefectDensity float64 `json:"defect_density"`
+	// Share of the files changed before that the author had changed too;
+	// 1 when none of the files has earlier changes
+	AuthorFamiliarity float64 `json:"author_familiarity"`
+}
+
+// Score rates how much review attention an intent needs, from 0 to 100
+type Score struct {
+	IntentID    string   `json:"intent_id"`
+	Description string   `json:"description"`
+	Score       int      `json:"score"`
+	Level       string   `json:"level"`
+	Signals     Signals  `json:"signals"`
+	Reasons     []string `json:"reasons,omitempty"` // The signals that raised the score most
+}
+
+// touch is an intent's changes, as far as history is concerned
+type touch struct {
+	id     string
+	defect bool // A fix, or the cause of an incident
+	author string
+	at     time.Time
+	paths  []string
+}
+
+// Scorer scores intents against the history of the intents given to it
+type Scorer struct {
+	db      *badger.DB
+	safe    *safe.Safe
+	touches map[string]touch
+	history []touch // Oldest first
+}
+
+// NewScorer loads the files every intent with a changeset touched, and
+// the incidents linked to their causes
+func NewScorer(db *badger.DB, s *safe.Safe, intents []*intent.Intent) (*Scorer, error) {
+	causes, err := incident.Causes(db)
+	if err != nil {
+		return nil, err
+	}
+	sc := &Scorer{db: db, safe: s, touches: make(map[string]touch)}
+	err = db.View(func(txn *badger.Txn) error {
+		for _, i := range intents {
+			if i.ChangeSetID == "" {
+				continue
+			}
+			cs, err := change.GetChangeSet(txn, i.ChangeSetID)
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("reading changeset of intent %s: %w", i.ID, err)
+			}
+			t := touch{id: i.ID, defect: i.Type == "fix" || len(causes[i.ID]) > 0, author: i.Metadata.Author, at: i.CreatedAt}
+			if t.author == "" {
+				t.author = cs.Author
+			}
+			for _, c := range cs.Changes {
+				t.paths = append(t.paths, filepath.ToSlash(c.Path))
+			}
+			sc.touches[i.ID] = t
+			sc.history = append(sc.history, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(sc.history, func(x, y int) bool { return sc.history[x].at.Before(sc.history[y].at) })
+	return sc, nil
+}
+
+// Score rates an intent against the intents created before it
+func (sc *Scorer) Score(i *intent.Intent) (*Score, error) {
+	s := &Score{IntentID: i.ID, Description: i.Description}
+	s.Signals.Breaking = i.Impact.Breaking
+	s.Signals.AuthorFamiliarity = 1
+
+	t, ok := sc.touches[i.ID]
+	if ok {
+		_, lines, err := stats.ChangeSetLines(sc.db, sc.safe, i.ChangeSetID)
+		if err != nil {
+			return nil, err
+		}
+		s.Signals.LinesChanged = lines.Added + lines.Deleted
+		s.Signals.FilesTouched = len(t.paths)
+		s.Signals.DefectDensity, s.Signals.AuthorFamiliarity = sc.past(t)
+	}
+
+	var reasons []reason
+	add := func(weight float64, text string) {
+		if weight > 0 {
+			reasons = append(reasons, reason{weight, text})
+		}
+	}
+	add(weightLines*ratio(math.Log1p(float64(s.Signals.LinesChanged)), math.Log1p(fullLines)),
+		fmt.Sprintf("%d lines changed", s.Signals.LinesChanged))
+	add(weightFiles*ratio(float64(s.Signals.FilesTouched), fullFiles),
+		fmt.Sprintf("%d files touched", s.Signals.FilesTouched))
+	if s.Signals.Breaking {
+		add(weightBreaking, "breaking change")
+	}
+	add(weightDefects*s.Signals.DefectDensity,
+		fmt.Sprintf("%.0f%% of earlier changes to these files were fixes or caused incidents", 100*s.Signals.DefectDensity))
+	add(weightUnfamiliar*(1-s.Signals.AuthorFamiliarity),
+		fmt.Sprintf("author changed %.0f%% of these files before", 100*s.Signals.AuthorFamiliarity))
+
+	var total float64
+	for _, r := range reasons {
+		total += r.weight
+	}
+	s.Score = int(math.Round(total))
+	s.Level = Level(s.Score)
+
+	// Explain with the signals that count for a fifth of their weight or
+	// more, heaviest first
+	sort.SliceStable(reasons, func(x, y int) bool { return reasons[x].weight > reasons[y].weight })
+	for _, r := range reasons {
+		if r.weight >= 4 {
+			s.Reasons = append(s.Reasons, r.text)
+		}
+	}
+	return s, nil
+}
+
+// ScoreAll scores intents, riskiest first
+func (sc *Scorer) ScoreAll(intents []*intent.Intent) ([]*Score, error) {
+	scores := make([]*Score, 0, len(intents))
+	for _, i := range intents {
+		s, err := sc.Score(i)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, s)
+	}
+	sort.SliceStable(scores, func(x, y int) bool { return scores[x].Score > scores[y].Score })
+	return scores, nil
+}
+
+// Level names the band a score falls in
+func Level(score int) string {
+	switch {
+	case score >= 60:
+		return LevelHigh
+	case score >= 30:
+		return LevelMedium
+	}
+	return LevelLow
+}
+
+type reason struct {
+	weight float64
+	text   string
+}
+
+// past measures the history of the files an intent touched: the share of
+// earlier intents touching them that were defects, and the share of those
+// with earlier changes that the intent's author had changed
+func (sc *Scorer) past(t touch) (density, familiarity float64) {
+	touched := make(map[string]bool, len(t.paths))
+	for _, p := range t.paths {
+		touched[p] = true
+	}
+
+	var changes, fixes int
+	changed := make(map[string]bool)
+	byAuthor := make(map[string]bool)
+	for _, h := range sc.history {
+		if h.id == t.id || !h.at.Before(t.at) {
+			continue
+		}
+		counted := false
+		for _, p := range h.paths {
+			if !touched[p] {
+				continue
+			}
+			changed[p] = true
+			if t.author != "" && h.author == t.author {
+				byAuthor[p] = true
+			}
+			if !counted {
+				counted = true
+				changes++
+				if h.defect {
+					fixes++
+				}
+			}
+		}
+	}
+
+	if changes > 0 {
+		density = float64(fixes) / float64(changes)
+	}
+	familiarity = 1
+	if len(changed) > 0 {
+		familiarity = float64(len(byAuthor)) / float64(len(changed))
+	}
+	return density, familiarity
+}
+
+// ratio is n/full, at most 1
+func ratio(n, full float64) float64 {
+	return math.Min(n/full, 1)
+}
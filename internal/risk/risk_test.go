@@ -0,0 +1,107 @@
+// internal/risk/risk_test.go
+package risk
+
+import (
+	"testing"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/incident"
+	"tig/internal/intent"
+	"tig/internal/safe"
+	"tig/shared/types"
+	tigtest "tig/testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScore(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	hashes, err := s.StoreBatch([][]byte{[]byte("x\n"), []byte("y\n"), []byte("one\ntwo\nthree\n")})
+	require.NoError(t, err)
+	at := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	changesets := []*change.ChangeSet{
+		{ID: "cs1", CreatedAt: at, Changes: []shared.Change{{Path: "a.go", Type: "add", NewHash: hashes[0]}}},
+		{ID: "cs2", CreatedAt: at.Add(time.Hour), Changes: []shared.Change{{Path: "a.go", Type: "modify", OldHash: hashes[0], NewHash: hashes[1]}}},
+		{ID: "cs3", CreatedAt: at.Add(2 * time.Hour), Changes: []shared.Change{
+			{Path: "a.go", Type: "modify", OldHash: hashes[1], NewHash: hashes[0]},
+			{Path: "b.go", Type: "add", NewHash: hashes[2]},
+		}},
+	}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		for _, cs := range changesets {
+			if err := change.PutChangeSet(txn, cs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	intents := []*intent.Intent{
+		{ID: "i1", Type: "fix", Description: "fix a", ChangeSetID: "cs1", Metadata: intent.Metadata{Author: "alice"}, CreatedAt: at},
+		{ID: "i2", Type: "feature", Description: "extend a", ChangeSetID: "cs2", Metadata: intent.Metadata{Author: "alice"}, CreatedAt: at.Add(time.Hour)},
+		{ID: "i3", Type: "feature", Description: "rework a, add b", ChangeSetID: "cs3", Metadata: intent.Metadata{Author: "bob"}, CreatedAt: at.Add(2 * time.Hour),
+			Impact: intent.Impact{Breaking: true}},
+		{ID: "i4", Type: "refactor", Description: "no changes yet", CreatedAt: at.Add(3 * time.Hour)},
+	}
+	box := tigtest.NewIntentBox()
+	for _, i := range intents {
+		require.NoError(t, box.Create(i))
+	}
+	sc, err := NewScorer(db, s, intents)
+	require.NoError(t, err)
+
+	// Half the earlier changes to a.go were fixes, and bob never touched it
+	s3, err := sc.Score(intents[2])
+	require.NoError(t, err)
+	assert.Equal(t, Signals{LinesChanged: 5, FilesTouched: 2, Breaking: true, DefectDensity: 0.5, AuthorFamiliarity: 0}, s3.Signals)
+	assert.Equal(t, 58, s3.Score)
+	assert.Equal(t, LevelMedium, s3.Level)
+	assert.Equal(t, []string{
+		"breaking change",
+		"author changed 0% of these files before",
+		"50% of earlier changes to these files were fixes or caused incidents",
+		"5 lines changed",
+	}, s3.Reasons)
+
+	// alice already changed a.go
+	s2, err := sc.Score(intents[1])
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, s2.Signals.AuthorFamiliarity)
+	assert.Equal(t, 1.0, s2.Signals.DefectDensity)
+	assert.Equal(t, 25, s2.Score)
+	assert.Equal(t, LevelLow, s2.Level)
+
+	s4, err := sc.Score(intents[3])
+	require.NoError(t, err)
+	assert.Equal(t, 0, s4.Score)
+	assert.Equal(t, LevelLow, s4.Level)
+	assert.Empty(t, s4.Reasons)
+
+	all, err := sc.ScoreAll(intents)
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	assert.Equal(t, "i3", all[0].IntentID)
+	assert.Equal(t, "i4", all[3].IntentID)
+
+	// Once i2 is known to have caused an incident, every earlier change
+	// to a.go counts as a defect
+	require.NoError(t, incident.Add(db, box, &incident.Link{IncidentID: "INC-7", IntentID: "i2"}))
+	sc, err = NewScorer(db, s, intents)
+	require.NoError(t, err)
+	s3, err = sc.Score(intents[2])
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, s3.Signals.DefectDensity)
+	assert.Equal(t, 68, s3.Score)
+	assert.Equal(t, LevelHigh, s3.Level)
+}
@@ -0,0 +1,136 @@
+// internal/safe/access.go
+package safe
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"tig/internal/storage"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Access time defaults. Reads only record an access time once the stored
+// one is older than the drift, and recorded times are written in batches.
+const (
+	DefaultAccessFlushInterval = time.Minute
+	DefaultAccessDrift         = time.Hour
+)
+
+// accessTracker batches AccessedAt updates so reads do not turn into
+// metadata writes
+type accessTracker struct {
+	mu       sync.Mutex
+	pending  map[string]time.Time
+	interval time.Duration
+	drift    time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newAccessTracker(interval, drift time.Duration) *accessTracker {
+	return &accessTracker{
+		pending:  make(map[string]time.Time),
+		interval: interval,
+		drift:    drift,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// touch records that meta was read. The metadata is written straight away
+// only when batching is disabled.
+func (s *Safe) touch(meta ContentMeta) error {
+	now := time.Now()
+	if now.Sub(meta.AccessedAt) < s.access.drift {
+		return nil
+	}
+	if s.access.interval < 0 {
+		meta.AccessedAt = now
+		return s.storeMeta(meta)
+	}
+
+	s.access.mu.Lock()
+	s.access.pending[meta.Hash] = now
+	s.access.mu.Unlock()
+	return nil
+}
+
+// flushLoop writes batched access times until Close is called
+func (s *Safe) flushLoop() {
+	defer close(s.access.done)
+
+	ticker := time.NewTicker(s.access.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.FlushAccessTimes()
+		case <-s.access.stop:
+			return
+		}
+	}
+}
+
+// FlushAccessTimes writes batched access times in a single transaction.
+// Content deleted since it was read is skipped.
+func (s *Safe) FlushAccessTimes() error {
+	s.access.mu.Lock()
+	pending := s.access.pending
+	s.access.pending = make(map[string]time.Time)
+	s.access.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := storage.Update(s.db, func(txn *badger.Txn) error {
+		for hash, at := range pending {
+			key := []byte(fmt.Sprintf("content:%s", hash))
+			item, err := txn.Get(key)
+			if err == badger.ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			var meta ContentMeta
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &meta)
+			}); err != nil {
+				return err
+			}
+			if !meta.AccessedAt.Before(at) {
+				continue
+			}
+			meta.AccessedAt = at
+			data, err := json.Marshal(meta)
+			if err != nil {
+				return err
+			}
+			if err := txn.Set(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("flushing access times: %w", err)
+	}
+	return nil
+}
+
+// Close stops the background access time flusher and writes whatever it
+// still holds. It must be called before the database is closed.
+func (s *Safe) Close() error {
+	s.access.once.Do(func() {
+		if s.access.interval > 0 {
+			close(s.access.stop)
+			<-s.access.done
+		}
+	})
+	return s.FlushAccessTimes()
+}
@@ -0,0 +1,138 @@
+// internal/safe/batch.go
+package safe
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BatchOptions configures parallel batch operations
+type BatchOptions struct {
+	// Number of concurrent workers; defaults to the number of CPUs
+	Workers int
+	// Fail the whole batch (rolling back stores) if any item fails
+	Atomic bool
+	// Totals of what stores read and wrote are added here when set
+	Stats *StoreStats
+}
+
+// StoreStats totals what stores read and wrote. Content already in the
+// safe counts towards logical bytes but writes nothing.
+type StoreStats struct {
+	Items        int   `json:"items"`
+	New          int   `json:"new"`        // Items not already in the safe
+	Compressed   int   `json:"compressed"` // New items written compressed
+	LogicalBytes int64 `json:"logical_bytes"`
+	StoredBytes  int64 `json:"stored_bytes"`
+}
+
+// Add accumulates o into s
+func (s *StoreStats) Add(o StoreStats) {
+	s.Items += o.Items
+	s.New += o.New
+	s.Compressed += o.Compressed
+	s.LogicalBytes += o.LogicalBytes
+	s.StoredBytes += o.StoredBytes
+}
+
+// Saved is the fraction of logical bytes that did not need writing,
+// through deduplication and compression
+func (s StoreStats) Saved() float64 {
+	if s.LogicalBytes == 0 {
+		return 0
+	}
+	return 1 - float64(s.StoredBytes)/float64(s.LogicalBytes)
+}
+
+func (o BatchOptions) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// BatchError aggregates per-item failures of a batch operation, keyed by
+// the item's index in the input slice
+type BatchError struct {
+	mu     sync.Mutex
+	Errors map[int]error
+}
+
+func (e *BatchError) add(i int, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if prev, ok := e.Errors[i]; ok {
+		err = errors.Join(prev, err)
+	}
+	e.Errors[i] = err
+}
+
+// Failed returns the indexes of failed items in ascending order
+func (e *BatchError) Failed() []int {
+	idx := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+	return idx
+}
+
+func (e *BatchError) Error() string {
+	failed := e.Failed()
+	msgs := make([]string, 0, min(len(failed), 3))
+	for _, i := range failed[:min(len(failed), 3)] {
+		msgs = append(msgs, fmt.Sprintf("item %d: %v", i, e.Errors[i]))
+	}
+	if len(failed) > 3 {
+		msgs = append(msgs, fmt.Sprintf("and %d more", len(failed)-3))
+	}
+	return fmt.Sprintf("%d of batch failed: %s", len(failed), strings.Join(msgs, "; "))
+}
+
+// Unwrap exposes the individual item errors to errors.Is and errors.As
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, i := range e.Failed() {
+		errs = append(errs, e.Errors[i])
+	}
+	return errs
+}
+
+// runBatch calls fn for every index in [0, n) across a pool of workers
+// and returns the aggregated failures, or nil if every item succeeded
+func runBatch(n, workers int, fn func(i int) error) *BatchError {
+	if workers > n {
+		workers = n
+	}
+
+	batchErr := &BatchError{Errors: make(map[int]error)}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := fn(i); err != nil {
+					batchErr.add(i, err)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(batchErr.Errors) == 0 {
+		return nil
+	}
+	return batchErr
+}
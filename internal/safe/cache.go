@@ -0,0 +1,83 @@
+// internal/safe/cache.go
+package safe
+
+import (
+	"sync/atomic"
+
+	"tig/internal/metrics"
+	"tig/internal/storage"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+var (
+	cacheHitsTotal   = metrics.NewCounter("tig_safe_cache_hits_total", "Safe reads served from the content cache")
+	cacheMissesTotal = metrics.NewCounter("tig_safe_cache_misses_total", "Safe reads that missed the content cache")
+)
+
+// contentCache is the safe's LRU of decoded content. A cache without an
+// LRU is disabled: it stores nothing and every lookup misses.
+type contentCache struct {
+	lru    *lru.Cache[string, []byte]
+	size   int
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// newContentCache creates a cache holding up to size entries; a size of
+// zero disables it
+func newContentCache(size int) (*contentCache, error) {
+	c := &contentCache{size: size}
+	if size <= 0 {
+		return c, nil
+	}
+	l, err := lru.New[string, []byte](size)
+	if err != nil {
+		return nil, err
+	}
+	c.lru = l
+	return c, nil
+}
+
+func (c *contentCache) Get(hash string) ([]byte, bool) {
+	var content []byte
+	ok := false
+	if c.lru != nil {
+		content, ok = c.lru.Get(hash)
+	}
+	if ok {
+		c.hits.Add(1)
+		cacheHitsTotal.Inc()
+	} else {
+		c.misses.Add(1)
+		cacheMissesTotal.Inc()
+	}
+	return content, ok
+}
+
+func (c *contentCache) Add(hash string, content []byte) {
+	if c.lru != nil {
+		c.lru.Add(hash, content)
+	}
+}
+
+func (c *contentCache) Contains(hash string) bool {
+	return c.lru != nil && c.lru.Contains(hash)
+}
+
+func (c *contentCache) Remove(hash string) {
+	if c.lru != nil {
+		c.lru.Remove(hash)
+	}
+}
+
+func (c *contentCache) Purge() {
+	if c.lru != nil {
+		c.lru.Purge()
+	}
+}
+
+// CacheStats reports the size and hit rate of the content cache
+func (s *Safe) CacheStats() storage.CacheStats {
+	return storage.NewCacheStats("content", s.cache.lru != nil, int64(s.cache.size), s.cache.hits.Load(), s.cache.misses.Load())
+}
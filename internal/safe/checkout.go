@@ -0,0 +1,139 @@
+// internal/safe/checkout.go
+package safe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// errReflinkUnsupported is returned where the platform can't clone files
+var errReflinkUnsupported = errors.New("reflinks are not supported on this platform")
+
+// LinkMethod says how checked out content was written
+type LinkMethod int
+
+const (
+	Copied     LinkMethod = iota // written as a new file
+	Cloned                       // copy-on-write clone sharing blocks with the safe
+	Hardlinked                   // another name for the safe's own file
+)
+
+func (m LinkMethod) String() string {
+	switch m {
+	case Cloned:
+		return "cloned"
+	case Hardlinked:
+		return "hardlinked"
+	default:
+		return "copied"
+	}
+}
+
+// CheckoutOptions configures Checkout
+type CheckoutOptions struct {
+	// ReadOnly files are never modified in place, so they may share the
+	// safe's file through a hardlink when cloning isn't supported
+	ReadOnly bool
+	// NoLinks always copies content
+	NoLinks bool
+	// Mode is the recorded mode of the file; only its permission bits
+	// are applied. Zero checks out with 0644.
+	Mode os.FileMode
+}
+
+// DefaultFileMode is used for files without a recorded mode
+const DefaultFileMode os.FileMode = 0644
+
+// mode returns the permissions of checked out files
+func (o CheckoutOptions) mode() os.FileMode {
+	mode := o.Mode.Perm()
+	if mode == 0 {
+		mode = DefaultFileMode
+	}
+	if o.ReadOnly {
+		mode &^= 0222
+	}
+	return mode
+}
+
+// Checkout writes the content for hash to dst, replacing any existing
+// file. Where the filesystem supports it the file is a copy-on-write
+// clone of the stored content (reflink on Linux, clonefile on macOS),
+// which costs no extra space and no copying. Read-only checkouts fall
+// back to hardlinks; everything else is copied. Compressed and cold
+// content is always copied.
+func (s *Safe) Checkout(hash, dst string, opts CheckoutOptions) (LinkMethod, error) {
+	if !s.isValidHash(hash) {
+		return Copied, ErrInvalidHash
+	}
+
+	meta, err := s.getMeta(hash)
+	if err != nil {
+		return Copied, fmt.Errorf("getting metadata: %w", err)
+	}
+
+	tmp, err := tempName(dst)
+	if err != nil {
+		return Copied, err
+	}
+
+	if !opts.NoLinks && !meta.Remote && !meta.Compressed && !meta.Cold {
+		src := s.contentPath(hash)
+		if err := reflink(src, tmp); err == nil {
+			return Cloned, finishCheckout(tmp, dst, opts.mode())
+		}
+		if opts.ReadOnly && opts.mode() == DefaultFileMode&^0222 {
+			// The safe's file becomes read-only too, which suits content
+			// that must never change. Other modes would be shared with
+			// every checkout of the same content, so they are not linked.
+			if err := os.Link(src, tmp); err == nil {
+				return Hardlinked, finishCheckout(tmp, dst, opts.mode())
+			}
+		}
+	}
+
+	content, err := s.Get(hash)
+	if err != nil {
+		return Copied, err
+	}
+	if err := os.WriteFile(tmp, content, opts.mode()); err != nil {
+		os.Remove(tmp)
+		return Copied, fmt.Errorf("writing %s: %w", dst, err)
+	}
+	return Copied, finishCheckout(tmp, dst, opts.mode())
+}
+
+// tempName reserves a name next to dst so the checkout can be renamed
+// into place atomically
+func tempName(dst string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tig-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary file: %w", err)
+	}
+	name := f.Name()
+	f.Close()
+	// Cloning and linking both need the name to be free
+	if err := os.Remove(name); err != nil {
+		return "", fmt.Errorf("removing temporary file: %w", err)
+	}
+	return name, nil
+}
+
+// finishCheckout sets the file mode on tmp and moves it over dst
+func finishCheckout(tmp, dst string, mode os.FileMode) error {
+	if err := applyMode(tmp, mode); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("setting mode of %s: %w", dst, err)
+	}
+	if err := prepareReplace(dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replacing %s: %w", dst, err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replacing %s: %w", dst, err)
+	}
+	return nil
+}
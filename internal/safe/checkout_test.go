@@ -0,0 +1,115 @@
+// internal/safe/checkout_test.go
+package safe
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckout(t *testing.T) {
+	s := setupSafe(t)
+	content := []byte("package main\n")
+	hash, err := s.Store(content)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(dst, []byte("stale"), 0644))
+
+	method, err := s.Checkout(hash, dst, CheckoutOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, []LinkMethod{Cloned, Copied}, method)
+	got, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// Writable checkouts never share the safe's file
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	stored, err := os.Stat(s.contentPath(hash))
+	require.NoError(t, err)
+	assert.False(t, os.SameFile(info, stored))
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	method, err = s.Checkout(hash, dst, CheckoutOptions{ReadOnly: true})
+	require.NoError(t, err)
+	assert.Contains(t, []LinkMethod{Cloned, Hardlinked}, method)
+	info, err = os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0444), info.Mode().Perm())
+	if method == Hardlinked {
+		assert.True(t, os.SameFile(info, stored))
+	}
+
+	method, err = s.Checkout(hash, dst, CheckoutOptions{NoLinks: true})
+	require.NoError(t, err)
+	assert.Equal(t, Copied, method)
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestCheckoutCompressed(t *testing.T) {
+	s := setupSafe(t)
+	opts := DefaultCompressionOptions()
+	opts.Level = 3
+	cm, err := newCompressionManager(opts)
+	require.NoError(t, err)
+	s.compression = cm
+
+	content := bytes.Repeat([]byte("compressible "), 200)
+	hash, err := s.Store(content)
+	require.NoError(t, err)
+
+	dst := filepath.Join(t.TempDir(), "out")
+	method, err := s.Checkout(hash, dst, CheckoutOptions{ReadOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, Copied, method)
+	got, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+}
+
+func TestCheckoutModes(t *testing.T) {
+	s := setupSafe(t)
+	hash, err := s.Store([]byte("#!/bin/sh\necho hi\n"))
+	require.NoError(t, err)
+	dst := filepath.Join(t.TempDir(), "run.sh")
+
+	// Read-only files can be replaced by later checkouts
+	_, err = s.Checkout(hash, dst, CheckoutOptions{ReadOnly: true, NoLinks: true})
+	require.NoError(t, err)
+	_, err = s.Checkout(hash, dst, CheckoutOptions{Mode: 0755, NoLinks: true})
+	require.NoError(t, err)
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+
+	if runtime.GOOS == "windows" {
+		// Only the read-only attribute carries over
+		assert.Equal(t, os.FileMode(0666), info.Mode().Perm())
+		_, err = s.Checkout(hash, dst, CheckoutOptions{Mode: 0555, NoLinks: true})
+		require.NoError(t, err)
+		info, err = os.Stat(dst)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0444), info.Mode().Perm())
+		return
+	}
+
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	// Read-only checkouts keep the executable bits and aren't linked
+	method, err := s.Checkout(hash, dst, CheckoutOptions{Mode: 0755, ReadOnly: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, Hardlinked, method)
+	info, err = os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0555), info.Mode().Perm())
+}
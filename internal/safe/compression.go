@@ -16,14 +16,51 @@ import (
 type CompressionOptions struct {
 	// Minimum size in bytes before compressing
 	MinSize int
-	// Compression level (1=fastest, 3=best)
+	// zstd compression level (1-22), mapped to the nearest encoder level.
+	// Zero stores new content uncompressed.
 	Level int
+	// Encoder window size in bytes, a power of two. Zero uses the default
+	// for the level.
+	WindowSize int
+	// Dictionary used to compress new content, if any
+	Dictionary []byte
+	// Every dictionary content may have been compressed with. The
+	// encoder dictionary is always included.
+	Dictionaries [][]byte
 	// File extensions to skip compression for
 	SkipExtensions []string
 	// Maximum file size for single-shot compression
 	StreamingThreshold int64
 }
 
+// encoderOptions translates the options into zstd encoder settings
+func (o CompressionOptions) encoderOptions() []zstd.EOption {
+	opts := []zstd.EOption{
+		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(o.Level)),
+		zstd.WithEncoderConcurrency(1),
+	}
+	if o.WindowSize > 0 {
+		opts = append(opts, zstd.WithWindowSize(o.WindowSize))
+	}
+	if len(o.Dictionary) > 0 {
+		opts = append(opts, zstd.WithEncoderDict(o.Dictionary))
+	}
+	return opts
+}
+
+// decoderOptions translates the options into zstd decoder settings
+func (o CompressionOptions) decoderOptions() []zstd.DOption {
+	opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	dicts := o.Dictionaries
+	if len(o.Dictionary) > 0 {
+		dicts = append([][]byte{o.Dictionary}, dicts...)
+	}
+	if len(dicts) > 0 {
+		opts = append(opts, zstd.WithDecoderDicts(dicts...))
+	}
+	return opts
+}
+
 // DefaultCompressionOptions provides sensible defaults
 func DefaultCompressionOptions() CompressionOptions {
 	return CompressionOptions{
@@ -54,18 +91,13 @@ type compressionManager struct {
 
 func newCompressionManager(opts CompressionOptions) (*compressionManager, error) {
 	// Create encoder/decoder for validation
-	enc, err := zstd.NewWriter(nil,
-		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(opts.Level)),
-		zstd.WithEncoderConcurrency(1),
-	)
+	enc, err := zstd.NewWriter(nil, opts.encoderOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("creating test encoder: %w", err)
 	}
 	enc.Close()
 
-	dec, err := zstd.NewReader(nil,
-		zstd.WithDecoderConcurrency(1),
-	)
+	dec, err := zstd.NewReader(nil, opts.decoderOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("creating test decoder: %w", err)
 	}
@@ -75,18 +107,13 @@ func newCompressionManager(opts CompressionOptions) (*compressionManager, error)
 		opts: opts,
 		encoders: sync.Pool{
 			New: func() interface{} {
-				enc, _ := zstd.NewWriter(nil,
-					zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(opts.Level)),
-					zstd.WithEncoderConcurrency(1),
-				)
+				enc, _ := zstd.NewWriter(nil, opts.encoderOptions()...)
 				return enc
 			},
 		},
 		decoders: sync.Pool{
 			New: func() interface{} {
-				dec, _ := zstd.NewReader(nil,
-					zstd.WithDecoderConcurrency(1),
-				)
+				dec, _ := zstd.NewReader(nil, opts.decoderOptions()...)
 				return dec
 			},
 		},
@@ -107,6 +134,10 @@ func newCompressionManager(opts CompressionOptions) (*compressionManager, error)
 
 // shouldCompress determines if content should be compressed
 func (cm *compressionManager) shouldCompress(path string, size int) bool {
+	if cm.opts.Level <= 0 {
+		return false
+	}
+
 	// Check minimum size
 	if size < cm.opts.MinSize {
 		return false
@@ -149,7 +180,8 @@ func (cm *compressionManager) compress(path string, content []byte) ([]byte, err
 		return cm.compressStream(enc, content)
 	}
 
-	return enc.EncodeAll(content, buf.Bytes()), nil
+	// The result must not alias the pooled buffer
+	return bytes.Clone(enc.EncodeAll(content, buf.Bytes())), nil
 }
 
 // compressStream handles large content compression
@@ -174,8 +206,8 @@ func (cm *compressionManager) compressStream(enc *zstd.Encoder, content []byte)
 		return nil, fmt.Errorf("finalizing compression: %w", err)
 	}
 
-	// Return compressed data
-	return buf.Bytes(), nil
+	// Return a copy; buf goes back to the pool
+	return bytes.Clone(buf.Bytes()), nil
 }
 
 // decompress decompresses content
@@ -213,8 +245,8 @@ func (cm *compressionManager) decompressStream(dec *zstd.Decoder, content []byte
 		return nil, fmt.Errorf("streaming decompression: %w", err)
 	}
 
-	// Return decompressed data
-	return buf.Bytes(), nil
+	// Return a copy; buf goes back to the pool
+	return bytes.Clone(buf.Bytes()), nil
 }
 
 // close cleans up resources
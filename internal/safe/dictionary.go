@@ -0,0 +1,173 @@
+// internal/safe/dictionary.go
+package safe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DictionaryExt is the file extension of trained dictionaries
+const DictionaryExt = ".zdict"
+
+// DefaultDictionarySize is the target size of a trained dictionary
+const DefaultDictionarySize = 112 * 1024
+
+// firstDictionaryID keeps generated IDs out of the range zstd reserves
+// for registered dictionaries
+const firstDictionaryID = 32768
+
+// MinDictionarySamples is the least content a dictionary is trained on
+const MinDictionarySamples = 8
+
+// ErrNoSamples is returned when there is too little content to train on
+var ErrNoSamples = errors.New("not enough content to train a dictionary on")
+
+// Sample returns up to limit stored content items for dictionary training.
+// Content is visited in hash order, which is effectively random. Items
+// larger than maxSize and content not fetched yet are skipped.
+func (s *Safe) Sample(limit, maxSize int) ([][]byte, error) {
+	var samples [][]byte
+	cursor := ""
+	for len(samples) < limit {
+		hashes, err := s.HashesAfter(cursor, s.batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("listing content: %w", err)
+		}
+		if len(hashes) == 0 {
+			break
+		}
+		cursor = hashes[len(hashes)-1]
+
+		for _, hash := range hashes {
+			meta, err := s.getMeta(hash)
+			if err != nil {
+				return nil, fmt.Errorf("getting metadata: %w", err)
+			}
+			if meta.Remote || meta.Size < 8 || meta.Size > int64(maxSize) {
+				continue
+			}
+			content, err := s.load(meta)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", hash, err)
+			}
+			samples = append(samples, content)
+			if len(samples) == limit {
+				break
+			}
+		}
+	}
+	return samples, nil
+}
+
+// TrainDictionary builds a zstd dictionary of roughly size bytes from
+// samples, tagged with id
+func TrainDictionary(samples [][]byte, size int, id uint32) (d []byte, err error) {
+	if len(samples) < MinDictionarySamples {
+		return nil, fmt.Errorf("%w: have %d samples, need %d", ErrNoSamples, len(samples), MinDictionarySamples)
+	}
+	// The builder panics on degenerate input instead of failing
+	defer func() {
+		if r := recover(); r != nil {
+			d, err = nil, fmt.Errorf("training dictionary: %v", r)
+		}
+	}()
+	if size <= 0 {
+		size = DefaultDictionarySize
+	}
+	d, err = dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: size,
+		HashBytes:   6,
+		ZstdDictID:  id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("training dictionary: %w", err)
+	}
+	return d, nil
+}
+
+// NextDictionaryID returns an ID not used by any of the given dictionaries
+func NextDictionaryID(dicts map[uint32][]byte) uint32 {
+	id := uint32(firstDictionaryID)
+	for existing := range dicts {
+		if existing >= id {
+			id = existing + 1
+		}
+	}
+	return id
+}
+
+// DictionaryPath returns the file holding dictionary id inside dir
+func DictionaryPath(dir string, id uint32) string {
+	return filepath.Join(dir, strconv.FormatUint(uint64(id), 10)+DictionaryExt)
+}
+
+// SaveDictionary writes a dictionary to dir and returns its ID
+func SaveDictionary(dir string, d []byte) (uint32, error) {
+	info, err := zstd.InspectDictionary(d)
+	if err != nil {
+		return 0, fmt.Errorf("inspecting dictionary: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("creating dictionary directory: %w", err)
+	}
+	if err := os.WriteFile(DictionaryPath(dir, info.ID()), d, 0644); err != nil {
+		return 0, fmt.Errorf("writing dictionary: %w", err)
+	}
+	return info.ID(), nil
+}
+
+// LoadDictionaries reads every dictionary in dir, keyed by ID. Old
+// dictionaries are kept so content compressed with them stays readable.
+// A missing directory yields no dictionaries.
+func LoadDictionaries(dir string) (map[uint32][]byte, error) {
+	dicts := make(map[uint32][]byte)
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return dicts, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading dictionary directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), DictionaryExt) {
+			continue
+		}
+		d, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading dictionary: %w", err)
+		}
+		info, err := zstd.InspectDictionary(d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		dicts[info.ID()] = d
+	}
+	return dicts, nil
+}
+
+// MeasureCompression compresses samples with opts and returns their total
+// size before and after. Content below the minimum size is counted as is.
+func MeasureCompression(samples [][]byte, opts CompressionOptions) (before, after int, err error) {
+	cm, err := newCompressionManager(opts)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, sample := range samples {
+		data, err := cm.compress("", sample)
+		if err != nil {
+			return 0, 0, err
+		}
+		before += len(sample)
+		after += min(len(data), len(sample))
+	}
+	return before, after, nil
+}
@@ -0,0 +1,136 @@
+// internal/safe/dictionary_test.go
+package safe
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sourceFile returns content resembling one of many similar source files
+func sourceFile(i int) []byte {
+	return []byte(fmt.Sprintf(`package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Handler%[1]d serves resource %[1]d
+func Handler%[1]d(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]int{"id": %[1]d}); err != nil {
+		http.Error(w, fmt.Sprintf("encoding response: %%v", err), http.StatusInternalServerError)
+	}
+}
+`, i))
+}
+
+func TestCompressionWithDictionary(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	root := t.TempDir()
+
+	// Train on the content of an uncompressed safe
+	plain, err := New(db, Options{Root: root, CacheSize: 16})
+	require.NoError(t, err)
+	t.Cleanup(func() { plain.Close() })
+	for i := 0; i < 200; i++ {
+		_, err := plain.Store(sourceFile(i))
+		require.NoError(t, err)
+	}
+	samples, err := plain.Sample(150, 64*1024)
+	require.NoError(t, err)
+	assert.Len(t, samples, 150)
+
+	d, err := TrainDictionary(samples, 8*1024, NextDictionaryID(nil))
+	require.NoError(t, err)
+	dir := t.TempDir()
+	id, err := SaveDictionary(dir, d)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(firstDictionaryID), id)
+
+	dicts, err := LoadDictionaries(dir)
+	require.NoError(t, err)
+	require.Contains(t, dicts, id)
+	assert.Equal(t, id+1, NextDictionaryID(dicts))
+
+	compression := DefaultCompressionOptions()
+	compression.Level = 19
+	compression.MinSize = 0
+	compression.WindowSize = 1 << 16
+	compression.Dictionary = dicts[id]
+
+	before, withDict, err := MeasureCompression(samples, compression)
+	require.NoError(t, err)
+	compression.Dictionary = nil
+	_, withoutDict, err := MeasureCompression(samples, compression)
+	require.NoError(t, err)
+	assert.Less(t, withDict, withoutDict)
+	assert.Less(t, withoutDict, before)
+
+	// New content is compressed with the dictionary
+	compression.Dictionary = dicts[id]
+	s, err := New(db, Options{Root: root, CacheSize: 16, Compression: &compression})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	content := sourceFile(1000)
+	hash, err := s.Store(content)
+	require.NoError(t, err)
+
+	meta, err := s.getMeta(hash)
+	require.NoError(t, err)
+	assert.True(t, meta.Compressed)
+	assert.Equal(t, int64(len(content)), meta.Size)
+
+	// Stays readable once the dictionary is no longer used for new content
+	readOnly := DefaultCompressionOptions()
+	readOnly.Level = 0
+	readOnly.Dictionaries = [][]byte{dicts[id]}
+	later, err := New(db, Options{Root: root, CacheSize: 16, Compression: &readOnly})
+	require.NoError(t, err)
+	t.Cleanup(func() { later.Close() })
+	got, err := later.Get(hash)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	require.NoError(t, later.Scrub(hash))
+
+	// Without the dictionary the content can't be decoded
+	lost, err := New(db, Options{Root: root, CacheSize: 16})
+	require.NoError(t, err)
+	t.Cleanup(func() { lost.Close() })
+	_, err = lost.Get(hash)
+	assert.Error(t, err)
+}
+
+func TestCompressionSkipsIncompressible(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	compression := DefaultCompressionOptions()
+	compression.Level = 3
+	s, err := New(db, Options{Root: t.TempDir(), CacheSize: 16, Compression: &compression})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	// Below the minimum size content is stored as is
+	hash, err := s.Store([]byte("short"))
+	require.NoError(t, err)
+	meta, err := s.getMeta(hash)
+	require.NoError(t, err)
+	assert.False(t, meta.Compressed)
+}
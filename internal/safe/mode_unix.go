@@ -0,0 +1,18 @@
+// internal/safe/mode_unix.go
+//go:build !windows
+
+package safe
+
+import "os"
+
+// applyMode sets the permission bits of path, including the executable
+// bits, so modes round-trip exactly
+func applyMode(path string, mode os.FileMode) error {
+	return os.Chmod(path, mode.Perm())
+}
+
+// prepareReplace readies dst to be replaced by a rename. Unix renames
+// replace files regardless of their mode.
+func prepareReplace(dst string) error {
+	return nil
+}
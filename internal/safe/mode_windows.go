@@ -0,0 +1,52 @@
+// internal/safe/mode_windows.go
+//go:build windows
+
+package safe
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// applyMode maps the permission bits of mode onto Windows, where only the
+// read-only attribute corresponds to them: files without an owner write
+// bit are made read-only. Executable bits have no equivalent and access
+// is governed by ACLs, which the file inherits from its directory and
+// which are left alone.
+func applyMode(path string, mode os.FileMode) error {
+	return setReadOnly(path, mode&0200 == 0)
+}
+
+// prepareReplace clears the read-only attribute of an existing dst,
+// which would otherwise make the rename over it fail
+func prepareReplace(dst string) error {
+	err := setReadOnly(dst, false)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func setReadOnly(path string, readOnly bool) error {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	attrs, err := windows.GetFileAttributes(name)
+	if err != nil {
+		return &os.PathError{Op: "getfileattributes", Path: path, Err: err}
+	}
+
+	updated := attrs &^ windows.FILE_ATTRIBUTE_READONLY
+	if readOnly {
+		updated |= windows.FILE_ATTRIBUTE_READONLY
+	}
+	if updated == attrs {
+		return nil
+	}
+	if err := windows.SetFileAttributes(name, updated); err != nil {
+		return &os.PathError{Op: "setfileattributes", Path: path, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// internal/safe/refcount.go
+package safe
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// RefCountFix describes a reference count that disagrees with the live
+// references found in the repository
+type RefCountFix struct {
+	Hash string `json:"hash"`
+	Old  uint32 `json:"old"`
+	New  uint32 `json:"new"`
+}
+
+// Walk calls fn with the metadata of every stored content item
+func (s *Safe) Walk(fn func(meta ContentMeta) error) error {
+	var metas []ContentMeta
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte("content:")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var meta ContentMeta
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &meta)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			metas = append(metas, meta)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Call fn outside the transaction so it may modify the safe
+	for _, meta := range metas {
+		if err := fn(meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebuildRefCounts compares stored reference counts with live, the number
+// of references to each hash held by changesets, gated changes and file
+// states. Mismatches are returned and, unless dryRun is set, corrected.
+// Content with no live references is removed.
+func (s *Safe) RebuildRefCounts(live map[string]uint32, dryRun bool) ([]RefCountFix, error) {
+	var fixes []RefCountFix
+
+	err := s.Walk(func(meta ContentMeta) error {
+		want := live[meta.Hash]
+		if meta.RefCount == want {
+			return nil
+		}
+
+		fixes = append(fixes, RefCountFix{Hash: meta.Hash, Old: meta.RefCount, New: want})
+		if dryRun {
+			return nil
+		}
+		return s.setRefCount(meta.Hash, want)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fixes, nil
+}
+
+// setRefCount overwrites the reference count of a hash, removing the
+// content when the count drops to zero
+func (s *Safe) setRefCount(hash string, count uint32) error {
+	unlock := s.lockHash(hash)
+	defer unlock()
+
+	meta, err := s.getMeta(hash)
+	if err != nil {
+		return fmt.Errorf("getting metadata: %w", err)
+	}
+
+	if count > 0 {
+		meta.RefCount = count
+		return s.storeMeta(meta)
+	}
+
+	if err := s.removeStored(meta); err != nil {
+		return fmt.Errorf("removing content file: %w", err)
+	}
+	if err := s.deleteMeta(hash); err != nil {
+		return fmt.Errorf("deleting metadata: %w", err)
+	}
+	s.cache.Remove(hash)
+	return nil
+}
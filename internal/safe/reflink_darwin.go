@@ -0,0 +1,12 @@
+// internal/safe/reflink_darwin.go
+//go:build darwin
+
+package safe
+
+import "golang.org/x/sys/unix"
+
+// reflink creates dst as a copy-on-write clone of src. APFS supports it;
+// HFS+ refuses it.
+func reflink(src, dst string) error {
+	return unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+}
@@ -0,0 +1,9 @@
+// internal/safe/reflink_other.go
+//go:build !linux && !darwin
+
+package safe
+
+// reflink is unavailable; checkouts fall back to hardlinks or copies
+func reflink(src, dst string) error {
+	return errReflinkUnsupported
+}
@@ -0,0 +1,165 @@
+// internal/safe/remote.go
+package safe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrNoFetcher is returned when remote content is needed but the safe has
+// no way to fetch it
+var ErrNoFetcher = errors.New("content is on the remote and no remote is configured")
+
+// Fetcher retrieves content that a partial clone has not downloaded yet
+type Fetcher interface {
+	Fetch(hash string) ([]byte, error)
+}
+
+// SetFetcher configures where remote content is fetched from on first use
+func (s *Safe) SetFetcher(f Fetcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetcher = f
+}
+
+// AddRemote records content that exists on the remote but not locally,
+// with the number of local references to it. Remote content is fetched
+// the first time it is read.
+func (s *Safe) AddRemote(hash string, refCount uint32) error {
+	if !s.isValidHash(hash) {
+		return ErrInvalidHash
+	}
+
+	unlock := s.lockHash(hash)
+	defer unlock()
+
+	meta, err := s.getMeta(hash)
+	if err == nil {
+		meta.RefCount = refCount
+		return s.storeMeta(meta)
+	}
+	if err != ErrContentNotFound {
+		return fmt.Errorf("getting metadata: %w", err)
+	}
+
+	return s.storeMeta(ContentMeta{
+		Hash:      hash,
+		RefCount:  refCount,
+		Remote:    true,
+		CreatedAt: time.Now(),
+	})
+}
+
+// Prefetch downloads remote content for the given hashes in parallel and
+// returns how many were fetched. Hashes already stored locally are skipped.
+func (s *Safe) Prefetch(hashes []string, opts BatchOptions) (int, error) {
+	var remote []string
+	for _, hash := range hashes {
+		meta, err := s.Meta(hash)
+		if err != nil {
+			return 0, fmt.Errorf("getting metadata for %s: %w", hash, err)
+		}
+		if meta.Remote {
+			remote = append(remote, hash)
+		}
+	}
+
+	_, err := s.GetBatchWithOptions(remote, opts)
+	if batchErr, ok := err.(*BatchError); ok {
+		return len(remote) - len(batchErr.Failed()), err
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(remote), nil
+}
+
+// fetch downloads remote content and stores it locally. The caller holds
+// the hash lock.
+func (s *Safe) fetch(meta ContentMeta) ([]byte, error) {
+	s.mu.RLock()
+	fetcher := s.fetcher
+	s.mu.RUnlock()
+	if fetcher == nil {
+		return nil, ErrNoFetcher
+	}
+
+	content, err := fetcher.Fetch(meta.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", meta.Hash, err)
+	}
+	if s.hashContent(content) != meta.Hash {
+		return nil, fmt.Errorf("fetching %s: %w", meta.Hash, ErrHashMismatch)
+	}
+
+	if err := s.fill(meta, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// fill writes content for a remote entry to disk and marks it local. The
+// caller holds the hash lock.
+func (s *Safe) fill(meta ContentMeta, content []byte) error {
+	contentPath := s.contentPath(meta.Hash)
+	if err := os.MkdirAll(filepath.Dir(contentPath), 0755); err != nil {
+		return fmt.Errorf("creating content directory: %w", err)
+	}
+	data, compressed, err := s.encode(content)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(contentPath, data, 0644); err != nil {
+		return fmt.Errorf("writing content file: %w", err)
+	}
+
+	meta.Remote = false
+	meta.Compressed = compressed
+	meta.Size = int64(len(content))
+	meta.AccessedAt = time.Now()
+	if err := s.storeMeta(meta); err != nil {
+		os.Remove(contentPath)
+		return fmt.Errorf("storing metadata: %w", err)
+	}
+	return nil
+}
+
+// Missing returns the hashes whose content is not stored locally, in input
+// order. Entries a partial clone has not fetched yet count as missing.
+func (s *Safe) Missing(hashes []string) ([]string, error) {
+	var missing []string
+	err := s.db.View(func(txn *badger.Txn) error {
+		for _, hash := range hashes {
+			if !s.isValidHash(hash) {
+				return fmt.Errorf("%q: %w", hash, ErrInvalidHash)
+			}
+
+			item, err := txn.Get([]byte("content:" + hash))
+			if err == badger.ErrKeyNotFound {
+				missing = append(missing, hash)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			var meta ContentMeta
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &meta)
+			}); err != nil {
+				return fmt.Errorf("decoding metadata for %s: %w", hash, err)
+			}
+			if meta.Remote {
+				missing = append(missing, hash)
+			}
+		}
+		return nil
+	})
+	return missing, err
+}
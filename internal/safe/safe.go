@@ -7,18 +7,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"tig/internal/storage"
+
 	"github.com/dgraph-io/badger/v4"
-	lru "github.com/hashicorp/golang-lru/v2"
 )
 
 var (
 	ErrContentNotFound = errors.New("content not found")
 	ErrInvalidHash    = errors.New("invalid content hash")
+	ErrRefCountUnderflow = errors.New("reference count underflow")
+	ErrRefCountOverflow  = errors.New("reference count overflow")
+	ErrHashMismatch      = errors.New("content hash mismatch")
 )
 
 // ContentMeta stores metadata about stored content
@@ -29,24 +34,38 @@ type ContentMeta struct {
 	Compressed bool      `json:"compressed"`
 	CreatedAt  time.Time `json:"created_at"`
 	AccessedAt time.Time `json:"accessed_at"`
+	VerifiedAt time.Time `json:"verified_at,omitempty"` // Last successful scrub
+	Remote     bool      `json:"remote,omitempty"`      // Not downloaded yet; fetched on first read
+	Cold       bool      `json:"cold,omitempty"`        // Moved to the cold store by the tier policy
 }
 
 // Safe provides secure, deduplicated content storage
 type Safe struct {
 	root      string           // Root directory for content files
 	db        *badger.DB       // Metadata database
-	cache     *lru.Cache[string, []byte] // Content cache
+	cache     *contentCache    // Content cache
 	mu        sync.RWMutex
+	locks     [64]sync.Mutex   // Per-hash locks serializing metadata updates
 	batchSize int             // Size for batch operations
-	decompress func([]byte) ([]byte, error)
+	compression *compressionManager // zstd settings for new and stored content
+	fetcher    Fetcher // Source of remote content in partial clones
+	access     *accessTracker // Batched AccessedAt updates
+	cold       ColdStore      // Where the tier policy moves rarely read content
 }
 
 // Options configures Safe behavior
 type Options struct {
 	Root          string // Root directory path
-	CacheSize     int    // Number of items to cache
+	CacheSize     int    // Number of items to cache; 0 disables the cache
 	BatchSize     int    // Size for batch operations
 	CompressAfter time.Duration // When to compress old content
+	Compression   *CompressionOptions // Compression of new content; nil stores it uncompressed
+
+	// How often batched access times are written; negative writes them
+	// on every read instead
+	AccessFlushInterval time.Duration
+	// Reads leave the access time alone until it is older than this
+	AccessDrift time.Duration
 }
 
 // New creates a new Safe instance
@@ -61,7 +80,7 @@ func New(db *badger.DB, opts Options) (*Safe, error) {
 	}
 
 	// Set up LRU cache
-	cache, err := lru.New[string, []byte](opts.CacheSize)
+	cache, err := newContentCache(opts.CacheSize)
 	if err != nil {
 		return nil, fmt.Errorf("creating cache: %w", err)
 	}
@@ -73,47 +92,97 @@ func New(db *badger.DB, opts Options) (*Safe, error) {
 	if opts.CompressAfter == 0 {
 		opts.CompressAfter = 30 * 24 * time.Hour // 30 days
 	}
+	if opts.AccessFlushInterval == 0 {
+		opts.AccessFlushInterval = DefaultAccessFlushInterval
+	}
+	if opts.AccessDrift == 0 {
+		opts.AccessDrift = DefaultAccessDrift
+	}
 
-	return &Safe{
-		root:      opts.Root,
-		db:        db,
-		cache:     cache,
-		batchSize: opts.BatchSize,
-	}, nil
+	// Content compressed under earlier settings must stay readable, so a
+	// manager exists even when new content is stored uncompressed
+	compression := DefaultCompressionOptions()
+	compression.Level = 0
+	if opts.Compression != nil {
+		compression = *opts.Compression
+	}
+	cm, err := newCompressionManager(compression)
+	if err != nil {
+		return nil, fmt.Errorf("configuring compression: %w", err)
+	}
+
+	s := &Safe{
+		root:        opts.Root,
+		db:          db,
+		cache:       cache,
+		batchSize:   opts.BatchSize,
+		compression: cm,
+		access:      newAccessTracker(opts.AccessFlushInterval, opts.AccessDrift),
+	}
+	if opts.AccessFlushInterval > 0 {
+		go s.flushLoop()
+	}
+	return s, nil
 }
 
 // Store saves content and returns its hash
 func (s *Safe) Store(content []byte) (string, error) {
+	hash, _, err := s.store(content)
+	return hash, err
+}
+
+// store saves content, reporting what was written for it
+func (s *Safe) store(content []byte) (string, StoreStats, error) {
 	if len(content) == 0 {
 		content = []byte{} // Convert nil to empty slice
 	}
+	stats := StoreStats{Items: 1, LogicalBytes: int64(len(content))}
 
 	// Generate hash
 	hash := s.hashContent(content)
 
+	unlock := s.lockHash(hash)
+	defer unlock()
+
 	// Check if content already exists
 	exists, err := s.Exists(hash)
 	if err != nil {
-		return "", fmt.Errorf("checking existence: %w", err)
+		return "", stats, fmt.Errorf("checking existence: %w", err)
 	}
 
 	if exists {
 		// Increment reference count
 		if err := s.incrementRefCount(hash); err != nil {
-			return "", fmt.Errorf("incrementing ref count: %w", err)
+			return "", stats, fmt.Errorf("incrementing ref count: %w", err)
+		}
+
+		// Keep a local copy of content a partial clone hasn't fetched yet
+		if meta, err := s.getMeta(hash); err == nil && meta.Remote {
+			if err := s.fill(meta, content); err != nil {
+				return "", stats, err
+			}
 		}
-		return hash, nil
+		return hash, stats, nil
 	}
 
 	// Prepare content path
 	contentPath := s.contentPath(hash)
 	if err := os.MkdirAll(filepath.Dir(contentPath), 0755); err != nil {
-		return "", fmt.Errorf("creating content directory: %w", err)
+		return "", stats, fmt.Errorf("creating content directory: %w", err)
 	}
 
 	// Write content file
-	if err := os.WriteFile(contentPath, content, 0644); err != nil {
-		return "", fmt.Errorf("writing content file: %w", err)
+	data, compressed, err := s.encode(content)
+	if err != nil {
+		return "", stats, err
+	}
+	if err := os.WriteFile(contentPath, data, 0644); err != nil {
+		return "", stats, fmt.Errorf("writing content file: %w", err)
+	}
+	stats.New = 1
+	stats.StoredBytes = int64(len(data))
+	if compressed {
+		stats.Compressed = 1
 	}
 
 	// Create metadata
@@ -121,7 +190,7 @@ func (s *Safe) Store(content []byte) (string, error) {
 		Hash:       hash,
 		Size:       int64(len(content)),
 		RefCount:   1,
-		Compressed: false,
+		Compressed: compressed,
 		CreatedAt:  time.Now(),
 		AccessedAt: time.Now(),
 	}
@@ -130,13 +199,13 @@ func (s *Safe) Store(content []byte) (string, error) {
 	if err := s.storeMeta(meta); err != nil {
 		// Cleanup on failure
 		os.Remove(contentPath)
-		return "", fmt.Errorf("storing metadata: %w", err)
+		return "", StoreStats{Items: 1, LogicalBytes: stats.LogicalBytes}, fmt.Errorf("storing metadata: %w", err)
 	}
 
 	// Update cache
 	s.cache.Add(hash, content)
 
-	return hash, nil
+	return hash, stats, nil
 }
 
 // Get retrieves content by hash
@@ -150,61 +219,103 @@ func (s *Safe) Get(hash string) ([]byte, error) {
 		return content, nil
 	}
 
+	unlock := s.lockHash(hash)
+	defer unlock()
+
 	// Get metadata
 	meta, err := s.getMeta(hash)
 	if err != nil {
 		return nil, fmt.Errorf("getting metadata: %w", err)
 	}
 
+	if meta.Remote {
+		content, err := s.fetch(meta)
+		if err != nil {
+			return nil, err
+		}
+		s.cache.Add(hash, content)
+		return content, nil
+	}
+
+	content, err := s.load(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update cache and access time
+	s.cache.Add(hash, content)
+	if err := s.touch(meta); err != nil {
+		return nil, fmt.Errorf("updating metadata: %w", err)
+	}
+
+	return content, nil
+}
+
+// load reads content from disk and checks it against its hash
+func (s *Safe) load(meta ContentMeta) ([]byte, error) {
 	// Read content file
-	contentPath := s.contentPath(hash)
-	content, err := os.ReadFile(contentPath)
+	content, err := s.readStored(meta)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, ErrContentNotFound
+		if err == ErrContentNotFound || err == ErrNoColdStore {
+			return nil, err
 		}
 		return nil, fmt.Errorf("reading content: %w", err)
 	}
 
 	// Decompress if needed
 	if meta.Compressed {
-		content, err = s.decompress(content)
+		content, err = s.compression.decompress(content)
 		if err != nil {
 			return nil, fmt.Errorf("decompressing content: %w", err)
 		}
 	}
 
 	// Verify hash
-	if s.hashContent(content) != hash {
-		return nil, fmt.Errorf("content hash mismatch")
-	}
-
-	// Update cache and access time
-	s.cache.Add(hash, content)
-	meta.AccessedAt = time.Now()
-	if err := s.storeMeta(meta); err != nil {
-		return nil, fmt.Errorf("updating metadata: %w", err)
+	if s.hashContent(content) != meta.Hash {
+		return nil, ErrHashMismatch
 	}
 
 	return content, nil
 }
 
+// encode returns the bytes to write to disk for content, compressing it
+// when configured and worthwhile
+func (s *Safe) encode(content []byte) ([]byte, bool, error) {
+	if !s.compression.shouldCompress("", len(content)) {
+		return content, false, nil
+	}
+	data, err := s.compression.compress("", content)
+	if err != nil {
+		return nil, false, fmt.Errorf("compressing content: %w", err)
+	}
+	if len(data) >= len(content) {
+		return content, false, nil
+	}
+	return data, true, nil
+}
+
 // Delete removes content and decrements its reference count
 func (s *Safe) Delete(hash string) error {
 	if !s.isValidHash(hash) {
 		return ErrInvalidHash
 	}
 
+	unlock := s.lockHash(hash)
+	defer unlock()
+
 	meta, err := s.getMeta(hash)
 	if err != nil {
 		return fmt.Errorf("getting metadata: %w", err)
 	}
 
+	if meta.RefCount == 0 {
+		return fmt.Errorf("%s: %w", hash, ErrRefCountUnderflow)
+	}
+
 	meta.RefCount--
 	if meta.RefCount == 0 {
 		// Remove content file
-		contentPath := s.contentPath(hash)
-		if err := os.Remove(contentPath); err != nil && !os.IsNotExist(err) {
+		if err := s.removeStored(meta); err != nil {
 			return fmt.Errorf("removing content file: %w", err)
 		}
 
@@ -262,34 +373,78 @@ func (s *Safe) Verify(hash string) error {
 	return nil
 }
 
-// StoreBatch stores multiple content items efficiently
+// StoreBatch stores multiple content items in parallel. If any item
+// fails, items already stored by the batch are rolled back.
 func (s *Safe) StoreBatch(contents [][]byte) ([]string, error) {
+	return s.StoreBatchWithOptions(contents, BatchOptions{Atomic: true})
+}
+
+// StoreBatchWithOptions stores multiple content items using a worker pool.
+// Without Atomic, hashes of successfully stored items are returned along
+// with a *BatchError describing the items that failed.
+func (s *Safe) StoreBatchWithOptions(contents [][]byte, opts BatchOptions) ([]string, error) {
 	hashes := make([]string, len(contents))
-	for i, content := range contents {
-		hash, err := s.Store(content)
+	var mu sync.Mutex
+	errs := runBatch(len(contents), opts.workers(), func(i int) error {
+		hash, stats, err := s.store(contents[i])
+		if opts.Stats != nil {
+			mu.Lock()
+			opts.Stats.Add(stats)
+			mu.Unlock()
+		}
 		if err != nil {
-			// Cleanup on failure
-			for j := 0; j < i; j++ {
-				s.Delete(hashes[j])
-			}
-			return nil, fmt.Errorf("storing content %d: %w", i, err)
+			return err
 		}
 		hashes[i] = hash
+		return nil
+	})
+
+	if errs == nil {
+		return hashes, nil
 	}
-	return hashes, nil
+
+	if opts.Atomic {
+		for i, hash := range hashes {
+			if hash == "" {
+				continue
+			}
+			if err := s.Delete(hash); err != nil {
+				errs.add(i, fmt.Errorf("rolling back: %w", err))
+			}
+		}
+		return nil, errs
+	}
+
+	return hashes, errs
 }
 
-// GetBatch retrieves multiple content items efficiently
+// GetBatch retrieves multiple content items in parallel, failing if any
+// item cannot be read
 func (s *Safe) GetBatch(hashes []string) ([][]byte, error) {
+	return s.GetBatchWithOptions(hashes, BatchOptions{Atomic: true})
+}
+
+// GetBatchWithOptions retrieves multiple content items using a worker pool.
+// Without Atomic, the contents that could be read are returned alongside a
+// *BatchError; missing entries are nil.
+func (s *Safe) GetBatchWithOptions(hashes []string, opts BatchOptions) ([][]byte, error) {
 	contents := make([][]byte, len(hashes))
-	for i, hash := range hashes {
-		content, err := s.Get(hash)
+	errs := runBatch(len(hashes), opts.workers(), func(i int) error {
+		content, err := s.Get(hashes[i])
 		if err != nil {
-			return nil, fmt.Errorf("getting content %s: %w", hash, err)
+			return fmt.Errorf("getting content %s: %w", hashes[i], err)
 		}
 		contents[i] = content
+		return nil
+	})
+
+	if errs == nil {
+		return contents, nil
 	}
-	return contents, nil
+	if opts.Atomic {
+		return nil, errs
+	}
+	return contents, errs
 }
 
 // Internal helper functions
@@ -303,6 +458,17 @@ func (s *Safe) contentPath(hash string) string {
 	return filepath.Join(s.root, hash[:2], hash[2:])
 }
 
+// lockHash serializes operations on a single hash and returns the unlock func
+func (s *Safe) lockHash(hash string) func() {
+	b, _ := hex.DecodeString(hash[:2])
+	var idx int
+	if len(b) == 1 {
+		idx = int(b[0]) % len(s.locks)
+	}
+	s.locks[idx].Lock()
+	return s.locks[idx].Unlock
+}
+
 func (s *Safe) isValidHash(hash string) bool {
 	if len(hash) != 64 {
 		return false
@@ -317,17 +483,27 @@ func (s *Safe) incrementRefCount(hash string) error {
 		return err
 	}
 
+	if meta.RefCount == math.MaxUint32 {
+		return fmt.Errorf("%s: %w", hash, ErrRefCountOverflow)
+	}
+
 	meta.RefCount++
 	return s.storeMeta(meta)
 }
 
+// MetaKey is the database key of the metadata, including the reference
+// count, of the content with hash
+func MetaKey(hash string) string {
+	return "content:" + hash
+}
+
 func (s *Safe) storeMeta(meta ContentMeta) error {
 	data, err := json.Marshal(meta)
 	if err != nil {
 		return err
 	}
 
-	return s.db.Update(func(txn *badger.Txn) error {
+	return storage.Update(s.db, func(txn *badger.Txn) error {
 		key := []byte(fmt.Sprintf("content:%s", meta.Hash))
 		return txn.Set(key, data)
 	})
@@ -355,8 +531,12 @@ func (s *Safe) getMeta(hash string) (ContentMeta, error) {
 }
 
 func (s *Safe) deleteMeta(hash string) error {
-	return s.db.Update(func(txn *badger.Txn) error {
+	return storage.Update(s.db, func(txn *badger.Txn) error {
 		key := []byte(fmt.Sprintf("content:%s", hash))
 		return txn.Delete(key)
 	})
-}
\ No newline at end of file
+}
+// Root returns the directory content files are stored in
+func (s *Safe) Root() string {
+	return s.root
+}
@@ -0,0 +1,251 @@
+// internal/safe/safe_test.go
+package safe
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSafe(t *testing.T) *Safe {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	s, err := New(db, Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStoreBatchParallel(t *testing.T) {
+	s := setupSafe(t)
+
+	var contents [][]byte
+	for i := 0; i < 200; i++ {
+		// Every content appears twice to exercise concurrent dedup
+		contents = append(contents, []byte(fmt.Sprintf("file %d", i%100)))
+	}
+
+	var stats StoreStats
+	hashes, err := s.StoreBatchWithOptions(contents, BatchOptions{Workers: 8, Stats: &stats})
+	require.NoError(t, err)
+	require.Len(t, hashes, len(contents))
+
+	var logical, unique int64
+	for i, c := range contents {
+		logical += int64(len(c))
+		if i < 100 {
+			unique += int64(len(c))
+		}
+	}
+	assert.Equal(t, StoreStats{Items: 200, New: 100, LogicalBytes: logical, StoredBytes: unique}, stats)
+	assert.InDelta(t, 0.5, stats.Saved(), 0.01)
+
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, hashes[i], hashes[i+100])
+		meta, err := s.getMeta(hashes[i])
+		require.NoError(t, err)
+		assert.Equal(t, uint32(2), meta.RefCount)
+	}
+
+	got, err := s.GetBatch(hashes)
+	require.NoError(t, err)
+	assert.Equal(t, contents, got)
+}
+
+func TestStoreBatchErrors(t *testing.T) {
+	s := setupSafe(t)
+
+	good := []byte("good content")
+	bad := []byte("bad content")
+
+	// Block the shard directory of the bad item so its write fails
+	badHash := s.hashContent(bad)
+	require.NoError(t, os.WriteFile(filepath.Join(s.root, badHash[:2]), nil, 0644))
+
+	hashes, err := s.StoreBatchWithOptions([][]byte{good, bad}, BatchOptions{})
+	var batchErr *BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, []int{1}, batchErr.Failed())
+	assert.NotEmpty(t, hashes[0])
+	assert.Empty(t, hashes[1])
+
+	// Atomic mode rolls the good item back to its previous ref count
+	_, err = s.StoreBatch([][]byte{good, bad})
+	require.True(t, errors.As(err, &batchErr))
+	meta, err := s.getMeta(hashes[0])
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), meta.RefCount)
+
+	// GetBatch reports missing items per index
+	missing := s.hashContent([]byte("never stored"))
+	contents, err := s.GetBatchWithOptions([]string{hashes[0], missing}, BatchOptions{})
+	require.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, []int{1}, batchErr.Failed())
+	assert.Equal(t, good, contents[0])
+	assert.True(t, errors.Is(err, ErrContentNotFound))
+
+	_, err = s.GetBatch([]string{hashes[0], missing})
+	assert.Error(t, err)
+}
+
+func TestRefCountGuards(t *testing.T) {
+	s := setupSafe(t)
+
+	hash, err := s.Store([]byte("content"))
+	require.NoError(t, err)
+
+	meta, err := s.getMeta(hash)
+	require.NoError(t, err)
+	meta.RefCount = math.MaxUint32
+	require.NoError(t, s.storeMeta(meta))
+	_, err = s.Store([]byte("content"))
+	assert.ErrorIs(t, err, ErrRefCountOverflow)
+
+	meta.RefCount = 0
+	require.NoError(t, s.storeMeta(meta))
+	assert.ErrorIs(t, s.Delete(hash), ErrRefCountUnderflow)
+
+	fixes, err := s.RebuildRefCounts(map[string]uint32{hash: 2}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []RefCountFix{{Hash: hash, Old: 0, New: 2}}, fixes)
+	require.NoError(t, s.Delete(hash))
+	require.NoError(t, s.Delete(hash))
+	exists, err := s.Exists(hash)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// mapFetcher serves remote content from memory and counts fetches
+type mapFetcher struct {
+	blobs   map[string][]byte
+	fetches int
+}
+
+func (f *mapFetcher) Fetch(hash string) ([]byte, error) {
+	f.fetches++
+	data, ok := f.blobs[hash]
+	if !ok {
+		return nil, ErrContentNotFound
+	}
+	return data, nil
+}
+
+func TestRemoteContent(t *testing.T) {
+	s := setupSafe(t)
+	content := []byte("remote content")
+	hash := s.hashContent(content)
+	other := s.hashContent([]byte("stored locally later"))
+
+	require.NoError(t, s.AddRemote(hash, 2))
+	require.NoError(t, s.AddRemote(other, 1))
+
+	// Without a fetcher remote content is unavailable
+	_, err := s.Get(hash)
+	assert.ErrorIs(t, err, ErrNoFetcher)
+
+	f := &mapFetcher{blobs: map[string][]byte{hash: content, other: []byte("tampered")}}
+	s.SetFetcher(f)
+
+	got, err := s.Get(hash)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	meta, err := s.Meta(hash)
+	require.NoError(t, err)
+	assert.False(t, meta.Remote)
+	assert.Equal(t, uint32(2), meta.RefCount)
+
+	// Fetched content is kept locally
+	s.cache.Purge()
+	_, err = s.Get(hash)
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.fetches)
+
+	// Content from the remote must match its hash
+	_, err = s.Get(other)
+	assert.ErrorIs(t, err, ErrHashMismatch)
+
+	// Storing the content locally fills in the remote entry
+	_, err = s.Store([]byte("stored locally later"))
+	require.NoError(t, err)
+	meta, err = s.Meta(other)
+	require.NoError(t, err)
+	assert.False(t, meta.Remote)
+	assert.Equal(t, uint32(2), meta.RefCount)
+	require.NoError(t, s.Verify(other))
+}
+
+func TestAccessTimesAreBatched(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := New(db, Options{Root: t.TempDir(), CacheSize: 1, AccessFlushInterval: time.Hour, AccessDrift: time.Minute})
+	require.NoError(t, err)
+
+	hash, err := s.Store([]byte("content"))
+	require.NoError(t, err)
+	s.cache.Purge()
+
+	// A recent access time is left alone
+	_, err = s.Get(hash)
+	require.NoError(t, err)
+	assert.Empty(t, s.access.pending)
+
+	// A stale one is recorded but not written until flushed
+	meta, err := s.getMeta(hash)
+	require.NoError(t, err)
+	stale := time.Now().Add(-2 * time.Minute)
+	meta.AccessedAt = stale
+	require.NoError(t, s.storeMeta(meta))
+	s.cache.Purge()
+
+	_, err = s.Get(hash)
+	require.NoError(t, err)
+	meta, err = s.getMeta(hash)
+	require.NoError(t, err)
+	assert.True(t, meta.AccessedAt.Equal(stale))
+
+	require.NoError(t, s.Close())
+	meta, err = s.getMeta(hash)
+	require.NoError(t, err)
+	assert.True(t, meta.AccessedAt.After(stale))
+}
+
+func TestDisabledCache(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := New(db, Options{Root: t.TempDir()})
+	require.NoError(t, err)
+	defer s.Close()
+
+	hash, err := s.Store([]byte("content"))
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		got, err := s.Get(hash)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("content"), got)
+	}
+
+	stats := s.CacheStats()
+	assert.False(t, stats.Enabled)
+	assert.Equal(t, uint64(0), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+}
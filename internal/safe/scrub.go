@@ -0,0 +1,75 @@
+// internal/safe/scrub.go
+package safe
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Scrub re-reads content from disk, bypassing the cache, and checks it
+// against its hash. On success the verification time is recorded in the
+// content metadata. Content not yet fetched from the remote is skipped.
+func (s *Safe) Scrub(hash string) error {
+	if !s.isValidHash(hash) {
+		return ErrInvalidHash
+	}
+
+	unlock := s.lockHash(hash)
+	defer unlock()
+
+	meta, err := s.getMeta(hash)
+	if err != nil {
+		return fmt.Errorf("getting metadata: %w", err)
+	}
+
+	// Nothing to verify until remote content has been fetched
+	if meta.Remote {
+		return nil
+	}
+
+	if _, err := s.load(meta); err != nil {
+		// Drop any cached copy so readers don't mask the corruption
+		s.cache.Remove(hash)
+		return err
+	}
+
+	meta.VerifiedAt = time.Now()
+	if err := s.storeMeta(meta); err != nil {
+		return fmt.Errorf("updating metadata: %w", err)
+	}
+	return nil
+}
+
+// Meta returns the stored metadata for a hash
+func (s *Safe) Meta(hash string) (ContentMeta, error) {
+	if !s.isValidHash(hash) {
+		return ContentMeta{}, ErrInvalidHash
+	}
+	return s.getMeta(hash)
+}
+
+// HashesAfter returns up to limit stored hashes in key order, starting
+// after the given hash. An empty cursor starts from the beginning.
+func (s *Safe) HashesAfter(cursor string, limit int) ([]string, error) {
+	var hashes []string
+	err := s.db.View(func(txn *badger.Txn) error {
+		prefix := []byte("content:")
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte("content:" + cursor)); it.Valid() && len(hashes) < limit; it.Next() {
+			hash := string(it.Item().Key()[len(prefix):])
+			if hash == cursor {
+				continue
+			}
+			hashes = append(hashes, hash)
+		}
+		return nil
+	})
+	return hashes, err
+}
@@ -0,0 +1,311 @@
+// internal/safe/tiers.go
+package safe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Storage tiers. Hot content is stored uncompressed in the safe, warm
+// content compressed in the safe, and cold content in the cold store.
+const (
+	TierHot  = "hot"
+	TierWarm = "warm"
+	TierCold = "cold"
+)
+
+// DefaultTierLevel is the zstd level warm and cold content is compressed
+// with when neither the policy nor the safe sets one
+const DefaultTierLevel = 3
+
+// ErrNoColdStore is returned when cold content is read but the safe has
+// no cold store
+var ErrNoColdStore = errors.New("content is in cold storage and no cold store is configured")
+
+// ColdStore keeps content moved out of the safe's directory, e.g. on
+// slower disks or in an object store. Data is stored as the safe wrote
+// it, compressed or not.
+type ColdStore interface {
+	Put(hash string, data []byte) error
+	Get(hash string) ([]byte, error) // fails with an error wrapping os.ErrNotExist when missing
+	Delete(hash string) error        // succeeds when the data is already gone
+}
+
+// DirStore is a ColdStore in a directory, laid out like the safe
+type DirStore struct {
+	Root string
+}
+
+func (d *DirStore) path(hash string) string {
+	return filepath.Join(d.Root, hash[:2], hash[2:])
+}
+
+// Put implements ColdStore
+func (d *DirStore) Put(hash string, data []byte) error {
+	path := d.path(hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating cold storage directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing cold content: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing cold content: %w", err)
+	}
+	return nil
+}
+
+// Get implements ColdStore
+func (d *DirStore) Get(hash string) ([]byte, error) {
+	return os.ReadFile(d.path(hash))
+}
+
+// Delete implements ColdStore
+func (d *DirStore) Delete(hash string) error {
+	if err := os.Remove(d.path(hash)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// SetColdStore configures where cold content is kept
+func (s *Safe) SetColdStore(c ColdStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cold = c
+}
+
+func (s *Safe) coldStore() ColdStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cold
+}
+
+// Tier returns the tier content is stored in
+func Tier(meta ContentMeta) string {
+	switch {
+	case meta.Cold:
+		return TierCold
+	case meta.Compressed:
+		return TierWarm
+	}
+	return TierHot
+}
+
+// TierPolicy places content in tiers by how long ago it was last read.
+// Zero ages never move content to that tier.
+type TierPolicy struct {
+	WarmAfter   time.Duration // compress content unread for this long
+	ColdAfter   time.Duration // move content unread for this long to the cold store
+	ColdMinSize int64         // smaller content is never moved to the cold store
+	Level       int           // zstd level of warm and cold content; 0 uses the safe's or DefaultTierLevel
+}
+
+// TierMove is content that a policy places in another tier
+type TierMove struct {
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// target returns the tier the policy places meta in at now. Without a
+// WarmAfter the policy leaves compression as it is.
+func (p TierPolicy) target(meta ContentMeta, now time.Time, cold bool, minSize int) string {
+	last := meta.AccessedAt
+	if last.IsZero() {
+		last = meta.CreatedAt
+	}
+	idle := now.Sub(last)
+	switch {
+	case cold && p.ColdAfter > 0 && idle >= p.ColdAfter && meta.Size >= p.ColdMinSize:
+		return TierCold
+	case p.WarmAfter == 0 && meta.Compressed:
+		return TierWarm
+	case p.WarmAfter > 0 && idle >= p.WarmAfter && meta.Size >= int64(minSize):
+		return TierWarm
+	}
+	return TierHot
+}
+
+// PlanTiers lists the content the policy moves to another tier at now,
+// ordered by hash. Batched access times are written first so recent
+// reads count. Content that compression does not shrink stays hot when
+// the moves are applied. Remote content in partial clones is never moved.
+func (s *Safe) PlanTiers(policy TierPolicy, now time.Time) ([]TierMove, error) {
+	if err := s.FlushAccessTimes(); err != nil {
+		return nil, err
+	}
+	cold := s.coldStore() != nil
+	var moves []TierMove
+	err := s.Walk(func(meta ContentMeta) error {
+		if meta.Remote {
+			return nil
+		}
+		from := Tier(meta)
+		if to := policy.target(meta, now, cold, s.compression.opts.MinSize); to != from {
+			moves = append(moves, TierMove{Hash: meta.Hash, Size: meta.Size, From: from, To: to})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(moves, func(a, b int) bool { return moves[a].Hash < moves[b].Hash })
+	return moves, nil
+}
+
+// ApplyTiers moves content to the tiers planned by PlanTiers and returns
+// the moves made. Each item is written to its new tier before its old
+// copy is removed, so an interrupted run leaves content readable.
+func (s *Safe) ApplyTiers(moves []TierMove, policy TierPolicy) ([]TierMove, error) {
+	opts := s.compression.opts
+	switch {
+	case policy.Level > 0:
+		opts.Level = policy.Level
+	case opts.Level <= 0:
+		opts.Level = DefaultTierLevel
+	}
+	cm, err := newCompressionManager(opts)
+	if err != nil {
+		return nil, fmt.Errorf("configuring tier compression: %w", err)
+	}
+
+	var done []TierMove
+	for _, m := range moves {
+		to, err := s.moveTier(m.Hash, m.To, cm)
+		if err != nil {
+			return done, fmt.Errorf("moving %s to %s storage: %w", m.Hash, m.To, err)
+		}
+		if to != m.From {
+			done = append(done, TierMove{Hash: m.Hash, Size: m.Size, From: m.From, To: to})
+		}
+	}
+	return done, nil
+}
+
+// moveTier rewrites one item for tier and returns the tier it ended in
+func (s *Safe) moveTier(hash, tier string, cm *compressionManager) (string, error) {
+	unlock := s.lockHash(hash)
+	defer unlock()
+
+	meta, err := s.getMeta(hash)
+	if errors.Is(err, ErrContentNotFound) {
+		return "", nil // deleted since it was planned
+	}
+	if err != nil {
+		return "", fmt.Errorf("getting metadata: %w", err)
+	}
+	if meta.Remote || Tier(meta) == tier {
+		return Tier(meta), nil
+	}
+	content, err := s.load(meta)
+	if err != nil {
+		return "", err
+	}
+
+	moved := meta
+	data := content
+	moved.Compressed = false
+	moved.Cold = tier == TierCold
+	if tier != TierHot {
+		compressed, err := cm.compress("", content)
+		if err != nil {
+			return "", fmt.Errorf("compressing content: %w", err)
+		}
+		if len(compressed) < len(content) {
+			data, moved.Compressed = compressed, true
+		}
+	}
+	if Tier(moved) == Tier(meta) {
+		return Tier(meta), nil // compression does not pay off
+	}
+
+	if moved.Cold {
+		cold := s.coldStore()
+		if cold == nil {
+			return "", ErrNoColdStore
+		}
+		if err := cold.Put(hash, data); err != nil {
+			return "", err
+		}
+	} else if err := writeAtomic(s.contentPath(hash), data); err != nil {
+		return "", err
+	}
+	if err := s.storeMeta(moved); err != nil {
+		return "", fmt.Errorf("storing metadata: %w", err)
+	}
+
+	// The old copy goes once the metadata points at the new one
+	switch {
+	case meta.Cold && !moved.Cold:
+		if cold := s.coldStore(); cold != nil {
+			err = cold.Delete(hash)
+		}
+	case !meta.Cold && moved.Cold:
+		if err = os.Remove(s.contentPath(hash)); os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("removing %s copy: %w", Tier(meta), err)
+	}
+	return Tier(moved), nil
+}
+
+// readStored returns the bytes stored for meta, wherever they are
+func (s *Safe) readStored(meta ContentMeta) ([]byte, error) {
+	if meta.Cold {
+		cold := s.coldStore()
+		if cold == nil {
+			return nil, ErrNoColdStore
+		}
+		data, err := cold.Get(meta.Hash)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrContentNotFound
+		}
+		return data, err
+	}
+	data, err := os.ReadFile(s.contentPath(meta.Hash))
+	if os.IsNotExist(err) {
+		return nil, ErrContentNotFound
+	}
+	return data, err
+}
+
+// removeStored deletes the bytes stored for meta
+func (s *Safe) removeStored(meta ContentMeta) error {
+	if meta.Cold {
+		cold := s.coldStore()
+		if cold == nil {
+			return ErrNoColdStore
+		}
+		return cold.Delete(meta.Hash)
+	}
+	if err := os.Remove(s.contentPath(meta.Hash)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// writeAtomic replaces path with data so readers never see a partial file
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating content directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing content file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing content file: %w", err)
+	}
+	return nil
+}
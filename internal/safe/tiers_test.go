@@ -0,0 +1,117 @@
+// internal/safe/tiers_test.go
+package safe
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTiers(t *testing.T) {
+	s := setupSafe(t)
+	cold := &DirStore{Root: t.TempDir()}
+	s.SetColdStore(cold)
+
+	content := bytes.Repeat([]byte("tiered content\n"), 200)
+	hash, err := s.Store(content)
+	require.NoError(t, err)
+	busy, err := s.Store([]byte("busy"))
+	require.NoError(t, err)
+
+	age := func(hash string, idle time.Duration) {
+		require.NoError(t, s.FlushAccessTimes())
+		meta, err := s.getMeta(hash)
+		require.NoError(t, err)
+		meta.AccessedAt = time.Now().Add(-idle)
+		require.NoError(t, s.storeMeta(meta))
+		s.cache.Purge()
+	}
+	apply := func(policy TierPolicy) []TierMove {
+		moves, err := s.PlanTiers(policy, time.Now())
+		require.NoError(t, err)
+		done, err := s.ApplyTiers(moves, policy)
+		require.NoError(t, err)
+		return done
+	}
+	tierOf := func(hash string) string {
+		meta, err := s.getMeta(hash)
+		require.NoError(t, err)
+		return Tier(meta)
+	}
+
+	policy := TierPolicy{WarmAfter: 24 * time.Hour, ColdAfter: 90 * 24 * time.Hour}
+
+	// Recently read content stays hot
+	assert.Empty(t, apply(policy))
+
+	// Idle content is compressed
+	age(hash, 48*time.Hour)
+	assert.Equal(t, []TierMove{{Hash: hash, Size: int64(len(content)), From: TierHot, To: TierWarm}}, apply(policy))
+	assert.Equal(t, TierHot, tierOf(busy))
+	got, err := s.Get(hash)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// Long idle content leaves the safe's directory
+	age(hash, 100*24*time.Hour)
+	assert.Equal(t, []TierMove{{Hash: hash, Size: int64(len(content)), From: TierWarm, To: TierCold}}, apply(policy))
+	_, err = os.Stat(s.contentPath(hash))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(cold.path(hash))
+	require.NoError(t, err)
+	got, err = s.Get(hash)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// Without a cold store it cannot be read
+	s.SetColdStore(nil)
+	s.cache.Purge()
+	_, err = s.Get(hash)
+	assert.ErrorIs(t, err, ErrNoColdStore)
+	s.SetColdStore(cold)
+
+	// Content read again returns to the hot tier
+	_, err = s.Get(hash)
+	require.NoError(t, err)
+	assert.Equal(t, []TierMove{{Hash: hash, Size: int64(len(content)), From: TierCold, To: TierHot}}, apply(policy))
+	_, err = os.Stat(cold.path(hash))
+	assert.True(t, os.IsNotExist(err))
+
+	// Deleting cold content removes it from the cold store
+	age(hash, 100*24*time.Hour)
+	require.Len(t, apply(policy), 1)
+	require.NoError(t, s.Delete(hash))
+	_, err = os.Stat(cold.path(hash))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTierPolicyMinimums(t *testing.T) {
+	s := setupSafe(t)
+	s.SetColdStore(&DirStore{Root: t.TempDir()})
+
+	hash, err := s.Store(bytes.Repeat([]byte("small\n"), 1000))
+	require.NoError(t, err)
+	meta, err := s.getMeta(hash)
+	require.NoError(t, err)
+	meta.AccessedAt = time.Now().Add(-365 * 24 * time.Hour)
+	require.NoError(t, s.storeMeta(meta))
+
+	// Content below the cold minimum is only compressed
+	policy := TierPolicy{WarmAfter: time.Hour, ColdAfter: time.Hour, ColdMinSize: 1 << 20}
+	moves, err := s.PlanTiers(policy, time.Now())
+	require.NoError(t, err)
+	require.Len(t, moves, 1)
+	assert.Equal(t, TierWarm, moves[0].To)
+
+	// Without a cold store nothing is moved there
+	s.SetColdStore(nil)
+	policy.ColdMinSize = 0
+	moves, err = s.PlanTiers(policy, time.Now())
+	require.NoError(t, err)
+	require.Len(t, moves, 1)
+	assert.Equal(t, TierWarm, moves[0].To)
+}
@@ -0,0 +1,188 @@
+// internal/scrub/scrub.go
+package scrub
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"tig/internal/events"
+	"tig/internal/metrics"
+	"tig/internal/safe"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.uber.org/zap"
+)
+
+// cursorKey stores the last hash verified so scrubbing resumes across restarts
+const cursorKey = "scrub:cursor"
+
+var (
+	verifiedTotal = metrics.NewCounter("tig_scrub_verified_total", "Safe objects verified by the scrubber")
+	corruptTotal  = metrics.NewCounter("tig_scrub_corrupt_total", "Safe objects found corrupt or missing by the scrubber")
+	errorsTotal   = metrics.NewCounter("tig_scrub_errors_total", "Scrubber checks that failed for reasons other than corruption")
+	lastPass      = metrics.NewGauge("tig_scrub_last_pass_timestamp_seconds", "Unix time the scrubber last completed a full pass")
+)
+
+// Options configures the scrub budget
+type Options struct {
+	ObjectsPerHour int           // Verification budget; defaults to 3600
+	Interval       time.Duration // Time between steps; defaults to one minute
+}
+
+// Result summarizes a single scrub step
+type Result struct {
+	Verified int
+	Corrupt  []string
+	Wrapped  bool // A full pass over the safe completed during this step
+}
+
+// Scrubber incrementally verifies Safe objects against their hashes,
+// spreading a per-hour budget evenly over fixed intervals
+type Scrubber struct {
+	db     *badger.DB
+	safe   *safe.Safe
+	events events.Publisher
+	logger *zap.Logger
+	opts   Options
+}
+
+// New creates a scrubber
+func New(db *badger.DB, s *safe.Safe, opts Options, logger *zap.Logger) *Scrubber {
+	if opts.ObjectsPerHour <= 0 {
+		opts.ObjectsPerHour = 3600
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	return &Scrubber{db: db, safe: s, logger: logger, opts: opts}
+}
+
+// WithEvents publishes corruption events to p
+func (s *Scrubber) WithEvents(p events.Publisher) *Scrubber {
+	s.events = p
+	return s
+}
+
+// batchSize is the number of objects verified per step
+func (s *Scrubber) batchSize() int {
+	n := int(float64(s.opts.ObjectsPerHour) * s.opts.Interval.Hours())
+	return max(n, 1)
+}
+
+// Run scrubs on every interval until ctx is cancelled
+func (s *Scrubber) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Step(); err != nil {
+				s.logger.Warn("Scrub step failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Step verifies the next batch of objects after the stored cursor,
+// wrapping to the start of the safe when the end is reached
+func (s *Scrubber) Step() (*Result, error) {
+	cursor, err := s.cursor()
+	if err != nil {
+		return nil, err
+	}
+
+	budget := s.batchSize()
+	hashes, err := s.safe.HashesAfter(cursor, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{}
+	if len(hashes) < budget {
+		res.Wrapped = true
+		if cursor != "" {
+			more, err := s.safe.HashesAfter("", budget-len(hashes))
+			if err != nil {
+				return nil, err
+			}
+			// Don't verify an object twice in one step on small safes
+			for _, h := range more {
+				if h > cursor {
+					break
+				}
+				hashes = append(hashes, h)
+			}
+		}
+	}
+
+	for _, hash := range hashes {
+		s.verify(hash, res)
+	}
+
+	if len(hashes) > 0 {
+		if err := s.setCursor(hashes[len(hashes)-1]); err != nil {
+			return nil, err
+		}
+	}
+	if res.Wrapped {
+		lastPass.Set(float64(time.Now().Unix()))
+	}
+
+	return res, nil
+}
+
+func (s *Scrubber) verify(hash string, res *Result) {
+	err := s.safe.Scrub(hash)
+	switch {
+	case err == nil:
+		res.Verified++
+		verifiedTotal.Inc()
+
+	case errors.Is(err, safe.ErrHashMismatch), errors.Is(err, safe.ErrContentNotFound):
+		res.Corrupt = append(res.Corrupt, hash)
+		corruptTotal.Inc()
+		s.logger.Error("Corrupt content in safe",
+			zap.String("hash", hash),
+			zap.Error(err))
+		if s.events != nil {
+			s.events.Publish(events.Event{
+				Type:    events.ContentCorrupt,
+				Summary: "content " + hash[:12] + ": " + err.Error(),
+				Data:    map[string]string{"hash": hash, "error": err.Error()},
+			})
+		}
+
+	default:
+		errorsTotal.Inc()
+		s.logger.Warn("Failed to scrub content",
+			zap.String("hash", hash),
+			zap.Error(err))
+	}
+}
+
+func (s *Scrubber) cursor() (string, error) {
+	var cursor string
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(cursorKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		val, err := item.ValueCopy(nil)
+		cursor = string(val)
+		return err
+	})
+	return cursor, err
+}
+
+func (s *Scrubber) setCursor(hash string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(cursorKey), []byte(hash))
+	})
+}
@@ -0,0 +1,82 @@
+// internal/scrub/scrub_test.go
+package scrub
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tig/internal/events"
+	"tig/internal/safe"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestStep(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	root := t.TempDir()
+	s, err := safe.New(db, safe.Options{Root: root, CacheSize: 16})
+	require.NoError(t, err)
+	defer s.Close()
+
+	var hashes []string
+	for i := 0; i < 5; i++ {
+		h, err := s.Store([]byte(fmt.Sprintf("object %d", i)))
+		require.NoError(t, err)
+		hashes = append(hashes, h)
+	}
+
+	// Corrupt one object on disk; the cached copy must not hide it
+	bad := hashes[2]
+	require.NoError(t, os.WriteFile(filepath.Join(root, bad[:2], bad[2:]), []byte("bitrot"), 0644))
+
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(events.ContentCorrupt, func(e events.Event) { published = append(published, e) })
+
+	// 120 objects/hour at one-minute steps gives a budget of two per step
+	sc := New(db, s, Options{ObjectsPerHour: 120, Interval: time.Minute}, zap.NewNop()).WithEvents(bus)
+
+	corrupt := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		res, err := sc.Step()
+		require.NoError(t, err)
+		assert.Equal(t, 2, res.Verified+len(res.Corrupt))
+		assert.Equal(t, i == 2, res.Wrapped)
+		for _, h := range res.Corrupt {
+			corrupt[h] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{bad: true}, corrupt)
+	require.NotEmpty(t, published)
+	assert.Equal(t, bad, published[0].Data["hash"])
+
+	for _, h := range hashes {
+		meta, err := s.Meta(h)
+		require.NoError(t, err)
+		assert.Equal(t, h != bad, !meta.VerifiedAt.IsZero(), h)
+	}
+
+	_, err = s.Get(bad)
+	assert.ErrorIs(t, err, safe.ErrHashMismatch)
+
+	// A new scrubber resumes from the stored cursor rather than restarting
+	cursor, err := sc.cursor()
+	require.NoError(t, err)
+	res, err := New(db, s, Options{ObjectsPerHour: 60}, zap.NewNop()).Step()
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Verified+len(res.Corrupt))
+	next, err := sc.cursor()
+	require.NoError(t, err)
+	assert.Greater(t, next, cursor)
+}
@@ -0,0 +1,56 @@
+// internal/selfupdate/keys.go
+package selfupdate
+
+import (
+	"crypto/ed25519"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Release signing public keys, one base64-encoded Ed25519 key per .pub
+// file. Several keys may be trusted at once to allow rotation.
+//
+//go:embed keys
+var keyFiles embed.FS
+
+// EmbeddedKeys returns the release keys compiled into the binary
+func EmbeddedKeys() ([]ed25519.PublicKey, error) {
+	return loadKeys(keyFiles)
+}
+
+func loadKeys(fsys fs.FS) ([]ed25519.PublicKey, error) {
+	matches, err := fs.Glob(fsys, "keys/*.pub")
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []ed25519.PublicKey
+	for _, name := range matches {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParseKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path.Base(name), err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// ParseKey decodes a base64 Ed25519 public key
+func ParseKey(s string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("decoding key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid key length %d", len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
@@ -0,0 +1,65 @@
+// internal/selfupdate/notice.go
+package selfupdate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CheckInterval is how often commands look for a new release in the
+// background
+const CheckInterval = 24 * time.Hour
+
+// CheckState remembers the last background check so that commands check
+// at most once per CheckInterval
+type CheckState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest,omitempty"` // newest release on the channel when checked
+}
+
+// StatePath returns where the background check state is kept, in the
+// user's cache directory
+func StatePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "tig", "update-check.json"), nil
+}
+
+// LoadState reads the check state. A missing file yields a state that is
+// due for a check.
+func LoadState(path string) (CheckState, error) {
+	var s CheckState
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return CheckState{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// SaveState writes the check state, creating its directory if needed
+func SaveState(path string, s CheckState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Due reports whether the next background check should run at now
+func (s CheckState) Due(now time.Time) bool {
+	return now.Sub(s.CheckedAt) >= CheckInterval
+}
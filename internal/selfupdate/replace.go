@@ -0,0 +1,15 @@
+// internal/selfupdate/replace.go
+//go:build !windows
+
+package selfupdate
+
+import "os"
+
+// replaceExecutable atomically renames the new binary over the old one.
+// The running process keeps its open inode, so this is safe while running.
+func replaceExecutable(newPath, exe string) error {
+	return os.Rename(newPath, exe)
+}
+
+// CleanupOld removes leftovers from a previous update; only needed on Windows
+func CleanupOld(exe string) {}
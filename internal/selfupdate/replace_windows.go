@@ -0,0 +1,34 @@
+// internal/selfupdate/replace_windows.go
+//go:build windows
+
+package selfupdate
+
+import (
+	"fmt"
+	"os"
+)
+
+// replaceExecutable swaps in the new binary. Windows won't overwrite or
+// delete a running executable but does allow renaming it, so the current
+// binary is moved aside first and restored if the swap fails.
+func replaceExecutable(newPath, exe string) error {
+	old := exe + ".old"
+	os.Remove(old)
+
+	if err := os.Rename(exe, old); err != nil {
+		return fmt.Errorf("moving current binary aside: %w", err)
+	}
+	if err := os.Rename(newPath, exe); err != nil {
+		if rerr := os.Rename(old, exe); rerr != nil {
+			return fmt.Errorf("%w (restoring previous binary failed: %v)", err, rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// CleanupOld removes the binary left behind by a previous update, which
+// could not be deleted while it was running
+func CleanupOld(exe string) {
+	os.Remove(exe + ".old")
+}
@@ -0,0 +1,279 @@
+// internal/selfupdate/selfupdate.go
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint serves the release manifest
+const DefaultEndpoint = "https://github.com/RobAntunes/TigVCS/releases/latest/download/release.json"
+
+// Release channels
+const (
+	Stable = "stable"
+	Beta   = "beta"
+)
+
+// Manifest lists the current release of each channel
+type Manifest struct {
+	Channels map[string]Release `json:"channels"`
+}
+
+// Release describes one published version
+type Release struct {
+	Version string           `json:"version"`
+	Notes   string           `json:"notes,omitempty"`
+	Assets  map[string]Asset `json:"assets"` // keyed by GOOS-GOARCH, e.g. linux-amd64
+}
+
+// Asset is a downloadable binary for one platform
+type Asset struct {
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	Signature string `json:"signature"` // base64 Ed25519 signature, see SignedMessage
+}
+
+// Platform returns the asset key for the running binary
+func Platform() string {
+	return runtime.GOOS + "-" + runtime.GOARCH
+}
+
+// Updater checks a release endpoint and replaces the running binary
+type Updater struct {
+	Endpoint string
+	Channel  string
+	Current  string // version of the running binary
+	Client   *http.Client
+	Keys     []ed25519.PublicKey // keys trusted to sign releases
+}
+
+// New creates an updater for the given endpoint, channel and current
+// version, trusting the release keys embedded in the binary
+func New(endpoint, channel, current string) (*Updater, error) {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if channel == "" {
+		channel = Stable
+	}
+	if channel != Stable && channel != Beta {
+		return nil, fmt.Errorf("unknown channel %q (use %s or %s)", channel, Stable, Beta)
+	}
+
+	keys, err := EmbeddedKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Updater{
+		Endpoint: endpoint,
+		Channel:  channel,
+		Current:  current,
+		Client: &http.Client{
+			Timeout: 5 * time.Minute,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if req.URL.Scheme != "https" {
+					return fmt.Errorf("refusing redirect to insecure URL %s", req.URL)
+				}
+				return nil
+			},
+		},
+		Keys: keys,
+	}, nil
+}
+
+// Check fetches the release manifest and reports whether the channel's
+// release is newer than the running version
+func (u *Updater) Check(ctx context.Context) (*Release, bool, error) {
+	body, err := u.get(ctx, u.Endpoint)
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching release manifest: %w", err)
+	}
+	defer body.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(body).Decode(&m); err != nil {
+		return nil, false, fmt.Errorf("decoding release manifest: %w", err)
+	}
+	rel, ok := m.Channels[u.Channel]
+	if !ok || rel.Version == "" {
+		return nil, false, fmt.Errorf("release manifest has no %s release", u.Channel)
+	}
+
+	return &rel, Newer(rel.Version, u.Current), nil
+}
+
+// Apply downloads the release asset for this platform, verifies its
+// checksum and signature and replaces the binary at exe
+func (u *Updater) Apply(ctx context.Context, rel *Release, exe string) error {
+	if len(u.Keys) == 0 {
+		return fmt.Errorf("this build has no release signing keys, so updates cannot be verified")
+	}
+
+	asset, ok := rel.Assets[Platform()]
+	if !ok {
+		return fmt.Errorf("release %s has no binary for %s", rel.Version, Platform())
+	}
+
+	exe, err := filepath.EvalSymlinks(exe)
+	if err != nil {
+		return fmt.Errorf("resolving executable: %w", err)
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return fmt.Errorf("reading executable: %w", err)
+	}
+
+	// Download next to the binary so the final rename stays on one filesystem
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".tig-update-*")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	body, err := u.get(ctx, asset.URL)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", rel.Version, err)
+	}
+	defer body.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), body); err != nil {
+		return fmt.Errorf("downloading %s: %w", rel.Version, err)
+	}
+	digest := h.Sum(nil)
+	if got := hex.EncodeToString(digest); !strings.EqualFold(got, asset.SHA256) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", asset.SHA256, got)
+	}
+	if err := u.verify(rel.Version, Platform(), digest, asset.Signature); err != nil {
+		return err
+	}
+
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		return fmt.Errorf("setting permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("syncing download: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := replaceExecutable(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("replacing binary: %w", err)
+	}
+	return nil
+}
+
+// verify checks the asset signature against the trusted keys
+func (u *Updater) verify(version, platform string, digest []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("release %s has a missing or malformed signature", version)
+	}
+
+	msg := SignedMessage(version, platform, digest)
+	for _, key := range u.Keys {
+		if ed25519.Verify(key, msg, sig) {
+			return nil
+		}
+	}
+	return fmt.Errorf("release %s is not signed by a trusted key", version)
+}
+
+// SignedMessage is the message release signatures cover. Binding the
+// version and platform to the binary digest prevents an old or foreign
+// signed binary from being served as a newer release.
+func SignedMessage(version, platform string, digest []byte) []byte {
+	msg := []byte("tig-release\x00" + version + "\x00" + platform + "\x00")
+	return append(msg, digest...)
+}
+
+func (u *Updater) get(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "https" {
+		return nil, fmt.Errorf("refusing insecure URL %s (https is required)", rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Newer reports whether version a is newer than b. Versions are dotted
+// numbers with an optional leading "v"; anything unparseable, such as a
+// "dev" build, sorts before every release.
+func Newer(a, b string) bool {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	switch {
+	case !okA:
+		return false
+	case !okB:
+		return true
+	}
+
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(v, "v")
+	// Ignore pre-release and build suffixes
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil, false
+	}
+
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
@@ -0,0 +1,186 @@
+// internal/selfupdate/selfupdate_test.go
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewer(t *testing.T) {
+	assert.True(t, Newer("v1.2.0", "v1.1.9"))
+	assert.True(t, Newer("1.10.0", "1.9.0"))
+	assert.True(t, Newer("v1.0.1", "dev"))
+	assert.False(t, Newer("v1.0.0", "v1.0"))
+	assert.False(t, Newer("v1.0.0-rc1", "v1.0.0"))
+	assert.False(t, Newer("dev", "v1.0.0"))
+}
+
+// releaseServer serves a manifest with a signed binary on each channel
+func releaseServer(t *testing.T, key ed25519.PrivateKey, binary []byte, sign func(version string, digest []byte) []byte) (*httptest.Server, *Manifest) {
+	m := &Manifest{Channels: map[string]Release{}}
+	mux := http.NewServeMux()
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+
+	digest := sha256.Sum256(binary)
+	for channel, version := range map[string]string{Stable: "v1.1.0", Beta: "v1.2.0-beta.1"} {
+		if sign == nil {
+			sign = func(version string, digest []byte) []byte {
+				return ed25519.Sign(key, SignedMessage(version, Platform(), digest))
+			}
+		}
+		m.Channels[channel] = Release{
+			Version: version,
+			Assets: map[string]Asset{Platform(): {
+				URL:       srv.URL + "/tig",
+				SHA256:    hex.EncodeToString(digest[:]),
+				Signature: base64.StdEncoding.EncodeToString(sign(version, digest[:])),
+			}},
+		}
+	}
+
+	mux.HandleFunc("/release.json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(m)
+	})
+	mux.HandleFunc("/tig", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	})
+	return srv, m
+}
+
+func newTestUpdater(t *testing.T, srv *httptest.Server, channel string, keys ...ed25519.PublicKey) *Updater {
+	u, err := New(srv.URL+"/release.json", channel, "v1.0.0")
+	require.NoError(t, err)
+	u.Client = srv.Client()
+	u.Keys = keys
+	return u
+}
+
+func writeExe(t *testing.T) string {
+	exe := filepath.Join(t.TempDir(), "tig")
+	require.NoError(t, os.WriteFile(exe, []byte("old"), 0755))
+	return exe
+}
+
+func TestUpdate(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	srv, _ := releaseServer(t, priv, []byte("new binary"), nil)
+
+	u := newTestUpdater(t, srv, Beta, pub)
+	rel, newer, err := u.Check(context.Background())
+	require.NoError(t, err)
+	assert.True(t, newer)
+	assert.Equal(t, "v1.2.0-beta.1", rel.Version)
+
+	u = newTestUpdater(t, srv, Stable, pub)
+	rel, newer, err = u.Check(context.Background())
+	require.NoError(t, err)
+	assert.True(t, newer)
+	assert.Equal(t, "v1.1.0", rel.Version)
+
+	exe := writeExe(t)
+	require.NoError(t, u.Apply(context.Background(), rel, exe))
+	data, err := os.ReadFile(exe)
+	require.NoError(t, err)
+	assert.Equal(t, "new binary", string(data))
+
+	info, err := os.Stat(exe)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+}
+
+func TestUpdateRejectsBadSignatures(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	_, other, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	tests := map[string]func(version string, digest []byte) []byte{
+		"untrusted key": func(version string, digest []byte) []byte {
+			return ed25519.Sign(other, SignedMessage(version, Platform(), digest))
+		},
+		"replayed version": func(version string, digest []byte) []byte {
+			return ed25519.Sign(priv, SignedMessage("v0.9.0", Platform(), digest))
+		},
+		"missing": func(string, []byte) []byte { return nil },
+	}
+
+	for name, sign := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv, m := releaseServer(t, priv, []byte("new binary"), sign)
+			rel := m.Channels[Stable]
+			exe := writeExe(t)
+
+			err := newTestUpdater(t, srv, Stable, pub).Apply(context.Background(), &rel, exe)
+			assert.Error(t, err)
+
+			data, _ := os.ReadFile(exe)
+			assert.Equal(t, "old", string(data))
+		})
+	}
+
+	// Builds without keys never install anything
+	srv, m := releaseServer(t, priv, []byte("new binary"), nil)
+	rel := m.Channels[Stable]
+	assert.ErrorContains(t, newTestUpdater(t, srv, Stable).Apply(context.Background(), &rel, writeExe(t)), "no release signing keys")
+}
+
+func TestInsecureEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	u, err := New(srv.URL+"/release.json", Stable, "v1.0.0")
+	require.NoError(t, err)
+	_, _, err = u.Check(context.Background())
+	assert.ErrorContains(t, err, "https is required")
+
+	_, err = New("", "nightly", "v1.0.0")
+	assert.Error(t, err)
+}
+
+func TestLoadKeys(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	keys, err := loadKeys(fstest.MapFS{
+		"keys/release.pub": {Data: []byte(base64.StdEncoding.EncodeToString(pub) + "\n")},
+		"keys/README.md":   {Data: []byte("not a key")},
+	})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, pub, keys[0])
+
+	_, err = loadKeys(fstest.MapFS{"keys/bad.pub": {Data: []byte("AAAA")}})
+	assert.Error(t, err)
+}
+
+func TestCheckState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tig", "update-check.json")
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	s, err := LoadState(path)
+	require.NoError(t, err)
+	assert.True(t, s.Due(now), "a missing state is due")
+
+	require.NoError(t, SaveState(path, CheckState{CheckedAt: now, Latest: "v1.2.0"}))
+	s, err = LoadState(path)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0", s.Latest)
+	assert.False(t, s.Due(now.Add(time.Hour)))
+	assert.True(t, s.Due(now.Add(CheckInterval)))
+}
@@ -0,0 +1,286 @@
+// internal/sentinel/sentinel.go
+package sentinel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
+)
+
+// FileName is the sentinel file in the .tig directory
+const FileName = "sentinel.json"
+
+// dbKey holds the database's half of the seal
+const dbKey = "sentinel:seal"
+
+// version of the sentinel format
+const version = 1
+
+// errMalformed marks a sentinel file that cannot be parsed
+var errMalformed = errors.New("malformed sentinel")
+
+// Prefixes are the database keys a seal covers. Keys under them are only
+// added or removed by tig, so a different set means the database was
+// edited or restored by other means.
+var Prefixes = []string{"changeset:", "intent:", "stream:", "release:", "file_state:"}
+
+// Record is what the sentinel records about a repository. The sentinel
+// file and the database each hold a copy of the ID and generation;
+// restoring either from a backup leaves them apart.
+type Record struct {
+	Version    int                  `json:"version"`
+	ID         string               `json:"id"`
+	Generation uint64               `json:"generation"`
+	Open       bool                 `json:"open,omitempty"` // a session is running, or ended without sealing
+	SealedAt   time.Time            `json:"sealed_at"`
+	Keys       map[string]KeyDigest `json:"keys,omitempty"` // by prefix; only in the sentinel file
+}
+
+// KeyDigest summarizes the keys under a prefix
+type KeyDigest struct {
+	Count int    `json:"count"`
+	Sum   string `json:"sum"` // SHA-256 of the sorted keys
+}
+
+// TamperedError is returned when the .tig directory was changed outside
+// tig or restored in part
+type TamperedError struct {
+	Problems []string
+}
+
+func (e *TamperedError) Error() string {
+	return fmt.Sprintf("repository integrity check failed: %s; run 'tig fsck' to inspect the repository and 'tig fsck --repair' to accept its current state",
+		strings.Join(e.Problems, "; "))
+}
+
+// Path returns the sentinel file of the repository at root
+func Path(root string) string {
+	return filepath.Join(root, ".tig", FileName)
+}
+
+// Check compares the sentinel file of the repository at root with its
+// database and lists every sign of tampering or a partial restore. A
+// repository never sealed has no problems. Key digests are only compared
+// when the last session sealed the repository.
+func Check(root string, db *badger.DB) ([]string, error) {
+	file, err := readFile(root)
+	if errors.Is(err, errMalformed) {
+		return []string{fmt.Sprintf("%s cannot be read: %v", filepath.Join(".tig", FileName), err)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stored, err := readDB(db)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case file == nil && stored == nil:
+		return nil, nil
+	case file == nil:
+		return []string{fmt.Sprintf("%s is missing but the database is sealed", filepath.Join(".tig", FileName))}, nil
+	case stored == nil:
+		return []string{"the database has no seal: it was replaced or restored from a backup older than the sentinel"}, nil
+	case file.ID != stored.ID:
+		return []string{fmt.Sprintf("the database belongs to repository %s, not %s", stored.ID, file.ID)}, nil
+	}
+
+	var problems []string
+	// A session that ended without closing may have sealed the database
+	// but not the file
+	if stored.Generation != file.Generation && !(file.Open && stored.Generation == file.Generation+1) {
+		if stored.Generation < file.Generation {
+			problems = append(problems, fmt.Sprintf("the database is at generation %d but the sentinel at %d: the database was restored from an older backup", stored.Generation, file.Generation))
+		} else {
+			problems = append(problems, fmt.Sprintf("the sentinel is at generation %d but the database at %d: the sentinel was restored from an older backup", file.Generation, stored.Generation))
+		}
+	}
+	if file.Open {
+		return problems, nil
+	}
+
+	keys, err := Digest(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, prefix := range Prefixes {
+		want, got := file.Keys[prefix], keys[prefix]
+		switch {
+		case want.Count != got.Count:
+			problems = append(problems, fmt.Sprintf("%s %d keys were sealed but %d found", strings.TrimSuffix(prefix, ":"), want.Count, got.Count))
+		case want.Sum != got.Sum:
+			problems = append(problems, fmt.Sprintf("%s keys were replaced outside tig", strings.TrimSuffix(prefix, ":")))
+		}
+	}
+	return problems, nil
+}
+
+// Verify checks the repository and fails with a TamperedError if it
+// finds problems
+func Verify(root string, db *badger.DB) error {
+	problems, err := Check(root, db)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return &TamperedError{Problems: problems}
+	}
+	return nil
+}
+
+// Begin marks a session open in the sentinel file, so that a session
+// that never seals is not taken for tampering. Repositories never sealed
+// get their ID here.
+func Begin(root string, db *badger.DB) error {
+	stored, err := readDB(db)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		stored = &Record{Version: version, ID: uuid.NewString(), SealedAt: time.Now().UTC()}
+		if err := writeDB(db, stored); err != nil {
+			return err
+		}
+	}
+	file, err := readFile(root)
+	if err != nil && !errors.Is(err, errMalformed) {
+		return err
+	}
+	if file == nil {
+		file = &Record{Version: version, ID: stored.ID, Generation: stored.Generation}
+	}
+	file.Open = true
+	return writeFile(root, file)
+}
+
+// Seal records the database's current keys and advances the generation
+// of both halves of the seal. The database is written first: a crash in
+// between leaves the sentinel one generation behind, which Check allows
+// for open sessions.
+func Seal(root string, db *badger.DB) error {
+	stored, err := readDB(db)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		stored = &Record{Version: version, ID: uuid.NewString()}
+	}
+	keys, err := Digest(db)
+	if err != nil {
+		return err
+	}
+
+	next := Record{Version: version, ID: stored.ID, Generation: stored.Generation + 1, SealedAt: time.Now().UTC()}
+	if err := writeDB(db, &next); err != nil {
+		return err
+	}
+	next.Keys = keys
+	return writeFile(root, &next)
+}
+
+// Digest summarizes the keys under each of Prefixes
+func Digest(db *badger.DB) (map[string]KeyDigest, error) {
+	digests := make(map[string]KeyDigest, len(Prefixes))
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		for _, prefix := range Prefixes {
+			opts.Prefix = []byte(prefix)
+			it := txn.NewIterator(opts)
+			h := sha256.New()
+			n := 0
+			for it.Rewind(); it.Valid(); it.Next() {
+				h.Write(it.Item().Key())
+				h.Write([]byte{0})
+				n++
+			}
+			it.Close()
+			digests[prefix] = KeyDigest{Count: n, Sum: hex.EncodeToString(h.Sum(nil))}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("digesting keys: %w", err)
+	}
+	return digests, nil
+}
+
+// readFile returns the sentinel file, or nil if there is none
+func readFile(root string) (*Record, error) {
+	data, err := os.ReadFile(Path(root))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading sentinel: %w", err)
+	}
+	var s Record
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%w: %v", errMalformed, err)
+	}
+	return &s, nil
+}
+
+// writeFile replaces the sentinel file
+func writeFile(root string, s *Record) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := Path(root)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing sentinel: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing sentinel: %w", err)
+	}
+	return nil
+}
+
+// readDB returns the database's seal, or nil if there is none
+func readDB(db *badger.DB) (*Record, error) {
+	var s *Record
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(dbKey))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s = &Record{}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, s)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading database seal: %w", err)
+	}
+	return s, nil
+}
+
+// writeDB stores the database's seal
+func writeDB(db *badger.DB, s *Record) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(dbKey), data)
+	}); err != nil {
+		return fmt.Errorf("writing database seal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// internal/sentinel/sentinel_test.go
+package sentinel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T) (string, *badger.DB) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".tig"), 0755))
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return root, db
+}
+
+func set(t *testing.T, db *badger.DB, key string) {
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(key), []byte("{}"))
+	}))
+}
+
+func del(t *testing.T, db *badger.DB, key string) {
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	}))
+}
+
+// session runs one sealed session that writes key
+func session(t *testing.T, root string, db *badger.DB, key string) {
+	require.NoError(t, Verify(root, db))
+	require.NoError(t, Begin(root, db))
+	set(t, db, key)
+	require.NoError(t, Seal(root, db))
+}
+
+func TestSessions(t *testing.T) {
+	root, db := setup(t)
+
+	// An unsealed repository is accepted and sealed
+	set(t, db, "changeset:a")
+	session(t, root, db, "intent:a")
+	session(t, root, db, "intent:b")
+
+	// A session that never sealed is not tampering
+	require.NoError(t, Begin(root, db))
+	set(t, db, "changeset:b")
+	require.NoError(t, Verify(root, db))
+	require.NoError(t, Seal(root, db))
+	require.NoError(t, Verify(root, db))
+}
+
+func TestTampering(t *testing.T) {
+	root, db := setup(t)
+	session(t, root, db, "changeset:a")
+
+	// Keys removed behind tig's back
+	del(t, db, "changeset:a")
+	problems, err := Check(root, db)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"changeset 1 keys were sealed but 0 found"}, problems)
+	var tampered *TamperedError
+	assert.ErrorAs(t, Verify(root, db), &tampered)
+
+	// Replaced by other keys
+	set(t, db, "changeset:b")
+	problems, err = Check(root, db)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"changeset keys were replaced outside tig"}, problems)
+
+	// Accepting the state seals it again
+	require.NoError(t, Seal(root, db))
+	require.NoError(t, Verify(root, db))
+}
+
+func TestPartialRestore(t *testing.T) {
+	root, db := setup(t)
+	session(t, root, db, "changeset:a")
+	backup, err := os.ReadFile(Path(root))
+	require.NoError(t, err)
+	session(t, root, db, "changeset:b")
+
+	// The sentinel restored from a backup of an earlier session
+	require.NoError(t, os.WriteFile(Path(root), backup, 0644))
+	problems, err := Check(root, db)
+	require.NoError(t, err)
+	require.NotEmpty(t, problems)
+	assert.Contains(t, problems[0], "the sentinel was restored from an older backup")
+
+	// The sentinel removed
+	require.NoError(t, os.Remove(Path(root)))
+	problems, err = Check(root, db)
+	require.NoError(t, err)
+	assert.Len(t, problems, 1)
+
+	// The database replaced by one never sealed
+	require.NoError(t, Seal(root, db))
+	_, fresh := setup(t)
+	problems, err = Check(root, fresh)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"the database has no seal: it was replaced or restored from a backup older than the sentinel"}, problems)
+
+	// An unreadable sentinel
+	require.NoError(t, os.WriteFile(Path(root), []byte("{"), 0644))
+	problems, err = Check(root, db)
+	require.NoError(t, err)
+	assert.Len(t, problems, 1)
+}
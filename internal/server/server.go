@@ -0,0 +1,366 @@
+// internal/server/server.go
+package server
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"tig/internal/api"
+	"tig/internal/assign"
+	"tig/internal/config"
+	"tig/internal/conflict"
+	"tig/internal/events"
+	"tig/internal/health"
+	"tig/internal/highlight"
+	"tig/internal/hooks"
+	"tig/internal/intent"
+	intentStorage "tig/internal/intent/storage"
+	"tig/internal/license"
+	"tig/internal/logging"
+	"tig/internal/merge"
+	"tig/internal/metrics"
+	"tig/internal/middleware"
+	"tig/internal/notify"
+	"tig/internal/plugin"
+	"tig/internal/query"
+	"tig/internal/release"
+	"tig/internal/review"
+	"tig/internal/safe"
+	"tig/internal/scrub"
+	"tig/internal/stale"
+	"tig/internal/storage"
+	"tig/internal/stream"
+	streamStorage "tig/internal/stream/storage"
+	ws "tig/internal/workspace"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.uber.org/zap"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// Server serves the HTTP API and web UI for a repository
+type Server struct {
+	cfg      *config.Config
+	logger   *logging.Logger
+	handler  http.Handler
+	Bus      *events.Bus
+	notifier *notify.Notifier
+	plugins  *plugin.Runtime
+	cancel   context.CancelFunc
+}
+
+// New wires the API handlers, notifications and background jobs on top of
+// an open database and content safe. root is the workspace directory.
+func New(cfg *config.Config, db *badger.DB, contentSafe *safe.Safe, root string, logger *logging.Logger) (*Server, error) {
+	// Initialize workspace
+	workspace, err := ws.NewLocalWorkspace(root, db, contentSafe)
+	if err != nil {
+		return nil, fmt.Errorf("initializing workspace: %w", err)
+	}
+
+	// Initialize repositories
+	intentStore := intentStorage.NewStore(db, workspace)
+	streamStore := streamStorage.NewStore(db, intentStore)
+
+	// Initialize event bus and chat notifications
+	bus := events.NewBus()
+	notifier, err := notify.New(cfg.Notifications, logger.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("initializing notifier: %w", err)
+	}
+	bus.SubscribeAll(notifier.Handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		cfg:      cfg,
+		logger:   logger,
+		Bus:      bus,
+		notifier: notifier,
+		cancel:   cancel,
+	}
+
+	// Background integrity scrubbing of the content safe
+	if cfg.Scrub.Enabled {
+		interval, err := time.ParseDuration(cfg.Scrub.Interval)
+		if err != nil && cfg.Scrub.Interval != "" {
+			s.Close()
+			return nil, fmt.Errorf("invalid scrub interval: %w", err)
+		}
+		scrubber := scrub.New(db, contentSafe, scrub.Options{
+			ObjectsPerHour: cfg.Scrub.ObjectsPerHour,
+			Interval:       interval,
+		}, logger.Logger).WithEvents(bus)
+		go scrubber.Run(ctx)
+	}
+
+	// Reminders about intents idle in review and streams left unsynced
+	if cfg.Stale.Enabled {
+		var opts stale.Options
+		for _, d := range []struct {
+			name, value string
+			dst         *time.Duration
+		}{
+			{"interval", cfg.Stale.Interval, &opts.Interval},
+			{"intent_age", cfg.Stale.IntentAge, &opts.IntentAge},
+			{"stream_age", cfg.Stale.StreamAge, &opts.StreamAge},
+			{"remind_every", cfg.Stale.RemindEvery, &opts.RemindEvery},
+		} {
+			if d.value == "" {
+				continue
+			}
+			if *d.dst, err = query.ParseDuration(d.value); err != nil {
+				s.Close()
+				return nil, fmt.Errorf("invalid stale %s: %w", d.name, err)
+			}
+		}
+		monitor := stale.New(db, intentStore, streamStore, opts, logger.Logger).WithEvents(bus)
+		go monitor.Run(ctx)
+	}
+
+	// Server-side hooks on received and merging intents
+	hookRunner, err := hooks.New(cfg.Hooks, root, logger.Logger)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("configuring hooks: %w", err)
+	}
+
+	// Merge queue, with automatic merging for AutoMerge streams
+	queue := merge.NewQueue(db, streamStore, intentStore).WithEvents(bus).WithSpeculation()
+	if hookRunner.Has(hooks.PreMerge) {
+		queue.WithGate(func(st *stream.Stream, i *intent.Intent, by string) error {
+			return hookRunner.Run(ctx, hooks.Payload{Event: hooks.PreMerge, Intent: i, Stream: st, By: by})
+		})
+	}
+	autoMerger := merge.NewAutoMerger(streamStore, intentStore, queue, logger.Logger)
+	autoMerger.Subscribe(bus)
+
+	// Reviewers assigned from a stream's reviewer group as intents join it
+	assign.NewAssigner(db, streamStore, intentStore, logger.Logger).Subscribe(bus)
+
+	// Release manifests for every landing on a release stream
+	release.NewRecorder(db, contentSafe, streamStore, logger.Logger).Subscribe(bus)
+	if err := autoMerger.Sweep(); err != nil {
+		logger.Warn("auto-merge sweep failed", zap.Error(err))
+	}
+
+	// Enterprise feature groups enabled by the license
+	keys, err := license.EmbeddedKeys()
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("loading license keys: %w", err)
+	}
+	licenses := license.Open(cfg.License.File(root), keys)
+	if status := licenses.Status(); status.Error != "" {
+		logger.Warn("license is invalid", zap.String("path", status.Path), zap.String("error", status.Error))
+	}
+
+	// WebAssembly plugins, compiled on first use
+	plugins, err := plugin.New(ctx, plugin.Options{})
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("initializing plugins: %w", err)
+	}
+	s.plugins = plugins
+
+	// Initialize handlers
+	intentHandler := api.NewIntentHandler(intentStore).WithEvents(bus).WithFields(cfg.IntentFields).WithHooks(hookRunner).WithWorkflow(cfg.Workflow)
+	streamHandler := api.NewStreamHandler(streamStore).WithEvents(bus)
+	mergeHandler := api.NewMergeHandler(queue, streamStore)
+	statsHandler := api.NewStatsHandler(db).WithSafe(contentSafe).WithStores(intentStore, streamStore)
+	historyHandler := api.NewHistoryHandler(db)
+	reportHandler := api.NewReportHandler(db, intentStore)
+	txIntents := func(u *storage.UnitOfWork) api.IntentWriter { return intentStore.With(u) }
+	syncHandler := api.NewSyncHandler(db, contentSafe, txIntents, streamStore).WithFields(cfg.IntentFields).WithHooks(hookRunner).WithWorkflow(cfg.Workflow)
+	conflictHandler := api.NewConflictHandler(conflict.New(db, streamStore))
+	healthHandler := api.NewHealthHandler(health.New(db, contentSafe, cfg.Health.MinFree()))
+	releaseHandler := api.NewReleaseHandler(db, contentSafe)
+	deployHandler := api.NewDeployHandler(db, contentSafe, intentStore, streamStore).WithEvents(bus)
+	buildCacheHandler := api.NewBuildCacheHandler(db)
+	assignHandler := api.NewAssignHandler(db, intentStore).WithEvents(bus)
+	coverageHandler := api.NewCoverageHandler(db, intentStore).WithEvents(bus)
+	riskHandler := api.NewRiskHandler(db, contentSafe, intentStore)
+	incidentHandler := api.NewIncidentHandler(db, intentStore).WithEvents(bus)
+	pluginHandler := api.NewPluginHandler(db, contentSafe, intentStore, root, plugins)
+	diffHandler := api.NewDiffHandler(db, contentSafe, intentStore, highlight.New(!cfg.Diff.DisableHighlight)).WithOptions(review.Options{
+		Structural:      cfg.Diff.IsStructural,
+		NotebookOutputs: cfg.Diff.NotebookOutputs,
+	})
+
+	// Set up router
+	mux := http.NewServeMux()
+
+	// Health checks
+	mux.HandleFunc("GET /health", healthCheck)
+	mux.HandleFunc("GET /healthz/deep", healthHandler.Deep)
+	storage.RegisterCacheMetrics(db)
+	mux.Handle("GET /metrics", metrics.Default.Handler())
+
+	// Intent endpoints
+	mux.HandleFunc("GET /api/intents", intentHandler.List)
+	mux.HandleFunc("POST /api/intents", intentHandler.Create)
+	mux.HandleFunc("GET /api/intents/{id}", intentHandler.Get)
+	mux.HandleFunc("PUT /api/intents/{id}", intentHandler.Update)
+	mux.HandleFunc("DELETE /api/intents/{id}", intentHandler.Delete)
+	mux.HandleFunc("POST /api/intents/{id}/reviews", intentHandler.AddReview)
+	mux.HandleFunc("POST /api/intents/{id}/state", intentHandler.SetState)
+	mux.HandleFunc("GET /api/workflow", intentHandler.Workflow)
+	mux.HandleFunc("GET /api/intents/{id}/assignments", assignHandler.List)
+	mux.HandleFunc("POST /api/intents/{id}/assignments", assignHandler.Assign)
+	mux.HandleFunc("POST /api/intents/{id}/assignments/{reviewer}/reassign", assignHandler.Reassign)
+	mux.HandleFunc("GET /api/assignments", assignHandler.Open)
+	mux.HandleFunc("GET /api/review-groups", assignHandler.Groups)
+	mux.HandleFunc("PUT /api/review-groups/{name}", assignHandler.SetGroup)
+	mux.HandleFunc("GET /api/plugins", pluginHandler.List)
+	mux.HandleFunc("POST /api/intents/{id}/plugins/{name}", pluginHandler.Run)
+	mux.HandleFunc("POST /api/intents/{id}/checks", intentHandler.SetCheck)
+	mux.HandleFunc("POST /api/intents/{id}/checks/junit", intentHandler.SetJUnitCheck)
+	mux.HandleFunc("POST /api/intents/{id}/coverage", coverageHandler.Record)
+	mux.HandleFunc("GET /api/intents/{id}/risk", riskHandler.Get)
+	mux.HandleFunc("GET /api/risk", riskHandler.List)
+	mux.HandleFunc("GET /api/intents/{id}/diff", diffHandler.Intent)
+	mux.HandleFunc("GET /api/thumbnails/{hash}", diffHandler.Thumbnail)
+	mux.HandleFunc("GET /api/intents/{id}/history", historyHandler.Entity("intent"))
+
+	// Stream endpoints
+	mux.HandleFunc("GET /api/streams", streamHandler.List)
+	mux.HandleFunc("POST /api/streams", streamHandler.Create)
+	mux.HandleFunc("GET /api/streams/{id}", streamHandler.Get)
+	mux.HandleFunc("DELETE /api/streams/{id}", streamHandler.Delete)
+	mux.HandleFunc("GET /api/streams/{id}/intents", streamHandler.GetIntents)
+	mux.HandleFunc("POST /api/streams/{id}/intents", streamHandler.AddIntent)
+	mux.HandleFunc("POST /api/streams/{id}/feature-flags", streamHandler.SetFeatureFlag)
+	mux.HandleFunc("GET /api/streams/{id}/feature-flags", streamHandler.GetFeatureFlags)
+	mux.HandleFunc("PUT /api/streams/{id}/protection", streamHandler.SetProtection)
+	mux.HandleFunc("GET /api/streams/{id}/queue", mergeHandler.Queue)
+	mux.HandleFunc("POST /api/streams/{id}/queue", mergeHandler.Enqueue)
+	mux.HandleFunc("GET /api/streams/{id}/history", historyHandler.Entity("stream"))
+
+	// Mutation log replay for consumers that missed webhook deliveries
+	mux.HandleFunc("GET /api/events", historyHandler.Events)
+
+	// Potential conflicts between open intents
+	mux.HandleFunc("GET /api/conflicts", conflictHandler.List)
+
+	// Clone and push support
+	mux.Handle("GET /api/sync/metadata", licenses.Require(license.FeatureReplication, http.HandlerFunc(syncHandler.Metadata)))
+	mux.Handle("POST /api/sync/negotiate", licenses.Require(license.FeatureReplication, http.HandlerFunc(syncHandler.Negotiate)))
+	mux.Handle("POST /api/sync/push", licenses.Require(license.FeatureReplication, http.HandlerFunc(syncHandler.PushBundle)))
+	mux.Handle("POST /api/sync/pull", licenses.Require(license.FeatureReplication, http.HandlerFunc(syncHandler.PullBundle)))
+	mux.Handle("GET /api/content/{hash}", licenses.Require(license.FeatureReplication, http.HandlerFunc(syncHandler.Content)))
+	mux.Handle("GET /api/content/{hash}/meta", licenses.Require(license.FeatureReplication, http.HandlerFunc(syncHandler.ContentMeta)))
+	mux.Handle("GET /api/content/lookup", licenses.Require(license.FeatureReplication, http.HandlerFunc(syncHandler.Lookup)))
+	mux.Handle("POST /api/transfer/manifest", licenses.Require(license.FeatureReplication, http.HandlerFunc(syncHandler.Manifest)))
+	mux.Handle("POST /api/transfer/blobs", licenses.Require(license.FeatureReplication, http.HandlerFunc(syncHandler.Blobs)))
+	mux.Handle("POST /api/transfer/push", licenses.Require(license.FeatureReplication, http.HandlerFunc(syncHandler.Push)))
+
+	// Release tags and their manifests
+	mux.HandleFunc("GET /api/releases", releaseHandler.List)
+	mux.HandleFunc("GET /api/releases/{tag...}", releaseHandler.Get)
+
+	// What is running in each environment
+	mux.HandleFunc("GET /api/deployments", deployHandler.List)
+	mux.HandleFunc("POST /api/deployments", deployHandler.Record)
+	mux.HandleFunc("GET /api/deployments/current", deployHandler.Current)
+	mux.HandleFunc("GET /api/deployments/rollback", deployHandler.PlanRollback)
+	mux.HandleFunc("POST /api/deployments/rollback", deployHandler.Rollback)
+
+	// Incidents and the intents that caused them
+	mux.HandleFunc("GET /api/incidents", incidentHandler.List)
+	mux.HandleFunc("POST /api/incidents/{id}/causes", incidentHandler.Link)
+	mux.HandleFunc("DELETE /api/incidents/{id}/causes/{intent}", incidentHandler.Unlink)
+
+	// Repository statistics
+	mux.HandleFunc("GET /api/stats/churn", statsHandler.Churn)
+	mux.HandleFunc("GET /api/stats/cache", statsHandler.Cache)
+	mux.HandleFunc("GET /api/stats/authors", statsHandler.Authors)
+
+	// Build contents for CI and deploy pipelines
+	mux.HandleFunc("GET /api/reports/contents", reportHandler.Contents)
+	mux.HandleFunc("GET /api/reports/hotspots", reportHandler.Hotspots)
+	mux.HandleFunc("GET /api/build-cache/{key}", buildCacheHandler.Get)
+	mux.HandleFunc("PUT /api/build-cache/{key}", buildCacheHandler.Set)
+
+	// Administration
+	mux.HandleFunc("GET /api/admin/status", healthHandler.Deep)
+	mux.HandleFunc("GET /api/admin/license", licenses.Handler)
+
+	// Web UI
+	ui, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("loading web UI: %w", err)
+	}
+	mux.Handle("GET /highlight.css", licenses.Require(license.FeatureWebUI, http.HandlerFunc(diffHandler.CSS)))
+	mux.Handle("GET /", licenses.Require(license.FeatureWebUI, http.FileServer(http.FS(ui))))
+
+	// Apply middleware
+	s.handler = middleware.Chain(
+		mux,
+		middleware.Fields,
+		middleware.ETag,
+		middleware.RequestID,
+		middleware.Logger(logger),
+		middleware.Recover(logger),
+	)
+
+	return s, nil
+}
+
+// Handler returns the root HTTP handler
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Addr returns the configured listen address
+func (s *Server) Addr() string {
+	return fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.Port)
+}
+
+// ListenAndServe serves until ctx is cancelled, then shuts down gracefully
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{Addr: s.Addr(), Handler: s.handler}
+
+	errc := make(chan error, 1)
+	go func() {
+		s.logger.Info("starting server", zap.String("address", srv.Addr))
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Close stops background jobs and flushes pending notifications
+func (s *Server) Close() error {
+	s.cancel()
+	s.notifier.Close()
+	if s.plugins != nil {
+		s.plugins.Close(context.Background())
+	}
+	return nil
+}
+
+func healthCheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"healthy"}`))
+}
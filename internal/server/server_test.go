@@ -0,0 +1,551 @@
+// internal/server/server_test.go
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tig/client"
+	"tig/internal/buildcache"
+	"tig/internal/change"
+	"tig/internal/config"
+	"tig/internal/intent"
+	"tig/internal/logging"
+	"tig/internal/remote"
+	"tig/internal/safe"
+	"tig/internal/stream"
+	"tig/shared/types"
+	"tig/shared/utils"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testServer is a server over an in-memory database, with the database,
+// safe and repository root it was started on
+type testServer struct {
+	*Server
+	db   *badger.DB
+	safe *safe.Safe
+	root string
+}
+
+// newTestServer starts a server with the default configuration, after
+// any configure funcs have adjusted it. The server, safe and database
+// are closed in that order when the test ends.
+func newTestServer(t *testing.T, configure ...func(*config.Config)) *testServer {
+	t.Helper()
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	cfg := config.Default()
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	root := t.TempDir()
+	srv, err := New(cfg, db, s, root, &logging.Logger{Logger: zap.NewNop()})
+	require.NoError(t, err)
+	t.Cleanup(func() { srv.Close() })
+	return &testServer{Server: srv, db: db, safe: s, root: root}
+}
+
+func TestRoutes(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, do("GET", "/health", "").Code)
+	rec := do("GET", "/healthz/deep", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"content","status":"ok"`)
+	assert.Contains(t, do("GET", "/api/admin/status", "").Body.String(), `"name":"badger"`)
+
+	rec = do("POST", "/api/intents", `{"description":"add server","type":"feature"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, do("GET", "/api/intents", "").Body.String(), "add server")
+
+	assert.Equal(t, "[]\n", do("GET", "/api/conflicts", "").Body.String())
+	assert.Contains(t, do("GET", "/api/stats/churn", "").Body.String(), `"changesets":0`)
+	assert.Contains(t, do("GET", "/api/stats/cache", "").Body.String(), `"name":"content","enabled":true,"capacity":16`)
+	assert.Contains(t, do("GET", "/metrics", "").Body.String(), "tig_badger_block_cache_hits_total")
+
+	var created intent.Intent
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "[]\n", do("GET", "/api/intents/"+created.ID+"/diff", "").Body.String())
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/intents/missing/diff", "").Code)
+	assert.Contains(t, do("GET", "/api/intents/"+created.ID+"/history", "").Body.String(), `"kind":"create"`)
+	assert.Contains(t, do("GET", "/api/events?entity=intent&limit=1", "").Body.String(), `"id":"`+created.ID+`"`)
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/api/events?since=yesterday", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/reports/contents?from=a&to=b", "").Code)
+	assert.Contains(t, do("GET", "/highlight.css", "").Body.String(), ".chroma")
+
+	rec = do("GET", "/", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<title>Tig</title>")
+
+	assert.Equal(t, http.StatusMethodNotAllowed, do("PATCH", "/api/intents", "").Code)
+}
+
+func TestListPagination(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	assert.Equal(t, "[]\n", do("/api/streams").Body.String())
+
+	for i := 0; i < 5; i++ {
+		rec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"description":"intent %d","type":"feature"}`, i)
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/api/intents", strings.NewReader(body)))
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+
+	// The full list is streamed as one array
+	var all []intent.Intent
+	require.NoError(t, json.Unmarshal(do("/api/intents").Body.Bytes(), &all))
+	require.Len(t, all, 5)
+
+	var seen []string
+	path := "/api/intents?limit=2"
+	for {
+		rec := do(path)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var page []intent.Intent
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		assert.LessOrEqual(t, len(page), 2)
+		for _, i := range page {
+			seen = append(seen, i.ID)
+		}
+		next := rec.Header().Get("X-Next-Cursor")
+		if next == "" {
+			break
+		}
+		path = "/api/intents?limit=2&after=" + next
+	}
+	require.Len(t, seen, 5)
+	for i, item := range all {
+		assert.Equal(t, item.ID, seen[i])
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("/api/intents?limit=0").Code)
+	assert.Equal(t, http.StatusBadRequest, do("/api/streams?limit=5000").Code)
+}
+
+func TestAutoMergeRoutes(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder, v any) {
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(v))
+	}
+
+	var st stream.Stream
+	decode(do("POST", "/api/streams", `{"name":"main","type":"feature","config":{"auto_merge":true,"protection":{"required_reviewers":1,"required_checks":["ci"]}}}`), &st)
+	var i intent.Intent
+	decode(do("POST", "/api/intents", `{"description":"fix","type":"fix"}`), &i)
+	require.Equal(t, http.StatusOK, do("POST", "/api/streams/"+st.ID+"/intents", `{"intent_id":"`+i.ID+`"}`).Code)
+
+	rec := do("POST", "/api/streams/"+st.ID+"/queue", `{"intent_id":"`+i.ID+`"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "0 of 1 required approvals")
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/intents/"+i.ID+"/checks", `{"name":"ci","status":"green"}`).Code)
+	require.Equal(t, http.StatusOK, do("POST", "/api/intents/"+i.ID+"/reviews", `{"reviewer":"alice","approved":true}`).Code)
+	require.Equal(t, http.StatusOK, do("POST", "/api/intents/"+i.ID+"/checks", `{"name":"ci","status":"passed"}`).Code)
+
+	decode(do("GET", "/api/streams/"+st.ID, ""), &st)
+	assert.Equal(t, []string{i.ID}, st.State.Merged)
+	decode(do("GET", "/api/intents/"+i.ID, ""), &i)
+	assert.Equal(t, intent.StateLanded, i.State)
+
+	// JUnit reports are recorded as checks
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/intents/"+i.ID+"/checks/junit", "not xml").Code)
+	rec = do("POST", "/api/intents/"+i.ID+"/checks/junit?name=unit", `<testsuite><testcase name="a"/><testcase name="b"><failure/></testcase></testsuite>`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	decode(rec, &i)
+	assert.Equal(t, intent.CheckFailed, i.CheckStatus("unit"))
+	require.Len(t, i.Checks, 2)
+	assert.Equal(t, 1, i.Checks[1].Tests.Failed)
+}
+
+func TestIntentStateRoutes(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	var i intent.Intent
+	require.NoError(t, json.Unmarshal(do("POST", "/api/intents", `{"description":"fix","type":"fix"}`).Body.Bytes(), &i))
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/intents", `{"description":"fix","state":"shipped"}`).Code)
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/intents/"+i.ID+"/state", `{"state":"shipped"}`).Code)
+	assert.Equal(t, http.StatusConflict, do("POST", "/api/intents/"+i.ID+"/state", `{"state":"landed"}`).Code)
+	assert.Equal(t, http.StatusNotFound, do("POST", "/api/intents/missing/state", `{"state":"in-review"}`).Code)
+
+	rec := do("POST", "/api/intents/"+i.ID+"/state", `{"state":"in-review","by":"alice"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"state":"in-review"`)
+
+	// An approving review moves it on
+	require.Equal(t, http.StatusOK, do("POST", "/api/intents/"+i.ID+"/reviews", `{"reviewer":"bob","approved":true}`).Code)
+	assert.Contains(t, do("GET", "/api/intents?state=approved", "").Body.String(), i.ID)
+	assert.Equal(t, "[]\n", do("GET", "/api/intents?state=draft", "").Body.String())
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/api/intents?state=shipped", "").Code)
+
+	// Updates do not bypass the workflow
+	require.Equal(t, http.StatusOK, do("PUT", "/api/intents/"+i.ID, `{"description":"fix","type":"fix","state":"landed"}`).Code)
+	assert.Contains(t, do("GET", "/api/intents/"+i.ID, "").Body.String(), `"state":"approved"`)
+
+	assert.Contains(t, do("GET", "/api/workflow", "").Body.String(), `"draft":["in-review"]`)
+}
+
+func TestContentLookupRoutes(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	hash, err := srv.safe.Store([]byte("stored"))
+	require.NoError(t, err)
+	_, err = srv.safe.Store([]byte("stored"))
+	require.NoError(t, err)
+	absent := strings.Repeat("0", 64)
+
+	rec := do("/api/content/" + hash + "/meta")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var info remote.ContentInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.Equal(t, remote.ContentInfo{Hash: hash, Present: true, Size: 6, RefCount: 2, Tier: safe.TierHot}, info)
+	assert.Equal(t, http.StatusNotFound, do("/api/content/"+absent+"/meta").Code)
+	assert.Equal(t, http.StatusBadRequest, do("/api/content/nothex/meta").Code)
+
+	rec = do("/api/content/lookup?hash=" + absent + "," + hash)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var infos []remote.ContentInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &infos))
+	require.Len(t, infos, 2)
+	assert.Equal(t, remote.ContentInfo{Hash: absent}, infos[0])
+	assert.True(t, infos[1].Present)
+
+	assert.Equal(t, http.StatusBadRequest, do("/api/content/lookup").Code)
+	assert.Equal(t, http.StatusBadRequest, do("/api/content/lookup?hash=nothex").Code)
+}
+
+func TestBuildCacheRoutes(t *testing.T) {
+	srv := newTestServer(t)
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	client := remote.NewClient(ts.URL)
+	ctx := context.Background()
+
+	tree := strings.Repeat("cd", 32)
+	e, err := client.BuildCache(ctx, tree)
+	require.NoError(t, err)
+	assert.Nil(t, e)
+
+	stored, err := client.SetBuildCache(ctx, tree, &buildcache.Entry{
+		Artifacts: []buildcache.Artifact{{Name: "app", Location: "https://ci.example.com/app"}},
+		Builder:   "ci",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, tree, stored.Key)
+
+	e, err = client.BuildCache(ctx, tree)
+	require.NoError(t, err)
+	require.NotNil(t, e)
+	assert.Equal(t, "https://ci.example.com/app", e.Artifacts[0].Location)
+	assert.Equal(t, "ci", e.Builder)
+
+	_, err = client.SetBuildCache(ctx, tree, &buildcache.Entry{})
+	assert.ErrorContains(t, err, "400")
+	_, err = client.BuildCache(ctx, "not-a-changeset")
+	assert.ErrorContains(t, err, "400")
+}
+
+func TestThumbnailRoute(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 400, 200))))
+	hash, err := srv.safe.Store(buf.Bytes())
+	require.NoError(t, err)
+	text, err := srv.safe.Store([]byte("not an image"))
+	require.NoError(t, err)
+
+	rec := do("/api/thumbnails/" + hash + "?size=100")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	img, err := png.Decode(rec.Body)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), img.Bounds())
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, do("/api/thumbnails/"+text).Code)
+	assert.Equal(t, http.StatusNotFound, do("/api/thumbnails/"+strings.Repeat("0", 64)).Code)
+	assert.Equal(t, http.StatusBadRequest, do("/api/thumbnails/"+hash+"?size=0").Code)
+}
+
+func TestReviewAssignmentRoutes(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder, v any) {
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(v))
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("PUT", "/api/review-groups/backend", `{"members":["alice"],"strategy":"random"}`).Code)
+	require.Equal(t, http.StatusOK, do("PUT", "/api/review-groups/backend", `{"members":["alice","bob","carol"]}`).Code)
+
+	// Joining a stream with a reviewer group assigns its required reviewers
+	var st stream.Stream
+	decode(do("POST", "/api/streams", `{"name":"main","type":"feature","config":{"protection":{"required_reviewers":2,"reviewer_group":"backend"}}}`), &st)
+	var i intent.Intent
+	decode(do("POST", "/api/intents", `{"description":"fix","type":"fix","metadata":{"author":"alice"}}`), &i)
+	require.Equal(t, http.StatusOK, do("POST", "/api/streams/"+st.ID+"/intents", `{"intent_id":"`+i.ID+`"}`).Code)
+
+	var assignments []struct {
+		Reviewer      string `json:"reviewer"`
+		DelegatedFrom string `json:"delegated_from"`
+		Done          bool   `json:"done"`
+	}
+	decode(do("GET", "/api/intents/"+i.ID+"/assignments", ""), &assignments)
+	require.Len(t, assignments, 2)
+	assert.ElementsMatch(t, []string{"bob", "carol"}, []string{assignments[0].Reviewer, assignments[1].Reviewer})
+
+	// Bob hands his review to dave; carol's review closes hers
+	require.Equal(t, http.StatusOK, do("POST", "/api/intents/"+i.ID+"/assignments/bob/reassign", `{"to":"dave"}`).Code)
+	assert.Equal(t, http.StatusNotFound, do("POST", "/api/intents/"+i.ID+"/assignments/bob/reassign", `{"to":"erin"}`).Code)
+	require.Equal(t, http.StatusOK, do("POST", "/api/intents/"+i.ID+"/reviews", `{"reviewer":"carol","approved":true}`).Code)
+
+	decode(do("GET", "/api/assignments", ""), &assignments)
+	require.Len(t, assignments, 1)
+	assert.Equal(t, "dave", assignments[0].Reviewer)
+	assert.Equal(t, "bob", assignments[0].DelegatedFrom)
+}
+
+func TestSparseFieldsets(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do("POST", "/api/intents?fields=id", `{"description":"fix parser","type":"fix","impact":{"breaking":true,"scope":["parser"]}}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created intent.Intent
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "fix parser", created.Description, "only GET responses are trimmed")
+
+	rec = do("GET", "/api/intents?fields=id,description,impact.breaking,missing", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"id":"`+created.ID+`","description":"fix parser","impact":{"breaking":true}}]`, rec.Body.String())
+
+	rec = do("GET", "/api/intents/"+created.ID+"?fields=type,impact,impact.scope", "")
+	assert.JSONEq(t, `{"type":"fix","impact":{"breaking":true,"scope":["parser"],"dependencies":null}}`, rec.Body.String())
+
+	// Errors pass through
+	rec = do("GET", "/api/intents/missing?fields=id", "")
+	assert.NotEqual(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "missing")
+}
+
+func TestETags(t *testing.T) {
+	srv := newTestServer(t)
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	c := client.New(ts.URL)
+	_, err := c.CreateIntent("fix parser", "fix")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/api/intents", nil))
+	tag := rec.Header().Get("ETag")
+	require.NotEmpty(t, tag)
+
+	req := httptest.NewRequest("GET", "/api/intents", nil)
+	req.Header.Set("If-None-Match", tag)
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// The client revalidates and serves unchanged lists from its cache
+	for range 2 {
+		intents, err := c.ListIntents()
+		require.NoError(t, err)
+		require.Len(t, intents, 1)
+	}
+	assert.Equal(t, client.CacheStats{Hits: 1, Misses: 1}, c.CacheStats())
+
+	_, err = c.CreateIntent("fix lexer", "fix")
+	require.NoError(t, err)
+	intents, err := c.ListIntents()
+	require.NoError(t, err)
+	assert.Len(t, intents, 2)
+	assert.Equal(t, client.CacheStats{Hits: 1, Misses: 2}, c.CacheStats())
+}
+
+func TestPushChangeSet(t *testing.T) {
+	srv := newTestServer(t)
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	local := make(map[string][]byte)
+	cs := &change.ChangeSet{ID: "cs-1", Description: "add modules", Author: "alice"}
+	for n := 0; n < 20; n++ {
+		data := bytes.Repeat([]byte(fmt.Sprintf("module %d\n", n)), 1000)
+		hash := utils.HashContent(data)
+		local[hash] = data
+		cs.Changes = append(cs.Changes, shared.Change{Path: fmt.Sprintf("mod%d.go", n), Type: "add", NewHash: hash, Size: int64(len(data))})
+	}
+	// The server already has one of the files
+	_, err := srv.safe.Store(local[cs.Changes[0].NewHash])
+	require.NoError(t, err)
+
+	c := client.New(ts.URL)
+	var last client.PushProgress
+	calls := 0
+	src := func(hash string) ([]byte, error) { return local[hash], nil }
+	res, err := c.Push(context.Background(), cs, src, func(p client.PushProgress) {
+		calls++
+		last = p
+	})
+	require.NoError(t, err)
+	assert.True(t, res.Created)
+	assert.Equal(t, 19, res.Stored)
+	assert.Equal(t, 20, calls)
+	assert.Equal(t, 19, last.Blobs)
+	assert.Equal(t, last.TotalBytes, last.Bytes)
+
+	require.NoError(t, srv.db.View(func(txn *badger.Txn) error {
+		stored, err := change.GetChangeSet(txn, "cs-1")
+		if err == nil {
+			assert.Len(t, stored.Changes, 20)
+		}
+		return err
+	}))
+
+	// Pushing again sends nothing
+	res, err = c.Push(context.Background(), cs, func(string) ([]byte, error) {
+		t.Fatal("no blobs should be read")
+		return nil, nil
+	}, nil)
+	require.NoError(t, err)
+	assert.False(t, res.Created)
+	assert.Zero(t, res.Stored)
+
+	// A changeset whose content never arrives is refused
+	bad := &change.ChangeSet{ID: "cs-2", Changes: []shared.Change{{Path: "x", Type: "add", NewHash: utils.HashContent([]byte("x"))}}}
+	_, err = c.Push(context.Background(), bad, func(string) ([]byte, error) { return nil, fmt.Errorf("gone") }, nil)
+	assert.Error(t, err)
+}
+
+func TestServerHooks(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) {
+		cfg.Hooks = []config.Hook{
+			{Name: "breaking", Event: "pre-receive-intent", Command: []string{"sh", "-c", `if grep -q '"breaking":true'; then echo "breaking changes need an RFC"; exit 1; fi`}},
+			{Name: "freeze", Event: "pre-merge", Command: []string{"sh", "-c", `echo "merges are paused"; exit 1`}, Streams: []string{"release"}},
+		}
+	})
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder, v any) {
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(v))
+	}
+
+	rec := do("POST", "/api/intents", `{"description":"drop v1","type":"feature","impact":{"breaking":true}}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "rejected by pre-receive-intent hook breaking: breaking changes need an RFC")
+
+	var i intent.Intent
+	decode(do("POST", "/api/intents", `{"description":"fix","type":"fix"}`), &i)
+	var st stream.Stream
+	decode(do("POST", "/api/streams", `{"name":"release","type":"release"}`), &st)
+	require.Equal(t, http.StatusOK, do("POST", "/api/streams/"+st.ID+"/intents", `{"intent_id":"`+i.ID+`"}`).Code)
+
+	rec = do("POST", "/api/streams/"+st.ID+"/queue", `{"intent_id":"`+i.ID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "rejected by pre-merge hook freeze: merges are paused")
+	decode(do("GET", "/api/streams/"+st.ID, ""), &st)
+	assert.Empty(t, st.State.Merged)
+}
+
+func TestPluginRoutes(t *testing.T) {
+	srv := newTestServer(t)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	assert.Equal(t, "[]\n", do("GET", "/api/plugins", "").Body.String())
+	var i intent.Intent
+	require.NoError(t, json.NewDecoder(do("POST", "/api/intents", `{"description":"fix","type":"fix"}`).Body).Decode(&i))
+	assert.Equal(t, http.StatusNotFound, do("POST", "/api/intents/"+i.ID+"/plugins/lint", "").Code)
+
+	dir := filepath.Join(srv.root, ".tig", "plugins")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lint.wasm"), []byte("not wasm"), 0644))
+	assert.Equal(t, "[\"lint\"]\n", do("GET", "/api/plugins", "").Body.String())
+	assert.Equal(t, http.StatusNotFound, do("POST", "/api/intents/missing/plugins/lint", "").Code)
+	rec := do("POST", "/api/intents/"+i.ID+"/plugins/lint", "")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "compiling plugin lint")
+}
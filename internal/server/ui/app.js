@@ -0,0 +1,191 @@
+// Minimal read-only dashboard over the tig HTTP API.
+
+async function getJSON(path) {
+  const res = await fetch(path);
+  if (!res.ok) {
+    throw new Error(`${path}: ${res.status}`);
+  }
+  return res.json();
+}
+
+function cell(text, tag = "td") {
+  const el = document.createElement(tag);
+  el.textContent = text;
+  return el;
+}
+
+function fill(tableId, rows, columns, onClick) {
+  const body = document.querySelector(`#${tableId} tbody`);
+  body.replaceChildren();
+  if (!rows || rows.length === 0) {
+    const td = cell("None yet");
+    td.colSpan = columns.length;
+    td.className = "empty";
+    body.append(document.createElement("tr"));
+    body.lastChild.append(td);
+    return;
+  }
+  for (const row of rows) {
+    const tr = document.createElement("tr");
+    for (const col of columns) {
+      tr.append(cell(col(row)));
+    }
+    if (onClick) {
+      tr.dataset.id = row.id;
+      tr.classList.toggle("selected", row.id === selected);
+      tr.addEventListener("click", () => onClick(row));
+    }
+    body.append(tr);
+  }
+}
+
+let selected = null;
+
+// Code cells hold HTML the server has already escaped and highlighted
+function codeCell(className, html) {
+  const td = document.createElement("td");
+  td.className = className;
+  td.innerHTML = html ?? "";
+  return td;
+}
+
+// Old and new thumbnails of a changed image with their dimensions
+function imageDiff(image) {
+  const div = document.createElement("div");
+  div.className = "image-diff";
+  for (const [label, side] of [["old", image.old], ["new", image.new]]) {
+    const figure = document.createElement("figure");
+    figure.className = label;
+    if (side) {
+      const img = document.createElement("img");
+      img.src = `/api/thumbnails/${side.hash}?size=256`;
+      img.alt = `${label} ${side.width}×${side.height}`;
+      figure.append(img, cell(`${side.format} ${side.width}×${side.height}, ${side.size} bytes`, "figcaption"));
+    } else {
+      figure.append(cell("none", "figcaption"));
+    }
+    div.append(figure);
+  }
+  if (image.distance >= 0) {
+    div.append(cell(`Perceptual distance ${image.distance}/64`, "p"));
+  }
+  return div;
+}
+
+// Key-level changes of a JSON or YAML file
+function structuralDiff(changes) {
+  const table = document.createElement("table");
+  table.className = "diff";
+  const value = (v) => (v === undefined ? "" : JSON.stringify(v));
+  for (const change of changes) {
+    const tr = document.createElement("tr");
+    tr.className = "change";
+    tr.append(cell(change.kind), cell(change.path), cell(value(change.old)), cell(value(change.new)));
+    tr.children[0].className = "num";
+    tr.children[2].className = "old";
+    tr.children[3].className = "new";
+    table.append(tr);
+  }
+  return table;
+}
+
+async function showDiff(intent) {
+  selected = intent.id;
+  for (const tr of document.querySelectorAll("#intents tbody tr")) {
+    tr.classList.toggle("selected", tr.dataset.id === selected);
+  }
+
+  const section = document.getElementById("diff");
+  const container = document.getElementById("diff-files");
+  document.getElementById("diff-title").textContent = intent.description;
+  section.hidden = false;
+  container.replaceChildren();
+
+  const files = await getJSON(`/api/intents/${intent.id}/diff`);
+  if (files.length === 0) {
+    container.append(cell("This intent has no changes.", "p"));
+    return;
+  }
+  for (const file of files) {
+    const div = document.createElement("div");
+    div.className = "file";
+    const name = file.old_path ? `${file.old_path} → ${file.path}` : file.path;
+    div.append(cell(`${name} (+${file.additions} -${file.deletions})`, "h3"));
+
+    if (file.image) {
+      div.append(imageDiff(file.image));
+      container.append(div);
+      continue;
+    }
+    if (file.binary) {
+      div.append(cell("Binary file not shown.", "p"));
+      container.append(div);
+      continue;
+    }
+    if (file.structural) {
+      div.append(structuralDiff(file.structural));
+      container.append(div);
+      continue;
+    }
+
+    const table = document.createElement("table");
+    table.className = "diff chroma";
+    for (const row of file.rows) {
+      const tr = document.createElement("tr");
+      tr.className = row.kind;
+      if (row.kind === "skip") {
+        const td = cell(`${row.skipped} unchanged lines`);
+        td.colSpan = 4;
+        tr.append(td);
+      } else {
+        tr.append(
+          cell(row.old_num ? String(row.old_num) : ""),
+          codeCell("old", row.old),
+          cell(row.new_num ? String(row.new_num) : ""),
+          codeCell("new", row.new),
+        );
+        tr.children[0].className = "num";
+        tr.children[2].className = "num";
+      }
+      table.append(tr);
+    }
+    div.append(table);
+    container.append(div);
+  }
+}
+
+async function refresh() {
+  const health = document.getElementById("health");
+  try {
+    await getJSON("/health");
+    health.textContent = "healthy";
+    health.className = "badge ok";
+  } catch (err) {
+    health.textContent = "unreachable";
+    health.className = "badge down";
+    return;
+  }
+
+  const [streams, intents] = await Promise.all([
+    getJSON("/api/streams?fields=name,type,state.status,state.intents"),
+    getJSON("/api/intents?fields=id,type,description,created_at"),
+  ]);
+
+  fill("streams", streams, [
+    (s) => s.name,
+    (s) => s.type,
+    (s) => s.state?.status ?? "",
+    (s) => String(s.state?.intents?.length ?? 0),
+  ]);
+
+  intents?.sort((a, b) => b.created_at.localeCompare(a.created_at));
+  fill("intents", intents, [
+    (i) => i.id.slice(0, 8),
+    (i) => i.type,
+    (i) => i.description,
+    (i) => new Date(i.created_at).toLocaleString(),
+  ], showDiff);
+}
+
+refresh();
+setInterval(refresh, 5000);
@@ -0,0 +1,357 @@
+// internal/stale/stale.go
+package stale
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tig/internal/events"
+	"tig/internal/intent"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.uber.org/zap"
+)
+
+// remindedPrefix stores when a reminder was last sent for an item, as
+// "stale:reminded:<kind>:<id>", so reminders survive restarts
+const remindedPrefix = "stale:reminded:"
+
+// Kinds of stale items
+const (
+	KindIntent = "intent"
+	KindStream = "stream"
+)
+
+// Defaults for unset options
+const (
+	DefaultIntentAge   = 7 * 24 * time.Hour
+	DefaultStreamAge   = 30 * 24 * time.Hour
+	DefaultInterval    = time.Hour
+	DefaultRemindEvery = 24 * time.Hour
+)
+
+// Options sets how long items may go without activity before they are
+// stale and how often the monitor reminds about them
+type Options struct {
+	IntentAge   time.Duration // Idle time of an intent in review; defaults to 7 days
+	StreamAge   time.Duration // Time since an active stream last synced; defaults to 30 days
+	Interval    time.Duration // Time between checks; defaults to one hour
+	RemindEvery time.Duration // Time between reminders about the same item; defaults to one day
+}
+
+func (o Options) withDefaults() Options {
+	if o.IntentAge <= 0 {
+		o.IntentAge = DefaultIntentAge
+	}
+	if o.StreamAge <= 0 {
+		o.StreamAge = DefaultStreamAge
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultInterval
+	}
+	if o.RemindEvery <= 0 {
+		o.RemindEvery = DefaultRemindEvery
+	}
+	return o
+}
+
+// Item is an intent or stream that has gone without activity too long
+type Item struct {
+	Kind         string        `json:"kind"`
+	ID           string        `json:"id"`
+	Name         string        `json:"name"` // Intent description or stream name
+	State        string        `json:"state"`
+	LastActivity time.Time     `json:"last_activity"`
+	Idle         time.Duration `json:"idle"`
+}
+
+// Report lists the stale intents and streams of a repository
+type Report struct {
+	Generated time.Time     `json:"generated"`
+	IntentAge time.Duration `json:"intent_age"`
+	StreamAge time.Duration `json:"stream_age"`
+	Intents   []Item        `json:"intents"`
+	Streams   []Item        `json:"streams"`
+}
+
+// LastActivity returns the last time anything happened to an intent: it
+// was created or updated, reviewed, checked or changed state
+func LastActivity(i *intent.Intent) time.Time {
+	last := i.CreatedAt
+	later := func(t time.Time) {
+		if t.After(last) {
+			last = t
+		}
+	}
+	later(i.UpdatedAt)
+	for _, r := range i.Reviews {
+		later(r.CreatedAt)
+	}
+	for _, c := range i.Checks {
+		later(c.UpdatedAt)
+	}
+	for _, s := range i.StateHistory {
+		later(s.At)
+	}
+	return last
+}
+
+// Detect finds the intents in review with no activity for opts.IntentAge
+// and the active streams that have not synced for opts.StreamAge, most
+// idle first
+func Detect(intents []*intent.Intent, streams []*stream.Stream, opts Options, now time.Time) *Report {
+	opts = opts.withDefaults()
+	r := &Report{
+		Generated: now,
+		IntentAge: opts.IntentAge,
+		StreamAge: opts.StreamAge,
+		Intents:   []Item{},
+		Streams:   []Item{},
+	}
+
+	for _, i := range intents {
+		if i.CurrentState() != intent.StateInReview {
+			continue
+		}
+		last := LastActivity(i)
+		if idle := now.Sub(last); idle >= opts.IntentAge {
+			r.Intents = append(r.Intents, Item{
+				Kind:         KindIntent,
+				ID:           i.ID,
+				Name:         i.Description,
+				State:        i.CurrentState(),
+				LastActivity: last,
+				Idle:         idle,
+			})
+		}
+	}
+	for _, st := range streams {
+		if !st.State.Active {
+			continue
+		}
+		last := st.State.LastSync
+		if last.IsZero() {
+			last = st.CreatedAt
+		}
+		if idle := now.Sub(last); idle >= opts.StreamAge {
+			r.Streams = append(r.Streams, Item{
+				Kind:         KindStream,
+				ID:           st.ID,
+				Name:         st.Name,
+				State:        st.State.Status,
+				LastActivity: last,
+				Idle:         idle,
+			})
+		}
+	}
+
+	for _, items := range [][]Item{r.Intents, r.Streams} {
+		sort.SliceStable(items, func(x, y int) bool { return items[x].Idle > items[y].Idle })
+	}
+	return r
+}
+
+// Markdown renders the report for reading or pasting into a team channel
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Stale intents and streams\n\n")
+	fmt.Fprintf(&b, "Generated %s.\n\n", r.Generated.Format("2006-01-02 15:04"))
+
+	fmt.Fprintf(&b, "## Intents in review idle for %s or more\n\n", FormatAge(r.IntentAge))
+	if len(r.Intents) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, it := range r.Intents {
+		fmt.Fprintf(&b, "- `%s` %s: idle %s, last activity %s\n", short(it.ID), it.Name, FormatAge(it.Idle), it.LastActivity.Format("2006-01-02"))
+	}
+
+	fmt.Fprintf(&b, "\n## Streams not synced for %s or more\n\n", FormatAge(r.StreamAge))
+	if len(r.Streams) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, it := range r.Streams {
+		fmt.Fprintf(&b, "- %s (%s): idle %s, last synced %s\n", it.Name, it.State, FormatAge(it.Idle), it.LastActivity.Format("2006-01-02"))
+	}
+	return b.String()
+}
+
+// FormatAge renders a duration in whole days, or hours below a day
+func FormatAge(d time.Duration) string {
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+	return fmt.Sprintf("%dh", int(d/time.Hour))
+}
+
+func short(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// lister is implemented by the intent and stream stores
+type lister[T any] interface {
+	List() ([]T, error)
+}
+
+// Monitor periodically looks for stale intents and streams and publishes
+// a reminder event for each, at most once per Options.RemindEvery
+type Monitor struct {
+	db      *badger.DB
+	intents lister[*intent.Intent]
+	streams lister[*stream.Stream]
+	events  events.Publisher
+	logger  *zap.Logger
+	opts    Options
+	now     func() time.Time
+}
+
+// New creates a monitor
+func New(db *badger.DB, intents lister[*intent.Intent], streams lister[*stream.Stream], opts Options, logger *zap.Logger) *Monitor {
+	return &Monitor{
+		db:      db,
+		intents: intents,
+		streams: streams,
+		logger:  logger,
+		opts:    opts.withDefaults(),
+		now:     time.Now,
+	}
+}
+
+// WithEvents publishes reminder events to p
+func (m *Monitor) WithEvents(p events.Publisher) *Monitor {
+	m.events = p
+	return m
+}
+
+// Run checks on every interval until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, _, err := m.Step(); err != nil {
+				m.logger.Warn("Stale check failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Step detects stale items and reminds about those not reminded about
+// within Options.RemindEvery, returning the report and the items
+// reminded about. Items no longer stale are forgotten, so they are
+// reminded about as soon as they go stale again.
+func (m *Monitor) Step() (*Report, []Item, error) {
+	intents, err := m.intents.List()
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing intents: %w", err)
+	}
+	streams, err := m.streams.List()
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing streams: %w", err)
+	}
+	now := m.now()
+	r := Detect(intents, streams, m.opts, now)
+
+	stale := make(map[string]Item)
+	for _, it := range append(append([]Item(nil), r.Intents...), r.Streams...) {
+		stale[remindedPrefix+it.Kind+":"+it.ID] = it
+	}
+
+	var due []Item
+	err = m.db.Update(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: []byte(remindedPrefix)})
+		var forget [][]byte
+		reminded := make(map[string]time.Time)
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := string(it.Item().Key())
+			if _, ok := stale[key]; !ok {
+				forget = append(forget, it.Item().KeyCopy(nil))
+				continue
+			}
+			err := it.Item().Value(func(val []byte) error {
+				var t time.Time
+				if err := t.UnmarshalText(val); err != nil {
+					return err
+				}
+				reminded[key] = t
+				return nil
+			})
+			if err != nil {
+				it.Close()
+				return err
+			}
+		}
+		it.Close()
+		for _, key := range forget {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		for key, item := range stale {
+			if last, ok := reminded[key]; ok && now.Sub(last) < m.opts.RemindEvery {
+				continue
+			}
+			stamp, err := now.MarshalText()
+			if err != nil {
+				return err
+			}
+			if err := txn.Set([]byte(key), stamp); err != nil {
+				return err
+			}
+			due = append(due, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("recording reminders: %w", err)
+	}
+
+	sort.Slice(due, func(x, y int) bool {
+		if due[x].Kind != due[y].Kind {
+			return due[x].Kind < due[y].Kind
+		}
+		return due[x].Idle > due[y].Idle
+	})
+	for _, item := range due {
+		m.remind(item)
+	}
+	return r, due, nil
+}
+
+func (m *Monitor) remind(item Item) {
+	m.logger.Info("Stale item",
+		zap.String("kind", item.Kind),
+		zap.String("id", item.ID),
+		zap.Duration("idle", item.Idle))
+	if m.events == nil {
+		return
+	}
+
+	e := events.Event{
+		Summary: item.Name,
+		Data: map[string]string{
+			"idle":          FormatAge(item.Idle),
+			"last_activity": item.LastActivity.Format(time.RFC3339),
+			"state":         item.State,
+		},
+	}
+	switch item.Kind {
+	case KindIntent:
+		e.Type, e.IntentID = events.IntentStale, item.ID
+	default:
+		e.Type, e.StreamID = events.StreamStale, item.ID
+		e.Data["name"] = item.Name
+	}
+	m.events.Publish(e)
+}
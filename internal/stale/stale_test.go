@@ -0,0 +1,102 @@
+// internal/stale/stale_test.go
+package stale
+
+import (
+	"testing"
+	"time"
+
+	"tig/internal/events"
+	"tig/internal/intent"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type list[T any] []T
+
+func (l list[T]) List() ([]T, error) { return l, nil }
+
+func TestDetect(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	days := func(n int) time.Time { return now.Add(-time.Duration(n) * 24 * time.Hour) }
+
+	intents := []*intent.Intent{
+		{ID: "idle", State: intent.StateInReview, CreatedAt: days(20), UpdatedAt: days(10)},
+		{ID: "reviewed", State: intent.StateInReview, CreatedAt: days(20), UpdatedAt: days(20),
+			Reviews: []intent.Review{{Reviewer: "ana", CreatedAt: days(2)}}},
+		{ID: "older", State: intent.StateInReview, CreatedAt: days(30), UpdatedAt: days(30)},
+		{ID: "draft", CreatedAt: days(30), UpdatedAt: days(30)},
+	}
+	streams := []*stream.Stream{
+		{ID: "s1", Name: "old", CreatedAt: days(90), State: stream.State{Active: true, Status: stream.StatusStable, LastSync: days(40)}},
+		{ID: "s2", Name: "fresh", CreatedAt: days(90), State: stream.State{Active: true, LastSync: days(1)}},
+		{ID: "s3", Name: "closed", CreatedAt: days(90), State: stream.State{LastSync: days(90)}},
+	}
+
+	r := Detect(intents, streams, Options{}, now)
+	require.Len(t, r.Intents, 2)
+	assert.Equal(t, "older", r.Intents[0].ID)
+	assert.Equal(t, "idle", r.Intents[1].ID)
+	assert.Equal(t, 10*24*time.Hour, r.Intents[1].Idle)
+	require.Len(t, r.Streams, 1)
+	assert.Equal(t, "old", r.Streams[0].Name)
+
+	r = Detect(intents, streams, Options{IntentAge: 15 * 24 * time.Hour}, now)
+	require.Len(t, r.Intents, 1)
+	assert.Equal(t, "older", r.Intents[0].ID)
+	assert.Contains(t, r.Markdown(), "Intents in review idle for 15d or more")
+}
+
+func TestMonitorReminders(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	i := &intent.Intent{ID: "i1", Description: "Slow review", State: intent.StateInReview,
+		CreatedAt: now.Add(-10 * 24 * time.Hour), UpdatedAt: now.Add(-10 * 24 * time.Hour)}
+	st := &stream.Stream{ID: "s1", Name: "feature", State: stream.State{Active: true, LastSync: now.Add(-60 * 24 * time.Hour)}}
+
+	bus := events.NewBus()
+	var published []events.Event
+	bus.SubscribeAll(func(e events.Event) { published = append(published, e) })
+
+	m := New(db, list[*intent.Intent]{i}, list[*stream.Stream]{st}, Options{}, zap.NewNop()).WithEvents(bus)
+	m.now = func() time.Time { return now }
+
+	_, due, err := m.Step()
+	require.NoError(t, err)
+	assert.Len(t, due, 2)
+	require.Len(t, published, 2)
+	assert.Equal(t, events.IntentStale, published[0].Type)
+	assert.Equal(t, "i1", published[0].IntentID)
+	assert.Equal(t, "10d", published[0].Data["idle"])
+	assert.Equal(t, events.StreamStale, published[1].Type)
+	assert.Equal(t, "s1", published[1].StreamID)
+
+	// Not reminded again within a day
+	now = now.Add(time.Hour)
+	_, due, err = m.Step()
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	// Activity forgets the intent; the stream is due again after a day
+	i.Reviews = []intent.Review{{Reviewer: "ana", CreatedAt: now}}
+	now = now.Add(24 * time.Hour)
+	_, due, err = m.Step()
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, KindStream, due[0].Kind)
+
+	// Idle again long enough, the intent is reminded about straight away
+	now = now.Add(7 * 24 * time.Hour)
+	_, due, err = m.Step()
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, KindIntent, due[0].Kind)
+}
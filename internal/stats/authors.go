@@ -0,0 +1,255 @@
+// internal/stats/authors.go
+package stats
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"tig/internal/diff"
+	"tig/internal/intent"
+	"tig/internal/safe"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// linesPrefix keys the cached line counts of each changeset. Changesets
+// never change, so the counts are computed once.
+const linesPrefix = "stats:lines:"
+
+// Bucket widths of author statistics
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// UnknownAuthor is reported for intents without a recorded author
+const UnknownAuthor = "unknown"
+
+// LineCount is how many lines a changeset added and deleted
+type LineCount struct {
+	Added   int `json:"added"`
+	Deleted int `json:"deleted"`
+}
+
+// AuthorOptions selects the intents counted in an author report
+type AuthorOptions struct {
+	Since  time.Time // only intents created after this; zero counts all
+	Bucket string    // BucketDay, BucketWeek or BucketMonth
+}
+
+// AuthorReport is the contribution overview per author served by the API
+// and CLI
+type AuthorReport struct {
+	Since   time.Time     `json:"since"`
+	Bucket  string        `json:"bucket"`
+	Authors []AuthorStats `json:"authors"`
+}
+
+// AuthorStats counts one author's contributions, in total and per bucket
+type AuthorStats struct {
+	Author        string         `json:"author"`
+	Intents       int            `json:"intents"`
+	LinesAdded    int            `json:"lines_added"`
+	LinesDeleted  int            `json:"lines_deleted"`
+	Breaking      int            `json:"breaking"`
+	ActiveStreams []string       `json:"active_streams"` // names of active streams holding the author's intents
+	Buckets       []AuthorBucket `json:"buckets"`
+}
+
+// AuthorBucket counts contributions within one period
+type AuthorBucket struct {
+	Start        time.Time `json:"start"`
+	Intents      int       `json:"intents"`
+	LinesAdded   int       `json:"lines_added"`
+	LinesDeleted int       `json:"lines_deleted"`
+}
+
+// BucketStart returns the start of the bucket holding t, in UTC. Weeks
+// start on Monday.
+func BucketStart(t time.Time, bucket string) (time.Time, error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case BucketDay:
+		return day, nil
+	case BucketWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)), nil
+	case BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid bucket %q: must be day, week or month", bucket)
+}
+
+// Authors counts the intents, changed lines, breaking changes and active
+// streams of each author since opts.Since. An intent's author is the
+// author of its changeset. Authors are sorted by intents, then lines.
+func Authors(db *badger.DB, s *safe.Safe, intents []*intent.Intent, streams []*stream.Stream, opts AuthorOptions) (*AuthorReport, error) {
+	if _, err := BucketStart(time.Time{}, opts.Bucket); err != nil {
+		return nil, err
+	}
+
+	streamsOf := map[string][]string{} // intent ID to active stream names
+	for _, st := range streams {
+		if !st.State.Active {
+			continue
+		}
+		for _, id := range st.State.Intents {
+			streamsOf[id] = append(streamsOf[id], st.Name)
+		}
+	}
+
+	byAuthor := map[string]*AuthorStats{}
+	buckets := map[string]map[time.Time]*AuthorBucket{}
+	activeStreams := map[string]map[string]bool{}
+	for _, i := range intents {
+		if i.CreatedAt.Before(opts.Since) {
+			continue
+		}
+
+		author := i.Metadata.Author
+		var lines LineCount
+		if i.ChangeSetID != "" {
+			csAuthor, count, err := ChangeSetLines(db, s, i.ChangeSetID)
+			if err != nil {
+				return nil, err
+			}
+			if csAuthor != "" {
+				author = csAuthor
+			}
+			lines = count
+		}
+		if author == "" {
+			author = UnknownAuthor
+		}
+
+		a := byAuthor[author]
+		if a == nil {
+			a = &AuthorStats{Author: author, ActiveStreams: []string{}}
+			byAuthor[author] = a
+			buckets[author] = map[time.Time]*AuthorBucket{}
+			activeStreams[author] = map[string]bool{}
+		}
+		a.Intents++
+		a.LinesAdded += lines.Added
+		a.LinesDeleted += lines.Deleted
+		if i.Impact.Breaking {
+			a.Breaking++
+		}
+		for _, name := range streamsOf[i.ID] {
+			activeStreams[author][name] = true
+		}
+
+		start, _ := BucketStart(i.CreatedAt, opts.Bucket)
+		b := buckets[author][start]
+		if b == nil {
+			b = &AuthorBucket{Start: start}
+			buckets[author][start] = b
+		}
+		b.Intents++
+		b.LinesAdded += lines.Added
+		b.LinesDeleted += lines.Deleted
+	}
+
+	report := &AuthorReport{Since: opts.Since, Bucket: opts.Bucket, Authors: []AuthorStats{}}
+	for author, a := range byAuthor {
+		for name := range activeStreams[author] {
+			a.ActiveStreams = append(a.ActiveStreams, name)
+		}
+		sort.Strings(a.ActiveStreams)
+		for _, b := range buckets[author] {
+			a.Buckets = append(a.Buckets, *b)
+		}
+		sort.Slice(a.Buckets, func(x, y int) bool { return a.Buckets[x].Start.Before(a.Buckets[y].Start) })
+		report.Authors = append(report.Authors, *a)
+	}
+	sort.Slice(report.Authors, func(x, y int) bool {
+		a, b := report.Authors[x], report.Authors[y]
+		if a.Intents != b.Intents {
+			return a.Intents > b.Intents
+		}
+		if a.LinesAdded+a.LinesDeleted != b.LinesAdded+b.LinesDeleted {
+			return a.LinesAdded+a.LinesDeleted > b.LinesAdded+b.LinesDeleted
+		}
+		return a.Author < b.Author
+	})
+	return report, nil
+}
+
+// lineChangeSet is the part of a stored changeset its line counts depend on
+type lineChangeSet struct {
+	Author  string `json:"author"`
+	Changes []struct {
+		Path    string `json:"path"`
+		Type    string `json:"type"`
+		OldHash string `json:"old_hash"`
+		NewHash string `json:"new_hash"`
+	} `json:"changes"`
+}
+
+// cachedLines is a changeset's cached line counts with its author
+type cachedLines struct {
+	Author string    `json:"author"`
+	Lines  LineCount `json:"lines"`
+}
+
+// ChangeSetLines returns a changeset's author and how many lines it added
+// and deleted, diffing its changes on first use. Binary files count no
+// lines.
+func ChangeSetLines(db *badger.DB, s *safe.Safe, id string) (string, LineCount, error) {
+	var cached cachedLines
+	var cs lineChangeSet
+	found := false
+	err := db.View(func(txn *badger.Txn) error {
+		err := get(txn, linesPrefix+id, &cached)
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			found = err == nil
+			return err
+		}
+		return get(txn, changeSetPrefix+id, &cs)
+	})
+	if err != nil {
+		return "", LineCount{}, fmt.Errorf("reading changeset %s: %w", id, err)
+	}
+	if found {
+		return cached.Author, cached.Lines, nil
+	}
+
+	cached = cachedLines{Author: cs.Author}
+	engine := diff.NewEngine(0)
+	for _, c := range cs.Changes {
+		var oldContent, newContent []byte
+		if c.OldHash != "" {
+			if oldContent, err = s.Get(c.OldHash); err != nil {
+				return "", LineCount{}, fmt.Errorf("loading previous content of %s: %w", c.Path, err)
+			}
+		}
+		if c.Type != "delete" && c.NewHash != "" {
+			if newContent, err = s.Get(c.NewHash); err != nil {
+				return "", LineCount{}, fmt.Errorf("loading content of %s: %w", c.Path, err)
+			}
+		}
+		if bytes.IndexByte(oldContent, 0) >= 0 || bytes.IndexByte(newContent, 0) >= 0 {
+			continue
+		}
+		for _, l := range engine.Align(oldContent, newContent) {
+			switch l.Type {
+			case diff.Addition:
+				cached.Lines.Added++
+			case diff.Deletion:
+				cached.Lines.Deleted++
+			}
+		}
+	}
+
+	if err := db.Update(func(txn *badger.Txn) error {
+		return put(txn, linesPrefix+id, cached)
+	}); err != nil {
+		return "", LineCount{}, fmt.Errorf("caching line counts of %s: %w", id, err)
+	}
+	return cached.Author, cached.Lines, nil
+}
@@ -0,0 +1,83 @@
+// internal/stats/authors_test.go
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"tig/internal/intent"
+	"tig/internal/safe"
+	"tig/internal/stream"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthors(t *testing.T) {
+	db := setupDB(t)
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	v1, err := s.Store([]byte("a\nb\n"))
+	require.NoError(t, err)
+	v2, err := s.Store([]byte("a\nc\nd\n"))
+	require.NoError(t, err)
+	changeSets := map[string]any{
+		"cs1": map[string]any{"author": "alice", "changes": []map[string]string{{"path": "f", "type": "add", "new_hash": v1}}},
+		"cs2": map[string]any{"author": "bob", "changes": []map[string]string{{"path": "f", "type": "modify", "old_hash": v1, "new_hash": v2}}},
+		"cs3": map[string]any{"author": "alice", "changes": []map[string]string{{"path": "f", "type": "delete", "old_hash": v2}}},
+	}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		for id, cs := range changeSets {
+			data, _ := json.Marshal(cs)
+			if err := txn.Set([]byte(changeSetPrefix+id), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	// Monday 2026-03-02 and the Sunday ending that week, then the next week
+	mon := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	intents := []*intent.Intent{
+		{ID: "i1", ChangeSetID: "cs1", CreatedAt: mon},
+		{ID: "i2", ChangeSetID: "cs2", CreatedAt: mon.AddDate(0, 0, 6), Impact: intent.Impact{Breaking: true}},
+		{ID: "i3", ChangeSetID: "cs3", CreatedAt: mon.AddDate(0, 0, 7)},
+		{ID: "i4", CreatedAt: mon.AddDate(0, 0, 8)},
+		{ID: "old", ChangeSetID: "cs1", CreatedAt: mon.AddDate(0, -1, 0)},
+	}
+	streams := []*stream.Stream{
+		{Name: "main", State: stream.State{Active: true, Intents: []string{"i1", "i2"}}},
+		{Name: "closed", State: stream.State{Intents: []string{"i3"}}},
+	}
+
+	report, err := Authors(db, s, intents, streams, AuthorOptions{Since: mon.AddDate(0, 0, -1), Bucket: BucketWeek})
+	require.NoError(t, err)
+	require.Len(t, report.Authors, 3)
+
+	alice := report.Authors[0]
+	assert.Equal(t, "alice", alice.Author)
+	assert.Equal(t, 2, alice.Intents)
+	assert.Equal(t, 2, alice.LinesAdded)
+	assert.Equal(t, 3, alice.LinesDeleted)
+	assert.Equal(t, []string{"main"}, alice.ActiveStreams)
+	assert.Equal(t, []AuthorBucket{
+		{Start: mon.Truncate(24 * time.Hour), Intents: 1, LinesAdded: 2},
+		{Start: mon.Truncate(24*time.Hour).AddDate(0, 0, 7), Intents: 1, LinesDeleted: 3},
+	}, alice.Buckets)
+
+	bob := report.Authors[1]
+	assert.Equal(t, "bob", bob.Author)
+	assert.Equal(t, 1, bob.Breaking)
+	assert.Equal(t, 2, bob.LinesAdded)
+	assert.Equal(t, 1, bob.LinesDeleted)
+	assert.Len(t, bob.Buckets, 1)
+
+	assert.Equal(t, UnknownAuthor, report.Authors[2].Author)
+
+	_, err = Authors(db, s, intents, streams, AuthorOptions{Bucket: "year"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,262 @@
+// internal/stats/churn.go
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Keys of the cached churn statistics
+const (
+	filePrefix      = "stats:file:"
+	summaryKey      = "stats:summary"
+	changeSetPrefix = "changeset:"
+)
+
+// FileChurn counts how often a file has changed and by whom
+type FileChurn struct {
+	Path        string         `json:"path"`
+	Changes     int            `json:"changes"`
+	Authors     map[string]int `json:"authors"`
+	LastChanged time.Time      `json:"last_changed"`
+}
+
+// Summary holds repository-wide totals
+type Summary struct {
+	ChangeSets int `json:"changesets"`
+	Changes    int `json:"changes"`
+}
+
+// Report is the churn overview served by the API and CLI
+type Report struct {
+	ChangeSets        int         `json:"changesets"`
+	Changes           int         `json:"changes"`
+	AverageIntentSize float64     `json:"average_intent_size"`
+	Files             []FileChurn `json:"files"`
+}
+
+// ChangeSet is the part of a stored changeset the statistics depend on
+type ChangeSet struct {
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	Changes   []struct {
+		Path string `json:"path"`
+	} `json:"changes"`
+}
+
+// Record adds a changeset to the cached statistics. It runs inside the
+// transaction that stores the changeset so the cache never drifts.
+func Record(txn *badger.Txn, author string, paths []string, at time.Time) error {
+	sum, err := getSummary(txn)
+	if err != nil {
+		return err
+	}
+	sum.ChangeSets++
+	sum.Changes += len(paths)
+	if err := put(txn, summaryKey, sum); err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fc := FileChurn{Path: path}
+		if err := get(txn, filePrefix+path, &fc); err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+		fc.add(author, at)
+		if err := put(txn, filePrefix+path, fc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add counts one change to the file by author at the given time
+func (fc *FileChurn) add(author string, at time.Time) {
+	if fc.Authors == nil {
+		fc.Authors = map[string]int{}
+	}
+	fc.Changes++
+	if author != "" {
+		fc.Authors[author]++
+	}
+	if at.After(fc.LastChanged) {
+		fc.LastChanged = at
+	}
+}
+
+// Churn returns the totals and the limit most frequently changed files
+// (all files when limit <= 0). Repositories created before statistics were
+// cached are rebuilt from their changesets on first use.
+func Churn(db *badger.DB, limit int) (*Report, error) {
+	var cached bool
+	if err := db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(summaryKey))
+		cached = err == nil
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("reading churn summary: %w", err)
+	}
+	if !cached {
+		if err := Rebuild(db); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &Report{Files: []FileChurn{}}
+	err := db.View(func(txn *badger.Txn) error {
+		sum, err := getSummary(txn)
+		if err != nil {
+			return err
+		}
+		report.ChangeSets = sum.ChangeSets
+		report.Changes = sum.Changes
+
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(filePrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var fc FileChurn
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &fc)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			report.Files = append(report.Files, fc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading churn statistics: %w", err)
+	}
+
+	if report.ChangeSets > 0 {
+		report.AverageIntentSize = float64(report.Changes) / float64(report.ChangeSets)
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if a.Changes != b.Changes {
+			return a.Changes > b.Changes
+		}
+		return a.Path < b.Path
+	})
+	if limit > 0 && len(report.Files) > limit {
+		report.Files = report.Files[:limit]
+	}
+	return report, nil
+}
+
+// Rebuild discards the cached statistics and recomputes them from every
+// stored changeset
+func Rebuild(db *badger.DB) error {
+	var changeSets []ChangeSet
+	err := db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(changeSetPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var cs ChangeSet
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &cs)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			changeSets = append(changeSets, cs)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading changesets: %w", err)
+	}
+
+	if err := db.DropPrefix([]byte(filePrefix), []byte(summaryKey)); err != nil {
+		return fmt.Errorf("clearing churn statistics: %w", err)
+	}
+
+	var sum Summary
+	files := map[string]*FileChurn{}
+	for _, cs := range changeSets {
+		sum.ChangeSets++
+		sum.Changes += len(cs.Changes)
+		for _, c := range cs.Changes {
+			fc, ok := files[c.Path]
+			if !ok {
+				fc = &FileChurn{Path: c.Path}
+				files[c.Path] = fc
+			}
+			fc.add(cs.Author, cs.CreatedAt)
+		}
+	}
+
+	// Written in a batch since large histories exceed a single transaction
+	wb := db.NewWriteBatch()
+	defer wb.Cancel()
+	for path, fc := range files {
+		if err := setJSON(wb, filePrefix+path, fc); err != nil {
+			return err
+		}
+	}
+	if err := setJSON(wb, summaryKey, sum); err != nil {
+		return err
+	}
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("rebuilding churn statistics: %w", err)
+	}
+	return nil
+}
+
+// TopAuthors returns a file's authors ordered by number of changes
+func (fc FileChurn) TopAuthors() []string {
+	authors := make([]string, 0, len(fc.Authors))
+	for a := range fc.Authors {
+		authors = append(authors, a)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if fc.Authors[authors[i]] != fc.Authors[authors[j]] {
+			return fc.Authors[authors[i]] > fc.Authors[authors[j]]
+		}
+		return authors[i] < authors[j]
+	})
+	return authors
+}
+
+func getSummary(txn *badger.Txn) (Summary, error) {
+	var sum Summary
+	if err := get(txn, summaryKey, &sum); err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return sum, err
+	}
+	return sum, nil
+}
+
+func get(txn *badger.Txn, key string, v any) error {
+	item, err := txn.Get([]byte(key))
+	if err != nil {
+		return err
+	}
+	return item.Value(func(val []byte) error {
+		return json.Unmarshal(val, v)
+	})
+}
+
+func put(txn *badger.Txn, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshaling %s: %w", key, err)
+	}
+	return txn.Set([]byte(key), data)
+}
+
+func setJSON(wb *badger.WriteBatch, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshaling %s: %w", key, err)
+	}
+	return wb.Set([]byte(key), data)
+}
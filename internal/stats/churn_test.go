@@ -0,0 +1,79 @@
+// internal/stats/churn_test.go
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupDB(t *testing.T) *badger.DB {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func record(t *testing.T, db *badger.DB, author string, paths ...string) {
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return Record(txn, author, paths, time.Now())
+	}))
+}
+
+func TestChurn(t *testing.T) {
+	db := setupDB(t)
+	record(t, db, "alice", "main.go", "go.mod")
+	record(t, db, "bob", "main.go")
+	record(t, db, "alice", "main.go", "README.md", "go.mod")
+
+	report, err := Churn(db, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.ChangeSets)
+	assert.Equal(t, 6, report.Changes)
+	assert.Equal(t, 2.0, report.AverageIntentSize)
+
+	require.Len(t, report.Files, 2)
+	assert.Equal(t, "main.go", report.Files[0].Path)
+	assert.Equal(t, 3, report.Files[0].Changes)
+	assert.Equal(t, []string{"alice", "bob"}, report.Files[0].TopAuthors())
+	assert.Equal(t, "go.mod", report.Files[1].Path)
+}
+
+func TestChurnRebuildsFromChangeSets(t *testing.T) {
+	db := setupDB(t)
+
+	// Changesets stored before statistics were cached
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		for i, author := range []string{"alice", "bob"} {
+			data, _ := json.Marshal(map[string]any{
+				"author":     author,
+				"created_at": time.Now(),
+				"changes":    []map[string]string{{"path": "a.go"}, {"path": fmt.Sprintf("f%d.go", i)}},
+			})
+			if err := txn.Set([]byte(fmt.Sprintf("changeset:%d", i)), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	report, err := Churn(db, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.ChangeSets)
+	require.Len(t, report.Files, 3)
+	assert.Equal(t, map[string]int{"alice": 1, "bob": 1}, report.Files[0].Authors)
+
+	// New changesets build on the rebuilt cache
+	record(t, db, "carol", "a.go")
+	report, err = Churn(db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.ChangeSets)
+	assert.Equal(t, 3, report.Files[0].Changes)
+}
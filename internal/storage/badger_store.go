@@ -2,7 +2,9 @@
 package storage
 
 import (
+    "bytes"
     "encoding/json"
+    "errors"
     "fmt"
     "strings"
 
@@ -14,142 +16,163 @@ type Entity interface {
     GetID() string
 }
 
-// BadgerStore provides generic storage operations
-type BadgerStore struct {
-    db        *badger.DB
-    prefix    string
+// ErrStop may be returned by a ListFunc callback to end iteration early
+// without an error
+var ErrStop = errors.New("stop iteration")
+
+// Errors returned for missing and duplicate entities
+var (
+    ErrNotFound = errors.New("entity not found")
+    ErrExists   = errors.New("entity already exists")
+)
+
+// Store keeps entities of type T as JSON under "<prefix>:<id>" keys. T is
+// usually a pointer, e.g. Store[*intent.Intent].
+type Store[T Entity] struct {
+    db      *badger.DB
+    prefix  string
+    history bool // record mutations, see WithHistory
 }
 
-func NewBadgerStore(db *badger.DB, prefix string) *BadgerStore {
-    return &BadgerStore{
+// New creates a store for entities under prefix
+func New[T Entity](db *badger.DB, prefix string) *Store[T] {
+    return &Store[T]{
         db:     db,
         prefix: prefix,
     }
 }
 
-func (s *BadgerStore) makeKey(id string) []byte {
+func (s *Store[T]) makeKey(id string) []byte {
     return []byte(fmt.Sprintf("%s:%s", s.prefix, id))
 }
 
-func (s *BadgerStore) stripPrefix(key []byte) string {
+func (s *Store[T]) stripPrefix(key []byte) string {
     return strings.TrimPrefix(string(key), fmt.Sprintf("%s:", s.prefix))
 }
 
-func (s *BadgerStore) Create(entity Entity) error {
-    if entity.GetID() == "" {
-        return fmt.Errorf("entity ID cannot be empty")
-    }
-
-    data, err := json.Marshal(entity)
-    if err != nil {
-        return fmt.Errorf("marshaling entity: %w", err)
+// decode unmarshals a stored value into a new T
+func (s *Store[T]) decode(id string, val []byte) (T, error) {
+    var entity T
+    if err := json.Unmarshal(val, &entity); err != nil {
+        return entity, fmt.Errorf("decoding %s %s: %w", s.prefix, id, err)
     }
+    return entity, nil
+}
 
-    key := s.makeKey(entity.GetID())
-    return s.db.Update(func(txn *badger.Txn) error {
-        // Check if key already exists
-        _, err := txn.Get(key)
-        if err == nil {
-            return fmt.Errorf("entity already exists: %s", entity.GetID())
-        } else if err != badger.ErrKeyNotFound {
-            return err
-        }
-
-        return txn.Set(key, data)
+func (s *Store[T]) Create(entity T) error {
+    return Run(s.db, func(u *UnitOfWork) error {
+        return s.With(u).Create(entity)
     })
 }
 
-func (s *BadgerStore) Get(id string, entity Entity) error {
-    key := s.makeKey(id)
-
+func (s *Store[T]) Get(id string) (T, error) {
+    var entity T
     err := s.db.View(func(txn *badger.Txn) error {
-        item, err := txn.Get(key)
-        if err != nil {
-            return err
-        }
-
-        return item.Value(func(val []byte) error {
-            return json.Unmarshal(val, entity)
-        })
+        var err error
+        entity, err = (&Txn[T]{store: s, txn: txn}).Get(id)
+        return err
     })
-
-    if err == badger.ErrKeyNotFound {
-        return fmt.Errorf("entity not found: %s", id)
-    }
-    return err
+    return entity, err
 }
 
-func (s *BadgerStore) Update(entity Entity) error {
-    if entity.GetID() == "" {
-        return fmt.Errorf("entity ID cannot be empty")
-    }
-
-    data, err := json.Marshal(entity)
-    if err != nil {
-        return fmt.Errorf("marshaling entity: %w", err)
-    }
-
-    key := s.makeKey(entity.GetID())
-    return s.db.Update(func(txn *badger.Txn) error {
-        // Check if exists
-        _, err := txn.Get(key)
-        if err == badger.ErrKeyNotFound {
-            return fmt.Errorf("entity not found: %s", entity.GetID())
-        } else if err != nil {
-            return err
-        }
+func (s *Store[T]) Update(entity T) error {
+    return Run(s.db, func(u *UnitOfWork) error {
+        return s.With(u).Update(entity)
+    })
+}
 
-        return txn.Set(key, data)
+func (s *Store[T]) Delete(id string) error {
+    return Run(s.db, func(u *UnitOfWork) error {
+        return s.With(u).Delete(id)
     })
 }
 
-func (s *BadgerStore) Delete(id string) error {
-    key := s.makeKey(id)
+// List returns every entity in ID order
+func (s *Store[T]) List() ([]T, error) {
+    return s.Query(nil)
+}
 
-    return s.db.Update(func(txn *badger.Txn) error {
-        // Check if exists
-        _, err := txn.Get(key)
-        if err == badger.ErrKeyNotFound {
-            return fmt.Errorf("entity not found: %s", id)
-        } else if err != nil {
-            return err
+// Query returns the entities match accepts, in ID order. A nil match
+// accepts everything.
+func (s *Store[T]) Query(match func(T) bool) ([]T, error) {
+    var results []T
+    err := s.ListFunc("", func(entity T) error {
+        if match == nil || match(entity) {
+            results = append(results, entity)
         }
-
-        return txn.Delete(key)
+        return nil
     })
+    if err != nil {
+        return nil, err
+    }
+    return results, nil
 }
 
-func (s *BadgerStore) List(results interface{}) error {
+// ListFunc calls fn with each entity whose ID starts with prefix, in ID
+// order, decoding one at a time. Returning ErrStop ends the iteration
+// early.
+func (s *Store[T]) ListFunc(prefix string, fn func(T) error) error {
+    _, err := s.ListPage(prefix, "", 0, fn)
+    return err
+}
+
+// ListPage is like ListFunc but starts after the entity with ID after and
+// visits at most limit entities; a limit of 0 means no limit. It returns
+// the ID to pass as after for the next page, or "" on the last page.
+func (s *Store[T]) ListPage(prefix, after string, limit int, fn func(T) error) (string, error) {
+    var next string
     err := s.db.View(func(txn *badger.Txn) error {
+        keyPrefix := s.makeKey(prefix)
         opts := badger.DefaultIteratorOptions
+        opts.Prefix = keyPrefix
+        // Pages are small; large prefetches waste reads past the limit
+        if limit > 0 && limit < opts.PrefetchSize {
+            opts.PrefetchSize = limit + 1
+        }
         it := txn.NewIterator(opts)
         defer it.Close()
 
-        prefix := []byte(s.prefix + ":")
-        var values []json.RawMessage
+        start := keyPrefix
+        if k := s.makeKey(after); after != "" && bytes.Compare(k, start) > 0 {
+            start = k
+        }
 
-        for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+        visited := 0
+        for it.Seek(start); it.ValidForPrefix(keyPrefix); it.Next() {
             item := it.Item()
-            err := item.Value(func(val []byte) error {
-                values = append(values, val)
+            id := s.stripPrefix(item.Key())
+            if after != "" && id <= after {
+                continue
+            }
+            if limit > 0 && visited == limit {
+                // There is at least one more entity
                 return nil
+            }
+
+            err := item.Value(func(val []byte) error {
+                entity, err := s.decode(id, val)
+                if err != nil {
+                    return err
+                }
+                return fn(entity)
             })
+            if err == ErrStop {
+                next = ""
+                return nil
+            }
             if err != nil {
                 return err
             }
+            visited++
+            next = id
         }
 
-        // Marshal collected values into final result
-        data, err := json.Marshal(values)
-        if err != nil {
-            return err
-        }
-
-        return json.Unmarshal(data, results)
+        // Reached the end
+        next = ""
+        return nil
     })
-
     if err != nil {
-        return fmt.Errorf("listing entities: %w", err)
+        return "", fmt.Errorf("listing entities: %w", err)
     }
-    return nil
-}
\ No newline at end of file
+    return next, nil
+}
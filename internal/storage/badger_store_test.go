@@ -0,0 +1,117 @@
+// internal/storage/badger_store_test.go
+package storage
+
+import (
+    "fmt"
+    "testing"
+
+    "github.com/dgraph-io/badger/v4"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+type item struct {
+    ID   string `json:"id"`
+    Name string `json:"name"`
+}
+
+func (i *item) GetID() string { return i.ID }
+
+func setupStore(t *testing.T, n int) *Store[*item] {
+    opts := badger.DefaultOptions("").WithInMemory(true)
+    opts.Logger = nil
+    db, err := badger.Open(opts)
+    require.NoError(t, err)
+    t.Cleanup(func() { db.Close() })
+
+    // Entities under a neighbouring prefix must never be listed
+    require.NoError(t, New[*item](db, "items").Create(&item{ID: "x"}))
+
+    s := New[*item](db, "item")
+    for i := 0; i < n; i++ {
+        require.NoError(t, s.Create(&item{ID: fmt.Sprintf("%03d", i), Name: fmt.Sprintf("item %d", i)}))
+    }
+    return s
+}
+
+func TestListPage(t *testing.T) {
+    s := setupStore(t, 25)
+
+    var ids []string
+    after := ""
+    pages := 0
+    for {
+        next, err := s.ListPage("", after, 10, func(it *item) error {
+            ids = append(ids, it.ID)
+            return nil
+        })
+        require.NoError(t, err)
+        pages++
+        if next == "" {
+            break
+        }
+        after = next
+    }
+    assert.Equal(t, 3, pages)
+    require.Len(t, ids, 25)
+    assert.Equal(t, "000", ids[0])
+    assert.Equal(t, "024", ids[24])
+
+    // An exactly full last page reports no next cursor
+    next, err := s.ListPage("", "014", 10, func(*item) error { return nil })
+    require.NoError(t, err)
+    assert.Empty(t, next)
+}
+
+func TestListFunc(t *testing.T) {
+    s := setupStore(t, 25)
+
+    var ids []string
+    require.NoError(t, s.ListFunc("01", func(it *item) error {
+        ids = append(ids, it.ID)
+        return nil
+    }))
+    assert.Equal(t, []string{"010", "011", "012", "013", "014", "015", "016", "017", "018", "019"}, ids)
+
+    count := 0
+    require.NoError(t, s.ListFunc("", func(*item) error {
+        count++
+        if count == 3 {
+            return ErrStop
+        }
+        return nil
+    }))
+    assert.Equal(t, 3, count)
+
+    items, err := s.List()
+    require.NoError(t, err)
+    require.Len(t, items, 25)
+    assert.Equal(t, "item 24", items[24].Name)
+}
+
+func TestCRUDAndQuery(t *testing.T) {
+    s := setupStore(t, 5)
+
+    it, err := s.Get("002")
+    require.NoError(t, err)
+    assert.Equal(t, "item 2", it.Name)
+
+    assert.Error(t, s.Create(&item{ID: "002"}), "duplicate IDs are rejected")
+    assert.Error(t, s.Update(&item{ID: "missing"}))
+
+    it.Name = "renamed"
+    require.NoError(t, s.Update(it))
+    it, err = s.Get("002")
+    require.NoError(t, err)
+    assert.Equal(t, "renamed", it.Name)
+
+    require.NoError(t, s.Delete("003"))
+    _, err = s.Get("003")
+    assert.Error(t, err)
+
+    matched, err := s.Query(func(it *item) bool { return it.ID >= "002" })
+    require.NoError(t, err)
+    require.Len(t, matched, 2)
+    assert.Equal(t, "002", matched[0].ID)
+    assert.Equal(t, "004", matched[1].ID)
+}
@@ -0,0 +1,64 @@
+// internal/storage/cache.go
+package storage
+
+import (
+    "github.com/dgraph-io/badger/v4"
+    "github.com/dgraph-io/ristretto"
+    "tig/internal/config"
+    "tig/internal/metrics"
+)
+
+// CacheStats describes one in-memory cache and how well it is doing
+type CacheStats struct {
+    Name     string  `json:"name"`
+    Enabled  bool    `json:"enabled"`
+    Capacity int64   `json:"capacity"` // entries for the content cache, bytes for Badger caches
+    Hits     uint64  `json:"hits"`
+    Misses   uint64  `json:"misses"`
+    HitRatio float64 `json:"hit_ratio"`
+}
+
+// NewCacheStats fills in the hit ratio
+func NewCacheStats(name string, enabled bool, capacity int64, hits, misses uint64) CacheStats {
+    s := CacheStats{Name: name, Enabled: enabled, Capacity: capacity, Hits: hits, Misses: misses}
+    if total := hits + misses; total > 0 {
+        s.HitRatio = float64(hits) / float64(total)
+    }
+    return s
+}
+
+// WithCache applies configured Badger cache sizes to opts
+func WithCache(opts badger.Options, c config.Cache) badger.Options {
+    if c.BlockCacheMB > 0 {
+        opts = opts.WithBlockCacheSize(c.BlockCacheMB << 20)
+    }
+    if c.IndexCacheMB > 0 {
+        opts = opts.WithIndexCacheSize(c.IndexCacheMB << 20)
+    }
+    return opts
+}
+
+// BadgerCacheStats reports the block and index caches of db
+func BadgerCacheStats(db *badger.DB) []CacheStats {
+    opts := db.Opts()
+    block, index := db.BlockCacheMetrics(), db.IndexCacheMetrics()
+    return []CacheStats{
+        NewCacheStats("block", block != nil, opts.BlockCacheSize, block.Hits(), block.Misses()),
+        NewCacheStats("index", index != nil, opts.IndexCacheSize, index.Hits(), index.Misses()),
+    }
+}
+
+// RegisterCacheMetrics exposes the Badger cache counters of db in the
+// default metrics registry
+func RegisterCacheMetrics(db *badger.DB) {
+    register := func(name string, m func() *ristretto.Metrics) {
+        metrics.NewCounterFunc("tig_badger_"+name+"_cache_hits_total", "Badger "+name+" cache hits", func() float64 {
+            return float64(m().Hits())
+        })
+        metrics.NewCounterFunc("tig_badger_"+name+"_cache_misses_total", "Badger "+name+" cache misses", func() float64 {
+            return float64(m().Misses())
+        })
+    }
+    register("block", db.BlockCacheMetrics)
+    register("index", db.IndexCacheMetrics)
+}
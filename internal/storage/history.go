@@ -0,0 +1,295 @@
+// internal/storage/history.go
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Key prefixes of the mutation log. Each mutation is stored once under
+// "events:<entity>:<id>:<time>" and indexed by time under
+// "events_time:<time>:<entity>:<id>".
+const (
+	eventsPrefix     = "events:"
+	eventsTimePrefix = "events_time:"
+)
+
+// Mutation kinds
+const (
+	MutationCreate = "create"
+	MutationUpdate = "update"
+	MutationDelete = "delete"
+)
+
+// Mutation is an immutable record of one change to an entity. State is
+// the entity as written, or empty for deletions.
+type Mutation struct {
+	Entity string          `json:"entity"` // Store prefix, e.g. "intent"
+	ID     string          `json:"id"`
+	Kind   string          `json:"kind"`
+	Time   time.Time       `json:"time"`
+	State  json.RawMessage `json:"state,omitempty"`
+	Cursor string          `json:"cursor,omitempty"` // Pass to Mutations to resume after this mutation
+}
+
+// WithHistory makes the store record every create, update and delete in
+// the mutation log, in the same transaction as the write
+func (s *Store[T]) WithHistory() *Store[T] {
+	s.history = true
+	return s
+}
+
+// record appends a mutation of the entity with the given ID to the log
+func (s *Store[T]) record(txn *badger.Txn, kind, id string, state []byte) error {
+	if !s.history {
+		return nil
+	}
+
+	// Keys must be unique even for several writes within one clock tick
+	now := time.Now()
+	var key []byte
+	for {
+		key = mutationKey(s.prefix, id, now)
+		if _, err := txn.Get(key); err == badger.ErrKeyNotFound {
+			break
+		} else if err != nil {
+			return err
+		}
+		now = now.Add(time.Nanosecond)
+	}
+
+	m := Mutation{Entity: s.prefix, ID: id, Kind: kind, Time: now, State: state}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshaling mutation: %w", err)
+	}
+	if err := txn.Set(key, data); err != nil {
+		return fmt.Errorf("recording mutation: %w", err)
+	}
+	if err := txn.Set([]byte(eventsTimePrefix+m.cursor()), key); err != nil {
+		return fmt.Errorf("indexing mutation: %w", err)
+	}
+	return nil
+}
+
+func mutationKey(entity, id string, at time.Time) []byte {
+	return []byte(fmt.Sprintf("%s%s:%s:%020d", eventsPrefix, entity, id, at.UnixNano()))
+}
+
+// cursor is the mutation's position in the time index
+func (m Mutation) cursor() string {
+	return fmt.Sprintf("%020d:%s:%s", m.Time.UnixNano(), m.Entity, m.ID)
+}
+
+// SinceCursor returns the cursor from which Mutations replays everything
+// recorded after t
+func SinceCursor(t time.Time) string {
+	return fmt.Sprintf("%020d", t.UnixNano()+1)
+}
+
+// History returns the mutations of the entity with the given ID, oldest
+// first
+func (s *Store[T]) History(id string) ([]Mutation, error) {
+	return History(s.db, s.prefix, id)
+}
+
+// GetAt reconstructs the entity as it was at time at from its recorded
+// mutations. It returns ErrNotFound if the entity did not exist then.
+func (s *Store[T]) GetAt(id string, at time.Time) (T, error) {
+	var entity T
+	mutations, err := s.History(id)
+	if err != nil {
+		return entity, err
+	}
+
+	var last *Mutation
+	for n := range mutations {
+		if mutations[n].Time.After(at) {
+			break
+		}
+		last = &mutations[n]
+	}
+	if last == nil || last.Kind == MutationDelete {
+		return entity, fmt.Errorf("%w: %s at %s", ErrNotFound, id, at.Format(time.RFC3339))
+	}
+	return s.decode(id, last.State)
+}
+
+// History returns the mutations recorded for one entity, oldest first
+func History(db *badger.DB, entity, id string) ([]Mutation, error) {
+	var mutations []Mutation
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(fmt.Sprintf("%s%s:%s:", eventsPrefix, entity, id))
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			m, err := decodeMutation(it.Item())
+			if err != nil {
+				return err
+			}
+			// IDs sharing a prefix, e.g. "a" and "a:b", are not this entity
+			if m.ID == id {
+				mutations = append(mutations, m)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading history of %s %s: %w", entity, id, err)
+	}
+	return mutations, nil
+}
+
+// Mutations calls fn with every mutation after the given cursor, oldest
+// first, e.g. to replay them to a consumer that missed deliveries. An
+// empty cursor starts at the beginning of the log and an empty entity
+// includes all entities. Returning ErrStop ends the replay early.
+func Mutations(db *badger.DB, entity, after string, fn func(Mutation) error) error {
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(eventsTimePrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		afterKey := []byte(eventsTimePrefix + after)
+		for it.Seek(afterKey); it.ValidForPrefix(opts.Prefix); it.Next() {
+			if bytes.Equal(it.Item().Key(), afterKey) {
+				continue
+			}
+			if entity != "" && !bytes.Contains(it.Item().Key(), []byte(":"+entity+":")) {
+				continue
+			}
+			key, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			item, err := txn.Get(key)
+			if err != nil {
+				return fmt.Errorf("reading mutation %s: %w", key, err)
+			}
+			m, err := decodeMutation(item)
+			if err != nil {
+				return err
+			}
+			if entity != "" && m.Entity != entity {
+				continue
+			}
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == ErrStop {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("replaying mutations: %w", err)
+	}
+	return nil
+}
+
+func decodeMutation(item *badger.Item) (Mutation, error) {
+	var m Mutation
+	err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &m)
+	})
+	if err != nil {
+		return m, fmt.Errorf("decoding mutation %s: %w", item.Key(), err)
+	}
+	m.Cursor = m.cursor()
+	return m, nil
+}
+
+// FieldChange is a field whose value differs between two states. Nested
+// fields are named with dots, e.g. "config.protection.required_reviewers".
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// DiffStates lists the fields that differ between two recorded states,
+// sorted by name. Either state may be empty.
+func DiffStates(before, after json.RawMessage) ([]FieldChange, error) {
+	a, err := flatten(before)
+	if err != nil {
+		return nil, err
+	}
+	b, err := flatten(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FieldChange
+	for field, av := range a {
+		if bv, ok := b[field]; !ok || !reflect.DeepEqual(av, bv) {
+			changes = append(changes, FieldChange{Field: field, Before: av, After: bv})
+		}
+	}
+	for field, bv := range b {
+		if _, ok := a[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, After: bv})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// flatten maps the leaf values of a JSON object to their dotted paths.
+// Arrays are kept whole so reordered lists show as one change.
+func flatten(state json.RawMessage) (map[string]any, error) {
+	fields := make(map[string]any)
+	if len(state) == 0 {
+		return fields, nil
+	}
+	var v any
+	if err := json.Unmarshal(state, &v); err != nil {
+		return nil, fmt.Errorf("decoding state: %w", err)
+	}
+
+	var walk func(path []string, v any)
+	walk = func(path []string, v any) {
+		obj, ok := v.(map[string]any)
+		if !ok || len(obj) == 0 {
+			fields[strings.Join(path, ".")] = v
+			return
+		}
+		for k, child := range obj {
+			walk(append(append([]string(nil), path...), k), child)
+		}
+	}
+	walk(nil, v)
+	return fields, nil
+}
+
+// AuditEntry is a mutation with the fields it changed relative to the
+// previous state
+type AuditEntry struct {
+	Mutation
+	Changes []FieldChange `json:"changes"`
+}
+
+// Audit pairs each of an entity's mutations, oldest first, with the
+// fields it changed
+func Audit(mutations []Mutation) ([]AuditEntry, error) {
+	entries := make([]AuditEntry, len(mutations))
+	var prev json.RawMessage
+	for n, m := range mutations {
+		changes, err := DiffStates(prev, m.State)
+		if err != nil {
+			return nil, fmt.Errorf("diffing %s %s at %s: %w", m.Entity, m.ID, m.Time.Format(time.RFC3339), err)
+		}
+		entries[n] = AuditEntry{Mutation: m, Changes: changes}
+		prev = m.State
+	}
+	return entries, nil
+}
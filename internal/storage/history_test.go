@@ -0,0 +1,89 @@
+// internal/storage/history_test.go
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New[*item](db, "item").WithHistory()
+	untracked := New[*item](db, "other")
+
+	require.NoError(t, s.Create(&item{ID: "a", Name: "first"}))
+	require.NoError(t, untracked.Create(&item{ID: "x"}))
+	afterCreate := time.Now()
+	require.NoError(t, s.Update(&item{ID: "a", Name: "second"}))
+	require.NoError(t, s.Create(&item{ID: "a:b", Name: "neighbour"}))
+	// Several writes in one unit of work keep their order
+	require.NoError(t, Run(db, func(u *UnitOfWork) error {
+		if err := s.With(u).Update(&item{ID: "a", Name: "third"}); err != nil {
+			return err
+		}
+		return s.With(u).Update(&item{ID: "a", Name: "fourth"})
+	}))
+	afterUpdates := time.Now()
+	require.NoError(t, s.Delete("a"))
+
+	history, err := s.History("a")
+	require.NoError(t, err)
+	require.Len(t, history, 5)
+	kinds := make([]string, len(history))
+	for n, m := range history {
+		kinds[n] = m.Kind
+	}
+	assert.Equal(t, []string{MutationCreate, MutationUpdate, MutationUpdate, MutationUpdate, MutationDelete}, kinds)
+	assert.True(t, history[2].Time.Before(history[3].Time))
+
+	// Point-in-time reconstruction
+	at, err := s.GetAt("a", afterCreate)
+	require.NoError(t, err)
+	assert.Equal(t, "first", at.Name)
+	at, err = s.GetAt("a", afterUpdates)
+	require.NoError(t, err)
+	assert.Equal(t, "fourth", at.Name)
+	_, err = s.GetAt("a", time.Now())
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.GetAt("a", afterCreate.Add(-time.Hour))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	changes, err := DiffStates(history[0].State, history[1].State)
+	require.NoError(t, err)
+	assert.Equal(t, []FieldChange{{Field: "name", Before: "first", After: "second"}}, changes)
+	changes, err = DiffStates(nil, json.RawMessage(`{"config":{"protection":{"required_reviewers":2}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []FieldChange{{Field: "config.protection.required_reviewers", After: float64(2)}}, changes)
+
+	// Replay resumes after a cursor without skipping or repeating
+	var replayed []Mutation
+	require.NoError(t, Mutations(db, "", "", func(m Mutation) error {
+		replayed = append(replayed, m)
+		return nil
+	}))
+	require.Len(t, replayed, 6, "only stores with history are recorded")
+	var rest []Mutation
+	require.NoError(t, Mutations(db, "item", replayed[2].Cursor, func(m Mutation) error {
+		rest = append(rest, m)
+		return nil
+	}))
+	assert.Equal(t, replayed[3:], rest)
+
+	var since []Mutation
+	require.NoError(t, Mutations(db, "item", SinceCursor(afterUpdates), func(m Mutation) error {
+		since = append(since, m)
+		return ErrStop
+	}))
+	require.Len(t, since, 1)
+	assert.Equal(t, MutationDelete, since[0].Kind)
+}
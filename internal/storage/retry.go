@@ -0,0 +1,64 @@
+// internal/storage/retry.go
+package storage
+
+import (
+    "errors"
+    "math/rand"
+    "time"
+
+    "github.com/dgraph-io/badger/v4"
+    "tig/internal/metrics"
+)
+
+var (
+    conflictRetries  = metrics.NewCounter("tig_badger_conflict_retries_total", "Badger transactions retried after a write conflict")
+    conflictFailures = metrics.NewCounter("tig_badger_conflict_failures_total", "Badger transactions that still conflicted after every retry")
+)
+
+// RetryPolicy controls how transactions that fail with badger.ErrConflict
+// are retried
+type RetryPolicy struct {
+    Attempts   int           // Total tries, including the first
+    Backoff    time.Duration // Wait before the first retry; doubled each time
+    MaxBackoff time.Duration // Upper bound on a single wait
+}
+
+// DefaultRetry is used by Update and Run
+var DefaultRetry = RetryPolicy{
+    Attempts:   5,
+    Backoff:    2 * time.Millisecond,
+    MaxBackoff: 100 * time.Millisecond,
+}
+
+// Retry calls fn until it returns something other than badger.ErrConflict
+// or the policy's attempts run out. fn must start a fresh transaction on
+// every call.
+func (p RetryPolicy) Retry(fn func() error) error {
+    backoff := p.Backoff
+    for attempt := 1; ; attempt++ {
+        err := fn()
+        if !errors.Is(err, badger.ErrConflict) {
+            return err
+        }
+        if attempt >= p.Attempts {
+            conflictFailures.Inc()
+            return err
+        }
+
+        conflictRetries.Inc()
+        // Jitter keeps conflicting writers from retrying in lockstep
+        time.Sleep(backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1)))
+        backoff *= 2
+        if backoff > p.MaxBackoff {
+            backoff = p.MaxBackoff
+        }
+    }
+}
+
+// Update is db.Update retried under DefaultRetry when the transaction
+// conflicts with a concurrent writer
+func Update(db *badger.DB, fn func(txn *badger.Txn) error) error {
+    return DefaultRetry.Retry(func() error {
+        return db.Update(fn)
+    })
+}
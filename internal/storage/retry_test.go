@@ -0,0 +1,66 @@
+// internal/storage/retry_test.go
+package storage
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/dgraph-io/badger/v4"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestRetryConflicts(t *testing.T) {
+    p := RetryPolicy{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+    calls := 0
+    retries := conflictRetries.Value()
+    err := p.Retry(func() error {
+        calls++
+        if calls < 3 {
+            return badger.ErrConflict
+        }
+        return nil
+    })
+    require.NoError(t, err)
+    assert.Equal(t, 3, calls)
+    assert.Equal(t, retries+2, conflictRetries.Value())
+
+    // Conflicts past the last attempt are returned
+    failures := conflictFailures.Value()
+    err = p.Retry(func() error { return badger.ErrConflict })
+    assert.ErrorIs(t, err, badger.ErrConflict)
+    assert.Equal(t, failures+1, conflictFailures.Value())
+
+    // Other errors are never retried
+    calls = 0
+    fail := errors.New("fail")
+    assert.ErrorIs(t, p.Retry(func() error { calls++; return fail }), fail)
+    assert.Equal(t, 1, calls)
+}
+
+func TestRunRetriesConflictingUnit(t *testing.T) {
+    s := setupStore(t, 1)
+
+    attempts := 0
+    err := Run(s.db, func(u *UnitOfWork) error {
+        attempts++
+        it, err := s.With(u).Get("000")
+        if err != nil {
+            return err
+        }
+        if attempts == 1 {
+            // A concurrent writer changes the entity after it was read
+            require.NoError(t, s.Update(&item{ID: "000", Name: "theirs"}))
+        }
+        it.Name += " and ours"
+        return s.With(u).Update(it)
+    })
+    require.NoError(t, err)
+    assert.Equal(t, 2, attempts)
+
+    got, err := s.Get("000")
+    require.NoError(t, err)
+    assert.Equal(t, "theirs and ours", got.Name)
+}
@@ -0,0 +1,263 @@
+// internal/storage/unit.go
+package storage
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+
+    "github.com/dgraph-io/badger/v4"
+)
+
+// ErrFinished is returned when a UnitOfWork is used after Commit or Rollback
+var ErrFinished = errors.New("unit of work already finished")
+
+// UnitOfWork groups writes to several stores into a single Badger
+// transaction. They are committed together by Commit or discarded
+// together by Rollback.
+type UnitOfWork struct {
+    txn      *badger.Txn
+    done     bool
+    onCommit []func()
+}
+
+// Begin starts a read-write unit of work
+func Begin(db *badger.DB) *UnitOfWork {
+    return &UnitOfWork{txn: db.NewTransaction(true)}
+}
+
+// Run calls fn inside a new unit of work, committing if it returns nil
+// and rolling back otherwise. A unit that conflicts with a concurrent
+// writer is retried from the start under DefaultRetry, so fn may run more
+// than once.
+func Run(db *badger.DB, fn func(u *UnitOfWork) error) error {
+    return DefaultRetry.Retry(func() error {
+        u := Begin(db)
+        defer u.Rollback()
+
+        if err := fn(u); err != nil {
+            return err
+        }
+        return u.Commit()
+    })
+}
+
+// Write is a key a unit of work sets to a new value or deletes
+type Write struct {
+    Key    string `json:"key"`
+    Delete bool   `json:"delete,omitempty"`
+}
+
+// Preview calls fn inside a unit of work that is always rolled back and
+// returns the keys it would have changed, in key order. Keys set to the
+// value they already hold are left out. OnCommit functions do not run.
+func Preview(db *badger.DB, fn func(u *UnitOfWork) error) ([]Write, error) {
+    u := Begin(db)
+    defer u.Rollback()
+    if err := fn(u); err != nil {
+        return nil, err
+    }
+
+    // Walk the unit's view, which includes its pending writes, alongside
+    // the committed data
+    committed := db.NewTransaction(false)
+    defer committed.Discard()
+    after := u.txn.NewIterator(badger.DefaultIteratorOptions)
+    defer after.Close()
+    before := committed.NewIterator(badger.DefaultIteratorOptions)
+    defer before.Close()
+
+    var writes []Write
+    after.Rewind()
+    before.Rewind()
+    for after.Valid() || before.Valid() {
+        var cmp int
+        switch {
+        case !after.Valid():
+            cmp = 1
+        case !before.Valid():
+            cmp = -1
+        default:
+            cmp = bytes.Compare(after.Item().Key(), before.Item().Key())
+        }
+
+        switch {
+        case cmp < 0:
+            writes = append(writes, Write{Key: string(after.Item().Key())})
+            after.Next()
+        case cmp > 0:
+            writes = append(writes, Write{Key: string(before.Item().Key()), Delete: true})
+            before.Next()
+        default:
+            a, err := after.Item().ValueCopy(nil)
+            if err != nil {
+                return nil, err
+            }
+            b, err := before.Item().ValueCopy(nil)
+            if err != nil {
+                return nil, err
+            }
+            if !bytes.Equal(a, b) {
+                writes = append(writes, Write{Key: string(after.Item().Key())})
+            }
+            after.Next()
+            before.Next()
+        }
+    }
+    return writes, nil
+}
+
+// Txn returns the underlying transaction for writes to keys no store
+// covers. Callers must not commit or discard it themselves.
+func (u *UnitOfWork) Txn() *badger.Txn {
+    return u.txn
+}
+
+// OnCommit registers fn to run after a successful Commit, typically to
+// bring in-memory caches in line with what was written
+func (u *UnitOfWork) OnCommit(fn func()) {
+    u.onCommit = append(u.onCommit, fn)
+}
+
+// Get decodes the JSON value at key into v
+func (u *UnitOfWork) Get(key string, v any) error {
+    item, err := u.txn.Get([]byte(key))
+    if err != nil {
+        return err
+    }
+    return item.Value(func(val []byte) error {
+        return json.Unmarshal(val, v)
+    })
+}
+
+// Set stores v as JSON at key
+func (u *UnitOfWork) Set(key string, v any) error {
+    data, err := json.Marshal(v)
+    if err != nil {
+        return fmt.Errorf("marshaling %s: %w", key, err)
+    }
+    return u.txn.Set([]byte(key), data)
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func (u *UnitOfWork) Delete(key string) error {
+    return u.txn.Delete([]byte(key))
+}
+
+// Commit writes every change made in the unit of work atomically
+func (u *UnitOfWork) Commit() error {
+    if u.done {
+        return ErrFinished
+    }
+    u.done = true
+    if err := u.txn.Commit(); err != nil {
+        return fmt.Errorf("committing unit of work: %w", err)
+    }
+    for _, fn := range u.onCommit {
+        fn()
+    }
+    return nil
+}
+
+// Rollback discards every change made in the unit of work. It is a no-op
+// after Commit, so it can be deferred.
+func (u *UnitOfWork) Rollback() {
+    if u.done {
+        return
+    }
+    u.done = true
+    u.txn.Discard()
+}
+
+// Txn is a view of a Store whose reads and writes go through a unit of
+// work
+type Txn[T Entity] struct {
+    store *Store[T]
+    txn   *badger.Txn
+}
+
+// With returns a view of the store bound to u
+func (s *Store[T]) With(u *UnitOfWork) *Txn[T] {
+    return &Txn[T]{store: s, txn: u.txn}
+}
+
+// Create stores a new entity, failing if its ID is taken
+func (t *Txn[T]) Create(entity T) error {
+    if entity.GetID() == "" {
+        return fmt.Errorf("entity ID cannot be empty")
+    }
+
+    data, err := json.Marshal(entity)
+    if err != nil {
+        return fmt.Errorf("marshaling entity: %w", err)
+    }
+
+    key := t.store.makeKey(entity.GetID())
+    _, err = t.txn.Get(key)
+    if err == nil {
+        return fmt.Errorf("%w: %s", ErrExists, entity.GetID())
+    } else if err != badger.ErrKeyNotFound {
+        return err
+    }
+    if err := t.txn.Set(key, data); err != nil {
+        return err
+    }
+    return t.store.record(t.txn, MutationCreate, entity.GetID(), data)
+}
+
+// Get loads the entity with the given ID
+func (t *Txn[T]) Get(id string) (T, error) {
+    var entity T
+    item, err := t.txn.Get(t.store.makeKey(id))
+    if err == badger.ErrKeyNotFound {
+        return entity, fmt.Errorf("%w: %s", ErrNotFound, id)
+    } else if err != nil {
+        return entity, err
+    }
+
+    err = item.Value(func(val []byte) error {
+        entity, err = t.store.decode(id, val)
+        return err
+    })
+    return entity, err
+}
+
+// Update replaces an existing entity
+func (t *Txn[T]) Update(entity T) error {
+    if entity.GetID() == "" {
+        return fmt.Errorf("entity ID cannot be empty")
+    }
+
+    data, err := json.Marshal(entity)
+    if err != nil {
+        return fmt.Errorf("marshaling entity: %w", err)
+    }
+
+    key := t.store.makeKey(entity.GetID())
+    _, err = t.txn.Get(key)
+    if err == badger.ErrKeyNotFound {
+        return fmt.Errorf("%w: %s", ErrNotFound, entity.GetID())
+    } else if err != nil {
+        return err
+    }
+    if err := t.txn.Set(key, data); err != nil {
+        return err
+    }
+    return t.store.record(t.txn, MutationUpdate, entity.GetID(), data)
+}
+
+// Delete removes the entity with the given ID
+func (t *Txn[T]) Delete(id string) error {
+    key := t.store.makeKey(id)
+    _, err := t.txn.Get(key)
+    if err == badger.ErrKeyNotFound {
+        return fmt.Errorf("%w: %s", ErrNotFound, id)
+    } else if err != nil {
+        return err
+    }
+    if err := t.txn.Delete(key); err != nil {
+        return err
+    }
+    return t.store.record(t.txn, MutationDelete, id, nil)
+}
@@ -0,0 +1,83 @@
+// internal/storage/unit_test.go
+package storage
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestUnitOfWork(t *testing.T) {
+    items := setupStore(t, 1)
+    others := New[*item](items.db, "other")
+
+    // Writes to both stores land together
+    committed := false
+    err := Run(items.db, func(u *UnitOfWork) error {
+        require.NoError(t, items.With(u).Create(&item{ID: "a"}))
+        require.NoError(t, others.With(u).Create(&item{ID: "b"}))
+        require.NoError(t, u.Set("raw:key", map[string]int{"n": 1}))
+        u.OnCommit(func() { committed = true })
+        return nil
+    })
+    require.NoError(t, err)
+    assert.True(t, committed)
+    _, err = items.Get("a")
+    assert.NoError(t, err)
+    _, err = others.Get("b")
+    assert.NoError(t, err)
+
+    // A failure discards every write made so far
+    fail := errors.New("fail")
+    err = Run(items.db, func(u *UnitOfWork) error {
+        require.NoError(t, items.With(u).Delete("a"))
+        require.NoError(t, others.With(u).Create(&item{ID: "c"}))
+        return fail
+    })
+    assert.ErrorIs(t, err, fail)
+    _, err = items.Get("a")
+    assert.NoError(t, err)
+    _, err = others.Get("c")
+    assert.Error(t, err)
+
+    // Reads see the unit's own uncommitted writes
+    u := Begin(items.db)
+    require.NoError(t, others.With(u).Update(&item{ID: "b", Name: "renamed"}))
+    got, err := others.With(u).Get("b")
+    require.NoError(t, err)
+    assert.Equal(t, "renamed", got.Name)
+    u.Rollback()
+    assert.ErrorIs(t, u.Commit(), ErrFinished)
+}
+
+func TestPreview(t *testing.T) {
+    items := setupStore(t, 2)
+    require.NoError(t, Run(items.db, func(u *UnitOfWork) error {
+        return u.Set("raw:same", 1)
+    }))
+
+    writes, err := Preview(items.db, func(u *UnitOfWork) error {
+        require.NoError(t, items.With(u).Delete("000"))
+        require.NoError(t, items.With(u).Update(&item{ID: "001", Name: "renamed"}))
+        require.NoError(t, items.With(u).Create(&item{ID: "002"}))
+        return u.Set("raw:same", 1)
+    })
+    require.NoError(t, err)
+    assert.Equal(t, []Write{
+        {Key: "item:000", Delete: true},
+        {Key: "item:001"},
+        {Key: "item:002"},
+    }, writes)
+
+    // Nothing was committed
+    _, err = items.Get("000")
+    assert.NoError(t, err)
+    _, err = items.Get("002")
+    assert.Error(t, err)
+
+    fail := errors.New("fail")
+    _, err = Preview(items.db, func(u *UnitOfWork) error { return fail })
+    assert.ErrorIs(t, err, fail)
+}
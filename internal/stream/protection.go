@@ -0,0 +1,41 @@
+// internal/stream/protection.go
+package stream
+
+import (
+	"fmt"
+
+	"tig/internal/intent"
+)
+
+// Unmet lists the protection requirements an intent does not yet satisfy,
+// ignoring schedules. An empty result means the intent may be merged
+// outside any scheduled window; UnmetAt accounts for them.
+func (p Protection) Unmet(i *intent.Intent) []string {
+	var unmet []string
+
+	if approvals := len(i.Approvals()); approvals < p.RequiredReviewers {
+		unmet = append(unmet, fmt.Sprintf("%d of %d required approvals", approvals, p.RequiredReviewers))
+	}
+
+	for _, name := range p.RequiredChecks {
+		if status := i.CheckStatus(name); status != intent.CheckPassed {
+			unmet = append(unmet, fmt.Sprintf("check %s is %s", name, status))
+		}
+	}
+
+	if p.MaxCoverageDrop != nil {
+		switch c := i.Coverage; {
+		case c == nil:
+			unmet = append(unmet, "coverage not recorded")
+		case -c.Delta > *p.MaxCoverageDrop:
+			unmet = append(unmet, fmt.Sprintf("coverage dropped %.1f points, more than the %.1f allowed", -c.Delta, *p.MaxCoverageDrop))
+		}
+	}
+
+	return unmet
+}
+
+// Enabled reports whether the stream has any protection rules
+func (p Protection) Enabled() bool {
+	return p.RequiredReviewers > 0 || len(p.RequiredChecks) > 0 || len(p.Schedules) > 0 || p.MaxCoverageDrop != nil
+}
@@ -0,0 +1,212 @@
+// internal/stream/schedule.go
+package stream
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tigerrors "tig/internal/errors"
+	"tig/internal/intent"
+)
+
+// Schedule changes a stream's protection during a window of time, such as
+// freezing merges over the weekend or requiring more reviewers during
+// release week. A window recurs weekly when Start and End are given as a
+// day and time, e.g. "Fri 18:00" and "Mon 08:00", read in Timezone; it
+// happens once when they are RFC 3339 times.
+type Schedule struct {
+	Name     string `json:"name"`
+	Reason   string `json:"reason,omitempty"` // Shown when the schedule blocks a merge
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"` // IANA name; UTC when empty
+
+	Freeze            bool     `json:"freeze,omitempty"`             // No merges during the window
+	RequiredReviewers int      `json:"required_reviewers,omitempty"` // Raises the stream's minimum during the window
+	RequiredChecks    []string `json:"required_checks,omitempty"`    // Required in addition to the stream's checks
+}
+
+// weekTime is a time of the week in minutes since Sunday 00:00
+type weekTime int
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseWeekTime parses a day and time such as "Fri 18:00"
+func parseWeekTime(s string) (weekTime, error) {
+	day, clock, ok := strings.Cut(strings.TrimSpace(s), " ")
+	wd, known := weekdays[strings.ToLower(day)[:min(3, len(day))]]
+	if !ok || !known {
+		return 0, fmt.Errorf("%q is not a day and time like \"Fri 18:00\"", s)
+	}
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a day and time like \"Fri 18:00\"", s)
+	}
+	return weekTime(int(wd)*24*60 + t.Hour()*60 + t.Minute()), nil
+}
+
+func (s Schedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// Validate checks that the window parses and that the schedule changes
+// something
+func (s Schedule) Validate() error {
+	name := s.Name
+	if name == "" {
+		name = "schedule"
+	}
+	if _, err := s.location(); err != nil {
+		return tigerrors.ValidationError(fmt.Sprintf("%s: unknown timezone %q", name, s.Timezone), nil)
+	}
+	if _, err := s.activeUntil(time.Now()); err != nil {
+		return tigerrors.ValidationError(fmt.Sprintf("%s: %v", name, err), nil)
+	}
+	if !s.Freeze && s.RequiredReviewers <= 0 && len(s.RequiredChecks) == 0 {
+		return tigerrors.ValidationError(fmt.Sprintf("%s: a schedule must freeze merges or require reviewers or checks", name), nil)
+	}
+	return nil
+}
+
+// Until returns when the window containing now ends, or the zero time
+// when now is outside the window
+func (s Schedule) Until(now time.Time) time.Time {
+	end, _ := s.activeUntil(now)
+	return end
+}
+
+func (s Schedule) activeUntil(now time.Time) (time.Time, error) {
+	loc, err := s.location()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	start, startErr := time.Parse(time.RFC3339, s.Start)
+	end, endErr := time.Parse(time.RFC3339, s.End)
+	if startErr == nil || endErr == nil {
+		if startErr != nil || endErr != nil {
+			return time.Time{}, fmt.Errorf("start and end must both be RFC 3339 times or both days and times")
+		}
+		if !end.After(start) {
+			return time.Time{}, fmt.Errorf("end %s is not after start %s", s.End, s.Start)
+		}
+		if now.Before(start) || !now.Before(end) {
+			return time.Time{}, nil
+		}
+		return end.In(loc), nil
+	}
+
+	from, err := parseWeekTime(s.Start)
+	if err != nil {
+		return time.Time{}, err
+	}
+	to, err := parseWeekTime(s.End)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if from == to {
+		return time.Time{}, fmt.Errorf("start and end are the same time")
+	}
+
+	local := now.In(loc)
+	at := weekTime(int(local.Weekday())*24*60 + local.Hour()*60 + local.Minute())
+	active := from <= at && at < to
+	if from > to { // Wraps past the end of the week
+		active = at >= from || at < to
+	}
+	if !active {
+		return time.Time{}, nil
+	}
+	days := (int(to)/(24*60) - int(local.Weekday()) + 7) % 7
+	if days == 0 && to <= at {
+		days = 7
+	}
+	return time.Date(local.Year(), local.Month(), local.Day()+days, int(to)%(24*60)/60, int(to)%60, 0, 0, loc), nil
+}
+
+func (s Schedule) describe() string {
+	switch {
+	case s.Reason != "":
+		return s.Reason
+	case s.Name != "":
+		return s.Name
+	}
+	return "scheduled"
+}
+
+// Validate checks the protection's coverage policy and schedules
+func (p Protection) Validate() error {
+	if p.MaxCoverageDrop != nil && *p.MaxCoverageDrop < 0 {
+		return fmt.Errorf("max_coverage_drop must not be negative")
+	}
+	for _, s := range p.Schedules {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Active returns the schedules whose window contains now
+func (p Protection) Active(now time.Time) []Schedule {
+	var active []Schedule
+	for _, s := range p.Schedules {
+		if !s.Until(now).IsZero() {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+// CanOverride reports whether by may merge into the stream regardless of
+// its schedules
+func (p Protection) CanOverride(by string) bool {
+	return by != "" && slices.Contains(p.OverrideBy, by)
+}
+
+// UnmetAt lists the protection requirements an intent does not satisfy
+// at a given time: those of Unmet, raised by any schedule in force, and
+// a freeze if one is
+func (p Protection) UnmetAt(i *intent.Intent, now time.Time) []string {
+	var unmet []string
+	reviewers, reviewersFor := p.RequiredReviewers, ""
+	var checks []string
+	checksFor := make(map[string]string)
+	for _, s := range p.Active(now) {
+		if s.Freeze {
+			unmet = append(unmet, fmt.Sprintf("stream frozen until %s (%s)", s.Until(now).Format("Mon Jan 2 15:04 MST"), s.describe()))
+		}
+		if s.RequiredReviewers > reviewers {
+			reviewers, reviewersFor = s.RequiredReviewers, s.describe()
+		}
+		for _, name := range s.RequiredChecks {
+			if _, seen := checksFor[name]; !seen && !slices.Contains(p.RequiredChecks, name) {
+				checks = append(checks, name)
+				checksFor[name] = s.describe()
+			}
+		}
+	}
+
+	base := p
+	base.RequiredReviewers = reviewers
+	for _, reason := range base.Unmet(i) {
+		if reviewersFor != "" && strings.HasSuffix(reason, "required approvals") {
+			reason += " during " + reviewersFor
+		}
+		unmet = append(unmet, reason)
+	}
+	for _, name := range checks {
+		if status := i.CheckStatus(name); status != intent.CheckPassed {
+			unmet = append(unmet, fmt.Sprintf("check %s is %s during %s", name, status, checksFor[name]))
+		}
+	}
+	return unmet
+}
@@ -0,0 +1,97 @@
+// internal/stream/schedule_test.go
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"tig/internal/intent"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeeklyFreeze(t *testing.T) {
+	freeze := Schedule{Name: "weekend", Start: "Fri 18:00", End: "Mon 08:00", Freeze: true}
+	require.NoError(t, freeze.Validate())
+
+	// 2026-10-16 is a Friday
+	friday := time.Date(2026, 10, 16, 17, 59, 0, 0, time.UTC)
+	assert.True(t, freeze.Until(friday).IsZero())
+	monday := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, monday, freeze.Until(friday.Add(time.Minute)))
+	assert.Equal(t, monday, freeze.Until(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)))
+	assert.True(t, freeze.Until(monday).IsZero())
+	assert.True(t, freeze.Until(time.Date(2026, 10, 21, 12, 0, 0, 0, time.UTC)).IsZero())
+
+	// Windows are read in their timezone
+	freeze.Timezone = "America/New_York"
+	assert.True(t, freeze.Until(time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)).IsZero())
+	until := freeze.Until(time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, "Mon 08:00", until.Format("Mon 15:04"))
+}
+
+func TestOneOffWindow(t *testing.T) {
+	s := Schedule{Start: "2026-12-20T00:00:00Z", End: "2027-01-04T00:00:00Z", Freeze: true}
+	require.NoError(t, s.Validate())
+	assert.True(t, s.Until(time.Date(2026, 12, 19, 0, 0, 0, 0, time.UTC)).IsZero())
+	assert.Equal(t, time.Date(2027, 1, 4, 0, 0, 0, 0, time.UTC), s.Until(time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestScheduleValidate(t *testing.T) {
+	for _, s := range []Schedule{
+		{Name: "bad day", Start: "Someday 18:00", End: "Mon 08:00", Freeze: true},
+		{Name: "bad clock", Start: "Fri 6pm", End: "Mon 08:00", Freeze: true},
+		{Name: "mixed", Start: "2026-12-20T00:00:00Z", End: "Mon 08:00", Freeze: true},
+		{Name: "backwards", Start: "2027-01-04T00:00:00Z", End: "2026-12-20T00:00:00Z", Freeze: true},
+		{Name: "zone", Start: "Fri 18:00", End: "Mon 08:00", Timezone: "Mars/Olympus", Freeze: true},
+		{Name: "no effect", Start: "Fri 18:00", End: "Mon 08:00"},
+	} {
+		assert.Error(t, s.Validate(), s.Name)
+	}
+}
+
+func TestUnmetAt(t *testing.T) {
+	p := Protection{
+		RequiredReviewers: 1,
+		RequiredChecks:    []string{"ci"},
+		Schedules: []Schedule{
+			{Name: "weekend", Reason: "weekend freeze", Start: "Fri 18:00", End: "Mon 08:00", Freeze: true},
+			{Name: "release week", Start: "2026-10-12T00:00:00Z", End: "2026-10-17T00:00:00Z",
+				RequiredReviewers: 2, RequiredChecks: []string{"ci", "e2e"}},
+		},
+		OverrideBy: []string{"release-manager"},
+	}
+	i := &intent.Intent{Reviews: []intent.Review{{Reviewer: "alice", Approved: true}}}
+	i.SetCheck(intent.Check{Name: "ci", Status: intent.CheckPassed})
+
+	assert.Empty(t, p.Unmet(i))
+	assert.Empty(t, p.UnmetAt(i, time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, []string{
+		"1 of 2 required approvals during release week",
+		"check e2e is pending during release week",
+	}, p.UnmetAt(i, time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, []string{
+		"stream frozen until Mon Oct 19 08:00 UTC (weekend freeze)",
+	}, p.UnmetAt(i, time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)))
+
+	assert.True(t, p.CanOverride("release-manager"))
+	assert.False(t, p.CanOverride("alice"))
+	assert.False(t, p.CanOverride(""))
+}
+
+func TestCoverageDrop(t *testing.T) {
+	drop := 1.0
+	p := Protection{MaxCoverageDrop: &drop}
+	require.True(t, p.Enabled())
+	i := &intent.Intent{}
+	assert.Equal(t, []string{"coverage not recorded"}, p.Unmet(i))
+
+	i.Coverage = &intent.Coverage{Delta: -0.5}
+	assert.Empty(t, p.Unmet(i))
+	i.Coverage.Delta = -2.5
+	assert.Equal(t, []string{"coverage dropped 2.5 points, more than the 1.0 allowed"}, p.Unmet(i))
+
+	drop = -1
+	assert.Error(t, p.Validate())
+}
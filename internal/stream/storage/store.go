@@ -6,6 +6,7 @@ import (
     "time"
 
     "github.com/dgraph-io/badger/v4"
+    "tig/internal/errors"
     "tig/internal/intent"
     "tig/internal/stream"
     "tig/internal/storage"
@@ -13,27 +14,18 @@ import (
 
 // Store handles all stream storage operations
 type Store struct {
-    store     *storage.BadgerStore
+    store     *storage.Store[*stream.Stream]
     intentBox intent.Box
 }
 
 // NewStore creates a new stream store
 func NewStore(db *badger.DB, intentBox intent.Box) *Store {
     return &Store{
-        store:     storage.NewBadgerStore(db, "stream"),
+        store:     storage.New[*stream.Stream](db, "stream").WithHistory(),
         intentBox: intentBox,
     }
 }
 
-// streamEntity wraps stream.Stream to implement storage.Entity
-type streamEntity struct {
-    *stream.Stream
-}
-
-func (s *streamEntity) GetID() string {
-    return s.ID
-}
-
 // validate checks if a stream has all required fields
 func validate(s *stream.Stream) error {
     if s.Name == "" {
@@ -45,8 +37,9 @@ func validate(s *stream.Stream) error {
     return nil
 }
 
-// Create stores a new stream
-func (s *Store) Create(st *stream.Stream) error {
+// prepareCreate validates a new stream and sets its timestamps and
+// initial state
+func prepareCreate(st *stream.Stream) error {
     if err := validate(st); err != nil {
         return fmt.Errorf("invalid stream: %w", err)
     }
@@ -61,36 +54,48 @@ func (s *Store) Create(st *stream.Stream) error {
     if st.State.LastSync.IsZero() {
         st.State.LastSync = st.CreatedAt
     }
-    
+
     // Initialize state if not set
     if st.State.Status == "" {
         st.State.Status = "stable"
     }
     st.State.Active = true
+    return nil
+}
 
-    return s.store.Create(&streamEntity{Stream: st})
+// prepareUpdate validates a changed stream and bumps its timestamp
+func prepareUpdate(st *stream.Stream) error {
+    if err := validate(st); err != nil {
+        return fmt.Errorf("invalid stream: %w", err)
+    }
+
+    st.UpdatedAt = time.Now()
+    return nil
+}
+
+// Create stores a new stream
+func (s *Store) Create(st *stream.Stream) error {
+    if err := prepareCreate(st); err != nil {
+        return err
+    }
+    return s.store.Create(st)
 }
 
 // Get retrieves a stream by ID
 func (s *Store) Get(id string) (*stream.Stream, error) {
-    var entity streamEntity
-    entity.Stream = &stream.Stream{}
-    
-    if err := s.store.Get(id, &entity); err != nil {
+    st, err := s.store.Get(id)
+    if err != nil {
         return nil, fmt.Errorf("getting stream: %w", err)
     }
-
-    return entity.Stream, nil
+    return st, nil
 }
 
 // Update modifies an existing stream
 func (s *Store) Update(st *stream.Stream) error {
-    if err := validate(st); err != nil {
-        return fmt.Errorf("invalid stream: %w", err)
+    if err := prepareUpdate(st); err != nil {
+        return err
     }
-
-    st.UpdatedAt = time.Now()
-    return s.store.Update(&streamEntity{Stream: st})
+    return s.store.Update(st)
 }
 
 // Delete removes a stream by ID
@@ -98,18 +103,49 @@ func (s *Store) Delete(id string) error {
     return s.store.Delete(id)
 }
 
+// History returns every recorded mutation of a stream, oldest first
+func (s *Store) History(id string) ([]storage.Mutation, error) {
+    return s.store.History(id)
+}
+
+// GetAt reconstructs a stream as it was at the given time
+func (s *Store) GetAt(id string, at time.Time) (*stream.Stream, error) {
+    st, err := s.store.GetAt(id, at)
+    if err != nil {
+        return nil, fmt.Errorf("getting stream: %w", err)
+    }
+    return st, nil
+}
+
 // List returns all streams
 func (s *Store) List() ([]*stream.Stream, error) {
-    var entities []streamEntity
-    if err := s.store.List(&entities); err != nil {
+    streams, err := s.store.List()
+    if err != nil {
         return nil, fmt.Errorf("listing streams: %w", err)
     }
+    return streams, nil
+}
+
+// ListFunc calls fn for each stream in ID order, decoding one at a time
+func (s *Store) ListFunc(fn func(*stream.Stream) error) error {
+    if err := s.store.ListFunc("", fn); err != nil {
+        return fmt.Errorf("listing streams: %w", err)
+    }
+    return nil
+}
 
-    streams := make([]*stream.Stream, len(entities))
-    for i, entity := range entities {
-        streams[i] = entity.Stream
+// ListPage returns up to limit streams with IDs after the given one and
+// the cursor for the next page, which is empty on the last page
+func (s *Store) ListPage(after string, limit int) ([]*stream.Stream, string, error) {
+    streams := make([]*stream.Stream, 0, limit)
+    next, err := s.store.ListPage("", after, limit, func(st *stream.Stream) error {
+        streams = append(streams, st)
+        return nil
+    })
+    if err != nil {
+        return nil, "", fmt.Errorf("listing streams: %w", err)
     }
-    return streams, nil
+    return streams, next, nil
 }
 
 // AddIntent adds an intent to a stream
@@ -153,7 +189,7 @@ func (s *Store) RemoveIntent(streamID string, intentID string) error {
     }
 
     if !found {
-        return fmt.Errorf("intent not found in stream: %s", intentID)
+        return errors.NotFound(fmt.Sprintf("intent not found in stream: %s", intentID))
     }
 
     st.State.Intents = newIntents
@@ -215,7 +251,7 @@ func (s *Store) GetFeatureFlag(streamID string, flagName string) (*stream.Featur
         }
     }
 
-    return nil, fmt.Errorf("feature flag not found: %s", flagName)
+    return nil, errors.NotFound(fmt.Sprintf("feature flag not found: %s", flagName))
 }
 
 // FindByType returns streams of a specific type
@@ -224,32 +260,63 @@ func (s *Store) FindByType(streamType string) ([]*stream.Stream, error) {
         return nil, fmt.Errorf("stream type is required")
     }
 
-    streams, err := s.List()
+    return s.store.Query(func(st *stream.Stream) bool { return st.Type == streamType })
+}
+
+// FindActive returns all active streams
+func (s *Store) FindActive() ([]*stream.Stream, error) {
+    return s.store.Query(func(st *stream.Stream) bool { return st.State.Active })
+}
+
+// Tx is a view of the store whose reads and writes go through a unit of
+// work, so they commit or roll back together with other stores
+type Tx struct {
+    txn *storage.Txn[*stream.Stream]
+}
+
+// With returns a view of the store bound to u
+func (s *Store) With(u *storage.UnitOfWork) *Tx {
+    return &Tx{txn: s.store.With(u)}
+}
+
+// Create stores a new stream
+func (t *Tx) Create(st *stream.Stream) error {
+    if err := prepareCreate(st); err != nil {
+        return err
+    }
+    return t.txn.Create(st)
+}
+
+// Get retrieves a stream by ID
+func (t *Tx) Get(id string) (*stream.Stream, error) {
+    st, err := t.txn.Get(id)
     if err != nil {
-        return nil, err
+        return nil, fmt.Errorf("getting stream: %w", err)
     }
+    return st, nil
+}
 
-    var result []*stream.Stream
-    for _, st := range streams {
-        if st.Type == streamType {
-            result = append(result, st)
-        }
+// Update modifies an existing stream
+func (t *Tx) Update(st *stream.Stream) error {
+    if err := prepareUpdate(st); err != nil {
+        return err
     }
-    return result, nil
+    return t.txn.Update(st)
 }
 
-// FindActive returns all active streams
-func (s *Store) FindActive() ([]*stream.Stream, error) {
-    streams, err := s.List()
+// AddIntent adds an intent to a stream. Unlike Store.AddIntent it does
+// not check that the intent exists, since it may be created in the same
+// unit of work.
+func (t *Tx) AddIntent(streamID, intentID string) error {
+    st, err := t.Get(streamID)
     if err != nil {
-        return nil, err
+        return err
     }
-
-    var result []*stream.Stream
-    for _, st := range streams {
-        if st.State.Active {
-            result = append(result, st)
+    for _, id := range st.State.Intents {
+        if id == intentID {
+            return nil // Already exists
         }
     }
-    return result, nil
-}
\ No newline at end of file
+    st.State.Intents = append(st.State.Intents, intentID)
+    return t.Update(st)
+}
@@ -15,6 +15,9 @@ type Stream struct {
     UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// GetID implements storage.Entity
+func (s *Stream) GetID() string { return s.ID }
+
 type Config struct {
     AutoMerge    bool           `json:"auto_merge"`
     FeatureFlags []FeatureFlag  `json:"feature_flags"`
@@ -28,15 +31,38 @@ type FeatureFlag struct {
 }
 
 type Protection struct {
-    RequiredReviewers int      `json:"required_reviewers"`
-    RequiredChecks    []string `json:"required_checks"`
+    RequiredReviewers int        `json:"required_reviewers"`
+    RequiredChecks    []string   `json:"required_checks"`
+    Schedules         []Schedule `json:"schedules,omitempty"`      // Freeze windows and scheduled rule changes
+    OverrideBy        []string   `json:"override_by,omitempty"`    // Who may merge regardless of schedules
+    ReviewerGroup     string     `json:"reviewer_group,omitempty"` // Group reviewers are assigned from when an intent joins
+    MaxCoverageDrop   *float64   `json:"max_coverage_drop,omitempty"` // Percentage points coverage of changed files may fall; unset allows any
 }
 
+// Stream statuses
+const (
+    StatusStable      = "stable"
+    StatusIntegrating = "integrating"
+    StatusConflict    = "conflict" // A stream merge left conflicts to resolve
+)
+
 type State struct {
     Active    bool      `json:"active"`
     Status    string    `json:"status"`    // stable, integrating, conflict
     LastSync  time.Time `json:"last_sync"`
     Intents   []string  `json:"intents"`   // IDs of associated intents
+    Merged    []string  `json:"merged,omitempty"` // IDs of intents landed on the stream, in order
+    Head      string    `json:"head,omitempty"`   // Changeset ID of the last landed intent
+}
+
+// IsMerged reports whether an intent has landed on the stream
+func (s *State) IsMerged(intentID string) bool {
+    for _, id := range s.Merged {
+        if id == intentID {
+            return true
+        }
+    }
+    return false
 }
 
 // Box defines the interface for stream storage operations
@@ -59,4 +85,11 @@ type Box interface {
     // Search operations
     FindByType(streamType string) ([]*Stream, error)
     FindActive() ([]*Stream, error)
-}
\ No newline at end of file
+}
+
+// Pager is implemented by boxes that can stream streams or return them a
+// page at a time instead of loading all of them
+type Pager interface {
+    ListFunc(fn func(*Stream) error) error
+    ListPage(after string, limit int) ([]*Stream, string, error)
+}
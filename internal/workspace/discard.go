@@ -0,0 +1,155 @@
+// internal/workspace/discard.go
+package workspace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tig/shared/utils"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Discarded is a file whose working-tree edits a discard throws away. Hash
+// is the content it is restored to; Delete means its deletion is gated,
+// so discarding removes it.
+type Discarded struct {
+	Path   string `json:"path"`
+	Hash   string `json:"hash,omitempty"`
+	Delete bool   `json:"delete,omitempty"`
+	Mode   int    `json:"-"`
+}
+
+// PlanDiscard lists the files under paths, or every file when all is set,
+// whose working-tree content differs from their gated content, or from
+// their recorded content when they are not gated. Untracked files are
+// refused, since there is nothing to restore them to.
+func (w *LocalWorkspace) PlanDiscard(paths []string, all bool) ([]Discarded, error) {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+
+	known := make(map[string]Discarded)
+	err := w.DB.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte("file_state:")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			path := string(bytes.TrimPrefix(it.Item().Key(), opts.Prefix))
+			var state FileState
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &state)
+			}); err != nil {
+				return fmt.Errorf("decoding state of %s: %w", path, err)
+			}
+			known[path] = Discarded{Path: path, Hash: state.Hash}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading file states: %w", err)
+	}
+	for path, c := range w.GatedChanges {
+		known[path] = Discarded{Path: path, Hash: c.NewHash, Delete: c.Type == "delete", Mode: c.Mode}
+	}
+
+	var candidates []string
+	if all {
+		for path := range known {
+			candidates = append(candidates, path)
+		}
+	} else {
+		for _, arg := range paths {
+			arg = filepath.Clean(arg)
+			if _, ok := known[arg]; ok {
+				candidates = append(candidates, arg)
+				continue
+			}
+			matched := false
+			for path := range known {
+				if arg == "." || strings.HasPrefix(path, arg+string(filepath.Separator)) {
+					candidates = append(candidates, path)
+					matched = true
+				}
+			}
+			if matched {
+				continue
+			}
+			if _, err := os.Lstat(filepath.Join(w.Root, arg)); err == nil {
+				return nil, fmt.Errorf("%s is untracked; there is nothing to restore it to", arg)
+			}
+			return nil, fmt.Errorf("%s matches no tracked or gated file", arg)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var plan []Discarded
+	for _, path := range candidates {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		d := known[path]
+		content, err := os.ReadFile(filepath.Join(w.Root, path))
+		switch {
+		case os.IsNotExist(err):
+			if d.Delete {
+				continue
+			}
+		case err != nil:
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		case !d.Delete && utils.HashContent(content) == d.Hash:
+			continue
+		}
+		plan = append(plan, d)
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].Path < plan[j].Path })
+	return plan, nil
+}
+
+// Discard restores planned files from the Safe, or removes those whose
+// deletion is gated. All content is read before any file is written.
+func (w *LocalWorkspace) Discard(plan []Discarded) error {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	contents := make([][]byte, len(plan))
+	for n, d := range plan {
+		if d.Delete {
+			continue
+		}
+		content, err := w.ContentSafe.Get(d.Hash)
+		if err != nil {
+			return fmt.Errorf("reading content of %s: %w", d.Path, err)
+		}
+		contents[n] = content
+	}
+
+	for n, d := range plan {
+		absPath := filepath.Join(w.Root, d.Path)
+		if d.Delete {
+			if err := os.Remove(absPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("removing %s: %w", d.Path, err)
+			}
+			continue
+		}
+		perm := os.FileMode(0644)
+		if info, err := os.Stat(absPath); err == nil {
+			perm = info.Mode().Perm()
+		} else if d.Mode != 0 {
+			perm = os.FileMode(d.Mode).Perm()
+		}
+		if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+			return fmt.Errorf("creating directory for %s: %w", d.Path, err)
+		}
+		if err := os.WriteFile(absPath, contents[n], perm); err != nil {
+			return fmt.Errorf("restoring %s: %w", d.Path, err)
+		}
+	}
+	return nil
+}
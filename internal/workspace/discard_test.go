@@ -0,0 +1,57 @@
+// internal/workspace/discard_test.go
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscard(t *testing.T) {
+	e := newEnv(t)
+	hash, err := e.safe.Store([]byte("recorded\n"))
+	require.NoError(t, err)
+	w := e.open(t)
+	require.NoError(t, os.Mkdir(filepath.Join(e.root, "src"), 0755))
+	for _, path := range []string{"a.txt", filepath.Join("src", "b.txt"), filepath.Join("src", "same.txt")} {
+		require.NoError(t, w.storeFileState(path, &FileState{Hash: hash}))
+	}
+	e.write(t, "a.txt", "edited\n")
+	e.write(t, filepath.Join("src", "same.txt"), "recorded\n")
+	// b.txt was deleted from the working tree; g.txt is gated then edited
+	e.write(t, "g.txt", "gated\n")
+	require.NoError(t, w.Gate([]string{"g.txt"}))
+	e.write(t, "g.txt", "gated and edited\n")
+	e.write(t, "untracked.txt", "new\n")
+
+	_, err = w.PlanDiscard([]string{"untracked.txt"}, false)
+	assert.ErrorContains(t, err, "untracked")
+	_, err = w.PlanDiscard([]string{"missing"}, false)
+	assert.Error(t, err)
+
+	plan, err := w.PlanDiscard([]string{"src"}, false)
+	require.NoError(t, err)
+	require.Len(t, plan, 1, "unchanged files are left out")
+	assert.Equal(t, filepath.Join("src", "b.txt"), plan[0].Path)
+
+	plan, err = w.PlanDiscard(nil, true)
+	require.NoError(t, err)
+	paths := make([]string, len(plan))
+	for n, d := range plan {
+		paths[n] = d.Path
+	}
+	assert.Equal(t, []string{"a.txt", "g.txt", filepath.Join("src", "b.txt")}, paths)
+
+	require.NoError(t, w.Discard(plan))
+	for path, want := range map[string]string{"a.txt": "recorded\n", "g.txt": "gated\n", filepath.Join("src", "b.txt"): "recorded\n", "untracked.txt": "new\n"} {
+		content, err := os.ReadFile(filepath.Join(e.root, path))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content), path)
+	}
+	plan, err = w.PlanDiscard(nil, true)
+	require.NoError(t, err)
+	assert.Empty(t, plan)
+}
@@ -0,0 +1,161 @@
+// internal/workspace/hunks.go
+package workspace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tig/internal/diff"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// hunkContext is the number of unchanged lines shown around each hunk
+const hunkContext = 3
+
+// ChooseHunk is asked about each hunk of a file in order and reports
+// whether to act on it
+type ChooseHunk func(n, total int, h diff.Hunk) (bool, error)
+
+// UngateHunks removes chosen hunks from a file's gated content, leaving
+// the working tree alone. The gated content is compared with the file's
+// recorded state; if every hunk is removed the file is ungated. It
+// returns the number of hunks removed.
+func (w *LocalWorkspace) UngateHunks(path string, choose ChooseHunk) (int, error) {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	gated, ok := w.GatedChanges[path]
+	if !ok {
+		return 0, fmt.Errorf("%s is not gated", path)
+	}
+	if gated.Type == "delete" {
+		return 0, fmt.Errorf("%s is gated for deletion and has no hunks", path)
+	}
+	gatedContent, err := w.ContentSafe.Get(gated.NewHash)
+	if err != nil {
+		return 0, fmt.Errorf("reading gated content of %s: %w", path, err)
+	}
+	base, err := w.recordedContent(path)
+	if err != nil {
+		return 0, err
+	}
+
+	kept, removed, err := selectHunks(base, gatedContent, choose)
+	if err != nil || removed == 0 {
+		return 0, err
+	}
+	if bytes.Equal(kept, base) {
+		delete(w.GatedChanges, path)
+		if err := w.DB.Update(func(txn *badger.Txn) error {
+			return txn.Delete([]byte(gatedChangePrefix + path))
+		}); err != nil {
+			return 0, fmt.Errorf("deleting gated change for %s: %w", path, err)
+		}
+		return removed, nil
+	}
+
+	hash, err := w.ContentSafe.Store(kept)
+	if err != nil {
+		return 0, fmt.Errorf("storing %s: %w", path, err)
+	}
+	gated.NewHash = hash
+	gated.Size = int64(len(kept))
+	data, err := json.Marshal(gated)
+	if err != nil {
+		return 0, fmt.Errorf("marshaling change for %s: %w", path, err)
+	}
+	if err := w.DB.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(gatedChangePrefix+path), data)
+	}); err != nil {
+		return 0, fmt.Errorf("storing change for %s: %w", path, err)
+	}
+	w.GatedChanges[path] = gated
+	return removed, nil
+}
+
+// DiscardHunks reverts chosen hunks of a file in the working tree to its
+// gated content, or to its recorded state when it is not gated. It
+// returns the number of hunks reverted.
+func (w *LocalWorkspace) DiscardHunks(path string, choose ChooseHunk) (int, error) {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	absPath := filepath.Join(w.Root, path)
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w", path, err)
+	}
+	current, err := os.ReadFile(absPath)
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var base []byte
+	if gated, ok := w.GatedChanges[path]; ok && gated.Type != "delete" {
+		base, err = w.ContentSafe.Get(gated.NewHash)
+		if err != nil {
+			return 0, fmt.Errorf("reading gated content of %s: %w", path, err)
+		}
+	} else {
+		if _, err := w.getFileState(path); err == badger.ErrKeyNotFound {
+			return 0, fmt.Errorf("%s is untracked; there is nothing to revert it to", path)
+		}
+		if base, err = w.recordedContent(path); err != nil {
+			return 0, err
+		}
+	}
+
+	// Choosing a hunk reverts it, so keep the current side of the rest
+	kept, reverted, err := selectHunks(base, current, choose)
+	if err != nil || reverted == 0 {
+		return 0, err
+	}
+	if err := os.WriteFile(absPath, kept, info.Mode().Perm()); err != nil {
+		return 0, fmt.Errorf("writing %s: %w", path, err)
+	}
+	return reverted, nil
+}
+
+// recordedContent returns a file's content as last recorded, or nothing
+// for a file that was never recorded
+func (w *LocalWorkspace) recordedContent(path string) ([]byte, error) {
+	state, err := w.getFileState(path)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := w.ContentSafe.Get(state.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("reading recorded content of %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// selectHunks asks about each hunk between base and changed and returns
+// changed with the chosen hunks reverted to base, and how many were chosen
+func selectHunks(base, changed []byte, choose ChooseHunk) ([]byte, int, error) {
+	engine := diff.NewEngine(hunkContext)
+	hunks := engine.Hunks(base, changed)
+	chosen := make([]bool, len(hunks))
+	count := 0
+	for n, h := range hunks {
+		ok, err := choose(n, len(hunks), h)
+		if err != nil {
+			return nil, 0, err
+		}
+		chosen[n] = ok
+		if ok {
+			count++
+		}
+	}
+	if count == 0 {
+		return changed, 0, nil
+	}
+	return engine.Select(base, changed, func(n int) bool { return !chosen[n] }), count, nil
+}
@@ -0,0 +1,65 @@
+// internal/workspace/hunks_test.go
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tig/internal/diff"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHunks(t *testing.T) {
+	e := newEnv(t)
+	base := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	hash, err := e.safe.Store([]byte(base))
+	require.NoError(t, err)
+	w := e.open(t)
+	require.NoError(t, w.storeFileState("f.txt", &FileState{Hash: hash}))
+
+	// Two hunks, far enough apart to be shown separately
+	edited := "ONE\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"
+	e.write(t, "f.txt", edited)
+	require.NoError(t, w.Gate([]string{"f.txt"}))
+
+	only := func(want int) ChooseHunk {
+		return func(n, total int, h diff.Hunk) (bool, error) {
+			assert.Equal(t, 2, total)
+			return n == want, nil
+		}
+	}
+
+	// Ungating the second hunk keeps the first gated and the file as is
+	removed, err := w.UngateHunks("f.txt", only(1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	gated, err := w.ContentSafe.Get(w.GatedChanges["f.txt"].NewHash)
+	require.NoError(t, err)
+	assert.Equal(t, "ONE\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n", string(gated))
+	current, err := os.ReadFile(filepath.Join(e.root, "f.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, edited, string(current))
+
+	// Discarding reverts the working tree to the gated content
+	reverted, err := w.DiscardHunks("f.txt", func(n, total int, h diff.Hunk) (bool, error) {
+		assert.Equal(t, 1, total)
+		return true, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, reverted)
+	current, err = os.ReadFile(filepath.Join(e.root, "f.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, string(gated), string(current))
+
+	// Removing the last hunk ungates the file, also after a restart
+	_, err = w.UngateHunks("f.txt", func(n, total int, h diff.Hunk) (bool, error) { return true, nil })
+	require.NoError(t, err)
+	assert.NotContains(t, e.open(t).GatedChanges, "f.txt")
+
+	e.write(t, "new.txt", "draft\n")
+	_, err = w.DiscardHunks("new.txt", only(0))
+	assert.ErrorContains(t, err, "untracked")
+}
@@ -0,0 +1,199 @@
+// internal/workspace/journal.go
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tig/internal/safe"
+	"tig/shared/types"
+	"tig/shared/utils"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const gateJournalPrefix = "gate_journal:"
+
+// gateJournal records a gate in progress. It is written before any content
+// is stored and removed in the same transaction that saves the gated
+// changes, so an entry found at startup means the process died mid-gate.
+type gateJournal struct {
+	ID      string          `json:"id"`
+	Changes []shared.Change `json:"changes"`
+	// Ref counts before the gate stored its content, used on replay to
+	// tell which stores completed
+	PreRefs   map[string]uint32 `json:"pre_refs"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Gate stages that tests can interrupt to simulate a crash
+const (
+	gateJournaled = "journaled"
+	gateStored    = "stored"
+)
+
+// afterGateStage calls the workspace's gate hook, if set, after a gate
+// stage; an error from the hook aborts the gate without any cleanup, as a
+// crash would
+func (w *LocalWorkspace) afterGateStage(stage string) error {
+	if w.gateHook == nil {
+		return nil
+	}
+	return w.gateHook(stage)
+}
+
+// beginGate records the intent to gate changes
+func (w *LocalWorkspace) beginGate(changes []shared.Change) (*gateJournal, error) {
+	j := &gateJournal{
+		ID:        uuid.New().String(),
+		Changes:   changes,
+		PreRefs:   make(map[string]uint32),
+		CreatedAt: time.Now(),
+	}
+
+	for _, change := range changes {
+		if _, ok := j.PreRefs[change.NewHash]; ok {
+			continue
+		}
+		refs, err := w.refCount(change.NewHash)
+		if err != nil {
+			return nil, err
+		}
+		j.PreRefs[change.NewHash] = refs
+	}
+
+	data, err := json.Marshal(j)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling gate journal: %w", err)
+	}
+	err = w.DB.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(gateJournalPrefix+j.ID), data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("writing gate journal: %w", err)
+	}
+
+	return j, nil
+}
+
+// commitGate atomically saves the journaled changes as gated and removes
+// the journal entry
+func (w *LocalWorkspace) commitGate(j *gateJournal) error {
+	err := w.DB.Update(func(txn *badger.Txn) error {
+		for _, change := range j.Changes {
+			data, err := json.Marshal(change)
+			if err != nil {
+				return fmt.Errorf("marshaling change for %s: %w", change.Path, err)
+			}
+			if err := txn.Set([]byte("gated:"+change.Path), data); err != nil {
+				return fmt.Errorf("storing change for %s: %w", change.Path, err)
+			}
+		}
+		return txn.Delete([]byte(gateJournalPrefix + j.ID))
+	})
+	if err != nil {
+		return fmt.Errorf("committing gate: %w", err)
+	}
+
+	for _, change := range j.Changes {
+		w.GatedChanges[change.Path] = change
+	}
+	return nil
+}
+
+// replayGateJournal finishes gates that were interrupted. Content that was
+// stored before the crash is kept; content that wasn't is stored again if
+// the file still matches, otherwise that file is dropped from the gate.
+func (w *LocalWorkspace) replayGateJournal() error {
+	if w.ContentSafe == nil {
+		return nil
+	}
+
+	var journals []*gateJournal
+	err := w.DB.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(gateJournalPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var j gateJournal
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &j)
+			}); err != nil {
+				return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+			}
+			journals = append(journals, &j)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, j := range journals {
+		if err := w.replayGate(j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *LocalWorkspace) replayGate(j *gateJournal) error {
+	// Number of completed stores per hash
+	stored := make(map[string]uint32)
+	for hash, pre := range j.PreRefs {
+		refs, err := w.refCount(hash)
+		if err != nil {
+			return err
+		}
+		if refs > pre {
+			stored[hash] = refs - pre
+		}
+	}
+
+	kept := j.Changes[:0]
+	for _, change := range j.Changes {
+		if stored[change.NewHash] > 0 {
+			stored[change.NewHash]--
+			kept = append(kept, change)
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(w.Root, change.Path))
+		if err != nil || utils.HashContent(content) != change.NewHash {
+			w.Logger.Warn("Dropping interrupted gate of modified file",
+				zap.String("path", change.Path))
+			continue
+		}
+		if _, err := w.ContentSafe.Store(content); err != nil {
+			return fmt.Errorf("storing %s: %w", change.Path, err)
+		}
+		kept = append(kept, change)
+	}
+	j.Changes = kept
+
+	w.Logger.Info("Recovered interrupted gate",
+		zap.String("journal", j.ID),
+		zap.Int("files", len(kept)))
+
+	return w.commitGate(j)
+}
+
+// refCount returns the Safe reference count of a hash, or 0 if absent
+func (w *LocalWorkspace) refCount(hash string) (uint32, error) {
+	meta, err := w.ContentSafe.Meta(hash)
+	if errors.Is(err, safe.ErrContentNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading ref count: %w", err)
+	}
+	return meta.RefCount, nil
+}
@@ -0,0 +1,136 @@
+// internal/workspace/journal_test.go
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tig/internal/safe"
+	"tig/shared/utils"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errCrash = errors.New("simulated crash")
+
+// crashAt makes the workspace's gates abort after the given stage
+func crashAt(w *LocalWorkspace, stage string) *LocalWorkspace {
+	w.gateHook = func(s string) error {
+		if s == stage {
+			return errCrash
+		}
+		return nil
+	}
+	return w
+}
+
+type env struct {
+	root string
+	db   *badger.DB
+	safe *safe.Safe
+}
+
+func newEnv(t *testing.T) *env {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	s, err := safe.New(db, safe.Options{Root: t.TempDir(), CacheSize: 16})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	return &env{root: t.TempDir(), db: db, safe: s}
+}
+
+// open simulates a process start against the same repository
+func (e *env) open(t *testing.T) *LocalWorkspace {
+	w, err := NewLocalWorkspace(e.root, e.db, e.safe)
+	require.NoError(t, err)
+	return w
+}
+
+func (e *env) write(t *testing.T, name, content string) string {
+	require.NoError(t, os.WriteFile(filepath.Join(e.root, name), []byte(content), 0644))
+	return utils.HashContent([]byte(content))
+}
+
+func (e *env) refs(t *testing.T, hash string) uint32 {
+	meta, err := e.safe.Meta(hash)
+	if errors.Is(err, safe.ErrContentNotFound) {
+		return 0
+	}
+	require.NoError(t, err)
+	return meta.RefCount
+}
+
+func (e *env) journalEntries(t *testing.T) int {
+	n := 0
+	require.NoError(t, e.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(gateJournalPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			n++
+		}
+		return nil
+	}))
+	return n
+}
+
+func TestGateCommits(t *testing.T) {
+	e := newEnv(t)
+	hash := e.write(t, "a.txt", "a")
+
+	w := e.open(t)
+	require.NoError(t, w.Gate([]string{"a.txt"}))
+
+	assert.Equal(t, hash, w.GatedChanges["a.txt"].NewHash)
+	assert.Equal(t, uint32(1), e.refs(t, hash))
+	assert.Equal(t, 0, e.journalEntries(t))
+}
+
+func TestGateCrashAfterStore(t *testing.T) {
+	e := newEnv(t)
+	hashA := e.write(t, "a.txt", "a")
+	hashB := e.write(t, "b.txt", "same")
+	e.write(t, "c.txt", "same")
+
+	err := crashAt(e.open(t), gateStored).Gate([]string{"a.txt", "b.txt", "c.txt"})
+	require.ErrorIs(t, err, errCrash)
+	assert.Equal(t, 1, e.journalEntries(t))
+
+	// Content was stored before the crash, so replay must not store it again
+	w := e.open(t)
+	assert.Len(t, w.GatedChanges, 3)
+	assert.Equal(t, uint32(1), e.refs(t, hashA))
+	assert.Equal(t, uint32(2), e.refs(t, hashB))
+	assert.Equal(t, 0, e.journalEntries(t))
+}
+
+func TestGateCrashBeforeStore(t *testing.T) {
+	e := newEnv(t)
+	hashA := e.write(t, "a.txt", "a")
+	hashB := e.write(t, "b.txt", "b")
+
+	err := crashAt(e.open(t), gateJournaled).Gate([]string{"a.txt", "b.txt"})
+	require.ErrorIs(t, err, errCrash)
+	assert.Equal(t, uint32(0), e.refs(t, hashA))
+
+	// b.txt changes before restart; its journaled content no longer exists
+	// anywhere, so it is dropped rather than gated with a dangling hash
+	e.write(t, "b.txt", "b2")
+
+	w := e.open(t)
+	assert.Equal(t, hashA, w.GatedChanges["a.txt"].NewHash)
+	assert.NotContains(t, w.GatedChanges, "b.txt")
+	assert.Equal(t, uint32(1), e.refs(t, hashA))
+	assert.Equal(t, uint32(0), e.refs(t, hashB))
+	assert.Equal(t, 0, e.journalEntries(t))
+}
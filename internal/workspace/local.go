@@ -9,12 +9,15 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
+	"runtime"
+	"sort"
 	"sync"
 	"time"
 
 	"tig/internal/content"
 	"tig/internal/diff"
+	tigerrors "tig/internal/errors"
+	"tig/internal/ignore"
 	"tig/internal/intent"
 	"tig/internal/safe"
 	"tig/internal/stream"
@@ -33,7 +36,7 @@ type FileState struct {
 	Tracked map[string]bool
 }
 
-const gatedChangePrefix = "gated/"
+const gatedChangePrefix = "gated:"
 
 var logger, _ = zap.NewDevelopment()
 
@@ -45,6 +48,31 @@ func (w *LocalWorkspace) CleanupGatedChanges() error {
 	w.Mu.Lock()
 	defer w.Mu.Unlock() // Ensure mutex is unlocked even if an error occurs
 
+	toRemove := w.orphanedGatedChanges()
+
+	w.Logger.Info("Total gated changes found", zap.Int("total", len(w.GatedChanges)))
+	w.Logger.Info("Total orphaned changes to remove", zap.Int("toRemove", len(toRemove)))
+
+	// Remove paths from the map
+	for _, path := range toRemove {
+		delete(w.GatedChanges, path)
+		w.Logger.Info("Removed orphaned gated change from map", zap.String("path", path))
+	}
+
+	return nil
+}
+
+// PlanCleanup lists the gated changes CleanupGatedChanges would remove,
+// in path order
+func (w *LocalWorkspace) PlanCleanup() []string {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+	return w.orphanedGatedChanges()
+}
+
+// orphanedGatedChanges returns the gated paths whose file and stored
+// content are both missing, in path order. Callers hold w.Mu.
+func (w *LocalWorkspace) orphanedGatedChanges() []string {
 	toRemove := make([]string, 0)
 
 	for path, changeObj := range w.GatedChanges {
@@ -93,16 +121,8 @@ func (w *LocalWorkspace) CleanupGatedChanges() error {
 		}
 	}
 
-	w.Logger.Info("Total gated changes found", zap.Int("total", len(w.GatedChanges)))
-	w.Logger.Info("Total orphaned changes to remove", zap.Int("toRemove", len(toRemove)))
-
-	// Remove paths from the map
-	for _, path := range toRemove {
-		delete(w.GatedChanges, path)
-		w.Logger.Info("Removed orphaned gated change from map", zap.String("path", path))
-	}
-
-	return nil
+	sort.Strings(toRemove)
+	return toRemove
 }
 
 // FindRoot searches for the workspace Root by looking for the ".tig" directory.
@@ -124,7 +144,7 @@ func FindRoot(startDir string) (string, error) {
 		dir = parent
 	}
 
-	return "", errors.New("workspace Root not found")
+	return "", fmt.Errorf("%w: no .tig directory in %s or its parents", tigerrors.ErrNotRepository, startDir)
 }
 
 // LocalWorkspace implements Workspace interface
@@ -138,6 +158,9 @@ type LocalWorkspace struct {
 	Mu           sync.RWMutex
 	Logger       *zap.Logger
 	Tracked      map[string]bool
+	Ignore       *ignore.Matcher          // Decides which paths are never gated
+	gateStats    safe.StoreStats          // Totals of the last gate
+	gateHook     func(stage string) error // Called after each gate stage
 }
 
 // GetGatedChanges retrieves gated changes as a slice of content.Change.
@@ -371,7 +394,7 @@ func (w *LocalWorkspace) GetGatedChange(path string) (shared.Change, error) {
 
 	change, exists := w.GatedChanges[path]
 	if !exists {
-		return shared.Change{}, fmt.Errorf("gated change not found for path: %s", path)
+		return shared.Change{}, tigerrors.NotFound(fmt.Sprintf("gated change not found for path: %s", path))
 	}
 	return change, nil
 }
@@ -395,6 +418,12 @@ func NewLocalWorkspace(Root string, DB *badger.DB, ContentSafe *safe.Safe) (*Loc
 		ContentSafe:  ContentSafe,
 		GatedChanges: make(map[string]shared.Change),
 		Logger:       logger,
+		Ignore:       ignore.New(Root, nil),
+	}
+
+	// Finish gates interrupted by a crash before loading gated changes
+	if err := ws.replayGateJournal(); err != nil {
+		return &LocalWorkspace{}, fmt.Errorf("replaying gate journal: %w", err)
 	}
 
 	// Load any existing gated changes
@@ -470,8 +499,7 @@ func (w *LocalWorkspace) gateAll() error {
 			continue
 		}
 
-		absPath := filepath.Join(w.Root, status.Path)
-		if err := w.gateFile(absPath); err != nil {
+		if err := w.gateFile(status.Path); err != nil {
 			w.Logger.Warn("Failed to gate file",
 				zap.String("path", status.Path),
 				zap.Error(err))
@@ -604,7 +632,20 @@ func (w *LocalWorkspace) Gate(paths []string) error {
         return fmt.Errorf("no paths specified")
     }
 
+    w.gateStats = safe.StoreStats{}
+    if err := w.gateFiles(w.gatePaths(paths)); err != nil {
+        return err
+    }
+
+    return w.saveGatedChanges()
+}
+
+// gatePaths resolves the paths given to Gate to the files to read,
+// walking directories and skipping ignored files. Callers hold w.Mu.
+func (w *LocalWorkspace) gatePaths(paths []string) []string {
     processed := make(map[string]bool)
+    // Files are stored in bulk once all paths are resolved
+    var files []string
 
     for _, path := range paths {
         // Clean and normalize path
@@ -652,13 +693,7 @@ func (w *LocalWorkspace) Gate(paths []string) error {
                     return nil
                 }
 
-                if err := w.gateFile(fileRelPath); err != nil {
-                    w.Logger.Warn("Failed to gate file",
-                        zap.String("path", fileRelPath),
-                        zap.Error(err))
-                    return nil
-                }
-
+                files = append(files, fileRelPath)
                 processed[fileRelPath] = true
                 return nil
             })
@@ -671,93 +706,198 @@ func (w *LocalWorkspace) Gate(paths []string) error {
         }
 
         // Handle single file
-        if err := w.gateFile(relPath); err != nil {
-            w.Logger.Warn("Failed to gate file",
-                zap.String("path", relPath),
-                zap.Error(err))
-            continue
-        }
-
+        files = append(files, relPath)
         processed[relPath] = true
     }
 
-    return w.saveGatedChanges()
+    return files
 }
 
-// gateFile handles gating a single file
-func (w *LocalWorkspace) gateFile(relPath string) error {
-    absPath := filepath.Join(w.Root, relPath)
-    
-    content, err := os.ReadFile(absPath)
-    if err != nil {
-        return fmt.Errorf("reading file: %w", err)
-    }
+// gateBatchSize and gateBatchBytes bound how many files, and how many
+// bytes of them, are held in memory per bulk store
+const (
+    gateBatchSize  = 256
+    gateBatchBytes = 64 << 20
+)
 
-    currentHash := utils.HashContent(content)
+// gateRead is a file read and hashed for gating
+type gateRead struct {
+    relPath string
+    info    os.FileInfo
+    content []byte
+    hash    string
+    err     error
+}
 
-    // Store content in ContentSafe
-    if _, err := w.ContentSafe.Store(content); err != nil {
-        return fmt.Errorf("storing content: %w", err)
-    }
+// gateFiles gates many files at once. Files are read and hashed by a
+// pool of workers while the previous chunk is compressed and written
+// through the Safe's parallel batch path; reading stalls when storing
+// falls behind, so at most two chunks are in memory. Each chunk is
+// journaled so a crash between storing content and saving the gated
+// changes is recovered on the next startup. Files that fail are logged
+// and skipped.
+func (w *LocalWorkspace) gateFiles(relPaths []string) error {
+    done := make(chan struct{})
+    defer close(done)
+    chunks := w.readChunks(relPaths, done)
+
+    for chunk := range chunks {
+        var (
+            changes  []shared.Change
+            contents [][]byte
+        )
+        for _, r := range chunk {
+            if r.err != nil {
+                w.Logger.Warn("Failed to gate file",
+                    zap.String("path", r.relPath),
+                    zap.Error(r.err))
+                continue
+            }
+            changes = append(changes, w.gateChange(r))
+            contents = append(contents, r.content)
+        }
+        if len(changes) == 0 {
+            continue
+        }
 
-    info, err := os.Stat(absPath)
-    if err != nil {
-        return fmt.Errorf("getting file info: %w", err)
+        // Phase one: record what is about to be gated
+        j, err := w.beginGate(changes)
+        if err != nil {
+            return err
+        }
+        if err := w.afterGateStage(gateJournaled); err != nil {
+            return err
+        }
+
+        // Phase two: compress and store the content
+        _, err = w.ContentSafe.StoreBatchWithOptions(contents, safe.BatchOptions{Stats: &w.gateStats})
+        var batchErr *safe.BatchError
+        if err != nil && !errors.As(err, &batchErr) {
+            return fmt.Errorf("storing files: %w", err)
+        }
+        if err := w.afterGateStage(gateStored); err != nil {
+            return err
+        }
+
+        if batchErr != nil {
+            stored := j.Changes[:0]
+            for i, change := range j.Changes {
+                if batchErr.Errors[i] != nil {
+                    w.Logger.Warn("Failed to gate file",
+                        zap.String("path", change.Path),
+                        zap.Error(batchErr.Errors[i]))
+                    continue
+                }
+                stored = append(stored, change)
+            }
+            j.Changes = stored
+        }
+
+        // Phase three: save the gated changes and retire the journal entry
+        if err := w.commitGate(j); err != nil {
+            return err
+        }
     }
 
-    // Determine change type
+    return nil
+}
+
+// gateChange is the gated change recorded for a file read by readForGate
+func (w *LocalWorkspace) gateChange(r gateRead) shared.Change {
     changeType := "modify"
-    if _, exists := w.GatedChanges[relPath]; !exists {
+    if _, exists := w.GatedChanges[r.relPath]; !exists {
         changeType = "add"
     }
-
-    w.GatedChanges[relPath] = shared.Change{
-        Path:    relPath,
+    return shared.Change{
+        Path:    r.relPath,
         Type:    changeType,
-        NewHash: currentHash,
-        Mode:    int(info.Mode()),
-        Size:    info.Size(),
-        ModTime: info.ModTime(),
+        NewHash: r.hash,
+        Mode:    int(r.info.Mode()),
+        Size:    r.info.Size(),
+        ModTime: r.info.ModTime(),
         Gated:   true,
     }
-
-    return nil
 }
 
-// shouldIgnore checks if a path should be ignored
-func (w *LocalWorkspace) shouldIgnore(path string) bool {
-    if path == "" {
-        return true
-    }
-
-    // Get absolute path for checking
-    absPath := filepath.Join(w.Root, path)
-    
-    // Don't ignore the root directory itself
-    if absPath == w.Root {
-        return false
-    }
+// readChunks reads and hashes files across all cores, delivering them in
+// chunks bounded by gateBatchSize and gateBatchBytes. The channel holds
+// one chunk, so reading waits for the consumer. Closing done stops it.
+func (w *LocalWorkspace) readChunks(relPaths []string, done <-chan struct{}) <-chan []gateRead {
+    chunks := make(chan []gateRead, 1)
+    go func() {
+        defer close(chunks)
+        for start := 0; start < len(relPaths); {
+            // Cut the chunk by count and by size, always taking at least
+            // one file
+            end, size := start, int64(0)
+            for end < len(relPaths) && end-start < gateBatchSize {
+                if info, err := os.Stat(filepath.Join(w.Root, relPaths[end])); err == nil {
+                    if end > start && size+info.Size() > gateBatchBytes {
+                        break
+                    }
+                    size += info.Size()
+                }
+                end++
+            }
 
-    // Check each path component
-    for _, part := range strings.Split(path, string(filepath.Separator)) {
-        // Skip empty parts
-        if part == "" {
-            continue
-        }
+            chunk := make([]gateRead, end-start)
+            jobs := make(chan int)
+            var wg sync.WaitGroup
+            for n := 0; n < min(runtime.NumCPU(), len(chunk)); n++ {
+                wg.Add(1)
+                go func() {
+                    defer wg.Done()
+                    for i := range jobs {
+                        chunk[i] = w.readForGate(relPaths[start+i])
+                    }
+                }()
+            }
+            for i := range chunk {
+                jobs <- i
+            }
+            close(jobs)
+            wg.Wait()
 
-        // Ignore hidden files and directories
-        if strings.HasPrefix(part, ".") {
-            return true
+            select {
+            case chunks <- chunk:
+            case <-done:
+                return
+            }
+            start = end
         }
+    }()
+    return chunks
+}
 
-        // Ignore common directories
-        switch part {
-        case "node_modules", "vendor", "dist", "build", ".git", ".tig":
-            return true
-        }
+// readForGate reads and hashes one file
+func (w *LocalWorkspace) readForGate(relPath string) gateRead {
+    r := gateRead{relPath: relPath}
+    absPath := filepath.Join(w.Root, relPath)
+    if r.info, r.err = os.Stat(absPath); r.err != nil {
+        return r
+    }
+    if r.content, r.err = os.ReadFile(absPath); r.err != nil {
+        return r
     }
+    r.hash = utils.HashContent(r.content)
+    return r
+}
+
+// LastGateStats reports what the most recent gate read and stored
+func (w *LocalWorkspace) LastGateStats() safe.StoreStats {
+    w.Mu.RLock()
+    defer w.Mu.RUnlock()
+    return w.gateStats
+}
 
-    return false
+// gateFile handles gating a single file
+func (w *LocalWorkspace) gateFile(relPath string) error {
+    return w.gateFiles([]string{relPath})
+}
+
+// shouldIgnore checks if a path should be ignored
+func (w *LocalWorkspace) shouldIgnore(path string) bool {
+    return w.Ignore.Ignored(path)
 }
 
 // saveGatedChanges persists gated changes to storage
@@ -0,0 +1,60 @@
+// internal/workspace/local_test.go
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tigerrors "tig/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateChunks(t *testing.T) {
+	e := newEnv(t)
+	require.NoError(t, os.Mkdir(filepath.Join(e.root, "src"), 0755))
+
+	// More files than fit one chunk, some identical
+	var logical int64
+	n := gateBatchSize*2 + 10
+	for i := 0; i < n; i++ {
+		content := fmt.Sprintf("file %d\n", i%(n-5))
+		e.write(t, filepath.Join("src", fmt.Sprintf("f%03d.txt", i)), content)
+		logical += int64(len(content))
+	}
+	// Unreadable files are skipped without failing the gate
+	require.NoError(t, os.Symlink("missing", filepath.Join(e.root, "src", "broken")))
+
+	w := e.open(t)
+	require.NoError(t, w.Gate([]string{"src"}))
+	assert.Len(t, w.GatedChanges, n)
+	assert.Equal(t, 0, e.journalEntries(t))
+
+	stats := w.LastGateStats()
+	assert.Equal(t, n, stats.Items)
+	assert.Equal(t, n-5, stats.New)
+	assert.Equal(t, logical, stats.LogicalBytes)
+	assert.Less(t, stats.StoredBytes, logical)
+
+	// Gating again stores nothing new
+	require.NoError(t, w.Gate([]string{"src/f000.txt"}))
+	assert.Equal(t, 0, w.LastGateStats().New)
+	assert.Equal(t, "modify", w.GatedChanges[filepath.Join("src", "f000.txt")].Type)
+}
+
+func TestFindRoot(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".tig"), 0755))
+	sub := filepath.Join(root, "src", "pkg")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	found, err := FindRoot(sub)
+	require.NoError(t, err)
+	assert.Equal(t, root, found)
+
+	_, err = FindRoot(t.TempDir())
+	assert.ErrorIs(t, err, tigerrors.ErrNotRepository)
+}
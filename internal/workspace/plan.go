@@ -0,0 +1,76 @@
+// internal/workspace/plan.go
+package workspace
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"tig/shared/types"
+)
+
+// GatePlan is what gating files would change. Changes are the gated
+// changes written, one per file in path order; Store lists the hashes of
+// their content not yet in the Safe.
+type GatePlan struct {
+	Changes []shared.Change `json:"changes"`
+	Store   []string        `json:"store,omitempty"`
+}
+
+// PlanGate reads and hashes the files Gate would gate for paths without
+// storing anything. Files that cannot be read are left out, as Gate skips
+// them.
+func (w *LocalWorkspace) PlanGate(paths []string) (*GatePlan, error) {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths specified")
+	}
+
+	files := w.gatePaths(paths)
+	sort.Strings(files)
+
+	plan := &GatePlan{}
+	seen := make(map[string]bool)
+	for _, path := range files {
+		r := w.readForGate(path)
+		if r.err != nil {
+			continue
+		}
+		plan.Changes = append(plan.Changes, w.gateChange(r))
+
+		if seen[r.hash] {
+			continue
+		}
+		seen[r.hash] = true
+		exists, err := w.ContentSafe.Exists(r.hash)
+		if err != nil {
+			return nil, fmt.Errorf("checking content of %s: %w", path, err)
+		}
+		if !exists {
+			plan.Store = append(plan.Store, r.hash)
+		}
+	}
+	return plan, nil
+}
+
+// PlanUngate lists the gated paths Ungate would remove, in path order
+func (w *LocalWorkspace) PlanUngate(paths []string) []string {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+
+	var gated []string
+	for _, path := range paths {
+		if _, ok := w.GatedChanges[path]; ok && !slices.Contains(gated, path) {
+			gated = append(gated, path)
+		}
+	}
+	sort.Strings(gated)
+	return gated
+}
+
+// GatedKey is the database key of the gated change for path
+func GatedKey(path string) string {
+	return gatedChangePrefix + path
+}
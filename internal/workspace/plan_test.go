@@ -0,0 +1,44 @@
+// internal/workspace/plan_test.go
+package workspace
+
+import (
+	"testing"
+
+	"tig/shared/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanGate(t *testing.T) {
+	e := newEnv(t)
+	stored, err := e.safe.Store([]byte("stored\n"))
+	require.NoError(t, err)
+	w := e.open(t)
+	e.write(t, "b.txt", "stored\n")
+	e.write(t, "a.txt", "new\n")
+	e.write(t, "g.txt", "gated\n")
+	require.NoError(t, w.Gate([]string{"g.txt"}))
+	e.write(t, "g.txt", "gated and edited\n")
+
+	plan, err := w.PlanGate([]string{"b.txt", "a.txt", "g.txt", "missing.txt"})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 3)
+	assert.Equal(t, "a.txt", plan.Changes[0].Path)
+	assert.Equal(t, "add", plan.Changes[0].Type)
+	assert.Equal(t, stored, plan.Changes[1].NewHash)
+	assert.Equal(t, "modify", plan.Changes[2].Type)
+	assert.ElementsMatch(t, []string{
+		utils.HashContent([]byte("new\n")),
+		utils.HashContent([]byte("gated and edited\n")),
+	}, plan.Store)
+
+	// Nothing was gated or stored
+	_, gated := w.GatedChanges["a.txt"]
+	assert.False(t, gated)
+	exists, err := e.safe.Exists(plan.Store[0])
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.Equal(t, []string{"g.txt"}, w.PlanUngate([]string{"g.txt", "a.txt", "g.txt"}))
+}
@@ -0,0 +1,138 @@
+// internal/workspace/restore.go
+package workspace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tig/internal/change"
+	tigerrors "tig/internal/errors"
+	"tig/shared/utils"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Restore writes files back to the working tree as they were in a
+// changeset, or as last recorded when changeSetID is empty, taking their
+// content from the Safe by the hashes recorded there. Paths may name
+// directories. Files with uncommitted edits are refused unless force is
+// set, since they would be overwritten. It returns the files written;
+// those already matching are left alone. Nothing is gated.
+func (w *LocalWorkspace) Restore(changeSetID string, paths []string, force bool) ([]string, error) {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	var tree, recorded map[string]change.FileState
+	err := w.DB.View(func(txn *badger.Txn) error {
+		var err error
+		if recorded, err = recordedStates(txn); err != nil {
+			return err
+		}
+		tree = recorded
+		if changeSetID != "" {
+			tree, err = change.TreeAt(txn, changeSetID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading tree to restore: %w", err)
+	}
+
+	selected := make(map[string]change.FileState)
+	for _, arg := range paths {
+		want := path.Clean(filepath.ToSlash(arg))
+		found := false
+		for file, state := range tree {
+			if want == "." || file == want || strings.HasPrefix(file, want+"/") {
+				selected[file] = state
+				found = true
+			}
+		}
+		if !found {
+			if changeSetID == "" {
+				return nil, tigerrors.NotFound(fmt.Sprintf("%s has no recorded content", arg))
+			}
+			return nil, tigerrors.NotFound(fmt.Sprintf("%s is not in changeset %s", arg, changeSetID))
+		}
+	}
+
+	// Files differing from their recorded content hold uncommitted edits
+	var dirty, pending []string
+	for file, state := range selected {
+		current, err := os.ReadFile(filepath.Join(w.Root, filepath.FromSlash(file)))
+		if err != nil {
+			pending = append(pending, file)
+			continue
+		}
+		hash := utils.HashContent(current)
+		if hash == state.Hash {
+			continue
+		}
+		if hash != recorded[file].Hash {
+			dirty = append(dirty, file)
+		}
+		pending = append(pending, file)
+	}
+	if len(dirty) > 0 && !force {
+		sort.Strings(dirty)
+		return nil, fmt.Errorf("%w: %s would be overwritten; commit them or use --force", tigerrors.ErrDirtyTree, strings.Join(dirty, ", "))
+	}
+
+	// All content is read before any file is written
+	contents := make(map[string][]byte, len(pending))
+	for _, file := range pending {
+		content, err := w.ContentSafe.Get(selected[file].Hash)
+		if err != nil {
+			return nil, fmt.Errorf("loading content of %s: %w", file, err)
+		}
+		contents[file] = content
+	}
+
+	var restored []string
+	for _, file := range pending {
+		dst := filepath.Join(w.Root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return restored, err
+		}
+		mode := os.FileMode(selected[file].Mode).Perm()
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.WriteFile(dst, contents[file], mode); err != nil {
+			return restored, fmt.Errorf("writing %s: %w", file, err)
+		}
+		if err := os.Chmod(dst, mode); err != nil {
+			return restored, err
+		}
+		restored = append(restored, filepath.FromSlash(file))
+	}
+	sort.Strings(restored)
+	return restored, nil
+}
+
+// recordedStates returns the last recorded state of every tracked file,
+// keyed by slash-separated path
+func recordedStates(txn *badger.Txn) (map[string]change.FileState, error) {
+	states := make(map[string]change.FileState)
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte("file_state:")
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		file := string(bytes.TrimPrefix(it.Item().Key(), opts.Prefix))
+		var state change.FileState
+		if err := it.Item().Value(func(val []byte) error {
+			return json.Unmarshal(val, &state)
+		}); err != nil {
+			return nil, fmt.Errorf("decoding state of %s: %w", file, err)
+		}
+		states[filepath.ToSlash(file)] = state
+	}
+	return states, nil
+}
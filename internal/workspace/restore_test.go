@@ -0,0 +1,44 @@
+// internal/workspace/restore_test.go
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tigerrors "tig/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreRecorded(t *testing.T) {
+	e := newEnv(t)
+	hash, err := e.safe.Store([]byte("recorded\n"))
+	require.NoError(t, err)
+	w := e.open(t)
+	require.NoError(t, os.Mkdir(filepath.Join(e.root, "src"), 0755))
+	for _, path := range []string{"a.txt", filepath.Join("src", "b.txt"), filepath.Join("src", "same.txt")} {
+		require.NoError(t, w.storeFileState(path, &FileState{Hash: hash}))
+	}
+	e.write(t, "a.txt", "edited\n")
+	e.write(t, filepath.Join("src", "same.txt"), "recorded\n")
+
+	_, err = w.Restore("", []string{"missing.txt"}, false)
+	var tigErr *tigerrors.Error
+	assert.ErrorAs(t, err, &tigErr)
+
+	// Edits are not overwritten without force; deleted files come back
+	_, err = w.Restore("", []string{"."}, false)
+	assert.ErrorIs(t, err, tigerrors.ErrDirtyTree)
+	restored, err := w.Restore("", []string{"src"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("src", "b.txt")}, restored)
+
+	restored, err = w.Restore("", []string{"a.txt"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt"}, restored)
+	content, err := os.ReadFile(filepath.Join(e.root, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "recorded\n", string(content))
+}
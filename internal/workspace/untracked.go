@@ -0,0 +1,52 @@
+// internal/workspace/untracked.go
+package workspace
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// UntrackedEntry is an untracked file, or a directory holding nothing but
+// untracked files
+type UntrackedEntry struct {
+	Path  string `json:"path"`
+	Dir   bool   `json:"dir,omitempty"`
+	Files int    `json:"files"`
+}
+
+// CollapseUntracked groups untracked files under the outermost directory
+// that holds no other path, so a new tree is listed once rather than file
+// by file. Other holds every path that is tracked, gated or deleted.
+func CollapseUntracked(untracked, other []string) []UntrackedEntry {
+	busy := make(map[string]bool)
+	for _, path := range other {
+		for dir := filepath.Dir(path); dir != "." && !busy[dir]; dir = filepath.Dir(dir) {
+			busy[dir] = true
+		}
+	}
+
+	byPath := make(map[string]*UntrackedEntry)
+	var entries []*UntrackedEntry
+	for _, path := range untracked {
+		key, dir := path, false
+		for parent := filepath.Dir(path); parent != "."; parent = filepath.Dir(parent) {
+			if !busy[parent] {
+				key, dir = parent, true
+			}
+		}
+		e, ok := byPath[key]
+		if !ok {
+			e = &UntrackedEntry{Path: key, Dir: dir}
+			byPath[key] = e
+			entries = append(entries, e)
+		}
+		e.Files++
+	}
+
+	result := make([]UntrackedEntry, len(entries))
+	for n, e := range entries {
+		result[n] = *e
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
@@ -0,0 +1,31 @@
+// internal/workspace/untracked_test.go
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollapseUntracked(t *testing.T) {
+	p := filepath.FromSlash
+	untracked := []string{
+		p("new_dir/a.go"), p("new_dir/sub/b.go"), p("new_dir/sub/c.go"),
+		p("src/new/x.go"), p("src/y.go"), "top.txt",
+	}
+	other := []string{p("src/main.go"), "README"}
+
+	assert.Equal(t, []UntrackedEntry{
+		{Path: "new_dir", Dir: true, Files: 3},
+		{Path: p("src/new"), Dir: true, Files: 1},
+		{Path: p("src/y.go"), Files: 1},
+		{Path: "top.txt", Files: 1},
+	}, CollapseUntracked(untracked, other))
+
+	// A gated file keeps its directory from collapsing
+	assert.Equal(t, []UntrackedEntry{
+		{Path: p("new_dir/a.go"), Files: 1},
+		{Path: p("new_dir/sub"), Dir: true, Files: 2},
+	}, CollapseUntracked(untracked[:3], []string{p("new_dir/gated.go")}))
+}
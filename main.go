@@ -1,24 +1,24 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
-	"tig/internal/api"
 	"tig/internal/config"
-	content "tig/internal/content"
-	"tig/internal/intent/storage"
 	"tig/internal/logging"
-	"tig/internal/middleware"
-	streamStorage "tig/internal/stream/storage"
-	ws "tig/internal/workspace"
+	"tig/internal/safe"
+	"tig/internal/server"
+	"tig/internal/storage"
 
 	"github.com/dgraph-io/badger/v4"
 	"go.uber.org/zap"
 )
 
+// Standalone server entrypoint. The same server is available as `tig serve`.
 func main() {
 	// Load configuration
 	cfg, err := config.Load("config.json")
@@ -34,67 +34,35 @@ func main() {
 	defer logger.Sync()
 
 	// Initialize BadgerDB
-	db, err := badger.Open(badger.DefaultOptions(cfg.Database.Path))
+	if err := cfg.Cache.Validate(); err != nil {
+		logger.Fatal("invalid cache config", zap.Error(err))
+	}
+	db, err := badger.Open(storage.WithCache(badger.DefaultOptions(cfg.Database.Path), cfg.Cache))
 	if err != nil {
 		logger.Fatal("failed to open database", zap.Error(err))
 	}
 	defer db.Close()
 
-	// Initialize content store
-	contentStore, err := content.NewFileStore(filepath.Join(cfg.Database.Path, "objects"))
+	// Initialize content safe
+	contentSafe, err := safe.New(db, safe.Options{
+		Root:      filepath.Join(cfg.Database.Path, "objects"),
+		CacheSize: cfg.Cache.ContentCacheItems(),
+	})
 	if err != nil {
-		logger.Fatal("failed to initialize content store", zap.Error(err))
+		logger.Fatal("failed to initialize content safe", zap.Error(err))
 	}
+	defer contentSafe.Close()
 
-	// Initialize workspace
-	ws, err := ws.NewLocalWorkspace(cfg.Database.Path, db, contentStore.Safe)
+	srv, err := server.New(cfg, db, contentSafe, cfg.Database.Path, logger)
 	if err != nil {
-		logger.Fatal("failed to initialize workspace", zap.Error(err))
+		logger.Fatal("failed to initialize server", zap.Error(err))
 	}
+	defer srv.Close()
 
-	// Initialize repositories
-	intentStore := storage.NewStore(db, ws)
-	streamStore := streamStorage.NewStore(db, intentStore)
-
-	// Initialize handlers
-	intentHandler := api.NewIntentHandler(intentStore)
-	streamHandler := api.NewStreamHandler(streamStore)
-	// Set up router
-	mux := http.NewServeMux()
-
-	// Health checks
-	mux.HandleFunc("/health", healthCheck)
-
-	// Intent endpoints
-	mux.HandleFunc("/api/intents", intentHandler.Create)
-	mux.HandleFunc("/api/intents/{id}", intentHandler.Get)
-	mux.HandleFunc("/api/intents/{id}", intentHandler.Update)
-
-	// Stream endpoints
-	mux.HandleFunc("/api/streams", streamHandler.Create)
-	mux.HandleFunc("/api/streams/{id}", streamHandler.Delete)
-	mux.HandleFunc("/api/streams/{id}/intents", streamHandler.AddIntent)
-	mux.HandleFunc("/api/streams/{id}/feature-flags", streamHandler.SetFeatureFlag)
-	mux.HandleFunc("/api/streams/{id}/feature-flags", streamHandler.GetFeatureFlags)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Apply middleware
-	handler := middleware.Chain(
-		mux,
-		middleware.RequestID,
-		middleware.Logger(logger),
-		middleware.Recover(logger),
-	)
-
-	// Start server
-	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	logger.Info("starting server", zap.String("address", addr))
-
-	if err := http.ListenAndServe(addr, handler); err != nil {
+	if err := srv.ListenAndServe(ctx); err != nil {
 		logger.Fatal("server failed", zap.Error(err))
 	}
 }
-
-func healthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"status":"healthy"}`))
-}
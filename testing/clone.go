@@ -0,0 +1,18 @@
+// testing/clone.go
+package tigtest
+
+import "encoding/json"
+
+// clone deep-copies v through JSON, so callers can't modify stored values
+// through returned pointers, matching the Badger-backed stores
+func clone[T any](v *T) *T {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic("tigtest: cloning value: " + err.Error())
+	}
+	var out T
+	if err := json.Unmarshal(data, &out); err != nil {
+		panic("tigtest: cloning value: " + err.Error())
+	}
+	return &out
+}
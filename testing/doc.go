@@ -0,0 +1,15 @@
+// Package tigtest provides in-memory implementations of tig's storage and
+// workspace interfaces, plus repository fixtures built from them. Tests
+// using it need neither Badger nor a working directory on disk.
+//
+// Import it as tig/testing:
+//
+//	import tigtest "tig/testing"
+//
+//	func TestReview(t *testing.T) {
+//		repo := tigtest.NewRepo(t)
+//		repo.WriteFile("main.go", "package main\n")
+//		i := repo.Commit("Add entry point")
+//		...
+//	}
+package tigtest
@@ -0,0 +1,148 @@
+// testing/intents.go
+package tigtest
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"tig/internal/intent"
+	"tig/internal/storage"
+)
+
+var _ intent.Box = (*IntentBox)(nil)
+
+// IntentBox is an in-memory intent.Box. Like the Badger store it
+// validates intents, sets timestamps, lists in ID order and hands out
+// copies.
+type IntentBox struct {
+	mu      sync.Mutex
+	intents map[string]*intent.Intent
+}
+
+// NewIntentBox creates an empty IntentBox
+func NewIntentBox() *IntentBox {
+	return &IntentBox{intents: make(map[string]*intent.Intent)}
+}
+
+func validateIntent(i *intent.Intent) error {
+	if i.ID == "" {
+		return fmt.Errorf("entity ID cannot be empty")
+	}
+	if i.Description == "" {
+		return fmt.Errorf("invalid intent: description is required")
+	}
+	if i.Type == "" {
+		return fmt.Errorf("invalid intent: type is required")
+	}
+	return nil
+}
+
+func (b *IntentBox) Create(i *intent.Intent) error {
+	if err := validateIntent(i); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.intents[i.ID]; ok {
+		return fmt.Errorf("entity already exists: %s", i.ID)
+	}
+	if i.CreatedAt.IsZero() {
+		i.CreatedAt = time.Now()
+	}
+	if i.UpdatedAt.IsZero() {
+		i.UpdatedAt = i.CreatedAt
+	}
+	b.intents[i.ID] = clone(i)
+	return nil
+}
+
+func (b *IntentBox) Get(id string) (*intent.Intent, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	i, ok := b.intents[id]
+	if !ok {
+		return nil, fmt.Errorf("getting intent: %w: %s", storage.ErrNotFound, id)
+	}
+	return clone(i), nil
+}
+
+func (b *IntentBox) Update(i *intent.Intent) error {
+	if err := validateIntent(i); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.intents[i.ID]; !ok {
+		return fmt.Errorf("%w: %s", storage.ErrNotFound, i.ID)
+	}
+	i.UpdatedAt = time.Now()
+	b.intents[i.ID] = clone(i)
+	return nil
+}
+
+func (b *IntentBox) Delete(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.intents[id]; !ok {
+		return fmt.Errorf("%w: %s", storage.ErrNotFound, id)
+	}
+	delete(b.intents, id)
+	return nil
+}
+
+func (b *IntentBox) List() ([]*intent.Intent, error) {
+	return b.filter(func(*intent.Intent) bool { return true }), nil
+}
+
+func (b *IntentBox) FindByType(intentType string) ([]*intent.Intent, error) {
+	if intentType == "" {
+		return nil, fmt.Errorf("intent type is required")
+	}
+	return b.filter(func(i *intent.Intent) bool { return i.Type == intentType }), nil
+}
+
+func (b *IntentBox) FindByAuthor(author string) ([]*intent.Intent, error) {
+	if author == "" {
+		return nil, fmt.Errorf("author is required")
+	}
+	return b.filter(func(i *intent.Intent) bool { return i.Metadata.Author == author }), nil
+}
+
+func (b *IntentBox) FindByTimeRange(start, end time.Time) ([]*intent.Intent, error) {
+	if start.IsZero() || end.IsZero() {
+		return nil, fmt.Errorf("start and end times are required")
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end time cannot be before start time")
+	}
+	return b.filter(func(i *intent.Intent) bool {
+		return !i.CreatedAt.Before(start) && !i.CreatedAt.After(end)
+	}), nil
+}
+
+func (b *IntentBox) FindWithBreakingChanges() ([]*intent.Intent, error) {
+	return b.filter(func(i *intent.Intent) bool { return i.Impact.Breaking }), nil
+}
+
+// filter returns copies of the matching intents in ID order
+func (b *IntentBox) filter(match func(*intent.Intent) bool) []*intent.Intent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var result []*intent.Intent
+	for _, i := range b.intents {
+		if match(i) {
+			result = append(result, clone(i))
+		}
+	}
+	sort.Slice(result, func(a, c int) bool { return result[a].ID < result[c].ID })
+	return result
+}
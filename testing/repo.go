@@ -0,0 +1,106 @@
+// testing/repo.go
+package tigtest
+
+import (
+	"testing"
+
+	"tig/internal/intent"
+	"tig/internal/stream"
+
+	"github.com/google/uuid"
+)
+
+// Repo wires the in-memory implementations together like a parcel
+type Repo struct {
+	t         testing.TB
+	Safe      *Safe
+	Tracker   *Tracker
+	Workspace *Workspace
+	Intents   *IntentBox
+	Streams   *StreamBox
+}
+
+// NewRepo creates an empty in-memory repository. Fixture helpers fail t
+// on error.
+func NewRepo(t testing.TB) *Repo {
+	s := NewSafe()
+	tracker := NewTracker(s)
+	intents := NewIntentBox()
+	return &Repo{
+		t:         t,
+		Safe:      s,
+		Tracker:   tracker,
+		Workspace: NewWorkspace(tracker, intents),
+		Intents:   intents,
+		Streams:   NewStreamBox(intents),
+	}
+}
+
+// WriteFile writes a file to the working tree
+func (r *Repo) WriteFile(path, content string) {
+	r.Tracker.WriteFile(path, []byte(content))
+}
+
+// RemoveFile deletes a file from the working tree
+func (r *Repo) RemoveFile(path string) {
+	r.Tracker.RemoveFile(path)
+}
+
+// Commit gates every pending change and creates a feature intent for it
+func (r *Repo) Commit(description string) *intent.Intent {
+	r.t.Helper()
+	return r.CommitPaths(description, ".")
+}
+
+// CommitPaths gates the given paths and creates a feature intent for them
+func (r *Repo) CommitPaths(description string, paths ...string) *intent.Intent {
+	r.t.Helper()
+	if err := r.Workspace.Gate(paths); err != nil {
+		r.t.Fatalf("gating %v: %v", paths, err)
+	}
+	i, err := r.Workspace.CreateIntent(description, "feature")
+	if err != nil {
+		r.t.Fatalf("creating intent: %v", err)
+	}
+	return i
+}
+
+// Stream creates a feature stream holding the given intents
+func (r *Repo) Stream(name string, intents ...*intent.Intent) *stream.Stream {
+	r.t.Helper()
+	st := &stream.Stream{ID: uuid.New().String(), Name: name, Type: "feature"}
+	if err := r.Streams.Create(st); err != nil {
+		r.t.Fatalf("creating stream %s: %v", name, err)
+	}
+	for _, i := range intents {
+		if err := r.Streams.AddIntent(st.ID, i.ID); err != nil {
+			r.t.Fatalf("adding intent to %s: %v", name, err)
+		}
+	}
+	st, err := r.Streams.Get(st.ID)
+	if err != nil {
+		r.t.Fatalf("reading stream %s: %v", name, err)
+	}
+	return st
+}
+
+// Content returns the content of the file at path as of the intent
+func (r *Repo) Content(i *intent.Intent, path string) string {
+	r.t.Helper()
+	cs, err := r.Tracker.ChangeSet(i.ChangeSetID)
+	if err != nil {
+		r.t.Fatalf("reading changeset: %v", err)
+	}
+	for _, c := range cs.Changes {
+		if c.Path != path {
+			continue
+		}
+		content, err := r.Safe.Get(c.NewHash)
+		if err != nil {
+			r.t.Fatalf("reading %s: %v", path, err)
+		}
+		return string(content)
+	}
+	r.t.Fatalf("%s not changed by intent %s", path, i.ID)
+	return ""
+}
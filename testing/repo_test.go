@@ -0,0 +1,101 @@
+// testing/repo_test.go
+package tigtest
+
+import (
+	"testing"
+
+	"tig/internal/safe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoHistory(t *testing.T) {
+	repo := NewRepo(t)
+	repo.WriteFile("main.go", "package main\n")
+	repo.WriteFile("docs/README.md", "# Demo\n")
+
+	changes, err := repo.Workspace.Status()
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "docs/README.md", changes[0].Path)
+	assert.Equal(t, "add", changes[0].Type)
+	assert.False(t, changes[0].Gated)
+
+	first := repo.Commit("Initial import")
+	assert.Equal(t, "package main\n", repo.Content(first, "main.go"))
+	changes, err = repo.Workspace.Status()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	repo.WriteFile("main.go", "package main\n\nfunc main() {}\n")
+	repo.RemoveFile("docs/README.md")
+	require.NoError(t, repo.Workspace.Gate([]string{"main.go"}))
+
+	changes, err = repo.Workspace.Status()
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "delete", changes[0].Type)
+	assert.False(t, changes[0].Gated)
+	assert.Equal(t, "modify", changes[1].Type)
+	assert.True(t, changes[1].Gated)
+
+	result, err := repo.Workspace.ShowFileDiff("main.go")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Stats.Additions)
+
+	second := repo.CommitPaths("Add main", "main.go")
+	cs, err := repo.Tracker.ChangeSet(second.ChangeSetID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ChangeSetID, cs.ParentID)
+	require.Len(t, cs.Changes, 1)
+
+	// The deletion is still pending
+	changes, err = repo.Workspace.Status()
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "docs/README.md", changes[0].Path)
+
+	st := repo.Stream("release", first, second)
+	intents, err := repo.Streams.GetIntents(st.ID)
+	require.NoError(t, err)
+	require.Len(t, intents, 2)
+	assert.Equal(t, "Initial import", intents[0].Description)
+}
+
+func TestBoxesReturnCopies(t *testing.T) {
+	repo := NewRepo(t)
+	repo.WriteFile("a.txt", "a")
+	i := repo.Commit("Add a")
+
+	got, err := repo.Intents.Get(i.ID)
+	require.NoError(t, err)
+	got.Description = "changed"
+	again, err := repo.Intents.Get(i.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Add a", again.Description)
+
+	_, err = repo.Intents.Get("missing")
+	assert.ErrorContains(t, err, "entity not found")
+
+	st := repo.Stream("main")
+	assert.True(t, st.State.Active)
+	assert.Error(t, repo.Streams.AddIntent(st.ID, "missing"))
+	assert.Error(t, repo.Streams.RemoveIntent(st.ID, i.ID))
+}
+
+func TestSafeRefCounts(t *testing.T) {
+	s := NewSafe()
+	hash, err := s.Store([]byte("content"))
+	require.NoError(t, err)
+	_, err = s.Store([]byte("content"))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), s.RefCount(hash))
+
+	require.NoError(t, s.Delete(hash))
+	require.NoError(t, s.Delete(hash))
+	_, err = s.Get(hash)
+	assert.ErrorIs(t, err, safe.ErrContentNotFound)
+	_, err = s.Get("not-a-hash")
+	assert.ErrorIs(t, err, safe.ErrInvalidHash)
+}
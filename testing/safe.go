@@ -0,0 +1,135 @@
+// testing/safe.go
+package tigtest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+
+	"tig/internal/safe"
+	"tig/shared/utils"
+)
+
+// ContentStore is the part of *safe.Safe most code depends on. Both the
+// real safe and Safe implement it.
+type ContentStore interface {
+	Store(content []byte) (string, error)
+	Get(hash string) ([]byte, error)
+	Exists(hash string) (bool, error)
+	Delete(hash string) error
+}
+
+var (
+	_ ContentStore = (*safe.Safe)(nil)
+	_ ContentStore = (*Safe)(nil)
+)
+
+// Safe is an in-memory, reference-counted content store. It returns the
+// same errors as safe.Safe.
+type Safe struct {
+	mu      sync.Mutex
+	content map[string][]byte
+	refs    map[string]uint32
+}
+
+// NewSafe creates an empty Safe
+func NewSafe() *Safe {
+	return &Safe{
+		content: make(map[string][]byte),
+		refs:    make(map[string]uint32),
+	}
+}
+
+// Store saves content and returns its hash
+func (s *Safe) Store(content []byte) (string, error) {
+	hash := utils.HashContent(content)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.content[hash]; !ok {
+		s.content[hash] = bytes.Clone(content)
+	}
+	s.refs[hash]++
+	return hash, nil
+}
+
+// Get retrieves content by hash
+func (s *Safe) Get(hash string) ([]byte, error) {
+	if !validHash(hash) {
+		return nil, safe.ErrInvalidHash
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, ok := s.content[hash]
+	if !ok {
+		return nil, fmt.Errorf("getting metadata: %w", safe.ErrContentNotFound)
+	}
+	return bytes.Clone(content), nil
+}
+
+// Exists reports whether content is stored
+func (s *Safe) Exists(hash string) (bool, error) {
+	if !validHash(hash) {
+		return false, safe.ErrInvalidHash
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.content[hash]
+	return ok, nil
+}
+
+// Delete drops a reference, removing the content with the last one
+func (s *Safe) Delete(hash string) error {
+	if !validHash(hash) {
+		return safe.ErrInvalidHash
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.content[hash]; !ok {
+		return fmt.Errorf("getting metadata: %w", safe.ErrContentNotFound)
+	}
+	s.refs[hash]--
+	if s.refs[hash] == 0 {
+		delete(s.content, hash)
+		delete(s.refs, hash)
+	}
+	return nil
+}
+
+// RefCount returns the number of references to hash
+func (s *Safe) RefCount(hash string) uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refs[hash]
+}
+
+// Hashes returns every stored hash in order
+func (s *Safe) Hashes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hashes := make([]string, 0, len(s.content))
+	for hash := range s.content {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// validHash matches safe's check for a hex SHA-256 digest
+func validHash(hash string) bool {
+	if len(hash) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
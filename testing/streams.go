@@ -0,0 +1,244 @@
+// testing/streams.go
+package tigtest
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"tig/internal/errors"
+	"tig/internal/intent"
+	"tig/internal/storage"
+	"tig/internal/stream"
+)
+
+var _ stream.Box = (*StreamBox)(nil)
+
+// StreamBox is an in-memory stream.Box with the same defaults and errors
+// as the Badger store
+type StreamBox struct {
+	mu      sync.Mutex
+	streams map[string]*stream.Stream
+	intents intent.Box
+}
+
+// NewStreamBox creates an empty StreamBox that resolves intents in intents
+func NewStreamBox(intents intent.Box) *StreamBox {
+	return &StreamBox{
+		streams: make(map[string]*stream.Stream),
+		intents: intents,
+	}
+}
+
+func validateStream(st *stream.Stream) error {
+	if st.ID == "" {
+		return fmt.Errorf("entity ID cannot be empty")
+	}
+	if st.Name == "" {
+		return fmt.Errorf("invalid stream: name is required")
+	}
+	if st.Type == "" {
+		return fmt.Errorf("invalid stream: type is required")
+	}
+	return nil
+}
+
+func (b *StreamBox) Create(st *stream.Stream) error {
+	if err := validateStream(st); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.streams[st.ID]; ok {
+		return fmt.Errorf("entity already exists: %s", st.ID)
+	}
+	if st.CreatedAt.IsZero() {
+		st.CreatedAt = time.Now()
+	}
+	if st.UpdatedAt.IsZero() {
+		st.UpdatedAt = st.CreatedAt
+	}
+	if st.State.LastSync.IsZero() {
+		st.State.LastSync = st.CreatedAt
+	}
+	if st.State.Status == "" {
+		st.State.Status = "stable"
+	}
+	st.State.Active = true
+	b.streams[st.ID] = clone(st)
+	return nil
+}
+
+func (b *StreamBox) Get(id string) (*stream.Stream, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.get(id)
+}
+
+// get returns a copy of a stream; the caller holds the lock
+func (b *StreamBox) get(id string) (*stream.Stream, error) {
+	st, ok := b.streams[id]
+	if !ok {
+		return nil, fmt.Errorf("getting stream: %w: %s", storage.ErrNotFound, id)
+	}
+	return clone(st), nil
+}
+
+func (b *StreamBox) Update(st *stream.Stream) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.update(st)
+}
+
+// update stores a stream; the caller holds the lock
+func (b *StreamBox) update(st *stream.Stream) error {
+	if err := validateStream(st); err != nil {
+		return err
+	}
+	if _, ok := b.streams[st.ID]; !ok {
+		return fmt.Errorf("%w: %s", storage.ErrNotFound, st.ID)
+	}
+	st.UpdatedAt = time.Now()
+	b.streams[st.ID] = clone(st)
+	return nil
+}
+
+func (b *StreamBox) Delete(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.streams[id]; !ok {
+		return fmt.Errorf("%w: %s", storage.ErrNotFound, id)
+	}
+	delete(b.streams, id)
+	return nil
+}
+
+func (b *StreamBox) List() ([]*stream.Stream, error) {
+	return b.filter(func(*stream.Stream) bool { return true }), nil
+}
+
+func (b *StreamBox) AddIntent(streamID string, intentID string) error {
+	if _, err := b.intents.Get(intentID); err != nil {
+		return fmt.Errorf("intent not found: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st, err := b.get(streamID)
+	if err != nil {
+		return err
+	}
+	for _, id := range st.State.Intents {
+		if id == intentID {
+			return nil
+		}
+	}
+	st.State.Intents = append(st.State.Intents, intentID)
+	return b.update(st)
+}
+
+func (b *StreamBox) RemoveIntent(streamID string, intentID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st, err := b.get(streamID)
+	if err != nil {
+		return err
+	}
+
+	kept := make([]string, 0, len(st.State.Intents))
+	for _, id := range st.State.Intents {
+		if id != intentID {
+			kept = append(kept, id)
+		}
+	}
+	if len(kept) == len(st.State.Intents) {
+		return errors.NotFound(fmt.Sprintf("intent not found in stream: %s", intentID))
+	}
+	st.State.Intents = kept
+	return b.update(st)
+}
+
+func (b *StreamBox) GetIntents(streamID string) ([]*intent.Intent, error) {
+	st, err := b.Get(streamID)
+	if err != nil {
+		return nil, err
+	}
+
+	intents := make([]*intent.Intent, 0, len(st.State.Intents))
+	for _, id := range st.State.Intents {
+		i, err := b.intents.Get(id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching intent %s: %w", id, err)
+		}
+		intents = append(intents, i)
+	}
+	return intents, nil
+}
+
+func (b *StreamBox) SetFeatureFlag(streamID string, flag stream.FeatureFlag) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st, err := b.get(streamID)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, f := range st.Config.FeatureFlags {
+		if f.Name == flag.Name {
+			st.Config.FeatureFlags[i] = flag
+			found = true
+			break
+		}
+	}
+	if !found {
+		st.Config.FeatureFlags = append(st.Config.FeatureFlags, flag)
+	}
+	return b.update(st)
+}
+
+func (b *StreamBox) GetFeatureFlag(streamID string, flagName string) (*stream.FeatureFlag, error) {
+	st, err := b.Get(streamID)
+	if err != nil {
+		return nil, err
+	}
+	for _, flag := range st.Config.FeatureFlags {
+		if flag.Name == flagName {
+			return &flag, nil
+		}
+	}
+	return nil, errors.NotFound(fmt.Sprintf("feature flag not found: %s", flagName))
+}
+
+func (b *StreamBox) FindByType(streamType string) ([]*stream.Stream, error) {
+	if streamType == "" {
+		return nil, fmt.Errorf("stream type is required")
+	}
+	return b.filter(func(st *stream.Stream) bool { return st.Type == streamType }), nil
+}
+
+func (b *StreamBox) FindActive() ([]*stream.Stream, error) {
+	return b.filter(func(st *stream.Stream) bool { return st.State.Active }), nil
+}
+
+// filter returns copies of the matching streams in ID order
+func (b *StreamBox) filter(match func(*stream.Stream) bool) []*stream.Stream {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var result []*stream.Stream
+	for _, st := range b.streams {
+		if match(st) {
+			result = append(result, clone(st))
+		}
+	}
+	sort.Slice(result, func(a, c int) bool { return result[a].ID < result[c].ID })
+	return result
+}
@@ -0,0 +1,297 @@
+// testing/tracker.go
+package tigtest
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tig/internal/change"
+	"tig/internal/diff"
+	"tig/internal/errors"
+	"tig/shared/types"
+	"tig/shared/utils"
+
+	"github.com/google/uuid"
+)
+
+var _ change.Tracker = (*Tracker)(nil)
+
+// Tracker is a change.Tracker over an in-memory working tree. Files are
+// written with WriteFile and RemoveFile instead of on disk; gated content
+// goes to the Safe and changesets are kept in memory.
+type Tracker struct {
+	mu         sync.Mutex
+	safe       *Safe
+	engine     *diff.Engine
+	files      map[string][]byte            // working tree
+	tracked    map[string]string            // path to hash of the last changeset; "" until then
+	gated      map[string]shared.Change     // path to gated change
+	changeSets map[string]*change.ChangeSet // by ID
+	head       string                       // latest changeset ID
+}
+
+// NewTracker creates a Tracker with an empty working tree that stores
+// content in s
+func NewTracker(s *Safe) *Tracker {
+	return &Tracker{
+		safe:       s,
+		engine:     diff.NewEngine(3),
+		files:      make(map[string][]byte),
+		tracked:    make(map[string]string),
+		gated:      make(map[string]shared.Change),
+		changeSets: make(map[string]*change.ChangeSet),
+	}
+}
+
+// WriteFile creates or replaces a file in the working tree and tracks it
+func (t *Tracker) WriteFile(path string, content []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.files[path] = bytes.Clone(content)
+	if _, ok := t.tracked[path]; !ok {
+		t.tracked[path] = ""
+	}
+}
+
+// RemoveFile deletes a file from the working tree
+func (t *Tracker) RemoveFile(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.files, path)
+}
+
+// ReadFile returns a file from the working tree
+func (t *Tracker) ReadFile(path string) ([]byte, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	content, ok := t.files[path]
+	return bytes.Clone(content), ok
+}
+
+// Track starts tracking files. A path naming a directory tracks every
+// file below it.
+func (t *Tracker) Track(paths []string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, path := range paths {
+		matched := t.match(path)
+		if len(matched) == 0 {
+			return fmt.Errorf("accessing path %s: file does not exist", path)
+		}
+		for _, p := range matched {
+			if _, ok := t.tracked[p]; !ok {
+				t.tracked[p] = ""
+			}
+		}
+	}
+	return nil
+}
+
+// Untrack stops tracking files
+func (t *Tracker) Untrack(paths []string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, path := range paths {
+		delete(t.tracked, path)
+		delete(t.gated, path)
+	}
+	return nil
+}
+
+// Status lists tracked files that differ from the latest changeset, in
+// path order. Changes whose current content is gated are marked Gated.
+func (t *Tracker) Status() ([]shared.Change, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var changes []shared.Change
+	for path := range t.tracked {
+		c, ok := t.pending(path)
+		if !ok {
+			continue
+		}
+		if g, ok := t.gated[path]; ok && g.NewHash == c.NewHash && g.Type == c.Type {
+			c.Gated = true
+		}
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// Gate stores the current content of a file and stages it for the next
+// changeset. Gating a deleted tracked file stages the deletion.
+func (t *Tracker) Gate(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.tracked[path]; !ok {
+		if _, exists := t.files[path]; !exists {
+			return fmt.Errorf("accessing file %s: file does not exist", path)
+		}
+		t.tracked[path] = ""
+	}
+
+	c, ok := t.pending(path)
+	if !ok {
+		return nil
+	}
+	if c.Type != "delete" {
+		if _, err := t.safe.Store(t.files[path]); err != nil {
+			return fmt.Errorf("storing content: %w", err)
+		}
+	}
+	c.Gated = true
+	t.gated[path] = c
+	return nil
+}
+
+// Ungate removes files from the next changeset
+func (t *Tracker) Ungate(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.gated, path)
+}
+
+// Gated returns the gated changes in path order
+func (t *Tracker) Gated() []shared.Change {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	changes := make([]shared.Change, 0, len(t.gated))
+	for _, c := range t.gated {
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// CreateChangeSet records the gated changes as a changeset following the
+// previous one and clears the gate
+func (t *Tracker) CreateChangeSet(description string) (*change.ChangeSet, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.gated) == 0 {
+		return nil, fmt.Errorf("no changes to commit")
+	}
+
+	changes := make([]shared.Change, 0, len(t.gated))
+	for _, c := range t.gated {
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	cs := &change.ChangeSet{
+		ID:          uuid.New().String(),
+		ParentID:    t.head,
+		Changes:     changes,
+		CreatedAt:   time.Now(),
+		Description: description,
+		Author:      "tigtest",
+	}
+	for _, c := range changes {
+		if c.Type == "delete" {
+			delete(t.tracked, c.Path)
+		} else {
+			t.tracked[c.Path] = c.NewHash
+		}
+	}
+
+	t.changeSets[cs.ID] = cs
+	t.head = cs.ID
+	t.gated = make(map[string]shared.Change)
+	return clone(cs), nil
+}
+
+// ChangeSet returns a recorded changeset
+func (t *Tracker) ChangeSet(id string) (*change.ChangeSet, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cs, ok := t.changeSets[id]
+	if !ok {
+		return nil, errors.NotFound(fmt.Sprintf("changeset not found: %s", id))
+	}
+	return clone(cs), nil
+}
+
+// Head returns the ID of the latest changeset
+func (t *Tracker) Head() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.head
+}
+
+// ShowFileDiff diffs a file against its content in the latest changeset
+func (t *Tracker) ShowFileDiff(path string) (*diff.DiffResult, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hash, ok := t.tracked[path]
+	if !ok {
+		return nil, fmt.Errorf("file not previously tracked")
+	}
+
+	var old []byte
+	if hash != "" {
+		content, err := t.safe.Get(hash)
+		if err != nil {
+			return nil, fmt.Errorf("getting previous content: %w", err)
+		}
+		old = content
+	}
+	return t.engine.DiffFile(path, old, t.files[path])
+}
+
+// pending compares a tracked path with the latest changeset; the caller
+// holds the lock
+func (t *Tracker) pending(path string) (shared.Change, bool) {
+	oldHash := t.tracked[path]
+	content, exists := t.files[path]
+
+	switch {
+	case !exists && oldHash == "":
+		return shared.Change{}, false
+	case !exists:
+		return shared.Change{Path: path, Type: "delete", OldHash: oldHash}, true
+	}
+
+	c := shared.Change{
+		Path:    path,
+		Type:    "modify",
+		OldHash: oldHash,
+		NewHash: utils.HashContent(content),
+		Size:    int64(len(content)),
+		Mode:    0644,
+	}
+	if oldHash == "" {
+		c.Type = "add"
+	} else if oldHash == c.NewHash {
+		return shared.Change{}, false
+	}
+	return c, true
+}
+
+// match returns the files at or below path; the caller holds the lock
+func (t *Tracker) match(path string) []string {
+	if _, ok := t.files[path]; ok {
+		return []string{path}
+	}
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	var matched []string
+	for p := range t.files {
+		if path == "." || path == "" || strings.HasPrefix(p, prefix) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
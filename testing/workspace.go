@@ -0,0 +1,101 @@
+// testing/workspace.go
+package tigtest
+
+import (
+	"fmt"
+
+	"tig/internal/diff"
+	"tig/internal/intent"
+	"tig/shared/types"
+
+	"github.com/google/uuid"
+)
+
+var _ shared.Workspace = (*Workspace)(nil)
+
+// Workspace is a shared.Workspace over a Tracker. Creating an intent
+// records the gated changes as its changeset.
+type Workspace struct {
+	Tracker *Tracker
+	Intents intent.Box
+}
+
+// NewWorkspace creates a Workspace gating through tracker and storing
+// intents in intents
+func NewWorkspace(tracker *Tracker, intents intent.Box) *Workspace {
+	return &Workspace{Tracker: tracker, Intents: intents}
+}
+
+// Gate stages files; "." gates every pending change
+func (w *Workspace) Gate(paths []string) error {
+	for _, path := range paths {
+		targets := []string{path}
+		if path == "." {
+			changes, err := w.Tracker.Status()
+			if err != nil {
+				return err
+			}
+			targets = targets[:0]
+			for _, c := range changes {
+				targets = append(targets, c.Path)
+			}
+		}
+		for _, p := range targets {
+			if err := w.Tracker.Gate(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *Workspace) Close() error { return nil }
+
+// CreateIntent records the gated changes and an intent describing them
+func (w *Workspace) CreateIntent(description string, intentType string) (*intent.Intent, error) {
+	cs, err := w.Tracker.CreateChangeSet(description)
+	if err != nil {
+		return nil, fmt.Errorf("creating changeset: %w", err)
+	}
+
+	i := &intent.Intent{
+		ID:          uuid.New().String(),
+		Description: description,
+		Type:        intentType,
+		ChangeSetID: cs.ID,
+	}
+	if err := w.Intents.Create(i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func (w *Workspace) UpdateIntent(i *intent.Intent) error {
+	return w.Intents.Update(i)
+}
+
+func (w *Workspace) Ungate(paths []string) error {
+	for _, path := range paths {
+		w.Tracker.Ungate(path)
+	}
+	return nil
+}
+
+func (w *Workspace) Status() ([]shared.Change, error) {
+	return w.Tracker.Status()
+}
+
+func (w *Workspace) ShowFileDiff(path string) (*diff.DiffResult, error) {
+	return w.Tracker.ShowFileDiff(path)
+}
+
+// CleanupGatedChanges clears the gate
+func (w *Workspace) CleanupGatedChanges() error {
+	for _, c := range w.Tracker.Gated() {
+		w.Tracker.Ungate(c.Path)
+	}
+	return nil
+}
+
+// LoadGatedChanges is a no-op; gated changes live in memory
+func (w *Workspace) LoadGatedChanges() error { return nil }